@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+)
+
+// accessLogEntry is one line of the access log. It deliberately leaves out
+// the query string, client address and headers, which may carry secrets or
+// personal data; callers are identified by key ID only.
+type accessLogEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"request_id"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Route     string    `json:"route,omitempty"`
+	Status    int       `json:"status"`
+	LatencyMs float64   `json:"latency_ms"`
+	Bytes     int       `json:"bytes"`
+	KeyID     string    `json:"key_id,omitempty"`
+}
+
+// redactedRoutes are routes whose path carries a credential; their path is
+// logged as the route with the parameter masked
+var redactedRoutes = map[string]string{
+	"/share/:token": "/share/REDACTED",
+}
+
+// accessLogMiddleware writes a JSON line per request to w
+func accessLogMiddleware(w io.Writer) gin.HandlerFunc {
+	var mu sync.Mutex
+	encoder := json.NewEncoder(w)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		entry := accessLogEntry{
+			Time:      start.UTC(),
+			RequestID: c.GetString("request_id"),
+			Method:    c.Request.Method,
+			Path:      c.Request.URL.Path,
+			Route:     c.FullPath(),
+			Status:    c.Writer.Status(),
+			LatencyMs: float64(time.Since(start).Microseconds()) / 1000,
+			Bytes:     c.Writer.Size(),
+		}
+		if redacted, ok := redactedRoutes[entry.Route]; ok {
+			entry.Path = redacted
+		}
+		if entry.Bytes < 0 {
+			entry.Bytes = 0
+		}
+		if key := auth.FromContext(c); key != nil {
+			entry.KeyID = key.ID
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+		if err := encoder.Encode(entry); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: Failed to write access log: %v\n", err)
+		}
+	}
+}
+
+// rotatingFile is an append-only file that is renamed to path.1, path.2 and
+// so on once it grows past maxSize, keeping at most maxBackups old files
+type rotatingFile struct {
+	mu         sync.Mutex
+	path       string
+	maxSize    int64
+	maxBackups int
+	file       *os.File
+	size       int64
+}
+
+// openRotatingFile opens or creates the file at path for appending
+func openRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+func (r *rotatingFile) open() error {
+	file, err := os.OpenFile(r.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0640)
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", r.path, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return fmt.Errorf("failed to stat %s: %w", r.path, err)
+	}
+	r.file = file
+	r.size = info.Size()
+	return nil
+}
+
+// Write appends p, rotating first if it would take the file past maxSize
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// rotate shifts the backups along, dropping the oldest, and starts a new file
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", r.path, err)
+	}
+
+	if r.maxBackups > 0 {
+		os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxBackups))
+		for i := r.maxBackups - 1; i >= 1; i-- {
+			os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+		}
+		if err := os.Rename(r.path, r.path+".1"); err != nil {
+			return fmt.Errorf("failed to rotate %s: %w", r.path, err)
+		}
+	} else if err := os.Remove(r.path); err != nil {
+		return fmt.Errorf("failed to rotate %s: %w", r.path, err)
+	}
+
+	return r.open()
+}
+
+// Close closes the current file
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
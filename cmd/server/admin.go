@@ -0,0 +1,178 @@
+package main
+
+import (
+	"net/http/pprof"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/api"
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/metrics"
+	"supabase-manager/internal/supabase"
+)
+
+// setupAdminRouter configures the router for the admin listener, serving
+// metrics, profiling and admin endpoints away from the public API
+func setupAdminRouter(handler *api.Handler, keyring *auth.Keyring, lockout *auth.Lockout, registry *metrics.Registry, publicRouter *gin.Engine, config *Config) *gin.Engine {
+	router := gin.New()
+	trustProxies(router, config)
+	router.Use(gin.Recovery())
+	router.Use(securityHeadersMiddleware(config))
+	router.Use(requestMetadataMiddleware())
+
+	// Prometheus metrics
+	router.GET("/metrics", gin.WrapH(registry.Handler()))
+
+	// Profiling
+	debug := router.Group("/debug/pprof")
+	{
+		debug.GET("/", gin.WrapF(pprof.Index))
+		debug.GET("/cmdline", gin.WrapF(pprof.Cmdline))
+		debug.GET("/profile", gin.WrapF(pprof.Profile))
+		debug.GET("/symbol", gin.WrapF(pprof.Symbol))
+		debug.POST("/symbol", gin.WrapF(pprof.Symbol))
+		debug.GET("/trace", gin.WrapF(pprof.Trace))
+		debug.GET("/:profile", gin.WrapF(pprof.Index))
+	}
+
+	// Admin API (requires a key with the admin scope)
+	adminRoutes := router.Group("/api/admin")
+	adminRoutes.Use(requestLimitsMiddleware(config.MaxRequestBodyBytes), authMiddleware(keyring, lockout, handler.ReportAuthBan), requireScope("admin"))
+	{
+		adminRoutes.GET("/health-rules", handler.HealthRules)
+		adminRoutes.GET("/runtime", handler.GetRuntimeTuning)
+		adminRoutes.PATCH("/runtime", handler.UpdateRuntimeTuning)
+		adminRoutes.GET("/dr-export", handler.ExportDRCredentials)
+		adminRoutes.GET("/routes", func(c *gin.Context) {
+			c.JSON(200, gin.H{
+				"routes": publicRouter.Routes(),
+			})
+		})
+	}
+
+	return router
+}
+
+// registerMetrics registers the metrics computed from handler and client state
+func registerMetrics(registry *metrics.Registry, handler *api.Handler, client *supabase.Client) {
+	registry.GaugeFunc("supabase_manager_background_tasks",
+		"Background tasks currently running",
+		func() float64 { return float64(handler.PendingTasks()) })
+
+	registry.CounterFunc("supabase_manager_management_api_requests_total",
+		"Requests sent to the Supabase Management API",
+		func() float64 { return float64(client.TransportStats().Requests) })
+	registry.CounterFunc("supabase_manager_management_api_errors_total",
+		"Management API requests that failed at the transport level",
+		func() float64 { return float64(client.TransportStats().Errors) })
+
+	registry.GaugeFunc("supabase_manager_db_operations_active",
+		"Database operations currently holding a pool slot",
+		func() float64 { return float64(handler.DBPoolStats().Active) })
+	registry.GaugeFunc("supabase_manager_db_operations_queued",
+		"Database operations waiting for a pool slot",
+		func() float64 { return float64(handler.DBPoolStats().Queued) })
+	registry.CounterFunc("supabase_manager_db_operations_total",
+		"Database operations that got a pool slot",
+		func() float64 { return float64(handler.DBPoolStats().Acquired) })
+	registry.CounterFunc("supabase_manager_db_queue_timeouts_total",
+		"Database operations that gave up waiting for a pool slot",
+		func() float64 { return float64(handler.DBPoolStats().Timeouts) })
+	registry.CounterFunc("supabase_manager_db_queue_wait_seconds_total",
+		"Time database operations spent waiting for a pool slot",
+		func() float64 { return handler.DBPoolStats().WaitSeconds })
+
+	registry.GaugeFunc("supabase_manager_provisioning_queue_depth",
+		"Projects being provisioned",
+		func() float64 { return float64(handler.QueueStats().Provisioning) })
+	registry.CounterFunc("supabase_manager_provisioning_requests_shed_total",
+		"Create requests refused with 503 because too many projects were being provisioned",
+		func() float64 { return float64(handler.QueueStats().ShedProvisioning) })
+	registry.CounterFunc("supabase_manager_migration_requests_shed_total",
+		"Schema requests refused with 503 because too many database operations were queued",
+		func() float64 { return float64(handler.QueueStats().ShedMigrations) })
+
+	registry.GaugeVecFunc("supabase_manager_health_rule_firing",
+		"Whether a self-monitoring health rule is firing",
+		[]string{"rule"},
+		func() map[string]float64 {
+			values := make(map[string]float64)
+			for _, status := range handler.HealthRuleStatus() {
+				values[metrics.LabelKey(status.Name)] = 0
+				if status.Firing {
+					values[metrics.LabelKey(status.Name)] = 1
+				}
+			}
+			return values
+		})
+}
+
+// storageMetrics records SQLite query metrics per storage operation
+type storageMetrics struct {
+	duration *metrics.HistogramVec
+	errors   *metrics.CounterVec
+	rows     *metrics.CounterVec
+}
+
+// newStorageMetrics registers the storage query metrics
+func newStorageMetrics(registry *metrics.Registry) *storageMetrics {
+	return &storageMetrics{
+		duration: registry.Histogram("supabase_manager_storage_query_duration_seconds",
+			"Time spent running SQLite queries", metrics.DefaultBuckets, "operation"),
+		errors: registry.Counter("supabase_manager_storage_errors_total",
+			"SQLite queries that failed", "operation"),
+		rows: registry.Counter("supabase_manager_storage_rows_total",
+			"Rows returned or affected by SQLite queries", "operation"),
+	}
+}
+
+// ObserveQuery implements storage.QueryObserver
+func (m *storageMetrics) ObserveQuery(operation string, duration time.Duration, rows int64, err error) {
+	m.duration.Observe(duration.Seconds(), operation)
+	m.rows.Add(float64(rows), operation)
+	if err != nil {
+		m.errors.Inc(operation)
+	}
+}
+
+// metricsMiddleware counts requests and their duration by route and status
+func metricsMiddleware(registry *metrics.Registry) gin.HandlerFunc {
+	requests := registry.Counter("supabase_manager_http_requests_total",
+		"HTTP requests served by the public API", "method", "route", "status")
+	duration := registry.Counter("supabase_manager_http_request_duration_seconds_total",
+		"Total time spent serving HTTP requests", "method", "route")
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		requests.Inc(c.Request.Method, route, strconv.Itoa(c.Writer.Status()))
+		duration.Add(time.Since(start).Seconds(), c.Request.Method, route)
+	}
+}
+
+// requireScope rejects keys without the given scope. It must run after
+// authMiddleware.
+func requireScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := auth.FromContext(c)
+		if key == nil || !key.HasScope(scope) {
+			c.JSON(403, gin.H{
+				"error": gin.H{
+					"code":    "FORBIDDEN",
+					"message": "API key lacks the " + scope + " scope",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"supabase-manager/internal/supabase"
+)
+
+// runDRDecrypt implements the dr-decrypt subcommand, opening a disaster
+// recovery export with its key. It needs neither the manager's state nor its
+// configuration, so it can run on a break-glass machine.
+func runDRDecrypt(args []string) {
+	flags := flag.NewFlagSet("dr-decrypt", flag.ExitOnError)
+	input := flags.String("in", "", "export file to decrypt; standard input when empty")
+	flags.Parse(args)
+
+	encodedKey := os.Getenv("DR_EXPORT_KEY")
+	if encodedKey == "" {
+		log.Fatal("dr-decrypt: DR_EXPORT_KEY is required")
+	}
+	key, err := supabase.ParseDRKey(encodedKey)
+	if err != nil {
+		log.Fatalf("dr-decrypt: invalid DR_EXPORT_KEY: %v", err)
+	}
+
+	var data []byte
+	if *input == "" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(*input)
+	}
+	if err != nil {
+		log.Fatalf("dr-decrypt: failed to read export: %v", err)
+	}
+
+	var export supabase.DRExport
+	if err := json.Unmarshal(data, &export); err != nil {
+		log.Fatalf("dr-decrypt: invalid export: %v", err)
+	}
+	if err := export.Open(key); err != nil {
+		log.Fatalf("dr-decrypt: %v", err)
+	}
+
+	output, _ := json.MarshalIndent(export, "", "  ")
+	fmt.Println(string(output))
+}
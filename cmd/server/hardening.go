@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/api"
+	"supabase-manager/internal/supabase"
+)
+
+// newHTTPServer returns a server for handler with the configured timeouts,
+// so slow clients can't hold connections open by trickling headers or
+// bodies
+func newHTTPServer(addr string, handler http.Handler, config *Config) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           handler,
+		ReadHeaderTimeout: config.HTTPReadHeaderTimeout,
+		ReadTimeout:       config.HTTPReadTimeout,
+		WriteTimeout:      config.HTTPWriteTimeout,
+		IdleTimeout:       config.HTTPIdleTimeout,
+		MaxHeaderBytes:    config.MaxHeaderBytes,
+	}
+}
+
+// securityHeadersMiddleware adds the standard security headers. Responses
+// are JSON and often carry keys, so nothing may frame, sniff or cache them.
+// Strict-Transport-Security is only sent on requests that arrived over
+// https, directly or through a proxy.
+func securityHeadersMiddleware(config *Config) gin.HandlerFunc {
+	hsts := ""
+	if seconds := int64(config.HSTSMaxAge.Seconds()); seconds > 0 {
+		hsts = "max-age=" + strconv.FormatInt(seconds, 10) + "; includeSubDomains"
+	}
+
+	return func(c *gin.Context) {
+		header := c.Writer.Header()
+		header.Set("X-Content-Type-Options", "nosniff")
+		header.Set("X-Frame-Options", "DENY")
+		header.Set("Content-Security-Policy", "default-src 'none'; frame-ancestors 'none'")
+		header.Set("Referrer-Policy", "no-referrer")
+		header.Set("Cache-Control", "no-store")
+		if hsts != "" && (c.Request.TLS != nil || c.GetHeader("X-Forwarded-Proto") == "https") {
+			header.Set("Strict-Transport-Security", hsts)
+		}
+		c.Next()
+	}
+}
+
+// uploadRoutes also take SQL uploads instead of JSON, as multipart/form-data
+// or a text/plain body
+var uploadRoutes = map[string]bool{
+	"POST /api/projects/:id/schema":  true,
+	"POST /api/databases/:id/schema": true,
+}
+
+// requestLimitsMiddleware rejects request bodies over maxBytes with 413,
+// and bodies of mutating requests that are not JSON, or an upload where one
+// is taken, with 415. Bodies sent without a length are cut off at maxBytes
+// while they are read.
+func requestLimitsMiddleware(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.Request.ContentLength > maxBytes {
+			c.AbortWithStatusJSON(http.StatusRequestEntityTooLarge, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "REQUEST_TOO_LARGE",
+					Message: "Request body exceeds the size limit",
+					Details: fmt.Sprintf("%d bytes, the limit is %d", c.Request.ContentLength, maxBytes),
+				},
+			})
+			return
+		}
+		c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes)
+
+		switch c.Request.Method {
+		case http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete:
+			contentType := c.GetHeader("Content-Type")
+			hasBody := c.Request.ContentLength != 0
+			upload := uploadRoutes[c.Request.Method+" "+c.FullPath()] && api.IsSQLUploadContentType(contentType)
+			if (contentType != "" || hasBody) && !isJSONContentType(contentType) && !upload {
+				c.AbortWithStatusJSON(http.StatusUnsupportedMediaType, supabase.ErrorResponse{
+					Error: supabase.ErrorDetail{
+						Code:    "UNSUPPORTED_MEDIA_TYPE",
+						Message: "Request bodies must be JSON",
+						Details: fmt.Sprintf("Content-Type %q; use application/json", contentType),
+					},
+				})
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// isJSONContentType reports whether a Content-Type is application/json or
+// a +json type such as application/merge-patch+json
+func isJSONContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	return mediaType == "application/json" || (strings.HasPrefix(mediaType, "application/") && strings.HasSuffix(mediaType, "+json"))
+}
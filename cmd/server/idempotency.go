@@ -0,0 +1,187 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/api"
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/storage"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	// idempotencyKeyTTL is how long a response is kept for retries with
+	// the same Idempotency-Key
+	idempotencyKeyTTL = 24 * time.Hour
+	// idempotencyKeyLease is how long a key stays claimed by a request that
+	// has not finished. A claim left behind by a crash expires after it,
+	// so the key can be used again.
+	idempotencyKeyLease = 5 * time.Minute
+	// maxIdempotencyKeyLength bounds the Idempotency-Key header
+	maxIdempotencyKeyLength = 255
+	// maxIdempotentResponseBytes bounds the responses kept for replay;
+	// larger ones release the key instead
+	maxIdempotentResponseBytes = 1 << 20
+)
+
+// capturingWriter keeps a copy of the response body for replay, up to
+// maxIdempotentResponseBytes
+type capturingWriter struct {
+	gin.ResponseWriter
+	body     bytes.Buffer
+	overflow bool
+}
+
+func (w *capturingWriter) capture(data []byte) {
+	if w.overflow {
+		return
+	}
+	if w.body.Len()+len(data) > maxIdempotentResponseBytes {
+		w.overflow = true
+		w.body.Reset()
+		return
+	}
+	w.body.Write(data)
+}
+
+func (w *capturingWriter) Write(data []byte) (int, error) {
+	w.capture(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *capturingWriter) WriteString(s string) (int, error) {
+	w.capture([]byte(s))
+	return w.ResponseWriter.WriteString(s)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer
+func (w *capturingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// idempotencyMiddleware makes POST requests sent with an Idempotency-Key
+// header safe to retry. The first response for a key is kept for
+// idempotencyKeyTTL and replayed, with Idempotent-Replayed: true, to later
+// requests by the same API key with the same key. Reusing a key for a
+// different request is refused with 422, and a key whose first request is
+// still running gets 409. Server errors, 423 and 429 responses are not kept,
+// nor are requests whose handler panicked, so the retry runs again.
+func idempotencyMiddleware(store *storage.SQLiteStorage) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		// SQL uploads are streamed into the migration; reading them here
+		// to fingerprint the request would buffer the whole script
+		if key == "" || c.Request.Method != http.MethodPost || api.IsSQLUploadContentType(c.GetHeader("Content-Type")) {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			c.AbortWithStatusJSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_IDEMPOTENCY_KEY",
+					Message: fmt.Sprintf("Idempotency-Key must be at most %d characters", maxIdempotencyKeyLength),
+				},
+			})
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Failed to read request body",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		scope := ""
+		if apiKey := auth.FromContext(c); apiKey != nil {
+			scope = apiKey.ID
+		}
+		fingerprint := requestFingerprint(c.Request, body)
+
+		now := time.Now()
+		record, err := store.ClaimIdempotencyKey(scope, key, fingerprint, now.Add(idempotencyKeyLease), now)
+		if err != nil {
+			c.AbortWithStatusJSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to check the idempotency key",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+
+		switch {
+		case record == nil:
+		case record.Fingerprint != fingerprint:
+			c.AbortWithStatusJSON(http.StatusUnprocessableEntity, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "IDEMPOTENCY_KEY_REUSED",
+					Message: "The Idempotency-Key was already used for a different request",
+				},
+			})
+			return
+		case record.StatusCode == 0:
+			c.Header("Retry-After", "1")
+			c.AbortWithStatusJSON(http.StatusConflict, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "IDEMPOTENCY_KEY_IN_USE",
+					Message: "A request with this Idempotency-Key is still running",
+				},
+			})
+			return
+		default:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.StatusCode, record.ContentType, record.Body)
+			c.Abort()
+			return
+		}
+
+		writer := &capturingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+
+		// Settle the key even if the handler panics, so it isn't left
+		// claimed until the lease runs out
+		finished := false
+		defer func() {
+			status := writer.Status()
+			var err error
+			if !finished || status >= http.StatusInternalServerError || status == http.StatusTooManyRequests ||
+				status == http.StatusLocked || writer.overflow {
+				err = store.ReleaseIdempotencyKey(scope, key)
+			} else {
+				err = store.CompleteIdempotencyKey(scope, key, status, writer.Header().Get("Content-Type"), writer.body.Bytes(), time.Now().Add(idempotencyKeyTTL))
+			}
+			if err != nil {
+				log.Printf("Warning: %v", err)
+			}
+		}()
+
+		c.Next()
+		finished = true
+	}
+}
+
+// requestFingerprint identifies a request by its path, query, dry-run
+// header and body, so a key reused for another request is noticed
+func requestFingerprint(r *http.Request, body []byte) string {
+	hash := sha256.New()
+	fmt.Fprintf(hash, "%s\n%s\n%s\n", r.URL.Path, r.URL.RawQuery, r.Header.Get("X-Dry-Run"))
+	hash.Write(body)
+	return hex.EncodeToString(hash.Sum(nil))
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/storage"
+)
+
+// idempotencyRouter serves POST /run through idempotencyMiddleware, calling
+// handle with the calling API key set to the X-Test-Key header
+func idempotencyRouter(t *testing.T, handle gin.HandlerFunc) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	router := gin.New()
+	router.Use(gin.Recovery())
+	router.Use(func(c *gin.Context) {
+		c.Set(auth.ContextKey, &auth.Key{ID: c.GetHeader("X-Test-Key")})
+	})
+	router.Use(idempotencyMiddleware(store))
+	router.POST("/run", handle)
+	return router
+}
+
+func postIdempotent(router *gin.Engine, apiKey, idempotencyKey, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(http.MethodPost, "/run", strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Test-Key", apiKey)
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+	rec := httptest.NewRecorder()
+	router.ServeHTTP(rec, req)
+	return rec
+}
+
+func TestIdempotencyReplaysFirstResponse(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotencyRouter(t, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{"call": calls.Add(1)})
+	})
+
+	first := postIdempotent(router, "key-a", "create-1", `{"name":"a"}`)
+	retry := postIdempotent(router, "key-a", "create-1", `{"name":"a"}`)
+	if first.Code != http.StatusCreated || retry.Code != http.StatusCreated {
+		t.Fatalf("got %d then %d", first.Code, retry.Code)
+	}
+	if retry.Body.String() != first.Body.String() || retry.Header().Get("Idempotent-Replayed") != "true" {
+		t.Errorf("retry was not replayed: %s %v", retry.Body.String(), retry.Header())
+	}
+	if calls.Load() != 1 {
+		t.Errorf("handler ran %d times, want 1", calls.Load())
+	}
+
+	// Keys are scoped to the API key, and requests without one always run
+	postIdempotent(router, "key-b", "create-1", `{"name":"a"}`)
+	postIdempotent(router, "key-a", "", `{"name":"a"}`)
+	if calls.Load() != 3 {
+		t.Errorf("handler ran %d times, want 3", calls.Load())
+	}
+}
+
+func TestIdempotencyRefusesReuseForAnotherRequest(t *testing.T) {
+	router := idempotencyRouter(t, func(c *gin.Context) {
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	postIdempotent(router, "key-a", "create-1", `{"name":"a"}`)
+	rec := postIdempotent(router, "key-a", "create-1", `{"name":"b"}`)
+	if rec.Code != http.StatusUnprocessableEntity || !strings.Contains(rec.Body.String(), "IDEMPOTENCY_KEY_REUSED") {
+		t.Errorf("got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestIdempotencyReleasesFailedRequests(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotencyRouter(t, func(c *gin.Context) {
+		switch calls.Add(1) {
+		case 1:
+			c.JSON(http.StatusBadGateway, gin.H{})
+		case 2:
+			panic("handler failed")
+		default:
+			c.JSON(http.StatusCreated, gin.H{})
+		}
+	})
+
+	for _, want := range []int{http.StatusBadGateway, http.StatusInternalServerError, http.StatusCreated} {
+		if rec := postIdempotent(router, "key-a", "create-1", `{}`); rec.Code != want {
+			t.Errorf("got %d, want %d", rec.Code, want)
+		}
+	}
+	if calls.Load() != 3 {
+		t.Errorf("handler ran %d times, want every failed attempt retried", calls.Load())
+	}
+}
+
+func TestIdempotencyRefusesConcurrentRetry(t *testing.T) {
+	entered, release := make(chan struct{}), make(chan struct{})
+	router := idempotencyRouter(t, func(c *gin.Context) {
+		close(entered)
+		<-release
+		c.JSON(http.StatusCreated, gin.H{})
+	})
+
+	done := make(chan *httptest.ResponseRecorder)
+	go func() { done <- postIdempotent(router, "key-a", "create-1", `{}`) }()
+	<-entered
+
+	rec := postIdempotent(router, "key-a", "create-1", `{}`)
+	if rec.Code != http.StatusConflict || !strings.Contains(rec.Body.String(), "IDEMPOTENCY_KEY_IN_USE") {
+		t.Errorf("retry while running: got %d %s", rec.Code, rec.Body.String())
+	}
+	close(release)
+	if first := <-done; first.Code != http.StatusCreated {
+		t.Errorf("first request: got %d", first.Code)
+	}
+}
+
+func TestIdempotencySkipsSQLUploads(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotencyRouter(t, func(c *gin.Context) {
+		calls.Add(1)
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	for i := 0; i < 2; i++ {
+		req := httptest.NewRequest(http.MethodPost, "/run", strings.NewReader("CREATE TABLE todos (id int);"))
+		req.Header.Set("Content-Type", "text/plain")
+		req.Header.Set("Idempotency-Key", "upload-1")
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		if rec.Header().Get("Idempotent-Replayed") != "" {
+			t.Errorf("upload was replayed")
+		}
+	}
+	if calls.Load() != 2 {
+		t.Errorf("handler ran %d times, want uploads to skip the idempotency key", calls.Load())
+	}
+}
+
+func TestIdempotencyReleasesLockedResponses(t *testing.T) {
+	var calls atomic.Int32
+	router := idempotencyRouter(t, func(c *gin.Context) {
+		if calls.Add(1) == 1 {
+			c.JSON(http.StatusLocked, gin.H{})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{})
+	})
+
+	first := postIdempotent(router, "key-a", "migrate-1", `{}`)
+	retry := postIdempotent(router, "key-a", "migrate-1", `{}`)
+	if first.Code != http.StatusLocked || retry.Code != http.StatusOK {
+		t.Errorf("got %d then %d, want the retry to run after the freeze", first.Code, retry.Code)
+	}
+}
@@ -1,26 +1,57 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"log"
+	"net"
+	"net/http"
 	"os"
 	"os/signal"
+	"runtime"
+	"strconv"
+	"strings"
 	"syscall"
+	"time"
 
 	"github.com/gin-gonic/gin"
 	"github.com/joho/godotenv"
 	
 	"supabase-manager/internal/api"
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/metrics"
+	"supabase-manager/internal/monitor"
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/policy"
+	"supabase-manager/internal/operator"
 	"supabase-manager/internal/storage"
 	"supabase-manager/internal/supabase"
+	"supabase-manager/pkg/supabasetest"
 )
 
+// version is set at build time with -ldflags "-X main.version=v1.2.3"
+var version = "dev"
+
+// minHealthProbeInterval keeps background health probes on a slow cadence
+const minHealthProbeInterval = time.Minute
+
 func main() {
 	// Load environment variables
 	if err := godotenv.Load(); err != nil {
 		log.Println("No .env file found, using environment variables")
 	}
 
+	// Subcommands
+	if len(os.Args) > 1 && os.Args[1] == "migrate-storage" {
+		runMigrateStorage(os.Args[2:])
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "dr-decrypt" {
+		runDRDecrypt(os.Args[2:])
+		return
+	}
+
 	// Get configuration from environment
 	config := loadConfig()
 
@@ -37,55 +68,222 @@ func main() {
 	}
 	defer store.Close()
 
+	// Projects stored before organizations were recorded belong to the
+	// manager's organization
+	if err := store.BackfillOrganization(config.SupabaseOrgID); err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+
 	// Initialize Supabase client
 	log.Println("Initializing Supabase client...")
 	supabaseClient := supabase.NewClient(
 		config.SupabaseAccessToken,
 		config.SupabaseOrgID,
 	)
+	supabaseClient.SetAPIVersions(config.apiVersions)
 
-	// Test Supabase connection
-	if err := supabaseClient.TestConnection(); err != nil {
-		log.Printf("Warning: Cannot connect to Supabase API: %v", err)
-		log.Println("Server will start but project creation will fail")
-	} else {
-		log.Println("✓ Successfully connected to Supabase API")
+	// In sandbox mode, talk to an in-process fake Management API
+	if config.SandboxMode {
+		sandbox := supabasetest.NewServer()
+		defer sandbox.Close()
+		supabaseClient.SetBaseURL(sandbox.ManagementURL())
+		supabaseClient.SetStatusPageURL(sandbox.StatusPageURL())
+		log.Printf("Sandbox mode: using fake Management API at %s", sandbox.ManagementURL())
+	}
+
+	// Record Management API traffic, or replay a recording offline
+	switch {
+	case config.OfflineMode:
+		replayer, err := supabasetest.LoadReplayer(config.CassettePath)
+		if err != nil {
+			log.Fatalf("Offline mode: %v", err)
+		}
+		supabaseClient.WrapTransport(func(http.RoundTripper) http.RoundTripper { return replayer })
+		log.Printf("Offline mode: replaying Management API responses from %s", config.CassettePath)
+	case config.RecordMode:
+		recorder, err := supabasetest.NewRecorder(config.CassettePath)
+		if err != nil {
+			log.Fatalf("Record mode: %v", err)
+		}
+		supabaseClient.WrapTransport(recorder.Wrap)
+		log.Printf("Record mode: recording Management API responses to %s", config.CassettePath)
+	}
+
+	// Initialize API keys
+	keyring := auth.NewKeyring(config.RateLimitPerMinute)
+	keyring.Add("default", config.APIKey, []string{"*"})
+	if err := keyring.ParseKeys(config.APIKeys); err != nil {
+		log.Fatalf("Configuration error: %v", err)
 	}
 
+	// Record storage query metrics
+	registry := metrics.NewRegistry()
+	store.SetQueryObserver(newStorageMetrics(registry))
+
+	// Initialize self-monitoring
+	notifier := notify.New(config.AlertWebhookURL)
+	mon := monitor.New(store, notifier, config.HeartbeatInterval)
+
 	// Initialize handlers
-	handler := api.NewHandler(supabaseClient, store, config.DefaultRegion)
+	handler := api.NewHandler(supabaseClient, store, api.Options{
+		DefaultRegion:  config.DefaultRegion,
+		RegionFallbacks: config.RegionFallbacks,
+		HealthCacheTTL: config.HealthCacheTTL,
+		SchemaCacheTTL: config.SchemaCacheTTL,
+		Keyring:        keyring,
+		Monitor:        mon,
+		AuthBaseline:   config.AuthBaseline,
+		AllowBYOCredentials: config.AllowBYOCredentials,
+		EphemeralDBRoles:    config.EphemeralDBRoles,
+		DefaultBuckets:      config.defaultBucketList,
+		SchemaFetcher:       supabase.NewSchemaFetcher(config.SchemaSourceAllowedHosts, config.SchemaSourceMaxBytes),
+		Notifier:            notifier,
+		ProvisionAnomalyFactor: config.ProvisionAnomalyFactor,
+		Policy:                 policy.New(config.PolicyWebhookURL, config.PolicyWebhookTimeout, config.PolicyFailOpen),
+		DBMaxConcurrent:        config.DBMaxConcurrent,
+		DBMaxConcurrentPerTarget: config.DBMaxConcurrentPerTarget,
+		DBQueueTimeout:         config.DBQueueTimeout,
+		MaxSQLBytes:            config.MaxSQLBytes,
+		SQLSecretScan:          config.SQLSecretScan,
+		PreviewCommentHosts:    config.PreviewCommentAllowedHosts,
+		WebhookHosts:           config.WebhookAllowedHosts,
+		HookSecret:             config.SupabaseHookSecret,
+		HookRequireSignature:   config.HookRequireSignature,
+		NamingPolicy:           config.NamingPolicy,
+		StrictLimits:           config.StrictLimits,
+		StrictMigrations:       config.StrictMigrations,
+		ProvisioningProfiles:   config.provisioningProfileList,
+		ProvisioningSLO:        config.ProvisioningSLO,
+		DRKey:                  config.drKey,
+		SoftQuotas:             config.SoftQuotas,
+		SnapshotPolicy:         config.snapshotPolicy,
+		SnapshotTool: &supabase.SnapshotTool{
+			Dir:     config.SnapshotDir,
+			PgDump:  config.PgDumpPath,
+			Psql:    config.PsqlPath,
+			Schemas: config.SnapshotSchemas,
+		},
+		SnapshotTimeout: config.SnapshotTimeout,
+		MaxProvisioning:        config.MaxProvisioning,
+		MaxMigrationQueue:      config.MaxMigrationQueue,
+		Deployment: api.Deployment{
+			Version:                 version,
+			Mode:                    config.Mode(),
+			DeletedProjectRetention: time.Duration(config.DeletedProjectRetentionDays) * 24 * time.Hour,
+			JobRetention:            time.Duration(config.JobRetentionDays) * 24 * time.Hour,
+			HealthProbeInterval:     config.HealthProbeInterval,
+		},
+	})
+	// Regions are known once the region list can be fetched
+	if err := handler.CheckConfiguredRegions(); err != nil {
+		log.Fatalf("Configuration error: %v", err)
+	}
+	mon.AddRule(monitor.Rule{
+		Name: "background_tasks_backed_up",
+		Check: func() (bool, string) {
+			pending := handler.PendingTasks()
+			if pending > int64(config.AlertMaxPendingTasks) {
+				return true, fmt.Sprintf("%d background tasks pending (threshold %d)", pending, config.AlertMaxPendingTasks)
+			}
+			return false, ""
+		},
+	})
+	mon.AddRule(monitor.Rule{
+		Name:  "provisioning_error_budget_burn",
+		Check: handler.ErrorBudgetBurning,
+	})
 
-	// Setup router
-	router := setupRouter(handler, config)
+	// Open the access log
+	var accessLog io.Writer
+	if config.AccessLogEnabled {
+		accessLog = os.Stdout
+		if config.AccessLogPath != "" {
+			file, err := openRotatingFile(config.AccessLogPath, int64(config.AccessLogMaxSizeMB)*1024*1024, config.AccessLogMaxBackups)
+			if err != nil {
+				log.Fatalf("Failed to open access log: %v", err)
+			}
+			defer file.Close()
+			accessLog = file
+		}
+	}
 
-	// temp code
+	// Setup routers
+	registerMetrics(registry, handler, supabaseClient)
+	// Both listeners share the bans of clients guessing API keys
+	lockout := auth.NewLockout(config.AuthFailureLimit, config.AuthFailureWindow, config.AuthBanDuration, config.AuthBanMaxDuration)
+	router := setupRouter(handler, store, keyring, lockout, registry, accessLog, config)
+	adminRouter := setupAdminRouter(handler, keyring, lockout, registry, router, config)
 
-	router.GET("/debug/routes", func(c *gin.Context) {
-	c.JSON(200, gin.H{
-		"routes": router.Routes(),
-			})
+	// Start Kubernetes controller if enabled
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if config.OperatorMode {
+		controller, err := operator.NewController(handler, config.OperatorNamespace, config.OperatorResyncInterval)
+		if err != nil {
+			log.Fatalf("Failed to initialize Kubernetes controller: %v", err)
+		}
+		mon.AddRule(monitor.Rule{
+			Name: "reconcile_failing",
+			Check: func() (bool, string) {
+				passes := controller.FailingPasses()
+				if passes >= int64(config.AlertReconcileFailures) {
+					return true, fmt.Sprintf("last %d reconcile passes failed", passes)
+				}
+				return false, ""
+			},
 		})
+		log.Println("Starting Kubernetes controller for SupabaseProject resources")
+		go controller.Run(ctx)
+	}
+
+	// Start heartbeat and health rule evaluation
+	go mon.Run(ctx)
+
+	// Record stats history
+	go handler.RecordStatsHistory(ctx, config.StatsHistoryInterval, config.StatsHistoryRetention)
 
+	// Probe active projects in the background
+	go handler.ProbeProjectHealth(ctx, config.HealthProbeInterval)
+
+	// Purge deleted projects past their retention
+	go handler.PurgeDeletedProjects(ctx, time.Duration(config.DeletedProjectRetentionDays)*24*time.Hour)
+
+	// Purge finished jobs past their retention
+	go handler.PurgeJobs(ctx, time.Duration(config.JobRetentionDays)*24*time.Hour)
 
 	// Start server
 	addr := fmt.Sprintf(":%s", config.Port)
 	log.Printf("Starting server on %s", addr)
+	log.Printf("Runtime: GOMAXPROCS %d of %d CPUs", runtime.GOMAXPROCS(0), runtime.NumCPU())
 	log.Printf("Health check: http://localhost%s/health", addr)
 	log.Printf("API base URL: http://localhost%s/api", addr)
 
+	// Admin listener for metrics, profiling and admin endpoints
+	log.Printf("Admin endpoints: http://%s/metrics", config.AdminAddr)
+	go func() {
+		if err := newHTTPServer(config.AdminAddr, adminRouter, config).ListenAndServe(); err != nil {
+			log.Fatalf("Admin server failed: %v", err)
+		}
+	}()
+
 	// Graceful shutdown
 	go func() {
-		if err := router.Run(addr); err != nil {
+		if err := newHTTPServer(addr, router, config).ListenAndServe(); err != nil {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
 
+	// Finish starting up while the listener answers health checks;
+	// mutating requests get 503 until then
+	go finishStartup(handler, supabaseClient, config)
+
 	// Wait for interrupt signal
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	log.Println("Shutting down server...")
+	cancel()
 	
 	// Wait for background tasks to finish
 	log.Println("Waiting for background tasks...")
@@ -96,40 +294,489 @@ func main() {
 // Config holds application configuration
 type Config struct {
 	Port                 string
+	AdminAddr            string
 	DBPath               string
 	SupabaseAccessToken  string
 	SupabaseOrgID        string
 	APIKey               string
+	APIKeys              string
+	RateLimitPerMinute   int
 	DefaultRegion        string
 	LogLevel             string
+	SandboxMode          bool
+	OfflineMode          bool
+	RecordMode           bool
+	CassettePath         string
+	HealthCacheTTL       time.Duration
+	SchemaCacheTTL       time.Duration
+
+	// Let callers supply their own Supabase access token and organization
+	AllowBYOCredentials bool
+
+	// Requests per minute per client IP to the public status endpoint
+	StatusRateLimitPerMinute int
+
+	// Requests per minute per client IP to the key usage telemetry endpoint
+	TelemetryRateLimitPerMinute int
+
+	// Ban client IPs that present AuthFailureLimit invalid API keys within
+	// AuthFailureWindow, for AuthBanDuration doubling up to
+	// AuthBanMaxDuration on repeat offenses; a limit of 0 disables bans
+	AuthFailureLimit   int
+	AuthFailureWindow  time.Duration
+	AuthBanDuration    time.Duration
+	AuthBanMaxDuration time.Duration
+
+	// Proxies whose forwarding headers give the client IP, as IPs or CIDRs,
+	// parsed by Validate. Empty trusts no proxy, so the client IP is the
+	// address the connection comes from.
+	TrustedProxies   string
+	trustedProxyList []string
+
+	// Regions tried in order when the requested region has no capacity
+	RegionFallbacks []string
+
+	// Run migrations as short-lived database roles
+	EphemeralDBRoles bool
+
+	// Buckets created for projects requested with with_default_buckets, as
+	// "name[:public],...", parsed by Validate
+	DefaultBuckets    string
+	defaultBucketList []supabase.BucketRequest
+
+	// Hosts schema SQL may be fetched from, and the largest script fetched
+	SchemaSourceAllowedHosts []string
+	SchemaSourceMaxBytes     int64
+
+	// Largest inline SQL accepted by schema requests
+	MaxSQLBytes int64
+
+	// What happens to migrations with embedded credentials: reject, warn
+	// or off
+	SQLSecretScan string
+
+	// Hosts preview outcomes may be posted to as PR comments
+	PreviewCommentAllowedHosts []string
+
+	// Hosts project lifecycle webhooks may be registered for; empty allows
+	// any host
+	WebhookAllowedHosts []string
+
+	// Secret verifying database webhooks and auth hooks sent to
+	// /hooks/supabase; empty disables the receiver. With
+	// HookRequireSignature, only signed hooks are accepted.
+	SupabaseHookSecret   string
+	HookRequireSignature bool
+
+	// Management API endpoints pinned to versions, as endpoint=version
+	// pairs, and whether the API's capabilities are probed at startup
+	ManagementAPIVersions string
+	ManagementAPIProbe    bool
+	apiVersions           supabase.APIVersions
+
+	// External policy endpoint approving project creation and schema changes
+	PolicyWebhookURL     string
+	PolicyWebhookTimeout time.Duration
+	PolicyFailOpen       bool
+
+	// Limits on database operations (migrations, introspection, checks)
+	// running at once, and how long an operation waits for a slot
+	DBMaxConcurrent          int
+	DBMaxConcurrentPerTarget int
+	DBQueueTimeout           time.Duration
+
+	// Create and migrate requests are shed with 503 once this many
+	// provisions are running or database operations are queued
+	MaxProvisioning   int
+	MaxMigrationQueue int
+
+	// Request hardening: the largest request body and header, server
+	// timeouts against slow clients, and the Strict-Transport-Security
+	// max-age (0 disables it)
+	MaxRequestBodyBytes   int64
+	MaxHeaderBytes        int
+	HTTPReadHeaderTimeout time.Duration
+	HTTPReadTimeout       time.Duration
+	HTTPWriteTimeout      time.Duration
+	HTTPIdleTimeout       time.Duration
+	HSTSMaxAge            time.Duration
+
+	// HTTP access log
+	AccessLogEnabled    bool
+	AccessLogPath       string
+	AccessLogMaxSizeMB  int
+	AccessLogMaxBackups int
+
+	// Stats history
+	StatsHistoryInterval  time.Duration
+	StatsHistoryRetention time.Duration
+
+	// Interval between background health probes of active projects; 0
+	// disables probing
+	HealthProbeInterval time.Duration
+
+	// Days a deleted project is kept before it is purged; 0 keeps it forever
+	DeletedProjectRetentionDays int
+
+	// Days a finished job and its result are kept; 0 keeps them forever
+	JobRetentionDays int
+
+	// Kubernetes controller mode
+	OperatorMode           bool
+	OperatorNamespace      string
+	OperatorResyncInterval time.Duration
+
+	// Self-monitoring
+	HeartbeatInterval      time.Duration
+	AlertWebhookURL        string
+	AlertMaxPendingTasks   int
+	ProvisionAnomalyFactor float64
+	AlertReconcileFailures int
+
+	// Auth settings applied to every new project
+	AuthBaseline supabase.AuthSettings
+
+	// Naming policy for new projects
+	NamingPolicy supabase.NamingPolicy
+
+	// Strict mode for migrations: default limits, and whether every
+	// migration runs in strict mode
+	StrictLimits     supabase.StrictLimits
+	StrictMigrations bool
+
+	// Provisioning profiles create requests can name, read from a JSON file
+	ProjectProfilesFile     string
+	provisioningProfileList []supabase.ProvisioningProfile
+
+	// Provisioning SLO targets and error budget burn alerting
+	ProvisioningSLO supabase.ProvisioningSLO
+
+	// Base64 key wrapping the credentials of disaster recovery exports;
+	// empty disables them
+	DRExportKey string
+	drKey       supabase.DRKey
+
+	// Soft quotas warning creates before hard limits reject them
+	SoftQuotas supabase.SoftQuotas
+
+	// Projects dumped before they are deleted in Supabase: off, always or
+	// a label selector such as env=production, parsed by Validate. The
+	// dumps cover SnapshotSchemas and are kept in SnapshotDir.
+	PreDeleteSnapshot string
+	snapshotPolicy    supabase.SnapshotPolicy
+	SnapshotDir       string
+	SnapshotSchemas   []string
+	SnapshotTimeout   time.Duration
+	PgDumpPath        string
+	PsqlPath          string
 }
 
 // loadConfig loads configuration from environment variables
 func loadConfig() *Config {
 	return &Config{
 		Port:                 getEnv("PORT", "8080"),
+		AdminAddr:            getEnv("ADMIN_ADDR", "127.0.0.1:9090"),
 		DBPath:               getEnv("DB_PATH","/tmp/supabase-manager.db"),
 		SupabaseAccessToken:  getEnv("SUPABASE_ACCESS_TOKEN", ""),
 		SupabaseOrgID:        getEnv("SUPABASE_ORGANIZATION_ID", ""),
 		APIKey:               getEnv("API_KEY", "dev-api-key-change-in-production"),
+		APIKeys:              getEnv("API_KEYS", ""),
+		RateLimitPerMinute:   getEnvInt("RATE_LIMIT_PER_MINUTE", 600),
 		DefaultRegion:        getEnv("DEFAULT_REGION", "us-east-1"),
 		LogLevel:             getEnv("LOG_LEVEL", "info"),
+		SandboxMode:          getEnvBool("SANDBOX_MODE", false),
+		OfflineMode:          getEnvBool("OFFLINE_MODE", false),
+		RecordMode:           getEnvBool("RECORD_MODE", false),
+		CassettePath:         getEnv("CASSETTE_PATH", "management-api-cassette.json"),
+		HealthCacheTTL:       getEnvDuration("HEALTH_CACHE_TTL", 10*time.Second),
+		SchemaCacheTTL:       getEnvDuration("SCHEMA_CACHE_TTL", 30*time.Second),
+
+		AllowBYOCredentials: getEnvBool("ALLOW_BYO_CREDENTIALS", false),
+		EphemeralDBRoles:    getEnvBool("EPHEMERAL_DB_ROLES", false),
+		DefaultBuckets:      getEnv("DEFAULT_BUCKETS", ""),
+
+		StatusRateLimitPerMinute:    getEnvInt("STATUS_RATE_LIMIT_PER_MINUTE", 60),
+		TelemetryRateLimitPerMinute: getEnvInt("TELEMETRY_RATE_LIMIT_PER_MINUTE", 600),
+		TrustedProxies:              getEnv("TRUSTED_PROXIES", ""),
+
+		AuthFailureLimit:   getEnvInt("AUTH_FAILURE_LIMIT", 10),
+		AuthFailureWindow:  getEnvDuration("AUTH_FAILURE_WINDOW", 5*time.Minute),
+		AuthBanDuration:    getEnvDuration("AUTH_BAN_DURATION", time.Minute),
+		AuthBanMaxDuration: getEnvDuration("AUTH_BAN_MAX_DURATION", 24*time.Hour),
+
+		RegionFallbacks: strings.Split(getEnv("REGION_FALLBACKS", ""), ","),
+
+		SchemaSourceAllowedHosts: strings.Split(getEnv("SCHEMA_SOURCE_ALLOWED_HOSTS", "github.com,raw.githubusercontent.com,gitlab.com"), ","),
+		SchemaSourceMaxBytes:     int64(getEnvInt("SCHEMA_SOURCE_MAX_BYTES", 10<<20)),
+
+		MaxSQLBytes: int64(getEnvInt("MAX_SQL_BYTES", 10<<20)),
+
+		SQLSecretScan: getEnv("SQL_SECRET_SCAN", supabase.SecretScanReject),
+
+		PreviewCommentAllowedHosts: strings.Split(getEnv("PREVIEW_COMMENT_ALLOWED_HOSTS", "api.github.com,gitlab.com"), ","),
+
+		WebhookAllowedHosts: strings.Split(getEnv("WEBHOOK_ALLOWED_HOSTS", ""), ","),
+
+		SupabaseHookSecret:   getEnv("SUPABASE_HOOK_SECRET", ""),
+		HookRequireSignature: getEnvBool("HOOK_REQUIRE_SIGNATURE", false),
+
+		ManagementAPIVersions: getEnv("MANAGEMENT_API_VERSIONS", ""),
+		ManagementAPIProbe:    getEnvBool("MANAGEMENT_API_PROBE", true),
+
+		PolicyWebhookURL:     getEnv("POLICY_WEBHOOK_URL", ""),
+		PolicyWebhookTimeout: getEnvDuration("POLICY_WEBHOOK_TIMEOUT", 5*time.Second),
+		PolicyFailOpen:       getEnvBool("POLICY_FAIL_OPEN", false),
+
+		DBMaxConcurrent:          getEnvInt("DB_MAX_CONCURRENT", 10),
+		DBMaxConcurrentPerTarget: getEnvInt("DB_MAX_CONCURRENT_PER_PROJECT", 2),
+		DBQueueTimeout:           getEnvDuration("DB_QUEUE_TIMEOUT", 2*time.Minute),
+
+		MaxProvisioning:   getEnvInt("MAX_PROVISIONING", 20),
+		MaxMigrationQueue: getEnvInt("MAX_MIGRATION_QUEUE", 50),
+
+		MaxRequestBodyBytes:   int64(getEnvInt("MAX_REQUEST_BODY_BYTES", 16<<20)),
+		MaxHeaderBytes:        getEnvInt("MAX_HEADER_BYTES", 64<<10),
+		HTTPReadHeaderTimeout: getEnvDuration("HTTP_READ_HEADER_TIMEOUT", 10*time.Second),
+		HTTPReadTimeout:       getEnvDuration("HTTP_READ_TIMEOUT", time.Minute),
+		HTTPWriteTimeout:      getEnvDuration("HTTP_WRITE_TIMEOUT", 10*time.Minute),
+		HTTPIdleTimeout:       getEnvDuration("HTTP_IDLE_TIMEOUT", 2*time.Minute),
+		HSTSMaxAge:            getEnvDuration("HSTS_MAX_AGE", 365*24*time.Hour),
+
+		AccessLogEnabled:    getEnvBool("ACCESS_LOG_ENABLED", false),
+		AccessLogPath:       getEnv("ACCESS_LOG_PATH", ""),
+		AccessLogMaxSizeMB:  getEnvInt("ACCESS_LOG_MAX_SIZE_MB", 100),
+		AccessLogMaxBackups: getEnvInt("ACCESS_LOG_MAX_BACKUPS", 5),
+
+		StatsHistoryInterval:  getEnvDuration("STATS_HISTORY_INTERVAL", time.Hour),
+		StatsHistoryRetention: getEnvDuration("STATS_HISTORY_RETENTION", 365*24*time.Hour),
+		HealthProbeInterval:   getEnvDuration("HEALTH_PROBE_INTERVAL", 0),
+
+		DeletedProjectRetentionDays: getEnvInt("DELETED_PROJECT_RETENTION_DAYS", 30),
+		JobRetentionDays:            getEnvInt("JOB_RETENTION_DAYS", 30),
+
+		OperatorMode:           getEnvBool("OPERATOR_MODE", false),
+		OperatorNamespace:      getEnv("OPERATOR_NAMESPACE", ""),
+		OperatorResyncInterval: getEnvDuration("OPERATOR_RESYNC_INTERVAL", 30*time.Second),
+
+		HeartbeatInterval:      getEnvDuration("HEARTBEAT_INTERVAL", 30*time.Second),
+		AlertWebhookURL:        getEnv("ALERT_WEBHOOK_URL", ""),
+		AlertMaxPendingTasks:   getEnvInt("ALERT_MAX_PENDING_TASKS", 50),
+		ProvisionAnomalyFactor: getEnvFloat("PROVISION_ANOMALY_FACTOR", 1.5),
+		AlertReconcileFailures: getEnvInt("ALERT_RECONCILE_FAILURES", 3),
+
+		AuthBaseline: supabase.AuthSettings{
+			JWTExpiry:                   optionalEnvInt("AUTH_JWT_EXPIRY"),
+			RefreshTokenRotationEnabled: optionalEnvBool("AUTH_REFRESH_TOKEN_ROTATION"),
+			RefreshTokenReuseInterval:   optionalEnvInt("AUTH_REFRESH_TOKEN_REUSE_INTERVAL"),
+			RateLimitEmailSent:          optionalEnvInt("AUTH_RATE_LIMIT_EMAIL_SENT"),
+			RateLimitSMSSent:            optionalEnvInt("AUTH_RATE_LIMIT_SMS_SENT"),
+			RateLimitVerify:             optionalEnvInt("AUTH_RATE_LIMIT_VERIFY"),
+			RateLimitTokenRefresh:       optionalEnvInt("AUTH_RATE_LIMIT_TOKEN_REFRESH"),
+			RateLimitOTP:                optionalEnvInt("AUTH_RATE_LIMIT_OTP"),
+			RateLimitAnonymousUsers:     optionalEnvInt("AUTH_RATE_LIMIT_ANONYMOUS_USERS"),
+		},
+
+		NamingPolicy: supabase.NamingPolicy{
+			Prefix:       getEnv("PROJECT_NAME_PREFIX", ""),
+			Suffix:       getEnv("PROJECT_NAME_SUFFIX", ""),
+			AllowedChars: getEnv("PROJECT_NAME_ALLOWED_CHARS", ""),
+			MaxLength:    getEnvInt("PROJECT_NAME_MAX_LENGTH", 0),
+			TeamLabel:    getEnv("PROJECT_NAME_TEAM_LABEL", "team"),
+		},
+
+		StrictLimits: supabase.StrictLimits{
+			MaxRows: int64(getEnvInt("MIGRATION_STRICT_MAX_ROWS", 10000)),
+			MaxCost: getEnvFloat("MIGRATION_STRICT_MAX_COST", 0),
+		},
+		StrictMigrations: getEnvBool("MIGRATION_STRICT", false),
+
+		ProjectProfilesFile: getEnv("PROJECT_PROFILES_FILE", ""),
+
+		ProvisioningSLO: supabase.ProvisioningSLO{
+			SuccessTarget:       getEnvFloat("SLO_PROVISION_SUCCESS_TARGET", 99),
+			TimeToHealthyTarget: getEnvDuration("SLO_PROVISION_P95_TARGET", 3*time.Minute),
+			Window:              getEnvDuration("SLO_WINDOW", 28*24*time.Hour),
+			BurnWindow:          getEnvDuration("SLO_BURN_WINDOW", time.Hour),
+			BurnRateAlert:       getEnvFloat("SLO_BURN_RATE_ALERT", 14.4),
+		},
+
+		DRExportKey: getEnv("DR_EXPORT_KEY", ""),
+
+		SoftQuotas: supabase.SoftQuotas{
+			ProjectsPerKey:       getEnvInt("SOFT_QUOTA_PROJECTS_PER_KEY", 0),
+			OrganizationCapacity: getEnvInt("ORG_PROJECT_CAPACITY", 0),
+			OrganizationPercent:  getEnvInt("SOFT_QUOTA_ORG_PERCENT", 80),
+		},
+
+		PreDeleteSnapshot: getEnv("PRE_DELETE_SNAPSHOT", "off"),
+		SnapshotDir:       getEnv("SNAPSHOT_DIR", "/tmp/supabase-manager-snapshots"),
+		SnapshotSchemas:   strings.Split(getEnv("SNAPSHOT_SCHEMAS", "public"), ","),
+		SnapshotTimeout:   getEnvDuration("SNAPSHOT_TIMEOUT", 30*time.Minute),
+		PgDumpPath:        getEnv("PG_DUMP_PATH", "pg_dump"),
+		PsqlPath:          getEnv("PSQL_PATH", "psql"),
 	}
 }
 
 // Validate checks if required configuration is present
 func (c *Config) Validate() error {
+	modes := 0
+	for _, enabled := range []bool{c.SandboxMode, c.OfflineMode, c.RecordMode} {
+		if enabled {
+			modes++
+		}
+	}
+	if modes > 1 {
+		return fmt.Errorf("SANDBOX_MODE, OFFLINE_MODE and RECORD_MODE are mutually exclusive")
+	}
+	if c.SandboxMode || c.OfflineMode {
+		if c.SupabaseAccessToken == "" {
+			c.SupabaseAccessToken = "sandbox-token"
+		}
+		if c.SupabaseOrgID == "" {
+			c.SupabaseOrgID = "sandbox-org"
+		}
+	}
 	if c.SupabaseAccessToken == "" {
 		return fmt.Errorf("SUPABASE_ACCESS_TOKEN is required")
 	}
 	if c.SupabaseOrgID == "" {
 		return fmt.Errorf("SUPABASE_ORGANIZATION_ID is required")
 	}
+	if err := c.AuthBaseline.Validate(); err != nil {
+		return fmt.Errorf("invalid auth baseline: %w", err)
+	}
+	if err := c.NamingPolicy.Validate(); err != nil {
+		return fmt.Errorf("invalid project naming policy: %w", err)
+	}
+	if err := c.ProvisioningSLO.Validate(); err != nil {
+		return fmt.Errorf("invalid provisioning SLO: %w", err)
+	}
+	if err := c.SoftQuotas.Validate(); err != nil {
+		return fmt.Errorf("invalid SOFT_QUOTA_PROJECTS_PER_KEY, ORG_PROJECT_CAPACITY or SOFT_QUOTA_ORG_PERCENT: %w", err)
+	}
+	if err := c.StrictLimits.Validate(); err != nil {
+		return fmt.Errorf("invalid MIGRATION_STRICT_MAX_ROWS or MIGRATION_STRICT_MAX_COST: %w", err)
+	}
+	versions, err := supabase.ParseAPIVersions(c.ManagementAPIVersions)
+	if err != nil {
+		return fmt.Errorf("invalid MANAGEMENT_API_VERSIONS: %w", err)
+	}
+	c.apiVersions = versions
+
+	buckets, err := supabase.ParseBucketList(c.DefaultBuckets)
+	if err != nil {
+		return fmt.Errorf("invalid DEFAULT_BUCKETS: %w", err)
+	}
+	c.defaultBucketList = buckets
+
+	if c.ProjectProfilesFile != "" {
+		data, err := os.ReadFile(c.ProjectProfilesFile)
+		if err != nil {
+			return fmt.Errorf("failed to read PROJECT_PROFILES_FILE: %w", err)
+		}
+		profiles, err := supabase.ParseProvisioningProfiles(data)
+		if err != nil {
+			return fmt.Errorf("invalid PROJECT_PROFILES_FILE: %w", err)
+		}
+		c.provisioningProfileList = profiles
+	}
+
+	if c.DRExportKey != "" {
+		key, err := supabase.ParseDRKey(c.DRExportKey)
+		if err != nil {
+			return fmt.Errorf("invalid DR_EXPORT_KEY: %w", err)
+		}
+		c.drKey = key
+	}
+
+	snapshotPolicy, err := supabase.ParseSnapshotPolicy(c.PreDeleteSnapshot)
+	if err != nil {
+		return fmt.Errorf("invalid PRE_DELETE_SNAPSHOT: %w", err)
+	}
+	c.snapshotPolicy = snapshotPolicy
+	var schemas []string
+	for _, schema := range c.SnapshotSchemas {
+		if schema = strings.TrimSpace(schema); schema != "" {
+			schemas = append(schemas, schema)
+		}
+	}
+	if len(schemas) == 0 {
+		return fmt.Errorf("SNAPSHOT_SCHEMAS must name at least one schema")
+	}
+	c.SnapshotSchemas = schemas
+	if c.SnapshotTimeout <= 0 {
+		return fmt.Errorf("SNAPSHOT_TIMEOUT must be positive")
+	}
+
+	if c.DBMaxConcurrent < 1 || c.DBMaxConcurrentPerTarget < 1 {
+		return fmt.Errorf("DB_MAX_CONCURRENT and DB_MAX_CONCURRENT_PER_PROJECT must be at least 1")
+	}
+	if c.MaxProvisioning < 0 || c.MaxMigrationQueue < 0 {
+		return fmt.Errorf("MAX_PROVISIONING and MAX_MIGRATION_QUEUE must not be negative")
+	}
+	if c.StatusRateLimitPerMinute < 0 || c.TelemetryRateLimitPerMinute < 0 {
+		return fmt.Errorf("STATUS_RATE_LIMIT_PER_MINUTE and TELEMETRY_RATE_LIMIT_PER_MINUTE must not be negative")
+	}
+	if c.AuthFailureLimit < 0 {
+		return fmt.Errorf("AUTH_FAILURE_LIMIT must not be negative")
+	}
+	if c.AuthFailureLimit > 0 && (c.AuthFailureWindow <= 0 || c.AuthBanDuration <= 0 || c.AuthBanMaxDuration <= 0) {
+		return fmt.Errorf("AUTH_FAILURE_WINDOW, AUTH_BAN_DURATION and AUTH_BAN_MAX_DURATION must be positive")
+	}
+	c.trustedProxyList = nil
+	for _, proxy := range strings.Split(c.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(proxy); err != nil && net.ParseIP(proxy) == nil {
+			return fmt.Errorf("TRUSTED_PROXIES: %q is not an IP or CIDR", proxy)
+		}
+		c.trustedProxyList = append(c.trustedProxyList, proxy)
+	}
+	if c.MaxRequestBodyBytes < 1 || c.MaxHeaderBytes < 1 {
+		return fmt.Errorf("MAX_REQUEST_BODY_BYTES and MAX_HEADER_BYTES must be at least 1")
+	}
+	if c.MaxSQLBytes > 0 && c.MaxRequestBodyBytes <= c.MaxSQLBytes {
+		return fmt.Errorf("MAX_REQUEST_BODY_BYTES (%d) must be larger than MAX_SQL_BYTES (%d)", c.MaxRequestBodyBytes, c.MaxSQLBytes)
+	}
+	if c.HealthProbeInterval != 0 && c.HealthProbeInterval < minHealthProbeInterval {
+		return fmt.Errorf("HEALTH_PROBE_INTERVAL must be 0 or at least %s", minHealthProbeInterval)
+	}
+	if c.HTTPReadHeaderTimeout <= 0 {
+		return fmt.Errorf("HTTP_READ_HEADER_TIMEOUT must be positive")
+	}
+	if !supabase.ValidSecretScanMode(c.SQLSecretScan) {
+		return fmt.Errorf("SQL_SECRET_SCAN must be reject, warn or off, got %q", c.SQLSecretScan)
+	}
 	return nil
 }
 
+// Mode names how the instance reaches the Management API: live, sandbox,
+// offline or record
+func (c *Config) Mode() string {
+	switch {
+	case c.SandboxMode:
+		return "sandbox"
+	case c.OfflineMode:
+		return "offline"
+	case c.RecordMode:
+		return "record"
+	}
+	return "live"
+}
+
+// trustProxies limits the senders whose forwarding headers are used for the
+// client IP, which IP rate limits and bans key on. Without TRUSTED_PROXIES
+// no sender is trusted, so X-Forwarded-For cannot be used to claim another
+// IP.
+func trustProxies(router *gin.Engine, config *Config) {
+	if err := router.SetTrustedProxies(config.trustedProxyList); err != nil {
+		log.Fatalf("Configuration error: TRUSTED_PROXIES: %v", err)
+	}
+}
+
 // setupRouter configures the HTTP router
-func setupRouter(handler *api.Handler, config *Config) *gin.Engine {
+func setupRouter(handler *api.Handler, store *storage.SQLiteStorage, keyring *auth.Keyring, lockout *auth.Lockout, registry *metrics.Registry, accessLog io.Writer, config *Config) *gin.Engine {
 	// Set Gin mode based on log level
 	if config.LogLevel == "debug" {
 		gin.SetMode(gin.DebugMode)
@@ -138,28 +785,191 @@ func setupRouter(handler *api.Handler, config *Config) *gin.Engine {
 	}
 
 	router := gin.Default()
+	trustProxies(router, config)
 
 	// CORS middleware
 	router.Use(corsMiddleware())
 
+	// Security headers
+	router.Use(securityHeadersMiddleware(config))
+
+	// Request ID and processing time headers
+	router.Use(requestMetadataMiddleware())
+
+	// Request metrics
+	router.Use(metricsMiddleware(registry))
+
+	// Access log, kept apart from the application log
+	if accessLog != nil {
+		router.Use(accessLogMiddleware(accessLog))
+	}
+
+	// Request body size and content type limits
+	router.Use(requestLimitsMiddleware(config.MaxRequestBodyBytes))
+
+	// Mutating requests wait for startup to finish
+	router.Use(readinessMiddleware(handler))
+
 	// Public routes
 	router.GET("/health", handler.HealthCheck)
+	router.GET("/ready", handler.Readiness)
+	router.GET("/status", ipRateLimitMiddleware(config.StatusRateLimitPerMinute), handler.PublicStatus)
+
+	// Share links carry their own one-time token instead of an API key
+	router.GET("/share/:token", handler.RedeemShareLink)
+
+	// Database webhooks and auth hooks of provisioned projects are verified
+	// with the hook secret instead of an API key
+	router.POST("/hooks/supabase", handler.ReceiveSupabaseHook)
+
+	// Provisioned apps report key usage with their project's key
+	router.POST("/api/telemetry", ipRateLimitMiddleware(config.TelemetryRateLimitPerMinute), handler.RecordTelemetry)
 
 	// API routes (with authentication)
 	apiRoutes := router.Group("/api")
-	apiRoutes.Use(authMiddleware(config.APIKey))
+	apiRoutes.Use(authMiddleware(keyring, lockout, handler.ReportAuthBan))
+	apiRoutes.Use(idempotencyMiddleware(store))
 	{
+		// Calling key
+		apiRoutes.GET("/me", handler.Me)
+		apiRoutes.GET("/capabilities", handler.GetCapabilities)
+
+		// Organization defaults for new projects
+		apiRoutes.GET("/org/defaults", handler.GetOrgDefaults)
+		apiRoutes.PUT("/org/defaults", requireScope("admin"), handler.UpdateOrgDefaults)
+
+		// Request schemas
+		apiRoutes.GET("/schemas", handler.ListSchemas)
+		apiRoutes.GET("/schemas/:file", handler.GetSchema)
+
 		// Projects
 		apiRoutes.POST("/projects", handler.CreateProject)
+		apiRoutes.POST("/projects/batch-delete", handler.BatchDeleteProjects)
 		apiRoutes.GET("/projects", handler.ListProjects)
+		apiRoutes.GET("/projects/compare", handler.CompareProjects)
+		apiRoutes.GET("/projects/lookup", handler.LookupProject)
 		apiRoutes.GET("/projects/:id", handler.GetProject)
+		apiRoutes.PATCH("/projects/:id", handler.UpdateProject)
+		apiRoutes.GET("/projects/:id/diagnose", handler.DiagnoseProject)
+		apiRoutes.GET("/projects/:id/db-pool", handler.GetProjectDBPool)
+		apiRoutes.GET("/projects/:id/setup", handler.GetProjectSetup)
 		apiRoutes.DELETE("/projects/:id", handler.DeleteProject)
 
 		// Schema management
+		apiRoutes.GET("/projects/:id/schema", handler.GetProjectSchema)
 		apiRoutes.POST("/projects/:id/schema", handler.ApplySchema)
+		apiRoutes.POST("/projects/:id/schema/diff", handler.DiffProjectSchema)
+		apiRoutes.POST("/projects/:id/schema/from-git", handler.ApplySchemaFromGit)
+		apiRoutes.GET("/projects/:id/migrations", handler.ListProjectMigrations)
+		apiRoutes.GET("/projects/:id/migrations/:version/sql", handler.GetProjectMigrationSQL)
+		apiRoutes.POST("/projects/:id/migrations/import", handler.ImportProjectMigrations)
+		apiRoutes.GET("/projects/:id/export/supabase-cli", handler.ExportSupabaseCLI)
+		apiRoutes.GET("/projects/:id/migration-roles", handler.GetProjectMigrationRoles)
+		apiRoutes.PUT("/projects/:id/migration-roles", handler.SetProjectMigrationRoles)
+		apiRoutes.POST("/schema/batch", handler.ApplySchemaBatch)
+
+		// Tenant schemas
+		apiRoutes.GET("/projects/:id/tenants", handler.ListTenants)
+		apiRoutes.POST("/projects/:id/tenants", handler.CreateTenant)
+		apiRoutes.GET("/projects/:id/tenants/:tenant", handler.GetTenant)
+		apiRoutes.DELETE("/projects/:id/tenants/:tenant", handler.DeleteTenant)
+
+		// Share links and audit log
+		apiRoutes.POST("/projects/:id/share", handler.CreateShareLink)
+		apiRoutes.GET("/projects/:id/share", handler.ListShareLinks)
+		apiRoutes.GET("/projects/:id/audit", handler.ListAuditEvents)
+		apiRoutes.GET("/projects/:id/at", handler.GetProjectAt)
+
+		// Labels and fleet drift reports
+		apiRoutes.PUT("/projects/:id/labels", handler.SetProjectLabels)
+		apiRoutes.PUT("/projects/:id/name", handler.RenameProject)
+		apiRoutes.POST("/projects/:id/restart", handler.RestartProject)
+		apiRoutes.POST("/projects/:id/plan", requireScope("admin"), handler.ChangeProjectPlan)
+		apiRoutes.POST("/drift-report", handler.CreateDriftReport)
+
+		// Feature flags, optionally synced into the project
+		apiRoutes.GET("/projects/:id/flags", handler.ListFeatureFlags)
+		apiRoutes.POST("/projects/:id/flags/sync", handler.SyncFeatureFlags)
+		apiRoutes.GET("/projects/:id/flags/:key", handler.GetFeatureFlag)
+		apiRoutes.PUT("/projects/:id/flags/:key", handler.SetFeatureFlag)
+		apiRoutes.DELETE("/projects/:id/flags/:key", handler.DeleteFeatureFlag)
+
+		// Remote projects the manager does not track
+		apiRoutes.GET("/remote-orphans", handler.ListRemoteOrphans)
+		apiRoutes.POST("/remote-orphans/:ref/adopt", handler.AdoptRemoteOrphan)
+		apiRoutes.POST("/remote-orphans/:ref/ignore", handler.IgnoreRemoteOrphan)
+		apiRoutes.DELETE("/remote-orphans/:ref/ignore", handler.UnignoreRemoteOrphan)
+
+		// Preview projects of git branches
+		apiRoutes.GET("/previews", handler.ListPreviews)
+		apiRoutes.POST("/previews", handler.CreatePreview)
+		apiRoutes.POST("/previews/close", handler.ClosePreview)
+
+		// Background jobs
+		apiRoutes.GET("/jobs", handler.ListJobs)
+		apiRoutes.GET("/jobs/:id", handler.GetJob)
+		apiRoutes.GET("/jobs/:id/logs", handler.GetJobLogs)
+
+		// Auth settings
+		apiRoutes.GET("/projects/:id/auth/settings", handler.GetAuthSettings)
+		apiRoutes.PATCH("/projects/:id/auth/settings", handler.UpdateAuthSettings)
+		apiRoutes.GET("/projects/:id/jwt-secret", handler.GetJWTSecret)
+		apiRoutes.POST("/projects/:id/jwt-secret/rotate", handler.RotateJWTSecret)
+
+		// Log drains
+		apiRoutes.GET("/projects/:id/log-drains", handler.ListLogDrains)
+		apiRoutes.POST("/projects/:id/log-drains", handler.CreateLogDrain)
+		apiRoutes.DELETE("/projects/:id/log-drains/:drain", handler.DeleteLogDrain)
+
+		// Storage buckets and policies
+		apiRoutes.GET("/projects/:id/buckets", handler.ListBuckets)
+		apiRoutes.POST("/projects/:id/buckets", handler.CreateBucket)
+		apiRoutes.PUT("/projects/:id/buckets/:bucket", handler.UpdateBucket)
+		apiRoutes.DELETE("/projects/:id/buckets/:bucket", handler.DeleteBucket)
+		apiRoutes.GET("/projects/:id/buckets/:bucket/policies", handler.ListBucketPolicies)
+		apiRoutes.POST("/projects/:id/buckets/:bucket/policies", handler.CreateBucketPolicy)
+		apiRoutes.DELETE("/projects/:id/buckets/:bucket/policies/:policy", handler.DeleteBucketPolicy)
+
+		// External Postgres databases
+		apiRoutes.POST("/databases", handler.RegisterDatabase)
+		apiRoutes.GET("/databases", handler.ListDatabases)
+		apiRoutes.GET("/databases/:id", handler.GetDatabase)
+		apiRoutes.DELETE("/databases/:id", handler.DeleteDatabase)
+		apiRoutes.POST("/databases/:id/schema", handler.ApplyDatabaseSchema)
+		apiRoutes.GET("/databases/:id/migrations", handler.ListDatabaseMigrations)
+		apiRoutes.GET("/databases/:id/migrations/:version/sql", handler.GetDatabaseMigrationSQL)
+		apiRoutes.POST("/databases/:id/migrations/import", handler.ImportDatabaseMigrations)
+		apiRoutes.GET("/databases/:id/migration-roles", handler.GetDatabaseMigrationRoles)
+		apiRoutes.PUT("/databases/:id/migration-roles", handler.SetDatabaseMigrationRoles)
+
+		// SQL linting
+		apiRoutes.POST("/lint", handler.LintSQL)
+
+		// Postgres versions
+		apiRoutes.GET("/postgres-versions", handler.ListPostgresVersions)
+		apiRoutes.GET("/regions", handler.ListRegions)
+
+		// Freeze windows blocking migrations and deletes
+		apiRoutes.GET("/freeze-windows", handler.ListFreezeWindows)
+		apiRoutes.POST("/freeze-windows", requireScope("admin"), handler.CreateFreezeWindow)
+		apiRoutes.DELETE("/freeze-windows/:id", requireScope("admin"), handler.DeleteFreezeWindow)
 
 		// Statistics
 		apiRoutes.GET("/stats", handler.GetStats)
+		apiRoutes.GET("/stats/history", handler.GetStatsHistory)
+		apiRoutes.GET("/stats/provisioning", handler.GetProvisioningStats)
+		apiRoutes.GET("/slo", handler.GetSLO)
+
+		// Project lifecycle webhooks
+		apiRoutes.POST("/webhooks", handler.CreateWebhook)
+		apiRoutes.GET("/webhooks", handler.ListWebhooks)
+		apiRoutes.DELETE("/webhooks/:id", handler.DeleteWebhook)
+
+		// Snapshots taken before projects were deleted
+		apiRoutes.GET("/snapshots", handler.ListSnapshots)
+		apiRoutes.GET("/snapshots/:id", handler.GetSnapshot)
+		apiRoutes.DELETE("/snapshots/:id", handler.DeleteSnapshot)
+		apiRoutes.POST("/snapshots/:id/restore", handler.RestoreSnapshot)
 	}
 
 	return router
@@ -169,8 +979,9 @@ func setupRouter(handler *api.Handler, config *Config) *gin.Engine {
 func corsMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		c.Writer.Header().Set("Access-Control-Allow-Origin", "*")
-		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key")
+		c.Writer.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, PATCH, DELETE, OPTIONS")
+		c.Writer.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization, X-API-Key, X-Request-Id, X-Dry-Run, X-Supabase-Access-Token, X-Supabase-Organization-ID, If-None-Match, Idempotency-Key")
+		c.Writer.Header().Set("Access-Control-Expose-Headers", "X-Request-Id, X-Processing-Time-Ms, X-RateLimit-Limit, X-RateLimit-Remaining, X-RateLimit-Reset, ETag, Idempotent-Replayed")
 
 		if c.Request.Method == "OPTIONS" {
 			c.AbortWithStatus(204)
@@ -181,9 +992,23 @@ func corsMiddleware() gin.HandlerFunc {
 	}
 }
 
-// authMiddleware validates API key
-func authMiddleware(validAPIKey string) gin.HandlerFunc {
+// authMiddleware validates the API key and applies its rate limit. Client
+// IPs that present too many invalid keys are banned by lockout, and each
+// ban is passed to onBan.
+func authMiddleware(keyring *auth.Keyring, lockout *auth.Lockout, onBan func(*gin.Context, *auth.Ban)) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if until, banned := lockout.Banned(c.ClientIP(), time.Now()); banned {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(until).Seconds())+1))
+			c.JSON(429, gin.H{
+				"error": gin.H{
+					"code":    "TOO_MANY_FAILED_ATTEMPTS",
+					"message": "Too many invalid API keys; try again later",
+				},
+			})
+			c.Abort()
+			return
+		}
+
 		apiKey := c.GetHeader("X-API-Key")
 		
 		if apiKey == "" {
@@ -197,7 +1022,11 @@ func authMiddleware(validAPIKey string) gin.HandlerFunc {
 			return
 		}
 
-		if apiKey != validAPIKey {
+		key, ok := keyring.Lookup(apiKey)
+		if !ok {
+			if ban := lockout.Fail(c.ClientIP(), time.Now()); ban != nil {
+				onBan(c, ban)
+			}
 			c.JSON(401, gin.H{
 				"error": gin.H{
 					"code":    "UNAUTHORIZED",
@@ -208,6 +1037,26 @@ func authMiddleware(validAPIKey string) gin.HandlerFunc {
 			return
 		}
 
+		usage, allowed := keyring.Allow(key)
+		if key.RateLimit > 0 {
+			c.Header("X-RateLimit-Limit", strconv.Itoa(key.RateLimit))
+			c.Header("X-RateLimit-Remaining", strconv.Itoa(usage.Remaining))
+			c.Header("X-RateLimit-Reset", strconv.FormatInt(usage.WindowResetsAt.Unix(), 10))
+		}
+
+		if !allowed {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(usage.WindowResetsAt).Seconds())+1))
+			c.JSON(429, gin.H{
+				"error": gin.H{
+					"code":    "RATE_LIMITED",
+					"message": "Rate limit exceeded",
+				},
+			})
+			c.Abort()
+			return
+		}
+
+		c.Set(auth.ContextKey, key)
 		c.Next()
 	}
 }
@@ -219,4 +1068,100 @@ func getEnv(key, defaultValue string) string {
 		return defaultValue
 	}
 	return value
-}
\ No newline at end of file
+}
+
+// getEnvInt gets an integer environment variable with default value
+func getEnvInt(key string, defaultValue int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvFloat gets a float environment variable with default value
+func getEnvFloat(key string, defaultValue float64) float64 {
+	value, err := strconv.ParseFloat(os.Getenv(key), 64)
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvBool gets a boolean environment variable with default value
+func getEnvBool(key string, defaultValue bool) bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// getEnvDuration gets a duration environment variable (e.g. "30s") with default value
+func getEnvDuration(key string, defaultValue time.Duration) time.Duration {
+	value, err := time.ParseDuration(os.Getenv(key))
+	if err != nil {
+		return defaultValue
+	}
+	return value
+}
+
+// optionalEnvInt gets an integer environment variable, or nil when unset
+func optionalEnvInt(key string) *int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// optionalEnvBool gets a boolean environment variable, or nil when unset
+func optionalEnvBool(key string) *bool {
+	value, err := strconv.ParseBool(os.Getenv(key))
+	if err != nil {
+		return nil
+	}
+	return &value
+}
+
+// finishStartup recovers work left by a previous run and checks the
+// Supabase credentials, then marks the handler ready. Storage migrations
+// have already run by the time the listener starts.
+func finishStartup(handler *api.Handler, supabaseClient *supabase.Client, config *Config) {
+	handler.SetStartupPhase(api.StartupRecovery)
+
+	// Fail jobs a previous run did not finish
+	if err := handler.FailInterruptedJobs(); err != nil {
+		log.Printf("Warning: Failed to check for interrupted jobs: %v", err)
+	}
+
+	// Resume remote deletions left pending by a previous run
+	if err := handler.ResumePendingDeletions(); err != nil {
+		log.Printf("Warning: Failed to resume pending deletions: %v", err)
+	}
+
+	handler.SetStartupPhase(api.StartupCredentialCheck)
+
+	// Test Supabase connection
+	if err := supabaseClient.TestConnection(); err != nil {
+		log.Printf("Warning: Cannot connect to Supabase API: %v", err)
+		log.Println("Server will start but project creation will fail")
+	} else {
+		log.Println("✓ Successfully connected to Supabase API")
+	}
+
+	// Learn the shape of the Management API, so renamed or newly required
+	// parameters are sent the way it expects
+	if config.ManagementAPIProbe {
+		probe := supabaseClient.ProbeCapabilities()
+		if probe.Error != "" {
+			log.Printf("Warning: Management API capability probe failed, using built-in request shapes: %s", probe.Error)
+		}
+		for _, warning := range probe.Warnings {
+			log.Printf("Warning: Management API: %s", warning)
+		}
+	}
+
+	handler.SetStartupPhase(api.StartupReady)
+	log.Println("Startup complete; accepting changes")
+}
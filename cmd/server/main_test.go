@@ -0,0 +1,60 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+)
+
+func TestAuthMiddlewareBansInvalidKeys(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	keyring := auth.NewKeyring(0)
+	keyring.Add("test", "valid-key", []string{"*"})
+	lockout := auth.NewLockout(3, time.Minute, time.Minute, time.Hour)
+
+	var bans []*auth.Ban
+	router := gin.New()
+	trustProxies(router, &Config{})
+	router.Use(authMiddleware(keyring, lockout, func(c *gin.Context, ban *auth.Ban) {
+		bans = append(bans, ban)
+	}))
+	router.GET("/api/me", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	request := func(key, forwardedFor string) int {
+		req := httptest.NewRequest(http.MethodGet, "/api/me", nil)
+		req.RemoteAddr = "203.0.113.7:4000"
+		req.Header.Set("X-API-Key", key)
+		if forwardedFor != "" {
+			req.Header.Set("X-Forwarded-For", forwardedFor)
+		}
+		rec := httptest.NewRecorder()
+		router.ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	if code := request("valid-key", ""); code != http.StatusOK {
+		t.Fatalf("valid key: got %d", code)
+	}
+	for i := 0; i < 3; i++ {
+		if code := request("guess", ""); code != http.StatusUnauthorized {
+			t.Fatalf("guess %d: got %d", i+1, code)
+		}
+	}
+	if len(bans) != 1 || bans[0].IP != "203.0.113.7" {
+		t.Fatalf("bans reported: %+v", bans)
+	}
+
+	// A banned IP is refused even with a valid key, and can't claim another
+	// IP while no proxies are trusted
+	if code := request("valid-key", ""); code != http.StatusTooManyRequests {
+		t.Errorf("valid key from banned IP: got %d", code)
+	}
+	if code := request("valid-key", "198.51.100.1"); code != http.StatusTooManyRequests {
+		t.Errorf("banned IP with X-Forwarded-For: got %d", code)
+	}
+}
@@ -0,0 +1,141 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/api"
+	"supabase-manager/internal/supabase"
+)
+
+// timingWriter sets the processing time header just before the response
+// headers are flushed
+type timingWriter struct {
+	gin.ResponseWriter
+	start   time.Time
+	stamped bool
+}
+
+// stamp records the elapsed time once
+func (w *timingWriter) stamp() {
+	if w.stamped || w.ResponseWriter.Written() {
+		return
+	}
+	w.stamped = true
+	elapsed := float64(time.Since(w.start).Microseconds()) / 1000
+	w.ResponseWriter.Header().Set("X-Processing-Time-Ms", fmt.Sprintf("%.3f", elapsed))
+}
+
+func (w *timingWriter) Write(data []byte) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *timingWriter) WriteString(s string) (int, error) {
+	w.stamp()
+	return w.ResponseWriter.WriteString(s)
+}
+
+func (w *timingWriter) WriteHeaderNow() {
+	w.stamp()
+	w.ResponseWriter.WriteHeaderNow()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer, as
+// streamed responses lift the write deadline through it
+func (w *timingWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// requestMetadataMiddleware assigns a request ID and reports processing time
+func requestMetadataMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := c.GetHeader("X-Request-Id")
+		if requestID == "" || len(requestID) > 128 {
+			requestID = uuid.New().String()
+		}
+		c.Set("request_id", requestID)
+		c.Header("X-Request-Id", requestID)
+
+		c.Writer = &timingWriter{ResponseWriter: c.Writer, start: time.Now()}
+		c.Next()
+	}
+}
+
+// startupRetryAfter is the Retry-After sent while the instance is starting
+const startupRetryAfter = "5"
+
+// readinessMiddleware refuses mutating requests with 503 until startup has
+// finished. Reads are served throughout.
+func readinessMiddleware(handler *api.Handler) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		switch c.Request.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			c.Next()
+			return
+		}
+
+		if phase := handler.StartupPhase(); phase != api.StartupReady {
+			c.Header("Retry-After", startupRetryAfter)
+			c.AbortWithStatusJSON(http.StatusServiceUnavailable, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "STARTING_UP",
+					Message: "The server is still starting; retry shortly",
+					Details: "startup phase: " + phase,
+				},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// ipRateLimitMiddleware limits unauthenticated routes to perMinute requests
+// per client IP in fixed one-minute windows; 0 disables the limit. The
+// client IP only comes from forwarding headers sent by TRUSTED_PROXIES.
+// Windows of clients that went quiet are dropped when a new window starts.
+func ipRateLimitMiddleware(perMinute int) gin.HandlerFunc {
+	var mu sync.Mutex
+	windowStart := time.Now()
+	counts := make(map[string]int)
+
+	return func(c *gin.Context) {
+		if perMinute <= 0 {
+			c.Next()
+			return
+		}
+
+		mu.Lock()
+		if time.Since(windowStart) >= time.Minute {
+			windowStart = time.Now()
+			clear(counts)
+		}
+		counts[c.ClientIP()]++
+		count, resetsAt := counts[c.ClientIP()], windowStart.Add(time.Minute)
+		mu.Unlock()
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(perMinute))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(max(perMinute-count, 0)))
+		c.Header("X-RateLimit-Reset", strconv.FormatInt(resetsAt.Unix(), 10))
+
+		if count > perMinute {
+			c.Header("Retry-After", strconv.Itoa(int(time.Until(resetsAt).Seconds())+1))
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "RATE_LIMITED",
+					Message: "Rate limit exceeded",
+				},
+			})
+			return
+		}
+
+		c.Next()
+	}
+}
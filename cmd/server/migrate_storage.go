@@ -0,0 +1,45 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"supabase-manager/internal/storage"
+)
+
+// runMigrateStorage implements the migrate-storage subcommand, copying the
+// SQLite state into a Postgres database
+func runMigrateStorage(args []string) {
+	flags := flag.NewFlagSet("migrate-storage", flag.ExitOnError)
+	target := flags.String("target", os.Getenv("STORAGE_POSTGRES_DSN"), "Postgres connection string to copy into")
+	dryRun := flags.Bool("dry-run", false, "report what would be copied without writing")
+	flags.Parse(args)
+
+	if *target == "" {
+		log.Fatal("migrate-storage: -target (or STORAGE_POSTGRES_DSN) is required")
+	}
+
+	config := loadConfig()
+	store, err := storage.NewSQLiteStorage(config.DBPath)
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	defer store.Close()
+
+	report, err := store.CopyToPostgres(*target, *dryRun)
+	if report != nil {
+		output, _ := json.MarshalIndent(report, "", "  ")
+		fmt.Println(string(output))
+	}
+	if err != nil {
+		log.Fatalf("migrate-storage failed: %v", err)
+	}
+
+	if !report.DryRun && !report.Verified {
+		log.Fatal("migrate-storage: verification failed, some rows are missing in the target")
+	}
+	log.Println("migrate-storage completed")
+}
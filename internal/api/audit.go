@@ -0,0 +1,104 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/pagination"
+	"supabase-manager/internal/supabase"
+)
+
+// audit records an action taken by the caller. Failures are logged rather
+// than failing the request, which has already taken effect.
+func (h *Handler) audit(c *gin.Context, action, projectID, details string) {
+	h.auditAs(c, callerID(c), action, projectID, details)
+}
+
+// auditAs records an action taken by the given actor
+func (h *Handler) auditAs(c *gin.Context, actor, action, projectID, details string) {
+	event := &supabase.AuditEvent{
+		ID:        uuid.New().String(),
+		Action:    action,
+		ProjectID: projectID,
+		Actor:     actor,
+		RequestID: c.GetString("request_id"),
+		ClientIP:  c.ClientIP(),
+		Details:   details,
+		CreatedAt: time.Now().UTC(),
+	}
+	h.recordAudit(event)
+}
+
+// recordAudit stores an audit event, logging failures
+func (h *Handler) recordAudit(event *supabase.AuditEvent) {
+	if err := h.storage.RecordAuditEvent(event); err != nil {
+		fmt.Printf("Warning: Failed to record audit event %s for project %s: %v\n", event.Action, event.ProjectID, err)
+	}
+}
+
+// callerID identifies the calling API key
+func callerID(c *gin.Context) string {
+	if key := auth.FromContext(c); key != nil {
+		return key.ID
+	}
+	return "anonymous"
+}
+
+// checkRecordOrganization checks the caller may see the records of a
+// project, which may have been deleted, like loadProject does for live
+// projects. project is the live project, nil if there is none. Records of
+// purged projects carry no organization and are left to the caller's scope.
+func (h *Handler) checkRecordOrganization(c *gin.Context, projectID string, project *supabase.StoredProject) bool {
+	if project != nil {
+		return checkOrganization(c, project.OrganizationID)
+	}
+	if deleted, err := h.storage.GetDeletedProject(projectID); err == nil {
+		return checkOrganization(c, deleted.OrganizationID)
+	}
+	return true
+}
+
+// ListAuditEvents handles GET /api/projects/:id/audit. Events outlive the
+// project, so deleted and purged projects can still be audited.
+func (h *Handler) ListAuditEvents(c *gin.Context) {
+	projectID := c.Param("id")
+	project, projectErr := h.storage.GetProject(projectID)
+	if !h.checkRecordOrganization(c, projectID, project) {
+		return
+	}
+
+	req, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+
+	events, page, err := h.storage.ListAuditEvents(projectID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list audit events",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if projectErr != nil && len(events) == 0 && req.After == 0 {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_FOUND",
+				Message: "Project not found",
+				Details: projectErr.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Envelope("events", events, page))
+}
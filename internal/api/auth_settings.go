@@ -0,0 +1,93 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// GetAuthSettings handles GET /api/projects/:id/auth/settings
+func (h *Handler) GetAuthSettings(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	settings, err := client.GetAuthSettings(storedProject.ProjectRef)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to get auth settings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// UpdateAuthSettings handles PATCH /api/projects/:id/auth/settings
+func (h *Handler) UpdateAuthSettings(c *gin.Context) {
+	var req supabase.AuthSettings
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_AUTH_SETTINGS",
+				Message: "Invalid auth settings",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "update_auth_settings", gin.H{"project_id": storedProject.ID, "settings": req})
+		return
+	}
+
+	settings, err := client.UpdateAuthSettings(storedProject.ProjectRef, req)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to update auth settings", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, settings)
+}
+
+// applyAuthBaseline applies the auth settings of a newly provisioned
+// project: the configured security baseline, with the settings of its
+// provisioning profile on top
+func (h *Handler) applyAuthBaseline(client *supabase.Client, projectID, projectRef string, settings supabase.AuthSettings) {
+	if settings.IsEmpty() {
+		return
+	}
+
+	if _, err := client.UpdateAuthSettings(projectRef, settings); err != nil {
+		fmt.Printf("Warning: Failed to apply auth baseline to %s: %v\n", projectID, err)
+	}
+}
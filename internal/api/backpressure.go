@@ -0,0 +1,101 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// Retry-After sent with shed requests. Provisions take minutes, so callers
+// are asked to back off longer than for migrations.
+const (
+	provisioningRetryAfter = 30 * time.Second
+	migrationRetryAfter    = 10 * time.Second
+)
+
+// backpressure sheds new create and migrate requests while too much work
+// is already queued, instead of accepting unbounded work
+type backpressure struct {
+	// maxProvisioning and maxMigrationQueue are the depths at which
+	// requests are shed; 0 disables shedding. They can be changed at
+	// runtime.
+	maxProvisioning   atomic.Int64
+	maxMigrationQueue atomic.Int64
+
+	// provisioning counts provisions from the Management API call until
+	// the project is ready or has failed
+	provisioning     atomic.Int64
+	shedProvisioning atomic.Int64
+	shedMigrations   atomic.Int64
+}
+
+// QueueStats describes the work queues and how many requests were shed
+type QueueStats struct {
+	Provisioning      int64 `json:"provisioning"`
+	ProvisioningLimit int64 `json:"provisioning_limit"`
+	// MigrationQueue is the database operations waiting for a pool slot
+	MigrationQueue      int64 `json:"migration_queue"`
+	MigrationQueueLimit int64 `json:"migration_queue_limit"`
+	ShedProvisioning    int64 `json:"shed_provisioning"`
+	ShedMigrations      int64 `json:"shed_migrations"`
+}
+
+// QueueStats returns the depth of the provisioning and migration queues
+func (h *Handler) QueueStats() QueueStats {
+	return QueueStats{
+		Provisioning:        h.backpressure.provisioning.Load(),
+		ProvisioningLimit:   h.backpressure.maxProvisioning.Load(),
+		MigrationQueue:      h.dbPool.queued.Load(),
+		MigrationQueueLimit: h.backpressure.maxMigrationQueue.Load(),
+		ShedProvisioning:    h.backpressure.shedProvisioning.Load(),
+		ShedMigrations:      h.backpressure.shedMigrations.Load(),
+	}
+}
+
+// shedProvisioning responds 503 to a new create request if the
+// provisioning queue is full. Dry runs are never shed.
+func (h *Handler) shedProvisioning(c *gin.Context) bool {
+	bp := &h.backpressure
+	depth, limit := bp.provisioning.Load(), bp.maxProvisioning.Load()
+	if limit == 0 || depth < limit || isDryRun(c) {
+		return false
+	}
+
+	bp.shedProvisioning.Add(1)
+	respondOverloaded(c, provisioningRetryAfter,
+		fmt.Sprintf("%d projects are being provisioned (limit %d)", depth, limit))
+	return true
+}
+
+// shedMigration responds 503 to a new migration if too many database
+// operations are waiting for a pool slot. Dry runs are never shed.
+func (h *Handler) shedMigration(c *gin.Context) bool {
+	bp := &h.backpressure
+	depth, limit := h.dbPool.queued.Load(), bp.maxMigrationQueue.Load()
+	if limit == 0 || depth < limit || isDryRun(c) {
+		return false
+	}
+
+	bp.shedMigrations.Add(1)
+	respondOverloaded(c, migrationRetryAfter,
+		fmt.Sprintf("%d database operations are waiting for a slot (limit %d)", depth, limit))
+	return true
+}
+
+// respondOverloaded writes a 503 asking the caller to retry later
+func respondOverloaded(c *gin.Context, retryAfter time.Duration, details string) {
+	c.Header("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+	c.JSON(http.StatusServiceUnavailable, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    "OVERLOADED",
+			Message: "Too much work is queued; retry later",
+			Details: details,
+		},
+	})
+}
@@ -0,0 +1,324 @@
+package api
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/supabase"
+)
+
+// batchDeletePlanTTL is how long a batch delete plan can be confirmed
+const batchDeletePlanTTL = 10 * time.Minute
+
+// batchDeletePlans holds issued plans by confirmation token until they are
+// confirmed or expire. Plans do not survive a restart.
+type batchDeletePlans struct {
+	mu    sync.Mutex
+	plans map[string]*pendingBatchDelete
+}
+
+// pendingBatchDelete is an issued plan and the caller it was issued to
+type pendingBatchDelete struct {
+	plan   *supabase.BatchDeletePlan
+	caller string
+}
+
+// issue stores a plan under a new confirmation token
+func (p *batchDeletePlans) issue(plan *supabase.BatchDeletePlan, caller string) error {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return fmt.Errorf("failed to generate confirmation token: %w", err)
+	}
+	plan.ConfirmationToken = base64.RawURLEncoding.EncodeToString(buf)
+	plan.ExpiresAt = time.Now().UTC().Add(batchDeletePlanTTL)
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.plans == nil {
+		p.plans = make(map[string]*pendingBatchDelete)
+	}
+	for token, pending := range p.plans {
+		if time.Now().After(pending.plan.ExpiresAt) {
+			delete(p.plans, token)
+		}
+	}
+	p.plans[plan.ConfirmationToken] = &pendingBatchDelete{plan: plan, caller: caller}
+	return nil
+}
+
+// take removes and returns the unexpired plan issued to caller under token
+func (p *batchDeletePlans) take(token, caller string) *supabase.BatchDeletePlan {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	pending, ok := p.plans[token]
+	if !ok || pending.caller != caller {
+		return nil
+	}
+	delete(p.plans, token)
+	if time.Now().After(pending.plan.ExpiresAt) {
+		return nil
+	}
+	return pending.plan
+}
+
+// BatchDeleteProjects handles POST /api/projects/batch-delete. Without a
+// confirmation token it returns the plan of projects matching the label
+// selector and a token; with the token it deletes them in a background job,
+// provided the selector still matches exactly the planned projects.
+func (h *Handler) BatchDeleteProjects(c *gin.Context) {
+	var req supabase.BatchDeleteRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	selector, err := supabase.ParseLabelSelector(req.LabelSelector)
+	if err == nil && selector.String() == "" {
+		err = fmt.Errorf("label_selector must have at least one term")
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid label selector",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	targets, err := h.batchDeleteTargets(c, selector)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list projects",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	for _, project := range targets {
+		if !h.checkFreeze(c, supabase.FreezeDeletes, project) {
+			return
+		}
+	}
+
+	if req.ConfirmationToken == "" {
+		plan := &supabase.BatchDeletePlan{
+			LabelSelector: selector.String(),
+			DeleteRemote:  req.DeleteRemote,
+			Projects:      make([]supabase.BatchDeleteTarget, len(targets)),
+		}
+		for i, project := range targets {
+			plan.Projects[i] = supabase.BatchDeleteTarget{
+				ID:         project.ID,
+				Name:       project.Name,
+				ProjectRef: project.ProjectRef,
+				Status:     project.Status,
+				Labels:     project.Labels,
+				LastKeyUse: h.lastKeyUse(project.ID),
+			}
+		}
+		if err := h.deletePlans.issue(plan, callerID(c)); err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to create batch delete plan",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{
+			"plan":    plan,
+			"message": "Send the request again with confirmation_token to delete these projects",
+		})
+		return
+	}
+
+	plan := h.deletePlans.take(req.ConfirmationToken, callerID(c))
+	if plan == nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_CONFIRMATION_TOKEN",
+				Message: "Confirmation token is unknown, expired or already used",
+				Details: "Request a new plan without confirmation_token",
+			},
+		})
+		return
+	}
+
+	if plan.LabelSelector != selector.String() || plan.DeleteRemote != req.DeleteRemote || !samePlanTargets(plan, targets) {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PLAN_CHANGED",
+				Message: "The request or the matching projects changed since the plan was made",
+				Details: "Request a new plan without confirmation_token",
+			},
+		})
+		return
+	}
+
+	client := h.supabaseClient
+	if needsRemoteClient(targets, req.DeleteRemote) {
+		var byo *supabase.StoredProject
+		for _, project := range targets {
+			if project.BYOCredentials {
+				byo = project
+				break
+			}
+		}
+		var ok bool
+		if client, ok = h.clientFor(c, byo); !ok {
+			return
+		}
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "batch_delete_projects", gin.H{
+			"label_selector": plan.LabelSelector,
+			"delete_remote":  plan.DeleteRemote,
+			"projects":       plan.Projects,
+		})
+		return
+	}
+
+	actor, requestID, clientIP := callerID(c), c.GetString("request_id"), c.ClientIP()
+	job, err := h.startJob(c, "batch_delete", func(logs *jobLog) (interface{}, error) {
+		results := make([]supabase.BatchDeleteResult, len(targets))
+		for i, project := range targets {
+			results[i] = h.batchDeleteProject(client, project, req.DeleteRemote, actor)
+			if results[i].Status == supabase.BatchDeleteFailed {
+				logs.printf("Project %d/%d %s (%s) failed: %s", i+1, len(targets), project.ID, project.Name, results[i].Error)
+				continue
+			}
+			logs.printf("Project %d/%d %s (%s): %s", i+1, len(targets), project.ID, project.Name, results[i].Status)
+			details := fmt.Sprintf("selector %s, %s", plan.LabelSelector, results[i].Status)
+			if results[i].SnapshotID != "" {
+				details += ", snapshot " + results[i].SnapshotID
+			}
+			h.recordAudit(&supabase.AuditEvent{
+				ID:        uuid.New().String(),
+				Action:    "project.batch_deleted",
+				ProjectID: project.ID,
+				Actor:     actor,
+				RequestID: requestID,
+				ClientIP:  clientIP,
+				Details:   details,
+				CreatedAt: time.Now().UTC(),
+			})
+		}
+		return gin.H{"label_selector": plan.LabelSelector, "results": results}, nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to start batch delete",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":  fmt.Sprintf("Deleting %d projects", len(targets)),
+		"projects": len(targets),
+		"job":      job,
+	})
+}
+
+// batchDeleteTargets returns the projects matching a selector that the
+// caller's API key may work on, ordered by ID
+func (h *Handler) batchDeleteTargets(c *gin.Context, selector *supabase.LabelSelector) ([]*supabase.StoredProject, error) {
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var targets []*supabase.StoredProject
+	for _, project := range projects {
+		if selector.Matches(project.Labels) && callerAllowsOrganization(c, project.OrganizationID) {
+			targets = append(targets, project)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool { return targets[i].ID < targets[j].ID })
+	return targets, nil
+}
+
+// batchDeleteProject deletes one project of a batch the way DELETE
+// /api/projects/:id does
+func (h *Handler) batchDeleteProject(client *supabase.Client, project *supabase.StoredProject, deleteRemote bool, actor string) supabase.BatchDeleteResult {
+	result := supabase.BatchDeleteResult{ID: project.ID, Status: supabase.BatchDeleteDeleted}
+
+	// Projects covered by the snapshot policy are not deleted in Supabase
+	// without their snapshot
+	if deleteRemote && h.wantsPreDeleteSnapshot(project, false) {
+		snapshot, err := h.takeSnapshot(context.Background(), project, supabase.SnapshotReasonPreDelete, actor)
+		if err != nil {
+			result.Status = supabase.BatchDeleteFailed
+			result.Error = "pre-delete snapshot failed: " + err.Error()
+			return result
+		}
+		result.SnapshotID = snapshot.ID
+	}
+
+	var err error
+	if deleteRemote || project.Status == "PENDING_DELETION" || project.Status == "DELETION_FAILED" {
+		if err = h.deleteRemote(client, project.ID, project.ProjectRef, project.DeletionAttempts); err != nil {
+			result.Status = supabase.BatchDeletePendingDeletion
+			if !isRetryableDeletion(err) {
+				result.Status = supabase.BatchDeleteFailed
+			}
+		}
+	} else if err = h.deleteLocal(project.ID); err != nil {
+		result.Status = supabase.BatchDeleteFailed
+	}
+	if err != nil {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// samePlanTargets reports whether the planned projects are exactly targets
+func samePlanTargets(plan *supabase.BatchDeletePlan, targets []*supabase.StoredProject) bool {
+	planned := make([]string, len(plan.Projects))
+	for i, project := range plan.Projects {
+		planned[i] = project.ID
+	}
+	current := make([]string, len(targets))
+	for i, project := range targets {
+		current[i] = project.ID
+	}
+	return strings.Join(planned, ",") == strings.Join(current, ",")
+}
+
+// needsRemoteClient reports whether any target will be deleted in Supabase
+func needsRemoteClient(targets []*supabase.StoredProject, deleteRemote bool) bool {
+	for _, project := range targets {
+		if deleteRemote || project.Status == "PENDING_DELETION" || project.Status == "DELETION_FAILED" {
+			return true
+		}
+	}
+	return false
+}
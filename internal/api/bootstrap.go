@@ -0,0 +1,334 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditSecretsSet          = "secrets.set"
+	auditAuthProviderEnabled = "auth_provider.enabled"
+)
+
+// bootstrapFor returns the setup a create request asks for. The profile
+// must have been checked with profileSetup, and the template validated.
+func (h *Handler) bootstrapFor(req *supabase.CreateProjectRequest) supabase.ProjectBootstrap {
+	bootstrap := supabase.ProjectBootstrap{Extensions: slices.Clone(req.EnableExtensions)}
+	if req.WithDefaultBuckets {
+		bootstrap.Buckets = h.defaultBuckets
+	}
+	bootstrap.AddTemplate(req.Template)
+
+	if configured, ok := h.provisioningProfiles[req.Profile]; ok {
+		bootstrap.AuthSettings = configured.AuthSettings
+		bootstrap.MigrationRoles = configured.MigrationRoles
+	}
+
+	profile, err := h.profileSetup(req)
+	if err != nil {
+		fmt.Printf("Warning: Skipping profile %s: %v\n", req.Profile, err)
+		return bootstrap
+	}
+	for _, extension := range profile.Extensions {
+		if !slices.Contains(bootstrap.Extensions, extension) {
+			bootstrap.Extensions = append(bootstrap.Extensions, extension)
+		}
+	}
+	bootstrap.Profile = profile.Profile
+	bootstrap.ProfileSQL = profile.ProfileSQL
+	bootstrap.LogDrains = profile.LogDrains
+	return bootstrap
+}
+
+// profileSetup returns the setup of the provisioning profile a create
+// request asks for, if any: its extensions, SQL and log drains. A
+// configured profile contributes the setup of the built-in profile it
+// names.
+func (h *Handler) profileSetup(req *supabase.CreateProjectRequest) (supabase.ProjectBootstrap, error) {
+	name, options := req.Profile, req.ProfileOptions
+	if configured, ok := h.provisioningProfiles[req.Profile]; ok {
+		name = configured.Setup
+		if options == nil {
+			options = configured.SetupOptions
+		}
+	}
+	if name == "" {
+		if req.Profile != "" && options != nil {
+			return supabase.ProjectBootstrap{}, fmt.Errorf("profile %s does not take profile_options", req.Profile)
+		}
+		return supabase.ProjectBootstrap{}, nil
+	}
+
+	profile, err := supabase.LookupProfile(name)
+	if err != nil {
+		return supabase.ProjectBootstrap{}, err
+	}
+
+	var opts supabase.ProfileOptions
+	if options != nil {
+		opts = *options
+	}
+	profileSQL, err := profile.SetupSQL(opts)
+	if err != nil {
+		return supabase.ProjectBootstrap{}, err
+	}
+
+	setup := supabase.ProjectBootstrap{
+		Extensions: profile.Extensions,
+		Profile:    profile.Name,
+		ProfileSQL: profileSQL,
+	}
+	if profile.LogDrains {
+		setup.LogDrains = opts.LogDrains
+	}
+	return setup, nil
+}
+
+// bootstrapProject creates the requested buckets, log drains, secrets and
+// auth providers in a project that has become ready, enables its
+// extensions, runs its profile and template SQL, then applies its
+// migrations. Each setup step is attempted even if an earlier one fails;
+// the outcome of every step is recorded in the project's setup report.
+// Migrations stop at the first failure, since later ones usually build on
+// it. The outcome of a preview is posted to its comment URL.
+func (h *Handler) bootstrapProject(client *supabase.Client, projectID string, bootstrap supabase.ProjectBootstrap, logs *jobLog) {
+	if bootstrap.IsEmpty() {
+		return
+	}
+
+	storedProject, err := h.storage.GetProject(projectID)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load project %s for bootstrap: %v\n", projectID, err)
+		return
+	}
+	project := storedProject.ToProject()
+	setup := &setupTracker{h: h, projectID: projectID, steps: bootstrap.Steps(), logs: logs}
+
+	for _, bucket := range bootstrap.Buckets {
+		err := client.CreateBucket(project, bucket)
+		if err != nil {
+			fmt.Printf("Warning: Failed to create bucket %s in %s: %v\n", bucket.Name, projectID, err)
+		}
+		setup.done(err)
+	}
+
+	// Drains come first so they ship the logs of the setup SQL
+	for _, drain := range bootstrap.LogDrains {
+		created, err := client.CreateLogDrain(storedProject.ProjectRef, drain)
+		setup.done(err)
+		if err != nil {
+			fmt.Printf("Warning: Failed to create log drain %s in %s: %v\n", drain.Name, projectID, err)
+			continue
+		}
+		h.recordAudit(&supabase.AuditEvent{
+			ID:        uuid.New().String(),
+			Action:    auditLogDrainCreated,
+			ProjectID: projectID,
+			Actor:     "system",
+			Details:   fmt.Sprintf("%s %s (%s), profile %s", drain.Type, drain.Name, created.ID, bootstrap.Profile),
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+
+	if len(bootstrap.Secrets) > 0 {
+		names := strings.Join(supabase.SecretNames(bootstrap.Secrets), ", ")
+		err := client.CreateProjectSecrets(storedProject.ProjectRef, bootstrap.Secrets)
+		setup.done(err)
+		if err != nil {
+			fmt.Printf("Warning: Failed to set secrets in %s: %v\n", projectID, err)
+		} else {
+			h.recordSetupAudit(projectID, auditSecretsSet, names)
+		}
+	}
+
+	for _, provider := range bootstrap.AuthProviders {
+		err := client.EnableAuthProvider(storedProject.ProjectRef, provider)
+		setup.done(err)
+		if err != nil {
+			fmt.Printf("Warning: Failed to enable auth provider %s in %s: %v\n", provider.Provider, projectID, err)
+		} else {
+			h.recordSetupAudit(projectID, auditAuthProviderEnabled, provider.Provider)
+		}
+	}
+
+	if len(bootstrap.Extensions) > 0 {
+		setup.done(h.applyBootstrapSQL(storedProject, "enable extensions", supabase.EnableExtensionsSQL(bootstrap.Extensions)))
+	}
+	if bootstrap.ProfileSQL != "" {
+		setup.done(h.applyBootstrapSQL(storedProject, "profile "+bootstrap.Profile, bootstrap.ProfileSQL))
+	}
+	if bootstrap.TemplateSQL != "" {
+		setup.done(h.applyBootstrapSQL(storedProject, "template", bootstrap.TemplateSQL))
+	}
+
+	var results []supabase.PreviewMigrationResult
+	failed := false
+	for _, migration := range bootstrap.Migrations {
+		if failed {
+			results = append(results, supabase.PreviewMigrationResult{Name: migration.Name, Skipped: true})
+			setup.skip("an earlier migration failed")
+			continue
+		}
+		record := h.applyBootstrapMigration(storedProject, migration)
+		results = append(results, supabase.PreviewMigrationResult{Name: migration.Name, Success: record.Success, Error: record.Error})
+		setup.done(migrationError(record))
+		failed = !record.Success
+	}
+
+	if bootstrap.Comment != nil {
+		report := &supabase.PreviewReport{
+			Event:      supabase.PreviewEventReady,
+			ProjectID:  projectID,
+			ProjectURL: storedProject.ProjectURL,
+			AnonKey:    storedProject.AnonKey,
+			Migrations: results,
+		}
+		if bootstrap.Comment.IncludeServiceKey {
+			report.ServiceKey = storedProject.ServiceKey
+		}
+		h.commentPreview(bootstrap.Comment, report)
+	}
+}
+
+// planSetup stores the steps of a bootstrap as the project's setup report,
+// all pending
+func (h *Handler) planSetup(projectID string, bootstrap supabase.ProjectBootstrap) {
+	if err := h.storage.SaveSetupSteps(projectID, bootstrap.Steps()); err != nil {
+		fmt.Printf("Warning: Failed to save setup steps of %s: %v\n", projectID, err)
+	}
+}
+
+// skipSetup marks the setup steps of a project that will not run as
+// skipped
+func (h *Handler) skipSetup(projectID, reason string) {
+	if err := h.storage.SkipPendingSetupSteps(projectID, reason); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// setupTracker records the outcome of each bootstrap step in the project's
+// setup report. Steps finish in the order ProjectBootstrap.Steps lists
+// them. Outcomes also go to logs.
+type setupTracker struct {
+	h         *Handler
+	projectID string
+	position  int
+	steps     []supabase.SetupStep
+	logs      *jobLog
+}
+
+// done records the outcome of the next step
+func (t *setupTracker) done(err error) {
+	if err != nil {
+		t.finish(supabase.SetupFailed, err.Error())
+		return
+	}
+	t.finish(supabase.SetupApplied, "")
+}
+
+// skip records that the next step did not run
+func (t *setupTracker) skip(reason string) {
+	t.finish(supabase.SetupSkipped, reason)
+}
+
+func (t *setupTracker) finish(status, errMsg string) {
+	t.position++
+	if err := t.h.storage.UpdateSetupStep(t.projectID, t.position, status, errMsg); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+
+	if t.position <= len(t.steps) {
+		step := t.steps[t.position-1]
+		if errMsg != "" {
+			t.logs.printf("Setup %s %s: %s: %s", step.Component, step.Name, status, errMsg)
+		} else {
+			t.logs.printf("Setup %s %s: %s", step.Component, step.Name, status)
+		}
+	}
+}
+
+// recordSetupAudit records a setup change made by the bootstrap
+func (h *Handler) recordSetupAudit(projectID, action, details string) {
+	h.recordAudit(&supabase.AuditEvent{
+		ID:        uuid.New().String(),
+		Action:    action,
+		ProjectID: projectID,
+		Actor:     "system",
+		Details:   details,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// applyBootstrapSQL runs a setup step in a project's database, recording
+// the run in its migration history under name
+func (h *Handler) applyBootstrapSQL(storedProject *supabase.StoredProject, name, setupSQL string) error {
+	return migrationError(h.applyBootstrapMigration(storedProject, supabase.BootstrapMigration{Name: name, SQL: setupSQL}))
+}
+
+// migrationError returns the error of a failed migration record
+func migrationError(record *supabase.MigrationRecord) error {
+	switch {
+	case record.Success:
+		return nil
+	case record.Error != "":
+		return errors.New(record.Error)
+	}
+	return errors.New("migration failed")
+}
+
+// applyBootstrapMigration runs SQL in a project's database and records the
+// run in its migration history. A versioned migration is also recorded in
+// the database's migration table, so applying it again later skips it.
+func (h *Handler) applyBootstrapMigration(storedProject *supabase.StoredProject, migration supabase.BootstrapMigration) *supabase.MigrationRecord {
+	record := &supabase.MigrationRecord{
+		ID:           uuid.New().String(),
+		TargetID:     storedProject.ID,
+		Version:      migration.Version,
+		Name:         migration.Name,
+		AppliedAt:    time.Now().UTC(),
+		Source:       migration.Source,
+		SourceSHA256: migration.SHA256,
+		SQL:          migration.SQL,
+	}
+	if record.Version == "" {
+		record.Version = newMigrationVersion()
+	}
+
+	runner, err := h.newMigrationRunner(storedProject.ToProject())
+	if err != nil {
+		record.Error = err.Error()
+		h.recordMigration(record)
+		fmt.Printf("Warning: Failed to connect to %s to apply %q: %v\n", storedProject.ID, migration.Name, err)
+		return record
+	}
+	defer runner.Close()
+
+	if migration.Version != "" {
+		if err := runner.EnsureMigrationTable(); err != nil {
+			record.Error = err.Error()
+			h.recordMigration(record)
+			fmt.Printf("Warning: Failed to prepare the migration table of %s: %v\n", storedProject.ID, err)
+			return record
+		}
+		runner.SetVersion(migration.Version, migration.Name)
+	}
+
+	result, err := runner.ApplyMigration(migration.SQL)
+	record.Success = result.Success
+	record.StatementsRun = result.StatementsRun
+	record.ExecutionTimeMs = result.ExecutionTime.Milliseconds()
+	record.Error = result.Error
+	h.recordMigration(record)
+
+	if err != nil {
+		fmt.Printf("Warning: Failed to apply %q in %s: %v\n", migration.Name, storedProject.ID, err)
+	}
+	return record
+}
@@ -0,0 +1,293 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// loadProject fetches a project the caller's API key may work on, writing
+// an error response and returning false otherwise. Keys bound to other
+// organizations get 403 ORGANIZATION_FORBIDDEN.
+func (h *Handler) loadProject(c *gin.Context, projectID string) (*supabase.StoredProject, bool) {
+	storedProject, err := h.storage.GetProject(projectID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_FOUND",
+				Message: "Project not found",
+				Details: err.Error(),
+			},
+		})
+		return nil, false
+	}
+
+	if !checkOrganization(c, storedProject.OrganizationID) {
+		return nil, false
+	}
+
+	return storedProject, true
+}
+
+// loadReadyProject fetches a project like loadProject and verifies it is
+// ready, writing an error response and returning false otherwise
+func (h *Handler) loadReadyProject(c *gin.Context, projectID string) (*supabase.StoredProject, bool) {
+	storedProject, ok := h.loadProject(c, projectID)
+	if !ok {
+		return nil, false
+	}
+
+	if storedProject.Status != "ACTIVE_HEALTHY" {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_READY",
+				Message: "Project is not ready yet",
+				Details: fmt.Sprintf("Current status: %s", storedProject.Status),
+			},
+		})
+		return nil, false
+	}
+
+	return storedProject, true
+}
+
+// ListBuckets handles GET /api/projects/:id/buckets
+func (h *Handler) ListBuckets(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	buckets, err := h.supabaseClient.ListBuckets(storedProject.ToProject())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "STORAGE_API_ERROR",
+				Message: "Failed to list buckets",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"buckets": buckets,
+		"total":   len(buckets),
+	})
+}
+
+// CreateBucket handles POST /api/projects/:id/buckets
+func (h *Handler) CreateBucket(c *gin.Context) {
+	var req supabase.BucketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "create_bucket", gin.H{"project_id": storedProject.ID, "bucket": req})
+		return
+	}
+
+	if err := h.supabaseClient.CreateBucket(storedProject.ToProject(), req); err != nil {
+		c.JSON(http.StatusBadGateway, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "STORAGE_API_ERROR",
+				Message: "Failed to create bucket",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message": "Bucket created successfully",
+		"bucket":  req.Name,
+	})
+}
+
+// UpdateBucket handles PUT /api/projects/:id/buckets/:bucket
+func (h *Handler) UpdateBucket(c *gin.Context) {
+	var req supabase.BucketRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	bucketID := c.Param("bucket")
+	if isDryRun(c) {
+		respondDryRun(c, "update_bucket", gin.H{"project_id": storedProject.ID, "bucket_id": bucketID, "bucket": req})
+		return
+	}
+
+	if err := h.supabaseClient.UpdateBucket(storedProject.ToProject(), bucketID, req); err != nil {
+		c.JSON(http.StatusBadGateway, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "STORAGE_API_ERROR",
+				Message: "Failed to update bucket",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bucket updated successfully",
+		"bucket":  bucketID,
+	})
+}
+
+// DeleteBucket handles DELETE /api/projects/:id/buckets/:bucket
+func (h *Handler) DeleteBucket(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	bucketID := c.Param("bucket")
+	if isDryRun(c) {
+		respondDryRun(c, "delete_bucket", gin.H{"project_id": storedProject.ID, "bucket_id": bucketID})
+		return
+	}
+
+	if err := h.supabaseClient.DeleteBucket(storedProject.ToProject(), bucketID); err != nil {
+		c.JSON(http.StatusBadGateway, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "STORAGE_API_ERROR",
+				Message: "Failed to delete bucket",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Bucket deleted successfully",
+		"bucket":  bucketID,
+	})
+}
+
+// ListBucketPolicies handles GET /api/projects/:id/buckets/:bucket/policies
+func (h *Handler) ListBucketPolicies(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	runner, err := h.connectDB(storedProject.ToProject(), false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_ERROR",
+				Message: "Failed to connect to database",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	defer runner.Close()
+
+	policies, err := runner.ListStoragePolicies(c.Param("bucket"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_ERROR",
+				Message: "Failed to list storage policies",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"bucket":   c.Param("bucket"),
+		"policies": policies,
+	})
+}
+
+// CreateBucketPolicy handles POST /api/projects/:id/buckets/:bucket/policies
+func (h *Handler) CreateBucketPolicy(c *gin.Context) {
+	var req supabase.BucketPolicyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	policySQL, err := supabase.BuildBucketPolicySQL(c.Param("bucket"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid storage policy",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	h.runMigration(c, storedProject.ID, storedProject.ToProject(), &supabase.ApplySchemaRequest{
+		SQL:  policySQL,
+		Name: fmt.Sprintf("create storage policy %s on bucket %s", req.Name, c.Param("bucket")),
+	})
+}
+
+// DeleteBucketPolicy handles DELETE /api/projects/:id/buckets/:bucket/policies/:policy
+func (h *Handler) DeleteBucketPolicy(c *gin.Context) {
+	dropSQL, err := supabase.BuildDropBucketPolicySQL(c.Param("bucket"), c.Param("policy"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid storage policy",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	h.runMigration(c, storedProject.ID, storedProject.ToProject(), &supabase.ApplySchemaRequest{
+		SQL:  dropSQL,
+		Name: fmt.Sprintf("drop storage policy %s on bucket %s", c.Param("policy"), c.Param("bucket")),
+	})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"maps"
+	"net/http"
+	"runtime"
+	"slices"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/supabase"
+)
+
+// Deployment describes how this instance was built and started, for
+// GET /api/capabilities
+type Deployment struct {
+	Version string
+	// Mode is live, sandbox, offline or record
+	Mode                    string
+	DeletedProjectRetention time.Duration
+	JobRetention            time.Duration
+	// HealthProbeInterval is 0 when background health probes are off
+	HealthProbeInterval time.Duration
+}
+
+// GetCapabilities handles GET /api/capabilities. It describes what this
+// deployment supports and its limits, so client tooling can adapt at
+// runtime instead of assuming them.
+func (h *Handler) GetCapabilities(c *gin.Context) {
+	quotas := gin.H{}
+	if key := auth.FromContext(c); key != nil {
+		quotas["requests_per_minute"] = key.RateLimit
+		quotas["scopes"] = key.Scopes
+	}
+
+	maxGlobal, maxPerTarget, queueTimeout := h.dbPool.limits()
+	defaults := h.currentDefaults()
+	limits := gin.H{
+		"max_sql_bytes":                  h.maxSQLBytes,
+		"db_max_concurrent":              maxGlobal,
+		"db_max_concurrent_per_project":  maxPerTarget,
+		"db_queue_timeout_seconds":       queueTimeout.Seconds(),
+		"share_link_max_ttl_seconds":     supabase.MaxShareLinkTTL.Seconds(),
+		"list_max_limit":                 listLimits.Max,
+		"batch_delete_plan_ttl_seconds":  batchDeletePlanTTL.Seconds(),
+		"deleted_project_retention_days": int(h.deployment.DeletedProjectRetention.Hours() / 24),
+		"job_retention_days":             int(h.deployment.JobRetention.Hours() / 24),
+		"max_provisioning":               h.backpressure.maxProvisioning.Load(),
+		"max_migration_queue":            h.backpressure.maxMigrationQueue.Load(),
+		"max_verifications":              supabase.MaxVerifications,
+	}
+
+	defaultBuckets := h.defaultBuckets
+	if defaultBuckets == nil {
+		defaultBuckets = []supabase.BucketRequest{}
+	}
+
+	schemaSources := gin.H{"enabled": h.schemaFetcher != nil}
+	if h.schemaFetcher != nil {
+		schemaSources["allowed_hosts"] = h.schemaFetcher.AllowedHosts()
+		schemaSources["max_bytes"] = h.schemaFetcher.MaxBytes()
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version": gin.H{
+			"version":    h.deployment.Version,
+			"go_version": runtime.Version(),
+		},
+		"mode":           h.deployment.Mode,
+		"management_api": h.supabaseClient.APICompatStatus(),
+		"features": gin.H{
+			"byo_credentials":    h.allowBYOCredentials,
+			"ephemeral_db_roles": h.ephemeralDBRoles,
+			"policy_hooks":       h.policy != nil,
+			"schema_sources":     schemaSources,
+			"default_buckets":    defaultBuckets,
+			"slow_provisioning_alerts": gin.H{
+				"enabled": h.provisionAnomalyFactor > 0,
+				"factor":  h.provisionAnomalyFactor,
+			},
+			"profiles":                supabase.Profiles(),
+			"provisioning_profiles":   h.provisioningProfileSummaries(),
+			"template_auth_providers": supabase.AuthProviderNames(),
+			"request_schemas": gin.H{
+				"version": supabase.SchemaVersion,
+				"names":   supabase.SchemaNames(),
+			},
+			"sql_secret_scan": h.sqlSecretScan,
+			"supabase_hooks":  h.hookSecret != "",
+			"remote_orphans":  true,
+			"naming_policy":   defaults.NamingPolicy,
+			"health_probes": gin.H{
+				"enabled":          h.deployment.HealthProbeInterval > 0,
+				"interval_seconds": h.deployment.HealthProbeInterval.Seconds(),
+			},
+			"strict_migrations": gin.H{
+				"always": h.strictMigrations,
+				"limits": h.strictLimits,
+			},
+			"webhooks": gin.H{
+				"events":        supabase.WebhookEvents,
+				"allowed_hosts": slices.Sorted(maps.Keys(h.webhookHosts)),
+			},
+			"pre_delete_snapshots": gin.H{
+				"policy":  h.snapshotPolicy.String(),
+				"schemas": h.snapshotTool.Schemas,
+			},
+			"soft_quotas": gin.H{
+				"projects_per_key":      h.softQuotas.ProjectsPerKey,
+				"organization_capacity": h.softQuotas.OrganizationCapacity,
+				"organization_percent":  h.softQuotas.OrganizationPercent,
+			},
+			"dry_run": true,
+		},
+		"limits": limits,
+		"quotas": quotas,
+		"regions": gin.H{
+			"default":   defaults.Region,
+			"supported": supabase.SupportedRegions,
+		},
+		"postgres_versions": supabase.SupportedPostgresVersions,
+		"plans":             supabase.Plans,
+		"compute_sizes":     supabase.ComputeSizes,
+	})
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// CompareProjects handles GET /api/projects/compare?a=:id&b=:id
+func (h *Handler) CompareProjects(c *gin.Context) {
+	idA := c.Query("a")
+	idB := c.Query("b")
+	if idA == "" || idB == "" {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Both 'a' and 'b' project IDs are required",
+			},
+		})
+		return
+	}
+
+	for _, id := range []string{idA, idB} {
+		if _, ok := h.loadProject(c, id); !ok {
+			return
+		}
+	}
+
+	snapshotA, status, errDetail := h.snapshotProject(idA)
+	if errDetail != nil {
+		c.JSON(status, supabase.ErrorResponse{Error: *errDetail})
+		return
+	}
+
+	snapshotB, status, errDetail := h.snapshotProject(idB)
+	if errDetail != nil {
+		c.JSON(status, supabase.ErrorResponse{Error: *errDetail})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"a":           idA,
+		"b":           idB,
+		"differences": supabase.CompareSnapshots(snapshotA, snapshotB),
+	})
+}
+
+// snapshotProject introspects a stored project's database, returning an HTTP
+// status and error detail on failure. Unchanged schemas come from the
+// schema cache.
+func (h *Handler) snapshotProject(projectID string) (*supabase.SchemaSnapshot, int, *supabase.ErrorDetail) {
+	schema, status, errDetail := h.introspectProject(projectID)
+	if errDetail != nil {
+		return nil, status, errDetail
+	}
+	return schema.snapshot, status, nil
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"crypto/sha256"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	// AccessTokenHeader carries a caller-supplied Supabase access token
+	AccessTokenHeader = "X-Supabase-Access-Token"
+	// OrganizationHeader carries the organization to use with it
+	OrganizationHeader = "X-Supabase-Organization-ID"
+
+	// credentialCacheTTL is how long verified credentials are trusted
+	// before they are checked against the Management API again
+	credentialCacheTTL = 5 * time.Minute
+)
+
+// credentialCache remembers recently verified credentials by hash, so the
+// token itself is never kept
+type credentialCache struct {
+	mu       sync.Mutex
+	verified map[[sha256.Size]byte]time.Time
+}
+
+func newCredentialCache() *credentialCache {
+	return &credentialCache{verified: make(map[[sha256.Size]byte]time.Time)}
+}
+
+func credentialKey(accessToken, organizationID string) [sha256.Size]byte {
+	return sha256.Sum256([]byte(accessToken + "\x00" + organizationID))
+}
+
+func (cc *credentialCache) valid(key [sha256.Size]byte) bool {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	expires, ok := cc.verified[key]
+	if ok && time.Now().After(expires) {
+		delete(cc.verified, key)
+		return false
+	}
+	return ok
+}
+
+func (cc *credentialCache) store(key [sha256.Size]byte) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+
+	now := time.Now()
+	for k, expires := range cc.verified {
+		if now.After(expires) {
+			delete(cc.verified, k)
+		}
+	}
+	cc.verified[key] = now.Add(credentialCacheTTL)
+}
+
+// callerAllowsOrganization reports whether the caller's API key may work in
+// a Supabase organization. Keys not bound to organizations may work in all
+// of them; bound keys never see projects whose organization is unknown.
+func callerAllowsOrganization(c *gin.Context, organizationID string) bool {
+	key := auth.FromContext(c)
+	return key == nil || key.AllowsOrganization(organizationID)
+}
+
+// checkOrganization writes an error response and returns false if the
+// caller's API key may not work in a Supabase organization
+func checkOrganization(c *gin.Context, organizationID string) bool {
+	if callerAllowsOrganization(c, organizationID) {
+		return true
+	}
+
+	details := "organization " + organizationID
+	if organizationID == "" {
+		details = "the project's organization is not known"
+	}
+	c.JSON(http.StatusForbidden, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    "ORGANIZATION_FORBIDDEN",
+			Message: "API key is not allowed in this organization",
+			Details: details,
+		},
+	})
+	return false
+}
+
+// clientFor returns the Management API client for a request: a client using
+// the caller's own credentials when they are supplied, otherwise the
+// manager's. project is the project being operated on, if any. It writes an
+// error response and returns false if the credentials are not acceptable,
+// or the caller's API key is bound to other organizations than the
+// client's or the project's.
+func (h *Handler) clientFor(c *gin.Context, project *supabase.StoredProject) (*supabase.Client, bool) {
+	accessToken := c.GetHeader(AccessTokenHeader)
+	organizationID := c.GetHeader(OrganizationHeader)
+
+	if project != nil && !checkOrganization(c, project.OrganizationID) {
+		return nil, false
+	}
+
+	if accessToken == "" && organizationID == "" {
+		if project != nil && project.BYOCredentials {
+			c.JSON(http.StatusUnauthorized, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "CREDENTIALS_REQUIRED",
+					Message: "Project was created with caller-supplied credentials",
+					Details: "Send " + AccessTokenHeader + " and " + OrganizationHeader,
+				},
+			})
+			return nil, false
+		}
+		if !checkOrganization(c, h.supabaseClient.OrganizationID()) {
+			return nil, false
+		}
+		return h.supabaseClient, true
+	}
+
+	if !h.allowBYOCredentials {
+		c.JSON(http.StatusForbidden, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "BYO_CREDENTIALS_DISABLED",
+				Message: "Caller-supplied Supabase credentials are not enabled",
+			},
+		})
+		return nil, false
+	}
+
+	if accessToken == "" || organizationID == "" {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_CREDENTIALS",
+				Message: "Both " + AccessTokenHeader + " and " + OrganizationHeader + " are required",
+			},
+		})
+		return nil, false
+	}
+
+	if !checkOrganization(c, organizationID) {
+		return nil, false
+	}
+
+	client := h.supabaseClient.WithCredentials(accessToken, organizationID)
+
+	key := credentialKey(accessToken, organizationID)
+	if h.credentials.valid(key) {
+		return client, true
+	}
+
+	found, err := client.HasOrganization()
+	if err != nil || !found {
+		details := "The access token cannot see organization " + organizationID
+		if err != nil {
+			details = err.Error()
+		}
+		c.JSON(http.StatusUnauthorized, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_CREDENTIALS",
+				Message: "Supabase credentials were rejected",
+				Details: details,
+			},
+		})
+		return nil, false
+	}
+
+	h.credentials.store(key)
+	return client, true
+}
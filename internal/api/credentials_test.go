@@ -0,0 +1,90 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/supabase"
+)
+
+// saveOrgProject stores a ready project in a Supabase organization
+func saveOrgProject(t *testing.T, env *testEnv, id, organizationID string) {
+	t.Helper()
+
+	project := readyProject(id)
+	project.OrganizationID = organizationID
+	env.saveProject(t, project)
+}
+
+func TestOrganizationBoundKeyProjects(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	saveOrgProject(t, env, "mine", "org-a")
+	saveOrgProject(t, env, "theirs", "org-b")
+	env.key = &auth.Key{ID: "bound", Scopes: []string{"*"}, Organizations: []string{"org-a"}}
+
+	if rec := env.do(t, http.MethodGet, "/api/projects/mine", nil); rec.Code != http.StatusOK {
+		t.Errorf("get own project: got %d %s", rec.Code, rec.Body.String())
+	}
+	for _, method := range []string{http.MethodGet, http.MethodDelete} {
+		rec := env.do(t, method, "/api/projects/theirs", nil)
+		if rec.Code != http.StatusForbidden || errorCode(t, rec) != "ORGANIZATION_FORBIDDEN" {
+			t.Errorf("%s other organization's project: got %d %s", method, rec.Code, rec.Body.String())
+		}
+	}
+	if _, err := env.store.GetProject("theirs"); err != nil {
+		t.Errorf("other organization's project was deleted: %v", err)
+	}
+
+	rec := env.do(t, http.MethodGet, "/api/projects", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list: got %d %s", rec.Code, rec.Body.String())
+	}
+	projects, _ := decode(t, rec)["projects"].([]interface{})
+	if len(projects) != 1 || projects[0].(map[string]interface{})["id"] != "mine" {
+		t.Errorf("list returned %v, want only the key's organization", projects)
+	}
+}
+
+func TestOrganizationBoundKeyJobs(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	env.router.GET("/api/jobs", env.handler.ListJobs)
+	env.router.GET("/api/jobs/:id", env.handler.GetJob)
+	for _, job := range []*supabase.Job{
+		{ID: "own-job", Type: "batch_delete", Status: supabase.JobSucceeded, CreatedBy: "bound", CreatedAt: time.Now()},
+		{ID: "other-job", Type: "batch_delete", Status: supabase.JobSucceeded, CreatedBy: "admin", CreatedAt: time.Now()},
+	} {
+		if err := env.store.SaveJob(job); err != nil {
+			t.Fatalf("save job: %v", err)
+		}
+	}
+	env.key = &auth.Key{ID: "bound", Scopes: []string{"*"}, Organizations: []string{"org-a"}}
+
+	if rec := env.do(t, http.MethodGet, "/api/jobs/own-job", nil); rec.Code != http.StatusOK {
+		t.Errorf("get own job: got %d %s", rec.Code, rec.Body.String())
+	}
+	rec := env.do(t, http.MethodGet, "/api/jobs/other-job", nil)
+	if rec.Code != http.StatusForbidden || errorCode(t, rec) != "ORGANIZATION_FORBIDDEN" {
+		t.Errorf("get other key's job: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = env.do(t, http.MethodGet, "/api/jobs", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("list jobs: got %d %s", rec.Code, rec.Body.String())
+	}
+	jobs, _ := decode(t, rec)["jobs"].([]interface{})
+	if len(jobs) != 1 || jobs[0].(map[string]interface{})["id"] != "own-job" {
+		t.Errorf("list jobs returned %v, want only the key's jobs", jobs)
+	}
+	rec = env.do(t, http.MethodGet, "/api/jobs?created_by=admin", nil)
+	if rec.Code != http.StatusForbidden {
+		t.Errorf("list another key's jobs: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	// Unbound keys see every job
+	env.key = &auth.Key{ID: "admin", Scopes: []string{"*"}}
+	if rec := env.do(t, http.MethodGet, "/api/jobs/own-job", nil); rec.Code != http.StatusOK {
+		t.Errorf("unbound key get job: got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,175 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/storage"
+	"supabase-manager/internal/supabase"
+)
+
+// RegisterDatabase handles POST /api/databases
+func (h *Handler) RegisterDatabase(c *gin.Context) {
+	var req supabase.RegisterDatabaseRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	// Apply Postgres defaults
+	if req.Port == 0 {
+		req.Port = 5432
+	}
+	if req.SSLMode == "" {
+		req.SSLMode = "require"
+	}
+
+	now := time.Now().UTC()
+	database := &supabase.ExternalDatabase{
+		ID:        uuid.New().String(),
+		Name:      req.Name,
+		Host:      req.Host,
+		Port:      req.Port,
+		Database:  req.Database,
+		User:      req.User,
+		Password:  req.Password,
+		SSLMode:   req.SSLMode,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "register_database", gin.H{"database": database})
+		return
+	}
+
+	if err := h.storage.SaveDatabase(database); err != nil {
+		if errors.Is(err, storage.ErrDatabaseNameExists) {
+			c.JSON(http.StatusConflict, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "DATABASE_EXISTS",
+					Message: "Database already registered",
+					Details: fmt.Sprintf("A database named %s is already registered", database.Name),
+				},
+			})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to register database",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, database)
+}
+
+// ListDatabases handles GET /api/databases
+func (h *Handler) ListDatabases(c *gin.Context) {
+	databases, err := h.storage.ListDatabases()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list databases",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"databases": databases,
+		"total":     len(databases),
+	})
+}
+
+// GetDatabase handles GET /api/databases/:id
+func (h *Handler) GetDatabase(c *gin.Context) {
+	database, err := h.storage.GetDatabase(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, database)
+}
+
+// DeleteDatabase handles DELETE /api/databases/:id
+func (h *Handler) DeleteDatabase(c *gin.Context) {
+	databaseID := c.Param("id")
+
+	if isDryRun(c) {
+		database, err := h.storage.GetDatabase(databaseID)
+		if err != nil {
+			c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "DATABASE_NOT_FOUND",
+					Message: "Database not found",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		respondDryRun(c, "delete_database", gin.H{"database": database})
+		return
+	}
+
+	if err := h.storage.DeleteDatabase(databaseID); err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Database deleted successfully",
+		"id":      databaseID,
+	})
+}
+
+// ApplyDatabaseSchema handles POST /api/databases/:id/schema
+func (h *Handler) ApplyDatabaseSchema(c *gin.Context) {
+	var req supabase.ApplySchemaRequest
+	if !h.bindSchemaRequest(c, &req) {
+		return
+	}
+
+	database, err := h.storage.GetDatabase(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.runSubmittedMigration(c, database.ID, database, &req)
+}
@@ -0,0 +1,406 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// errDBPoolStopped is returned to operations still queued at shutdown
+var errDBPoolStopped = errors.New("shutting down")
+
+// dbPool bounds the database operations running at once, globally and per
+// target database, so a burst of requests cannot exhaust outbound
+// connections or pooler limits. Operations beyond the limits queue until a
+// slot frees up or the queue timeout passes. The limits can be changed
+// while the pool is in use.
+type dbPool struct {
+	global       *semaphore
+	queueTimeout atomic.Int64
+
+	mu        sync.Mutex
+	perTarget int
+	targets   map[string]*targetSlots
+
+	active   atomic.Int64
+	queued   atomic.Int64
+	acquired atomic.Int64
+	timeouts atomic.Int64
+	waitNano atomic.Int64
+}
+
+// targetSlots are the slots of one target database, dropped once unused
+type targetSlots struct {
+	slots *semaphore
+	users int
+	// runners are the open connections holding the target's slots
+	runners map[*supabase.MigrationRunner]struct{}
+}
+
+// DBPoolStats describes the database operation pool
+type DBPoolStats struct {
+	Active int64 `json:"active"`
+	Queued int64 `json:"queued"`
+	// Acquired counts operations that got a slot, Timeouts those that gave
+	// up waiting
+	Acquired    int64   `json:"acquired"`
+	Timeouts    int64   `json:"timeouts"`
+	WaitSeconds float64 `json:"wait_seconds"`
+}
+
+// DBTargetStats describes the operations and connections the manager has
+// against one database
+type DBTargetStats struct {
+	Limit       int               `json:"limit"`
+	Active      int               `json:"active"`
+	Queued      int               `json:"queued"`
+	Connections DBConnectionStats `json:"connections"`
+}
+
+// DBConnectionStats sums the connection pools of the manager's open
+// connections to a database
+type DBConnectionStats struct {
+	Open  int `json:"open"`
+	InUse int `json:"in_use"`
+	Idle  int `json:"idle"`
+	// WaitCount counts waits for a free connection, WaitSeconds their total
+	// duration
+	WaitCount   int64   `json:"wait_count"`
+	WaitSeconds float64 `json:"wait_seconds"`
+}
+
+// newDBPool creates a pool running at most maxGlobal operations at once and
+// at most maxPerTarget against one database. Zero limits default to 1.
+func newDBPool(maxGlobal, maxPerTarget int, queueTimeout time.Duration) *dbPool {
+	if maxGlobal < 1 {
+		maxGlobal = 1
+	}
+	if maxPerTarget < 1 {
+		maxPerTarget = 1
+	}
+	p := &dbPool{
+		global:    newSemaphore(maxGlobal),
+		perTarget: maxPerTarget,
+		targets:   make(map[string]*targetSlots),
+	}
+	p.queueTimeout.Store(int64(queueTimeout))
+	return p
+}
+
+// limits returns the global and per-target limits and the queue timeout
+func (p *dbPool) limits() (maxGlobal, maxPerTarget int, queueTimeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.global.limit(), p.perTarget, time.Duration(p.queueTimeout.Load())
+}
+
+// resize changes the limits. Operations already holding a slot keep it;
+// lowering a limit only holds back new operations until enough finish.
+func (p *dbPool) resize(maxGlobal, maxPerTarget int, queueTimeout time.Duration) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.global.setLimit(maxGlobal)
+	p.perTarget = maxPerTarget
+	for _, ts := range p.targets {
+		ts.slots.setLimit(maxPerTarget)
+	}
+	p.queueTimeout.Store(int64(queueTimeout))
+}
+
+// acquire waits for a slot for an operation against target, taking the
+// target's slot before the global one so a busy database does not hold
+// global slots while it queues. The returned function releases the slot.
+func (p *dbPool) acquire(target string, stop <-chan struct{}) (func(), error) {
+	p.queued.Add(1)
+	started := time.Now()
+	defer func() {
+		p.queued.Add(-1)
+		p.waitNano.Add(int64(time.Since(started)))
+	}()
+
+	var timeout <-chan time.Time
+	queueTimeout := time.Duration(p.queueTimeout.Load())
+	if queueTimeout > 0 {
+		timer := time.NewTimer(queueTimeout)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+
+	ts := p.targetSlots(target)
+	if err := ts.slots.acquire(timeout, stop); err != nil {
+		p.releaseTarget(target, false)
+		return nil, p.acquireError(err, queueTimeout)
+	}
+	if err := p.global.acquire(timeout, stop); err != nil {
+		p.releaseTarget(target, true)
+		return nil, p.acquireError(err, queueTimeout)
+	}
+
+	p.acquired.Add(1)
+	p.active.Add(1)
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			p.active.Add(-1)
+			p.global.release()
+			p.releaseTarget(target, true)
+		})
+	}, nil
+}
+
+// acquireError turns a failed wait for a slot into the error returned to
+// the operation
+func (p *dbPool) acquireError(err error, queueTimeout time.Duration) error {
+	if err == errSemaphoreTimeout {
+		p.timeouts.Add(1)
+		return fmt.Errorf("database operation queue is full: no slot within %s", queueTimeout)
+	}
+	return errDBPoolStopped
+}
+
+// targetSlots returns the slots of a target, registering one more user
+func (p *dbPool) targetSlots(target string) *targetSlots {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ts, ok := p.targets[target]
+	if !ok {
+		ts = &targetSlots{
+			slots:   newSemaphore(p.perTarget),
+			runners: make(map[*supabase.MigrationRunner]struct{}),
+		}
+		p.targets[target] = ts
+	}
+	ts.users++
+	return ts
+}
+
+// releaseTarget unregisters a user of a target, freeing its slot if it held
+// one
+func (p *dbPool) releaseTarget(target string, held bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	ts := p.targets[target]
+	if held {
+		ts.slots.release()
+	}
+	ts.users--
+	if ts.users == 0 {
+		delete(p.targets, target)
+	}
+}
+
+// track registers an open connection holding one of target's slots. The
+// returned function unregisters it.
+func (p *dbPool) track(target string, runner *supabase.MigrationRunner) func() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.targets[target].runners[runner] = struct{}{}
+	return func() {
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		delete(p.targets[target].runners, runner)
+	}
+}
+
+// targetStats returns a snapshot of the operations and connections against
+// a target
+func (p *dbPool) targetStats(target string) DBTargetStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	stats := DBTargetStats{Limit: p.perTarget}
+	ts, ok := p.targets[target]
+	if !ok {
+		return stats
+	}
+
+	stats.Active = ts.slots.held()
+	stats.Queued = ts.users - stats.Active
+	for runner := range ts.runners {
+		pool := runner.PoolStats()
+		stats.Connections.Open += pool.OpenConnections
+		stats.Connections.InUse += pool.InUse
+		stats.Connections.Idle += pool.Idle
+		stats.Connections.WaitCount += pool.WaitCount
+		stats.Connections.WaitSeconds += pool.WaitDuration.Seconds()
+	}
+	return stats
+}
+
+// stats returns a snapshot of the pool counters
+func (p *dbPool) stats() DBPoolStats {
+	return DBPoolStats{
+		Active:      p.active.Load(),
+		Queued:      p.queued.Load(),
+		Acquired:    p.acquired.Load(),
+		Timeouts:    p.timeouts.Load(),
+		WaitSeconds: time.Duration(p.waitNano.Load()).Seconds(),
+	}
+}
+
+// DBPoolStats returns the state of the database operation pool
+func (h *Handler) DBPoolStats() DBPoolStats {
+	return h.dbPool.stats()
+}
+
+// connectDB opens a runner for a target once the pool has a slot for it.
+// The slot is released when the runner is closed. ephemeral connects as a
+// temporary role when ephemeral database roles are enabled.
+func (h *Handler) connectDB(target supabase.DatabaseTarget, ephemeral bool) (*supabase.MigrationRunner, error) {
+	key := target.GetDatabaseConnectionString()
+	release, err := h.dbPool.acquire(key, h.stop)
+	if err != nil {
+		return nil, err
+	}
+
+	var runner *supabase.MigrationRunner
+	if ephemeral && h.ephemeralDBRoles {
+		runner, err = supabase.NewEphemeralMigrationRunner(target)
+	} else {
+		runner, err = supabase.NewMigrationRunner(target)
+	}
+	if err != nil {
+		release()
+		return nil, err
+	}
+
+	runner.OnClose(h.dbPool.track(key, runner))
+	runner.OnClose(release)
+	return runner, nil
+}
+
+// GetProjectDBPool handles GET /api/projects/:id/db-pool. It reports the
+// manager's operations and connections against the project's database, the
+// connections the database sees from every client and the pooler
+// configuration, to diagnose "too many connections" errors.
+func (h *Handler) GetProjectDBPool(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	// Taken before connecting, so the check's own connection is not counted
+	project := storedProject.ToProject()
+	response := gin.H{
+		"project_id": storedProject.ID,
+		"manager":    h.dbPool.targetStats(project.GetDatabaseConnectionString()),
+	}
+
+	if runner, err := h.connectDB(project, false); err != nil {
+		response["database"] = gin.H{"error": err.Error()}
+	} else {
+		stats, err := runner.ConnectionStats()
+		runner.Close()
+		if err != nil {
+			response["database"] = gin.H{"error": err.Error()}
+		} else {
+			response["database"] = stats
+		}
+	}
+
+	if poolers, err := client.GetPoolerConfig(storedProject.ProjectRef); err != nil {
+		response["pooler"] = gin.H{"error": err.Error()}
+	} else {
+		response["pooler"] = poolers
+	}
+
+	response["checked_at"] = time.Now().UTC()
+	c.JSON(http.StatusOK, response)
+}
+
+// errSemaphoreTimeout and errSemaphoreStopped end a wait for a semaphore
+var (
+	errSemaphoreTimeout = errors.New("timed out")
+	errSemaphoreStopped = errors.New("stopped")
+)
+
+// semaphore is a counting semaphore whose limit can change while slots are
+// held
+type semaphore struct {
+	mu    sync.Mutex
+	max   int
+	taken int
+	// freed is closed, and replaced, whenever a slot may have become
+	// available
+	freed chan struct{}
+}
+
+// newSemaphore creates a semaphore with limit slots
+func newSemaphore(limit int) *semaphore {
+	return &semaphore{max: limit, freed: make(chan struct{})}
+}
+
+// acquire waits for a slot until timeout fires or stop is closed
+func (s *semaphore) acquire(timeout <-chan time.Time, stop <-chan struct{}) error {
+	for {
+		s.mu.Lock()
+		if s.taken < s.max {
+			s.taken++
+			s.mu.Unlock()
+			return nil
+		}
+		freed := s.freed
+		s.mu.Unlock()
+
+		select {
+		case <-freed:
+		case <-timeout:
+			return errSemaphoreTimeout
+		case <-stop:
+			return errSemaphoreStopped
+		}
+	}
+}
+
+// release frees a slot
+func (s *semaphore) release() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.taken--
+	s.wake()
+}
+
+// setLimit changes the number of slots
+func (s *semaphore) setLimit(limit int) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.max = limit
+	s.wake()
+}
+
+// wake lets waiters check for a free slot again. s.mu must be held.
+func (s *semaphore) wake() {
+	close(s.freed)
+	s.freed = make(chan struct{})
+}
+
+// limit returns the number of slots
+func (s *semaphore) limit() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.max
+}
+
+// held returns the number of slots taken
+func (s *semaphore) held() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.taken
+}
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	deleteRetryInitialDelay = 30 * time.Second
+	deleteRetryMaxDelay     = 30 * time.Minute
+)
+
+// deleteRemote deletes a project from Supabase and then locally. If the
+// remote delete fails the project is marked PENDING_DELETION and retried in
+// the background, unless retrying cannot help, in which case it is marked
+// DELETION_FAILED. It returns the remote error, if any.
+func (h *Handler) deleteRemote(client *supabase.Client, projectID, projectRef string, attempts int) error {
+	err := client.DeleteProject(projectRef)
+	if err == nil {
+		return h.deleteLocal(projectID)
+	}
+
+	attempts++
+	if !isRetryableDeletion(err) {
+		h.failDeletion(projectID, attempts, err)
+		return err
+	}
+	if recordErr := h.storage.RecordDeletionAttempt(projectID, attempts, err.Error()); recordErr != nil {
+		fmt.Printf("Warning: Failed to record deletion attempt for %s: %v\n", projectID, recordErr)
+	} else if attempts == 1 {
+		h.recordStatus(projectID, "PENDING_DELETION")
+	}
+	h.scheduleRemoteDeletion(client, projectID, projectRef, attempts)
+
+	return err
+}
+
+// isRetryableDeletion reports whether a failed remote delete may succeed
+// later. Management API errors blaming the request, such as a token that
+// may not delete the project, will not.
+func isRetryableDeletion(err error) bool {
+	var apiErr *supabase.APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+	if apiErr.StatusCode == http.StatusTooManyRequests {
+		return true
+	}
+	return apiErr.StatusCode < 400 || apiErr.StatusCode >= 500
+}
+
+// deletionStatus is the status a project is left in after its remote
+// delete failed with err
+func deletionStatus(err error) string {
+	if isRetryableDeletion(err) {
+		return "PENDING_DELETION"
+	}
+	return "DELETION_FAILED"
+}
+
+// failDeletion marks a project DELETION_FAILED, stopping background
+// retries until the project is deleted again
+func (h *Handler) failDeletion(projectID string, attempts int, err error) {
+	fmt.Printf("Remote deletion of %s failed and will not be retried (attempt %d): %v\n", projectID, attempts, err)
+	if recordErr := h.storage.RecordDeletionFailure(projectID, attempts, err.Error()); recordErr != nil {
+		fmt.Printf("Warning: Failed to record deletion failure for %s: %v\n", projectID, recordErr)
+		return
+	}
+	h.recordStatus(projectID, "DELETION_FAILED")
+}
+
+// deleteLocal deletes the stored project and tells webhooks it is gone
+func (h *Handler) deleteLocal(projectID string) error {
+	project, err := h.storage.GetProject(projectID)
+	if err != nil {
+		return err
+	}
+	if err := h.storage.DeleteProject(projectID); err != nil {
+		return err
+	}
+	h.notifyWebhooks(project, supabase.WebhookPayload{Type: supabase.WebhookEventProjectDeleted})
+	return nil
+}
+
+// scheduleRemoteDeletion starts a background retry loop for a project unless
+// one is already running
+func (h *Handler) scheduleRemoteDeletion(client *supabase.Client, projectID, projectRef string, attempts int) {
+	if _, running := h.deleting.LoadOrStore(projectID, true); running {
+		return
+	}
+
+	h.runBackground(func() {
+		defer h.deleting.Delete(projectID)
+		h.retryRemoteDeletion(client, projectID, projectRef, attempts)
+	})
+}
+
+// retryRemoteDeletion retries a remote delete with exponential backoff until
+// it succeeds, fails in a way retrying cannot fix, or the handler shuts
+// down. Pending deletions are resumed at the next start.
+func (h *Handler) retryRemoteDeletion(client *supabase.Client, projectID, projectRef string, attempts int) {
+	delay := deleteRetryInitialDelay
+
+	for {
+		if !h.sleepOrStop(delay) {
+			return
+		}
+
+		// Stop if the project was deleted by another request meanwhile
+		if _, err := h.storage.GetProject(projectID); err != nil {
+			return
+		}
+
+		err := client.DeleteProject(projectRef)
+		if err == nil {
+			if err := h.deleteLocal(projectID); err != nil {
+				fmt.Printf("Error deleting project %s locally: %v\n", projectID, err)
+			}
+			fmt.Printf("Deleted project %s from Supabase after %d failed attempts\n", projectID, attempts)
+			return
+		}
+
+		attempts++
+		if !isRetryableDeletion(err) {
+			h.failDeletion(projectID, attempts, err)
+			return
+		}
+		fmt.Printf("Remote deletion of %s failed (attempt %d): %v\n", projectID, attempts, err)
+		if err := h.storage.RecordDeletionAttempt(projectID, attempts, err.Error()); err != nil {
+			fmt.Printf("Warning: Failed to record deletion attempt for %s: %v\n", projectID, err)
+		}
+
+		delay *= 2
+		if delay > deleteRetryMaxDelay {
+			delay = deleteRetryMaxDelay
+		}
+	}
+}
+
+// ResumePendingDeletions restarts the retry loop for every project left in
+// PENDING_DELETION by a previous run. Projects created with caller-supplied
+// credentials wait for the caller to send DELETE again, since their
+// credentials were not kept, and so do DELETION_FAILED projects.
+func (h *Handler) ResumePendingDeletions() error {
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		return err
+	}
+
+	for _, project := range projects {
+		if project.Status == "PENDING_DELETION" && !project.BYOCredentials {
+			h.scheduleRemoteDeletion(h.supabaseClient, project.ID, project.ProjectRef, project.DeletionAttempts)
+		}
+	}
+
+	return nil
+}
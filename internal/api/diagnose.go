@@ -0,0 +1,288 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/supabase"
+)
+
+// stuckProvisioningAfter is how long a project may stay in a provisioning
+// state before it is reported as stuck
+const stuckProvisioningAfter = 15 * time.Minute
+
+// Diagnostic check results
+const (
+	checkOK      = "ok"
+	checkWarning = "warning"
+	checkFailed  = "failed"
+	checkSkipped = "skipped"
+)
+
+// diagnosticCheck is the outcome of one diagnostic layer
+type diagnosticCheck struct {
+	Name    string `json:"name"`
+	Result  string `json:"result"`
+	Message string `json:"message"`
+}
+
+// diagnosticProblem is a likely problem with suggested remediation
+type diagnosticProblem struct {
+	Severity    string   `json:"severity"`
+	Title       string   `json:"title"`
+	Detail      string   `json:"detail,omitempty"`
+	Remediation []string `json:"remediation"`
+}
+
+// severityRank orders problems most severe first
+var severityRank = map[string]int{
+	notify.SeverityCritical: 0,
+	notify.SeverityWarning:  1,
+	notify.SeverityInfo:     2,
+}
+
+// diagnosis collects checks and problems for one project
+type diagnosis struct {
+	checks   []diagnosticCheck
+	problems []diagnosticProblem
+}
+
+func (d *diagnosis) check(name, result, message string) {
+	d.checks = append(d.checks, diagnosticCheck{Name: name, Result: result, Message: message})
+}
+
+func (d *diagnosis) problem(severity, title, detail string, remediation ...string) {
+	d.problems = append(d.problems, diagnosticProblem{
+		Severity:    severity,
+		Title:       title,
+		Detail:      detail,
+		Remediation: remediation,
+	})
+}
+
+// DiagnoseProject handles GET /api/projects/:id/diagnose. It runs layered
+// checks against a project and returns the likely problems, most severe
+// first, with suggested remediation for support engineers.
+func (h *Handler) DiagnoseProject(c *gin.Context) {
+	project, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, project)
+	if !ok {
+		return
+	}
+
+	d := &diagnosis{}
+	h.diagnoseLocalStatus(d, project)
+	remoteFound := h.diagnoseRemote(d, client, project)
+	h.diagnoseKeys(d, project)
+	h.diagnoseDatabase(d, project, remoteFound)
+	h.diagnoseMigrations(d, project)
+	h.diagnoseIncidents(d, client)
+
+	sort.SliceStable(d.problems, func(i, j int) bool {
+		return severityRank[d.problems[i].Severity] < severityRank[d.problems[j].Severity]
+	})
+
+	healthy := true
+	for _, p := range d.problems {
+		if p.Severity != notify.SeverityInfo {
+			healthy = false
+		}
+	}
+
+	if d.problems == nil {
+		d.problems = []diagnosticProblem{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": project.ID,
+		"status":     project.Status,
+		"healthy":    healthy,
+		"checked_at": time.Now().UTC(),
+		"problems":   d.problems,
+		"checks":     d.checks,
+	})
+}
+
+// diagnoseLocalStatus looks at the status recorded by the manager
+func (h *Handler) diagnoseLocalStatus(d *diagnosis, project *supabase.StoredProject) {
+	switch project.Status {
+	case "ACTIVE_HEALTHY":
+		d.check("local_status", checkOK, "Project is ACTIVE_HEALTHY")
+	case "FAILED":
+		d.check("local_status", checkFailed, "Provisioning failed")
+		d.problem(notify.SeverityCritical, "Provisioning failed",
+			"The project did not become healthy within the provisioning timeout",
+			"Check the project in the Supabase dashboard",
+			fmt.Sprintf("Delete it with DELETE /api/projects/%s?delete_remote=true and create it again", project.ID))
+	case "PENDING_DELETION":
+		d.check("local_status", checkWarning, "Remote deletion has not succeeded yet")
+		d.problem(notify.SeverityWarning, "Remote deletion pending",
+			fmt.Sprintf("%d failed attempts, last error: %s", project.DeletionAttempts, project.DeletionError),
+			"Deletion is retried in the background with backoff",
+			fmt.Sprintf("Retry now with DELETE /api/projects/%s", project.ID),
+			"Check that the access token may delete projects in the organization")
+	case "DELETION_FAILED":
+		d.check("local_status", checkFailed, "Remote deletion failed and is not retried")
+		d.problem(notify.SeverityCritical, "Remote deletion failed",
+			fmt.Sprintf("%d failed attempts, last error: %s", project.DeletionAttempts, project.DeletionError),
+			"Supabase refused the delete, so it is not retried in the background",
+			"Check that the access token may delete projects in the organization",
+			fmt.Sprintf("Retry with DELETE /api/projects/%s once the cause is fixed", project.ID))
+	case "ACTIVE_PENDING_KEYS":
+		d.check("local_status", checkWarning, "Project is up but its API keys were not fetched")
+	default:
+		age := time.Since(project.CreatedAt)
+		if age > stuckProvisioningAfter {
+			d.check("local_status", checkFailed, fmt.Sprintf("Still %s after %s", project.Status, age.Round(time.Minute)))
+			d.problem(notify.SeverityCritical, "Provisioning appears stuck",
+				fmt.Sprintf("Project has been %s for %s", project.Status, age.Round(time.Minute)),
+				"Compare with the remote status below; the manager may have stopped polling after a restart",
+				"Check the project in the Supabase dashboard")
+			return
+		}
+		d.check("local_status", checkOK, fmt.Sprintf("Provisioning (%s), started %s ago", project.Status, age.Round(time.Second)))
+		d.problem(notify.SeverityInfo, "Project is still provisioning",
+			"New projects usually take a few minutes to come up",
+			fmt.Sprintf("Poll GET /api/projects/%s", project.ID))
+	}
+}
+
+// diagnoseRemote checks the project in the Management API. It reports
+// whether the project exists remotely.
+func (h *Handler) diagnoseRemote(d *diagnosis, client *supabase.Client, project *supabase.StoredProject) bool {
+	remote, err := client.GetProject(project.ProjectRef)
+	if err != nil {
+		if strings.Contains(err.Error(), "project not found") {
+			d.check("remote_exists", checkFailed, "Project does not exist in Supabase")
+			d.check("remote_status", checkSkipped, "Project does not exist in Supabase")
+			d.problem(notify.SeverityCritical, "Project missing in Supabase",
+				fmt.Sprintf("Project ref %s was not found by the Management API", project.ProjectRef),
+				"The project was probably deleted outside the manager",
+				fmt.Sprintf("Remove the local record with DELETE /api/projects/%s", project.ID))
+			return false
+		}
+
+		d.check("remote_exists", checkWarning, err.Error())
+		d.check("remote_status", checkSkipped, "Management API request failed")
+		d.problem(notify.SeverityWarning, "Management API request failed", err.Error(),
+			"Check the manager's access token and network access to api.supabase.com")
+		return true
+	}
+	d.check("remote_exists", checkOK, "Project exists in Supabase")
+
+	switch {
+	case remote.Status == "INACTIVE" || remote.Status == "PAUSED":
+		d.check("remote_status", checkFailed, "Project is "+remote.Status)
+		d.problem(notify.SeverityCritical, "Project is paused",
+			"Supabase reports the project as "+remote.Status,
+			"Restore the project from the Supabase dashboard")
+	case remote.Status == "ACTIVE_HEALTHY" && project.Status != "ACTIVE_HEALTHY" && project.Status != "PENDING_DELETION" && project.Status != "DELETION_FAILED":
+		d.check("remote_status", checkWarning, fmt.Sprintf("Supabase reports ACTIVE_HEALTHY but the local status is %s", project.Status))
+		d.problem(notify.SeverityWarning, "Local status is out of date",
+			fmt.Sprintf("Supabase reports ACTIVE_HEALTHY, the manager has %s", project.Status),
+			"Polling stops if the manager restarts during provisioning",
+			fmt.Sprintf("Delete it with DELETE /api/projects/%s?delete_remote=true and create it again", project.ID))
+	case remote.IsReady():
+		d.check("remote_status", checkOK, "Project is ACTIVE_HEALTHY")
+	default:
+		d.check("remote_status", checkWarning, "Project is "+remote.Status)
+	}
+
+	return true
+}
+
+// diagnoseKeys checks that the API keys were stored
+func (h *Handler) diagnoseKeys(d *diagnosis, project *supabase.StoredProject) {
+	if project.AnonKey != "" && project.ServiceKey != "" {
+		d.check("api_keys", checkOK, "Anon and service keys are stored")
+		return
+	}
+
+	if project.Status != "ACTIVE_HEALTHY" && project.Status != "ACTIVE_PENDING_KEYS" {
+		d.check("api_keys", checkSkipped, "Keys are fetched once the project is ready")
+		return
+	}
+
+	d.check("api_keys", checkFailed, "API keys are missing")
+	d.problem(notify.SeverityWarning, "API keys missing",
+		"Storage, bucket and client operations need the project's keys",
+		"Keys are retried in the background for a while after provisioning",
+		"Check that the access token may read the project's API keys")
+}
+
+// diagnoseDatabase checks that the project's database accepts connections
+func (h *Handler) diagnoseDatabase(d *diagnosis, project *supabase.StoredProject, remoteFound bool) {
+	if !remoteFound || (project.Status != "ACTIVE_HEALTHY" && project.Status != "ACTIVE_PENDING_KEYS") {
+		d.check("database", checkSkipped, "Database is only checked for active projects")
+		return
+	}
+
+	runner, err := h.connectDB(project.ToProject(), false)
+	if err != nil {
+		d.check("database", checkFailed, err.Error())
+		d.problem(notify.SeverityCritical, "Database unreachable", err.Error(),
+			"Check that the stored database password is still valid",
+			"Check network restrictions and SSL enforcement in the Supabase dashboard")
+		return
+	}
+	runner.Close()
+
+	d.check("database", checkOK, "Database accepts connections")
+}
+
+// diagnoseMigrations looks at the most recent migration
+func (h *Handler) diagnoseMigrations(d *diagnosis, project *supabase.StoredProject) {
+	records, err := h.storage.ListMigrations(project.ID)
+	if err != nil {
+		d.check("last_migration", checkSkipped, err.Error())
+		return
+	}
+	if len(records) == 0 {
+		d.check("last_migration", checkOK, "No migrations applied")
+		return
+	}
+
+	last := records[0]
+	if last.Success {
+		d.check("last_migration", checkOK, fmt.Sprintf("Migration %s succeeded", last.Version))
+		return
+	}
+
+	d.check("last_migration", checkFailed, fmt.Sprintf("Migration %s failed", last.Version))
+	d.problem(notify.SeverityWarning, "Last migration failed", last.Error,
+		fmt.Sprintf("Fetch the script with GET /api/projects/%s/migrations/%s/sql", project.ID, last.Version),
+		"Fix the SQL and submit it again; failed migrations are rolled back")
+}
+
+// diagnoseIncidents checks the Supabase status page for open incidents
+func (h *Handler) diagnoseIncidents(d *diagnosis, client *supabase.Client) {
+	incidents, err := client.UnresolvedIncidents()
+	if err != nil {
+		d.check("supabase_incidents", checkSkipped, err.Error())
+		return
+	}
+	if len(incidents) == 0 {
+		d.check("supabase_incidents", checkOK, "No open incidents on the Supabase status page")
+		return
+	}
+
+	names := make([]string, len(incidents))
+	for i, incident := range incidents {
+		names[i] = fmt.Sprintf("%s (%s)", incident.Name, incident.Status)
+	}
+
+	d.check("supabase_incidents", checkWarning, fmt.Sprintf("%d open incidents", len(incidents)))
+	d.problem(notify.SeverityWarning, "Supabase incident in progress", strings.Join(names, "; "),
+		"Check https://status.supabase.com before digging further",
+		"Wait for the incident to be resolved and run the diagnosis again")
+}
@@ -0,0 +1,80 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const auditDRExported = "dr.exported"
+
+// ExportDRCredentials handles GET /api/admin/dr-export. It returns the
+// minimal credential set of every project, or of the projects named with
+// repeated project_id parameters, sealed with the DR key for break-glass
+// recovery.
+func (h *Handler) ExportDRCredentials(c *gin.Context) {
+	if h.drKey == nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DR_EXPORT_DISABLED",
+				Message: "Disaster recovery exports are not configured",
+				Details: "set DR_EXPORT_KEY to enable them",
+			},
+		})
+		return
+	}
+
+	var projects []*supabase.StoredProject
+	if ids := c.QueryArray("project_id"); len(ids) > 0 {
+		for _, id := range ids {
+			project, err := h.storage.GetProject(id)
+			if err != nil {
+				c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+					Error: supabase.ErrorDetail{
+						Code:    "PROJECT_NOT_FOUND",
+						Message: "Project not found",
+						Details: fmt.Sprintf("%s: %v", id, err),
+					},
+				})
+				return
+			}
+			projects = append(projects, project)
+		}
+	} else {
+		var err error
+		projects, err = h.storage.ListProjects()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to list projects",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	export, err := supabase.NewDRExport(h.drKey, projects, time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to seal credentials",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	for _, project := range projects {
+		h.audit(c, auditDRExported, project.ID, "key "+export.KeyID)
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, export)
+}
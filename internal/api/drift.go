@@ -0,0 +1,205 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// driftConcurrency is how many projects a drift report introspects at once
+const driftConcurrency = 4
+
+// CreateDriftReport handles POST /api/drift-report. It compares every
+// project matching a label selector against a canonical schema, given as
+// SQL or as a reference project, in a background job.
+func (h *Handler) CreateDriftReport(c *gin.Context) {
+	var req supabase.DriftReportRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if (req.CanonicalSQL == "") == (req.ReferenceProjectID == "") {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Exactly one of canonical_sql and reference_project_id is required",
+			},
+		})
+		return
+	}
+
+	selector, err := supabase.ParseLabelSelector(req.LabelSelector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid label selector",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	report := &supabase.DriftReport{
+		LabelSelector: selector.String(),
+		Projects:      []supabase.ProjectDrift{},
+	}
+
+	var canonical *supabase.SchemaSnapshot
+	if req.CanonicalSQL != "" {
+		var ignored int
+		canonical, ignored, err = supabase.ParseSchemaSQL(req.CanonicalSQL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_CANONICAL_SCHEMA",
+					Message: "Failed to parse canonical_sql",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		report.Canonical = "sql"
+		report.IgnoredStatements = ignored
+	} else {
+		if _, ok := h.loadReadyProject(c, req.ReferenceProjectID); !ok {
+			return
+		}
+		report.Canonical = "project:" + req.ReferenceProjectID
+	}
+
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list projects",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	var targets []*supabase.StoredProject
+	for _, project := range projects {
+		if project.ID != req.ReferenceProjectID && selector.Matches(project.Labels) && callerAllowsOrganization(c, project.OrganizationID) {
+			targets = append(targets, project)
+		}
+	}
+
+	if len(targets) == 0 {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "NO_MATCHING_PROJECTS",
+				Message: "No projects match the label selector",
+				Details: fmt.Sprintf("label_selector: %q", report.LabelSelector),
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		ids := make([]string, len(targets))
+		for i, project := range targets {
+			ids[i] = project.ID
+		}
+		respondDryRun(c, "create_drift_report", gin.H{
+			"canonical":   report.Canonical,
+			"project_ids": ids,
+		})
+		return
+	}
+
+	job, err := h.startJob(c, "drift_report", func(logs *jobLog) (interface{}, error) {
+		if canonical == nil {
+			snapshot, _, errDetail := h.snapshotProject(req.ReferenceProjectID)
+			if errDetail != nil {
+				return nil, fmt.Errorf("reference project: %s: %s", errDetail.Message, errDetail.Details)
+			}
+			canonical = snapshot
+		}
+
+		logs.printf("Comparing %d projects with %s", len(targets), report.Canonical)
+		for _, drift := range h.detectDrift(canonical, req.CanonicalSQL != "", targets) {
+			report.Add(drift)
+		}
+		logs.printf("%d in sync, %d drifted, %d errors, %d skipped", report.Summary.InSync, report.Summary.Drifted, report.Summary.Errors, report.Summary.Skipped)
+		return report, nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to start drift report",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job":      job,
+		"projects": len(targets),
+		"message":  fmt.Sprintf("Drift report started. Poll /api/jobs/%s for the result.", job.ID),
+	})
+}
+
+// detectDrift compares each project against the canonical snapshot, a few
+// at a time, returning results in the order of projects
+func (h *Handler) detectDrift(canonical *supabase.SchemaSnapshot, fromSQL bool, projects []*supabase.StoredProject) []supabase.ProjectDrift {
+	results := make([]supabase.ProjectDrift, len(projects))
+	slots := make(chan struct{}, driftConcurrency)
+	var wg sync.WaitGroup
+
+	for i, project := range projects {
+		results[i] = supabase.ProjectDrift{ProjectID: project.ID, Name: project.Name}
+
+		if project.Status != "ACTIVE_HEALTHY" {
+			results[i].Status = supabase.DriftSkipped
+			results[i].Error = "project is " + project.Status
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(result *supabase.ProjectDrift) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			snapshot, _, errDetail := h.snapshotProject(result.ProjectID)
+			if errDetail != nil {
+				result.Status = supabase.DriftError
+				result.Error = strings.TrimSpace(errDetail.Message + ": " + errDetail.Details)
+				return
+			}
+
+			var differences *supabase.SchemaComparison
+			if fromSQL {
+				differences = supabase.StructuralDrift(canonical, snapshot)
+			} else {
+				differences = supabase.CompareSnapshots(canonical, snapshot)
+			}
+
+			result.Status = supabase.DriftInSync
+			if !differences.Identical {
+				result.Status = supabase.DriftDrifted
+				result.Differences = differences
+			}
+		}(&results[i])
+	}
+
+	wg.Wait()
+	return results
+}
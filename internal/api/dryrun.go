@@ -0,0 +1,27 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+// isDryRun reports whether the caller asked for a dry run, via the dry_run
+// query parameter or the X-Dry-Run header
+func isDryRun(c *gin.Context) bool {
+	if value, err := strconv.ParseBool(c.Query("dry_run")); err == nil && value {
+		return true
+	}
+	value, err := strconv.ParseBool(c.GetHeader("X-Dry-Run"))
+	return err == nil && value
+}
+
+// respondDryRun writes the would-be effect of a validated mutating request
+func respondDryRun(c *gin.Context, action string, effect gin.H) {
+	c.JSON(http.StatusOK, gin.H{
+		"dry_run": true,
+		"action":  action,
+		"effect":  effect,
+	})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"errors"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// respondManagementError writes the error response for a failed Management
+// API call. Typed API errors get a status and code matching their kind, so
+// callers can tell a quota or naming problem from an outage; anything else
+// uses the given status and code.
+func respondManagementError(c *gin.Context, status int, code, message string, err error) {
+	var apiErr *supabase.APIError
+	if errors.As(err, &apiErr) {
+		c.JSON(apiErr.HTTPStatus(), supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    apiErr.Code(),
+				Message: message,
+				Details: apiErr.Message,
+			},
+		})
+		return
+	}
+
+	c.JSON(status, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    code,
+			Message: message,
+			Details: err.Error(),
+		},
+	})
+}
@@ -0,0 +1,114 @@
+package api
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// seedPlaceholder is the seed.sql of an exported bundle. The manager does
+// not track seed data.
+const seedPlaceholder = "-- Seed data for local development, loaded by supabase start and supabase db reset\n"
+
+// ExportSupabaseCLI handles GET /api/projects/:id/export/supabase-cli. It
+// returns the project as a supabase directory for the Supabase CLI:
+// config.toml with its auth settings and buckets, migrations/ with every
+// successfully applied migration, and seed.sql.
+func (h *Handler) ExportSupabaseCLI(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	auth, err := client.GetAuthSettings(storedProject.ProjectRef)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to get auth settings", err)
+		return
+	}
+
+	buckets, err := h.supabaseClient.ListBuckets(storedProject.ToProject())
+	if err != nil {
+		c.JSON(http.StatusBadGateway, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "STORAGE_API_ERROR",
+				Message: "Failed to list buckets",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	migrations, err := h.exportedMigrations(storedProject.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to load migration history",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	bundle := &supabase.CLIBundle{
+		ProjectID:       supabase.Slugify(storedProject.Name),
+		PostgresVersion: storedProject.PostgresVersion,
+		Auth:            auth,
+		Buckets:         buckets,
+		Migrations:      migrations,
+		Seed:            seedPlaceholder,
+	}
+
+	var buf bytes.Buffer
+	if err := bundle.WriteTarGz(&buf); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to build export",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename=%q", bundle.ProjectID+"-supabase.tar.gz"))
+	c.Data(http.StatusOK, "application/gzip", buf.Bytes())
+}
+
+// exportedMigrations returns the successful migrations of a project, oldest
+// first, with their SQL. Only the latest run of a version is kept, since the
+// CLI identifies migrations by version.
+func (h *Handler) exportedMigrations(projectID string) ([]*supabase.MigrationRecord, error) {
+	history, err := h.storage.ListMigrations(projectID)
+	if err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var migrations []*supabase.MigrationRecord
+	for _, record := range history {
+		if !record.Success || seen[record.Version] {
+			continue
+		}
+		seen[record.Version] = true
+
+		withSQL, err := h.storage.GetMigration(projectID, record.Version)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, withSQL)
+	}
+
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
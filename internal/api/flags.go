@@ -0,0 +1,319 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditFlagSet     = "flag.set"
+	auditFlagDeleted = "flag.deleted"
+	auditFlagsSynced = "flags.synced"
+)
+
+// ListFeatureFlags handles GET /api/projects/:id/flags. Besides the flags it
+// returns their values by key, the shape apps read at startup.
+func (h *Handler) ListFeatureFlags(c *gin.Context) {
+	project, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	flags, err := h.storage.ListFeatureFlags(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list feature flags",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": project.ID,
+		"flags":      flags,
+		"values":     supabase.FlagValues(flags),
+		"total":      len(flags),
+	})
+}
+
+// GetFeatureFlag handles GET /api/projects/:id/flags/:key
+func (h *Handler) GetFeatureFlag(c *gin.Context) {
+	if _, ok := h.loadProject(c, c.Param("id")); !ok {
+		return
+	}
+
+	flag, err := h.storage.GetFeatureFlag(c.Param("id"), c.Param("key"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "FLAG_NOT_FOUND",
+				Message: "Feature flag not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// SetFeatureFlag handles PUT /api/projects/:id/flags/:key, creating or
+// replacing the flag. Synced copies are only updated by the next sync.
+func (h *Handler) SetFeatureFlag(c *gin.Context) {
+	var req supabase.SetFeatureFlagRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	project, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	flag, err := supabase.NewFeatureFlag(project.ID, c.Param("key"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid feature flag",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	flag.UpdatedBy = callerID(c)
+	flag.UpdatedAt = time.Now().UTC()
+
+	previous, _ := h.storage.GetFeatureFlag(project.ID, flag.Key)
+
+	if isDryRun(c) {
+		respondDryRun(c, "set_feature_flag", gin.H{
+			"project_id": project.ID,
+			"flag":       flag,
+			"previous":   previous,
+		})
+		return
+	}
+
+	if err := h.storage.SaveFeatureFlag(flag); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to save feature flag",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	details := fmt.Sprintf("%s = %s", flag.Key, flag.Value)
+	if previous != nil {
+		details += fmt.Sprintf(" (was %s)", previous.Value)
+	}
+	h.audit(c, auditFlagSet, project.ID, details)
+
+	c.JSON(http.StatusOK, flag)
+}
+
+// DeleteFeatureFlag handles DELETE /api/projects/:id/flags/:key
+func (h *Handler) DeleteFeatureFlag(c *gin.Context) {
+	if _, ok := h.loadProject(c, c.Param("id")); !ok {
+		return
+	}
+
+	flag, err := h.storage.GetFeatureFlag(c.Param("id"), c.Param("key"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "FLAG_NOT_FOUND",
+				Message: "Feature flag not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "delete_feature_flag", gin.H{"project_id": flag.ProjectID, "flag": flag})
+		return
+	}
+
+	if err := h.storage.DeleteFeatureFlag(flag.ProjectID, flag.Key); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete feature flag",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.audit(c, auditFlagDeleted, flag.ProjectID, flag.Key)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Feature flag deleted successfully",
+		"key":     flag.Key,
+	})
+}
+
+// SyncFeatureFlags handles POST /api/projects/:id/flags/sync. It makes each
+// target hold exactly the project's flags: the feature_flags table of its
+// database, or its FLAG_ Edge Function secrets.
+func (h *Handler) SyncFeatureFlags(c *gin.Context) {
+	var req supabase.SyncFeatureFlagsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid sync targets",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.checkFreeze(c, supabase.FreezeMigrations, storedProject) {
+		return
+	}
+
+	client := h.supabaseClient
+	if slices.Contains(req.Targets, supabase.FlagSyncSecrets) {
+		if client, ok = h.clientFor(c, storedProject); !ok {
+			return
+		}
+	}
+
+	flags, err := h.storage.ListFeatureFlags(storedProject.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list feature flags",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "sync_feature_flags", gin.H{
+			"project_id": storedProject.ID,
+			"targets":    req.Targets,
+			"values":     supabase.FlagValues(flags),
+		})
+		return
+	}
+
+	results := make([]supabase.FlagSyncResult, len(req.Targets))
+	var failures []string
+	for i, target := range req.Targets {
+		results[i] = supabase.FlagSyncResult{Target: target, Synced: len(flags)}
+		var err error
+		switch target {
+		case supabase.FlagSyncTable:
+			results[i].Removed, err = h.syncFlagTable(storedProject, flags)
+		case supabase.FlagSyncSecrets:
+			results[i].Removed, err = syncFlagSecrets(client, storedProject.ProjectRef, flags)
+		}
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("%s: %v", target, err))
+		}
+	}
+
+	if len(failures) > 0 {
+		c.JSON(http.StatusBadGateway, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "FLAG_SYNC_FAILED",
+				Message: "Failed to sync feature flags",
+				Details: strings.Join(failures, "; "),
+			},
+		})
+		return
+	}
+
+	h.audit(c, auditFlagsSynced, storedProject.ID, fmt.Sprintf("%d flags to %s", len(flags), strings.Join(req.Targets, ",")))
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": storedProject.ID,
+		"results":    results,
+	})
+}
+
+// syncFlagTable writes the flags to the project's feature_flags table
+func (h *Handler) syncFlagTable(project *supabase.StoredProject, flags []*supabase.FeatureFlag) (int, error) {
+	runner, err := h.newMigrationRunner(project.ToProject())
+	if err != nil {
+		return 0, fmt.Errorf("failed to connect to database: %w", err)
+	}
+	defer runner.Close()
+
+	removed, err := runner.SyncFeatureFlags(flags)
+	h.schemas.invalidate(project.ID)
+	return removed, err
+}
+
+// syncFlagSecrets sets a FLAG_ secret per flag and deletes the FLAG_
+// secrets of flags no longer held
+func syncFlagSecrets(client *supabase.Client, projectRef string, flags []*supabase.FeatureFlag) (int, error) {
+	held := make(map[string]bool, len(flags))
+	secrets := make([]supabase.ProjectSecret, len(flags))
+	for i, flag := range flags {
+		held[flag.SecretName()] = true
+		secrets[i] = supabase.ProjectSecret{Name: flag.SecretName(), Value: flag.SecretValue()}
+	}
+
+	if len(secrets) > 0 {
+		if err := client.CreateProjectSecrets(projectRef, secrets); err != nil {
+			return 0, err
+		}
+	}
+
+	names, err := client.ListProjectSecretNames(projectRef)
+	if err != nil {
+		return 0, err
+	}
+	var stale []string
+	for _, name := range names {
+		if strings.HasPrefix(name, supabase.FlagSecretPrefix) && !held[name] {
+			stale = append(stale, name)
+		}
+	}
+	if len(stale) > 0 {
+		if err := client.DeleteProjectSecrets(projectRef, stale); err != nil {
+			return 0, err
+		}
+	}
+	return len(stale), nil
+}
@@ -0,0 +1,326 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditFreezeWindowCreated = "freeze_window.created"
+	auditFreezeWindowDeleted = "freeze_window.deleted"
+	auditFreezeBlocked       = "freeze.blocked"
+	auditFreezeOverridden    = "freeze.overridden"
+)
+
+// freezeOverrideScope lets a key run frozen operations when it asks to with
+// override_freeze=true
+const freezeOverrideScope = "freeze_override"
+
+// freezeWindowStatus is a freeze window with whether it is in force now
+type freezeWindowStatus struct {
+	*supabase.FreezeWindow
+	Active bool `json:"active"`
+	// ActiveUntil is when the current occurrence ends
+	ActiveUntil *time.Time `json:"active_until,omitempty"`
+}
+
+// newFreezeWindowStatus reports a window as of now
+func newFreezeWindowStatus(window *supabase.FreezeWindow, now time.Time) freezeWindowStatus {
+	status := freezeWindowStatus{FreezeWindow: window}
+	if until, active := window.ActiveAt(now); active {
+		status.Active = true
+		status.ActiveUntil = &until
+	}
+	return status
+}
+
+// CreateFreezeWindow handles POST /api/freeze-windows
+func (h *Handler) CreateFreezeWindow(c *gin.Context) {
+	var req supabase.CreateFreezeWindowRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid freeze window",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if req.ProjectID != "" {
+		if _, ok := h.loadProject(c, req.ProjectID); !ok {
+			return
+		}
+	}
+
+	window := &supabase.FreezeWindow{
+		ID:            uuid.New().String(),
+		ProjectID:     req.ProjectID,
+		LabelSelector: req.LabelSelector,
+		StartsAt:      req.StartsAt,
+		EndsAt:        req.EndsAt,
+		Schedule:      req.Schedule,
+		Duration:      req.Duration,
+		TimeZone:      req.TimeZone,
+		Operations:    req.Operations,
+		Reason:        req.Reason,
+		CreatedBy:     callerID(c),
+		CreatedAt:     time.Now().UTC(),
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "create_freeze_window", gin.H{"freeze_window": newFreezeWindowStatus(window, time.Now().UTC())})
+		return
+	}
+
+	if err := h.storage.SaveFreezeWindow(window); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create freeze window",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	h.audit(c, auditFreezeWindowCreated, window.ProjectID, fmt.Sprintf("%s: %s", window.ID, window.Reason))
+
+	c.JSON(http.StatusCreated, gin.H{"freeze_window": newFreezeWindowStatus(window, time.Now().UTC())})
+}
+
+// ListFreezeWindows handles GET /api/freeze-windows. With project_id, only
+// the windows covering that project are listed, and with active=true only
+// those in force now.
+func (h *Handler) ListFreezeWindows(c *gin.Context) {
+	var project *supabase.StoredProject
+	if projectID := c.Query("project_id"); projectID != "" {
+		var err error
+		if project, err = h.storage.GetProject(projectID); err != nil {
+			c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "PROJECT_NOT_FOUND",
+					Message: "Project not found",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+	activeOnly := c.Query("active") == "true"
+
+	windows, err := h.storage.ListFreezeWindows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list freeze windows",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	statuses := []freezeWindowStatus{}
+	for _, window := range windows {
+		if project != nil && !window.Covers(project) {
+			continue
+		}
+		status := newFreezeWindowStatus(window, now)
+		if activeOnly && !status.Active {
+			continue
+		}
+		statuses = append(statuses, status)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"freeze_windows": statuses,
+		"total":          len(statuses),
+	})
+}
+
+// DeleteFreezeWindow handles DELETE /api/freeze-windows/:id, lifting the
+// window
+func (h *Handler) DeleteFreezeWindow(c *gin.Context) {
+	window, err := h.storage.GetFreezeWindow(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "FREEZE_WINDOW_NOT_FOUND",
+				Message: "Freeze window not found",
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "delete_freeze_window", gin.H{"freeze_window": window})
+		return
+	}
+
+	if err := h.storage.DeleteFreezeWindow(window.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete freeze window",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	h.audit(c, auditFreezeWindowDeleted, window.ProjectID, fmt.Sprintf("%s: %s", window.ID, window.Reason))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Freeze window deleted successfully",
+		"id":      window.ID,
+	})
+}
+
+// checkFreeze refuses an operation on a project while a freeze window
+// blocking it is in force, writing the error response and returning false.
+// Keys with the freeze_override scope get through by asking with
+// override_freeze=true, which is audited.
+func (h *Handler) checkFreeze(c *gin.Context, operation string, project *supabase.StoredProject) bool {
+	windows, err := h.storage.ListFreezeWindows()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to check freeze windows",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+
+	now := time.Now().UTC()
+	for _, window := range windows {
+		if !window.Blocks(operation) || !window.Covers(project) {
+			continue
+		}
+		until, active := window.ActiveAt(now)
+		if !active {
+			continue
+		}
+
+		details := freezeDetails(operation, window, until)
+		if canOverrideFreeze(c) {
+			if !isDryRun(c) {
+				h.audit(c, auditFreezeOverridden, project.ID, details)
+			}
+			continue
+		}
+		h.audit(c, auditFreezeBlocked, project.ID, details)
+
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(until.Sub(now).Seconds()))))
+		c.JSON(http.StatusLocked, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "CHANGE_FREEZE",
+				Message: fmt.Sprintf("Project %s is in a change freeze", project.ID),
+				Details: details + "; keys with the " + freezeOverrideScope + " scope may retry with override_freeze=true",
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// freezeGate captures the caller of a job so the job's steps can check
+// freeze windows again right before they apply, since a window may come
+// into force while the job waits. The returned function fails a step whose
+// project is frozen with a CHANGE_FREEZE error, audited as freeze.blocked;
+// callers that may override freezes are let through as with checkFreeze.
+func (h *Handler) freezeGate(c *gin.Context, operation string) func(project *supabase.StoredProject) error {
+	override := canOverrideFreeze(c)
+	actor, requestID, clientIP := callerID(c), c.GetString("request_id"), c.ClientIP()
+
+	return func(project *supabase.StoredProject) error {
+		windows, err := h.storage.ListFreezeWindows()
+		if err != nil {
+			return fmt.Errorf("failed to check freeze windows: %w", err)
+		}
+		// Labels may have changed since the job started
+		if current, err := h.storage.GetProject(project.ID); err == nil {
+			project = current
+		}
+
+		now := time.Now().UTC()
+		for _, window := range windows {
+			if !window.Blocks(operation) || !window.Covers(project) {
+				continue
+			}
+			until, active := window.ActiveAt(now)
+			if !active {
+				continue
+			}
+
+			details := freezeDetails(operation, window, until)
+			action := auditFreezeBlocked
+			if override {
+				action = auditFreezeOverridden
+			}
+			h.recordAudit(&supabase.AuditEvent{
+				ID:        uuid.New().String(),
+				Action:    action,
+				ProjectID: project.ID,
+				Actor:     actor,
+				RequestID: requestID,
+				ClientIP:  clientIP,
+				Details:   details,
+				CreatedAt: now,
+			})
+			if !override {
+				return fmt.Errorf("CHANGE_FREEZE: %s", details)
+			}
+		}
+		return nil
+	}
+}
+
+// freezeDetails describes the freeze window blocking an operation
+func freezeDetails(operation string, window *supabase.FreezeWindow, until time.Time) string {
+	return fmt.Sprintf("%s frozen by window %s until %s: %s",
+		operation, window.ID, until.UTC().Format(time.RFC3339), window.Reason)
+}
+
+// checkTargetFreeze is checkFreeze for a migration target, which may be an
+// external database rather than a project. Freeze windows only cover
+// projects.
+func (h *Handler) checkTargetFreeze(c *gin.Context, operation, targetID string) bool {
+	project, err := h.storage.GetProject(targetID)
+	if err != nil {
+		return true
+	}
+	return h.checkFreeze(c, operation, project)
+}
+
+// canOverrideFreeze reports whether the caller asked to override freeze
+// windows and may
+func canOverrideFreeze(c *gin.Context) bool {
+	if value, err := strconv.ParseBool(c.Query("override_freeze")); err != nil || !value {
+		return false
+	}
+	key := auth.FromContext(c)
+	return key == nil || key.HasScope(freezeOverrideScope)
+}
@@ -0,0 +1,59 @@
+package api
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/supabase"
+)
+
+func TestFreezeGateRechecksBeforeEachStep(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	project := readyProject("frozen")
+	env.saveProject(t, project)
+
+	// Gates are taken when the job is accepted, before the window exists
+	accept := func(query string, key *auth.Key) func(*supabase.StoredProject) error {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodPost, "/api/schema-batch"+query, nil)
+		c.Set(auth.ContextKey, key)
+		return env.handler.freezeGate(c, supabase.FreezeMigrations)
+	}
+	gate := accept("", &auth.Key{ID: "deployer"})
+	override := accept("?override_freeze=true", &auth.Key{ID: "oncall", Scopes: []string{freezeOverrideScope}})
+
+	if err := gate(project); err != nil {
+		t.Fatalf("gate without a window: %v", err)
+	}
+
+	ends := time.Now().Add(time.Hour)
+	window := &supabase.FreezeWindow{
+		ID:         "release",
+		ProjectID:  project.ID,
+		EndsAt:     &ends,
+		Operations: []string{supabase.FreezeMigrations},
+		Reason:     "release week",
+		CreatedBy:  "admin",
+		CreatedAt:  time.Now(),
+	}
+	if err := env.store.SaveFreezeWindow(window); err != nil {
+		t.Fatalf("save freeze window: %v", err)
+	}
+
+	err := gate(project)
+	if err == nil || !strings.HasPrefix(err.Error(), "CHANGE_FREEZE: ") || !strings.Contains(err.Error(), "release week") {
+		t.Errorf("gate after the window came into force: got %v", err)
+	}
+	if err := override(project); err != nil {
+		t.Errorf("override_freeze with the scope: got %v", err)
+	}
+	if err := gate(readyProject("other")); err != nil {
+		t.Errorf("project outside the window: got %v", err)
+	}
+}
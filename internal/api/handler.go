@@ -1,192 +1,524 @@
 package api
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/gin-gonic/gin"
-	"github.com/google/uuid"
 	
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/monitor"
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/policy"
 	"supabase-manager/internal/storage"
 	"supabase-manager/internal/supabase"
 )
 
+// Options holds handler configuration
+type Options struct {
+	DefaultRegion  string
+	// RegionFallbacks are tried in order when a project cannot be created
+	// in its region for lack of capacity; empty disables failover
+	RegionFallbacks []string
+	HealthCacheTTL time.Duration
+	// SchemaCacheTTL is how long an introspected schema is served before
+	// its version is checked against the project's database again
+	SchemaCacheTTL time.Duration
+	Keyring        *auth.Keyring
+	Monitor        *monitor.Monitor
+	AuthBaseline   supabase.AuthSettings
+	// AllowBYOCredentials lets callers supply their own Supabase access
+	// token and organization per request
+	AllowBYOCredentials bool
+	// EphemeralDBRoles runs migrations as a login role created for the
+	// run and dropped afterwards
+	EphemeralDBRoles bool
+	// DefaultBuckets are created in projects requested with
+	// with_default_buckets
+	DefaultBuckets []supabase.BucketRequest
+	// SchemaFetcher fetches SQL for schema requests with a source_url
+	SchemaFetcher *supabase.SchemaFetcher
+	// Notifier receives alerts about managed projects
+	Notifier notify.Notifier
+	// ProvisionAnomalyFactor flags provisions running longer than the
+	// region's p95 times this factor; 0 disables it
+	ProvisionAnomalyFactor float64
+	// Policy approves project creation and schema changes; nil allows all
+	Policy *policy.Checker
+	// DBMaxConcurrent and DBMaxConcurrentPerTarget bound the database
+	// operations running at once; DBQueueTimeout bounds the wait for a slot
+	DBMaxConcurrent          int
+	DBMaxConcurrentPerTarget int
+	DBQueueTimeout           time.Duration
+	// MaxSQLBytes limits inline SQL in schema requests; 0 means no limit
+	MaxSQLBytes int64
+	// SQLSecretScan rejects or warns about migrations with embedded
+	// credentials: "reject", "warn" or "off"
+	SQLSecretScan string
+	// PreviewCommentHosts are the hosts preview outcomes may be posted to
+	PreviewCommentHosts []string
+	// WebhookHosts are the hosts lifecycle webhooks may be registered
+	// for; empty allows any host
+	WebhookHosts []string
+	// HookSecret verifies requests to the Supabase hook receiver; empty
+	// disables it
+	HookSecret string
+	// HookRequireSignature refuses hooks that authenticate with the bare
+	// secret instead of a signature, since they can be replayed
+	HookRequireSignature bool
+	// NamingPolicy constrains project names; the zero policy allows any
+	NamingPolicy supabase.NamingPolicy
+	// StrictLimits are the default limits of migrations run in strict
+	// mode; StrictMigrations runs every migration in strict mode
+	StrictLimits     supabase.StrictLimits
+	StrictMigrations bool
+	// ProvisioningProfiles are the configured profiles create requests
+	// can name in addition to the built-in ones
+	ProvisioningProfiles []supabase.ProvisioningProfile
+	// ProvisioningSLO holds the provisioning targets reported by /api/slo
+	ProvisioningSLO supabase.ProvisioningSLO
+	// DRKey wraps the credentials of disaster recovery exports; nil
+	// disables them
+	DRKey supabase.DRKey
+	// SoftQuotas warn creates that reach them without rejecting them
+	SoftQuotas supabase.SoftQuotas
+	// SnapshotPolicy selects the projects dumped before they are deleted
+	// in Supabase; SnapshotTool takes and restores the dumps, each within
+	// SnapshotTimeout
+	SnapshotPolicy  supabase.SnapshotPolicy
+	SnapshotTool    *supabase.SnapshotTool
+	SnapshotTimeout time.Duration
+	// MaxProvisioning and MaxMigrationQueue shed create and migrate
+	// requests with 503 once that many provisions are running or database
+	// operations are queued; 0 disables shedding
+	MaxProvisioning   int
+	MaxMigrationQueue int
+	// Deployment is reported by GET /api/capabilities
+	Deployment Deployment
+}
+
 // Handler contains dependencies for HTTP handlers
 type Handler struct {
 	supabaseClient *supabase.Client
 	storage        *storage.SQLiteStorage
 	wg             sync.WaitGroup
+	pending        atomic.Int64
+	completed      atomic.Int64
+	deleting       sync.Map
+	naming         sync.Mutex
+	stop           chan struct{}
 	defaultRegion  string
+	regionFallbacks []string
+	health         *healthCache
+	schemas        *schemaCache
+	regions        *regionCache
+	keyring        *auth.Keyring
+	monitor        *monitor.Monitor
+	authBaseline   supabase.AuthSettings
+	allowBYOCredentials bool
+	credentials    *credentialCache
+	ephemeralDBRoles bool
+	defaultBuckets []supabase.BucketRequest
+	schemaFetcher  *supabase.SchemaFetcher
+	startup        startupState
+	notifier       notify.Notifier
+	provisionAnomalyFactor float64
+	policy                 *policy.Checker
+	deletePlans            batchDeletePlans
+	dbPool                 *dbPool
+	maxSQLBytes            int64
+	sqlSecretScan          string
+	previewCommentHosts    map[string]bool
+	webhookHosts           map[string]bool
+	webhookClient          *http.Client
+	previewing             sync.Map
+	hookSecret             string
+	hookRequireSignature   bool
+	namingPolicy           supabase.NamingPolicy
+	strictLimits           supabase.StrictLimits
+	strictMigrations       bool
+	provisioningProfiles   map[string]supabase.ProvisioningProfile
+	provisioningSLO        supabase.ProvisioningSLO
+	drKey                  supabase.DRKey
+	softQuotas             supabase.SoftQuotas
+	snapshotPolicy         supabase.SnapshotPolicy
+	snapshotTool           *supabase.SnapshotTool
+	snapshotTimeout        time.Duration
+	migrationQueue         migrationQueue
+	deployment             Deployment
+	backpressure           backpressure
 }
 
 // NewHandler creates a new handler instance
-func NewHandler(supabaseClient *supabase.Client, storage *storage.SQLiteStorage, defaultRegion string) *Handler {
-	return &Handler{
+func NewHandler(supabaseClient *supabase.Client, storage *storage.SQLiteStorage, opts Options) *Handler {
+	h := &Handler{
 		supabaseClient: supabaseClient,
 		storage:        storage,
-		defaultRegion:  defaultRegion,
+		stop:           make(chan struct{}),
+		defaultRegion:  opts.DefaultRegion,
+		keyring:        opts.Keyring,
+		monitor:        opts.Monitor,
+		authBaseline:   opts.AuthBaseline,
+		allowBYOCredentials: opts.AllowBYOCredentials,
+		credentials:    newCredentialCache(),
+		regions:        newRegionCache(supabaseClient),
+		ephemeralDBRoles: opts.EphemeralDBRoles,
+		defaultBuckets: opts.DefaultBuckets,
+		schemaFetcher:  opts.SchemaFetcher,
+		notifier:       opts.Notifier,
+		provisionAnomalyFactor: opts.ProvisionAnomalyFactor,
+		policy:                 opts.Policy,
+		dbPool:                 newDBPool(opts.DBMaxConcurrent, opts.DBMaxConcurrentPerTarget, opts.DBQueueTimeout),
+		maxSQLBytes:            opts.MaxSQLBytes,
+		sqlSecretScan:          opts.SQLSecretScan,
+		hookSecret:             opts.HookSecret,
+		hookRequireSignature:   opts.HookRequireSignature,
+		namingPolicy:           opts.NamingPolicy,
+		strictLimits:           opts.StrictLimits,
+		strictMigrations:       opts.StrictMigrations,
+		provisioningSLO:        opts.ProvisioningSLO,
+		drKey:                  opts.DRKey,
+		softQuotas:             opts.SoftQuotas,
+		snapshotPolicy:         opts.SnapshotPolicy,
+		snapshotTool:           opts.SnapshotTool,
+		snapshotTimeout:        opts.SnapshotTimeout,
+		deployment:             opts.Deployment,
+	}
+	h.previewCommentHosts = make(map[string]bool)
+	h.provisioningProfiles = make(map[string]supabase.ProvisioningProfile, len(opts.ProvisioningProfiles))
+	for _, profile := range opts.ProvisioningProfiles {
+		h.provisioningProfiles[profile.Name] = profile
+	}
+	for _, host := range opts.PreviewCommentHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			h.previewCommentHosts[host] = true
+		}
+	}
+	h.webhookHosts = make(map[string]bool)
+	for _, host := range opts.WebhookHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			h.webhookHosts[host] = true
+		}
 	}
+	for _, region := range opts.RegionFallbacks {
+		if region = strings.TrimSpace(region); region != "" && !slices.Contains(h.regionFallbacks, region) {
+			h.regionFallbacks = append(h.regionFallbacks, region)
+		}
+	}
+	h.webhookClient = newWebhookHTTPClient()
+	h.backpressure.maxProvisioning.Store(int64(opts.MaxProvisioning))
+	h.backpressure.maxMigrationQueue.Store(int64(opts.MaxMigrationQueue))
+	h.health = newHealthCache(h.checkDependencies, opts.HealthCacheTTL)
+	h.schemas = newSchemaCache(opts.SchemaCacheTTL)
+	h.startup.phase = StartupRecovery
+
+	return h
 }
 
-// WaitForPendingTasks waits for all background tasks to complete
+// WaitForPendingTasks signals retry loops to stop and waits for all
+// background tasks to complete
 func (h *Handler) WaitForPendingTasks() {
+	close(h.stop)
 	h.wg.Wait()
 }
 
-// HealthCheck handles GET /health
-func (h *Handler) HealthCheck(c *gin.Context) {
-	// Test database connection
-	dbStatus := "connected"
-	if _, err := h.storage.GetStats(); err != nil {
-		dbStatus = "error"
-	}
+// runBackground runs fn in a tracked background goroutine
+func (h *Handler) runBackground(fn func()) {
+	h.wg.Add(1)
+	h.pending.Add(1)
+	go func() {
+		defer h.wg.Done()
+		defer h.pending.Add(-1)
+		defer h.completed.Add(1)
+		fn()
+	}()
+}
 
-	// Test Supabase API
-	supabaseStatus := "reachable"
-	if err := h.supabaseClient.TestConnection(); err != nil {
-		supabaseStatus = "error"
-	}
+// PendingTasks returns the number of background tasks still running
+func (h *Handler) PendingTasks() int64 {
+	return h.pending.Load()
+}
 
-	c.JSON(http.StatusOK, gin.H{
-		"status":       "ok",
-		"database":     dbStatus,
-		"supabase_api": supabaseStatus,
-		"timestamp":    time.Now().Format(time.RFC3339),
-	})
+// sleepOrStop waits for the given duration, returning false if the handler
+// is shutting down
+func (h *Handler) sleepOrStop(d time.Duration) bool {
+	select {
+	case <-time.After(d):
+		return true
+	case <-h.stop:
+		return false
+	}
 }
 
 // CreateProject handles POST /api/projects
 func (h *Handler) CreateProject(c *gin.Context) {
+	if h.shedProvisioning(c) {
+		return
+	}
+
 	var req supabase.CreateProjectRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
+	if !bindValidatedJSON(c, supabase.SchemaCreateProject, &req) {
+		return
+	}
+	h.applyProvisioningProfile(&req)
+	h.applyOrgDefaults(&req)
+
+	if !h.checkRegion(c, req.Region) {
+		return
+	}
+
+	if req.PostgresVersion != "" && !supabase.IsSupportedPostgresVersion(req.PostgresVersion) {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_POSTGRES_VERSION",
+				Message: "Unsupported Postgres version",
+				Details: fmt.Sprintf("See GET /api/postgres-versions for supported versions, got %q", req.PostgresVersion),
+			},
+		})
+		return
+	}
+
+	if !validNameConflict(req.NameConflict) {
 		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
 			Error: supabase.ErrorDetail{
 				Code:    "INVALID_REQUEST",
-				Message: "Invalid request body",
-				Details: err.Error(),
+				Message: "Invalid name_conflict",
+				Details: fmt.Sprintf("Expected error, suffix or timestamp, got %q", req.NameConflict),
 			},
 		})
 		return
 	}
 
-	// Set default region if not provided
-	if req.Region == "" {
-		req.Region = h.defaultRegion
+	if err := supabase.ValidateLabels(req.Labels); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid labels",
+				Details: err.Error(),
+			},
+		})
+		return
 	}
 
-	// Generate unique project name if needed
-	projectName := req.Name
-	if projectName == "" {
-		projectName = fmt.Sprintf("project-%s", uuid.New().String()[:8])
+	if err := supabase.ValidateExtensions(req.EnableExtensions); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid enable_extensions",
+				Details: err.Error(),
+			},
+		})
+		return
 	}
 
-	// Create project via Supabase API
-	project, err := h.supabaseClient.CreateProject(projectName, req.Region)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+	if _, err := h.profileSetup(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
 			Error: supabase.ErrorDetail{
-				Code:    "PROJECT_CREATION_FAILED",
-				Message: "Failed to create Supabase project",
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid profile",
 				Details: err.Error(),
 			},
 		})
 		return
 	}
 
-	// Generate a stable ID for our system
-	projectID := uuid.New().String()
-	project.ID = projectID
-	project.Region = req.Region // Store the region we used
+	if req.Template != nil {
+		if err := req.Template.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid template",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		for _, script := range req.Template.SQLScripts() {
+			if !h.checkSecrets(c, "", script) {
+				return
+			}
+		}
+	}
 
-	// Store initial project data (status will be updated later)
-	storedProject := project.ToStoredProject()
-	if err := h.storage.SaveProject(storedProject); err != nil {
-		// Project created in Supabase but failed to save locally
-		// Log error but don't fail the request
-		fmt.Printf("Warning: Failed to save project to storage: %v\n", err)
+	namingPolicy := h.projectNamingPolicy()
+	if err := namingPolicy.Check(req.Name, req.Labels); err != nil {
+		respondNamingPolicyViolation(c, namingPolicy, req.Name, req.Labels, err)
+		return
 	}
 
-	// Start waiting for project in background
-	h.wg.Add(1)
-	go func() {
-		defer h.wg.Done()
-		
-		readyProject, err := h.supabaseClient.WaitForProject(project.ProjectRef, 5*time.Minute)
-		if err != nil {
-			fmt.Printf("Error waiting for project %s: %v\n", projectID, err)
-			h.storage.UpdateProjectStatus(projectID, "FAILED")
+	if req.WithDefaultBuckets && len(h.defaultBuckets) == 0 {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "No default buckets are configured",
+				Details: "set DEFAULT_BUCKETS on the manager to use with_default_buckets",
+			},
+		})
+		return
+	}
+
+	client, ok := h.clientFor(c, nil)
+	if !ok {
+		return
+	}
+
+	if !h.checkPolicy(c, policy.OperationCreateProject, "", &req) {
+		return
+	}
+
+	// Hold the naming lock until the project is saved so concurrent
+	// requests cannot resolve to the same name
+	h.naming.Lock()
+	defer h.naming.Unlock()
+
+	name, err := h.resolveProjectName(client, &req)
+	if err != nil {
+		var conflict *nameConflictError
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "PROJECT_NAME_TAKEN",
+					Message: "Project name is already in use",
+					Details: err.Error() + `; set "name_conflict" to "suffix" or "timestamp" to pick a free name`,
+				},
+			})
 			return
 		}
+		respondManagementError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to check project name", err)
+		return
+	}
+	req.Name = name
+
+	bootstrap := h.bootstrapFor(&req)
+	if isDryRun(c) {
+		respondDryRun(c, "create_project", gin.H{
+			"name":             req.Name,
+			"region":           req.Region,
+			"fallback_regions": h.regionCandidates(&req)[1:],
+			"plan":             planOrDefault(req.Plan),
+			"compute_size":     req.ComputeSize,
+			"postgres_version": req.PostgresVersion,
+			"auth_settings":    h.authSettingsFor(bootstrap),
+			"byo_credentials":  client != h.supabaseClient,
+			"labels":           req.Labels,
+			"bootstrap":        bootstrap,
+		})
+		return
+	}
 
-		// Fetch API keys from Supabase
-		apiKeys, err := h.supabaseClient.GetProjectAPIKeys(project.ProjectRef)
-		if err != nil {
-			fmt.Printf("Error fetching API keys for %s: %v\n", projectID, err)
-			// Still mark as active even if we can't get keys right away
-			// They might be available later
-		}
+	storedProject, job, err := h.provisionProject(client, &req, bootstrap, callerID(c))
+	if err != nil {
+		respondManagementError(c, http.StatusInternalServerError, "PROJECT_CREATION_FAILED", "Failed to create Supabase project", err)
+		return
+	}
 
-		// Update with full details once ready
-		readyProject.ID = projectID
-		readyProject.Region = req.Region
-		readyProject.DBPassword = project.DBPassword // Preserve the password we generated
-		
-		updatedStoredProject := readyProject.ToStoredProject()
-
-		// Store API keys if we got them
-		if apiKeys != nil {
-			updatedStoredProject.AnonKey = apiKeys.AnonKey
-			updatedStoredProject.ServiceKey = apiKeys.ServiceKey
-		}
+	response := h.projectResponse(storedProject, job)
+	response["message"] = "Project creation initiated. Poll /api/projects/:id to check status."
+	if storedProject.RequestedRegion != "" {
+		response["region_warning"] = fmt.Sprintf("%s had no capacity; the project was created in %s instead", storedProject.RequestedRegion, storedProject.Region)
+	}
+	if warnings := h.checkSoftQuotas(c, storedProject); len(warnings) > 0 {
+		response["warnings"] = warnings
+	}
+	c.JSON(http.StatusCreated, response)
+}
 
-		if err := h.storage.SaveProject(updatedStoredProject); err != nil {
-			fmt.Printf("Error updating project %s: %v\n", projectID, err)
-		}
-	}()
+// projectResponse is the representation of a stored project returned by
+// create and get, without its secrets. job, if set, is the job provisioning
+// it.
+func (h *Handler) projectResponse(project *supabase.StoredProject, job *supabase.Job) gin.H {
+	labels := project.Labels
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	links := gin.H{
+		"self":     "/api/projects/" + project.ID,
+		"timeline": "/api/projects/" + project.ID + "/audit",
+		"setup":    "/api/projects/" + project.ID + "/setup",
+	}
 
-	c.JSON(http.StatusCreated, gin.H{
-		"id":          projectID,
-		"project_ref": project.ProjectRef,
-		"project_url": project.GetProjectURL(),
-		"status":      "creating",
-		"message":     "Project creation initiated. Poll /api/projects/:id to check status.",
-	})
+	response := gin.H{
+		"id":               project.ID,
+		"name":             project.Name,
+		"project_ref":      project.ProjectRef,
+		"project_url":      project.ProjectURL,
+		"anon_key":         project.AnonKey,
+		"status":           project.Status,
+		"region":           project.Region,
+		"labels":           labels,
+		"created_at":       project.CreatedAt,
+		"updated_at":       project.UpdatedAt,
+		"postgres_version": project.PostgresVersion,
+		"links":            links,
+	}
+	if job != nil {
+		response["job_id"] = job.ID
+		links["job"] = "/api/jobs/" + job.ID
+	}
+	if project.BYOCredentials {
+		response["byo_credentials"] = true
+	}
+	if project.OrganizationID != "" {
+		response["organization_id"] = project.OrganizationID
+	}
+	if project.RequestedRegion != "" {
+		response["requested_region"] = project.RequestedRegion
+	}
+	if project.Name != "" {
+		response["slug"] = supabase.Slugify(project.Name)
+	}
+	if names, err := h.storage.ListProjectNames(project.ID); err != nil {
+		fmt.Printf("Warning: Failed to list former names of %s: %v\n", project.ID, err)
+	} else if len(names) > 0 {
+		response["former_names"] = names
+	}
+	if health, err := h.storage.GetProjectHealth(project.ID); err == nil {
+		response["health"] = health
+	}
+	if plan, err := h.storage.GetProjectPlan(project.ID); err != nil {
+		fmt.Printf("Warning: Failed to get plan of %s: %v\n", project.ID, err)
+	} else if plan != "" {
+		response["plan"] = plan
+	}
+	if usages, err := h.storage.GetKeyUsage(project.ID); err != nil {
+		fmt.Printf("Warning: Failed to get key usage of %s: %v\n", project.ID, err)
+	} else if len(usages) > 0 {
+		response["key_usage"] = gin.H{
+			"last_used_at": supabase.LastKeyUse(usages),
+			"keys":         usages,
+		}
+	}
+	if project.Status == "PENDING_DELETION" || project.Status == "DELETION_FAILED" {
+		response["deletion"] = gin.H{
+			"attempts":   project.DeletionAttempts,
+			"last_error": project.DeletionError,
+		}
+	}
+	return response
 }
 
 // GetProject handles GET /api/projects/:id
 func (h *Handler) GetProject(c *gin.Context) {
 	projectID := c.Param("id")
 
-	project, err := h.storage.GetProject(projectID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
-			Error: supabase.ErrorDetail{
-				Code:    "PROJECT_NOT_FOUND",
-				Message: "Project not found",
-				Details: err.Error(),
-			},
-		})
+	project, ok := h.loadProject(c, projectID)
+	if !ok {
 		return
 	}
 
 	// Return project with sensitive data (service key) only if requested
-	response := gin.H{
-		"id":          project.ID,
-		"project_ref": project.ProjectRef,
-		"project_url": project.ProjectURL,
-		"anon_key":    project.AnonKey,
-		"status":      project.Status,
-		"created_at":  project.CreatedAt,
-		"updated_at":  project.UpdatedAt,
-	}
+	response := h.projectResponse(project, nil)
 
 	// Include sensitive keys if query param is set
 	if c.Query("include_keys") == "true" {
 		response["service_key"] = project.ServiceKey
 		response["db_password"] = project.DBPassword
-		response["region"] = project.Region
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -194,6 +526,18 @@ func (h *Handler) GetProject(c *gin.Context) {
 
 // ListProjects handles GET /api/projects
 func (h *Handler) ListProjects(c *gin.Context) {
+	selector, err := supabase.ParseLabelSelector(c.Query("label_selector"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid label selector",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
 	projects, err := h.storage.ListProjects()
 	if err != nil {
 		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
@@ -206,16 +550,64 @@ func (h *Handler) ListProjects(c *gin.Context) {
 		return
 	}
 
+	// Projects probed in the background carry their uptime and last failure
+	healths, err := h.storage.ListProjectHealth()
+	if err != nil {
+		fmt.Printf("Warning: Failed to list project health: %v\n", err)
+	}
+
 	// Return simplified list (without sensitive keys)
 	var projectList []gin.H
 	for _, p := range projects {
-		projectList = append(projectList, gin.H{
-			"id":          p.ID,
-			"project_ref": p.ProjectRef,
-			"project_url": p.ProjectURL,
-			"status":      p.Status,
-			"created_at":  p.CreatedAt,
-		})
+		if !selector.Matches(p.Labels) || !callerAllowsOrganization(c, p.OrganizationID) {
+			continue
+		}
+		item := gin.H{
+			"id":              p.ID,
+			"name":            p.Name,
+			"project_ref":     p.ProjectRef,
+			"project_url":     p.ProjectURL,
+			"status":          p.Status,
+			"organization_id": p.OrganizationID,
+			"labels":          p.Labels,
+			"created_at":      p.CreatedAt,
+		}
+		if health, ok := healths[p.ID]; ok {
+			item["health"] = health
+		}
+		projectList = append(projectList, item)
+	}
+
+	// Deleted projects not yet purged are listed with status DELETED
+	if c.Query("include_deleted") == "true" {
+		deleted, err := h.storage.ListDeletedProjects()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to list deleted projects",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+
+		for _, p := range deleted {
+			if !selector.Matches(p.Labels) || !callerAllowsOrganization(c, p.OrganizationID) {
+				continue
+			}
+			projectList = append(projectList, gin.H{
+				"id":              p.ID,
+				"name":            p.Name,
+				"project_ref":     p.ProjectRef,
+				"status":          "DELETED",
+				"last_status":     p.LastStatus,
+				"organization_id": p.OrganizationID,
+				"labels":          p.Labels,
+				"created_at":      p.CreatedAt,
+				"deleted_at":      p.DeletedAt,
+			})
+		}
 	}
 
 	c.JSON(http.StatusOK, gin.H{
@@ -229,27 +621,20 @@ func (h *Handler) ApplySchema(c *gin.Context) {
 	projectID := c.Param("id")
 
 	var req supabase.ApplySchemaRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
-			Error: supabase.ErrorDetail{
-				Code:    "INVALID_REQUEST",
-				Message: "Invalid request body",
-				Details: err.Error(),
-			},
-		})
+	if !h.bindSchemaRequest(c, &req) {
 		return
 	}
 
 	// Get project from storage
-	storedProject, err := h.storage.GetProject(projectID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
-			Error: supabase.ErrorDetail{
-				Code:    "PROJECT_NOT_FOUND",
-				Message: "Project not found",
-				Details: err.Error(),
-			},
-		})
+	storedProject, ok := h.loadProject(c, projectID)
+	if !ok {
+		return
+	}
+
+	// Migrations for a project that is still provisioning, or behind
+	// migrations already queued for it, are queued until it is ready
+	if provisioningStatuses[storedProject.Status] || h.migrationQueue.busy(storedProject.ID) {
+		h.queueSubmittedMigration(c, storedProject, &req)
 		return
 	}
 
@@ -265,70 +650,94 @@ func (h *Handler) ApplySchema(c *gin.Context) {
 		return
 	}
 
-	// Convert to supabase.Project for migration runner
-	project := &supabase.Project{
-		ProjectRef: storedProject.ProjectRef,
-		DBPassword: storedProject.DBPassword,
-		Region:     storedProject.Region,
-	}
+	h.runSubmittedMigration(c, storedProject.ID, storedProject.ToProject(), &req)
+}
 
-	// Create migration runner
-	runner, err := supabase.NewMigrationRunner(project)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
-			Error: supabase.ErrorDetail{
-				Code:    "MIGRATION_FAILED",
-				Message: "Failed to connect to database",
-				Details: err.Error(),
-			},
-		})
+// DeleteProject handles DELETE /api/projects/:id
+func (h *Handler) DeleteProject(c *gin.Context) {
+	projectID := c.Param("id")
+
+	// Get project to get reference
+	project, ok := h.loadProject(c, projectID)
+	if !ok {
 		return
 	}
-	defer runner.Close()
 
-	// Apply migration
-	result, err := runner.ApplyMigration(req.SQL)
-	if err != nil {
-		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
-			Error: supabase.ErrorDetail{
-				Code:    "MIGRATION_FAILED",
-				Message: "Failed to apply schema",
-				Details: err.Error(),
-			},
-		})
+	if !h.checkFreeze(c, supabase.FreezeDeletes, project) {
 		return
 	}
 
-	c.JSON(http.StatusOK, result)
-}
+	// Delete from Supabase (optional - might want to keep for POC). A
+	// project already waiting for remote deletion, or whose remote
+	// deletion failed, is always retried.
+	deleteFromSupabase := c.Query("delete_remote") == "true" || project.Status == "PENDING_DELETION" || project.Status == "DELETION_FAILED"
 
-// DeleteProject handles DELETE /api/projects/:id
-func (h *Handler) DeleteProject(c *gin.Context) {
-	projectID := c.Param("id")
+	client := h.supabaseClient
+	if deleteFromSupabase {
+		if client, ok = h.clientFor(c, project); !ok {
+			return
+		}
+	}
 
-	// Get project to get reference
-	project, err := h.storage.GetProject(projectID)
-	if err != nil {
-		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
-			Error: supabase.ErrorDetail{
-				Code:    "PROJECT_NOT_FOUND",
-				Message: "Project not found",
-			},
+	// Projects covered by the snapshot policy, or asked for with
+	// snapshot=true, are dumped before they are deleted in Supabase
+	takeSnapshot := deleteFromSupabase && h.wantsPreDeleteSnapshot(project, c.Query("snapshot") == "true")
+
+	if isDryRun(c) {
+		respondDryRun(c, "delete_project", gin.H{
+			"id":            projectID,
+			"project_ref":   project.ProjectRef,
+			"delete_local":  true,
+			"delete_remote": deleteFromSupabase,
+			"snapshot":      takeSnapshot,
+			"last_key_use":  h.lastKeyUse(projectID),
 		})
 		return
 	}
 
-	// Delete from Supabase (optional - might want to keep for POC)
-	deleteFromSupabase := c.Query("delete_remote") == "true"
 	if deleteFromSupabase {
-		if err := h.supabaseClient.DeleteProject(project.ProjectRef); err != nil {
-			// Log but don't fail - we'll still delete locally
-			fmt.Printf("Warning: Failed to delete project from Supabase: %v\n", err)
+		var snapshot *supabase.Snapshot
+		if takeSnapshot {
+			var err error
+			if snapshot, err = h.takeSnapshot(c.Request.Context(), project, supabase.SnapshotReasonPreDelete, callerID(c)); err != nil {
+				c.JSON(http.StatusBadGateway, supabase.ErrorResponse{
+					Error: supabase.ErrorDetail{
+						Code:    "SNAPSHOT_FAILED",
+						Message: "Pre-delete snapshot failed; the project was not deleted",
+						Details: err.Error(),
+					},
+				})
+				return
+			}
+		}
+		h.audit(c, auditProjectDeleted, projectID, deletionDetails(project, true, snapshot))
+
+		response := gin.H{
+			"message": "Project deleted successfully",
+			"id":      projectID,
+		}
+		if snapshot != nil {
+			response["snapshot_id"] = snapshot.ID
+		}
+		if err := h.deleteRemote(client, projectID, project.ProjectRef, project.DeletionAttempts); err != nil {
+			response["status"] = deletionStatus(err)
+			response["error"] = err.Error()
+			if !isRetryableDeletion(err) {
+				response["message"] = "Remote deletion failed and will not be retried; fix the cause and send DELETE again"
+				c.JSON(http.StatusBadGateway, response)
+				return
+			}
+			response["message"] = "Remote deletion failed; it will be retried in the background"
+			c.JSON(http.StatusAccepted, response)
+			return
 		}
+
+		c.JSON(http.StatusOK, response)
+		return
 	}
 
 	// Delete from local storage
-	if err := h.storage.DeleteProject(projectID); err != nil {
+	if err := h.deleteLocal(projectID); err != nil {
 		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
 			Error: supabase.ErrorDetail{
 				Code:    "INTERNAL_ERROR",
@@ -338,6 +747,7 @@ func (h *Handler) DeleteProject(c *gin.Context) {
 		})
 		return
 	}
+	h.audit(c, auditProjectDeleted, projectID, deletionDetails(project, false, nil))
 
 	c.JSON(http.StatusOK, gin.H{
 		"message": "Project deleted successfully",
@@ -345,6 +755,13 @@ func (h *Handler) DeleteProject(c *gin.Context) {
 	})
 }
 
+// ListPostgresVersions handles GET /api/postgres-versions
+func (h *Handler) ListPostgresVersions(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{
+		"versions": supabase.SupportedPostgresVersions,
+	})
+}
+
 // GetStats handles GET /api/stats
 func (h *Handler) GetStats(c *gin.Context) {
 	stats, err := h.storage.GetStats()
@@ -359,5 +776,71 @@ func (h *Handler) GetStats(c *gin.Context) {
 		return
 	}
 
+	// Project counts per organization. For a key bound to organizations
+	// they are limited to its organizations, and so are the totals.
+	organizations, err := h.projectsByOrganization(c)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get statistics",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	stats["organizations"] = organizations
+	if key := auth.FromContext(c); key != nil && len(key.Organizations) > 0 {
+		total, active := 0, 0
+		for _, counts := range organizations {
+			total += counts.TotalProjects
+			active += counts.ActiveProjects
+		}
+		stats["total_projects"] = total
+		stats["active_projects"] = active
+	}
+
+	stats["management_api"] = h.supabaseClient.TransportStats()
+	stats["background_tasks"] = h.PendingTasks()
+	if h.monitor != nil {
+		stats["health_rules"] = h.monitor.Status()
+	}
+
 	c.JSON(http.StatusOK, stats)
-}
\ No newline at end of file
+}
+
+// organizationCounts are the project counts of one organization
+type organizationCounts struct {
+	TotalProjects  int `json:"total_projects"`
+	ActiveProjects int `json:"active_projects"`
+}
+
+// projectsByOrganization counts the projects of each organization the
+// caller may see, projects of unknown organization under "unknown"
+func (h *Handler) projectsByOrganization(c *gin.Context) (map[string]*organizationCounts, error) {
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	organizations := make(map[string]*organizationCounts)
+	for _, project := range projects {
+		if !callerAllowsOrganization(c, project.OrganizationID) {
+			continue
+		}
+		org := project.OrganizationID
+		if org == "" {
+			org = "unknown"
+		}
+		counts, ok := organizations[org]
+		if !ok {
+			counts = &organizationCounts{}
+			organizations[org] = counts
+		}
+		counts.TotalProjects++
+		if project.Status == "ACTIVE_HEALTHY" {
+			counts.ActiveProjects++
+		}
+	}
+	return organizations, nil
+}
@@ -0,0 +1,196 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/storage"
+	"supabase-manager/pkg/supabasetest"
+)
+
+// testEnv is a handler backed by the fake Management API and a temporary
+// database, with the routes under test registered on router
+type testEnv struct {
+	fake    *supabasetest.Server
+	store   *storage.SQLiteStorage
+	handler *Handler
+	router  *gin.Engine
+
+	// key is set as the calling API key of every request when not nil
+	key *auth.Key
+}
+
+func newTestEnv(t *testing.T, opts Options) *testEnv {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+
+	fake := supabasetest.NewServer()
+	t.Cleanup(fake.Close)
+
+	store, err := storage.NewSQLiteStorage(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatalf("open storage: %v", err)
+	}
+	t.Cleanup(func() { store.Close() })
+
+	if opts.DefaultRegion == "" {
+		opts.DefaultRegion = "us-east-1"
+	}
+	env := &testEnv{fake: fake, store: store}
+	env.handler = NewHandler(fake.Client(), store, opts)
+	t.Cleanup(env.handler.WaitForPendingTasks)
+	t.Cleanup(func() { awaitHealthCheck(env.handler) })
+
+	env.router = gin.New()
+	env.router.Use(func(c *gin.Context) {
+		if env.key != nil {
+			c.Set(auth.ContextKey, env.key)
+		}
+	})
+	env.router.POST("/api/projects", env.handler.CreateProject)
+	env.router.GET("/api/projects", env.handler.ListProjects)
+	env.router.GET("/api/projects/:id", env.handler.GetProject)
+	env.router.DELETE("/api/projects/:id", env.handler.DeleteProject)
+	return env
+}
+
+// awaitHealthCheck waits for the health cache's background check, which
+// would otherwise still be using the database while it is removed
+func awaitHealthCheck(h *Handler) {
+	for {
+		h.health.mu.Lock()
+		refreshing := h.health.refreshing
+		h.health.mu.Unlock()
+		if !refreshing {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// do sends a request to the router, encoding body as JSON when it is not nil
+func (e *testEnv) do(t *testing.T, method, path string, body interface{}) *httptest.ResponseRecorder {
+	t.Helper()
+
+	var reader *bytes.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			t.Fatalf("encode body: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	} else {
+		reader = bytes.NewReader(nil)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// decode unmarshals a JSON response into a map
+func decode(t *testing.T, rec *httptest.ResponseRecorder) map[string]interface{} {
+	t.Helper()
+
+	var body map[string]interface{}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("decode response %q: %v", rec.Body.String(), err)
+	}
+	return body
+}
+
+// errorCode returns the code of an error response
+func errorCode(t *testing.T, rec *httptest.ResponseRecorder) string {
+	t.Helper()
+
+	detail, _ := decode(t, rec)["error"].(map[string]interface{})
+	code, _ := detail["code"].(string)
+	return code
+}
+
+// awaitActive polls a project until the manager reports it ACTIVE_HEALTHY
+func (e *testEnv) awaitActive(t *testing.T, id string) map[string]interface{} {
+	t.Helper()
+
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		rec := e.do(t, http.MethodGet, "/api/projects/"+id, nil)
+		if rec.Code != http.StatusOK {
+			t.Fatalf("get project: %d %s", rec.Code, rec.Body.String())
+		}
+		project := decode(t, rec)
+		if project["status"] == "ACTIVE_HEALTHY" {
+			return project
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("project %s did not become ready, last status %v", id, project["status"])
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestProjectLifecycle(t *testing.T) {
+	env := newTestEnv(t, Options{})
+
+	rec := env.do(t, http.MethodPost, "/api/projects", map[string]string{"name": "lifecycle"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: got %d %s", rec.Code, rec.Body.String())
+	}
+	created := decode(t, rec)
+	id, _ := created["id"].(string)
+	ref, _ := created["project_ref"].(string)
+	if id == "" || ref == "" {
+		t.Fatalf("create returned no id or project_ref: %v", created)
+	}
+	if !slices.Contains(env.fake.ProjectRefs(), ref) {
+		t.Fatalf("project %s was not created in the Management API", ref)
+	}
+
+	project := env.awaitActive(t, id)
+	if project["anon_key"] == "" || project["anon_key"] == nil {
+		t.Errorf("ready project has no anon key: %v", project)
+	}
+	if _, ok := project["service_key"]; ok {
+		t.Errorf("service key returned without include_keys")
+	}
+
+	rec = env.do(t, http.MethodGet, "/api/projects/"+id+"?include_keys=true", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("get with keys: got %d %s", rec.Code, rec.Body.String())
+	}
+	if keys := decode(t, rec); keys["service_key"] == "" || keys["service_key"] == nil {
+		t.Errorf("include_keys returned no service key: %v", keys)
+	}
+
+	rec = env.do(t, http.MethodDelete, "/api/projects/"+id+"?delete_remote=true", nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("delete: got %d %s", rec.Code, rec.Body.String())
+	}
+	if slices.Contains(env.fake.ProjectRefs(), ref) {
+		t.Errorf("project %s still exists in the Management API", ref)
+	}
+
+	rec = env.do(t, http.MethodGet, "/api/projects/"+id, nil)
+	if rec.Code != http.StatusNotFound || errorCode(t, rec) != "PROJECT_NOT_FOUND" {
+		t.Errorf("get after delete: got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestGetUnknownProject(t *testing.T) {
+	env := newTestEnv(t, Options{})
+
+	rec := env.do(t, http.MethodGet, "/api/projects/missing", nil)
+	if rec.Code != http.StatusNotFound || errorCode(t, rec) != "PROJECT_NOT_FOUND" {
+		t.Errorf("got %d %s", rec.Code, rec.Body.String())
+	}
+}
@@ -0,0 +1,138 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/monitor"
+)
+
+// dependencyStatus is the result of one round of dependency checks
+type dependencyStatus struct {
+	Database    string
+	SupabaseAPI string
+	CheckedAt   time.Time
+}
+
+// healthCache serves dependency checks from memory and refreshes them
+// asynchronously once they are older than the TTL, so frequent probes do not
+// generate upstream traffic
+type healthCache struct {
+	check func() dependencyStatus
+	ttl   time.Duration
+
+	mu         sync.Mutex
+	status     dependencyStatus
+	refreshing bool
+}
+
+// newHealthCache creates a cache and starts the first check
+func newHealthCache(check func() dependencyStatus, ttl time.Duration) *healthCache {
+	if ttl <= 0 {
+		ttl = 10 * time.Second
+	}
+
+	cache := &healthCache{
+		check: check,
+		ttl:   ttl,
+		status: dependencyStatus{
+			Database:    "pending",
+			SupabaseAPI: "pending",
+		},
+	}
+	cache.refreshing = true
+	go cache.refresh()
+
+	return cache
+}
+
+// get returns the cached status, triggering a background refresh when stale
+func (hc *healthCache) get() dependencyStatus {
+	hc.mu.Lock()
+	defer hc.mu.Unlock()
+
+	if !hc.refreshing && time.Since(hc.status.CheckedAt) > hc.ttl {
+		hc.refreshing = true
+		go hc.refresh()
+	}
+
+	return hc.status
+}
+
+// refresh runs the dependency checks and stores the result
+func (hc *healthCache) refresh() {
+	status := hc.check()
+
+	hc.mu.Lock()
+	hc.status = status
+	hc.refreshing = false
+	hc.mu.Unlock()
+}
+
+// checkDependencies tests the database and the Supabase API
+func (h *Handler) checkDependencies() dependencyStatus {
+	// Test database connection
+	dbStatus := "connected"
+	if _, err := h.storage.GetStats(); err != nil {
+		dbStatus = "error"
+	}
+
+	// Test Supabase API
+	supabaseStatus := "reachable"
+	if err := h.supabaseClient.TestConnection(); err != nil {
+		supabaseStatus = "error"
+	}
+
+	return dependencyStatus{
+		Database:    dbStatus,
+		SupabaseAPI: supabaseStatus,
+		CheckedAt:   time.Now().UTC(),
+	}
+}
+
+// HealthCheck handles GET /health
+func (h *Handler) HealthCheck(c *gin.Context) {
+	status := h.health.get()
+
+	response := gin.H{
+		"status":       "ok",
+		"database":     status.Database,
+		"supabase_api": status.SupabaseAPI,
+		"startup":      h.StartupPhase(),
+		"queues":       h.QueueStats(),
+		"timestamp":    time.Now().UTC().Format(time.RFC3339),
+	}
+	if !status.CheckedAt.IsZero() {
+		response["checked_at"] = status.CheckedAt.Format(time.RFC3339)
+		response["age_seconds"] = int(time.Since(status.CheckedAt).Seconds())
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// HealthRuleStatus returns the last evaluation of the self-monitoring rules
+func (h *Handler) HealthRuleStatus() []monitor.RuleStatus {
+	if h.monitor == nil {
+		return nil
+	}
+	return h.monitor.Status()
+}
+
+// HealthRules handles GET /api/admin/health-rules
+func (h *Handler) HealthRules(c *gin.Context) {
+	rules := h.HealthRuleStatus()
+	firing := 0
+	for _, rule := range rules {
+		if rule.Firing {
+			firing++
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"rules":  rules,
+		"firing": firing,
+	})
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/supabase"
+)
+
+// healthProbeSpacing is the pause between the probes of two projects, so a
+// pass over many projects does not open many connections at once
+const healthProbeSpacing = 2 * time.Second
+
+// ProbeProjectHealth checks the REST endpoint and database of every active
+// project every interval, one project at a time, until the context is
+// cancelled. Outcomes are stored per project and a notification is sent
+// when a project becomes unreachable or recovers. A zero interval disables
+// probing.
+func (h *Handler) ProbeProjectHealth(ctx context.Context, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		h.probeActiveProjects(ctx)
+	}
+}
+
+// probeActiveProjects runs one probe pass over the active projects
+func (h *Handler) probeActiveProjects(ctx context.Context) {
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		fmt.Printf("Warning: Failed to list projects to probe: %v\n", err)
+		return
+	}
+
+	probed := 0
+	for _, project := range projects {
+		if project.Status != "ACTIVE_HEALTHY" {
+			continue
+		}
+		if probed > 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(healthProbeSpacing):
+			}
+		}
+		probed++
+		h.probeProject(project)
+	}
+}
+
+// probeProject probes one project and records the outcome. The database is
+// only checked when the manager knows its password.
+func (h *Handler) probeProject(project *supabase.StoredProject) {
+	target := project.ToProject()
+	target.Endpoint = project.ProjectURL
+
+	var failures []string
+	if err := h.supabaseClient.ProbeREST(target); err != nil {
+		failures = append(failures, "REST endpoint: "+err.Error())
+	}
+	if project.DBPassword != "" {
+		runner, err := h.connectDB(target, false)
+		if err != nil {
+			failures = append(failures, "database: "+err.Error())
+		} else {
+			runner.Close()
+		}
+	}
+
+	health, err := h.storage.GetProjectHealth(project.ID)
+	if err != nil {
+		health = &supabase.ProjectHealth{ProjectID: project.ID, Reachable: true}
+	}
+	wasReachable := health.Reachable
+
+	health.Record(strings.Join(failures, "; "), time.Now())
+	if err := h.storage.SaveProjectHealth(health); err != nil {
+		fmt.Printf("Warning: Failed to save health of %s: %v\n", project.ID, err)
+	}
+
+	if wasReachable != health.Reachable {
+		h.notifyReachability(project, health)
+	}
+}
+
+// notifyReachability tells the notifiers that a project became
+// unreachable or recovered
+func (h *Handler) notifyReachability(project *supabase.StoredProject, health *supabase.ProjectHealth) {
+	if h.notifier == nil {
+		return
+	}
+
+	notification := notify.Notification{
+		Severity: notify.SeverityCritical,
+		Title:    "Project unreachable: " + project.Name,
+		Message:  health.LastFailure,
+		Labels: map[string]string{
+			"project_id":  project.ID,
+			"project_ref": project.ProjectRef,
+		},
+		Time: health.LastCheckedAt,
+	}
+	if health.Reachable {
+		notification.Severity = notify.SeverityInfo
+		notification.Title = "Project reachable again: " + project.Name
+		notification.Message = fmt.Sprintf("Uptime %.2f%% over %d probes", health.UptimePercent, health.Checks)
+	}
+
+	if err := h.notifier.Notify(notification); err != nil {
+		fmt.Printf("Warning: Failed to send health notification for %s: %v\n", project.ID, err)
+	}
+}
@@ -0,0 +1,106 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/supabase"
+)
+
+// recordStatus records a project's new status in its audit log, so GET
+// /api/projects/:id/at can replay it
+func (h *Handler) recordStatus(projectID, status string) {
+	h.recordAudit(&supabase.AuditEvent{
+		ID:        uuid.New().String(),
+		Action:    supabase.AuditProjectStatusChanged,
+		ProjectID: projectID,
+		Actor:     "system",
+		Details:   status,
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// recordKeys records that API keys were stored for a project. Only which
+// keys are present is recorded, never the keys.
+func (h *Handler) recordKeys(projectID, anonKey, serviceKey string) {
+	h.recordAudit(&supabase.AuditEvent{
+		ID:        uuid.New().String(),
+		Action:    supabase.AuditProjectKeysStored,
+		ProjectID: projectID,
+		Actor:     "system",
+		Details:   supabase.KeysStoredDetails(anonKey, serviceKey),
+		CreatedAt: time.Now().UTC(),
+	})
+}
+
+// GetProjectAt handles GET /api/projects/:id/at?time=<RFC 3339>. It rebuilds
+// the project's status, API keys and applied migrations at that time from
+// its audit log and migration history, for incident retrospectives. Deleted
+// and purged projects can still be looked up.
+func (h *Handler) GetProjectAt(c *gin.Context) {
+	projectID := c.Param("id")
+
+	at, err := time.Parse(time.RFC3339, c.Query("time"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid time",
+				Details: "time must be an RFC 3339 timestamp, such as 2024-05-01T14:30:00Z",
+			},
+		})
+		return
+	}
+
+	project, _ := h.storage.GetProject(projectID)
+	if !h.checkRecordOrganization(c, projectID, project) {
+		return
+	}
+
+	var lifetime supabase.ProjectLifetime
+	if project != nil {
+		lifetime = supabase.ProjectLifetime{Name: project.Name, CreatedAt: project.CreatedAt}
+	} else if deleted, err := h.storage.GetDeletedProject(projectID); err == nil {
+		lifetime = supabase.ProjectLifetime{Name: deleted.Name, CreatedAt: deleted.CreatedAt, DeletedAt: deleted.DeletedAt}
+	}
+
+	events, err := h.storage.ListAllAuditEvents(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to read project history",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if lifetime.CreatedAt.IsZero() && len(events) == 0 {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_FOUND",
+				Message: "Project not found",
+				Details: "no project or recorded history with this ID",
+			},
+		})
+		return
+	}
+
+	migrations, err := h.storage.ListMigrations(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to read migration history",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, supabase.ReplayProject(projectID, at, lifetime, events, migrations))
+}
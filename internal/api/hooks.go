@@ -0,0 +1,171 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/supabase"
+)
+
+const auditHookReceived = "hook.received"
+
+// maxHookBytes limits the body of a hook request
+const maxHookBytes = 1 << 20
+
+// hookNonceScope is the replay cache scope of hook delivery IDs
+const hookNonceScope = "supabase_hook"
+
+// ReceiveSupabaseHook handles POST /hooks/supabase?project=<id or ref>, the
+// receiver for database webhooks and auth hooks configured on provisioned
+// projects. Requests are verified against SUPABASE_HOOK_SECRET instead of
+// an API key, and signed deliveries are handled once: a delivery ID seen
+// within the timestamp tolerance, even across restarts, is a retry or a
+// replay and is only acknowledged. Each event is recorded in the project's audit log and sent
+// to the notifiers. Auth hooks get an empty object back, which lets the
+// action proceed.
+func (h *Handler) ReceiveSupabaseHook(c *gin.Context) {
+	if h.hookSecret == "" {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "HOOKS_DISABLED",
+				Message: "The hook receiver is not enabled",
+				Details: "set SUPABASE_HOOK_SECRET on the manager to receive hooks",
+			},
+		})
+		return
+	}
+
+	body, err := io.ReadAll(io.LimitReader(c.Request.Body, maxHookBytes+1))
+	if err != nil || len(body) > maxHookBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "REQUEST_TOO_LARGE",
+				Message: "Hook body exceeds the size limit",
+				Details: fmt.Sprintf("the limit is %d bytes", maxHookBytes),
+			},
+		})
+		return
+	}
+
+	// Unsigned hooks carry no timestamp or delivery ID, so a captured
+	// request could be replayed at any time
+	if h.hookRequireSignature && c.GetHeader("webhook-signature") == "" {
+		c.JSON(http.StatusUnauthorized, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "UNAUTHORIZED",
+				Message: "Unsigned hooks are not accepted",
+				Details: "HOOK_REQUIRE_SIGNATURE is set; sign hooks following Standard Webhooks",
+			},
+		})
+		return
+	}
+
+	now := time.Now()
+	if err := supabase.VerifyHook(c.Request.Header, body, h.hookSecret, now); err != nil {
+		status := http.StatusUnauthorized
+		if !errors.Is(err, supabase.ErrHookUnauthorized) {
+			status = http.StatusInternalServerError
+		}
+		c.JSON(status, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "UNAUTHORIZED",
+				Message: "Invalid hook signature",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	project, err := h.hookProject(c.Query("project"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_FOUND",
+				Message: "Project not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	event, err := supabase.ParseHookEvent(body, c.Query("hook"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid hook payload",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	event.WebhookID = c.GetHeader("webhook-id")
+
+	if event.WebhookID != "" {
+		fresh, err := h.storage.ClaimNonce(hookNonceScope, event.WebhookID, now.Add(2*supabase.HookTimestampTolerance), now)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to check the hook delivery",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		if !fresh {
+			c.JSON(http.StatusOK, gin.H{})
+			return
+		}
+	}
+
+	h.auditAs(c, "supabase", auditHookReceived, project.ID, event.String())
+	h.notifyHook(project, event)
+
+	c.JSON(http.StatusOK, gin.H{})
+}
+
+// hookProject finds the project a hook was configured on, by ID or ref
+func (h *Handler) hookProject(idOrRef string) (*supabase.StoredProject, error) {
+	if idOrRef == "" {
+		return nil, fmt.Errorf("the hook URL must name the project with ?project=<id or ref>")
+	}
+	if project, err := h.storage.GetProject(idOrRef); err == nil {
+		return project, nil
+	}
+	return h.storage.GetProjectByRef(idOrRef)
+}
+
+// notifyHook sends a received event to the notifiers in the background,
+// since Supabase gives hooks only a few seconds to answer
+func (h *Handler) notifyHook(project *supabase.StoredProject, event *supabase.HookEvent) {
+	if h.notifier == nil {
+		return
+	}
+	h.runBackground(func() {
+		labels := map[string]string{
+			"project_id": project.ID,
+			"source":     event.Source,
+			"type":       event.Type,
+		}
+		if event.Table != "" {
+			labels["table"] = event.Schema + "." + event.Table
+		}
+		err := h.notifier.Notify(notify.Notification{
+			Severity: notify.SeverityInfo,
+			Title:    "Supabase event: " + project.Name,
+			Message:  event.String(),
+			Labels:   labels,
+			Time:     time.Now().UTC(),
+		})
+		if err != nil {
+			fmt.Printf("Warning: Failed to send hook notification for %s: %v\n", project.ID, err)
+		}
+	})
+}
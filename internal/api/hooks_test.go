@@ -0,0 +1,129 @@
+package api
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/pagination"
+)
+
+const testHookSecret = "hook-secret"
+
+// sendHook posts a database webhook for a project, signed following
+// Standard Webhooks when id is not empty and with the bare secret otherwise
+func (e *testEnv) sendHook(t *testing.T, projectID, id, secret string) *httptest.ResponseRecorder {
+	t.Helper()
+
+	body := []byte(`{"type":"INSERT","schema":"public","table":"todos","record":{"id":1}}`)
+	req := httptest.NewRequest(http.MethodPost, "/hooks/supabase?project="+projectID, bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	if id == "" {
+		req.Header.Set("X-Hook-Secret", secret)
+	} else {
+		timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+		mac := hmac.New(sha256.New, []byte(secret))
+		fmt.Fprintf(mac, "%s.%s.", id, timestamp)
+		mac.Write(body)
+		req.Header.Set("webhook-id", id)
+		req.Header.Set("webhook-timestamp", timestamp)
+		req.Header.Set("webhook-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	}
+
+	rec := httptest.NewRecorder()
+	e.router.ServeHTTP(rec, req)
+	return rec
+}
+
+// hookEvents counts the hooks recorded in a project's audit log
+func (e *testEnv) hookEvents(t *testing.T, projectID string) int {
+	t.Helper()
+
+	events, _, err := e.store.ListAuditEvents(projectID, pagination.Request{Limit: 100})
+	if err != nil {
+		t.Fatalf("list audit events: %v", err)
+	}
+	count := 0
+	for _, event := range events {
+		if event.Action == auditHookReceived {
+			count++
+		}
+	}
+	return count
+}
+
+func newHookEnv(t *testing.T, opts Options) *testEnv {
+	t.Helper()
+
+	opts.HookSecret = testHookSecret
+	env := newTestEnv(t, opts)
+	env.router.POST("/hooks/supabase", env.handler.ReceiveSupabaseHook)
+	env.saveProject(t, readyProject("hooked"))
+	return env
+}
+
+func TestReceiveHookVerifiesSecret(t *testing.T) {
+	env := newHookEnv(t, Options{})
+
+	if rec := env.sendHook(t, "hooked", "", testHookSecret); rec.Code != http.StatusOK {
+		t.Errorf("shared secret: got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := env.sendHook(t, "hooked", "msg_1", testHookSecret); rec.Code != http.StatusOK {
+		t.Errorf("signed: got %d %s", rec.Code, rec.Body.String())
+	}
+	for _, id := range []string{"", "msg_2"} {
+		rec := env.sendHook(t, "hooked", id, "wrong-secret")
+		if rec.Code != http.StatusUnauthorized || errorCode(t, rec) != "UNAUTHORIZED" {
+			t.Errorf("wrong secret (id %q): got %d %s", id, rec.Code, rec.Body.String())
+		}
+	}
+	if got := env.hookEvents(t, "hooked"); got != 2 {
+		t.Errorf("recorded %d hooks, want the 2 verified ones", got)
+	}
+}
+
+func TestReceiveHookIgnoresReplays(t *testing.T) {
+	env := newHookEnv(t, Options{})
+
+	for i := 0; i < 3; i++ {
+		if rec := env.sendHook(t, "hooked", "msg_1", testHookSecret); rec.Code != http.StatusOK {
+			t.Fatalf("delivery %d: got %d %s", i, rec.Code, rec.Body.String())
+		}
+	}
+	if got := env.hookEvents(t, "hooked"); got != 1 {
+		t.Errorf("recorded %d hooks for one delivery ID, want 1", got)
+	}
+
+	// The replay cache is kept in the database, so it holds across restarts
+	env.handler = NewHandler(env.fake.Client(), env.store, Options{HookSecret: testHookSecret})
+	t.Cleanup(env.handler.WaitForPendingTasks)
+	env.router = gin.New()
+	env.router.POST("/hooks/supabase", env.handler.ReceiveSupabaseHook)
+	if rec := env.sendHook(t, "hooked", "msg_1", testHookSecret); rec.Code != http.StatusOK {
+		t.Fatalf("replay after restart: got %d %s", rec.Code, rec.Body.String())
+	}
+	if got := env.hookEvents(t, "hooked"); got != 1 {
+		t.Errorf("replay after restart was recorded again: %d hooks", got)
+	}
+}
+
+func TestReceiveHookRequireSignature(t *testing.T) {
+	env := newHookEnv(t, Options{HookRequireSignature: true})
+
+	rec := env.sendHook(t, "hooked", "", testHookSecret)
+	if rec.Code != http.StatusUnauthorized {
+		t.Errorf("unsigned hook: got %d %s", rec.Code, rec.Body.String())
+	}
+	if rec := env.sendHook(t, "hooked", "msg_1", testHookSecret); rec.Code != http.StatusOK {
+		t.Errorf("signed hook: got %d %s", rec.Code, rec.Body.String())
+	}
+}
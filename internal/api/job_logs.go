@@ -0,0 +1,200 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	// maxJobLogLines caps the log of one job; later lines are dropped
+	maxJobLogLines = 5000
+	// jobLogPage is the most lines returned or streamed at once
+	jobLogPage = 500
+	// jobLogPoll is how often a followed log checks for new lines
+	jobLogPoll = 500 * time.Millisecond
+	// jobLogKeepAlive is how often a followed log with no new lines sends
+	// a comment, so proxies keep the stream open
+	jobLogKeepAlive = 15 * time.Second
+)
+
+// jobLog writes the log of a running job. A nil log discards its lines, so
+// code shared with synchronous requests can log unconditionally.
+type jobLog struct {
+	h     *Handler
+	jobID string
+
+	mu    sync.Mutex
+	lines int
+}
+
+// printf appends a line to the log
+func (l *jobLog) printf(format string, args ...interface{}) {
+	if l == nil {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.lines >= maxJobLogLines {
+		return
+	}
+	l.lines++
+	message := fmt.Sprintf(format, args...)
+	if l.lines == maxJobLogLines {
+		message = fmt.Sprintf("Log truncated after %d lines", maxJobLogLines)
+	}
+
+	line := &supabase.JobLogLine{JobID: l.jobID, Message: message, CreatedAt: time.Now().UTC()}
+	if err := l.h.storage.AppendJobLog(line); err != nil {
+		fmt.Printf("Warning: Failed to log for job %s: %v\n", l.jobID, err)
+	}
+}
+
+// logStatements logs each statement of the runner's migrations as it starts
+func (l *jobLog) logStatements(runner *supabase.MigrationRunner) {
+	if l == nil {
+		return
+	}
+	runner.OnStatement(func(n, total int, statement string) {
+		l.printf("Statement %d/%d: %s", n, total, statement[:min(len(statement), 100)])
+	})
+}
+
+// GetJobLogs handles GET /api/jobs/:id/logs. Lines after ?after are
+// returned as JSON; with ?follow=true they are streamed as server-sent
+// events until the job has finished, resuming after Last-Event-ID.
+func (h *Handler) GetJobLogs(c *gin.Context) {
+	job, ok := h.loadJob(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	cursor := c.Query("after")
+	if lastEventID := c.GetHeader("Last-Event-ID"); lastEventID != "" {
+		cursor = lastEventID
+	}
+	var after int64
+	if cursor != "" {
+		var err error
+		if after, err = strconv.ParseInt(cursor, 10, 64); err != nil || after < 0 {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid log cursor",
+					Details: fmt.Sprintf("Expected the ID of a log line, got %q", cursor),
+				},
+			})
+			return
+		}
+	}
+
+	if c.Query("follow") == "true" {
+		h.followJobLogs(c, job, after)
+		return
+	}
+
+	lines, err := h.storage.ListJobLogs(job.ID, after, jobLogPage)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to read job logs",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if len(lines) > 0 {
+		after = lines[len(lines)-1].ID
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"job_id": job.ID,
+		"status": job.Status,
+		"lines":  lines,
+		"after":  after,
+	})
+}
+
+// followJobLogs streams a job's log lines after the given ID as
+// server-sent "log" events, then a "done" event with the job once it has
+// finished and every line was sent. The stream also ends when the client
+// goes away or the manager stops.
+func (h *Handler) followJobLogs(c *gin.Context, job *supabase.Job, after int64) {
+	// Jobs outlast the server's write timeout
+	if err := http.NewResponseController(c.Writer).SetWriteDeadline(time.Time{}); err != nil {
+		fmt.Printf("Warning: Failed to lift the write deadline of a job log stream: %v\n", err)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	ticker := time.NewTicker(jobLogPoll)
+	defer ticker.Stop()
+	idle := time.Now()
+
+	for {
+		// Read the job before its lines, so no line logged before it
+		// finished is missed
+		current, err := h.storage.GetJob(job.ID)
+		if err != nil {
+			writeEvent(c, "error", 0, gin.H{"message": err.Error()})
+			return
+		}
+
+		lines, err := h.storage.ListJobLogs(job.ID, after, jobLogPage)
+		if err != nil {
+			writeEvent(c, "error", 0, gin.H{"message": err.Error()})
+			return
+		}
+		for _, line := range lines {
+			writeEvent(c, "log", line.ID, line)
+			after = line.ID
+		}
+
+		switch {
+		case len(lines) == jobLogPage:
+			c.Writer.Flush()
+			continue
+		case current.Done():
+			writeEvent(c, "done", 0, current)
+			c.Writer.Flush()
+			return
+		case len(lines) > 0:
+			idle = time.Now()
+		case time.Since(idle) >= jobLogKeepAlive:
+			fmt.Fprint(c.Writer, ": keepalive\n\n")
+			idle = time.Now()
+		}
+		c.Writer.Flush()
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-h.stop:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// writeEvent writes a server-sent event with its data as JSON, and an ID
+// unless it is 0
+func writeEvent(c *gin.Context, event string, id int64, data interface{}) {
+	encoded, _ := json.Marshal(data)
+	if id != 0 {
+		fmt.Fprintf(c.Writer, "id: %d\n", id)
+	}
+	fmt.Fprintf(c.Writer, "event: %s\ndata: %s\n\n", event, encoded)
+}
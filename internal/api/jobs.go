@@ -0,0 +1,230 @@
+package api
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/pagination"
+	"supabase-manager/internal/storage"
+	"supabase-manager/internal/supabase"
+)
+
+// startJob records a queued job for the caller and runs it in the
+// background. The value run returns is stored as the job's JSON result; an
+// error fails the job. run writes its progress to the job's log.
+func (h *Handler) startJob(c *gin.Context, jobType string, run func(logs *jobLog) (interface{}, error)) (*supabase.Job, error) {
+	return h.runJob(callerID(c), jobType, run)
+}
+
+// runJob records a queued job created by createdBy and runs it in the
+// background, like startJob
+func (h *Handler) runJob(createdBy, jobType string, run func(logs *jobLog) (interface{}, error)) (*supabase.Job, error) {
+	job := &supabase.Job{
+		ID:        uuid.New().String(),
+		Type:      jobType,
+		Status:    supabase.JobQueued,
+		CreatedBy: createdBy,
+		CreatedAt: time.Now().UTC(),
+	}
+	if err := h.storage.SaveJob(job); err != nil {
+		return nil, err
+	}
+
+	queued := *job
+	logs := &jobLog{h: h, jobID: job.ID}
+	h.runBackground(func() {
+		started := time.Now()
+		job.Status = supabase.JobRunning
+		job.StartedAt = &started
+		if err := h.storage.SaveJob(job); err != nil {
+			fmt.Printf("Warning: Failed to update job %s: %v\n", job.ID, err)
+		}
+
+		result, err := run(logs)
+		if err == nil {
+			job.Result, err = json.Marshal(result)
+		}
+
+		completed := time.Now().UTC()
+		job.CompletedAt = &completed
+		job.Status = supabase.JobSucceeded
+		if err != nil {
+			job.Status = supabase.JobFailed
+			job.Error = err.Error()
+			job.Result = nil
+			logs.printf("Job failed: %v", err)
+		} else {
+			logs.printf("Job succeeded after %s", completed.Sub(started).Round(time.Millisecond))
+		}
+		if err := h.storage.SaveJob(job); err != nil {
+			fmt.Printf("Warning: Failed to update job %s: %v\n", job.ID, err)
+		}
+	})
+
+	return &queued, nil
+}
+
+// FailInterruptedJobs marks jobs a previous run did not finish as failed
+func (h *Handler) FailInterruptedJobs() error {
+	count, err := h.storage.FailInterruptedJobs()
+	if err != nil {
+		return err
+	}
+	if count > 0 {
+		fmt.Printf("Warning: Marked %d interrupted jobs as failed\n", count)
+	}
+	return nil
+}
+
+// PurgeJobs deletes finished jobs once they completed more than retention
+// ago, every hour until the context is cancelled. A zero retention keeps
+// jobs forever.
+func (h *Handler) PurgeJobs(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		count, err := h.storage.PurgeJobs(time.Now().Add(-retention))
+		if err != nil {
+			fmt.Printf("Warning: Failed to purge jobs: %v\n", err)
+		} else if count > 0 {
+			fmt.Printf("Purged %d jobs finished more than %d days ago\n", count, int(retention.Hours()/24))
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// GetJob handles GET /api/jobs/:id
+func (h *Handler) GetJob(c *gin.Context) {
+	job, ok := h.loadJob(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, job)
+}
+
+// loadJob fetches a job the caller's API key may see, writing an error
+// response and returning false otherwise
+func (h *Handler) loadJob(c *gin.Context, jobID string) (*supabase.Job, bool) {
+	job, err := h.storage.GetJob(jobID)
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "JOB_NOT_FOUND",
+				Message: "Job not found",
+				Details: err.Error(),
+			},
+		})
+		return nil, false
+	}
+
+	if creator := jobCreatorScope(c); creator != "" && job.CreatedBy != creator {
+		respondJobForbidden(c)
+		return nil, false
+	}
+
+	return job, true
+}
+
+// jobCreatorScope returns the ID of the caller's API key if the key is
+// bound to organizations, or "" if it may see every job. Jobs can cover
+// projects of several organizations, so bound keys only see the jobs they
+// started.
+func jobCreatorScope(c *gin.Context) string {
+	if key := auth.FromContext(c); key != nil && len(key.Organizations) > 0 {
+		return key.ID
+	}
+	return ""
+}
+
+// respondJobForbidden refuses a bound API key the jobs of other callers
+func respondJobForbidden(c *gin.Context) {
+	c.JSON(http.StatusForbidden, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    "ORGANIZATION_FORBIDDEN",
+			Message: "API key is not allowed to see this job",
+			Details: "keys bound to organizations only see the jobs they started",
+		},
+	})
+}
+
+// ListJobs handles GET /api/jobs, newest first, optionally filtered by
+// ?type, ?status, ?created_by and the RFC 3339 creation bounds ?since and
+// ?until
+func (h *Handler) ListJobs(c *gin.Context) {
+	req, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+
+	filter := storage.JobFilter{
+		Type:      c.Query("type"),
+		Status:    c.Query("status"),
+		CreatedBy: c.Query("created_by"),
+	}
+	if filter.Since, ok = timeQuery(c, "since"); !ok {
+		return
+	}
+	if filter.Until, ok = timeQuery(c, "until"); !ok {
+		return
+	}
+	if creator := jobCreatorScope(c); creator != "" {
+		if filter.CreatedBy != "" && filter.CreatedBy != creator {
+			respondJobForbidden(c)
+			return
+		}
+		filter.CreatedBy = creator
+	}
+	jobs, page, err := h.storage.ListJobs(filter, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list jobs",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Envelope("jobs", jobs, page))
+}
+
+// timeQuery parses an optional RFC 3339 query parameter. It writes an error
+// response and returns false if the value is invalid.
+func timeQuery(c *gin.Context, param string) (*time.Time, bool) {
+	value := c.Query(param)
+	if value == "" {
+		return nil, true
+	}
+
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid " + param,
+				Details: fmt.Sprintf("Expected an RFC 3339 time such as 2024-05-01T00:00:00Z, got %q", value),
+			},
+		})
+		return nil, false
+	}
+	return &t, true
+}
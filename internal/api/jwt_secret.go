@@ -0,0 +1,175 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditJWTSecretViewed  = "jwt_secret.viewed"
+	auditJWTSecretRotated = supabase.AuditJWTSecretRotated
+)
+
+// GetJWTSecret handles GET /api/projects/:id/jwt-secret
+func (h *Handler) GetJWTSecret(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	secret, err := client.GetJWTSecret(storedProject.ProjectRef)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to get JWT secret", err)
+		return
+	}
+
+	h.audit(c, auditJWTSecretViewed, storedProject.ID, "")
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"jwt_secret": secret})
+}
+
+// RotateJWTSecret handles POST /api/projects/:id/jwt-secret/rotate. It
+// replaces the project's JWT secret, which invalidates the anon and service
+// role keys and every issued session, then stores the reissued keys. The
+// key refresh runs as a background job.
+func (h *Handler) RotateJWTSecret(c *gin.Context) {
+	var req supabase.RotateJWTSecretRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if !req.Confirm {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "CONFIRMATION_REQUIRED",
+				Message: "Rotating the JWT secret must be confirmed",
+				Details: "The anon and service role keys and all user sessions stop working; send \"confirm\": true",
+			},
+		})
+		return
+	}
+
+	if req.Secret != "" {
+		if err := supabase.ValidateJWTSecret(req.Secret); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid JWT secret",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "rotate_jwt_secret", gin.H{
+			"project_id":       storedProject.ID,
+			"generated_secret": req.Secret == "",
+			"invalidates":      []string{"anon_key", "service_role_key", "user_sessions"},
+		})
+		return
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		generated, err := supabase.GenerateJWTSecret()
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to generate JWT secret",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		secret = generated
+	}
+
+	if err := client.UpdateJWTSecret(storedProject.ProjectRef, secret); err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to rotate JWT secret", err)
+		return
+	}
+
+	h.audit(c, auditJWTSecretRotated, storedProject.ID, "anon and service role keys invalidated")
+
+	job, err := h.startJob(c, "jwt_secret_key_refresh", func(logs *jobLog) (interface{}, error) {
+		return h.refreshRotatedKeys(client, storedProject, logs)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "JWT secret rotated, but the key refresh could not be started",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusAccepted, gin.H{
+		"message":    "JWT secret rotated; refreshing API keys",
+		"jwt_secret": secret,
+		"job":        job,
+	})
+}
+
+// refreshRotatedKeys polls for the keys Supabase reissues after a JWT secret
+// rotation and stores them once they differ from the old ones
+func (h *Handler) refreshRotatedKeys(client *supabase.Client, project *supabase.StoredProject, logs *jobLog) (interface{}, error) {
+	delay := keyRetryInitialDelay
+
+	for attempt := 1; attempt <= keyRetryAttempts; attempt++ {
+		if !h.sleepOrStop(delay) {
+			return nil, fmt.Errorf("shutting down before the new API keys arrived")
+		}
+
+		apiKeys, err := client.GetProjectAPIKeys(project.ProjectRef)
+		if err == nil && apiKeys.AnonKey != "" && apiKeys.AnonKey != project.AnonKey {
+			if err := h.storage.UpdateProjectKeys(project.ID, apiKeys.AnonKey, apiKeys.ServiceKey, "ACTIVE_HEALTHY"); err != nil {
+				return nil, fmt.Errorf("failed to store the new API keys: %w", err)
+			}
+			h.recordKeys(project.ID, apiKeys.AnonKey, apiKeys.ServiceKey)
+			return gin.H{"project_id": project.ID, "keys_refreshed_at": time.Now().UTC()}, nil
+		}
+		fmt.Printf("New API keys for %s not available yet (attempt %d/%d): %v\n", project.ID, attempt, keyRetryAttempts, err)
+		logs.printf("New API keys not available yet (attempt %d/%d)", attempt, keyRetryAttempts)
+
+		delay *= 2
+		if delay > keyRetryMaxDelay {
+			delay = keyRetryMaxDelay
+		}
+	}
+
+	return nil, fmt.Errorf("Supabase did not reissue the API keys after %d attempts", keyRetryAttempts)
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// SetProjectLabels handles PUT /api/projects/:id/labels, replacing the
+// project's labels
+func (h *Handler) SetProjectLabels(c *gin.Context) {
+	var req supabase.ProjectLabelsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := supabase.ValidateLabels(req.Labels); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid labels",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	project, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "set_project_labels", gin.H{
+			"project_id": project.ID,
+			"labels":     req.Labels,
+			"previous":   project.Labels,
+		})
+		return
+	}
+
+	if err := h.storage.SetProjectLabels(project.ID, req.Labels); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to update labels",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if req.Labels == nil {
+		req.Labels = map[string]string{}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": project.ID,
+		"labels":     req.Labels,
+	})
+}
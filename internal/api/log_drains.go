@@ -0,0 +1,126 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditLogDrainCreated = "log_drain.created"
+	auditLogDrainDeleted = "log_drain.deleted"
+)
+
+// ListLogDrains handles GET /api/projects/:id/log-drains. Secret config
+// values are redacted.
+func (h *Handler) ListLogDrains(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	drains, err := client.ListLogDrains(storedProject.ProjectRef)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to list log drains", err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"log_drains": drains,
+		"total":      len(drains),
+	})
+}
+
+// CreateLogDrain handles POST /api/projects/:id/log-drains
+func (h *Handler) CreateLogDrain(c *gin.Context) {
+	var drain supabase.LogDrain
+	if err := c.ShouldBindJSON(&drain); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	drain.ID = ""
+
+	if err := drain.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid log drain",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "create_log_drain", gin.H{"project_id": storedProject.ID, "log_drain": drain})
+		return
+	}
+
+	created, err := client.CreateLogDrain(storedProject.ProjectRef, drain)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to create log drain", err)
+		return
+	}
+
+	h.audit(c, auditLogDrainCreated, storedProject.ID, fmt.Sprintf("%s %s (%s)", created.Type, created.Name, created.ID))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"message":   "Log drain created successfully",
+		"log_drain": created,
+	})
+}
+
+// DeleteLogDrain handles DELETE /api/projects/:id/log-drains/:drain
+func (h *Handler) DeleteLogDrain(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	drainID := c.Param("drain")
+	if isDryRun(c) {
+		respondDryRun(c, "delete_log_drain", gin.H{"project_id": storedProject.ID, "log_drain_id": drainID})
+		return
+	}
+
+	if err := client.DeleteLogDrain(storedProject.ProjectRef, drainID); err != nil {
+		respondManagementError(c, http.StatusBadGateway, "MANAGEMENT_API_ERROR", "Failed to delete log drain", err)
+		return
+	}
+
+	h.audit(c, auditLogDrainDeleted, storedProject.ID, drainID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message":      "Log drain deleted successfully",
+		"log_drain_id": drainID,
+	})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/supabase"
+)
+
+// Me handles GET /api/me, describing the calling API key
+func (h *Handler) Me(c *gin.Context) {
+	key := auth.FromContext(c)
+	if key == nil || h.keyring == nil {
+		c.JSON(http.StatusUnauthorized, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "UNAUTHORIZED",
+				Message: "API key required",
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"key_id": key.ID,
+		"name":   key.Name,
+		"scopes": key.Scopes,
+		"quotas": gin.H{
+			"requests_per_minute": key.RateLimit,
+		},
+		"usage":      h.keyring.Usage(key),
+		"request_id": c.GetString("request_id"),
+	})
+}
@@ -0,0 +1,151 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// checkMigrationBatch checks a batch of versioned migrations, each like a
+// submitted migration of its own. It writes an error response and returns
+// false if the batch may not run.
+func (h *Handler) checkMigrationBatch(c *gin.Context, targetID string, req *supabase.ApplySchemaRequest) bool {
+	if err := req.ValidateBatch(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid migrations",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+
+	role, ok := h.resolveMigrationRole(c, targetID, req.Role)
+	if !ok {
+		return false
+	}
+	req.Role = role
+
+	for _, step := range req.BatchSteps() {
+		if !h.checkSubmittedMigration(c, targetID, step) {
+			return false
+		}
+	}
+	return true
+}
+
+// runMigrationBatch applies a batch of versioned migrations to a database
+// target and writes the HTTP response
+func (h *Handler) runMigrationBatch(c *gin.Context, targetID string, target supabase.DatabaseTarget, req *supabase.ApplySchemaRequest) {
+	steps := req.BatchSteps()
+
+	if isDryRun(c) {
+		migrations := make([]gin.H, 0, len(steps))
+		for _, step := range steps {
+			plan, err := supabase.PlanMigration(step.SQL)
+			if err != nil {
+				c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+					Error: supabase.ErrorDetail{
+						Code:    "INVALID_SQL",
+						Message: "SQL validation failed",
+						Details: fmt.Sprintf("migration %s: %v", step.Version, err),
+					},
+				})
+				return
+			}
+			plan.Warnings = append(plan.Warnings, h.secretWarnings(step.SQL)...)
+			migrations = append(migrations, gin.H{
+				"version": step.Version,
+				"name":    step.Name,
+				"plan":    plan,
+			})
+		}
+
+		effect := gin.H{
+			"target_id":  targetID,
+			"role":       req.Role,
+			"migrations": migrations,
+		}
+		if limits := h.strictLimitsFor(req); limits != nil {
+			effect["strict"] = limits
+		}
+		respondDryRun(c, "apply_migrations", effect)
+		return
+	}
+
+	batch, err := h.applyMigrationBatch(targetID, target, req, nil)
+	if batch == nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "MIGRATION_FAILED",
+				Message: "Failed to connect to database",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err != nil {
+		failed := batch.Results[len(batch.Results)-1]
+		status, code := http.StatusInternalServerError, "MIGRATION_FAILED"
+		if errors.Is(err, supabase.ErrStrictLimitExceeded) {
+			status, code = http.StatusUnprocessableEntity, "STRICT_LIMIT_EXCEEDED"
+		}
+		applied := "none"
+		if len(batch.Applied) > 0 {
+			applied = strings.Join(batch.Applied, ", ")
+		}
+		c.JSON(status, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    code,
+				Message: fmt.Sprintf("Migration %s failed and was rolled back; the batch stopped there", failed.Version),
+				Details: fmt.Sprintf("%s (applied before it: %s)", failed.Error, applied),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, batch)
+}
+
+// applyMigrationBatch applies the migrations of a batch in version order
+// over one connection, skipping versions already applied and stopping at
+// the first failure. It is nil, with the error, if the database could not
+// be reached. Progress goes to logs.
+func (h *Handler) applyMigrationBatch(targetID string, target supabase.DatabaseTarget, req *supabase.ApplySchemaRequest, logs *jobLog) (*supabase.MigrationBatchResult, error) {
+	steps := req.BatchSteps()
+
+	runner, err := h.newMigrationRunner(target)
+	if err != nil {
+		record := newMigrationRecord(targetID, steps[0])
+		record.Error = err.Error()
+		h.recordMigration(record)
+		return nil, err
+	}
+	defer runner.Close()
+
+	batch := &supabase.MigrationBatchResult{
+		Applied: []string{},
+		Skipped: []string{},
+		Results: make([]*supabase.MigrationResult, 0, len(steps)),
+	}
+	for _, step := range steps {
+		result, err := h.applyWithRunner(runner, targetID, step, logs)
+		batch.Results = append(batch.Results, result)
+		if err != nil {
+			return batch, err
+		}
+		if result.Skipped {
+			batch.Skipped = append(batch.Skipped, step.Version)
+		} else {
+			batch.Applied = append(batch.Applied, step.Version)
+		}
+	}
+	return batch, nil
+}
@@ -0,0 +1,153 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/supabase"
+)
+
+// ImportProjectMigrations handles POST /api/projects/:id/migrations/import
+func (h *Handler) ImportProjectMigrations(c *gin.Context) {
+	projectID := c.Param("id")
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	h.importMigrations(c, projectID)
+}
+
+// ImportDatabaseMigrations handles POST /api/databases/:id/migrations/import
+func (h *Handler) ImportDatabaseMigrations(c *gin.Context) {
+	databaseID := c.Param("id")
+
+	if _, err := h.storage.GetDatabase(databaseID); err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.importMigrations(c, databaseID)
+}
+
+// importMigrations registers migrations applied outside the manager in a
+// target's history without running them. A version already applied
+// successfully is skipped, or reported as a conflict when its recorded
+// checksum differs; the rest are recorded as imported.
+func (h *Handler) importMigrations(c *gin.Context, targetID string) {
+	var req supabase.ImportMigrationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid migrations",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	history, err := h.storage.ListMigrations(targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list migrations",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	applied := make(map[string]*supabase.MigrationRecord)
+	for _, record := range history {
+		if _, ok := applied[record.Version]; !ok && record.Success {
+			applied[record.Version] = record
+		}
+	}
+
+	now := time.Now().UTC()
+	var records []*supabase.MigrationRecord
+	results := make([]supabase.ImportedMigrationResult, 0, len(req.Migrations))
+	counts := map[string]int{}
+	for _, migration := range req.Migrations {
+		version, _ := migration.Version()
+		checksum, _ := migration.Checksum()
+		result := supabase.ImportedMigrationResult{File: migration.File, Version: version, Status: supabase.ImportStatusImported}
+
+		if existing, ok := applied[version]; ok {
+			result.Status = supabase.ImportStatusSkipped
+			result.Details = "already applied as " + existing.Name
+			if existing.SourceSHA256 != "" && existing.SourceSHA256 != checksum {
+				result.Status = supabase.ImportStatusConflict
+				result.Details = fmt.Sprintf("already applied as %s with checksum %s", existing.Name, existing.SourceSHA256)
+			}
+		} else {
+			record := &supabase.MigrationRecord{
+				ID:           uuid.New().String(),
+				TargetID:     targetID,
+				Version:      version,
+				Name:         migration.File,
+				Author:       callerID(c),
+				Success:      true,
+				AppliedAt:    now,
+				SourceSHA256: checksum,
+				Imported:     true,
+				SQL:          migration.SQL,
+			}
+			if migration.AppliedAt != nil {
+				record.AppliedAt = *migration.AppliedAt
+			}
+			records = append(records, record)
+		}
+
+		counts[result.Status]++
+		results = append(results, result)
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "import_migrations", gin.H{"target_id": targetID, "migrations": results})
+		return
+	}
+
+	for _, record := range records {
+		if err := h.storage.SaveMigration(record); err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to record imported migration",
+					Details: fmt.Sprintf("%s: %v; migrations before it were imported", record.Name, err),
+				},
+			})
+			return
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"target_id":  targetID,
+		"imported":   counts[supabase.ImportStatusImported],
+		"skipped":    counts[supabase.ImportStatusSkipped],
+		"conflicts":  counts[supabase.ImportStatusConflict],
+		"migrations": results,
+	})
+}
@@ -0,0 +1,646 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/pagination"
+	"supabase-manager/internal/policy"
+	"supabase-manager/internal/supabase"
+)
+
+// newMigrationVersion returns a timestamp-based version for a migration
+func newMigrationVersion() string {
+	return time.Now().UTC().Format("20060102150405")
+}
+
+// newMigrationRunner connects to a target for a migration run, as a
+// temporary role when ephemeral database roles are enabled
+func (h *Handler) newMigrationRunner(target supabase.DatabaseTarget) (*supabase.MigrationRunner, error) {
+	return h.connectDB(target, true)
+}
+
+// runSubmittedMigration runs caller-submitted SQL under the target's
+// migration role
+func (h *Handler) runSubmittedMigration(c *gin.Context, targetID string, target supabase.DatabaseTarget, req *supabase.ApplySchemaRequest) {
+	if h.shedMigration(c) {
+		return
+	}
+
+	if !h.checkSubmittedMigration(c, targetID, req) {
+		return
+	}
+
+	h.runMigration(c, targetID, target, req)
+}
+
+// checkSubmittedMigration resolves the SQL of a submitted migration and
+// checks it, its verifications and its role against the target. It writes
+// an error response and returns false if the migration may not run.
+func (h *Handler) checkSubmittedMigration(c *gin.Context, targetID string, req *supabase.ApplySchemaRequest) bool {
+	if len(req.Migrations) > 0 {
+		return h.checkMigrationBatch(c, targetID, req)
+	}
+
+	if req.Version != "" {
+		if err := supabase.ValidateMigrationVersion(req.Version); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid migration version",
+					Details: err.Error(),
+				},
+			})
+			return false
+		}
+	}
+
+	if !h.resolveSchemaSource(c, req) {
+		return false
+	}
+
+	if err := supabase.ValidateVerifications(req.Verify, req.OnVerifyFailure); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid verification queries",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+
+	if req.Strict != nil {
+		if err := req.Strict.Validate(); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid strict mode limits",
+					Details: err.Error(),
+				},
+			})
+			return false
+		}
+	}
+
+	if !h.checkSecrets(c, targetID, req.SQL) {
+		return false
+	}
+
+	role, ok := h.resolveMigrationRole(c, targetID, req.Role)
+	if !ok {
+		return false
+	}
+	req.Role = role
+
+	return h.checkPolicy(c, policy.OperationApplySchema, targetID, schemaPolicyRequest(targetID, req))
+}
+
+// runMigration applies SQL to a database target as req.Role (the connecting
+// user when empty), records it in the migration history and writes the HTTP
+// response
+func (h *Handler) runMigration(c *gin.Context, targetID string, target supabase.DatabaseTarget, req *supabase.ApplySchemaRequest) {
+	// Every migration run for a request passes here, so freeze windows
+	// cover them all. A simulation changes nothing and may run during a
+	// freeze.
+	if !isSimulation(c) && !h.checkTargetFreeze(c, supabase.FreezeMigrations, targetID) {
+		return
+	}
+
+	if isSimulation(c) {
+		h.simulateMigration(c, targetID, target, req)
+		return
+	}
+	if len(req.Migrations) > 0 {
+		h.runMigrationBatch(c, targetID, target, req)
+		return
+	}
+
+	role := req.Role
+	secretWarnings := h.secretWarnings(req.SQL)
+
+	if isDryRun(c) {
+		plan, err := supabase.PlanMigration(req.SQL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_SQL",
+					Message: "SQL validation failed",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		plan.Warnings = append(plan.Warnings, secretWarnings...)
+
+		effect := gin.H{
+			"target_id": targetID,
+			"name":      req.Name,
+			"role":      role,
+			"plan":      plan,
+		}
+		if req.Version != "" {
+			effect["version"] = req.Version
+		}
+		if req.SourceURL != "" {
+			effect["source"] = req.SourceURL
+			effect["source_sha256"] = req.SHA256
+		}
+		if len(req.Verify) > 0 {
+			effect["verify"] = req.Verify
+		}
+		if limits := h.strictLimitsFor(req); limits != nil {
+			effect["strict"] = limits
+		}
+		respondDryRun(c, "apply_migration", effect)
+		return
+	}
+
+	result, err := h.applyMigration(targetID, target, req, nil)
+	if result == nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "MIGRATION_FAILED",
+				Message: "Failed to connect to database",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if errors.Is(err, supabase.ErrStrictLimitExceeded) {
+		c.JSON(http.StatusUnprocessableEntity, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "STRICT_LIMIT_EXCEEDED",
+				Message: "Migration rolled back: a statement exceeds the strict mode limits",
+				Details: result.Error,
+			},
+		})
+		return
+	}
+	if errors.Is(err, supabase.ErrVerificationFailed) {
+		c.JSON(http.StatusUnprocessableEntity, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "VERIFICATION_FAILED",
+				Message: "Migration rolled back: verification failed",
+				Details: supabase.FailedVerifications(result.Verifications),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "MIGRATION_FAILED",
+				Message: "Failed to apply schema",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// applyMigration applies SQL to a database target as req.Role and records
+// it in the migration history. The result carries the migration's ID and
+// version; it is nil, with the error, if the database could not be reached.
+// Progress goes to logs.
+func (h *Handler) applyMigration(targetID string, target supabase.DatabaseTarget, req *supabase.ApplySchemaRequest, logs *jobLog) (*supabase.MigrationResult, error) {
+	// Create migration runner
+	runner, err := h.newMigrationRunner(target)
+	if err != nil {
+		record := newMigrationRecord(targetID, req)
+		record.Error = err.Error()
+		h.recordMigration(record)
+		return nil, err
+	}
+	defer runner.Close()
+
+	return h.applyWithRunner(runner, targetID, req, logs)
+}
+
+// newMigrationRecord starts the history entry of a migration, under its
+// requested version or a new timestamp version
+func newMigrationRecord(targetID string, req *supabase.ApplySchemaRequest) *supabase.MigrationRecord {
+	record := &supabase.MigrationRecord{
+		ID:          uuid.New().String(),
+		TargetID:    targetID,
+		Version:     req.Version,
+		Name:        req.Name,
+		Description: req.Description,
+		Author:      req.Author,
+		Ticket:      req.Ticket,
+		Role:        req.Role,
+		AppliedAt:   time.Now().UTC(),
+		SQL:         req.SQL,
+	}
+	if record.Version == "" {
+		record.Version = newMigrationVersion()
+	}
+	if req.SourceURL != "" {
+		record.Source = req.SourceURL
+		record.SourceSHA256 = req.SHA256
+	}
+	return record
+}
+
+// applyWithRunner applies a migration over an open runner. A versioned
+// migration whose version is already in the database's migration table is
+// skipped without a history entry; otherwise its version is recorded there
+// in the migration's transaction.
+func (h *Handler) applyWithRunner(runner *supabase.MigrationRunner, targetID string, req *supabase.ApplySchemaRequest, logs *jobLog) (*supabase.MigrationResult, error) {
+	role := req.Role
+	secretWarnings := h.secretWarnings(req.SQL)
+	record := newMigrationRecord(targetID, req)
+
+	if req.Version != "" {
+		applied, err := h.appliedVersions(runner)
+		if err != nil {
+			record.Error = err.Error()
+			h.recordMigration(record)
+			return &supabase.MigrationResult{Error: record.Error, Version: req.Version}, err
+		}
+		if applied[req.Version] {
+			logs.printf("Migration %s skipped: already applied", req.Version)
+			return &supabase.MigrationResult{Success: true, Skipped: true, Version: req.Version}, nil
+		}
+	}
+	runner.SetVersion(req.Version, req.Name)
+
+	runner.SetStrictLimits(h.strictLimitsFor(req))
+
+	// Check for Supabase-specific pitfalls before executing
+	warnings := append(supabase.LintMigration(req.SQL, runner.GetRowCount), secretWarnings...)
+
+	// Apply migration
+	logs.printf("Applying migration %s", record.Version)
+	logs.logStatements(runner)
+	result, err := runner.ApplyVerifiedMigration(req.SQL, role, req.Verify, req.OnVerifyFailure)
+	if result.Success {
+		logs.printf("Migration %s committed: %d statements in %s", record.Version, result.StatementsRun, result.ExecutionTime.Round(time.Millisecond))
+	} else {
+		logs.printf("Migration %s rolled back: %s", record.Version, result.Error)
+	}
+	result.Warnings = warnings
+	record.Success = result.Success
+	record.StatementsRun = result.StatementsRun
+	record.ExecutionTimeMs = result.ExecutionTime.Milliseconds()
+	record.Error = result.Error
+	if result.Success && result.VerificationFailed {
+		record.Error = "verification failed, flagged: " + supabase.FailedVerifications(result.Verifications)
+	}
+	h.recordMigration(record)
+
+	result.MigrationID = record.ID
+	result.Version = record.Version
+	return result, err
+}
+
+// appliedVersions creates the migration table of the runner's database if
+// needed and returns the versions recorded in it
+func (h *Handler) appliedVersions(runner *supabase.MigrationRunner) (map[string]bool, error) {
+	if err := runner.EnsureMigrationTable(); err != nil {
+		return nil, err
+	}
+	return runner.AppliedVersions()
+}
+
+// strictLimitsFor returns the strict mode limits of a migration, with the
+// manager's defaults for limits it leaves unset, or nil if it does not run
+// in strict mode
+func (h *Handler) strictLimitsFor(req *supabase.ApplySchemaRequest) *supabase.StrictLimits {
+	if req.Strict == nil && !h.strictMigrations {
+		return nil
+	}
+	var limits supabase.StrictLimits
+	if req.Strict != nil {
+		limits = *req.Strict
+	}
+	limits = limits.WithDefaults(h.strictLimits)
+	return &limits
+}
+
+// LintSQL handles POST /api/lint
+func (h *Handler) LintSQL(c *gin.Context) {
+	var req supabase.LintRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	warnings := append(supabase.LintMigration(req.SQL, nil), supabase.SecretWarnings(supabase.ScanSecrets(req.SQL))...)
+	if warnings == nil {
+		warnings = []supabase.LintWarning{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"warnings": warnings,
+		"total":    len(warnings),
+	})
+}
+
+// resolveMigrationRole picks the role a migration runs as: the requested
+// role if the target allows it, otherwise the target's default role. It
+// writes an error response and returns false if the role is not allowed.
+func (h *Handler) resolveMigrationRole(c *gin.Context, targetID, requested string) (string, bool) {
+	roles, err := h.storage.GetMigrationRoles(targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to load migration roles",
+				Details: err.Error(),
+			},
+		})
+		return "", false
+	}
+
+	role := requested
+	if role == "" {
+		role = roles.DefaultRole
+	}
+
+	if role != "" && !roles.Allows(role) {
+		c.JSON(http.StatusForbidden, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "ROLE_NOT_ALLOWED",
+				Message: "Migrations cannot run as this role",
+				Details: fmt.Sprintf("Role %q is not in the target's migration roles %v", role, roles.Roles),
+			},
+		})
+		return "", false
+	}
+
+	return role, true
+}
+
+// recordMigration stores a history entry, logging on failure, and drops
+// the cached schema of its target
+func (h *Handler) recordMigration(record *supabase.MigrationRecord) {
+	h.schemas.invalidate(record.TargetID)
+	if err := h.storage.SaveMigration(record); err != nil {
+		fmt.Printf("Warning: Failed to record migration %s: %v\n", record.ID, err)
+	}
+}
+
+// ListProjectMigrations handles GET /api/projects/:id/migrations
+func (h *Handler) ListProjectMigrations(c *gin.Context) {
+	projectID := c.Param("id")
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	h.listMigrations(c, projectID)
+}
+
+// ListDatabaseMigrations handles GET /api/databases/:id/migrations
+func (h *Handler) ListDatabaseMigrations(c *gin.Context) {
+	databaseID := c.Param("id")
+
+	if _, err := h.storage.GetDatabase(databaseID); err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.listMigrations(c, databaseID)
+}
+
+// GetProjectMigrationSQL handles GET /api/projects/:id/migrations/:version/sql
+func (h *Handler) GetProjectMigrationSQL(c *gin.Context) {
+	projectID := c.Param("id")
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	h.getMigrationSQL(c, projectID)
+}
+
+// GetDatabaseMigrationSQL handles GET /api/databases/:id/migrations/:version/sql
+func (h *Handler) GetDatabaseMigrationSQL(c *gin.Context) {
+	databaseID := c.Param("id")
+
+	if _, err := h.storage.GetDatabase(databaseID); err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.getMigrationSQL(c, databaseID)
+}
+
+// getMigrationSQL writes the SQL of a target's migration, as JSON or, with
+// ?format=raw, as plain SQL
+func (h *Handler) getMigrationSQL(c *gin.Context, targetID string) {
+	record, err := h.storage.GetMigration(targetID, c.Param("version"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "MIGRATION_NOT_FOUND",
+				Message: "Migration not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if record.SQL == "" {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SQL_NOT_AVAILABLE",
+				Message: "SQL was not stored for this migration",
+				Details: "Migrations applied before SQL retention was added have no stored script",
+			},
+		})
+		return
+	}
+
+	if c.Query("format") == "raw" {
+		c.Data(http.StatusOK, "application/sql; charset=utf-8", []byte(record.SQL))
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":         record.ID,
+		"version":    record.Version,
+		"name":       record.Name,
+		"success":    record.Success,
+		"applied_at": record.AppliedAt,
+		"sql":        record.SQL,
+	})
+}
+
+// listMigrations writes a page of the migration history of a target
+func (h *Handler) listMigrations(c *gin.Context, targetID string) {
+	req, ok := pageRequest(c)
+	if !ok {
+		return
+	}
+
+	records, page, err := h.storage.ListMigrationsPage(targetID, req)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list migrations",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, pagination.Envelope("migrations", records, page))
+}
+
+// GetProjectMigrationRoles handles GET /api/projects/:id/migration-roles
+func (h *Handler) GetProjectMigrationRoles(c *gin.Context) {
+	projectID := c.Param("id")
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	h.getMigrationRoles(c, projectID)
+}
+
+// SetProjectMigrationRoles handles PUT /api/projects/:id/migration-roles
+func (h *Handler) SetProjectMigrationRoles(c *gin.Context) {
+	projectID := c.Param("id")
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	h.setMigrationRoles(c, projectID)
+}
+
+// GetDatabaseMigrationRoles handles GET /api/databases/:id/migration-roles
+func (h *Handler) GetDatabaseMigrationRoles(c *gin.Context) {
+	databaseID := c.Param("id")
+
+	if _, err := h.storage.GetDatabase(databaseID); err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.getMigrationRoles(c, databaseID)
+}
+
+// SetDatabaseMigrationRoles handles PUT /api/databases/:id/migration-roles
+func (h *Handler) SetDatabaseMigrationRoles(c *gin.Context) {
+	databaseID := c.Param("id")
+
+	if _, err := h.storage.GetDatabase(databaseID); err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "DATABASE_NOT_FOUND",
+				Message: "Database not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.setMigrationRoles(c, databaseID)
+}
+
+// getMigrationRoles writes the migration roles of a target
+func (h *Handler) getMigrationRoles(c *gin.Context, targetID string) {
+	roles, err := h.storage.GetMigrationRoles(targetID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to load migration roles",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
+
+// setMigrationRoles validates and replaces the migration roles of a target
+func (h *Handler) setMigrationRoles(c *gin.Context, targetID string) {
+	var req supabase.MigrationRolesRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	roles := &supabase.MigrationRoles{
+		TargetID:    targetID,
+		Roles:       req.Roles,
+		DefaultRole: req.DefaultRole,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if roles.Roles == nil {
+		roles.Roles = []string{}
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_ROLE",
+				Message: "Invalid migration role",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "set_migration_roles", gin.H{"migration_roles": roles})
+		return
+	}
+
+	if err := h.storage.SaveMigrationRoles(roles); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to save migration roles",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, roles)
+}
@@ -0,0 +1,127 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// maxNameSuffix bounds the search for a free name-N suffix
+const maxNameSuffix = 100
+
+// nameConflictError reports a project name that is already taken
+type nameConflictError struct {
+	name string
+}
+
+func (e *nameConflictError) Error() string {
+	return fmt.Sprintf("a project named %q already exists", e.name)
+}
+
+// resolveProjectName returns the name to create a project under, applying
+// the request's name conflict strategy. Local projects are always checked;
+// the organization's Supabase projects only with check_remote. Callers must
+// hold h.naming until the project is saved, so concurrent requests cannot
+// pick the same name.
+func (h *Handler) resolveProjectName(client *supabase.Client, req *supabase.CreateProjectRequest) (string, error) {
+	taken, err := h.takenNames(client, req.CheckRemote)
+	if err != nil {
+		return "", err
+	}
+
+	name := req.Name
+	isTaken := func(candidate string) (bool, error) {
+		if taken[candidate] {
+			return true, nil
+		}
+		return h.storage.ProjectNameTaken(candidate)
+	}
+
+	used, err := isTaken(name)
+	if err != nil || !used {
+		return name, err
+	}
+
+	// Candidates that break the naming policy, such as a suffixed name over
+	// the length limit, are not used
+	namingPolicy := h.projectNamingPolicy()
+	switch req.NameConflict {
+	case supabase.NameConflictSuffix:
+		for i := 2; i <= maxNameSuffix; i++ {
+			candidate := namingPolicy.Disambiguate(name, strconv.Itoa(i), req.Labels)
+			if namingPolicy.Check(candidate, req.Labels) != nil {
+				continue
+			}
+			used, err := isTaken(candidate)
+			if err != nil {
+				return "", err
+			}
+			if !used {
+				return candidate, nil
+			}
+		}
+	case supabase.NameConflictTimestamp:
+		candidate := namingPolicy.Disambiguate(name, time.Now().UTC().Format("20060102150405"), req.Labels)
+		if namingPolicy.Check(candidate, req.Labels) != nil {
+			break
+		}
+		used, err := isTaken(candidate)
+		if err != nil {
+			return "", err
+		}
+		if !used {
+			return candidate, nil
+		}
+		name = candidate
+	}
+
+	return "", &nameConflictError{name: name}
+}
+
+// takenNames returns the names of the organization's Supabase projects when
+// checkRemote is set
+func (h *Handler) takenNames(client *supabase.Client, checkRemote bool) (map[string]bool, error) {
+	taken := make(map[string]bool)
+	if !checkRemote {
+		return taken, nil
+	}
+
+	projects, err := client.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Supabase projects: %w", err)
+	}
+	for _, project := range projects {
+		taken[project.Name] = true
+	}
+	return taken, nil
+}
+
+// validNameConflict reports whether a name conflict strategy is supported
+func validNameConflict(strategy string) bool {
+	switch strategy {
+	case "", supabase.NameConflictError, supabase.NameConflictSuffix, supabase.NameConflictTimestamp:
+		return true
+	}
+	return false
+}
+
+// respondNamingPolicyViolation writes the error response for a name that
+// breaks the naming policy, suggesting a name that follows it
+func respondNamingPolicyViolation(c *gin.Context, policy *supabase.NamingPolicy, name string, labels map[string]string, err error) {
+	details := err.Error()
+	if suggestion, err := policy.Apply(name, labels); err == nil {
+		details += fmt.Sprintf("; for example %q", suggestion)
+	}
+	c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    "NAME_POLICY_VIOLATION",
+			Message: "Project name does not follow the naming policy",
+			Details: details,
+		},
+	})
+}
@@ -0,0 +1,164 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const auditOrgDefaultsUpdated = "org.defaults_updated"
+
+// GetOrgDefaults handles GET /api/org/defaults. It returns the stored
+// organization defaults and the defaults new projects get once the
+// deployment's configuration fills in the rest.
+func (h *Handler) GetOrgDefaults(c *gin.Context) {
+	stored, err := h.storage.GetOrgDefaults()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get organization defaults",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"defaults":  stored,
+		"effective": h.effectiveDefaults(stored),
+	})
+}
+
+// UpdateOrgDefaults handles PUT /api/org/defaults, replacing the stored
+// organization defaults. Defaults left out fall back to the deployment's
+// configuration, so an empty body clears them all. The change applies to
+// projects created from then on.
+func (h *Handler) UpdateOrgDefaults(c *gin.Context) {
+	var req supabase.OrgDefaults
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	req.UpdatedAt, req.UpdatedBy = nil, ""
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid organization defaults",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if !h.checkRegion(c, req.Region) {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "update_org_defaults", gin.H{
+			"defaults":  req,
+			"effective": h.effectiveDefaults(&req),
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	req.UpdatedAt = &now
+	req.UpdatedBy = callerID(c)
+	if err := h.storage.SaveOrgDefaults(&req); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to save organization defaults",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	h.audit(c, auditOrgDefaultsUpdated, "", orgDefaultsSummary(&req))
+
+	c.JSON(http.StatusOK, gin.H{
+		"defaults":  req,
+		"effective": h.effectiveDefaults(&req),
+	})
+}
+
+// orgDefaultsSummary describes which defaults are set, for the audit log
+func orgDefaultsSummary(defaults *supabase.OrgDefaults) string {
+	return fmt.Sprintf("region=%q plan=%q naming_policy=%t security_baseline=%t",
+		defaults.Region, defaults.Plan, defaults.NamingPolicy != nil, defaults.SecurityBaseline != nil)
+}
+
+// effectiveDefaults returns the defaults of new projects: the stored
+// organization defaults over the deployment's configuration
+func (h *Handler) effectiveDefaults(stored *supabase.OrgDefaults) supabase.OrgDefaults {
+	effective := supabase.OrgDefaults{
+		Region:           h.defaultRegion,
+		Plan:             defaultPlan,
+		NamingPolicy:     &h.namingPolicy,
+		SecurityBaseline: &h.authBaseline,
+	}
+	if stored == nil {
+		return effective
+	}
+	if stored.Region != "" {
+		effective.Region = stored.Region
+	}
+	if stored.Plan != "" {
+		effective.Plan = stored.Plan
+	}
+	if stored.NamingPolicy != nil {
+		policy := *stored.NamingPolicy
+		if policy.TeamLabel == "" {
+			policy.TeamLabel = h.namingPolicy.TeamLabel
+		}
+		effective.NamingPolicy = &policy
+	}
+	if stored.SecurityBaseline != nil {
+		baseline := h.authBaseline.Merge(*stored.SecurityBaseline)
+		effective.SecurityBaseline = &baseline
+	}
+	effective.UpdatedAt = stored.UpdatedAt
+	effective.UpdatedBy = stored.UpdatedBy
+	return effective
+}
+
+// currentDefaults returns the defaults of new projects as stored now. If
+// the stored defaults can't be read, the deployment's configuration is
+// used alone.
+func (h *Handler) currentDefaults() supabase.OrgDefaults {
+	stored, err := h.storage.GetOrgDefaults()
+	if err != nil {
+		fmt.Printf("Warning: Using the configured defaults: %v\n", err)
+	}
+	return h.effectiveDefaults(stored)
+}
+
+// projectNamingPolicy returns the naming policy projects are created and
+// renamed under
+func (h *Handler) projectNamingPolicy() *supabase.NamingPolicy {
+	return h.currentDefaults().NamingPolicy
+}
+
+// applyOrgDefaults fills the region and plan a create request leaves unset
+// from the current defaults
+func (h *Handler) applyOrgDefaults(req *supabase.CreateProjectRequest) {
+	defaults := h.currentDefaults()
+	if req.Region == "" {
+		req.Region = defaults.Region
+	}
+	if req.Plan == "" {
+		req.Plan = defaults.Plan
+	}
+}
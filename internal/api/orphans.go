@@ -0,0 +1,351 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/storage"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditOrphanIgnored   = "remote_orphan.ignored"
+	auditOrphanUnignored = "remote_orphan.unignored"
+)
+
+// goneRemoteStatuses are the statuses of remote projects on their way out,
+// which are not reported as orphans
+var goneRemoteStatuses = map[string]bool{
+	"GOING_DOWN": true,
+	"REMOVED":    true,
+}
+
+// remoteOrphans compares the organization's projects in Supabase with the
+// projects the manager tracks, including deleted projects not yet purged,
+// and returns the untracked ones sorted by ref. Orphans on the ignore list
+// are included with Ignored set.
+func (h *Handler) remoteOrphans(client *supabase.Client) ([]*supabase.RemoteOrphan, error) {
+	remote, err := client.ListProjects()
+	if err != nil {
+		return nil, fmt.Errorf("failed to list Supabase projects: %w", err)
+	}
+
+	tracked := make(map[string]bool)
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		tracked[project.ProjectRef] = true
+	}
+	deleted, err := h.storage.ListDeletedProjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range deleted {
+		tracked[project.ProjectRef] = true
+	}
+
+	ignored, err := h.storage.ListIgnoredOrphans()
+	if err != nil {
+		return nil, err
+	}
+
+	orphans := []*supabase.RemoteOrphan{}
+	for _, project := range remote {
+		if project.ProjectRef == "" || tracked[project.ProjectRef] || goneRemoteStatuses[project.Status] {
+			continue
+		}
+		orphan := &supabase.RemoteOrphan{
+			ProjectRef: project.ProjectRef,
+			Name:       project.Name,
+			Region:     project.Region,
+			Status:     project.Status,
+			CreatedAt:  project.CreatedAt,
+		}
+		if ignore, ok := ignored[project.ProjectRef]; ok {
+			orphan.Ignored = true
+			orphan.Ignore = ignore
+		}
+		orphans = append(orphans, orphan)
+	}
+	sort.Slice(orphans, func(i, j int) bool { return orphans[i].ProjectRef < orphans[j].ProjectRef })
+
+	return orphans, nil
+}
+
+// findRemoteOrphan returns the orphan with the given ref. It writes an
+// error response and returns false if the ref is tracked, unknown, or the
+// comparison failed.
+func (h *Handler) findRemoteOrphan(c *gin.Context, client *supabase.Client, ref string) (*supabase.RemoteOrphan, bool) {
+	if project, err := h.storage.GetProjectByRef(ref); err == nil {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_ALREADY_TRACKED",
+				Message: "Project is already tracked by the manager",
+				Details: "project " + project.ID,
+			},
+		})
+		return nil, false
+	}
+
+	orphans, err := h.remoteOrphans(client)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "REMOTE_LIST_FAILED", "Failed to compare remote projects", err)
+		return nil, false
+	}
+	for _, orphan := range orphans {
+		if orphan.ProjectRef == ref {
+			return orphan, true
+		}
+	}
+
+	c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    "REMOTE_ORPHAN_NOT_FOUND",
+			Message: "No untracked remote project with this ref",
+			Details: ref,
+		},
+	})
+	return nil, false
+}
+
+// ListRemoteOrphans handles GET /api/remote-orphans. It lists the
+// organization's Supabase projects the manager does not track, so drift
+// can be resolved by adopting or ignoring them. Ignored projects are only
+// listed with include_ignored=true.
+func (h *Handler) ListRemoteOrphans(c *gin.Context) {
+	client, ok := h.clientFor(c, nil)
+	if !ok {
+		return
+	}
+
+	orphans, err := h.remoteOrphans(client)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "REMOTE_LIST_FAILED", "Failed to compare remote projects", err)
+		return
+	}
+
+	includeIgnored := c.Query("include_ignored") == "true"
+	listed := []*supabase.RemoteOrphan{}
+	ignoredCount := 0
+	for _, orphan := range orphans {
+		if orphan.Ignored {
+			ignoredCount++
+			if !includeIgnored {
+				continue
+			}
+		}
+		listed = append(listed, orphan)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"orphans":       listed,
+		"count":         len(listed),
+		"ignored_count": ignoredCount,
+	})
+}
+
+// AdoptRemoteOrphan handles POST /api/remote-orphans/:ref/adopt. It imports
+// an untracked remote project with its status, region, database details
+// and API keys, and takes it off the ignore list. The database password of
+// an adopted project is not known, so SQL can't be applied to it.
+func (h *Handler) AdoptRemoteOrphan(c *gin.Context) {
+	var req supabase.AdoptProjectRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid request body",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+	if err := supabase.ValidateLabels(req.Labels); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid labels",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	client, ok := h.clientFor(c, nil)
+	if !ok {
+		return
+	}
+
+	ref := c.Param("ref")
+	orphan, ok := h.findRemoteOrphan(c, client, ref)
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "adopt_project", gin.H{
+			"project_ref": orphan.ProjectRef,
+			"name":        orphan.Name,
+			"region":      orphan.Region,
+			"status":      orphan.Status,
+			"labels":      req.Labels,
+			"ignored":     orphan.Ignored,
+		})
+		return
+	}
+
+	project, err := client.GetProject(ref)
+	if err != nil {
+		respondManagementError(c, http.StatusBadGateway, "REMOTE_PROJECT_UNAVAILABLE", "Failed to fetch the remote project", err)
+		return
+	}
+	if project.Region == "" {
+		project.Region = orphan.Region
+	}
+	project.ID = uuid.New().String()
+
+	stored := project.ToStoredProject()
+	stored.BYOCredentials = client != h.supabaseClient
+	stored.OrganizationID = client.OrganizationID()
+	stored.Labels = req.Labels
+
+	// A healthy project whose keys are not available yet is adopted as
+	// ACTIVE_PENDING_KEYS, like a new one, and its keys are retried
+	apiKeys, err := client.GetProjectAPIKeys(ref)
+	if err != nil {
+		fmt.Printf("Warning: Failed to fetch API keys of adopted project %s: %v\n", ref, err)
+	}
+	keysMissing := apiKeys == nil || apiKeys.AnonKey == ""
+	if keysMissing {
+		if stored.Status == "ACTIVE_HEALTHY" {
+			stored.Status = "ACTIVE_PENDING_KEYS"
+		}
+	} else {
+		stored.AnonKey = apiKeys.AnonKey
+		stored.ServiceKey = apiKeys.ServiceKey
+	}
+
+	saved, err := h.storage.UpsertProjectByRef(stored, storage.RefConflictError)
+	if errors.Is(err, storage.ErrProjectRefExists) {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_ALREADY_TRACKED",
+				Message: "Project is already tracked by the manager",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to store the adopted project",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if orphan.Ignored {
+		if err := h.storage.UnignoreRemoteOrphan(ref); err != nil {
+			fmt.Printf("Warning: Failed to take adopted project %s off the ignore list: %v\n", ref, err)
+		}
+	}
+
+	h.audit(c, supabase.AuditProjectAdopted, saved.ID, ref)
+	h.recordStatus(saved.ID, saved.Status)
+	if !keysMissing {
+		h.recordKeys(saved.ID, saved.AnonKey, saved.ServiceKey)
+	} else if saved.Status == "ACTIVE_PENDING_KEYS" {
+		h.runBackground(func() { h.retryAPIKeys(client, saved.ID, ref) })
+	}
+
+	c.JSON(http.StatusCreated, h.projectResponse(saved, nil))
+}
+
+// IgnoreRemoteOrphan handles POST /api/remote-orphans/:ref/ignore, putting
+// an untracked remote project on the ignore list
+func (h *Handler) IgnoreRemoteOrphan(c *gin.Context) {
+	var req supabase.IgnoreOrphanRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid request body",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	client, ok := h.clientFor(c, nil)
+	if !ok {
+		return
+	}
+
+	ref := c.Param("ref")
+	if _, ok := h.findRemoteOrphan(c, client, ref); !ok {
+		return
+	}
+
+	ignore := &supabase.OrphanIgnore{
+		ProjectRef: ref,
+		Reason:     req.Reason,
+		IgnoredBy:  callerID(c),
+		CreatedAt:  time.Now().UTC(),
+	}
+	if err := h.storage.IgnoreRemoteOrphan(ignore); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to ignore remote project",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	details := ref
+	if req.Reason != "" {
+		details += ": " + req.Reason
+	}
+	h.audit(c, auditOrphanIgnored, "", details)
+
+	c.JSON(http.StatusOK, ignore)
+}
+
+// UnignoreRemoteOrphan handles DELETE /api/remote-orphans/:ref/ignore,
+// so the project is reported as an orphan again
+func (h *Handler) UnignoreRemoteOrphan(c *gin.Context) {
+	ref := c.Param("ref")
+	if err := h.storage.UnignoreRemoteOrphan(ref); err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "ORPHAN_NOT_IGNORED",
+				Message: "Remote project is not on the ignore list",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.audit(c, auditOrphanUnignored, "", ref)
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_ref": ref,
+		"message":     "Remote project is reported as an orphan again",
+	})
+}
@@ -0,0 +1,30 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/pagination"
+	"supabase-manager/internal/supabase"
+)
+
+// listLimits are the page sizes of the list endpoints
+var listLimits = pagination.Limits{Default: 100, Max: 1000}
+
+// pageRequest reads the page a list request asks for, responding with an
+// error if the parameters are invalid
+func pageRequest(c *gin.Context) (pagination.Request, bool) {
+	req, err := pagination.ParseRequest(c.Request.URL.Query(), listLimits)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid pagination parameters",
+				Details: err.Error(),
+			},
+		})
+		return req, false
+	}
+	return req, true
+}
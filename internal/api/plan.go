@@ -0,0 +1,165 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const auditProjectPlanChanged = "project.plan_changed"
+
+// ChangeProjectPlan handles POST /api/projects/:id/plan, so a project can be
+// promoted to a paid plan without recreating it. Supabase bills plans per
+// organization, so a plan is only recorded for the project once its
+// organization is on it; the compute size is changed with a compute add-on.
+func (h *Handler) ChangeProjectPlan(c *gin.Context) {
+	var req supabase.ChangePlanRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if req.Plan == "" && req.ComputeSize == "" {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Nothing to change",
+				Details: "set plan, compute_size or both",
+			},
+		})
+		return
+	}
+	if req.Plan != "" && !slices.Contains(supabase.Plans, req.Plan) {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid plan",
+				Details: fmt.Sprintf("plan must be one of %v, got %q", supabase.Plans, req.Plan),
+			},
+		})
+		return
+	}
+	if req.ComputeSize != "" && !slices.Contains(supabase.ComputeSizes, req.ComputeSize) {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid compute size",
+				Details: fmt.Sprintf("compute_size must be one of %v, got %q", supabase.ComputeSizes, req.ComputeSize),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	previous, err := h.storage.GetProjectPlan(storedProject.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get project plan",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	planChange := req.Plan != "" && req.Plan != previous
+	if !planChange && req.ComputeSize == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"id":      storedProject.ID,
+			"plan":    req.Plan,
+			"message": "Project is already on this plan",
+		})
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	if planChange {
+		org, err := client.GetOrganization()
+		if err != nil {
+			respondManagementError(c, http.StatusBadGateway, "PLAN_CHANGE_FAILED", "Failed to get the organization's plan from Supabase", err)
+			return
+		}
+		if org.Plan != req.Plan {
+			c.JSON(http.StatusConflict, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "PLAN_IS_ORGANIZATION_LEVEL",
+					Message: "Supabase bills plans per organization",
+					Details: fmt.Sprintf("organization %s is on the %q plan; change its plan in the Supabase dashboard, then retry", org.ID, org.Plan),
+				},
+			})
+			return
+		}
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "change_project_plan", gin.H{
+			"id":            storedProject.ID,
+			"project_ref":   storedProject.ProjectRef,
+			"previous_plan": previous,
+			"plan":          req.Plan,
+			"compute_size":  req.ComputeSize,
+		})
+		return
+	}
+
+	if req.ComputeSize != "" {
+		if err := client.UpdateComputeSize(storedProject.ProjectRef, req.ComputeSize); err != nil {
+			respondManagementError(c, http.StatusBadGateway, "PLAN_CHANGE_FAILED", "Failed to change project compute size in Supabase", err)
+			return
+		}
+	}
+
+	var changes []string
+	if planChange {
+		if err := h.storage.SetProjectPlan(storedProject.ID, req.Plan); err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to record project plan",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		from := previous
+		if from == "" {
+			from = "unknown"
+		}
+		changes = append(changes, from+" -> "+req.Plan)
+	}
+	if req.ComputeSize != "" {
+		changes = append(changes, "compute "+req.ComputeSize)
+	}
+	h.audit(c, auditProjectPlanChanged, storedProject.ID, strings.Join(changes, ", "))
+
+	response := gin.H{
+		"id":            storedProject.ID,
+		"plan":          req.Plan,
+		"previous_plan": previous,
+	}
+	if req.Plan == "" {
+		response["plan"] = previous
+	}
+	if req.ComputeSize != "" {
+		response["compute_size"] = req.ComputeSize
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,57 @@
+package api
+
+import (
+	"net/http"
+	"slices"
+	"testing"
+)
+
+func TestChangeProjectPlan(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	env.router.POST("/api/projects/:id/plan", env.handler.ChangeProjectPlan)
+
+	rec := env.do(t, http.MethodPost, "/api/projects", map[string]string{"name": "promote"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: got %d %s", rec.Code, rec.Body.String())
+	}
+	id, _ := decode(t, rec)["id"].(string)
+	env.awaitActive(t, id)
+
+	// Plans are billed per organization, so the project follows its
+	// organization's plan
+	rec = env.do(t, http.MethodPost, "/api/projects/"+id+"/plan", map[string]string{"plan": "pro"})
+	if rec.Code != http.StatusConflict || errorCode(t, rec) != "PLAN_IS_ORGANIZATION_LEVEL" {
+		t.Fatalf("pro on a free organization: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	env.fake.OrganizationPlan = "pro"
+	rec = env.do(t, http.MethodPost, "/api/projects/"+id+"/plan", map[string]string{"plan": "pro", "compute_size": "small"})
+	if rec.Code != http.StatusOK {
+		t.Fatalf("pro on a pro organization: got %d %s", rec.Code, rec.Body.String())
+	}
+	if changed := decode(t, rec); changed["plan"] != "pro" || changed["previous_plan"] != "free" || changed["compute_size"] != "small" {
+		t.Errorf("change returned %v", changed)
+	}
+	if plan, err := env.store.GetProjectPlan(id); err != nil || plan != "pro" {
+		t.Errorf("recorded plan %q, %v", plan, err)
+	}
+	if !slices.ContainsFunc(env.fake.Requests(), func(r string) bool { return r == "PATCH /v1/projects/"+env.projectRef(t, id)+"/billing/addons" }) {
+		t.Errorf("compute add-on was not applied: %v", env.fake.Requests())
+	}
+
+	rec = env.do(t, http.MethodPost, "/api/projects/"+id+"/plan", map[string]string{"compute_size": "huge"})
+	if rec.Code != http.StatusBadRequest {
+		t.Errorf("unknown compute size: got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+// projectRef returns the Supabase ref of a stored project
+func (e *testEnv) projectRef(t *testing.T, id string) string {
+	t.Helper()
+
+	project, err := e.store.GetProject(id)
+	if err != nil {
+		t.Fatalf("get project: %v", err)
+	}
+	return project.ProjectRef
+}
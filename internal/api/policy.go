@@ -0,0 +1,99 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/operator"
+	"supabase-manager/internal/policy"
+	"supabase-manager/internal/supabase"
+)
+
+const auditPolicyDenied = "policy.denied"
+
+// operatorCaller identifies the Kubernetes controller to the policy endpoint
+var operatorCaller = policy.Caller{KeyID: "operator"}
+
+// checkPolicy asks the policy endpoint whether the caller may perform an
+// operation. It writes an error response and returns false when the
+// operation is denied or no decision could be made.
+func (h *Handler) checkPolicy(c *gin.Context, operation, projectID string, request interface{}) bool {
+	if h.policy == nil {
+		return true
+	}
+
+	caller := policy.Caller{KeyID: callerID(c), IP: c.ClientIP()}
+	if key := auth.FromContext(c); key != nil {
+		caller.Name = key.Name
+		caller.Scopes = key.Scopes
+	}
+
+	decision, err := h.policy.Check(c.Request.Context(), policy.Input{
+		Operation: operation,
+		Caller:    caller,
+		DryRun:    isDryRun(c),
+		RequestID: c.GetString("request_id"),
+		Request:   request,
+	})
+	if err != nil {
+		c.JSON(http.StatusServiceUnavailable, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "POLICY_UNAVAILABLE",
+				Message: "Policy check failed",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+
+	if !decision.Allow {
+		h.audit(c, auditPolicyDenied, projectID, fmt.Sprintf("%s: %s", operation, decision.Reason))
+		c.JSON(http.StatusForbidden, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "POLICY_DENIED",
+				Message: "Operation denied by policy",
+				Details: decision.Reason,
+			},
+		})
+		return false
+	}
+
+	return true
+}
+
+// checkOperatorPolicy asks the policy endpoint whether the Kubernetes
+// controller may perform an operation
+func (h *Handler) checkOperatorPolicy(operation string, request interface{}) error {
+	decision, err := h.policy.Check(context.Background(), policy.Input{
+		Operation: operation,
+		Caller:    operatorCaller,
+		Request:   request,
+	})
+	if err != nil {
+		return fmt.Errorf("policy check failed: %w", err)
+	}
+	if !decision.Allow {
+		return fmt.Errorf("%w: denied by policy: %s", operator.ErrRejected, decision.Reason)
+	}
+	return nil
+}
+
+// schemaPolicyRequest is the part of a schema request sent to the policy
+// endpoint. The source token is left out.
+func schemaPolicyRequest(targetID string, req *supabase.ApplySchemaRequest) gin.H {
+	return gin.H{
+		"target_id":   targetID,
+		"sql":         req.SQL,
+		"name":        req.Name,
+		"description": req.Description,
+		"author":      req.Author,
+		"ticket":      req.Ticket,
+		"role":        req.Role,
+		"source_url":  req.SourceURL,
+		"sha256":      req.SHA256,
+	}
+}
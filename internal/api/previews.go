@@ -0,0 +1,502 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/policy"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditPreviewCreated = "preview.created"
+	auditPreviewUpdated = "preview.updated"
+	auditPreviewClosed  = "preview.closed"
+)
+
+// CreatePreview handles POST /api/previews. It creates a preview project
+// for a branch and applies the branch's migrations once it is ready. If the
+// branch already has a preview, the migrations it has not applied yet are
+// applied to it instead.
+func (h *Handler) CreatePreview(c *gin.Context) {
+	var req supabase.CreatePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid preview",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if req.CommentURL != "" {
+		u, _ := url.Parse(req.CommentURL)
+		if !h.previewCommentHosts[strings.ToLower(u.Hostname())] {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid preview",
+					Details: fmt.Sprintf("comment_url host %s is not in PREVIEW_COMMENT_ALLOWED_HOSTS", u.Hostname()),
+				},
+			})
+			return
+		}
+	}
+
+	if req.PostgresVersion != "" && !supabase.IsSupportedPostgresVersion(req.PostgresVersion) {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_POSTGRES_VERSION",
+				Message: "Unsupported Postgres version",
+				Details: fmt.Sprintf("See GET /api/postgres-versions for supported versions, got %q", req.PostgresVersion),
+			},
+		})
+		return
+	}
+
+	if !h.checkRegion(c, req.Region) {
+		return
+	}
+
+	// The preview labels take precedence over the caller's
+	labels := make(map[string]string)
+	for key, value := range req.Labels {
+		labels[key] = value
+	}
+	for key, value := range supabase.PreviewLabels(req.Repo, req.Branch) {
+		labels[key] = value
+	}
+	if err := supabase.ValidateLabels(labels); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid labels",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	// One request per branch at a time, so a push arriving while the
+	// previous one is still being applied cannot run a migration twice
+	previewID := labels[supabase.PreviewLabelID]
+	if _, busy := h.previewing.LoadOrStore(previewID, true); busy {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PREVIEW_BUSY",
+				Message: "The preview of this branch is being updated",
+				Details: "retry once the current request has finished",
+			},
+		})
+		return
+	}
+	handedOff := false
+	defer func() {
+		if !handedOff {
+			h.previewing.Delete(previewID)
+		}
+	}()
+
+	files, err := h.schemaFetcher.FetchDirectory(c.Request.Context(), req.MigrationsURL(), req.SourceToken)
+	req.SourceToken = ""
+	if err != nil {
+		respondSourceError(c, "Failed to fetch the branch's migrations", err)
+		return
+	}
+	migrations := make([]supabase.BootstrapMigration, len(files))
+	for i, file := range files {
+		migrations[i] = supabase.BootstrapMigration{
+			Name:   path.Base(file.Source),
+			Source: file.Source,
+			SHA256: file.SHA256,
+			SQL:    file.SQL,
+		}
+		if !h.checkSecrets(c, "", file.SQL) {
+			return
+		}
+	}
+
+	var comment *supabase.PreviewComment
+	if req.CommentURL != "" {
+		comment = &supabase.PreviewComment{
+			URL:               req.CommentURL,
+			Token:             req.CommentToken,
+			Repo:              req.Repo,
+			Branch:            req.Branch,
+			IncludeServiceKey: req.IncludeServiceKey,
+		}
+	}
+
+	existing, err := h.findPreviews(c, previewID, false)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to look up previews",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if len(existing) > 0 {
+		handedOff = h.updatePreview(c, existing[0], &req, migrations, comment)
+		return
+	}
+
+	if h.shedProvisioning(c) {
+		return
+	}
+
+	client, ok := h.clientFor(c, nil)
+	if !ok {
+		return
+	}
+
+	if !h.checkPolicy(c, policy.OperationCreatePreview, "", previewPolicyRequest("", &req, migrations)) {
+		return
+	}
+
+	// Preview names are generated, so they are made to follow the naming
+	// policy rather than checked against it
+	namingPolicy := h.projectNamingPolicy()
+	previewName, err := namingPolicy.Apply(supabase.PreviewProjectName(req.Repo, req.Branch), labels)
+	if err != nil {
+		respondNamingPolicyViolation(c, namingPolicy, supabase.PreviewProjectName(req.Repo, req.Branch), labels, err)
+		return
+	}
+
+	createReq := supabase.CreateProjectRequest{
+		Name:            previewName,
+		Region:          req.Region,
+		PostgresVersion: req.PostgresVersion,
+		NameConflict:    supabase.NameConflictSuffix,
+		Labels:          labels,
+	}
+	bootstrap := supabase.ProjectBootstrap{Migrations: migrations, Comment: comment}
+
+	h.naming.Lock()
+	defer h.naming.Unlock()
+
+	name, err := h.resolveProjectName(client, &createReq)
+	if err != nil {
+		respondManagementError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to pick a project name", err)
+		return
+	}
+	createReq.Name = name
+
+	if isDryRun(c) {
+		region := createReq.Region
+		if region == "" {
+			region = h.currentDefaults().Region
+		}
+		respondDryRun(c, "create_preview", gin.H{
+			"preview_id":       previewID,
+			"name":             createReq.Name,
+			"region":           region,
+			"postgres_version": createReq.PostgresVersion,
+			"labels":           labels,
+			"bootstrap":        bootstrap,
+		})
+		return
+	}
+
+	storedProject, job, err := h.provisionProject(client, &createReq, bootstrap, callerID(c))
+	if err != nil {
+		respondManagementError(c, http.StatusInternalServerError, "PROJECT_CREATION_FAILED", "Failed to create Supabase project", err)
+		return
+	}
+	h.audit(c, auditPreviewCreated, storedProject.ID, fmt.Sprintf("%s %s, %d migrations", req.Repo, req.Branch, len(migrations)))
+
+	response := h.projectResponse(storedProject, job)
+	response["preview_id"] = previewID
+	response["migrations"] = migrationNames(migrations)
+	response["message"] = "Preview creation initiated. Poll /api/projects/:id to check status."
+	c.JSON(http.StatusCreated, response)
+}
+
+// updatePreview applies the migrations an existing preview has not applied
+// yet, by file name, in the background. It reports whether the background
+// run took over the branch's preview lock.
+func (h *Handler) updatePreview(c *gin.Context, project *supabase.StoredProject, req *supabase.CreatePreviewRequest, migrations []supabase.BootstrapMigration, comment *supabase.PreviewComment) bool {
+	if project.Status != "ACTIVE_HEALTHY" {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PREVIEW_NOT_READY",
+				Message: "The preview of this branch is not ready yet",
+				Details: fmt.Sprintf("Project %s is %s; its migrations are applied once it is ready", project.ID, project.Status),
+			},
+		})
+		return false
+	}
+
+	history, err := h.storage.ListMigrations(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list migrations",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+	applied := make(map[string]bool)
+	for _, record := range history {
+		if record.Success {
+			applied[record.Name] = true
+		}
+	}
+	var pending []supabase.BootstrapMigration
+	for _, migration := range migrations {
+		if !applied[migration.Name] {
+			pending = append(pending, migration)
+		}
+	}
+
+	if !h.checkPolicy(c, policy.OperationCreatePreview, project.ID, previewPolicyRequest(project.ID, req, pending)) {
+		return false
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "update_preview", gin.H{
+			"preview_id": project.Labels[supabase.PreviewLabelID],
+			"id":         project.ID,
+			"migrations": migrationNames(pending),
+		})
+		return false
+	}
+
+	if len(pending) == 0 {
+		c.JSON(http.StatusOK, gin.H{
+			"preview_id":  project.Labels[supabase.PreviewLabelID],
+			"id":          project.ID,
+			"name":        project.Name,
+			"project_url": project.ProjectURL,
+			"status":      project.Status,
+			"migrations":  []string{},
+			"message":     "Preview is up to date",
+		})
+		return false
+	}
+
+	if h.shedMigration(c) {
+		return false
+	}
+
+	h.audit(c, auditPreviewUpdated, project.ID, fmt.Sprintf("%s %s, %d migrations", req.Repo, req.Branch, len(pending)))
+	previewID := project.Labels[supabase.PreviewLabelID]
+	bootstrap := supabase.ProjectBootstrap{Migrations: pending, Comment: comment}
+	h.planSetup(project.ID, bootstrap)
+	h.runBackground(func() {
+		defer h.previewing.Delete(previewID)
+		h.bootstrapProject(h.supabaseClient, project.ID, bootstrap, nil)
+	})
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"preview_id":  previewID,
+		"id":          project.ID,
+		"name":        project.Name,
+		"project_url": project.ProjectURL,
+		"status":      project.Status,
+		"migrations":  migrationNames(pending),
+		"message":     "Applying new migrations. See /api/projects/:id/migrations for the outcome.",
+	})
+	return true
+}
+
+// ClosePreview handles POST /api/previews/close. It deletes the preview of
+// a branch that was merged or closed, locally and in Supabase. Closing a
+// branch without a preview succeeds, so the call can be retried.
+func (h *Handler) ClosePreview(c *gin.Context) {
+	var req supabase.ClosePreviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if req.Reason != "" && req.Reason != supabase.PreviewMerged && req.Reason != supabase.PreviewClosed {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid reason",
+				Details: fmt.Sprintf("Expected merged or closed, got %q", req.Reason),
+			},
+		})
+		return
+	}
+
+	previewID := supabase.PreviewID(req.Repo, req.Branch)
+	projects, err := h.findPreviews(c, previewID, true)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to look up previews",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	for _, project := range projects {
+		if !h.checkFreeze(c, supabase.FreezeDeletes, project) {
+			return
+		}
+	}
+
+	if isDryRun(c) {
+		ids := make([]string, len(projects))
+		for i, project := range projects {
+			ids[i] = project.ID
+		}
+		respondDryRun(c, "close_preview", gin.H{
+			"preview_id": previewID,
+			"delete":     ids,
+		})
+		return
+	}
+
+	deleted := []gin.H{}
+	for _, project := range projects {
+		client, ok := h.clientFor(c, project)
+		if !ok {
+			return
+		}
+
+		result := gin.H{"id": project.ID, "status": "DELETED"}
+		if err := h.deleteRemote(client, project.ID, project.ProjectRef, project.DeletionAttempts); err != nil {
+			result["status"] = deletionStatus(err)
+			result["error"] = err.Error()
+		}
+		h.audit(c, auditPreviewClosed, project.ID, fmt.Sprintf("%s %s %s", req.Repo, req.Branch, req.Reason))
+		deleted = append(deleted, result)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"preview_id": previewID,
+		"deleted":    deleted,
+	})
+}
+
+// ListPreviews handles GET /api/previews
+func (h *Handler) ListPreviews(c *gin.Context) {
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list projects",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	previews := []gin.H{}
+	for _, p := range projects {
+		previewID, ok := p.Labels[supabase.PreviewLabelID]
+		if !ok || !callerAllowsOrganization(c, p.OrganizationID) {
+			continue
+		}
+		previews = append(previews, gin.H{
+			"preview_id":  previewID,
+			"repo":        p.Labels[supabase.PreviewLabelRepo],
+			"branch":      p.Labels[supabase.PreviewLabelBranch],
+			"id":          p.ID,
+			"name":        p.Name,
+			"project_url": p.ProjectURL,
+			"status":      p.Status,
+			"created_at":  p.CreatedAt,
+		})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"previews": previews,
+		"total":    len(previews),
+	})
+}
+
+// findPreviews returns the projects of a preview that the caller's API key
+// may work on. Unless includeAll is set, projects that failed to provision
+// or are being deleted are left out, so a new preview replaces them.
+func (h *Handler) findPreviews(c *gin.Context, previewID string, includeAll bool) ([]*supabase.StoredProject, error) {
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+
+	var previews []*supabase.StoredProject
+	for _, p := range projects {
+		if p.Labels[supabase.PreviewLabelID] != previewID || !callerAllowsOrganization(c, p.OrganizationID) {
+			continue
+		}
+		if (p.Status == "FAILED" || p.Status == "PENDING_DELETION" || p.Status == "DELETION_FAILED") && !includeAll {
+			continue
+		}
+		previews = append(previews, p)
+	}
+	return previews, nil
+}
+
+// commentPreview posts the outcome of a preview to its comment URL,
+// logging failures
+func (h *Handler) commentPreview(comment *supabase.PreviewComment, report *supabase.PreviewReport) {
+	if err := supabase.PostPreviewComment(comment, report); err != nil {
+		fmt.Printf("Warning: Failed to comment on preview of %s %s: %v\n", comment.Repo, comment.Branch, err)
+	}
+}
+
+// previewPolicyRequest is the request sent to the policy endpoint for a
+// preview. The migrations are included with their SQL; tokens never are.
+func previewPolicyRequest(targetID string, req *supabase.CreatePreviewRequest, migrations []supabase.BootstrapMigration) gin.H {
+	files := make([]gin.H, len(migrations))
+	for i, migration := range migrations {
+		files[i] = gin.H{
+			"name":   migration.Name,
+			"source": migration.Source,
+			"sha256": migration.SHA256,
+			"sql":    migration.SQL,
+		}
+	}
+	return gin.H{
+		"target_id":        targetID,
+		"repo":             req.Repo,
+		"branch":           req.Branch,
+		"region":           req.Region,
+		"postgres_version": req.PostgresVersion,
+		"labels":           req.Labels,
+		"migrations":       files,
+	}
+}
+
+// migrationNames lists the names of migrations
+func migrationNames(migrations []supabase.BootstrapMigration) []string {
+	names := make([]string, len(migrations))
+	for i, migration := range migrations {
+		names[i] = migration.Name
+	}
+	return names
+}
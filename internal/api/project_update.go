@@ -0,0 +1,148 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// UpdateProject handles PATCH /api/projects/:id, changing a project's name
+// and labels in one request. A new name is applied in Supabase first and
+// then locally, like PUT /api/projects/:id/name; labels are only stored
+// locally. The updated project is returned.
+func (h *Handler) UpdateProject(c *gin.Context) {
+	var req supabase.UpdateProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if req.Name == nil && req.Labels == nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Nothing to update",
+				Details: "set name or labels",
+			},
+		})
+		return
+	}
+
+	project, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	labels := project.Labels
+	if req.Labels != nil {
+		labels = *req.Labels
+		if err := supabase.ValidateLabels(labels); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid labels",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	name := project.Name
+	if req.Name != nil {
+		name = strings.TrimSpace(*req.Name)
+		if name == "" {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid request body",
+					Details: "name must not be empty",
+				},
+			})
+			return
+		}
+	}
+	renaming := name != project.Name
+
+	var client *supabase.Client
+	if renaming {
+		var ok bool
+		client, ok = h.clientFor(c, project)
+		if !ok {
+			return
+		}
+
+		// Hold the naming lock so a concurrent create or rename cannot
+		// take the same name. The name is checked against the new labels,
+		// as those carry the team label the naming policy may require.
+		h.naming.Lock()
+		defer h.naming.Unlock()
+
+		if !h.checkNewName(c, name, labels) {
+			return
+		}
+	}
+
+	if isDryRun(c) {
+		effect := gin.H{
+			"id":          project.ID,
+			"project_ref": project.ProjectRef,
+		}
+		if renaming {
+			effect["previous_name"] = project.Name
+			effect["name"] = name
+			effect["slug"] = supabase.Slugify(name)
+		}
+		if req.Labels != nil {
+			effect["previous_labels"] = project.Labels
+			effect["labels"] = labels
+		}
+		respondDryRun(c, "update_project", effect)
+		return
+	}
+
+	if renaming {
+		if _, ok := h.renameProject(c, client, project, name); !ok {
+			return
+		}
+	}
+
+	if req.Labels != nil {
+		if err := h.storage.SetProjectLabels(project.ID, labels); err != nil {
+			message := "Failed to update labels"
+			if renaming {
+				message = "Project was renamed, but its labels were not updated; retry the labels"
+			}
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: message,
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	updated, err := h.storage.GetProject(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Project was updated, but could not be read back",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, h.projectResponse(updated, nil))
+}
@@ -0,0 +1,338 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/operator"
+	"supabase-manager/internal/policy"
+	"supabase-manager/internal/storage"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	keyRetryAttempts     = 10
+	keyRetryInitialDelay = 5 * time.Second
+	keyRetryMaxDelay     = 2 * time.Minute
+)
+
+// jobProvisionProject is the type of the job waiting for a new project to
+// become ready and applying its bootstrap
+const jobProvisionProject = "provision_project"
+
+// ProvisionProject creates a Supabase project, stores it locally and waits
+// for it to become ready in the background. It is shared by the HTTP API and
+// the Kubernetes controller, and checked against the policy endpoint as the
+// controller.
+func (h *Handler) ProvisionProject(req *supabase.CreateProjectRequest) (*supabase.StoredProject, error) {
+	if req.Name != "" {
+		if err := h.projectNamingPolicy().Check(req.Name, req.Labels); err != nil {
+			return nil, fmt.Errorf("%w: project name %q does not follow the naming policy: %w", operator.ErrRejected, req.Name, err)
+		}
+	}
+	if err := h.validateRegion(req.Region); err != nil {
+		return nil, fmt.Errorf("%w: %w", operator.ErrRejected, err)
+	}
+	if err := h.checkOperatorPolicy(policy.OperationCreateProject, req); err != nil {
+		return nil, err
+	}
+	project, _, err := h.provisionProject(h.supabaseClient, req, h.bootstrapFor(req), operatorCaller.KeyID)
+	return project, err
+}
+
+// provisionProject provisions a project through the given Management API
+// client, which may carry caller-supplied credentials, and applies the
+// bootstrap once it is ready. It returns the project as stored, so a read
+// right after sees the same record, and the job tracking the rest of the
+// provisioning, which is nil if the job could not be recorded.
+func (h *Handler) provisionProject(client *supabase.Client, req *supabase.CreateProjectRequest, bootstrap supabase.ProjectBootstrap, createdBy string) (*supabase.StoredProject, *supabase.Job, error) {
+	// Fill in the default region and plan if not provided
+	h.applyOrgDefaults(req)
+
+	// Generate unique project name if needed
+	projectName := req.Name
+	if projectName == "" {
+		generated, err := h.projectNamingPolicy().Apply(fmt.Sprintf("project-%s", uuid.New().String()[:8]), req.Labels)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to generate a project name: %w", err)
+		}
+		projectName = generated
+	}
+
+	// Create project via Supabase API. The provision counts towards the
+	// provisioning queue until it is ready or has failed.
+	h.backpressure.provisioning.Add(1)
+	started := time.Now()
+	project, err := h.createProjectWithFailover(client, projectName, req)
+	if err != nil {
+		h.backpressure.provisioning.Add(-1)
+		if isProvisioningFault(err) {
+			h.recordProvisionFailure("", req.Region, started)
+		}
+		return nil, nil, err
+	}
+
+	// Generate a stable ID for our system
+	project.ID = uuid.New().String()
+
+	// Store initial project data (status will be updated later). The save
+	// is keyed on the ref, so a record already discovered for this project
+	// is merged and its ID kept. A project that could not be stored is not
+	// reported as created, since reads of its ID would not find it.
+	storedProject := project.ToStoredProject()
+	storedProject.BYOCredentials = client != h.supabaseClient
+	storedProject.OrganizationID = client.OrganizationID()
+	storedProject.CreatedBy = createdBy
+	storedProject.Labels = req.Labels
+	if project.Region != req.Region {
+		storedProject.RequestedRegion = req.Region
+	}
+	saved, err := h.storage.UpsertProjectByRef(storedProject, storage.RefConflictMerge)
+	if err != nil {
+		h.backpressure.provisioning.Add(-1)
+		fmt.Printf("Error storing project %s created in Supabase: %v\n", project.ProjectRef, err)
+		return nil, nil, fmt.Errorf("project %s was created in Supabase but could not be stored: %w", project.ProjectRef, err)
+	}
+	project.ID = saved.ID
+	h.recordStatus(saved.ID, saved.Status)
+	plan := req.Plan
+	if plan == "" {
+		plan = defaultPlan
+	}
+	if err := h.storage.SetProjectPlan(saved.ID, plan); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	h.planSetup(saved.ID, bootstrap)
+	h.saveProfileMigrationRoles(saved.ID, bootstrap)
+
+	// Wait for the project in the background, tracked as a job. Migrations
+	// submitted meanwhile are queued until the job has finished.
+	h.migrationQueue.startProvisioning(saved.ID)
+	provision := func(logs *jobLog) (interface{}, error) {
+		defer h.backpressure.provisioning.Add(-1)
+		defer h.migrationQueue.finishProvisioning(saved.ID)
+		if err := h.awaitProvisioning(client, project, started, bootstrap, logs); err != nil {
+			h.notifyProvisioned(saved.ID, err)
+			return nil, err
+		}
+		h.notifyProvisioned(saved.ID, nil)
+		return h.provisionResult(saved.ID), nil
+	}
+	job, err := h.runJob(createdBy, jobProvisionProject, provision)
+	if err != nil {
+		fmt.Printf("Warning: Failed to record the provisioning job of %s: %v\n", saved.ID, err)
+		h.runBackground(func() { provision(nil) })
+	}
+
+	return saved, job, nil
+}
+
+// regionCandidates returns the regions a create request may be placed in,
+// in order: its own, then the fallback regions unless it is strict
+func (h *Handler) regionCandidates(req *supabase.CreateProjectRequest) []string {
+	regions := []string{req.Region}
+	if req.StrictRegion {
+		return regions
+	}
+	for _, region := range h.regionFallbacks {
+		if region != req.Region {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// createProjectWithFailover creates a project in the requested region or,
+// while regions turn it down for lack of capacity, in the next fallback
+// region. Other errors are returned right away. The project's Region is the
+// one it was created in.
+func (h *Handler) createProjectWithFailover(client *supabase.Client, name string, req *supabase.CreateProjectRequest) (*supabase.Project, error) {
+	opts := supabase.ProjectOptions{
+		PostgresVersion: req.PostgresVersion,
+		Plan:            req.Plan,
+		ComputeSize:     req.ComputeSize,
+	}
+
+	regions := h.regionCandidates(req)
+	var err error
+	for i, region := range regions {
+		var project *supabase.Project
+		project, err = client.CreateProject(name, region, opts)
+		if err == nil {
+			project.Region = region
+			if i > 0 {
+				fmt.Printf("Warning: Project %s was created in %s because %s had no capacity\n", name, region, strings.Join(regions[:i], ", "))
+			}
+			return project, nil
+		}
+
+		var apiErr *supabase.APIError
+		if !errors.As(err, &apiErr) || apiErr.Kind != supabase.ErrKindRegionCapacity {
+			return nil, err
+		}
+	}
+	if len(regions) > 1 {
+		return nil, fmt.Errorf("no capacity in any of %s: %w", strings.Join(regions, ", "), err)
+	}
+	return nil, err
+}
+
+// provisionResult is the result of a provisioning job: the project's final
+// status and the outcome of its setup
+func (h *Handler) provisionResult(projectID string) gin.H {
+	result := gin.H{"project_id": projectID}
+	if project, err := h.storage.GetProject(projectID); err == nil {
+		result["status"] = project.Status
+	}
+	if steps, err := h.storage.ListSetupSteps(projectID); err == nil {
+		result["setup_status"] = supabase.SetupStatus(steps)
+	}
+	return result
+}
+
+// GetProjectRecord returns the locally stored state of a project
+func (h *Handler) GetProjectRecord(projectID string) (*supabase.StoredProject, error) {
+	return h.storage.GetProject(projectID)
+}
+
+// FindProjectByLabel returns a stored project carrying the label, or nil if
+// there is none
+func (h *Handler) FindProjectByLabel(key, value string) (*supabase.StoredProject, error) {
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		return nil, err
+	}
+	for _, project := range projects {
+		if label, ok := project.Labels[key]; ok && label == value {
+			return project, nil
+		}
+	}
+	return nil, nil
+}
+
+// awaitProvisioning waits for a new project to become healthy and stores its
+// final details and API keys, then applies the requested bootstrap. The
+// time it took is recorded for the region's provisioning baseline. It
+// returns an error if the project never became ready. Progress goes to
+// logs.
+func (h *Handler) awaitProvisioning(client *supabase.Client, project *supabase.Project, started time.Time, bootstrap supabase.ProjectBootstrap, logs *jobLog) error {
+	projectID := project.ID
+
+	logs.printf("Project %s (%s) created in %s; waiting for it to become healthy", projectID, project.ProjectRef, project.Region)
+	stopWatch := h.watchProvisioning(project, started)
+	readyProject, err := client.WaitForProject(project.ProjectRef, 5*time.Minute)
+	stopWatch()
+	if err != nil {
+		fmt.Printf("Error waiting for project %s: %v\n", projectID, err)
+		h.recordProvisionFailure(projectID, project.Region, started)
+		if err := h.storage.UpdateProjectStatus(projectID, "FAILED"); err == nil {
+			h.recordStatus(projectID, "FAILED")
+		}
+		h.skipSetup(projectID, "project failed to provision: "+err.Error())
+		if bootstrap.Comment != nil {
+			h.commentPreview(bootstrap.Comment, &supabase.PreviewReport{
+				Event:     supabase.PreviewEventFailed,
+				ProjectID: projectID,
+				Error:     err.Error(),
+			})
+		}
+		return fmt.Errorf("project failed to provision: %w", err)
+	}
+
+	if err := h.storage.RecordProvisionDuration(projectID, project.Region, started, time.Since(started)); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+	logs.printf("Project is %s after %s", readyProject.Status, time.Since(started).Round(time.Second))
+
+	// Apply the configured auth security baseline and profile settings
+	h.applyAuthBaseline(client, projectID, project.ProjectRef, h.authSettingsFor(bootstrap))
+
+	// Fetch API keys from Supabase
+	apiKeys, err := client.GetProjectAPIKeys(project.ProjectRef)
+	if err != nil {
+		fmt.Printf("Error fetching API keys for %s: %v\n", projectID, err)
+		logs.printf("API keys not available yet: %v", err)
+	}
+
+	// Update with full details once ready
+	readyProject.ID = projectID
+	readyProject.Region = project.Region
+	readyProject.DBPassword = project.DBPassword // Preserve the password we generated
+	if readyProject.PostgresVersion == "" {
+		readyProject.PostgresVersion = project.PostgresVersion
+	}
+
+	updatedStoredProject := readyProject.ToStoredProject()
+
+	// Store API keys if we got them, otherwise keep retrying in the background
+	keysMissing := apiKeys == nil || apiKeys.AnonKey == ""
+	if keysMissing {
+		updatedStoredProject.Status = "ACTIVE_PENDING_KEYS"
+	} else {
+		updatedStoredProject.AnonKey = apiKeys.AnonKey
+		updatedStoredProject.ServiceKey = apiKeys.ServiceKey
+	}
+
+	if err := h.storage.SaveProject(updatedStoredProject); err != nil {
+		fmt.Printf("Error updating project %s: %v\n", projectID, err)
+	} else {
+		h.recordStatus(projectID, updatedStoredProject.Status)
+		if !keysMissing {
+			h.recordKeys(projectID, apiKeys.AnonKey, apiKeys.ServiceKey)
+		}
+	}
+
+	if keysMissing {
+		logs.printf("Waiting for the API keys")
+		if !h.retryAPIKeys(client, projectID, project.ProjectRef) {
+			logs.printf("API keys did not arrive; setup skipped")
+			h.skipSetup(projectID, "the project's API keys were not available")
+			return nil
+		}
+		logs.printf("API keys stored")
+	}
+
+	if !bootstrap.IsEmpty() {
+		logs.printf("Applying %d setup steps", len(bootstrap.Steps()))
+	}
+	h.bootstrapProject(client, projectID, bootstrap, logs)
+	return nil
+}
+
+// retryAPIKeys polls for a project's API keys with exponential backoff and
+// marks the project ACTIVE_HEALTHY once they arrive. It reports whether the
+// keys were stored.
+func (h *Handler) retryAPIKeys(client *supabase.Client, projectID, projectRef string) bool {
+	delay := keyRetryInitialDelay
+
+	for attempt := 1; attempt <= keyRetryAttempts; attempt++ {
+		if !h.sleepOrStop(delay) {
+			return false
+		}
+
+		apiKeys, err := client.GetProjectAPIKeys(projectRef)
+		if err == nil && apiKeys.AnonKey != "" {
+			if err := h.storage.UpdateProjectKeys(projectID, apiKeys.AnonKey, apiKeys.ServiceKey, "ACTIVE_HEALTHY"); err != nil {
+				fmt.Printf("Error storing API keys for %s: %v\n", projectID, err)
+				return false
+			}
+			h.recordKeys(projectID, apiKeys.AnonKey, apiKeys.ServiceKey)
+			h.recordStatus(projectID, "ACTIVE_HEALTHY")
+			return true
+		}
+		fmt.Printf("API keys for %s not available yet (attempt %d/%d): %v\n", projectID, attempt, keyRetryAttempts, err)
+
+		delay *= 2
+		if delay > keyRetryMaxDelay {
+			delay = keyRetryMaxDelay
+		}
+	}
+
+	fmt.Printf("Giving up on API keys for %s; project stays ACTIVE_PENDING_KEYS\n", projectID)
+	return false
+}
@@ -0,0 +1,154 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	// provisionBaselineSize is how many recent provisions per region the
+	// percentiles are computed from
+	provisionBaselineSize = 100
+	// provisionBaselineMinSamples is how many provisions a region needs
+	// before slow ones are flagged
+	provisionBaselineMinSamples = 10
+)
+
+// provisionBaseline summarizes recent provisioning durations in a region
+type provisionBaseline struct {
+	Region  string  `json:"region"`
+	Samples int     `json:"samples"`
+	P50     float64 `json:"p50_seconds"`
+	P95     float64 `json:"p95_seconds"`
+	P99     float64 `json:"p99_seconds"`
+	p95     time.Duration
+}
+
+// loadProvisionBaseline computes the percentiles of a region's recent
+// provisioning durations
+func (h *Handler) loadProvisionBaseline(region string) (*provisionBaseline, error) {
+	durations, err := h.storage.RecentProvisionDurations(region, provisionBaselineSize)
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+
+	baseline := &provisionBaseline{Region: region, Samples: len(durations)}
+	if len(durations) > 0 {
+		baseline.p95 = percentile(durations, 95)
+		baseline.P50 = percentile(durations, 50).Seconds()
+		baseline.P95 = baseline.p95.Seconds()
+		baseline.P99 = percentile(durations, 99).Seconds()
+	}
+	return baseline, nil
+}
+
+// percentile returns the nearest-rank percentile of sorted durations
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// watchProvisioning flags a provision that is still running once it takes
+// longer than the region's p95 times the anomaly factor. Calling the
+// returned function stops the watch.
+func (h *Handler) watchProvisioning(project *supabase.Project, started time.Time) func() {
+	if h.provisionAnomalyFactor <= 0 {
+		return func() {}
+	}
+
+	baseline, err := h.loadProvisionBaseline(project.Region)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load provisioning baseline for %s: %v\n", project.Region, err)
+		return func() {}
+	}
+	if baseline.Samples < provisionBaselineMinSamples {
+		return func() {}
+	}
+
+	threshold := time.Duration(float64(baseline.p95) * h.provisionAnomalyFactor)
+	timer := time.AfterFunc(time.Until(started.Add(threshold)), func() {
+		h.flagSlowProvision(project, started, baseline, threshold)
+	})
+	return func() { timer.Stop() }
+}
+
+// flagSlowProvision records an audit event and sends a notification for a
+// provision running past its threshold
+func (h *Handler) flagSlowProvision(project *supabase.Project, started time.Time, baseline *provisionBaseline, threshold time.Duration) {
+	elapsed := time.Since(started).Round(time.Second)
+	message := fmt.Sprintf("Project %s (%s) has been provisioning in %s for %s; p95 there is %s over the last %d provisions (threshold %s)",
+		project.Name, project.ProjectRef, project.Region, elapsed,
+		baseline.p95.Round(time.Second), baseline.Samples, threshold.Round(time.Second))
+
+	h.recordAudit(&supabase.AuditEvent{
+		ID:        uuid.New().String(),
+		Action:    "provisioning.slow",
+		ProjectID: project.ID,
+		Actor:     "system",
+		Details:   message,
+		CreatedAt: time.Now().UTC(),
+	})
+
+	if h.notifier == nil {
+		return
+	}
+	err := h.notifier.Notify(notify.Notification{
+		Severity: notify.SeverityWarning,
+		Title:    "Slow provisioning: " + project.Name,
+		Message:  message,
+		Labels:   map[string]string{"project_id": project.ID, "region": project.Region},
+		Time:     time.Now().UTC(),
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to send slow provisioning notification for %s: %v\n", project.ID, err)
+	}
+}
+
+// GetProvisioningStats handles GET /api/stats/provisioning
+func (h *Handler) GetProvisioningStats(c *gin.Context) {
+	regions, err := h.storage.ProvisionRegions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to load provisioning stats",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	baselines := []*provisionBaseline{}
+	for _, region := range regions {
+		baseline, err := h.loadProvisionBaseline(region)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to load provisioning stats",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		baselines = append(baselines, baseline)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"regions":        baselines,
+		"anomaly_factor": h.provisionAnomalyFactor,
+		"min_samples":    provisionBaselineMinSamples,
+	})
+}
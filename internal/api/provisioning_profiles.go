@@ -0,0 +1,91 @@
+package api
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// defaultPlan is the plan of projects created without one
+const defaultPlan = "free"
+
+// applyProvisioningProfile fills the settings a create request leaves unset
+// from the configured provisioning profile it names, if any
+func (h *Handler) applyProvisioningProfile(req *supabase.CreateProjectRequest) {
+	if profile, ok := h.provisioningProfiles[req.Profile]; ok {
+		profile.Apply(req)
+	}
+}
+
+// ProvisioningProfiles returns the configured provisioning profiles by name
+func (h *Handler) ProvisioningProfiles() []supabase.ProvisioningProfile {
+	list := make([]supabase.ProvisioningProfile, 0, len(h.provisioningProfiles))
+	for _, profile := range h.provisioningProfiles {
+		list = append(list, profile)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// provisioningProfileSummaries describes the configured provisioning
+// profiles for capabilities, leaving out template contents such as secrets
+func (h *Handler) provisioningProfileSummaries() []gin.H {
+	summaries := []gin.H{}
+	for _, profile := range h.ProvisioningProfiles() {
+		summaries = append(summaries, gin.H{
+			"name":             profile.Name,
+			"description":      profile.Description,
+			"plan":             planOrDefault(profile.Plan),
+			"region":           profile.Region,
+			"compute_size":     profile.ComputeSize,
+			"postgres_version": profile.PostgresVersion,
+			"setup":            profile.Setup,
+			"labels":           profile.Labels,
+			"template":         profile.Template != nil,
+		})
+	}
+	return summaries
+}
+
+// planOrDefault returns the plan a project is created on
+func planOrDefault(plan string) string {
+	if plan == "" {
+		return defaultPlan
+	}
+	return plan
+}
+
+// authSettingsFor returns the auth settings applied to a new project: the
+// baseline with the settings of its provisioning profile on top
+func (h *Handler) authSettingsFor(bootstrap supabase.ProjectBootstrap) supabase.AuthSettings {
+	baseline := *h.currentDefaults().SecurityBaseline
+	if bootstrap.AuthSettings == nil {
+		return baseline
+	}
+	return baseline.Merge(*bootstrap.AuthSettings)
+}
+
+// saveProfileMigrationRoles sets the migration roles a new project gets
+// from its provisioning profile
+func (h *Handler) saveProfileMigrationRoles(projectID string, bootstrap supabase.ProjectBootstrap) {
+	if bootstrap.MigrationRoles == nil {
+		return
+	}
+
+	roles := &supabase.MigrationRoles{
+		TargetID:    projectID,
+		Roles:       bootstrap.MigrationRoles.Roles,
+		DefaultRole: bootstrap.MigrationRoles.DefaultRole,
+		UpdatedAt:   time.Now().UTC(),
+	}
+	if roles.Roles == nil {
+		roles.Roles = []string{}
+	}
+	if err := h.storage.SaveMigrationRoles(roles); err != nil {
+		fmt.Printf("Warning: Failed to set the migration roles of %s: %v\n", projectID, err)
+	}
+}
@@ -0,0 +1,56 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/supabase"
+)
+
+// purgeInterval is how often deleted projects are checked for expiry
+const purgeInterval = time.Hour
+
+// PurgeDeletedProjects permanently removes deleted projects once they are
+// older than retention, every hour until the context is cancelled. Each
+// purge is recorded in the audit log. A zero retention keeps deleted
+// projects forever.
+func (h *Handler) PurgeDeletedProjects(ctx context.Context, retention time.Duration) {
+	if retention <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		h.purgeExpiredProjects(retention)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// purgeExpiredProjects purges projects deleted more than retention ago
+func (h *Handler) purgeExpiredProjects(retention time.Duration) {
+	purged, err := h.storage.PurgeDeletedProjects(time.Now().Add(-retention))
+	if err != nil {
+		fmt.Printf("Warning: Failed to purge deleted projects: %v\n", err)
+	}
+
+	for _, project := range purged {
+		h.recordAudit(&supabase.AuditEvent{
+			ID:        uuid.New().String(),
+			Action:    supabase.AuditProjectPurged,
+			ProjectID: project.ID,
+			Actor:     "system",
+			Details:   fmt.Sprintf("ref %s, name %q, deleted %s", project.ProjectRef, project.Name, project.DeletedAt.UTC().Format(time.RFC3339)),
+			CreatedAt: time.Now().UTC(),
+		})
+	}
+}
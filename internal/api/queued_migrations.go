@@ -0,0 +1,229 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// jobQueuedMigration is the type of the job applying a migration submitted
+// while its project was provisioning
+const jobQueuedMigration = "queued_migration"
+
+const (
+	// queuedMigrationTimeout bounds how long a queued migration waits for
+	// its project to become ready
+	queuedMigrationTimeout = 30 * time.Minute
+	// queuedMigrationPoll is how often a queued migration checks the
+	// project's status
+	queuedMigrationPoll = 5 * time.Second
+)
+
+// provisioningStatuses are the statuses of projects that are still coming
+// up, for which submitted migrations are queued
+var provisioningStatuses = map[string]bool{
+	"COMING_UP":           true,
+	"ACTIVE_PENDING_KEYS": true,
+}
+
+// migrationQueue tracks the projects being provisioned and the migrations
+// queued for them. Queued migrations of a project run one at a time in the
+// order they were submitted, after the project's bootstrap. The queue is
+// kept in memory; jobs still waiting when the manager stops are failed on
+// the next start.
+type migrationQueue struct {
+	mu sync.Mutex
+	// provisioning are the projects whose provisioning job is running
+	provisioning map[string]bool
+	// tails are closed when the last migration queued for a project has
+	// finished; queued counts the migrations still waiting or running
+	tails  map[string]chan struct{}
+	queued map[string]int
+}
+
+// startProvisioning records that a project's provisioning job is running
+func (q *migrationQueue) startProvisioning(projectID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.provisioning == nil {
+		q.provisioning = make(map[string]bool)
+	}
+	q.provisioning[projectID] = true
+}
+
+// finishProvisioning records that a project's provisioning job has ended
+func (q *migrationQueue) finishProvisioning(projectID string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	delete(q.provisioning, projectID)
+}
+
+// isProvisioning reports whether a project's provisioning job is running
+func (q *migrationQueue) isProvisioning(projectID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.provisioning[projectID]
+}
+
+// busy reports whether new migrations for a project must be queued: its
+// provisioning job is running or earlier migrations are still queued
+func (q *migrationQueue) busy(projectID string) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	return q.provisioning[projectID] || q.queued[projectID] > 0
+}
+
+// enqueue adds a migration to a project's queue. It returns a channel
+// closed when the migration before it has finished, nil if there is none,
+// and the function to call once this migration has finished.
+func (q *migrationQueue) enqueue(projectID string) (<-chan struct{}, func()) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if q.tails == nil {
+		q.tails = make(map[string]chan struct{})
+		q.queued = make(map[string]int)
+	}
+	previous := q.tails[projectID]
+	tail := make(chan struct{})
+	q.tails[projectID] = tail
+	q.queued[projectID]++
+
+	done := func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+
+		close(tail)
+		if q.queued[projectID]--; q.queued[projectID] == 0 {
+			delete(q.queued, projectID)
+		}
+		if q.tails[projectID] == tail {
+			delete(q.tails, projectID)
+		}
+	}
+	if previous == nil {
+		return nil, done
+	}
+	return previous, done
+}
+
+// queueSubmittedMigration checks a migration for a project that is still
+// provisioning and queues it in a job that applies it once the project is
+// ready. The response is 202 with the job.
+func (h *Handler) queueSubmittedMigration(c *gin.Context, project *supabase.StoredProject, req *supabase.ApplySchemaRequest) {
+	if !h.checkFreeze(c, supabase.FreezeMigrations, project) {
+		return
+	}
+	if !h.checkSubmittedMigration(c, project.ID, req) {
+		return
+	}
+
+	// A simulation runs against the project's tables as they are now, so
+	// it cannot wait in the queue
+	if isSimulation(c) {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_READY",
+				Message: "Migrations cannot be simulated while the project is provisioning or has queued migrations",
+				Details: fmt.Sprintf("Current status: %s", project.Status),
+			},
+		})
+		return
+	}
+
+	// A dry run plans the migration as it would run now
+	if isDryRun(c) {
+		h.runMigration(c, project.ID, project.ToProject(), req)
+		return
+	}
+
+	checkFreeze := h.freezeGate(c, supabase.FreezeMigrations)
+	previous, done := h.migrationQueue.enqueue(project.ID)
+	job, err := h.startJob(c, jobQueuedMigration, func(logs *jobLog) (interface{}, error) {
+		defer done()
+
+		logs.printf("Waiting for project %s to become ready", project.ID)
+		ready, err := h.awaitQueuedMigration(project.ID, previous)
+		if err != nil {
+			return nil, err
+		}
+		if err := checkFreeze(ready); err != nil {
+			return nil, err
+		}
+
+		if len(req.Migrations) > 0 {
+			batch, err := h.applyMigrationBatch(ready.ID, ready.ToProject(), req, logs)
+			if err != nil {
+				return nil, err
+			}
+			return batch, nil
+		}
+
+		result, err := h.applyMigration(ready.ID, ready.ToProject(), req, logs)
+		if err != nil {
+			return nil, err
+		}
+		return result, nil
+	})
+	if err != nil {
+		done()
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to queue migration",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job":     job,
+		"status":  project.Status,
+		"message": fmt.Sprintf("Project is still provisioning; the migration runs once it is ready. Poll /api/jobs/%s for the result.", job.ID),
+	})
+}
+
+// awaitQueuedMigration waits for the migration queued before this one and
+// then for the project to be healthy with its provisioning finished. It
+// returns the project as stored, or an error if the project failed, was
+// deleted or did not become ready in time.
+func (h *Handler) awaitQueuedMigration(projectID string, previous <-chan struct{}) (*supabase.StoredProject, error) {
+	if previous != nil {
+		select {
+		case <-previous:
+		case <-h.stop:
+			return nil, errors.New("the manager stopped before the migration ran")
+		}
+	}
+
+	deadline := time.Now().Add(queuedMigrationTimeout)
+	for {
+		project, err := h.storage.GetProject(projectID)
+		if err != nil {
+			return nil, fmt.Errorf("project is gone: %w", err)
+		}
+		switch {
+		case project.Status == "ACTIVE_HEALTHY" && !h.migrationQueue.isProvisioning(projectID):
+			return project, nil
+		case project.Status != "ACTIVE_HEALTHY" && !provisioningStatuses[project.Status]:
+			return nil, fmt.Errorf("project did not become ready, status %s", project.Status)
+		case time.Now().After(deadline):
+			return nil, fmt.Errorf("project was not ready after %s, status %s", queuedMigrationTimeout, project.Status)
+		}
+
+		if !h.sleepOrStop(queuedMigrationPoll) {
+			return nil, errors.New("the manager stopped before the migration ran")
+		}
+	}
+}
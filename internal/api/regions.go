@@ -0,0 +1,154 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// Sources of the region list
+const (
+	regionSourceManagementAPI = "management_api"
+	regionSourceBuiltin       = "builtin"
+)
+
+const (
+	// regionCacheTTL is how long the Management API's region list is used
+	// before it is fetched again
+	regionCacheTTL = 10 * time.Minute
+	// regionRetryAfter is how long the built-in list stands in after the
+	// Management API's list could not be fetched
+	regionRetryAfter = time.Minute
+)
+
+// regionList is the region list as fetched at one time
+type regionList struct {
+	Regions   []supabase.Region `json:"regions"`
+	Source    string            `json:"source"`
+	FetchedAt time.Time         `json:"fetched_at"`
+	// Warning explains why the built-in list is used
+	Warning string `json:"warning,omitempty"`
+}
+
+// find returns the region with a code
+func (l *regionList) find(code string) (supabase.Region, bool) {
+	for _, region := range l.Regions {
+		if region.Code == code {
+			return region, true
+		}
+	}
+	return supabase.Region{}, false
+}
+
+// regionCache holds the Management API's region list, falling back to the
+// built-in list while it can't be fetched
+type regionCache struct {
+	client *supabase.Client
+
+	mu        sync.Mutex
+	list      *regionList
+	expiresAt time.Time
+}
+
+// newRegionCache creates an empty cache; the list is fetched on first use
+func newRegionCache(client *supabase.Client) *regionCache {
+	return &regionCache{client: client}
+}
+
+// get returns the current region list, fetching it when it has expired
+func (r *regionCache) get() *regionList {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	now := time.Now()
+	if r.list != nil && now.Before(r.expiresAt) {
+		return r.list
+	}
+
+	regions, err := r.client.ListRegions()
+	if err == nil && len(regions) == 0 {
+		err = fmt.Errorf("the Management API listed no regions")
+	}
+	if err != nil {
+		fmt.Printf("Warning: Using the built-in region list: %v\n", err)
+		r.list = &regionList{
+			Regions:   supabase.BuiltinRegions(),
+			Source:    regionSourceBuiltin,
+			FetchedAt: now,
+			Warning:   "the Management API's region list is unavailable: " + err.Error(),
+		}
+		r.expiresAt = now.Add(regionRetryAfter)
+		return r.list
+	}
+
+	r.list = &regionList{Regions: regions, Source: regionSourceManagementAPI, FetchedAt: now}
+	r.expiresAt = now.Add(regionCacheTTL)
+	return r.list
+}
+
+// ListRegions handles GET /api/regions, listing the regions projects can be
+// created in with their friendly names and whether they take new projects
+func (h *Handler) ListRegions(c *gin.Context) {
+	list := h.regions.get()
+	response := gin.H{
+		"regions":        list.Regions,
+		"default_region": h.currentDefaults().Region,
+		"source":         list.Source,
+		"fetched_at":     list.FetchedAt,
+	}
+	if list.Warning != "" {
+		response["warning"] = list.Warning
+	}
+	c.JSON(http.StatusOK, response)
+}
+
+// validateRegion returns an error if projects can't be requested in
+// region. Regions that are known but have no capacity pass, since creation
+// fails over from them.
+func (h *Handler) validateRegion(region string) error {
+	if region == "" {
+		return nil
+	}
+	if _, ok := h.regions.get().find(region); ok {
+		return nil
+	}
+	return fmt.Errorf("unknown region %q, see GET /api/regions for valid regions", region)
+}
+
+// checkRegion checks region like validateRegion, writing the error
+// response if it is not valid
+func (h *Handler) checkRegion(c *gin.Context, region string) bool {
+	if err := h.validateRegion(region); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REGION",
+				Message: "Unknown region",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// CheckConfiguredRegions checks the fallback regions and the regions of
+// the provisioning profiles like validateRegion, so a mistyped region
+// fails startup rather than the creates that use it
+func (h *Handler) CheckConfiguredRegions() error {
+	for _, region := range h.regionFallbacks {
+		if err := h.validateRegion(region); err != nil {
+			return fmt.Errorf("REGION_FALLBACKS: %w", err)
+		}
+	}
+	for _, profile := range h.ProvisioningProfiles() {
+		if err := h.validateRegion(profile.Region); err != nil {
+			return fmt.Errorf("provisioning profile %s: %w", profile.Name, err)
+		}
+	}
+	return nil
+}
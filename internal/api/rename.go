@@ -0,0 +1,221 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// RenameProject handles PUT /api/projects/:id/name. The project is renamed
+// in Supabase first and then locally; the old name is kept so lookups by it
+// still resolve.
+func (h *Handler) RenameProject(c *gin.Context) {
+	var req supabase.RenameProjectRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	name := strings.TrimSpace(req.Name)
+
+	project, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if name == project.Name {
+		c.JSON(http.StatusOK, gin.H{
+			"id":      project.ID,
+			"name":    project.Name,
+			"slug":    supabase.Slugify(project.Name),
+			"message": "Project already has this name",
+		})
+		return
+	}
+
+	client, ok := h.clientFor(c, project)
+	if !ok {
+		return
+	}
+
+	// Hold the naming lock so a concurrent create or rename cannot take
+	// the same name
+	h.naming.Lock()
+	defer h.naming.Unlock()
+
+	if !h.checkNewName(c, name, project.Labels) {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "rename_project", gin.H{
+			"id":            project.ID,
+			"project_ref":   project.ProjectRef,
+			"previous_name": project.Name,
+			"name":          name,
+			"slug":          supabase.Slugify(name),
+		})
+		return
+	}
+
+	previous, ok := h.renameProject(c, client, project, name)
+	if !ok {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":            project.ID,
+		"name":          name,
+		"slug":          supabase.Slugify(name),
+		"previous_name": previous,
+	})
+}
+
+// checkNewName checks that a project may be renamed to name given the
+// labels it will have, writing the error response if not. The caller holds
+// the naming lock.
+func (h *Handler) checkNewName(c *gin.Context, name string, labels map[string]string) bool {
+	namingPolicy := h.projectNamingPolicy()
+	if err := namingPolicy.Check(name, labels); err != nil {
+		respondNamingPolicyViolation(c, namingPolicy, name, labels, err)
+		return false
+	}
+
+	taken, err := h.storage.ProjectNameTaken(name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to check project name",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+	if taken {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NAME_TAKEN",
+				Message: "Project name is already in use",
+				Details: name,
+			},
+		})
+		return false
+	}
+	return true
+}
+
+// renameProject renames a project in Supabase and then locally, returning
+// its previous name. It writes the error response if either fails.
+func (h *Handler) renameProject(c *gin.Context, client *supabase.Client, project *supabase.StoredProject, name string) (string, bool) {
+	if err := client.RenameProject(project.ProjectRef, name); err != nil {
+		respondManagementError(c, http.StatusBadGateway, "RENAME_FAILED", "Failed to rename project in Supabase", err)
+		return "", false
+	}
+
+	previous, err := h.storage.RenameProject(project.ID, name)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Project was renamed in Supabase but not locally; retry the rename",
+				Details: err.Error(),
+			},
+		})
+		return "", false
+	}
+
+	h.audit(c, supabase.AuditProjectRenamed, project.ID, previous+" -> "+name)
+	return previous, true
+}
+
+// LookupProject handles GET /api/projects/lookup?name=. The name may be a
+// project's current name or slug, or a name it had before being renamed.
+// Current names take precedence over former ones.
+func (h *Handler) LookupProject(c *gin.Context) {
+	query := strings.TrimSpace(c.Query("name"))
+	if query == "" {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "name is required",
+			},
+		})
+		return
+	}
+
+	projects, err := h.storage.ListProjects()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list projects",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	var found *supabase.StoredProject
+	matched := ""
+	for _, project := range projects {
+		if project.Name == query {
+			found, matched = project, "name"
+			break
+		}
+		if found == nil && project.Name != "" && supabase.Slugify(project.Name) == query {
+			found, matched = project, "slug"
+		}
+	}
+
+	if found == nil {
+		id, err := h.storage.FindProjectIDByFormerName(query)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to look up project",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		if id != "" {
+			if found, err = h.storage.GetProject(id); err == nil {
+				matched = "former_name"
+			}
+		}
+	}
+
+	if found == nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_FOUND",
+				Message: "No project has or had this name",
+				Details: query,
+			},
+		})
+		return
+	}
+	if !checkOrganization(c, found.OrganizationID) {
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"id":          found.ID,
+		"name":        found.Name,
+		"slug":        supabase.Slugify(found.Name),
+		"project_ref": found.ProjectRef,
+		"project_url": found.ProjectURL,
+		"status":      found.Status,
+		"matched":     matched,
+	})
+}
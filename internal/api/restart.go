@@ -0,0 +1,119 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditProjectRestarted = "project.restarted"
+	jobRestartProject     = "restart_project"
+	// statusRestarting is the local status of a project while it restarts
+	statusRestarting = "RESTARTING"
+)
+
+const (
+	// restartSettleDelay is how long a restart is given to take the project
+	// down before its status is polled, so the status from before the
+	// restart isn't taken for the end of it
+	restartSettleDelay = 10 * time.Second
+	// restartTimeout bounds how long a restart may take
+	restartTimeout = 10 * time.Minute
+)
+
+// RestartProject handles POST /api/projects/:id/restart, e.g. to apply
+// Postgres config changes that need one. The project is marked RESTARTING
+// locally, so operations that need a ready project are refused until a
+// background job sees it ACTIVE_HEALTHY again.
+func (h *Handler) RestartProject(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	client, ok := h.clientFor(c, storedProject)
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "restart_project", gin.H{
+			"id":          storedProject.ID,
+			"project_ref": storedProject.ProjectRef,
+			"status":      storedProject.Status,
+		})
+		return
+	}
+
+	if err := client.RestartProject(storedProject.ProjectRef); err != nil {
+		respondManagementError(c, http.StatusBadGateway, "RESTART_FAILED", "Failed to restart project in Supabase", err)
+		return
+	}
+	h.audit(c, auditProjectRestarted, storedProject.ID, "")
+
+	if err := h.storage.UpdateProjectStatus(storedProject.ID, statusRestarting); err != nil {
+		fmt.Printf("Warning: Failed to mark project %s as restarting: %v\n", storedProject.ID, err)
+	} else {
+		h.recordStatus(storedProject.ID, statusRestarting)
+	}
+
+	job, err := h.startJob(c, jobRestartProject, func(logs *jobLog) (interface{}, error) {
+		return h.awaitRestart(client, storedProject, logs)
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Project is restarting, but waiting for it could not be started",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job":     job,
+		"message": fmt.Sprintf("Project is restarting. Poll /api/jobs/%s for completion", job.ID),
+	})
+}
+
+// awaitRestart waits for a restarted project to become healthy again and
+// stores the status it ends up in
+func (h *Handler) awaitRestart(client *supabase.Client, project *supabase.StoredProject, logs *jobLog) (interface{}, error) {
+	started := time.Now()
+	logs.printf("Restart of %s (%s) requested; waiting for it to become healthy", project.ID, project.ProjectRef)
+	if !h.sleepOrStop(restartSettleDelay) {
+		return nil, fmt.Errorf("shutting down before the project finished restarting")
+	}
+
+	readyProject, err := client.WaitForProject(project.ProjectRef, restartTimeout-restartSettleDelay)
+	if err != nil {
+		// Keep whatever status Supabase reports now, so the project isn't
+		// left marked as restarting
+		if current, getErr := client.GetProject(project.ProjectRef); getErr == nil && current.Status != "" {
+			h.updateRestartedStatus(project.ID, current.Status)
+			logs.printf("Project is %s", current.Status)
+		}
+		return nil, fmt.Errorf("project did not come back from the restart: %w", err)
+	}
+
+	h.updateRestartedStatus(project.ID, readyProject.Status)
+	elapsed := time.Since(started).Round(time.Second)
+	logs.printf("Project is %s after %s", readyProject.Status, elapsed)
+
+	return gin.H{"project_id": project.ID, "status": readyProject.Status, "restarted_in": elapsed.String()}, nil
+}
+
+// updateRestartedStatus stores the status a project is in after a restart
+func (h *Handler) updateRestartedStatus(projectID, status string) {
+	if err := h.storage.UpdateProjectStatus(projectID, status); err != nil {
+		fmt.Printf("Warning: Failed to update status of project %s: %v\n", projectID, err)
+		return
+	}
+	h.recordStatus(projectID, status)
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// jobSchemaBatch is the type of the job applying a migration across
+// projects
+const jobSchemaBatch = "schema_batch"
+
+// ApplySchemaBatch handles POST /api/schema/batch. It applies one migration
+// to every listed or selected project in a background job, a few projects
+// at a time. The migration is checked for each ready project before any of
+// them is migrated; projects that are not ready are skipped.
+func (h *Handler) ApplySchemaBatch(c *gin.Context) {
+	if h.shedMigration(c) {
+		return
+	}
+
+	var req supabase.BatchSchemaRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid batch",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	projects, ok := h.schemaBatchProjects(c, &req)
+	if !ok {
+		return
+	}
+
+	// Every project records the migration under the same version, so a
+	// batch sent again only runs where it did not apply
+	if req.Version == "" {
+		req.Version = newMigrationVersion()
+	}
+
+	steps := make([]*supabase.ApplySchemaRequest, len(projects))
+	for i, project := range projects {
+		if project.Status != "ACTIVE_HEALTHY" {
+			continue
+		}
+		if !h.checkFreeze(c, supabase.FreezeMigrations, project) {
+			return
+		}
+		step := req.ApplySchemaRequest
+		if !h.checkSubmittedMigration(c, project.ID, &step) {
+			return
+		}
+		steps[i] = &step
+	}
+
+	if isDryRun(c) {
+		plan, err := supabase.PlanMigration(req.SQL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_SQL",
+					Message: "SQL validation failed",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		targets := make([]gin.H, len(projects))
+		for i, project := range projects {
+			targets[i] = gin.H{"project_id": project.ID, "name": project.Name, "status": project.Status}
+			if steps[i] != nil {
+				targets[i]["role"] = steps[i].Role
+			}
+		}
+		respondDryRun(c, "apply_schema_batch", gin.H{
+			"version":     req.Version,
+			"plan":        plan,
+			"projects":    targets,
+			"concurrency": req.Concurrency,
+		})
+		return
+	}
+
+	checkFreeze := h.freezeGate(c, supabase.FreezeMigrations)
+	job, err := h.startJob(c, jobSchemaBatch, func(logs *jobLog) (interface{}, error) {
+		logs.printf("Applying migration %s to %d projects, %d at a time", req.Version, len(projects), req.Concurrency)
+		result := &supabase.SchemaBatchResult{Version: req.Version, Projects: []supabase.ProjectMigration{}}
+		for _, migration := range h.migrateProjects(projects, steps, req.Concurrency, req.FailFast, checkFreeze, logs) {
+			result.Add(migration)
+		}
+		logs.printf("%d applied, %d skipped, %d failed, %d cancelled", result.Summary.Applied, result.Summary.Skipped, result.Summary.Failed, result.Summary.Cancelled)
+		return result, nil
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to start batch migration",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusAccepted, gin.H{
+		"job":      job,
+		"version":  req.Version,
+		"projects": len(projects),
+		"message":  fmt.Sprintf("Batch migration started. Poll /api/jobs/%s for the per-project results.", job.ID),
+	})
+}
+
+// schemaBatchProjects returns the projects of a batch schema request, in
+// the order listed or, for a selector, as stored. Only projects the
+// caller's API key may work on are matched by a selector. It writes an
+// error response and returns false if a listed project does not exist or
+// belongs to another organization, or if none match the selector.
+func (h *Handler) schemaBatchProjects(c *gin.Context, req *supabase.BatchSchemaRequest) ([]*supabase.StoredProject, bool) {
+	if len(req.ProjectIDs) > 0 {
+		projects := make([]*supabase.StoredProject, 0, len(req.ProjectIDs))
+		for _, id := range req.ProjectIDs {
+			project, ok := h.loadProject(c, id)
+			if !ok {
+				return nil, false
+			}
+			projects = append(projects, project)
+		}
+		return projects, true
+	}
+
+	selector, err := supabase.ParseLabelSelector(req.LabelSelector)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid label selector",
+				Details: err.Error(),
+			},
+		})
+		return nil, false
+	}
+
+	all, err := h.storage.ListProjects()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list projects",
+				Details: err.Error(),
+			},
+		})
+		return nil, false
+	}
+
+	var projects []*supabase.StoredProject
+	for _, project := range all {
+		if selector.Matches(project.Labels) && callerAllowsOrganization(c, project.OrganizationID) {
+			projects = append(projects, project)
+		}
+	}
+	if len(projects) == 0 {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "NO_MATCHING_PROJECTS",
+				Message: "No projects match the label selector",
+				Details: fmt.Sprintf("label_selector: %q", selector.String()),
+			},
+		})
+		return nil, false
+	}
+	return projects, true
+}
+
+// migrateProjects applies each project's checked migration, concurrency at
+// a time, returning outcomes in the order of projects. Projects without a
+// step were not ready and are skipped. With failFast, projects not started
+// when one fails are cancelled. checkFreeze runs right before each
+// migration, so a project frozen since the batch started fails instead.
+func (h *Handler) migrateProjects(projects []*supabase.StoredProject, steps []*supabase.ApplySchemaRequest, concurrency int, failFast bool, checkFreeze func(*supabase.StoredProject) error, logs *jobLog) []supabase.ProjectMigration {
+	results := make([]supabase.ProjectMigration, len(projects))
+	slots := make(chan struct{}, concurrency)
+	var failed atomic.Bool
+	var wg sync.WaitGroup
+
+	for i, project := range projects {
+		results[i] = supabase.ProjectMigration{ProjectID: project.ID, Name: project.Name}
+
+		if steps[i] == nil {
+			results[i].Status = supabase.SchemaBatchSkipped
+			results[i].Error = "project is " + project.Status
+			continue
+		}
+
+		wg.Add(1)
+		slots <- struct{}{}
+		go func(result *supabase.ProjectMigration, project *supabase.StoredProject, step *supabase.ApplySchemaRequest) {
+			defer wg.Done()
+			defer func() { <-slots }()
+
+			if failFast && failed.Load() {
+				result.Status = supabase.SchemaBatchCancelled
+				result.Error = "an earlier project failed"
+				return
+			}
+
+			var migration *supabase.MigrationResult
+			err := checkFreeze(project)
+			if err == nil {
+				migration, err = h.applyMigration(project.ID, project.ToProject(), step, nil)
+			}
+			result.Result = migration
+			switch {
+			case err != nil || migration == nil || !migration.Success:
+				result.Status = supabase.SchemaBatchFailed
+				if err != nil {
+					result.Error = err.Error()
+				} else if migration != nil {
+					result.Error = migration.Error
+				}
+				failed.Store(true)
+				logs.printf("%s (%s): failed: %s", project.Name, project.ID, result.Error)
+			case migration.Skipped:
+				result.Status = supabase.SchemaBatchSkipped
+				result.Error = "version already applied"
+				logs.printf("%s (%s): skipped, version already applied", project.Name, project.ID)
+			default:
+				result.Status = supabase.SchemaBatchApplied
+				logs.printf("%s (%s): applied in %s", project.Name, project.ID, migration.ExecutionTime)
+			}
+		}(&results[i], project, steps[i])
+	}
+
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,169 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// projectSchema is an introspected project schema with the version hash it
+// was taken at
+type projectSchema struct {
+	snapshot  *supabase.SchemaSnapshot
+	version   string
+	checkedAt time.Time
+}
+
+// schemaCache holds the last introspected schema of each project. An entry
+// younger than the TTL is served without touching the project's database;
+// an older one is served after a cheap version check, and only a changed
+// version triggers a full introspection. Migrations drop the entry of their
+// target.
+type schemaCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]*projectSchema
+}
+
+// newSchemaCache creates an empty cache
+func newSchemaCache(ttl time.Duration) *schemaCache {
+	return &schemaCache{ttl: ttl, entries: make(map[string]*projectSchema)}
+}
+
+// fresh returns the entry of a project if it is younger than the TTL
+func (sc *schemaCache) fresh(projectID string) *projectSchema {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, ok := sc.entries[projectID]
+	if !ok || time.Since(entry.checkedAt) > sc.ttl {
+		return nil
+	}
+	return entry
+}
+
+// matching returns the entry of a project if it was taken at version,
+// marking it as checked now
+func (sc *schemaCache) matching(projectID, version string) *projectSchema {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+
+	entry, ok := sc.entries[projectID]
+	if !ok || entry.version != version {
+		return nil
+	}
+	entry.checkedAt = time.Now()
+	return entry
+}
+
+// store replaces the entry of a project
+func (sc *schemaCache) store(projectID string, entry *projectSchema) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	sc.entries[projectID] = entry
+}
+
+// invalidate drops the entry of a project, so the next read introspects it
+func (sc *schemaCache) invalidate(projectID string) {
+	sc.mu.Lock()
+	defer sc.mu.Unlock()
+	delete(sc.entries, projectID)
+}
+
+// GetProjectSchema handles GET /api/projects/:id/schema. The schema version
+// is the ETag, so a client sending it in If-None-Match gets 304 while the
+// schema is unchanged.
+func (h *Handler) GetProjectSchema(c *gin.Context) {
+	projectID := c.Param("id")
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	schema, status, errDetail := h.introspectProject(projectID)
+	if errDetail != nil {
+		c.JSON(status, supabase.ErrorResponse{Error: *errDetail})
+		return
+	}
+
+	etag := `"` + schema.version + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": projectID,
+		"version":    schema.version,
+		"checked_at": schema.checkedAt,
+		"schema":     schema.snapshot,
+	})
+}
+
+// introspectProject returns the schema of a stored project, from the cache
+// when it is still current, returning an HTTP status and error detail on
+// failure
+func (h *Handler) introspectProject(projectID string) (*projectSchema, int, *supabase.ErrorDetail) {
+	storedProject, err := h.storage.GetProject(projectID)
+	if err != nil {
+		return nil, http.StatusNotFound, &supabase.ErrorDetail{
+			Code:    "PROJECT_NOT_FOUND",
+			Message: "Project not found",
+			Details: fmt.Sprintf("%s: %v", projectID, err),
+		}
+	}
+
+	if storedProject.Status != "ACTIVE_HEALTHY" {
+		return nil, http.StatusBadRequest, &supabase.ErrorDetail{
+			Code:    "PROJECT_NOT_READY",
+			Message: "Project is not ready yet",
+			Details: fmt.Sprintf("%s: current status: %s", projectID, storedProject.Status),
+		}
+	}
+
+	if entry := h.schemas.fresh(projectID); entry != nil {
+		return entry, http.StatusOK, nil
+	}
+
+	runner, err := h.connectDB(storedProject.ToProject(), false)
+	if err != nil {
+		return nil, http.StatusInternalServerError, &supabase.ErrorDetail{
+			Code:    "INTROSPECTION_FAILED",
+			Message: "Failed to connect to database",
+			Details: fmt.Sprintf("%s: %v", projectID, err),
+		}
+	}
+	defer runner.Close()
+
+	version, err := runner.SchemaVersion()
+	if err != nil {
+		return nil, http.StatusInternalServerError, &supabase.ErrorDetail{
+			Code:    "INTROSPECTION_FAILED",
+			Message: "Failed to introspect database",
+			Details: fmt.Sprintf("%s: %v", projectID, err),
+		}
+	}
+	if entry := h.schemas.matching(projectID, version); entry != nil {
+		return entry, http.StatusOK, nil
+	}
+
+	snapshot, err := runner.Snapshot()
+	if err != nil {
+		return nil, http.StatusInternalServerError, &supabase.ErrorDetail{
+			Code:    "INTROSPECTION_FAILED",
+			Message: "Failed to introspect database",
+			Details: fmt.Sprintf("%s: %v", projectID, err),
+		}
+	}
+
+	entry := &projectSchema{snapshot: snapshot, version: version, checkedAt: time.Now()}
+	h.schemas.store(projectID, entry)
+	return entry, http.StatusOK, nil
+}
@@ -0,0 +1,87 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// DiffProjectSchema handles POST /api/projects/:id/schema/diff. It
+// introspects the project's database, from the schema cache while it is
+// current, and returns the DDL converging it on the desired schema given
+// as SQL or JSON. Nothing is applied.
+func (h *Handler) DiffProjectSchema(c *gin.Context) {
+	projectID := c.Param("id")
+
+	var req supabase.SchemaDiffRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid desired schema",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if h.maxSQLBytes > 0 && int64(len(req.SQL)) > h.maxSQLBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SQL_TOO_LARGE",
+				Message: "SQL exceeds the size limit",
+				Details: fmt.Sprintf("%d bytes, the limit is %d", len(req.SQL), h.maxSQLBytes),
+			},
+		})
+		return
+	}
+
+	desired, desiredFrom, ignored := req.Schema, "schema", 0
+	if req.SQL != "" {
+		var err error
+		desired, ignored, err = supabase.ParseSchemaSQL(req.SQL)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_DESIRED_SCHEMA",
+					Message: "Failed to parse sql",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		desiredFrom = "sql"
+	}
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+	schema, status, errDetail := h.introspectProject(projectID)
+	if errDetail != nil {
+		c.JSON(status, supabase.ErrorResponse{Error: *errDetail})
+		return
+	}
+
+	response := gin.H{
+		"project_id": projectID,
+		"version":    schema.version,
+		"desired":    desiredFrom,
+		"diff":       supabase.DiffSchemas(desired, schema.snapshot, req.Schemas, desiredFrom == "sql"),
+	}
+	if desiredFrom == "sql" {
+		response["ignored_statements"] = ignored
+	}
+	c.JSON(http.StatusOK, response)
+}
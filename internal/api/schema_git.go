@@ -0,0 +1,82 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// ApplySchemaFromGit handles POST /api/projects/:id/schema/from-git. It
+// fetches the migration files of a directory at a ref of a repository and
+// applies them in version order as one batch, so versions the database
+// already has are skipped and the batch stops at the first failure.
+func (h *Handler) ApplySchemaFromGit(c *gin.Context) {
+	var req supabase.ApplyGitMigrationsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err := req.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid git source",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	queued := provisioningStatuses[storedProject.Status] || h.migrationQueue.busy(storedProject.ID)
+	if !queued && storedProject.Status != "ACTIVE_HEALTHY" {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "PROJECT_NOT_READY",
+				Message: "Project is not ready yet",
+				Details: fmt.Sprintf("Current status: %s", storedProject.Status),
+			},
+		})
+		return
+	}
+
+	files, err := h.schemaFetcher.FetchDirectory(c.Request.Context(), req.SourceURL(), req.SourceToken)
+	req.SourceToken = ""
+	if err != nil {
+		respondSourceError(c, "Failed to fetch migrations from the repository", err)
+		return
+	}
+
+	batch, err := req.Batch(files)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid migrations directory",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	// Like submitted migrations, the batch waits for a project that is
+	// still provisioning
+	if queued {
+		h.queueSubmittedMigration(c, storedProject, batch)
+		return
+	}
+	h.runSubmittedMigration(c, storedProject.ID, storedProject.ToProject(), batch)
+}
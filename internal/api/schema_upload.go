@@ -0,0 +1,197 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// maxUploadFieldBytes caps the form fields sent with an uploaded script
+const maxUploadFieldBytes = 4 << 10
+
+// errSQLTooLarge is returned for uploaded scripts over MAX_SQL_BYTES
+var errSQLTooLarge = errors.New("SQL exceeds the size limit")
+
+// IsSQLUploadContentType reports whether a Content-Type is one a schema
+// request can upload its SQL with instead of JSON: multipart/form-data,
+// text/plain or application/sql
+func IsSQLUploadContentType(contentType string) bool {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return false
+	}
+	switch mediaType {
+	case "multipart/form-data", "text/plain", "application/sql":
+		return true
+	}
+	return false
+}
+
+// bindSchemaRequest reads a schema request sent as JSON or as an upload. A
+// multipart/form-data upload carries the script in its file (or sql) part
+// and the other string fields as form fields; a text/plain or
+// application/sql body is the script itself, with the fields as query
+// parameters. Uploads are read as a stream, without being buffered twice.
+// It writes an error response and returns false if the request can't be
+// read.
+func (h *Handler) bindSchemaRequest(c *gin.Context, req *supabase.ApplySchemaRequest) bool {
+	contentType := c.GetHeader("Content-Type")
+	if !IsSQLUploadContentType(contentType) {
+		if err := c.ShouldBindJSON(req); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid request body",
+					Details: err.Error(),
+				},
+			})
+			return false
+		}
+		return true
+	}
+
+	var err error
+	if strings.HasPrefix(contentType, "multipart/") {
+		err = h.readSchemaForm(c, req)
+	} else {
+		for field, values := range c.Request.URL.Query() {
+			if field == "dry_run" {
+				continue
+			}
+			if err = setSchemaField(req, field, values[0]); err != nil {
+				break
+			}
+		}
+		if err == nil {
+			req.SQL, err = h.readUploadedSQL(c.Request.Body)
+		}
+	}
+
+	var maxBytesErr *http.MaxBytesError
+	switch {
+	case err == nil:
+		return true
+	case errors.Is(err, errSQLTooLarge):
+		c.JSON(http.StatusRequestEntityTooLarge, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SQL_TOO_LARGE",
+				Message: "SQL exceeds the size limit",
+				Details: fmt.Sprintf("The limit is %d bytes; use source_url for larger scripts", h.maxSQLBytes),
+			},
+		})
+	case errors.As(err, &maxBytesErr):
+		c.JSON(http.StatusRequestEntityTooLarge, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "REQUEST_TOO_LARGE",
+				Message: "Request body exceeds the size limit",
+				Details: fmt.Sprintf("The limit is %d bytes", maxBytesErr.Limit),
+			},
+		})
+	default:
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid upload",
+				Details: err.Error(),
+			},
+		})
+	}
+	return false
+}
+
+// readSchemaForm reads a multipart/form-data schema request part by part
+func (h *Handler) readSchemaForm(c *gin.Context, req *supabase.ApplySchemaRequest) error {
+	reader, err := c.Request.MultipartReader()
+	if err != nil {
+		return err
+	}
+
+	uploaded := false
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+
+		name := part.FormName()
+		switch {
+		case name == "file" || name == "sql":
+			if uploaded {
+				return fmt.Errorf("only one file or sql part may be sent")
+			}
+			uploaded = true
+			if req.SQL, err = h.readUploadedSQL(part); err != nil {
+				return err
+			}
+		default:
+			value, err := io.ReadAll(io.LimitReader(part, maxUploadFieldBytes+1))
+			if err != nil {
+				return err
+			}
+			if len(value) > maxUploadFieldBytes {
+				return fmt.Errorf("field %s is larger than %d bytes", name, maxUploadFieldBytes)
+			}
+			if err := setSchemaField(req, name, string(value)); err != nil {
+				return err
+			}
+		}
+		part.Close()
+	}
+
+	if !uploaded {
+		return fmt.Errorf("a file or sql part with the script is required")
+	}
+	return nil
+}
+
+// readUploadedSQL reads an uploaded script, failing with errSQLTooLarge
+// past MAX_SQL_BYTES
+func (h *Handler) readUploadedSQL(r io.Reader) (string, error) {
+	if h.maxSQLBytes > 0 {
+		r = io.LimitReader(r, h.maxSQLBytes+1)
+	}
+	var sql strings.Builder
+	if _, err := io.Copy(&sql, r); err != nil {
+		return "", err
+	}
+	if h.maxSQLBytes > 0 && int64(sql.Len()) > h.maxSQLBytes {
+		return "", errSQLTooLarge
+	}
+	if strings.TrimSpace(sql.String()) == "" {
+		return "", fmt.Errorf("the uploaded script is empty")
+	}
+	return sql.String(), nil
+}
+
+// setSchemaField sets a string field of a schema request from a form field
+// or query parameter. The structured fields, such as verify and
+// migrations, are only taken as JSON.
+func setSchemaField(req *supabase.ApplySchemaRequest, field, value string) error {
+	switch field {
+	case "name":
+		req.Name = value
+	case "description":
+		req.Description = value
+	case "author":
+		req.Author = value
+	case "ticket":
+		req.Ticket = value
+	case "role":
+		req.Role = value
+	case "version":
+		req.Version = value
+	default:
+		return fmt.Errorf("unknown field %q (uploads take name, description, author, ticket, role and version)", field)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gin-gonic/gin/binding"
+
+	"supabase-manager/internal/jsonschema"
+	"supabase-manager/internal/supabase"
+)
+
+// ListSchemas handles GET /api/schemas
+func (h *Handler) ListSchemas(c *gin.Context) {
+	names := supabase.SchemaNames()
+	schemas := make([]gin.H, len(names))
+	for i, name := range names {
+		schemas[i] = gin.H{
+			"name": name,
+			"url":  "/api/schemas/" + name + ".json",
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"version": supabase.SchemaVersion,
+		"schemas": schemas,
+	})
+}
+
+// GetSchema handles GET /api/schemas/:file, serving a published JSON Schema
+// such as create-project.json
+func (h *Handler) GetSchema(c *gin.Context) {
+	name := strings.TrimSuffix(c.Param("file"), ".json")
+	schema, ok := supabase.LookupSchema(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SCHEMA_NOT_FOUND",
+				Message: "Schema not found",
+				Details: fmt.Sprintf("Expected one of %s", strings.Join(supabase.SchemaNames(), ", ")),
+			},
+		})
+		return
+	}
+
+	c.Header("Content-Type", "application/schema+json")
+	c.JSON(http.StatusOK, schema)
+}
+
+// bindValidatedJSON checks the request body against a published schema and
+// binds it to obj. It writes an error response listing every violation and
+// returns false if the body does not match.
+func bindValidatedJSON(c *gin.Context, schemaName string, obj interface{}) bool {
+	schema, _ := supabase.LookupSchema(schemaName)
+
+	body, err := c.GetRawData()
+	if err == nil {
+		var violations []jsonschema.Violation
+		violations, err = jsonschema.ValidateJSON(schema, body)
+		if err == nil && len(violations) > 0 {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:       "SCHEMA_VALIDATION_FAILED",
+					Message:    "Request body does not match its schema",
+					Details:    fmt.Sprintf("%d violations of /api/schemas/%s.json", len(violations), schemaName),
+					Violations: violations,
+				},
+			})
+			return false
+		}
+	}
+	if err == nil {
+		err = binding.JSON.BindBody(body, obj)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return false
+	}
+	return true
+}
@@ -0,0 +1,54 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const auditSecretsRejected = "migration.secrets_rejected"
+
+// checkSecrets rejects a submitted migration with embedded credentials
+// when SQL_SECRET_SCAN is reject. It runs before the SQL is sent to the
+// policy endpoint or stored. It writes a 422 response, audits the attempt
+// and returns false when the migration is rejected.
+func (h *Handler) checkSecrets(c *gin.Context, targetID, sqlScript string) bool {
+	if h.sqlSecretScan != supabase.SecretScanReject {
+		return true
+	}
+
+	findings := supabase.ScanSecrets(sqlScript)
+	if len(findings) == 0 {
+		return true
+	}
+
+	kinds := make([]string, len(findings))
+	locations := make([]string, len(findings))
+	for i, f := range findings {
+		kinds[i] = f.Kind
+		locations[i] = fmt.Sprintf("%s on line %d (%s)", f.Kind, f.Line, f.Preview)
+	}
+	h.audit(c, auditSecretsRejected, targetID, strings.Join(kinds, ","))
+
+	c.JSON(http.StatusUnprocessableEntity, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    "SECRETS_DETECTED",
+			Message: "Migration contains embedded credentials",
+			Details: strings.Join(locations, "; ") + ". Secrets would be kept in the migration history; load them at runtime instead",
+		},
+	})
+	return false
+}
+
+// secretWarnings returns the credentials embedded in a migration as lint
+// warnings when SQL_SECRET_SCAN is warn
+func (h *Handler) secretWarnings(sqlScript string) []supabase.LintWarning {
+	if h.sqlSecretScan != supabase.SecretScanWarn {
+		return nil
+	}
+	return supabase.SecretWarnings(supabase.ScanSecrets(sqlScript))
+}
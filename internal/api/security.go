@@ -0,0 +1,36 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/notify"
+)
+
+const auditIPBanned = "security.ip_banned"
+
+// ReportAuthBan records a client IP being banned for presenting too many
+// invalid API keys and raises a warning, as it may be someone guessing
+// keys
+func (h *Handler) ReportAuthBan(c *gin.Context, ban *auth.Ban) {
+	details := fmt.Sprintf("%d invalid API keys from %s; banned for %s until %s (ban %d)",
+		ban.Failures, ban.IP, ban.Duration, ban.Until.UTC().Format(time.RFC3339), ban.Offense)
+	h.auditAs(c, "anonymous", auditIPBanned, "", details)
+
+	if h.notifier == nil {
+		return
+	}
+	err := h.notifier.Notify(notify.Notification{
+		Severity: notify.SeverityWarning,
+		Title:    "Client IP banned",
+		Message:  details,
+		Labels:   map[string]string{"client_ip": ban.IP, "offense": fmt.Sprint(ban.Offense)},
+		Time:     time.Now().UTC(),
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to send ban notification for %s: %v\n", ban.IP, err)
+	}
+}
@@ -0,0 +1,39 @@
+package api
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// GetProjectSetup handles GET /api/projects/:id/setup, reporting the
+// outcome of each component of the project's bootstrap: buckets, log
+// drains, secrets, auth providers, extensions, profile and template SQL,
+// and migrations
+func (h *Handler) GetProjectSetup(c *gin.Context) {
+	projectID := c.Param("id")
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	steps, err := h.storage.ListSetupSteps(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to load setup steps",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"project_id": projectID,
+		"status":     supabase.SetupStatus(steps),
+		"steps":      steps,
+	})
+}
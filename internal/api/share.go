@@ -0,0 +1,249 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/storage"
+	"supabase-manager/internal/supabase"
+)
+
+// Audit actions for share links
+const (
+	auditShareLinkCreated  = "share_link.created"
+	auditShareLinkRedeemed = "share_link.redeemed"
+	auditShareLinkRejected = "share_link.rejected"
+	auditShareLinkReplayed = "share_link.replayed"
+)
+
+// CreateShareLink handles POST /api/projects/:id/share. It mints a
+// one-time, expiring link that returns the project's URL and anon key to
+// whoever opens it first, without an API key. The service key is never
+// shared.
+func (h *Handler) CreateShareLink(c *gin.Context) {
+	var req supabase.CreateShareLinkRequest
+	if c.Request.ContentLength != 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INVALID_REQUEST",
+					Message: "Invalid request body",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+	}
+
+	ttl, err := req.TTL()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid share link",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	project, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	if project.AnonKey == "" {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "API_KEYS_MISSING",
+				Message: "Project has no anon key to share",
+				Details: "The project's API keys have not been fetched yet",
+			},
+		})
+		return
+	}
+
+	now := time.Now().UTC()
+	if isDryRun(c) {
+		respondDryRun(c, "create_share_link", gin.H{
+			"project_id": project.ID,
+			"expires_at": now.Add(ttl),
+			"shares":     []string{"project_url", "anon_key"},
+		})
+		return
+	}
+
+	token, tokenHash, err := supabase.NewShareToken()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create share link",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	link := &supabase.ShareLink{
+		ID:        uuid.New().String(),
+		ProjectID: project.ID,
+		TokenHash: tokenHash,
+		CreatedBy: callerID(c),
+		Note:      req.Note,
+		ExpiresAt: now.Add(ttl),
+		CreatedAt: now,
+	}
+	if err := h.storage.SaveShareLink(link); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create share link",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.audit(c, auditShareLinkCreated, project.ID,
+		fmt.Sprintf("link %s expires %s; note: %s", link.ID, link.ExpiresAt.Format(time.RFC3339), link.Note))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"link":  link,
+		"token": token,
+		"url":   fmt.Sprintf("%s://%s/share/%s", requestScheme(c), c.Request.Host, token),
+	})
+}
+
+// ListShareLinks handles GET /api/projects/:id/share. Tokens are not
+// stored, so they cannot be listed.
+func (h *Handler) ListShareLinks(c *gin.Context) {
+	project, ok := h.loadProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+
+	links, err := h.storage.ListShareLinks(project.ID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list share links",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"share_links": links,
+		"total":       len(links),
+	})
+}
+
+// RedeemShareLink handles GET /share/:token. It needs no API key; the
+// token itself is the credential and works once.
+func (h *Handler) RedeemShareLink(c *gin.Context) {
+	link, err := h.storage.RedeemShareLink(supabase.HashShareToken(c.Param("token")), time.Now())
+	if err != nil {
+		switch {
+		case link != nil && errors.Is(err, storage.ErrShareLinkUsed):
+			h.reportShareLinkReplay(c, link)
+		case link != nil:
+			h.auditAs(c, "share_link:"+link.ID, auditShareLinkRejected, link.ProjectID, err.Error())
+		}
+
+		switch {
+		case errors.Is(err, storage.ErrShareLinkNotFound):
+			c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "SHARE_LINK_NOT_FOUND",
+					Message: "Share link not found",
+				},
+			})
+		case errors.Is(err, storage.ErrShareLinkUsed), errors.Is(err, storage.ErrShareLinkExpired):
+			c.JSON(http.StatusGone, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "SHARE_LINK_GONE",
+					Message: "Share link is no longer valid",
+					Details: err.Error(),
+				},
+			})
+		default:
+			c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "INTERNAL_ERROR",
+					Message: "Failed to redeem share link",
+					Details: err.Error(),
+				},
+			})
+		}
+		return
+	}
+
+	project, err := h.storage.GetProject(link.ProjectID)
+	if err != nil {
+		h.auditAs(c, "share_link:"+link.ID, auditShareLinkRejected, link.ProjectID, "project no longer exists")
+		c.JSON(http.StatusGone, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SHARE_LINK_GONE",
+				Message: "Share link is no longer valid",
+				Details: "The project no longer exists",
+			},
+		})
+		return
+	}
+
+	h.auditAs(c, "share_link:"+link.ID, auditShareLinkRedeemed, project.ID,
+		fmt.Sprintf("link created by %s", link.CreatedBy))
+
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"project_ref": project.ProjectRef,
+		"project_url": project.ProjectURL,
+		"anon_key":    project.AnonKey,
+	})
+}
+
+// reportShareLinkReplay records an attempt to redeem a link that was
+// already redeemed and raises a warning: the token is single-use, so the
+// attempt fails, but someone other than the recipient may hold the link
+func (h *Handler) reportShareLinkReplay(c *gin.Context, link *supabase.ShareLink) {
+	details := "link was presented again after it was redeemed"
+	if link.UsedAt != nil {
+		details = fmt.Sprintf("link redeemed at %s was presented again", link.UsedAt.UTC().Format(time.RFC3339))
+	}
+	h.auditAs(c, "share_link:"+link.ID, auditShareLinkReplayed, link.ProjectID, details)
+
+	if h.notifier == nil {
+		return
+	}
+	err := h.notifier.Notify(notify.Notification{
+		Severity: notify.SeverityWarning,
+		Title:    "Share link replayed",
+		Message:  fmt.Sprintf("Share link %s of project %s: %s", link.ID, link.ProjectID, details),
+		Labels:   map[string]string{"project_id": link.ProjectID, "share_link_id": link.ID},
+		Time:     time.Now().UTC(),
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to send share link replay notification for %s: %v\n", link.ID, err)
+	}
+}
+
+// requestScheme returns the scheme the client used, honouring a proxy's
+// X-Forwarded-Proto
+func requestScheme(c *gin.Context) string {
+	if proto := c.GetHeader("X-Forwarded-Proto"); proto == "http" || proto == "https" {
+		return proto
+	}
+	if c.Request.TLS != nil {
+		return "https"
+	}
+	return "http"
+}
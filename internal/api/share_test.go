@@ -0,0 +1,97 @@
+package api
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// readyProject returns a ready project with API keys, not yet stored
+func readyProject(id string) *supabase.StoredProject {
+	return &supabase.StoredProject{
+		ID:         id,
+		ProjectRef: "ref" + id,
+		ProjectURL: "https://ref" + id + ".supabase.co",
+		AnonKey:    "anon-" + id,
+		ServiceKey: "service-" + id,
+		Status:     "ACTIVE_HEALTHY",
+		CreatedAt:  time.Now(),
+		UpdatedAt:  time.Now(),
+	}
+}
+
+// saveProject stores a project directly, bypassing provisioning
+func (e *testEnv) saveProject(t *testing.T, project *supabase.StoredProject) {
+	t.Helper()
+
+	if err := e.store.SaveProject(project); err != nil {
+		t.Fatalf("save project: %v", err)
+	}
+}
+
+func TestShareLinkRedeemsOnce(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	env.router.POST("/api/projects/:id/share", env.handler.CreateShareLink)
+	env.router.GET("/share/:token", env.handler.RedeemShareLink)
+	env.saveProject(t, readyProject("shared"))
+
+	rec := env.do(t, http.MethodPost, "/api/projects/shared/share", map[string]string{"note": "for the demo"})
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create share link: got %d %s", rec.Code, rec.Body.String())
+	}
+	token, _ := decode(t, rec)["token"].(string)
+	if token == "" {
+		t.Fatalf("share link has no token: %s", rec.Body.String())
+	}
+
+	rec = env.do(t, http.MethodGet, "/share/"+token, nil)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("redeem: got %d %s", rec.Code, rec.Body.String())
+	}
+	shared := decode(t, rec)
+	if shared["anon_key"] != "anon-shared" || shared["project_url"] != "https://refshared.supabase.co" {
+		t.Errorf("redeem returned %v", shared)
+	}
+	if _, ok := shared["service_key"]; ok {
+		t.Errorf("share link exposed the service key")
+	}
+
+	rec = env.do(t, http.MethodGet, "/share/"+token, nil)
+	if rec.Code != http.StatusGone || errorCode(t, rec) != "SHARE_LINK_GONE" {
+		t.Errorf("second redeem: got %d %s", rec.Code, rec.Body.String())
+	}
+
+	rec = env.do(t, http.MethodGet, "/share/not-a-token", nil)
+	if rec.Code != http.StatusNotFound || errorCode(t, rec) != "SHARE_LINK_NOT_FOUND" {
+		t.Errorf("unknown token: got %d %s", rec.Code, rec.Body.String())
+	}
+}
+
+func TestShareLinkExpires(t *testing.T) {
+	env := newTestEnv(t, Options{})
+	env.router.GET("/share/:token", env.handler.RedeemShareLink)
+	env.saveProject(t, readyProject("shared"))
+
+	token, tokenHash, err := supabase.NewShareToken()
+	if err != nil {
+		t.Fatalf("new token: %v", err)
+	}
+	link := &supabase.ShareLink{
+		ID:        "expired",
+		ProjectID: "shared",
+		TokenHash: tokenHash,
+		CreatedBy: "test",
+		ExpiresAt: time.Now().Add(-time.Minute),
+		CreatedAt: time.Now().Add(-time.Hour),
+	}
+	if err := env.store.SaveShareLink(link); err != nil {
+		t.Fatalf("save share link: %v", err)
+	}
+
+	rec := env.do(t, http.MethodGet, "/share/"+token, nil)
+	if rec.Code != http.StatusGone || errorCode(t, rec) != "SHARE_LINK_GONE" {
+		t.Errorf("expired link: got %d %s", rec.Code, rec.Body.String())
+	}
+}
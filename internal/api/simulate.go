@@ -0,0 +1,100 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const auditMigrationSimulated = "migration.simulated"
+
+// isSimulation reports whether the caller asked for a migration to be
+// simulated, via the simulate query parameter or the X-Simulate header
+func isSimulation(c *gin.Context) bool {
+	if value, err := strconv.ParseBool(c.Query("simulate")); err == nil && value {
+		return true
+	}
+	value, err := strconv.ParseBool(c.GetHeader("X-Simulate"))
+	return err == nil && value
+}
+
+// simulateMigration runs a checked migration against a scratch schema of
+// the target and writes the simulation as the response. Unlike a dry run,
+// which only plans the SQL, it executes every statement against copies of
+// the target's tables, and unlike applying it, nothing outlives the
+// request.
+func (h *Handler) simulateMigration(c *gin.Context, targetID string, target supabase.DatabaseTarget, req *supabase.ApplySchemaRequest) {
+	if len(req.Migrations) > 0 {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Batches cannot be simulated",
+				Details: "simulate the migrations one at a time, sending each as sql",
+			},
+		})
+		return
+	}
+
+	if _, err := supabase.PlanMigration(req.SQL); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_SQL",
+				Message: "SQL validation failed",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	runner, err := h.newMigrationRunner(target)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SIMULATION_FAILED",
+				Message: "Failed to connect to database",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	defer runner.Close()
+
+	result, err := runner.SimulateMigration(req.SQL, req.Role)
+	if result == nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SIMULATION_FAILED",
+				Message: "Failed to prepare the scratch schema",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_SQL",
+				Message: "SQL validation failed",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.audit(c, auditMigrationSimulated, targetID, fmt.Sprintf("success=%t statements=%d", result.Success, result.StatementsRun))
+
+	response := gin.H{
+		"simulated": true,
+		"target_id": targetID,
+		"role":      req.Role,
+		"result":    result,
+	}
+	if warnings := h.secretWarnings(req.SQL); len(warnings) > 0 {
+		response["secret_warnings"] = warnings
+	}
+	c.JSON(http.StatusOK, response)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// provisioningSLOReport measures the provisions of the SLO window
+func (h *Handler) provisioningSLOReport() (*supabase.ProvisioningSLOReport, error) {
+	now := time.Now().UTC()
+	outcomes, err := h.storage.ProvisionOutcomes(now.Add(-h.provisioningSLO.Window))
+	if err != nil {
+		return nil, err
+	}
+	return h.provisioningSLO.Report(outcomes, now), nil
+}
+
+// GetSLO handles GET /api/slo
+func (h *Handler) GetSLO(c *gin.Context) {
+	report, err := h.provisioningSLOReport()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to load provisioning outcomes",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"provisioning": report,
+		"compliant":    report.SuccessRate.Compliant && report.TimeToHealthy.Compliant,
+		"checked_at":   time.Now().UTC(),
+	})
+}
+
+// ErrorBudgetBurning reports whether the provisioning error budget is being
+// spent faster than the alert threshold over the burn window, with an
+// explanation, for the self-monitoring rules
+func (h *Handler) ErrorBudgetBurning() (bool, string) {
+	report, err := h.provisioningSLOReport()
+	if err != nil {
+		fmt.Printf("Warning: Failed to check the provisioning error budget: %v\n", err)
+		return false, ""
+	}
+
+	burn := report.SuccessRate.Burn
+	if !burn.Burning {
+		return false, ""
+	}
+	return true, fmt.Sprintf("%d of %d provisions failed over the last %s, burning the error budget %.1fx as fast as the %.2f%% target allows (threshold %.1fx); %.1f%% of the budget remains",
+		burn.Failed, burn.Provisions, h.provisioningSLO.BurnWindow, burn.Rate,
+		report.SuccessRate.TargetPercent, burn.AlertThreshold, report.SuccessRate.BudgetRemainingPercent)
+}
+
+// isProvisioningFault reports whether a failed create counts against the
+// provisioning SLO: rejections of the request itself, such as a taken name
+// or an exhausted quota, do not
+func isProvisioningFault(err error) bool {
+	var apiErr *supabase.APIError
+	return !errors.As(err, &apiErr) || apiErr.HTTPStatus() == http.StatusBadGateway
+}
+
+// recordProvisionFailure stores a failed provision for the SLO
+func (h *Handler) recordProvisionFailure(projectID, region string, started time.Time) {
+	if err := h.storage.RecordProvisionFailure(projectID, region, started, time.Since(started)); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
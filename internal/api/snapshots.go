@@ -0,0 +1,328 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/policy"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditProjectDeleted   = "project.deleted"
+	auditSnapshotRestored = "snapshot.restored"
+	auditSnapshotDeleted  = "snapshot.deleted"
+)
+
+// jobRestoreSnapshot is the type of the job loading a snapshot into the
+// project restored from it
+const jobRestoreSnapshot = "restore_snapshot"
+
+// wantsPreDeleteSnapshot reports whether a project is snapshotted before it
+// is deleted in Supabase: when the snapshot policy covers it or the caller
+// asked for it. Projects whose remote deletion was already tried are not, as
+// their database may already be gone.
+func (h *Handler) wantsPreDeleteSnapshot(project *supabase.StoredProject, requested bool) bool {
+	if project.Status == "PENDING_DELETION" || project.Status == "DELETION_FAILED" {
+		return false
+	}
+	return requested || h.snapshotPolicy.Requires(project.Labels)
+}
+
+// takeSnapshot dumps a project's database and stores the snapshot
+func (h *Handler) takeSnapshot(ctx context.Context, project *supabase.StoredProject, reason, createdBy string) (*supabase.Snapshot, error) {
+	target := project.ToProject()
+	release, err := h.dbPool.acquire(target.GetDatabaseConnectionString(), h.stop)
+	if err != nil {
+		return nil, err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(ctx, h.snapshotTimeout)
+	defer cancel()
+
+	snapshot := &supabase.Snapshot{
+		ID:              uuid.New().String(),
+		ProjectID:       project.ID,
+		ProjectRef:      project.ProjectRef,
+		ProjectName:     project.Name,
+		Region:          project.Region,
+		PostgresVersion: project.PostgresVersion,
+		Reason:          reason,
+		CreatedBy:       createdBy,
+		CreatedAt:       time.Now().UTC(),
+	}
+	if err := h.snapshotTool.Dump(ctx, target, snapshot); err != nil {
+		return nil, err
+	}
+	if err := h.storage.SaveSnapshot(snapshot); err != nil {
+		os.Remove(snapshot.Path)
+		return nil, err
+	}
+	return snapshot, nil
+}
+
+// deletionDetails describes a project deletion for the audit log
+func deletionDetails(project *supabase.StoredProject, remote bool, snapshot *supabase.Snapshot) string {
+	details := fmt.Sprintf("ref %s, name %q, delete_remote %t", project.ProjectRef, project.Name, remote)
+	if snapshot != nil {
+		details += ", snapshot " + snapshot.ID
+	}
+	return details
+}
+
+// ListSnapshots handles GET /api/snapshots, optionally filtered with
+// project_id
+func (h *Handler) ListSnapshots(c *gin.Context) {
+	snapshots, err := h.storage.ListSnapshots(c.Query("project_id"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list snapshots",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"snapshots": snapshots,
+		"total":     len(snapshots),
+	})
+}
+
+// GetSnapshot handles GET /api/snapshots/:id
+func (h *Handler) GetSnapshot(c *gin.Context) {
+	snapshot, ok := h.loadSnapshot(c)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, snapshot)
+}
+
+// DeleteSnapshot handles DELETE /api/snapshots/:id, removing the dump
+// from disk
+func (h *Handler) DeleteSnapshot(c *gin.Context) {
+	snapshot, ok := h.loadSnapshot(c)
+	if !ok {
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "delete_snapshot", gin.H{"snapshot": snapshot})
+		return
+	}
+
+	if err := h.storage.DeleteSnapshot(snapshot.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete snapshot",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err := os.Remove(snapshot.Path); err != nil && !errors.Is(err, os.ErrNotExist) {
+		fmt.Printf("Warning: Failed to remove snapshot file %s: %v\n", snapshot.Path, err)
+	}
+	h.audit(c, auditSnapshotDeleted, snapshot.ProjectID, snapshot.ID)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Snapshot deleted successfully",
+		"id":      snapshot.ID,
+	})
+}
+
+// RestoreSnapshot handles POST /api/snapshots/:id/restore. It creates a new
+// project like the snapshotted one and loads the snapshot into it once it
+// is ready, in a job. Migrations submitted for the new project meanwhile
+// run after the restore.
+func (h *Handler) RestoreSnapshot(c *gin.Context) {
+	if h.shedProvisioning(c) {
+		return
+	}
+
+	snapshot, ok := h.loadSnapshot(c)
+	if !ok {
+		return
+	}
+
+	var req supabase.RestoreSnapshotRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err := supabase.ValidateLabels(req.Labels); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid labels",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	namingPolicy := h.projectNamingPolicy()
+	if err := namingPolicy.Check(req.Name, req.Labels); err != nil {
+		respondNamingPolicyViolation(c, namingPolicy, req.Name, req.Labels, err)
+		return
+	}
+
+	if _, err := os.Stat(snapshot.Path); err != nil {
+		c.JSON(http.StatusGone, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SNAPSHOT_FILE_MISSING",
+				Message: "The snapshot's dump is no longer on disk",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	createReq := supabase.CreateProjectRequest{
+		Name:            req.Name,
+		Region:          req.Region,
+		PostgresVersion: snapshot.PostgresVersion,
+		Labels:          req.Labels,
+	}
+	if createReq.Region == "" {
+		createReq.Region = snapshot.Region
+	}
+	if !h.checkRegion(c, createReq.Region) {
+		return
+	}
+
+	if !h.checkPolicy(c, policy.OperationCreateProject, "", &createReq) {
+		return
+	}
+
+	// Hold the naming lock until the project is saved so concurrent
+	// requests cannot resolve to the same name
+	h.naming.Lock()
+	defer h.naming.Unlock()
+
+	if _, err := h.resolveProjectName(h.supabaseClient, &createReq); err != nil {
+		var conflict *nameConflictError
+		if errors.As(err, &conflict) {
+			c.JSON(http.StatusConflict, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "PROJECT_NAME_TAKEN",
+					Message: "Project name is already in use",
+					Details: err.Error(),
+				},
+			})
+			return
+		}
+		respondManagementError(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to check project name", err)
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "restore_snapshot", gin.H{
+			"snapshot":         snapshot,
+			"name":             createReq.Name,
+			"region":           createReq.Region,
+			"postgres_version": createReq.PostgresVersion,
+			"labels":           createReq.Labels,
+		})
+		return
+	}
+
+	storedProject, provisionJob, err := h.provisionProject(h.supabaseClient, &createReq, h.bootstrapFor(&createReq), callerID(c))
+	if err != nil {
+		respondManagementError(c, http.StatusInternalServerError, "PROJECT_CREATION_FAILED", "Failed to create Supabase project", err)
+		return
+	}
+
+	actor, requestID, clientIP := callerID(c), c.GetString("request_id"), c.ClientIP()
+	previous, done := h.migrationQueue.enqueue(storedProject.ID)
+	job, err := h.startJob(c, jobRestoreSnapshot, func(logs *jobLog) (interface{}, error) {
+		defer done()
+
+		logs.printf("Waiting for project %s to become ready", storedProject.ID)
+		ready, err := h.awaitQueuedMigration(storedProject.ID, previous)
+		if err != nil {
+			return nil, err
+		}
+		logs.printf("Restoring snapshot %s (%d bytes)", snapshot.ID, snapshot.SizeBytes)
+		if err := h.restoreSnapshot(ready, snapshot); err != nil {
+			return nil, err
+		}
+
+		h.recordAudit(&supabase.AuditEvent{
+			ID:        uuid.New().String(),
+			Action:    auditSnapshotRestored,
+			ProjectID: ready.ID,
+			Actor:     actor,
+			RequestID: requestID,
+			ClientIP:  clientIP,
+			Details:   fmt.Sprintf("snapshot %s of project %s (%s)", snapshot.ID, snapshot.ProjectID, snapshot.ProjectRef),
+			CreatedAt: time.Now().UTC(),
+		})
+		return gin.H{"project_id": ready.ID, "snapshot_id": snapshot.ID}, nil
+	})
+	if err != nil {
+		done()
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to queue the restore",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	response := h.projectResponse(storedProject, provisionJob)
+	response["restore_job_id"] = job.ID
+	response["message"] = fmt.Sprintf("Project creation initiated; the snapshot is restored once it is ready. Poll /api/jobs/%s for the result.", job.ID)
+	c.JSON(http.StatusCreated, response)
+}
+
+// restoreSnapshot loads a snapshot into a ready project
+func (h *Handler) restoreSnapshot(project *supabase.StoredProject, snapshot *supabase.Snapshot) error {
+	target := project.ToProject()
+	release, err := h.dbPool.acquire(target.GetDatabaseConnectionString(), h.stop)
+	if err != nil {
+		return err
+	}
+	defer release()
+
+	ctx, cancel := context.WithTimeout(context.Background(), h.snapshotTimeout)
+	defer cancel()
+
+	defer h.schemas.invalidate(project.ID)
+	return h.snapshotTool.Restore(ctx, target, snapshot)
+}
+
+// loadSnapshot looks up the snapshot named by the :id parameter, writing a
+// 404 if there is none
+func (h *Handler) loadSnapshot(c *gin.Context) (*supabase.Snapshot, bool) {
+	snapshot, err := h.storage.GetSnapshot(c.Param("id"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "SNAPSHOT_NOT_FOUND",
+				Message: "Snapshot not found",
+				Details: err.Error(),
+			},
+		})
+		return nil, false
+	}
+	return snapshot, true
+}
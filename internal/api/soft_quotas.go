@@ -0,0 +1,74 @@
+package api
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/notify"
+	"supabase-manager/internal/supabase"
+)
+
+const auditQuotaReached = "quota.reached"
+
+// checkSoftQuotas returns the soft quotas the create of a project reached
+// or went past. The create that first reaches a quota is audited and sends
+// a notification; later ones only carry the warning.
+func (h *Handler) checkSoftQuotas(c *gin.Context, project *supabase.StoredProject) []supabase.QuotaWarning {
+	if !h.softQuotas.Enabled() {
+		return nil
+	}
+
+	keyProjects, orgProjects := -1, -1
+	if h.softQuotas.ProjectsPerKey > 0 && auth.FromContext(c) != nil {
+		count, err := h.storage.CountProjectsCreatedBy(project.CreatedBy)
+		if err != nil {
+			fmt.Printf("Warning: Failed to check the project quota of %s: %v\n", project.CreatedBy, err)
+		} else {
+			keyProjects = count
+		}
+	}
+	if h.softQuotas.OrganizationCapacity > 0 && project.OrganizationID != "" {
+		count, err := h.storage.CountOrganizationProjects(project.OrganizationID)
+		if err != nil {
+			fmt.Printf("Warning: Failed to check the capacity of organization %s: %v\n", project.OrganizationID, err)
+		} else {
+			orgProjects = count
+		}
+	}
+
+	warnings := h.softQuotas.Check(keyProjects, orgProjects)
+	for _, warning := range warnings {
+		if warning.Crossed {
+			h.audit(c, auditQuotaReached, project.ID, warning.Message)
+			h.notifyQuotaReached(project, warning)
+		}
+	}
+	return warnings
+}
+
+// notifyQuotaReached sends a notification about a soft quota reached by
+// the create of a project
+func (h *Handler) notifyQuotaReached(project *supabase.StoredProject, warning supabase.QuotaWarning) {
+	if h.notifier == nil {
+		return
+	}
+	labels := map[string]string{"quota": warning.Quota, "project_id": project.ID}
+	if warning.Quota == supabase.QuotaProjectsPerKey {
+		labels["key_id"] = project.CreatedBy
+	} else {
+		labels["organization_id"] = project.OrganizationID
+	}
+	err := h.notifier.Notify(notify.Notification{
+		Severity: notify.SeverityWarning,
+		Title:    "Soft quota reached: " + warning.Quota,
+		Message:  fmt.Sprintf("Creating project %s: %s", project.Name, warning.Message),
+		Labels:   labels,
+		Time:     time.Now().UTC(),
+	})
+	if err != nil {
+		fmt.Printf("Warning: Failed to send soft quota notification for %s: %v\n", project.ID, err)
+	}
+}
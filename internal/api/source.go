@@ -0,0 +1,73 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// resolveSchemaSource checks that a schema request has SQL inline or a
+// source URL, and fetches the SQL from the source. It writes an error
+// response and returns false if the SQL cannot be had. The source token is
+// cleared once used.
+func (h *Handler) resolveSchemaSource(c *gin.Context, req *supabase.ApplySchemaRequest) bool {
+	if (req.SQL == "") == (req.SourceURL == "") {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Exactly one of sql and source_url is required",
+			},
+		})
+		return false
+	}
+	if req.SourceURL == "" {
+		if h.maxSQLBytes > 0 && int64(len(req.SQL)) > h.maxSQLBytes {
+			c.JSON(http.StatusRequestEntityTooLarge, supabase.ErrorResponse{
+				Error: supabase.ErrorDetail{
+					Code:    "SQL_TOO_LARGE",
+					Message: "SQL exceeds the size limit",
+					Details: fmt.Sprintf("%d bytes, the limit is %d; use source_url for larger scripts", len(req.SQL), h.maxSQLBytes),
+				},
+			})
+			return false
+		}
+		return true
+	}
+
+	fetched, err := h.schemaFetcher.Fetch(c.Request.Context(), req.SourceURL, req.SourceToken, req.SHA256)
+	req.SourceToken = ""
+	if err != nil {
+		respondSourceError(c, "Failed to fetch SQL from source_url", err)
+		return false
+	}
+
+	req.SQL = fetched.SQL
+	req.SourceURL = fetched.Source
+	req.SHA256 = fetched.SHA256
+	return true
+}
+
+// respondSourceError writes the error response for a failed fetch from a
+// schema source
+func respondSourceError(c *gin.Context, message string, err error) {
+	status, code := http.StatusBadGateway, "SOURCE_FETCH_FAILED"
+	switch {
+	case errors.Is(err, supabase.ErrSourceNotAllowed):
+		status, code = http.StatusBadRequest, "SOURCE_NOT_ALLOWED"
+	case errors.Is(err, supabase.ErrSourceTooLarge):
+		status, code = http.StatusRequestEntityTooLarge, "SOURCE_TOO_LARGE"
+	case errors.Is(err, supabase.ErrSourceChecksumFailed):
+		status, code = http.StatusBadRequest, "SOURCE_CHECKSUM_MISMATCH"
+	}
+	c.JSON(status, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    code,
+			Message: message,
+			Details: err.Error(),
+		},
+	})
+}
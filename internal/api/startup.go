@@ -0,0 +1,63 @@
+package api
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Startup phases, in order
+const (
+	StartupRecovery        = "recovery"
+	StartupCredentialCheck = "credential_check"
+	StartupReady           = "ready"
+)
+
+// startupState tracks how far the instance is through startup
+type startupState struct {
+	mu      sync.RWMutex
+	phase   string
+	readyAt time.Time
+}
+
+// SetStartupPhase records the startup step in progress
+func (h *Handler) SetStartupPhase(phase string) {
+	h.startup.mu.Lock()
+	defer h.startup.mu.Unlock()
+
+	h.startup.phase = phase
+	if phase == StartupReady {
+		h.startup.readyAt = time.Now()
+	}
+}
+
+// StartupPhase returns the startup step in progress, StartupReady once
+// startup has finished
+func (h *Handler) StartupPhase() string {
+	h.startup.mu.RLock()
+	defer h.startup.mu.RUnlock()
+	return h.startup.phase
+}
+
+// Readiness handles GET /ready. It returns 503 until startup has finished,
+// so load balancers only route traffic to a fully initialized instance.
+func (h *Handler) Readiness(c *gin.Context) {
+	h.startup.mu.RLock()
+	phase, readyAt := h.startup.phase, h.startup.readyAt
+	h.startup.mu.RUnlock()
+
+	if phase != StartupReady {
+		c.JSON(http.StatusServiceUnavailable, gin.H{
+			"status": "starting",
+			"phase":  phase,
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"status":   "ready",
+		"ready_at": readyAt.Format(time.RFC3339),
+	})
+}
@@ -0,0 +1,123 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	defaultHistoryRange = 7 * 24 * time.Hour
+	maxHistoryRange     = 365 * 24 * time.Hour
+)
+
+// RecordStatsHistory stores a stats snapshot every interval and prunes
+// snapshots older than retention, until the context is cancelled. A zero
+// interval disables recording.
+func (h *Handler) RecordStatsHistory(ctx context.Context, interval, retention time.Duration) {
+	if interval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	last := time.Now()
+	var lastCompleted int64
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		snapshot, err := h.storage.TakeStatsSnapshot(last)
+		if err != nil {
+			fmt.Printf("Warning: Failed to take stats snapshot: %v\n", err)
+			continue
+		}
+
+		completed := h.completed.Load()
+		snapshot.BackgroundTasksCompleted = completed - lastCompleted
+
+		if err := h.storage.SaveStatsSnapshot(snapshot); err != nil {
+			fmt.Printf("Warning: Failed to save stats snapshot: %v\n", err)
+			continue
+		}
+		last = snapshot.RecordedAt
+		lastCompleted = completed
+
+		if err := h.storage.PruneStatsHistory(time.Now().Add(-retention)); err != nil {
+			fmt.Printf("Warning: Failed to prune stats history: %v\n", err)
+		}
+	}
+}
+
+// GetStatsHistory handles GET /api/stats/history?range=7d
+func (h *Handler) GetStatsHistory(c *gin.Context) {
+	rangeParam := c.DefaultQuery("range", "7d")
+	window, err := parseHistoryRange(rangeParam)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_RANGE",
+				Message: "Invalid range",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	snapshots, err := h.storage.ListStatsHistory(time.Now().Add(-window))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to get stats history",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"range":  rangeParam,
+		"points": snapshots,
+		"total":  len(snapshots),
+	})
+}
+
+// parseHistoryRange parses a range such as "7d", "12h" or "90m"
+func parseHistoryRange(value string) (time.Duration, error) {
+	if value == "" {
+		return defaultHistoryRange, nil
+	}
+
+	var window time.Duration
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil {
+			return 0, fmt.Errorf("range must look like 7d, 24h or 30m, got %q", value)
+		}
+		window = time.Duration(n) * 24 * time.Hour
+	} else {
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return 0, fmt.Errorf("range must look like 7d, 24h or 30m, got %q", value)
+		}
+		window = d
+	}
+
+	if window <= 0 || window > maxHistoryRange {
+		return 0, fmt.Errorf("range must be positive and at most 365d")
+	}
+	return window, nil
+}
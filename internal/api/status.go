@@ -0,0 +1,70 @@
+package api
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Public status levels, from best to worst
+const (
+	statusOperational = "operational"
+	statusDegraded    = "degraded"
+	statusOutage      = "outage"
+)
+
+// PublicStatus handles GET /status, the unauthenticated status for
+// dashboards. It is built from the cached dependency checks, so it causes
+// no upstream traffic, and only reports coarse states: no versions,
+// counts or error messages. It answers 503 during an outage.
+func (h *Handler) PublicStatus(c *gin.Context) {
+	dependencies := h.health.get()
+
+	components := gin.H{
+		"api":      statusOperational,
+		"database": statusOperational,
+		"supabase": statusOperational,
+	}
+	status := statusOperational
+	degraded := []string{}
+
+	if dependencies.Database == "error" {
+		components["database"] = statusOutage
+		status = statusOutage
+	}
+	if dependencies.SupabaseAPI == "error" {
+		components["supabase"] = statusOutage
+		degraded = append(degraded, "provisioning")
+	}
+
+	queues := h.QueueStats()
+	if queues.ProvisioningLimit > 0 && queues.Provisioning >= queues.ProvisioningLimit && dependencies.SupabaseAPI != "error" {
+		degraded = append(degraded, "provisioning")
+	}
+	if queues.MigrationQueueLimit > 0 && queues.MigrationQueue >= queues.MigrationQueueLimit {
+		degraded = append(degraded, "migrations")
+	}
+	if h.StartupPhase() != StartupReady {
+		degraded = append(degraded, "writes")
+	}
+	if status == statusOperational && len(degraded) > 0 {
+		status = statusDegraded
+	}
+
+	response := gin.H{
+		"status":            status,
+		"components":        components,
+		"degraded_features": degraded,
+	}
+	if !dependencies.CheckedAt.IsZero() {
+		response["checked_at"] = dependencies.CheckedAt.UTC().Format(time.RFC3339)
+	}
+
+	code := http.StatusOK
+	if status == statusOutage {
+		code = http.StatusServiceUnavailable
+	}
+	c.Header("Cache-Control", "public, max-age=10")
+	c.JSON(code, response)
+}
@@ -0,0 +1,77 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+// RecordTelemetry handles POST /api/telemetry, where provisioned apps
+// report using one of their project's keys. It is authenticated with that
+// key in the apikey header rather than an API key of the manager. Reports
+// are aggregated per project and key type, so projects still in use can be
+// told apart from idle ones before they are cleaned up.
+func (h *Handler) RecordTelemetry(c *gin.Context) {
+	var req supabase.KeyUsageReport
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	if err := req.Validate(time.Now()); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid usage report",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	// An unknown project and a wrong key are answered alike, so the
+	// endpoint can't be used to find out which projects exist
+	project, err := h.storage.GetProjectByRef(req.ProjectRef)
+	if err != nil || !project.MatchesKey(req.KeyType, c.GetHeader("apikey")) {
+		c.JSON(http.StatusUnauthorized, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "UNAUTHORIZED",
+				Message: "Invalid project key",
+				Details: fmt.Sprintf("send the project's %s key in the apikey header", req.KeyType),
+			},
+		})
+		return
+	}
+
+	if err := h.storage.RecordKeyUsage(project.ID, req.KeyType, *req.Timestamp); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to record key usage",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// lastKeyUse returns when a key of a project was last reported used, or nil
+func (h *Handler) lastKeyUse(projectID string) *time.Time {
+	usages, err := h.storage.GetKeyUsage(projectID)
+	if err != nil {
+		fmt.Printf("Warning: Failed to get key usage of %s: %v\n", projectID, err)
+		return nil
+	}
+	return supabase.LastKeyUse(usages)
+}
@@ -0,0 +1,281 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/supabase"
+)
+
+// CreateTenant handles POST /api/projects/:id/tenants
+func (h *Handler) CreateTenant(c *gin.Context) {
+	var req supabase.CreateTenantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	if !h.checkFreeze(c, supabase.FreezeMigrations, storedProject) {
+		return
+	}
+
+	var tenantSQL string
+	tenant, err := supabase.NewTenant(storedProject.ID, req.Name)
+	if err == nil {
+		tenantSQL, err = supabase.BuildTenantSQL(tenant, req.TemplateSQL)
+	}
+	if err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid tenant",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if _, err := h.storage.GetTenant(storedProject.ID, tenant.Name); err == nil {
+		c.JSON(http.StatusConflict, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "TENANT_EXISTS",
+				Message: "Tenant already exists",
+				Details: fmt.Sprintf("Project %s already has a tenant named %s", storedProject.ID, tenant.Name),
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "create_tenant", gin.H{
+			"project_id": storedProject.ID,
+			"name":       tenant.Name,
+			"schema":     tenant.Schema,
+			"role":       tenant.Role,
+			"sql":        tenantSQL,
+		})
+		return
+	}
+
+	record := &supabase.MigrationRecord{
+		ID:        uuid.New().String(),
+		TargetID:  storedProject.ID,
+		Version:   newMigrationVersion(),
+		Name:      fmt.Sprintf("create tenant %s", tenant.Name),
+		AppliedAt: time.Now().UTC(),
+		SQL:       tenantSQL,
+	}
+
+	runner, err := h.newMigrationRunner(storedProject.ToProject())
+	if err != nil {
+		record.Error = err.Error()
+		h.recordMigration(record)
+
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "TENANT_CREATION_FAILED",
+				Message: "Failed to connect to database",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	defer runner.Close()
+
+	result, err := runner.ApplyMigration(tenantSQL)
+	record.Success = result.Success
+	record.StatementsRun = result.StatementsRun
+	record.ExecutionTimeMs = result.ExecutionTime.Milliseconds()
+	record.Error = result.Error
+	h.recordMigration(record)
+
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "TENANT_CREATION_FAILED",
+				Message: "Failed to create tenant schema",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	tenant.ID = uuid.New().String()
+	tenant.CreatedAt = time.Now().UTC()
+	if err := h.storage.SaveTenant(tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Tenant schema was created but could not be recorded",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	h.setTenantMigrationRole(storedProject.ID, tenant.Role, true)
+
+	c.JSON(http.StatusCreated, gin.H{
+		"tenant":         tenant,
+		"migration_id":   record.ID,
+		"tables_created": result.TablesCreated,
+	})
+}
+
+// ListTenants handles GET /api/projects/:id/tenants
+func (h *Handler) ListTenants(c *gin.Context) {
+	projectID := c.Param("id")
+
+	if _, ok := h.loadProject(c, projectID); !ok {
+		return
+	}
+
+	tenants, err := h.storage.ListTenants(projectID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list tenants",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"tenants": tenants,
+		"total":   len(tenants),
+	})
+}
+
+// GetTenant handles GET /api/projects/:id/tenants/:tenant
+func (h *Handler) GetTenant(c *gin.Context) {
+	if _, ok := h.loadProject(c, c.Param("id")); !ok {
+		return
+	}
+
+	tenant, err := h.storage.GetTenant(c.Param("id"), c.Param("tenant"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "TENANT_NOT_FOUND",
+				Message: "Tenant not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, tenant)
+}
+
+// DeleteTenant handles DELETE /api/projects/:id/tenants/:tenant
+func (h *Handler) DeleteTenant(c *gin.Context) {
+	storedProject, ok := h.loadReadyProject(c, c.Param("id"))
+	if !ok {
+		return
+	}
+	// Dropping the tenant's schema deletes its data, so it is frozen like
+	// a project delete
+	if !h.checkFreeze(c, supabase.FreezeDeletes, storedProject) {
+		return
+	}
+
+	tenant, err := h.storage.GetTenant(storedProject.ID, c.Param("tenant"))
+	if err != nil {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "TENANT_NOT_FOUND",
+				Message: "Tenant not found",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "delete_tenant", gin.H{
+			"project_id": storedProject.ID,
+			"name":       tenant.Name,
+			"schema":     tenant.Schema,
+			"role":       tenant.Role,
+		})
+		return
+	}
+
+	runner, err := h.newMigrationRunner(storedProject.ToProject())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "TENANT_DELETION_FAILED",
+				Message: "Failed to connect to database",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	defer runner.Close()
+
+	if err := runner.DropTenant(tenant); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "TENANT_DELETION_FAILED",
+				Message: "Failed to drop tenant schema",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := h.storage.DeleteTenant(tenant.ID); err != nil {
+		fmt.Printf("Warning: Failed to delete tenant record %s: %v\n", tenant.ID, err)
+	}
+	h.setTenantMigrationRole(storedProject.ID, tenant.Role, false)
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Tenant deleted successfully",
+		"name":    tenant.Name,
+	})
+}
+
+// setTenantMigrationRole adds or removes a tenant role from the project's
+// migration roles, so tenant migrations can run as the tenant
+func (h *Handler) setTenantMigrationRole(projectID, role string, allow bool) {
+	roles, err := h.storage.GetMigrationRoles(projectID)
+	if err != nil {
+		fmt.Printf("Warning: Failed to load migration roles for %s: %v\n", projectID, err)
+		return
+	}
+
+	updated := []string{}
+	for _, r := range roles.Roles {
+		if r != role {
+			updated = append(updated, r)
+		}
+	}
+	if allow {
+		updated = append(updated, role)
+	} else if roles.DefaultRole == role {
+		roles.DefaultRole = ""
+	}
+	roles.Roles = updated
+	roles.UpdatedAt = time.Now().UTC()
+
+	if err := h.storage.SaveMigrationRoles(roles); err != nil {
+		fmt.Printf("Warning: Failed to update migration roles for %s: %v\n", projectID, err)
+	}
+}
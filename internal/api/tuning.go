@@ -0,0 +1,252 @@
+package api
+
+import (
+	"fmt"
+	"math"
+	"net/http"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+
+	"supabase-manager/internal/supabase"
+)
+
+const auditRuntimeTuned = "runtime.tuned"
+
+// RuntimeTuning is the current value of the settings that can be changed
+// at runtime through the admin API
+type RuntimeTuning struct {
+	// GOMAXPROCS is the number of threads running Go code at once. By
+	// default the runtime derives it from the CPU limit of the container
+	// and follows changes to it.
+	GOMAXPROCS int `json:"gomaxprocs"`
+	// GOMAXPROCSAuto is whether GOMAXPROCS follows the runtime default
+	GOMAXPROCSAuto bool `json:"gomaxprocs_auto"`
+	NumCPU         int  `json:"num_cpu"`
+	// GCPercent is GOGC; -1 turns the collector off until the memory limit
+	GCPercent int `json:"gc_percent"`
+	// MemoryLimitBytes is GOMEMLIMIT; math.MaxInt64 means no limit
+	MemoryLimitBytes int64 `json:"memory_limit_bytes"`
+
+	DBMaxConcurrent          int     `json:"db_max_concurrent"`
+	DBMaxConcurrentPerTarget int     `json:"db_max_concurrent_per_project"`
+	DBQueueTimeoutSeconds    float64 `json:"db_queue_timeout_seconds"`
+	MaxProvisioning          int64   `json:"max_provisioning"`
+	MaxMigrationQueue        int64   `json:"max_migration_queue"`
+}
+
+// RuntimeTuningUpdate changes runtime settings; unset fields are kept.
+// GOMAXPROCS 0 returns it to the runtime default.
+type RuntimeTuningUpdate struct {
+	GOMAXPROCS               *int     `json:"gomaxprocs,omitempty"`
+	GCPercent                *int     `json:"gc_percent,omitempty"`
+	MemoryLimitBytes         *int64   `json:"memory_limit_bytes,omitempty"`
+	DBMaxConcurrent          *int     `json:"db_max_concurrent,omitempty"`
+	DBMaxConcurrentPerTarget *int     `json:"db_max_concurrent_per_project,omitempty"`
+	DBQueueTimeoutSeconds    *float64 `json:"db_queue_timeout_seconds,omitempty"`
+	MaxProvisioning          *int64   `json:"max_provisioning,omitempty"`
+	MaxMigrationQueue        *int64   `json:"max_migration_queue,omitempty"`
+}
+
+var (
+	// tuningMu serializes changes to the runtime settings
+	tuningMu sync.Mutex
+	// gomaxprocsSet is whether GOMAXPROCS was set explicitly, by the
+	// environment or the admin API, instead of following the runtime default
+	gomaxprocsSet = os.Getenv("GOMAXPROCS") != ""
+	// gcPercent is the GC percent in effect, which the runtime only reports
+	// by changing it
+	gcPercent = envGCPercent()
+)
+
+// envGCPercent returns the GC percent set by GOGC, as the runtime reads it
+func envGCPercent() int {
+	value := os.Getenv("GOGC")
+	if value == "off" {
+		return -1
+	}
+	if n, err := strconv.Atoi(value); err == nil {
+		return n
+	}
+	return 100
+}
+
+// RuntimeTuning returns the current runtime settings
+func (h *Handler) RuntimeTuning() RuntimeTuning {
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+	return h.runtimeTuning()
+}
+
+// runtimeTuning reads the runtime settings. tuningMu must be held.
+func (h *Handler) runtimeTuning() RuntimeTuning {
+	maxGlobal, maxPerTarget, queueTimeout := h.dbPool.limits()
+	return RuntimeTuning{
+		GOMAXPROCS:               runtime.GOMAXPROCS(0),
+		GOMAXPROCSAuto:           !gomaxprocsSet,
+		NumCPU:                   runtime.NumCPU(),
+		GCPercent:                gcPercent,
+		MemoryLimitBytes:         debug.SetMemoryLimit(-1),
+		DBMaxConcurrent:          maxGlobal,
+		DBMaxConcurrentPerTarget: maxPerTarget,
+		DBQueueTimeoutSeconds:    queueTimeout.Seconds(),
+		MaxProvisioning:          h.backpressure.maxProvisioning.Load(),
+		MaxMigrationQueue:        h.backpressure.maxMigrationQueue.Load(),
+	}
+}
+
+// TuneRuntime applies an update to the runtime settings and returns the
+// settings before and after it
+func (h *Handler) TuneRuntime(update RuntimeTuningUpdate) (before, after RuntimeTuning, err error) {
+	if err := update.validate(); err != nil {
+		return RuntimeTuning{}, RuntimeTuning{}, err
+	}
+
+	tuningMu.Lock()
+	defer tuningMu.Unlock()
+
+	before = h.runtimeTuning()
+
+	if update.GOMAXPROCS != nil {
+		if *update.GOMAXPROCS == 0 {
+			runtime.SetDefaultGOMAXPROCS()
+			gomaxprocsSet = false
+		} else {
+			runtime.GOMAXPROCS(*update.GOMAXPROCS)
+			gomaxprocsSet = true
+		}
+	}
+	if update.GCPercent != nil {
+		debug.SetGCPercent(*update.GCPercent)
+		gcPercent = *update.GCPercent
+	}
+	if update.MemoryLimitBytes != nil {
+		debug.SetMemoryLimit(*update.MemoryLimitBytes)
+	}
+
+	maxGlobal, maxPerTarget, queueTimeout := h.dbPool.limits()
+	if update.DBMaxConcurrent != nil {
+		maxGlobal = *update.DBMaxConcurrent
+	}
+	if update.DBMaxConcurrentPerTarget != nil {
+		maxPerTarget = *update.DBMaxConcurrentPerTarget
+	}
+	if update.DBQueueTimeoutSeconds != nil {
+		queueTimeout = time.Duration(*update.DBQueueTimeoutSeconds * float64(time.Second))
+	}
+	h.dbPool.resize(maxGlobal, maxPerTarget, queueTimeout)
+
+	if update.MaxProvisioning != nil {
+		h.backpressure.maxProvisioning.Store(*update.MaxProvisioning)
+	}
+	if update.MaxMigrationQueue != nil {
+		h.backpressure.maxMigrationQueue.Store(*update.MaxMigrationQueue)
+	}
+
+	return before, h.runtimeTuning(), nil
+}
+
+// validate checks the values of an update
+func (u RuntimeTuningUpdate) validate() error {
+	switch {
+	case u.GOMAXPROCS != nil && *u.GOMAXPROCS < 0:
+		return fmt.Errorf("gomaxprocs must be 0 (runtime default) or more")
+	case u.GCPercent != nil && *u.GCPercent < -1:
+		return fmt.Errorf("gc_percent must be -1 (off) or more")
+	case u.MemoryLimitBytes != nil && *u.MemoryLimitBytes <= 0:
+		return fmt.Errorf("memory_limit_bytes must be positive, %d for no limit", int64(math.MaxInt64))
+	case u.DBMaxConcurrent != nil && *u.DBMaxConcurrent < 1:
+		return fmt.Errorf("db_max_concurrent must be at least 1")
+	case u.DBMaxConcurrentPerTarget != nil && *u.DBMaxConcurrentPerTarget < 1:
+		return fmt.Errorf("db_max_concurrent_per_project must be at least 1")
+	case u.DBQueueTimeoutSeconds != nil && *u.DBQueueTimeoutSeconds < 0:
+		return fmt.Errorf("db_queue_timeout_seconds must not be negative")
+	case u.MaxProvisioning != nil && *u.MaxProvisioning < 0:
+		return fmt.Errorf("max_provisioning must not be negative")
+	case u.MaxMigrationQueue != nil && *u.MaxMigrationQueue < 0:
+		return fmt.Errorf("max_migration_queue must not be negative")
+	}
+	return nil
+}
+
+// GetRuntimeTuning handles GET /api/admin/runtime
+func (h *Handler) GetRuntimeTuning(c *gin.Context) {
+	c.JSON(http.StatusOK, h.RuntimeTuning())
+}
+
+// UpdateRuntimeTuning handles PATCH /api/admin/runtime. Changes last until
+// the manager restarts; the environment sets the values it starts with.
+func (h *Handler) UpdateRuntimeTuning(c *gin.Context) {
+	var update RuntimeTuningUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		if err := update.validate(); err != nil {
+			respondInvalidTuning(c, err)
+			return
+		}
+		respondDryRun(c, "update_runtime", gin.H{
+			"current": h.RuntimeTuning(),
+			"update":  update,
+		})
+		return
+	}
+
+	before, after, err := h.TuneRuntime(update)
+	if err != nil {
+		respondInvalidTuning(c, err)
+		return
+	}
+	if changes := tuningChanges(before, after); changes != "" {
+		h.audit(c, auditRuntimeTuned, "", changes)
+	}
+
+	c.JSON(http.StatusOK, after)
+}
+
+// respondInvalidTuning writes the error response for an invalid update
+func respondInvalidTuning(c *gin.Context, err error) {
+	c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+		Error: supabase.ErrorDetail{
+			Code:    "INVALID_REQUEST",
+			Message: "Invalid runtime settings",
+			Details: err.Error(),
+		},
+	})
+}
+
+// tuningChanges describes the settings that differ, such as
+// "db_max_concurrent 10 -> 20"
+func tuningChanges(before, after RuntimeTuning) string {
+	var changes []string
+	add := func(name string, old, new interface{}) {
+		if old != new {
+			changes = append(changes, fmt.Sprintf("%s %v -> %v", name, old, new))
+		}
+	}
+	add("gomaxprocs", before.GOMAXPROCS, after.GOMAXPROCS)
+	add("gomaxprocs_auto", before.GOMAXPROCSAuto, after.GOMAXPROCSAuto)
+	add("gc_percent", before.GCPercent, after.GCPercent)
+	add("memory_limit_bytes", before.MemoryLimitBytes, after.MemoryLimitBytes)
+	add("db_max_concurrent", before.DBMaxConcurrent, after.DBMaxConcurrent)
+	add("db_max_concurrent_per_project", before.DBMaxConcurrentPerTarget, after.DBMaxConcurrentPerTarget)
+	add("db_queue_timeout_seconds", before.DBQueueTimeoutSeconds, after.DBQueueTimeoutSeconds)
+	add("max_provisioning", before.MaxProvisioning, after.MaxProvisioning)
+	add("max_migration_queue", before.MaxMigrationQueue, after.MaxMigrationQueue)
+	return strings.Join(changes, ", ")
+}
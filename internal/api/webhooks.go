@@ -0,0 +1,324 @@
+package api
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/auth"
+	"supabase-manager/internal/supabase"
+)
+
+const (
+	auditWebhookCreated = "webhook.created"
+	auditWebhookDeleted = "webhook.deleted"
+)
+
+// Webhook deliveries are attempted webhookDeliveryAttempts times, waiting
+// webhookRetryInitialDelay before the first retry and doubling after that
+const (
+	webhookDeliveryAttempts  = 4
+	webhookRetryInitialDelay = 10 * time.Second
+	webhookDeliveryTimeout   = 10 * time.Second
+)
+
+// newWebhookHTTPClient returns the client deliveries are posted with. It
+// does not follow redirects, so a delivery cannot be bounced to a host
+// outside WEBHOOK_ALLOWED_HOSTS.
+func newWebhookHTTPClient() *http.Client {
+	return &http.Client{
+		Timeout: webhookDeliveryTimeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// CreateWebhook handles POST /api/webhooks. The signing secret is only
+// returned here.
+func (h *Handler) CreateWebhook(c *gin.Context) {
+	var req supabase.CreateWebhookRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid request body",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	if err := req.Validate(h.webhookHosts); err != nil {
+		c.JSON(http.StatusBadRequest, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INVALID_REQUEST",
+				Message: "Invalid webhook",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	key := auth.FromContext(c)
+	if req.Global && key != nil && !key.HasScope("admin") {
+		c.JSON(http.StatusForbidden, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "FORBIDDEN",
+				Message: "Global webhooks require the admin scope",
+			},
+		})
+		return
+	}
+
+	webhook := &supabase.Webhook{
+		ID:          uuid.New().String(),
+		URL:         req.URL,
+		Events:      req.Events,
+		Description: req.Description,
+		CreatedBy:   callerID(c),
+		CreatedAt:   time.Now().UTC(),
+	}
+	if webhook.Events == nil {
+		webhook.Events = []string{}
+	}
+	if !req.Global {
+		webhook.KeyID = callerID(c)
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "create_webhook", gin.H{"webhook": webhook})
+		return
+	}
+
+	secret, err := supabase.NewWebhookSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create webhook",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	webhook.Secret = secret
+
+	if err := h.storage.SaveWebhook(webhook); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to create webhook",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	h.audit(c, auditWebhookCreated, "", fmt.Sprintf("%s %s", webhook.ID, webhook.URL))
+
+	c.JSON(http.StatusCreated, gin.H{
+		"webhook": webhook,
+		"secret":  secret,
+	})
+}
+
+// ListWebhooks handles GET /api/webhooks: the caller's webhooks, or all of
+// them for keys with the admin scope
+func (h *Handler) ListWebhooks(c *gin.Context) {
+	webhooks, err := h.storage.ListWebhooks()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to list webhooks",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+
+	visible := []*supabase.Webhook{}
+	for _, webhook := range webhooks {
+		if canManageWebhook(c, webhook) {
+			visible = append(visible, webhook)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"webhooks": visible,
+		"total":    len(visible),
+	})
+}
+
+// DeleteWebhook handles DELETE /api/webhooks/:id
+func (h *Handler) DeleteWebhook(c *gin.Context) {
+	webhook, err := h.storage.GetWebhook(c.Param("id"))
+	if err != nil || !canManageWebhook(c, webhook) {
+		c.JSON(http.StatusNotFound, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "WEBHOOK_NOT_FOUND",
+				Message: "Webhook not found",
+			},
+		})
+		return
+	}
+
+	if isDryRun(c) {
+		respondDryRun(c, "delete_webhook", gin.H{"webhook": webhook})
+		return
+	}
+
+	if err := h.storage.DeleteWebhook(webhook.ID); err != nil {
+		c.JSON(http.StatusInternalServerError, supabase.ErrorResponse{
+			Error: supabase.ErrorDetail{
+				Code:    "INTERNAL_ERROR",
+				Message: "Failed to delete webhook",
+				Details: err.Error(),
+			},
+		})
+		return
+	}
+	h.audit(c, auditWebhookDeleted, "", fmt.Sprintf("%s %s", webhook.ID, webhook.URL))
+
+	c.JSON(http.StatusOK, gin.H{
+		"message": "Webhook deleted successfully",
+		"id":      webhook.ID,
+	})
+}
+
+// canManageWebhook reports whether the caller owns a webhook; keys with the
+// admin scope manage all webhooks, including global ones
+func canManageWebhook(c *gin.Context, webhook *supabase.Webhook) bool {
+	key := auth.FromContext(c)
+	return key == nil || key.HasScope("admin") || webhook.KeyID == key.ID
+}
+
+// notifyProvisioned tells webhooks that provisioning of a project finished,
+// successfully when err is nil
+func (h *Handler) notifyProvisioned(projectID string, err error) {
+	project, getErr := h.storage.GetProject(projectID)
+	if getErr != nil {
+		fmt.Printf("Warning: Failed to load project %s for webhooks: %v\n", projectID, getErr)
+		return
+	}
+
+	payload := supabase.WebhookPayload{Type: supabase.WebhookEventProjectReady}
+	if err != nil {
+		payload.Type = supabase.WebhookEventProjectFailed
+		payload.Error = err.Error()
+	} else if steps, err := h.storage.ListSetupSteps(projectID); err == nil {
+		payload.SetupStatus = supabase.SetupStatus(steps)
+	}
+	h.notifyWebhooks(project, payload)
+}
+
+// notifyWebhooks delivers a lifecycle event of a project, in the
+// background, to every webhook subscribed to it that reaches the project
+func (h *Handler) notifyWebhooks(project *supabase.StoredProject, payload supabase.WebhookPayload) {
+	webhooks, err := h.storage.ListWebhooks()
+	if err != nil {
+		fmt.Printf("Warning: Failed to list webhooks for %s of %s: %v\n", payload.Type, project.ID, err)
+		return
+	}
+
+	payload.ID = uuid.New().String()
+	payload.Timestamp = time.Now().UTC()
+	payload.Project = supabase.NewWebhookProject(project)
+	body, err := json.Marshal(payload)
+	if err != nil {
+		fmt.Printf("Warning: Failed to encode %s of %s: %v\n", payload.Type, project.ID, err)
+		return
+	}
+
+	for _, webhook := range webhooks {
+		if !webhook.Subscribes(payload.Type) || !h.webhookReaches(webhook, project) {
+			continue
+		}
+		webhook := webhook
+		h.runBackground(func() { h.deliverWebhook(webhook, payload.ID, payload.Type, body) })
+	}
+}
+
+// webhookReaches reports whether a webhook hears about a project: global
+// webhooks hear about all of them, others about those in the organizations
+// their key may work in. Webhooks of keys that were removed hear nothing.
+func (h *Handler) webhookReaches(webhook *supabase.Webhook, project *supabase.StoredProject) bool {
+	if webhook.KeyID == "" {
+		return true
+	}
+	if h.keyring == nil {
+		return false
+	}
+	key, ok := h.keyring.Get(webhook.KeyID)
+	return ok && key.AllowsOrganization(project.OrganizationID)
+}
+
+// deliverWebhook posts a signed payload, retrying with exponential backoff
+// until it is accepted with a 2xx, the attempts run out or the handler
+// shuts down, and records the outcome on the webhook
+func (h *Handler) deliverWebhook(webhook *supabase.Webhook, deliveryID, event string, body []byte) {
+	delay := webhookRetryInitialDelay
+	var status int
+	var deliveryErr error
+
+	for attempt := 1; attempt <= webhookDeliveryAttempts; attempt++ {
+		if attempt > 1 {
+			if !h.sleepOrStop(delay) {
+				break
+			}
+			delay *= 2
+		}
+
+		status, deliveryErr = h.postWebhook(webhook, deliveryID, event, body)
+		if deliveryErr == nil {
+			break
+		}
+		fmt.Printf("Webhook %s delivery of %s failed (attempt %d/%d): %v\n", webhook.ID, event, attempt, webhookDeliveryAttempts, deliveryErr)
+	}
+
+	errText := ""
+	if deliveryErr != nil {
+		errText = deliveryErr.Error()
+	}
+	if err := h.storage.RecordWebhookDelivery(webhook.ID, time.Now(), status, errText); err != nil {
+		fmt.Printf("Warning: %v\n", err)
+	}
+}
+
+// postWebhook makes one delivery attempt, returning the response status
+func (h *Handler) postWebhook(webhook *supabase.Webhook, deliveryID, event string, body []byte) (int, error) {
+	now := time.Now()
+	signature, err := supabase.SignWebhook(deliveryID, now, body, webhook.Secret)
+	if err != nil {
+		return 0, err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, webhook.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("webhook-id", deliveryID)
+	req.Header.Set("webhook-timestamp", strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set("webhook-signature", signature)
+	req.Header.Set("X-Webhook-Event", event)
+
+	resp, err := h.webhookClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to send: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return resp.StatusCode, fmt.Errorf("status %d: %s", resp.StatusCode, string(bodyBytes))
+	}
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,231 @@
+package auth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// ContextKey is the gin context key holding the authenticated *Key
+const ContextKey = "api_key"
+
+// rateWindow is the length of a rate limiting window
+const rateWindow = time.Minute
+
+// Key describes an API key accepted by the server. The secret itself is never
+// exposed; callers are identified by ID.
+type Key struct {
+	ID        string   `json:"id"`
+	Name      string   `json:"name"`
+	Scopes    []string `json:"scopes"`
+	RateLimit int      `json:"rate_limit_per_minute"`
+	// Organizations are the Supabase organizations the key may work in;
+	// empty allows all
+	Organizations []string `json:"organizations,omitempty"`
+
+	secret string
+}
+
+// HasScope reports whether the key grants a scope, "*" granting all scopes
+func (k *Key) HasScope(scope string) bool {
+	for _, s := range k.Scopes {
+		if s == "*" || s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsOrganization reports whether the key may work in a Supabase
+// organization
+func (k *Key) AllowsOrganization(organizationID string) bool {
+	if len(k.Organizations) == 0 {
+		return true
+	}
+	for _, org := range k.Organizations {
+		if org == organizationID {
+			return true
+		}
+	}
+	return false
+}
+
+// Usage is a snapshot of a key's request counters
+type Usage struct {
+	RequestsThisWindow int       `json:"requests_this_window"`
+	Remaining          int       `json:"remaining"`
+	WindowResetsAt     time.Time `json:"window_resets_at"`
+	TotalRequests      int64     `json:"total_requests"`
+	LastSeenAt         time.Time `json:"last_seen_at,omitempty"`
+}
+
+// usageCounter tracks a fixed rate limiting window for one key
+type usageCounter struct {
+	windowStart time.Time
+	count       int
+	total       int64
+	lastSeen    time.Time
+}
+
+// Keyring holds the configured API keys and their usage
+type Keyring struct {
+	defaultRateLimit int
+
+	mu    sync.Mutex
+	keys  []*Key
+	usage map[string]*usageCounter
+}
+
+// NewKeyring creates an empty keyring; defaultRateLimit is the per-minute
+// request allowance of keys added without an explicit limit (0 = unlimited)
+func NewKeyring(defaultRateLimit int) *Keyring {
+	return &Keyring{
+		defaultRateLimit: defaultRateLimit,
+		usage:            make(map[string]*usageCounter),
+	}
+}
+
+// Add registers an API key
+func (kr *Keyring) Add(name, secret string, scopes []string) *Key {
+	sum := sha256.Sum256([]byte(secret))
+	key := &Key{
+		ID:        "key_" + hex.EncodeToString(sum[:])[:12],
+		Name:      name,
+		Scopes:    scopes,
+		RateLimit: kr.defaultRateLimit,
+		secret:    secret,
+	}
+
+	kr.mu.Lock()
+	kr.keys = append(kr.keys, key)
+	kr.mu.Unlock()
+
+	return key
+}
+
+// ParseKeys adds keys from a "name:secret:scope|scope,name2:secret2:scope"
+// specification. A fourth part binds a key to organizations, as in
+// "name:secret:*:org1|org2".
+func (kr *Keyring) ParseKeys(spec string) error {
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 4)
+		if len(parts) < 2 || parts[0] == "" || parts[1] == "" {
+			return fmt.Errorf("invalid API key entry %q, expected name:secret[:scopes[:organizations]]", entry)
+		}
+
+		scopes := []string{"*"}
+		if len(parts) >= 3 && parts[2] != "" {
+			scopes = strings.Split(parts[2], "|")
+		}
+
+		key := kr.Add(parts[0], parts[1], scopes)
+		if len(parts) == 4 && parts[3] != "" {
+			key.Organizations = strings.Split(parts[3], "|")
+		}
+	}
+	return nil
+}
+
+// Lookup finds the key matching a presented secret
+func (kr *Keyring) Lookup(secret string) (*Key, bool) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	for _, key := range kr.keys {
+		if subtle.ConstantTimeCompare([]byte(key.secret), []byte(secret)) == 1 {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Get finds a key by ID
+func (kr *Keyring) Get(id string) (*Key, bool) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	for _, key := range kr.keys {
+		if key.ID == id {
+			return key, true
+		}
+	}
+	return nil, false
+}
+
+// Allow counts a request against the key's rate limit and reports whether it
+// may proceed, along with the resulting usage
+func (kr *Keyring) Allow(key *Key) (Usage, bool) {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	now := time.Now()
+	counter := kr.counter(key.ID, now)
+
+	counter.total++
+	counter.lastSeen = now
+
+	allowed := key.RateLimit <= 0 || counter.count < key.RateLimit
+	if allowed {
+		counter.count++
+	}
+
+	return kr.snapshot(key, counter), allowed
+}
+
+// Usage returns the current usage of a key without counting a request
+func (kr *Keyring) Usage(key *Key) Usage {
+	kr.mu.Lock()
+	defer kr.mu.Unlock()
+
+	return kr.snapshot(key, kr.counter(key.ID, time.Now()))
+}
+
+// counter returns the usage counter of a key, rolling the window if expired
+func (kr *Keyring) counter(keyID string, now time.Time) *usageCounter {
+	counter, ok := kr.usage[keyID]
+	if !ok {
+		counter = &usageCounter{windowStart: now}
+		kr.usage[keyID] = counter
+	}
+	if now.Sub(counter.windowStart) >= rateWindow {
+		counter.windowStart = now
+		counter.count = 0
+	}
+	return counter
+}
+
+// snapshot converts a counter into a Usage value
+func (kr *Keyring) snapshot(key *Key, counter *usageCounter) Usage {
+	usage := Usage{
+		RequestsThisWindow: counter.count,
+		Remaining:          -1,
+		WindowResetsAt:     counter.windowStart.Add(rateWindow),
+		TotalRequests:      counter.total,
+		LastSeenAt:         counter.lastSeen,
+	}
+	if key.RateLimit > 0 {
+		usage.Remaining = key.RateLimit - counter.count
+	}
+	return usage
+}
+
+// FromContext returns the authenticated key of a request, if any
+func FromContext(c *gin.Context) *Key {
+	value, ok := c.Get(ContextKey)
+	if !ok {
+		return nil
+	}
+	key, _ := value.(*Key)
+	return key
+}
@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"sync"
+	"time"
+)
+
+// Lockout bans client IPs that present too many invalid API keys. Each ban
+// of an IP lasts twice as long as its previous one, up to a maximum; an IP
+// that stays out of trouble for that maximum after a ban starts over.
+// Failures only expire with their window: valid keys don't clear them, so
+// an attacker holding one key can't interleave it to keep guessing.
+type Lockout struct {
+	maxFailures int
+	window      time.Duration
+	baseBan     time.Duration
+	maxBan      time.Duration
+
+	mu        sync.Mutex
+	clients   map[string]*lockoutState
+	lastSweep time.Time
+}
+
+// lockoutState tracks the failures and bans of one client IP
+type lockoutState struct {
+	windowStart time.Time
+	failures    int
+	bans        int
+	bannedUntil time.Time
+}
+
+// Ban describes a ban imposed on a client IP
+type Ban struct {
+	IP string
+	// Failures is the number of invalid keys that led to the ban
+	Failures int
+	// Offense counts the IP's bans, this one included
+	Offense  int
+	Duration time.Duration
+	Until    time.Time
+}
+
+// NewLockout bans an IP for baseBan once it presents maxFailures invalid
+// keys within window; maxFailures 0 disables banning. Repeated bans double
+// up to maxBan.
+func NewLockout(maxFailures int, window, baseBan, maxBan time.Duration) *Lockout {
+	return &Lockout{
+		maxFailures: maxFailures,
+		window:      window,
+		baseBan:     baseBan,
+		maxBan:      max(maxBan, baseBan),
+		clients:     make(map[string]*lockoutState),
+	}
+}
+
+// Banned reports whether ip is banned at now and until when
+func (l *Lockout) Banned(ip string, now time.Time) (time.Time, bool) {
+	if l == nil || l.maxFailures <= 0 {
+		return time.Time{}, false
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	state, ok := l.clients[ip]
+	if !ok || !now.Before(state.bannedUntil) {
+		return time.Time{}, false
+	}
+	return state.bannedUntil, true
+}
+
+// Fail records an invalid key presented by ip at now. It returns the ban
+// the failure triggered, or nil if it didn't trigger one.
+func (l *Lockout) Fail(ip string, now time.Time) *Ban {
+	if l == nil || l.maxFailures <= 0 {
+		return nil
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	state, ok := l.clients[ip]
+	if !ok {
+		state = &lockoutState{windowStart: now}
+		l.clients[ip] = state
+	}
+	if now.Sub(state.windowStart) >= l.window {
+		state.windowStart = now
+		state.failures = 0
+	}
+	state.failures++
+	if state.failures < l.maxFailures {
+		return nil
+	}
+
+	// Bans escalate only while the IP keeps offending
+	if state.bans > 0 && now.Sub(state.bannedUntil) >= l.maxBan {
+		state.bans = 0
+	}
+	state.bans++
+	duration := l.maxBan
+	if shift := state.bans - 1; shift < 32 {
+		duration = min(l.baseBan<<shift, l.maxBan)
+	}
+
+	ban := &Ban{
+		IP:       ip,
+		Failures: state.failures,
+		Offense:  state.bans,
+		Duration: duration,
+		Until:    now.Add(duration),
+	}
+	state.bannedUntil = ban.Until
+	state.windowStart = now
+	state.failures = 0
+	return ban
+}
+
+// sweep drops IPs with no recent failures whose ban history has lapsed, at
+// most once per window. The caller holds mu.
+func (l *Lockout) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < l.window {
+		return
+	}
+	l.lastSweep = now
+
+	for ip, state := range l.clients {
+		if now.Sub(state.windowStart) >= l.window && now.Sub(state.bannedUntil) >= l.maxBan {
+			delete(l.clients, ip)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package auth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLockoutBansAfterMaxFailures(t *testing.T) {
+	l := NewLockout(3, time.Minute, 10*time.Minute, time.Hour)
+	now := time.Unix(1700000000, 0)
+
+	for i := 0; i < 2; i++ {
+		if ban := l.Fail("10.0.0.1", now); ban != nil {
+			t.Fatalf("failure %d banned the IP", i+1)
+		}
+	}
+	ban := l.Fail("10.0.0.1", now)
+	if ban == nil || ban.Duration != 10*time.Minute || ban.Failures != 3 || ban.Offense != 1 {
+		t.Fatalf("third failure: got ban %+v", ban)
+	}
+
+	if until, banned := l.Banned("10.0.0.1", now.Add(time.Minute)); !banned || !until.Equal(ban.Until) {
+		t.Errorf("IP not banned during its ban")
+	}
+	if _, banned := l.Banned("10.0.0.2", now); banned {
+		t.Errorf("another IP was banned")
+	}
+	if _, banned := l.Banned("10.0.0.1", ban.Until); banned {
+		t.Errorf("IP still banned after the ban ended")
+	}
+}
+
+func TestLockoutFailuresExpireWithWindow(t *testing.T) {
+	l := NewLockout(3, time.Minute, 10*time.Minute, time.Hour)
+	now := time.Unix(1700000000, 0)
+
+	l.Fail("10.0.0.1", now)
+	l.Fail("10.0.0.1", now)
+	if ban := l.Fail("10.0.0.1", now.Add(time.Minute)); ban != nil {
+		t.Errorf("failures from an earlier window counted: %+v", ban)
+	}
+}
+
+func TestLockoutEscalates(t *testing.T) {
+	l := NewLockout(1, time.Minute, 10*time.Minute, 30*time.Minute)
+	now := time.Unix(1700000000, 0)
+
+	var durations []time.Duration
+	for i := 0; i < 3; i++ {
+		ban := l.Fail("10.0.0.1", now)
+		durations = append(durations, ban.Duration)
+		now = ban.Until
+	}
+	if durations[0] != 10*time.Minute || durations[1] != 20*time.Minute || durations[2] != 30*time.Minute {
+		t.Errorf("ban durations %v, want doubling up to the maximum", durations)
+	}
+
+	// An IP that stays quiet for the maximum ban starts over
+	if ban := l.Fail("10.0.0.1", now.Add(30*time.Minute)); ban.Duration != 10*time.Minute || ban.Offense != 1 {
+		t.Errorf("ban after a quiet period: %+v", ban)
+	}
+}
+
+func TestLockoutDisabled(t *testing.T) {
+	l := NewLockout(0, time.Minute, 10*time.Minute, time.Hour)
+	now := time.Now()
+
+	for i := 0; i < 10; i++ {
+		if ban := l.Fail("10.0.0.1", now); ban != nil {
+			t.Fatalf("disabled lockout banned: %+v", ban)
+		}
+	}
+	if _, banned := l.Banned("10.0.0.1", now); banned {
+		t.Errorf("disabled lockout reports a ban")
+	}
+}
@@ -0,0 +1,352 @@
+// Package jsonschema validates JSON documents against the JSON Schemas the
+// API publishes for its request bodies. It implements the subset of JSON
+// Schema (draft 2020-12) those schemas use: type, enum, properties,
+// required, additionalProperties, propertyNames, maxProperties, items,
+// minLength, maxLength, pattern, minimum and maximum. Annotations such as title, description and examples are
+// ignored; any other keyword is an error in the schema itself.
+package jsonschema
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+)
+
+// Draft is the JSON Schema dialect of the published schemas
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema as decoded from JSON
+type Schema = map[string]interface{}
+
+// Violation is a place where a document does not match its schema
+type Violation struct {
+	// Path is a JSON Pointer to the offending value, "" for the document
+	Path    string `json:"path"`
+	Message string `json:"message"`
+}
+
+// annotations are keywords that do not constrain a document
+var annotations = map[string]bool{
+	"$schema": true, "$id": true, "title": true, "description": true,
+	"examples": true, "default": true, "version": true,
+}
+
+// keywords are the constraints this package implements
+var keywords = map[string]bool{
+	"type": true, "enum": true, "properties": true, "required": true,
+	"additionalProperties": true, "propertyNames": true, "maxProperties": true,
+	"items": true, "minLength": true, "maxLength": true, "pattern": true,
+	"minimum": true, "maximum": true,
+}
+
+// patterns caches compiled pattern keywords, which Compile has checked
+var patterns sync.Map
+
+// Compile prepares a schema built in Go, or decoded from JSON, for
+// validation. It is normalized through JSON so it holds the same types as
+// a decoded document, and checked for keywords this package does not
+// implement.
+func Compile(schema interface{}) (Schema, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode schema: %w", err)
+	}
+	var compiled Schema
+	if err := json.Unmarshal(data, &compiled); err != nil {
+		return nil, fmt.Errorf("schema must be an object: %w", err)
+	}
+	if err := check(compiled, ""); err != nil {
+		return nil, err
+	}
+	return compiled, nil
+}
+
+// MustCompile is like Compile but panics on an invalid schema
+func MustCompile(schema interface{}) Schema {
+	compiled, err := Compile(schema)
+	if err != nil {
+		panic(fmt.Sprintf("jsonschema: %v", err))
+	}
+	return compiled
+}
+
+// check rejects unsupported keywords and invalid patterns in a schema and
+// its subschemas
+func check(schema Schema, path string) error {
+	for keyword, value := range schema {
+		if !annotations[keyword] && !keywords[keyword] {
+			return fmt.Errorf("unsupported keyword %q at %q", keyword, path)
+		}
+		switch keyword {
+		case "pattern":
+			if _, err := regexp.Compile(value.(string)); err != nil {
+				return fmt.Errorf("invalid pattern at %q: %w", path, err)
+			}
+		case "properties":
+			for name, property := range value.(Schema) {
+				if err := check(property.(Schema), path+"/properties/"+escapePointer(name)); err != nil {
+					return err
+				}
+			}
+		case "items", "propertyNames", "additionalProperties":
+			if sub, ok := value.(Schema); ok {
+				if err := check(sub, path+"/"+keyword); err != nil {
+					return err
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// Validate checks a document decoded with encoding/json against a
+// compiled schema and returns every violation, ordered by path
+func Validate(schema Schema, document interface{}) []Violation {
+	var violations []Violation
+	validate(schema, document, "", &violations)
+	sort.SliceStable(violations, func(i, j int) bool { return violations[i].Path < violations[j].Path })
+	return violations
+}
+
+// ValidateJSON decodes a document and checks it against a compiled schema.
+// It returns an error if the document is not valid JSON.
+func ValidateJSON(schema Schema, data []byte) ([]Violation, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return nil, err
+	}
+	return Validate(schema, document), nil
+}
+
+// validate checks value at path, appending violations
+func validate(schema Schema, value interface{}, path string, violations *[]Violation) {
+	fail := func(format string, args ...interface{}) {
+		*violations = append(*violations, Violation{Path: path, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if expected, ok := schema["type"]; ok && !matchesType(expected, value) {
+		fail("must be %s, got %s", describeType(expected), typeOf(value))
+		return
+	}
+
+	if enum, ok := schema["enum"].([]interface{}); ok && !containsValue(enum, value) {
+		fail("must be one of %s", formatValues(enum))
+	}
+
+	switch v := value.(type) {
+	case string:
+		validateString(schema, v, fail)
+	case float64:
+		validateNumber(schema, v, fail)
+	case []interface{}:
+		validateArray(schema, v, path, violations)
+	case map[string]interface{}:
+		validateObject(schema, v, path, violations, fail)
+	}
+}
+
+// validateString checks the string keywords
+func validateString(schema Schema, value string, fail func(string, ...interface{})) {
+	length := utf8.RuneCountInString(value)
+	if min, ok := number(schema["minLength"]); ok && float64(length) < min {
+		if min == 1 {
+			fail("must not be empty")
+		} else {
+			fail("must be at least %s characters", formatNumber(min))
+		}
+	}
+	if max, ok := number(schema["maxLength"]); ok && float64(length) > max {
+		fail("must be at most %s characters", formatNumber(max))
+	}
+	if pattern, ok := schema["pattern"].(string); ok && !compile(pattern).MatchString(value) {
+		fail("must match %s", pattern)
+	}
+}
+
+// validateNumber checks the numeric keywords
+func validateNumber(schema Schema, value float64, fail func(string, ...interface{})) {
+	if min, ok := number(schema["minimum"]); ok && value < min {
+		fail("must be at least %s", formatNumber(min))
+	}
+	if max, ok := number(schema["maximum"]); ok && value > max {
+		fail("must be at most %s", formatNumber(max))
+	}
+}
+
+// validateArray checks the items of an array
+func validateArray(schema Schema, value []interface{}, path string, violations *[]Violation) {
+	if items, ok := schema["items"].(Schema); ok {
+		for i, item := range value {
+			validate(items, item, path+"/"+strconv.Itoa(i), violations)
+		}
+	}
+}
+
+// validateObject checks the object keywords and the properties
+func validateObject(schema Schema, value map[string]interface{}, path string, violations *[]Violation, fail func(string, ...interface{})) {
+	if max, ok := number(schema["maxProperties"]); ok && float64(len(value)) > max {
+		fail("must have at most %s properties", formatNumber(max))
+	}
+
+	if required, ok := schema["required"].([]interface{}); ok {
+		for _, name := range required {
+			if _, present := value[name.(string)]; !present {
+				*violations = append(*violations, Violation{
+					Path:    path + "/" + escapePointer(name.(string)),
+					Message: "is required",
+				})
+			}
+		}
+	}
+
+	properties, _ := schema["properties"].(Schema)
+	names := make([]string, 0, len(value))
+	for name := range value {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		child := path + "/" + escapePointer(name)
+		if names, ok := schema["propertyNames"].(Schema); ok {
+			var nameViolations []Violation
+			validate(names, name, child, &nameViolations)
+			for _, v := range nameViolations {
+				*violations = append(*violations, Violation{Path: v.Path, Message: "name " + v.Message})
+			}
+		}
+
+		if property, ok := properties[name].(Schema); ok {
+			validate(property, value[name], child, violations)
+			continue
+		}
+		switch additional := schema["additionalProperties"].(type) {
+		case bool:
+			if !additional {
+				*violations = append(*violations, Violation{Path: child, Message: "is not a known property"})
+			}
+		case Schema:
+			validate(additional, value[name], child, violations)
+		}
+	}
+}
+
+// matchesType reports whether value has the type, or one of the types
+func matchesType(expected interface{}, value interface{}) bool {
+	switch expected := expected.(type) {
+	case string:
+		return hasType(expected, value)
+	case []interface{}:
+		for _, t := range expected {
+			if hasType(t.(string), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasType reports whether value is of a JSON Schema type
+func hasType(expected string, value interface{}) bool {
+	actual := typeOf(value)
+	if expected == "number" && actual == "integer" {
+		return true
+	}
+	return expected == actual
+}
+
+// typeOf returns the JSON Schema type of a decoded value
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case string:
+		return "string"
+	case float64:
+		if v == math.Trunc(v) {
+			return "integer"
+		}
+		return "number"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}
+
+// describeType names the expected type or types
+func describeType(expected interface{}) string {
+	article := func(t string) string {
+		if strings.IndexAny(t[:1], "aeiou") == 0 {
+			return "an " + t
+		}
+		return "a " + t
+	}
+	switch expected := expected.(type) {
+	case string:
+		return article(expected)
+	case []interface{}:
+		var names []string
+		for _, t := range expected {
+			names = append(names, article(t.(string)))
+		}
+		return strings.Join(names, " or ")
+	}
+	return fmt.Sprint(expected)
+}
+
+// containsValue reports whether value is one of the values
+func containsValue(values []interface{}, value interface{}) bool {
+	for _, v := range values {
+		if reflect.DeepEqual(v, value) {
+			return true
+		}
+	}
+	return false
+}
+
+// formatValues lists enum values as JSON
+func formatValues(values []interface{}) string {
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		encoded, _ := json.Marshal(v)
+		formatted[i] = string(encoded)
+	}
+	return strings.Join(formatted, ", ")
+}
+
+// number reads a numeric keyword
+func number(value interface{}) (float64, bool) {
+	n, ok := value.(float64)
+	return n, ok
+}
+
+// formatNumber prints a keyword bound without a trailing .0
+func formatNumber(n float64) string {
+	return strconv.FormatFloat(n, 'f', -1, 64)
+}
+
+// compile returns the compiled form of a pattern keyword
+func compile(pattern string) *regexp.Regexp {
+	if re, ok := patterns.Load(pattern); ok {
+		return re.(*regexp.Regexp)
+	}
+	re := regexp.MustCompile(pattern)
+	patterns.Store(pattern, re)
+	return re
+}
+
+// escapePointer escapes a property name for a JSON Pointer
+func escapePointer(name string) string {
+	return strings.ReplaceAll(strings.ReplaceAll(name, "~", "~0"), "/", "~1")
+}
@@ -0,0 +1,296 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Registry holds metrics and renders them in the Prometheus text format
+type Registry struct {
+	mu         sync.Mutex
+	counters   map[string]*CounterVec
+	histograms map[string]*HistogramVec
+	funcs      map[string]*funcMetric
+}
+
+// NewRegistry creates an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		counters:   make(map[string]*CounterVec),
+		histograms: make(map[string]*HistogramVec),
+		funcs:      make(map[string]*funcMetric),
+	}
+}
+
+// CounterVec is a counter partitioned by label values
+type CounterVec struct {
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// DefaultBuckets are histogram buckets in seconds suited to local database
+// queries and HTTP requests
+var DefaultBuckets = []float64{0.0005, 0.001, 0.0025, 0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5}
+
+// HistogramVec is a histogram partitioned by label values
+type HistogramVec struct {
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	values map[string]*histogram
+}
+
+// histogram holds the observations for one set of label values
+type histogram struct {
+	counts []uint64 // per bucket, not cumulative
+	sum    float64
+	count  uint64
+}
+
+// funcMetric is a metric whose labelled values are computed on scrape
+type funcMetric struct {
+	help   string
+	kind   string
+	labels []string
+	fn     func() map[string]float64
+}
+
+// Counter registers (or returns the existing) counter with the given labels
+func (r *Registry) Counter(name, help string, labels ...string) *CounterVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if counter, ok := r.counters[name]; ok {
+		return counter
+	}
+	counter := &CounterVec{help: help, labels: labels, values: make(map[string]float64)}
+	r.counters[name] = counter
+	return counter
+}
+
+// Histogram registers (or returns the existing) histogram with the given
+// upper bucket bounds, which must be sorted
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if hist, ok := r.histograms[name]; ok {
+		return hist
+	}
+	hist := &HistogramVec{help: help, labels: labels, buckets: buckets, values: make(map[string]*histogram)}
+	r.histograms[name] = hist
+	return hist
+}
+
+// GaugeFunc registers a gauge without labels computed by fn on every scrape
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.GaugeVecFunc(name, help, nil, func() map[string]float64 {
+		return map[string]float64{"": fn()}
+	})
+}
+
+// GaugeVecFunc registers a labelled gauge computed by fn on every scrape.
+// fn returns values keyed by the label values joined with "\x00".
+func (r *Registry) GaugeVecFunc(name, help string, labels []string, fn func() map[string]float64) {
+	r.register(name, help, "gauge", labels, fn)
+}
+
+// CounterFunc registers a counter maintained elsewhere, read by fn on every
+// scrape
+func (r *Registry) CounterFunc(name, help string, fn func() float64) {
+	r.register(name, help, "counter", nil, func() map[string]float64 {
+		return map[string]float64{"": fn()}
+	})
+}
+
+// register adds a computed metric
+func (r *Registry) register(name, help, kind string, labels []string, fn func() map[string]float64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.funcs[name] = &funcMetric{help: help, kind: kind, labels: labels, fn: fn}
+}
+
+// Inc adds one to the counter for the given label values
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add adds v to the counter for the given label values
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.values[LabelKey(labelValues...)] += v
+}
+
+// Observe records v in the histogram for the given label values
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	key := LabelKey(labelValues...)
+	hist, ok := h.values[key]
+	if !ok {
+		hist = &histogram{counts: make([]uint64, len(h.buckets))}
+		h.values[key] = hist
+	}
+
+	for i, bound := range h.buckets {
+		if v <= bound {
+			hist.counts[i]++
+			break
+		}
+	}
+	hist.sum += v
+	hist.count++
+}
+
+// LabelKey joins label values into the key used by GaugeVecFunc
+func LabelKey(labelValues ...string) string {
+	return strings.Join(labelValues, "\x00")
+}
+
+// Handler serves the registry in the Prometheus text format
+func (r *Registry) Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		r.WriteTo(w)
+	})
+}
+
+// WriteTo renders all metrics sorted by name
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	var names []string
+	for name := range r.counters {
+		names = append(names, name)
+	}
+	for name := range r.histograms {
+		names = append(names, name)
+	}
+	for name := range r.funcs {
+		names = append(names, name)
+	}
+	counters := make(map[string]*CounterVec, len(r.counters))
+	for name, counter := range r.counters {
+		counters[name] = counter
+	}
+	histograms := make(map[string]*HistogramVec, len(r.histograms))
+	for name, hist := range r.histograms {
+		histograms[name] = hist
+	}
+	funcs := make(map[string]*funcMetric, len(r.funcs))
+	for name, metric := range r.funcs {
+		funcs[name] = metric
+	}
+	r.mu.Unlock()
+
+	sort.Strings(names)
+
+	var b strings.Builder
+	for _, name := range names {
+		if counter, ok := counters[name]; ok {
+			counter.mu.Lock()
+			values := make(map[string]float64, len(counter.values))
+			for key, v := range counter.values {
+				values[key] = v
+			}
+			counter.mu.Unlock()
+			writeFamily(&b, name, counter.help, "counter", counter.labels, values)
+			continue
+		}
+		if hist, ok := histograms[name]; ok {
+			writeHistogram(&b, name, hist)
+			continue
+		}
+		metric := funcs[name]
+		writeFamily(&b, name, metric.help, metric.kind, metric.labels, metric.fn())
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+// writeFamily renders one metric family
+func writeFamily(b *strings.Builder, name, help, kind string, labels []string, values map[string]float64) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(b, "# TYPE %s %s\n", name, kind)
+
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(b, "%s%s %g\n", name, formatLabels(labels, key), values[key])
+	}
+}
+
+// writeHistogram renders a histogram family with cumulative buckets
+func writeHistogram(b *strings.Builder, name string, h *HistogramVec) {
+	fmt.Fprintf(b, "# HELP %s %s\n", name, h.help)
+	fmt.Fprintf(b, "# TYPE %s histogram\n", name)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	keys := make([]string, 0, len(h.values))
+	for key := range h.values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	bucketLabels := append(append([]string(nil), h.labels...), "le")
+	for _, key := range keys {
+		hist := h.values[key]
+
+		var cumulative uint64
+		for i, bound := range h.buckets {
+			cumulative += hist.counts[i]
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(b, "%s_bucket%s %d\n", name, formatLabels(bucketLabels, bucketKey(h.labels, key, le)), cumulative)
+		}
+		fmt.Fprintf(b, "%s_bucket%s %d\n", name, formatLabels(bucketLabels, bucketKey(h.labels, key, "+Inf")), hist.count)
+		fmt.Fprintf(b, "%s_sum%s %g\n", name, formatLabels(h.labels, key), hist.sum)
+		fmt.Fprintf(b, "%s_count%s %d\n", name, formatLabels(h.labels, key), hist.count)
+	}
+}
+
+// bucketKey appends the le label value to a label key
+func bucketKey(labels []string, key, le string) string {
+	if len(labels) == 0 {
+		return le
+	}
+	return key + "\x00" + le
+}
+
+// formatLabels renders {name="value",...} for a label key
+func formatLabels(labels []string, key string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	values := strings.Split(key, "\x00")
+	pairs := make([]string, len(labels))
+	for i, label := range labels {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		value = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+		pairs[i] = fmt.Sprintf(`%s="%s"`, label, value)
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
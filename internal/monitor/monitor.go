@@ -0,0 +1,194 @@
+package monitor
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"supabase-manager/internal/notify"
+)
+
+// Rule is a health rule evaluated on every heartbeat. Check returns whether
+// the rule is firing and a human readable explanation.
+type Rule struct {
+	Name     string
+	Severity string
+	Check    func() (bool, string)
+}
+
+// HeartbeatStore persists heartbeats of manager instances
+type HeartbeatStore interface {
+	RecordHeartbeat(instanceID, hostname string, startedAt, beatAt time.Time) error
+}
+
+// RuleStatus is the last evaluation of a rule
+type RuleStatus struct {
+	Name      string     `json:"name"`
+	Severity  string     `json:"severity"`
+	Firing    bool       `json:"firing"`
+	Message   string     `json:"message,omitempty"`
+	Since     *time.Time `json:"since,omitempty"`
+	CheckedAt time.Time  `json:"checked_at"`
+}
+
+// Monitor writes periodic heartbeats and alerts when health rules change state
+type Monitor struct {
+	store      HeartbeatStore
+	notifier   notify.Notifier
+	interval   time.Duration
+	instanceID string
+	hostname   string
+	startedAt  time.Time
+
+	mu                sync.Mutex
+	rules             []Rule
+	status            map[string]*RuleStatus
+	heartbeatFailures int
+}
+
+// New creates a monitor with the built-in heartbeat rule
+func New(store HeartbeatStore, notifier notify.Notifier, interval time.Duration) *Monitor {
+	hostname, _ := os.Hostname()
+
+	m := &Monitor{
+		store:      store,
+		notifier:   notifier,
+		interval:   interval,
+		instanceID: uuid.New().String(),
+		hostname:   hostname,
+		startedAt:  time.Now(),
+		status:     make(map[string]*RuleStatus),
+	}
+
+	m.AddRule(Rule{
+		Name:     "storage_errors",
+		Severity: notify.SeverityCritical,
+		Check: func() (bool, string) {
+			m.mu.Lock()
+			defer m.mu.Unlock()
+			if m.heartbeatFailures >= 3 {
+				return true, fmt.Sprintf("%d consecutive heartbeat writes failed", m.heartbeatFailures)
+			}
+			return false, ""
+		},
+	})
+
+	return m
+}
+
+// AddRule registers a health rule
+func (m *Monitor) AddRule(rule Rule) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if rule.Severity == "" {
+		rule.Severity = notify.SeverityWarning
+	}
+	m.rules = append(m.rules, rule)
+}
+
+// Run beats and evaluates rules every interval until the context is cancelled
+func (m *Monitor) Run(ctx context.Context) {
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+
+	for {
+		m.beat()
+		m.evaluate()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Status returns the last evaluation of every rule
+func (m *Monitor) Status() []RuleStatus {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	statuses := make([]RuleStatus, 0, len(m.rules))
+	for _, rule := range m.rules {
+		if status, ok := m.status[rule.Name]; ok {
+			statuses = append(statuses, *status)
+		}
+	}
+	return statuses
+}
+
+// beat records a heartbeat, counting consecutive failures
+func (m *Monitor) beat() {
+	err := m.store.RecordHeartbeat(m.instanceID, m.hostname, m.startedAt, time.Now())
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err != nil {
+		m.heartbeatFailures++
+		log.Printf("monitor: failed to record heartbeat: %v", err)
+		return
+	}
+	m.heartbeatFailures = 0
+}
+
+// evaluate checks every rule and notifies on state transitions
+func (m *Monitor) evaluate() {
+	m.mu.Lock()
+	rules := append([]Rule(nil), m.rules...)
+	m.mu.Unlock()
+
+	for _, rule := range rules {
+		firing, message := rule.Check()
+		now := time.Now()
+
+		m.mu.Lock()
+		previous, seen := m.status[rule.Name]
+		wasFiring := seen && previous.Firing
+		status := &RuleStatus{
+			Name:      rule.Name,
+			Severity:  rule.Severity,
+			Firing:    firing,
+			Message:   message,
+			CheckedAt: now,
+		}
+		if firing {
+			status.Since = &now
+			if wasFiring {
+				status.Since = previous.Since
+			}
+		}
+		m.status[rule.Name] = status
+		m.mu.Unlock()
+
+		switch {
+		case firing && !wasFiring:
+			m.send(notify.Notification{
+				Severity: rule.Severity,
+				Title:    "Health rule firing: " + rule.Name,
+				Message:  message,
+				Labels:   map[string]string{"rule": rule.Name, "instance": m.hostname},
+			})
+		case !firing && wasFiring:
+			m.send(notify.Notification{
+				Severity: notify.SeverityInfo,
+				Title:    "Health rule resolved: " + rule.Name,
+				Message:  fmt.Sprintf("%s is healthy again after %s", rule.Name, now.Sub(*previous.Since).Round(time.Second)),
+				Labels:   map[string]string{"rule": rule.Name, "instance": m.hostname},
+			})
+		}
+	}
+}
+
+// send delivers a notification, logging failures
+func (m *Monitor) send(n notify.Notification) {
+	if err := m.notifier.Notify(n); err != nil {
+		log.Printf("monitor: failed to send notification: %v", err)
+	}
+}
@@ -0,0 +1,105 @@
+package notify
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+)
+
+// Severity levels for notifications
+const (
+	SeverityInfo     = "info"
+	SeverityWarning  = "warning"
+	SeverityCritical = "critical"
+)
+
+// Notification is a message about the manager's own health or about a
+// managed project
+type Notification struct {
+	Severity string            `json:"severity"`
+	Title    string            `json:"title"`
+	Message  string            `json:"message"`
+	Labels   map[string]string `json:"labels,omitempty"`
+	Time     time.Time         `json:"time"`
+}
+
+// Notifier delivers notifications
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// New returns a notifier that always logs and additionally posts JSON to
+// webhookURL when it is set
+func New(webhookURL string) Notifier {
+	notifiers := Multi{LogNotifier{}}
+	if webhookURL != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(webhookURL))
+	}
+	return notifiers
+}
+
+// LogNotifier writes notifications to the application log
+type LogNotifier struct{}
+
+// Notify logs the notification
+func (LogNotifier) Notify(n Notification) error {
+	log.Printf("[%s] %s: %s", n.Severity, n.Title, n.Message)
+	return nil
+}
+
+// WebhookNotifier posts notifications as JSON to a URL
+type WebhookNotifier struct {
+	url        string
+	httpClient *http.Client
+}
+
+// NewWebhookNotifier creates a notifier posting to url
+func NewWebhookNotifier(url string) *WebhookNotifier {
+	return &WebhookNotifier{
+		url:        url,
+		httpClient: &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Notify posts the notification
+func (w *WebhookNotifier) Notify(n Notification) error {
+	body, err := json.Marshal(n)
+	if err != nil {
+		return fmt.Errorf("failed to marshal notification: %w", err)
+	}
+
+	resp, err := w.httpClient.Post(w.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to send notification: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("notification webhook error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return nil
+}
+
+// Multi fans a notification out to several notifiers
+type Multi []Notifier
+
+// Notify delivers to every notifier, returning the first error
+func (m Multi) Notify(n Notification) error {
+	if n.Time.IsZero() {
+		n.Time = time.Now()
+	}
+
+	var firstErr error
+	for _, notifier := range m {
+		if err := notifier.Notify(n); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
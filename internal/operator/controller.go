@@ -0,0 +1,318 @@
+package operator
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// SupabaseProject is the custom resource reconciled by the controller
+type SupabaseProject struct {
+	APIVersion string                `json:"apiVersion"`
+	Kind       string                `json:"kind"`
+	Metadata   ObjectMeta            `json:"metadata"`
+	Spec       SupabaseProjectSpec   `json:"spec"`
+	Status     SupabaseProjectStatus `json:"status"`
+}
+
+// SupabaseProjectList is a list of SupabaseProject resources
+type SupabaseProjectList struct {
+	Items []SupabaseProject `json:"items"`
+}
+
+// ObjectMeta holds the metadata fields the controller needs
+type ObjectMeta struct {
+	Name              string `json:"name"`
+	Namespace         string `json:"namespace"`
+	UID               string `json:"uid"`
+	Generation        int64  `json:"generation,omitempty"`
+	DeletionTimestamp string `json:"deletionTimestamp,omitempty"`
+}
+
+// SupabaseProjectSpec is the desired state of a project
+type SupabaseProjectSpec struct {
+	Name       string `json:"name,omitempty"`
+	Region     string `json:"region,omitempty"`
+	SecretName string `json:"secretName,omitempty"`
+}
+
+// SupabaseProjectStatus is the observed state written back by the controller
+type SupabaseProjectStatus struct {
+	ProjectID  string `json:"projectId,omitempty"`
+	ProjectRef string `json:"projectRef,omitempty"`
+	ProjectURL string `json:"projectUrl,omitempty"`
+	Phase      string `json:"phase,omitempty"`
+	SecretName string `json:"secretName,omitempty"`
+	Message    string `json:"message,omitempty"`
+	// ObservedGeneration is the spec generation the phase was reached for
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+}
+
+// Resource phases set by the controller. Other phases are the project's
+// status.
+const (
+	// PhaseFailed is terminal: the project was refused, and provisioning is
+	// only tried again once the spec changes
+	PhaseFailed = "Failed"
+	// PhaseRetrying means provisioning failed and will be retried after a
+	// backoff
+	PhaseRetrying = "Retrying"
+)
+
+// UIDLabel is the project label recording the UID of the resource a project
+// was provisioned for, so a resource whose status could not be written is
+// not provisioned twice
+const UIDLabel = "supabase-manager/operator-uid"
+
+// ErrRejected is wrapped by provisioner errors that retrying will not fix
+var ErrRejected = errors.New("request rejected")
+
+const (
+	retryInitialDelay = 30 * time.Second
+	retryMaxDelay     = 30 * time.Minute
+)
+
+// Provisioner is the part of the manager's service layer used by the controller
+type Provisioner interface {
+	ProvisionProject(req *supabase.CreateProjectRequest) (*supabase.StoredProject, error)
+	GetProjectRecord(projectID string) (*supabase.StoredProject, error)
+	// FindProjectByLabel returns a project carrying the label, or nil
+	FindProjectByLabel(key, value string) (*supabase.StoredProject, error)
+}
+
+// retryState is the backoff of a resource whose provisioning failed
+type retryState struct {
+	delay time.Duration
+	next  time.Time
+}
+
+// Controller reconciles SupabaseProject resources into managed projects
+type Controller struct {
+	kube        *kubeClient
+	provisioner Provisioner
+	namespace   string
+	interval    time.Duration
+
+	// failingPasses counts consecutive reconcile passes with errors
+	failingPasses atomic.Int64
+	// retries holds the backoff of failed provisions by resource UID. It
+	// is only used by the reconcile loop.
+	retries map[string]*retryState
+}
+
+// NewController creates a controller watching the given namespace (all
+// namespaces when empty)
+func NewController(provisioner Provisioner, namespace string, interval time.Duration) (*Controller, error) {
+	kube, err := newKubeClient()
+	if err != nil {
+		return nil, err
+	}
+
+	return &Controller{
+		kube:        kube,
+		provisioner: provisioner,
+		namespace:   namespace,
+		interval:    interval,
+		retries:     make(map[string]*retryState),
+	}, nil
+}
+
+// Run reconciles all resources every interval until the context is cancelled
+func (ctrl *Controller) Run(ctx context.Context) {
+	ticker := time.NewTicker(ctrl.interval)
+	defer ticker.Stop()
+
+	for {
+		ctrl.reconcileAll()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// FailingPasses returns the number of consecutive reconcile passes that
+// ended with at least one error
+func (ctrl *Controller) FailingPasses() int64 {
+	return ctrl.failingPasses.Load()
+}
+
+// reconcileAll lists resources and reconciles each of them
+func (ctrl *Controller) reconcileAll() {
+	resources, err := ctrl.kube.listProjects(ctrl.namespace)
+	if err != nil {
+		log.Printf("operator: failed to list SupabaseProject resources: %v", err)
+		ctrl.failingPasses.Add(1)
+		return
+	}
+
+	failed := false
+	listed := make(map[string]bool, len(resources))
+	for i := range resources {
+		resource := &resources[i]
+		listed[resource.Metadata.UID] = true
+		if resource.Metadata.DeletionTimestamp != "" {
+			continue
+		}
+		if err := ctrl.reconcile(resource); err != nil {
+			log.Printf("operator: failed to reconcile %s/%s: %v",
+				resource.Metadata.Namespace, resource.Metadata.Name, err)
+			failed = true
+		}
+	}
+
+	// Forget the backoff of resources that are gone
+	for uid := range ctrl.retries {
+		if !listed[uid] {
+			delete(ctrl.retries, uid)
+		}
+	}
+
+	if failed {
+		ctrl.failingPasses.Add(1)
+	} else {
+		ctrl.failingPasses.Store(0)
+	}
+}
+
+// reconcile drives a single resource toward a ready project with a secret
+func (ctrl *Controller) reconcile(resource *SupabaseProject) error {
+	// Provision a project for resources we have not seen before
+	if resource.Status.ProjectID == "" {
+		return ctrl.provision(resource)
+	}
+
+	project, err := ctrl.provisioner.GetProjectRecord(resource.Status.ProjectID)
+	if err != nil {
+		return fmt.Errorf("failed to load project %s: %w", resource.Status.ProjectID, err)
+	}
+
+	status := resource.Status
+	status.Phase = project.Status
+	status.ProjectURL = project.ProjectURL
+
+	// Publish credentials once the project is ready
+	if project.Status == "ACTIVE_HEALTHY" && project.AnonKey != "" {
+		secretName := resource.Spec.SecretName
+		if secretName == "" {
+			secretName = resource.Metadata.Name + "-supabase"
+		}
+
+		err := ctrl.kube.applySecret(resource.Metadata.Namespace, secretName, map[string]string{
+			"SUPABASE_URL":         project.ProjectURL,
+			"SUPABASE_PROJECT_REF": project.ProjectRef,
+			"SUPABASE_ANON_KEY":    project.AnonKey,
+			"SUPABASE_SERVICE_KEY": project.ServiceKey,
+		}, resource)
+		if err != nil {
+			return fmt.Errorf("failed to write secret: %w", err)
+		}
+
+		status.SecretName = secretName
+		status.Message = "Project ready"
+	}
+
+	if status == resource.Status {
+		return nil
+	}
+
+	resource.Status = status
+	return ctrl.kube.updateStatus(resource)
+}
+
+// provision creates the project of a resource, or adopts the one already
+// created for it. Failures retrying will not fix leave the resource Failed
+// until its spec changes; others are retried with backoff.
+func (ctrl *Controller) provision(resource *SupabaseProject) error {
+	uid := resource.Metadata.UID
+	if resource.Status.Phase == PhaseFailed && resource.Status.ObservedGeneration == resource.Metadata.Generation {
+		return nil
+	}
+	if retry, ok := ctrl.retries[uid]; ok && time.Now().Before(retry.next) {
+		return nil
+	}
+
+	project, err := ctrl.provisioner.FindProjectByLabel(UIDLabel, uid)
+	if err != nil {
+		return fmt.Errorf("failed to look up the project of %s: %w", uid, err)
+	}
+
+	if project == nil {
+		name := resource.Spec.Name
+		if name == "" {
+			name = resource.Metadata.Name
+		}
+
+		project, err = ctrl.provisioner.ProvisionProject(&supabase.CreateProjectRequest{
+			Name:   name,
+			Region: resource.Spec.Region,
+			Labels: map[string]string{UIDLabel: uid},
+		})
+		if err != nil {
+			resource.Status.Phase = PhaseRetrying
+			resource.Status.Message = err.Error()
+			if isPermanent(err) {
+				delete(ctrl.retries, uid)
+				resource.Status.Phase = PhaseFailed
+			} else {
+				delay := ctrl.backoff(uid)
+				resource.Status.Message = fmt.Sprintf("%s (retrying in %s)", err, delay)
+			}
+			resource.Status.ObservedGeneration = resource.Metadata.Generation
+			if statusErr := ctrl.kube.updateStatus(resource); statusErr != nil {
+				return statusErr
+			}
+			return fmt.Errorf("failed to provision project: %w", err)
+		}
+	}
+	delete(ctrl.retries, uid)
+
+	resource.Status = SupabaseProjectStatus{
+		ProjectID:          project.ID,
+		ProjectRef:         project.ProjectRef,
+		ProjectURL:         project.ProjectURL,
+		Phase:              project.Status,
+		Message:            "Project creation initiated",
+		ObservedGeneration: resource.Metadata.Generation,
+	}
+	return ctrl.kube.updateStatus(resource)
+}
+
+// backoff schedules the next provisioning attempt of a resource, doubling
+// the delay after each failure, and returns the delay
+func (ctrl *Controller) backoff(uid string) time.Duration {
+	retry, ok := ctrl.retries[uid]
+	if !ok {
+		retry = &retryState{delay: retryInitialDelay}
+		ctrl.retries[uid] = retry
+	} else {
+		retry.delay = min(retry.delay*2, retryMaxDelay)
+	}
+	retry.next = time.Now().Add(retry.delay)
+	return retry.delay
+}
+
+// isPermanent reports whether a provisioning error will not go away on
+// retry: a rejection by the manager, or a Management API error blaming the
+// request rather than the API or its capacity
+func isPermanent(err error) bool {
+	if errors.Is(err, ErrRejected) {
+		return true
+	}
+	var apiErr *supabase.APIError
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.Kind {
+	case supabase.ErrKindRateLimited, supabase.ErrKindRegionCapacity:
+		return false
+	}
+	return apiErr.StatusCode >= 400 && apiErr.StatusCode < 500
+}
@@ -0,0 +1,173 @@
+package operator
+
+import (
+	"bytes"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+const (
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+
+	// CRD coordinates for SupabaseProject resources
+	resourceGroup   = "supabase-manager.io"
+	resourceVersion = "v1alpha1"
+	resourcePlural  = "supabaseprojects"
+)
+
+// kubeClient is a minimal Kubernetes REST client for the resources the
+// controller touches
+type kubeClient struct {
+	baseURL    string
+	token      string
+	httpClient *http.Client
+}
+
+// newKubeClient builds a client from KUBE_API_URL/KUBE_TOKEN when set, or
+// from the in-cluster service account otherwise
+func newKubeClient() (*kubeClient, error) {
+	if apiURL := os.Getenv("KUBE_API_URL"); apiURL != "" {
+		return &kubeClient{
+			baseURL:    strings.TrimSuffix(apiURL, "/"),
+			token:      os.Getenv("KUBE_TOKEN"),
+			httpClient: &http.Client{Timeout: 30 * time.Second},
+		}, nil
+	}
+
+	host := os.Getenv("KUBERNETES_SERVICE_HOST")
+	port := os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, fmt.Errorf("not running in a cluster and KUBE_API_URL is not set")
+	}
+
+	token, err := os.ReadFile(serviceAccountDir + "/token")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read service account token: %w", err)
+	}
+
+	caCert, err := os.ReadFile(serviceAccountDir + "/ca.crt")
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cluster CA: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caCert) {
+		return nil, fmt.Errorf("failed to parse cluster CA")
+	}
+
+	return &kubeClient{
+		baseURL: "https://" + host + ":" + port,
+		token:   strings.TrimSpace(string(token)),
+		httpClient: &http.Client{
+			Timeout: 30 * time.Second,
+			Transport: &http.Transport{
+				TLSClientConfig: &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12},
+			},
+		},
+	}, nil
+}
+
+// do sends a request to the API server and decodes a JSON response into out
+func (k *kubeClient) do(method, path, contentType string, body interface{}, out interface{}) (int, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return 0, fmt.Errorf("failed to marshal request: %w", err)
+		}
+		reader = bytes.NewReader(payload)
+	}
+
+	req, err := http.NewRequest(method, k.baseURL+path, reader)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	if k.token != "" {
+		req.Header.Set("Authorization", "Bearer "+k.token)
+	}
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := k.httpClient.Do(req)
+	if err != nil {
+		return 0, fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return resp.StatusCode, fmt.Errorf("kubernetes API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return resp.StatusCode, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return resp.StatusCode, nil
+}
+
+// listProjects lists SupabaseProject resources, across all namespaces when
+// namespace is empty
+func (k *kubeClient) listProjects(namespace string) ([]SupabaseProject, error) {
+	path := fmt.Sprintf("/apis/%s/%s/%s", resourceGroup, resourceVersion, resourcePlural)
+	if namespace != "" {
+		path = fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s", resourceGroup, resourceVersion, namespace, resourcePlural)
+	}
+
+	var list SupabaseProjectList
+	if _, err := k.do("GET", path, "", nil, &list); err != nil {
+		return nil, err
+	}
+
+	return list.Items, nil
+}
+
+// updateStatus merge-patches the status subresource of a SupabaseProject
+func (k *kubeClient) updateStatus(resource *SupabaseProject) error {
+	path := fmt.Sprintf("/apis/%s/%s/namespaces/%s/%s/%s/status",
+		resourceGroup, resourceVersion, resource.Metadata.Namespace, resourcePlural, resource.Metadata.Name)
+
+	patch := map[string]interface{}{"status": resource.Status}
+	_, err := k.do("PATCH", path, "application/merge-patch+json", patch, nil)
+	return err
+}
+
+// applySecret creates or replaces an Opaque secret
+func (k *kubeClient) applySecret(namespace, name string, data map[string]string, owner *SupabaseProject) error {
+	secret := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Secret",
+		"type":       "Opaque",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": namespace,
+			"labels": map[string]string{
+				"app.kubernetes.io/managed-by": "supabase-manager",
+			},
+			"ownerReferences": []map[string]interface{}{{
+				"apiVersion": resourceGroup + "/" + resourceVersion,
+				"kind":       "SupabaseProject",
+				"name":       owner.Metadata.Name,
+				"uid":        owner.Metadata.UID,
+			}},
+		},
+		"stringData": data,
+	}
+
+	path := fmt.Sprintf("/api/v1/namespaces/%s/secrets/%s", namespace, name)
+	status, err := k.do("PUT", path, "application/json", secret, nil)
+	if status == http.StatusNotFound {
+		_, err = k.do("POST", fmt.Sprintf("/api/v1/namespaces/%s/secrets", namespace), "application/json", secret, nil)
+	}
+	return err
+}
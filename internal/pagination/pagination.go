@@ -0,0 +1,124 @@
+// Package pagination implements the cursor-based pagination shared by the
+// API's list endpoints. Lists are ordered newest first and paged by an
+// opaque cursor naming the last item returned, so pages stay stable while
+// new items are added.
+package pagination
+
+import (
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+)
+
+// cursorPrefix versions the cursor encoding
+const cursorPrefix = "v1:"
+
+// Limits are the page sizes of a list endpoint
+type Limits struct {
+	Default int
+	Max     int
+}
+
+// Request is a page request parsed from ?limit, ?cursor and ?include_total
+type Request struct {
+	Limit int
+	// After is the position of the last item of the previous page; zero
+	// starts from the newest item
+	After int64
+	// IncludeTotal asks for the number of items across all pages, which
+	// costs an extra count query
+	IncludeTotal bool
+}
+
+// Page describes where a page sits in its list. It is returned alongside
+// the items of every list endpoint.
+type Page struct {
+	Limit      int    `json:"limit"`
+	HasMore    bool   `json:"has_more"`
+	NextCursor string `json:"next_cursor,omitempty"`
+	Total      *int   `json:"total,omitempty"`
+}
+
+// ParseRequest reads a page request from query parameters
+func ParseRequest(query url.Values, limits Limits) (Request, error) {
+	req := Request{Limit: limits.Default}
+
+	if value := query.Get("limit"); value != "" {
+		limit, err := strconv.Atoi(value)
+		if err != nil || limit < 1 || limit > limits.Max {
+			return req, fmt.Errorf("limit must be between 1 and %d", limits.Max)
+		}
+		req.Limit = limit
+	}
+
+	if value := query.Get("cursor"); value != "" {
+		after, err := decodeCursor(value)
+		if err != nil {
+			return req, err
+		}
+		req.After = after
+	}
+
+	if value := query.Get("include_total"); value != "" {
+		includeTotal, err := strconv.ParseBool(value)
+		if err != nil {
+			return req, fmt.Errorf("include_total must be true or false")
+		}
+		req.IncludeTotal = includeTotal
+	}
+
+	return req, nil
+}
+
+// Slice trims items fetched with one more than the page limit to the page,
+// and describes the page. positions holds each item's position in the list.
+func Slice[T any](req Request, items []T, positions []int64) ([]T, Page) {
+	page := Page{Limit: req.Limit}
+	if len(items) > req.Limit {
+		items = items[:req.Limit]
+		page.HasMore = true
+		page.NextCursor = encodeCursor(positions[req.Limit-1])
+	}
+	return items, page
+}
+
+// WithTotal sets the page's total when the request asked for it
+func (p Page) WithTotal(req Request, total int) Page {
+	if req.IncludeTotal {
+		p.Total = &total
+	}
+	return p
+}
+
+// Envelope returns the response body for a page: the items under key, next
+// to the page description
+func Envelope(key string, items interface{}, page Page) map[string]interface{} {
+	return map[string]interface{}{
+		key:          items,
+		"pagination": page,
+	}
+}
+
+// encodeCursor returns the opaque cursor for a position
+func encodeCursor(position int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(cursorPrefix + strconv.FormatInt(position, 10)))
+}
+
+// decodeCursor returns the position a cursor names
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	value, ok := strings.CutPrefix(string(raw), cursorPrefix)
+	if !ok {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	position, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || position < 1 {
+		return 0, fmt.Errorf("invalid cursor")
+	}
+	return position, nil
+}
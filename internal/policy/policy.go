@@ -0,0 +1,134 @@
+package policy
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Operations checked against the policy endpoint
+const (
+	OperationCreateProject = "project.create"
+	OperationApplySchema   = "schema.apply"
+	OperationCreatePreview = "preview.create"
+)
+
+// Caller identifies who asked for an operation
+type Caller struct {
+	KeyID  string   `json:"key_id"`
+	Name   string   `json:"name,omitempty"`
+	Scopes []string `json:"scopes,omitempty"`
+	IP     string   `json:"ip,omitempty"`
+}
+
+// Input is sent to the policy endpoint for every checked operation
+type Input struct {
+	Operation string      `json:"operation"`
+	Caller    Caller      `json:"caller"`
+	DryRun    bool        `json:"dry_run"`
+	RequestID string      `json:"request_id,omitempty"`
+	Request   interface{} `json:"request"`
+}
+
+// Decision is the policy endpoint's answer
+type Decision struct {
+	Allow  bool   `json:"allow"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// Checker asks an external endpoint whether an operation may proceed. The
+// input is posted as {"input": ...}, and the answer may be a decision or an
+// OPA-style {"result": decision}. A nil Checker allows everything.
+type Checker struct {
+	url        string
+	httpClient *http.Client
+	failOpen   bool
+}
+
+// New returns a checker posting to url, or nil when url is empty. With
+// failOpen, operations are allowed when the endpoint cannot be reached or
+// answers with an error.
+func New(url string, timeout time.Duration, failOpen bool) *Checker {
+	if url == "" {
+		return nil
+	}
+	return &Checker{
+		url:        url,
+		httpClient: &http.Client{Timeout: timeout},
+		failOpen:   failOpen,
+	}
+}
+
+// Check returns the decision for an operation. An error means no decision
+// could be made.
+func (c *Checker) Check(ctx context.Context, input Input) (*Decision, error) {
+	if c == nil {
+		return &Decision{Allow: true}, nil
+	}
+
+	decision, err := c.query(ctx, input)
+	if err != nil && c.failOpen {
+		fmt.Printf("Warning: Policy check for %s failed, allowing it: %v\n", input.Operation, err)
+		return &Decision{Allow: true, Reason: "policy endpoint unavailable"}, nil
+	}
+	return decision, err
+}
+
+// query posts the input and decodes the decision
+func (c *Checker) query(ctx context.Context, input Input) (*Decision, error) {
+	body, err := json.Marshal(map[string]interface{}{"input": input})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal policy input: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", c.url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach policy endpoint: %w", err)
+	}
+	defer resp.Body.Close()
+
+	bodyBytes, err := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read policy response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("policy endpoint error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	return decodeDecision(bodyBytes)
+}
+
+// decodeDecision accepts a decision, {"result": decision} or
+// {"result": true|false}
+func decodeDecision(body []byte) (*Decision, error) {
+	var wrapped struct {
+		Result json.RawMessage `json:"result"`
+	}
+	if err := json.Unmarshal(body, &wrapped); err != nil {
+		return nil, fmt.Errorf("failed to decode policy response: %w", err)
+	}
+
+	if wrapped.Result != nil {
+		var allow bool
+		if err := json.Unmarshal(wrapped.Result, &allow); err == nil {
+			return &Decision{Allow: allow}, nil
+		}
+		body = wrapped.Result
+	}
+
+	var decision Decision
+	if err := json.Unmarshal(body, &decision); err != nil {
+		return nil, fmt.Errorf("failed to decode policy decision: %w", err)
+	}
+	return &decision, nil
+}
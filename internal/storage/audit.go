@@ -0,0 +1,87 @@
+package storage
+
+import (
+	"fmt"
+
+	"supabase-manager/internal/pagination"
+	"supabase-manager/internal/supabase"
+)
+
+// RecordAuditEvent appends an event to the audit log
+func (s *SQLiteStorage) RecordAuditEvent(event *supabase.AuditEvent) error {
+	query := `
+		INSERT INTO audit_events (id, action, project_id, actor, request_id, client_ip, details, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		event.ID,
+		event.Action,
+		event.ProjectID,
+		event.Actor,
+		event.RequestID,
+		event.ClientIP,
+		event.Details,
+		event.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+
+	return nil
+}
+
+// auditColumns is the column list shared by audit event queries
+const auditColumns = "id, action, project_id, actor, request_id, client_ip, details, created_at"
+
+// ListAuditEvents returns a page of a project's audit events, newest first
+func (s *SQLiteStorage) ListAuditEvents(projectID string, req pagination.Request) ([]*supabase.AuditEvent, pagination.Page, error) {
+	return listPage(s, "audit_events", auditColumns, "project_id = ?", []interface{}{projectID}, req,
+		func(row rowScanner, position *int64) (*supabase.AuditEvent, error) {
+			var event supabase.AuditEvent
+			err := row.Scan(
+				position,
+				&event.ID,
+				&event.Action,
+				&event.ProjectID,
+				&event.Actor,
+				&event.RequestID,
+				&event.ClientIP,
+				&event.Details,
+				&event.CreatedAt,
+			)
+			return &event, err
+		})
+}
+
+// ListAllAuditEvents returns every audit event of a project, oldest first
+func (s *SQLiteStorage) ListAllAuditEvents(projectID string) ([]*supabase.AuditEvent, error) {
+	query := "SELECT " + auditColumns + " FROM audit_events WHERE project_id = ? ORDER BY rowid"
+
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list audit events: %w", err)
+	}
+	defer rows.Close()
+
+	events := []*supabase.AuditEvent{}
+	for rows.Next() {
+		var event supabase.AuditEvent
+		err := rows.Scan(
+			&event.ID,
+			&event.Action,
+			&event.ProjectID,
+			&event.Actor,
+			&event.RequestID,
+			&event.ClientIP,
+			&event.Details,
+			&event.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		events = append(events, &event)
+	}
+
+	return events, rows.Err()
+}
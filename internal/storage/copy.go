@@ -0,0 +1,338 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	_ "github.com/lib/pq" // PostgreSQL driver
+)
+
+// CopyReport summarizes a copy of the local SQLite data into Postgres
+type CopyReport struct {
+	DryRun   bool              `json:"dry_run"`
+	Tables   []TableCopyReport `json:"tables"`
+	Verified bool              `json:"verified"`
+}
+
+// TableCopyReport summarizes the copy of a single table
+type TableCopyReport struct {
+	Table            string `json:"table"`
+	CreateTable      bool   `json:"create_table"`
+	SourceRows       int64  `json:"source_rows"`
+	TargetRowsBefore int64  `json:"target_rows_before"`
+	Conflicts        int64  `json:"conflicts"`
+	CopiedRows       int64  `json:"copied_rows"`
+	TargetRowsAfter  int64  `json:"target_rows_after"`
+	Missing          int64  `json:"missing"`
+	Verified         bool   `json:"verified"`
+}
+
+// sqliteColumn is a column description from PRAGMA table_info
+type sqliteColumn struct {
+	name    string
+	colType string
+	notNull bool
+	pk      int
+}
+
+// CopyToPostgres copies every local table into the Postgres database at dsn,
+// creating missing tables and skipping rows whose primary key already exists.
+// With dryRun set nothing is written and the report describes what would
+// happen. Every copied table is verified by primary key afterwards.
+func (s *SQLiteStorage) CopyToPostgres(dsn string, dryRun bool) (*CopyReport, error) {
+	target, err := sql.Open("postgres", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open target database: %w", err)
+	}
+	defer target.Close()
+
+	if err := target.Ping(); err != nil {
+		return nil, fmt.Errorf("failed to connect to target database: %w", err)
+	}
+
+	tables, err := s.listTables()
+	if err != nil {
+		return nil, err
+	}
+
+	report := &CopyReport{DryRun: dryRun, Verified: !dryRun}
+	for _, table := range tables {
+		tableReport, err := s.copyTable(target, table, dryRun)
+		if err != nil {
+			return report, fmt.Errorf("table %s: %w", table, err)
+		}
+		report.Tables = append(report.Tables, *tableReport)
+		if !dryRun && !tableReport.Verified {
+			report.Verified = false
+		}
+	}
+
+	return report, nil
+}
+
+// listTables returns the user tables of the SQLite database
+func (s *SQLiteStorage) listTables() ([]string, error) {
+	rows, err := s.db.Query(`SELECT name FROM sqlite_master WHERE type = 'table' AND name NOT LIKE 'sqlite_%' ORDER BY name`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tables: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return nil, fmt.Errorf("failed to scan table name: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	return tables, rows.Err()
+}
+
+// tableColumns returns the column definitions of a SQLite table
+func (s *SQLiteStorage) tableColumns(table string) ([]sqliteColumn, error) {
+	rows, err := s.db.Query(fmt.Sprintf(`PRAGMA table_info(%q)`, table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to describe table: %w", err)
+	}
+	defer rows.Close()
+
+	var columns []sqliteColumn
+	for rows.Next() {
+		var cid int
+		var col sqliteColumn
+		var defaultValue sql.NullString
+		if err := rows.Scan(&cid, &col.name, &col.colType, &col.notNull, &defaultValue, &col.pk); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+		columns = append(columns, col)
+	}
+	return columns, rows.Err()
+}
+
+// copyTable creates, copies and verifies a single table
+func (s *SQLiteStorage) copyTable(target *sql.DB, table string, dryRun bool) (*TableCopyReport, error) {
+	columns, err := s.tableColumns(table)
+	if err != nil {
+		return nil, err
+	}
+
+	var names, pkNames []string
+	for _, col := range columns {
+		names = append(names, col.name)
+		if col.pk > 0 {
+			pkNames = append(pkNames, col.name)
+		}
+	}
+	if len(pkNames) == 0 {
+		return nil, fmt.Errorf("table has no primary key")
+	}
+
+	report := &TableCopyReport{Table: table}
+
+	if err := s.db.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %q`, table)).Scan(&report.SourceRows); err != nil {
+		return nil, fmt.Errorf("failed to count source rows: %w", err)
+	}
+
+	var exists bool
+	if err := target.QueryRow(`SELECT to_regclass($1) IS NOT NULL`, "public."+table).Scan(&exists); err != nil {
+		return nil, fmt.Errorf("failed to check target table: %w", err)
+	}
+	report.CreateTable = !exists
+
+	var targetKeys map[string]bool
+	if exists {
+		if err := target.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %q`, table)).Scan(&report.TargetRowsBefore); err != nil {
+			return nil, fmt.Errorf("failed to count target rows: %w", err)
+		}
+		if targetKeys, err = primaryKeySet(target, table, pkNames); err != nil {
+			return nil, err
+		}
+	}
+
+	sourceRows, err := s.db.Query(fmt.Sprintf(`SELECT %s FROM %q`, quoteColumns(names), table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read source rows: %w", err)
+	}
+	defer sourceRows.Close()
+
+	if dryRun {
+		for sourceRows.Next() {
+			values, err := scanRow(sourceRows, len(names))
+			if err != nil {
+				return nil, err
+			}
+			if targetKeys[rowKey(values, columns)] {
+				report.Conflicts++
+			}
+		}
+		return report, sourceRows.Err()
+	}
+
+	tx, err := target.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if report.CreateTable {
+		if _, err := tx.Exec(postgresCreateTable(table, columns, pkNames)); err != nil {
+			return nil, fmt.Errorf("failed to create target table: %w", err)
+		}
+	}
+
+	placeholders := make([]string, len(names))
+	for i := range names {
+		placeholders[i] = fmt.Sprintf("$%d", i+1)
+	}
+	insert := fmt.Sprintf(`INSERT INTO %q (%s) VALUES (%s) ON CONFLICT (%s) DO NOTHING`,
+		table, quoteColumns(names), strings.Join(placeholders, ", "), quoteColumns(pkNames))
+
+	sourceKeys := make([]string, 0, report.SourceRows)
+	for sourceRows.Next() {
+		values, err := scanRow(sourceRows, len(names))
+		if err != nil {
+			return nil, err
+		}
+		sourceKeys = append(sourceKeys, rowKey(values, columns))
+
+		result, err := tx.Exec(insert, values...)
+		if err != nil {
+			return nil, fmt.Errorf("failed to insert row: %w", err)
+		}
+		affected, _ := result.RowsAffected()
+		if affected == 0 {
+			report.Conflicts++
+		}
+		report.CopiedRows += affected
+	}
+	if err := sourceRows.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read source rows: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit: %w", err)
+	}
+
+	// Verify every source row is present in the target
+	if err := target.QueryRow(fmt.Sprintf(`SELECT COUNT(*) FROM %q`, table)).Scan(&report.TargetRowsAfter); err != nil {
+		return nil, fmt.Errorf("failed to count target rows: %w", err)
+	}
+	copiedKeys, err := primaryKeySet(target, table, pkNames)
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range sourceKeys {
+		if !copiedKeys[key] {
+			report.Missing++
+		}
+	}
+	report.Verified = report.Missing == 0
+
+	return report, nil
+}
+
+// postgresCreateTable renders a CREATE TABLE statement for a SQLite table
+func postgresCreateTable(table string, columns []sqliteColumn, pkNames []string) string {
+	var defs []string
+	for _, col := range columns {
+		def := fmt.Sprintf("%q %s", col.name, postgresType(col.colType))
+		if col.notNull {
+			def += " NOT NULL"
+		}
+		defs = append(defs, def)
+	}
+	defs = append(defs, fmt.Sprintf("PRIMARY KEY (%s)", quoteColumns(pkNames)))
+
+	return fmt.Sprintf("CREATE TABLE %q (\n\t%s\n)", table, strings.Join(defs, ",\n\t"))
+}
+
+// postgresType maps a SQLite declared type to a Postgres type
+func postgresType(sqliteType string) string {
+	switch strings.ToUpper(sqliteType) {
+	case "INTEGER":
+		return "BIGINT"
+	case "REAL":
+		return "DOUBLE PRECISION"
+	case "BLOB":
+		return "BYTEA"
+	case "DATETIME":
+		return "TIMESTAMPTZ"
+	default:
+		return "TEXT"
+	}
+}
+
+// primaryKeySet loads the primary keys of a Postgres table
+func primaryKeySet(db *sql.DB, table string, pkNames []string) (map[string]bool, error) {
+	rows, err := db.Query(fmt.Sprintf(`SELECT %s FROM %q`, castColumnsToText(pkNames), table))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read target keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := make(map[string]bool)
+	for rows.Next() {
+		values, err := scanRow(rows, len(pkNames))
+		if err != nil {
+			return nil, err
+		}
+		keys[joinValues(values)] = true
+	}
+	return keys, rows.Err()
+}
+
+// rowKey extracts the primary key of a source row as a comparable string
+func rowKey(values []interface{}, columns []sqliteColumn) string {
+	var pk []interface{}
+	for i, col := range columns {
+		if col.pk > 0 {
+			pk = append(pk, values[i])
+		}
+	}
+	return joinValues(pk)
+}
+
+// joinValues renders values as a single key string
+func joinValues(values []interface{}) string {
+	parts := make([]string, len(values))
+	for i, v := range values {
+		if b, ok := v.([]byte); ok {
+			v = string(b)
+		}
+		parts[i] = fmt.Sprint(v)
+	}
+	return strings.Join(parts, "\x00")
+}
+
+// scanRow scans a row of n columns into generic values
+func scanRow(rows rowScanner, n int) ([]interface{}, error) {
+	values := make([]interface{}, n)
+	pointers := make([]interface{}, n)
+	for i := range values {
+		pointers[i] = &values[i]
+	}
+	if err := rows.Scan(pointers...); err != nil {
+		return nil, fmt.Errorf("failed to scan row: %w", err)
+	}
+	return values, nil
+}
+
+// quoteColumns renders a quoted, comma-separated column list
+func quoteColumns(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = fmt.Sprintf("%q", name)
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// castColumnsToText renders a column list cast to text
+func castColumnsToText(names []string) string {
+	cast := make([]string, len(names))
+	for i, name := range names {
+		cast[i] = fmt.Sprintf("%q::text", name)
+	}
+	return strings.Join(cast, ", ")
+}
@@ -0,0 +1,148 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"supabase-manager/internal/supabase"
+)
+
+// ErrDatabaseNameExists is returned by SaveDatabase when another database
+// is registered under the same name
+var ErrDatabaseNameExists = errors.New("a database with this name is already registered")
+
+// SaveDatabase stores an external database registration
+func (s *SQLiteStorage) SaveDatabase(database *supabase.ExternalDatabase) error {
+	query := `
+		INSERT INTO databases (
+			id, name, host, port, database_name, username, password,
+			sslmode, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			name = excluded.name,
+			host = excluded.host,
+			port = excluded.port,
+			database_name = excluded.database_name,
+			username = excluded.username,
+			password = excluded.password,
+			sslmode = excluded.sslmode,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.Exec(
+		query,
+		database.ID,
+		database.Name,
+		database.Host,
+		database.Port,
+		database.Database,
+		database.User,
+		database.Password,
+		database.SSLMode,
+		database.CreatedAt,
+		database.UpdatedAt,
+	)
+
+	if err != nil {
+		if strings.Contains(err.Error(), "UNIQUE constraint failed: databases.name") {
+			return ErrDatabaseNameExists
+		}
+		return fmt.Errorf("failed to save database: %w", err)
+	}
+
+	return nil
+}
+
+// GetDatabase retrieves an external database by ID
+func (s *SQLiteStorage) GetDatabase(id string) (*supabase.ExternalDatabase, error) {
+	query := `
+		SELECT id, name, host, port, database_name, username, password,
+		       sslmode, created_at, updated_at
+		FROM databases
+		WHERE id = ?
+	`
+
+	var database supabase.ExternalDatabase
+	err := s.db.QueryRow(query, id).Scan(
+		&database.ID,
+		&database.Name,
+		&database.Host,
+		&database.Port,
+		&database.Database,
+		&database.User,
+		&database.Password,
+		&database.SSLMode,
+		&database.CreatedAt,
+		&database.UpdatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("database not found")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get database: %w", err)
+	}
+
+	return &database, nil
+}
+
+// ListDatabases returns all registered external databases
+func (s *SQLiteStorage) ListDatabases() ([]*supabase.ExternalDatabase, error) {
+	query := `
+		SELECT id, name, host, port, database_name, username, password,
+		       sslmode, created_at, updated_at
+		FROM databases
+		ORDER BY created_at DESC
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list databases: %w", err)
+	}
+	defer rows.Close()
+
+	var databases []*supabase.ExternalDatabase
+	for rows.Next() {
+		var database supabase.ExternalDatabase
+		err := rows.Scan(
+			&database.ID,
+			&database.Name,
+			&database.Host,
+			&database.Port,
+			&database.Database,
+			&database.User,
+			&database.Password,
+			&database.SSLMode,
+			&database.CreatedAt,
+			&database.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan database: %w", err)
+		}
+		databases = append(databases, &database)
+	}
+
+	return databases, nil
+}
+
+// DeleteDatabase removes an external database registration
+func (s *SQLiteStorage) DeleteDatabase(id string) error {
+	result, err := s.db.Exec(`DELETE FROM databases WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete database: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("database not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,135 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// deletedProjectColumns is the column list shared by deleted project queries
+const deletedProjectColumns = `id, project_ref, name, region, last_status, organization_id, labels, created_at, deleted_at`
+
+// projectArtifacts lists the tables holding per-project rows, and their
+// project column, removed when a deleted project is purged. Audit events are
+// kept.
+var projectArtifacts = []struct {
+	table  string
+	column string
+}{
+	{"migrations", "target_id"},
+	{"migration_roles", "target_id"},
+	{"tenants", "project_id"},
+	{"share_links", "project_id"},
+	{"project_names", "project_id"},
+	{"project_setup", "project_id"},
+	{"project_health", "project_id"},
+	{"key_usage", "project_id"},
+	{"project_plans", "project_id"},
+	{"freeze_windows", "project_id"},
+}
+
+// scanDeletedProject reads a row selected with deletedProjectColumns
+func scanDeletedProject(row rowScanner) (*supabase.DeletedProject, error) {
+	var project supabase.DeletedProject
+	var labels string
+	err := row.Scan(
+		&project.ID,
+		&project.ProjectRef,
+		&project.Name,
+		&project.Region,
+		&project.LastStatus,
+		&project.OrganizationID,
+		&labels,
+		&project.CreatedAt,
+		&project.DeletedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if project.Labels, err = decodeLabels(labels); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// ListDeletedProjects returns deleted projects not yet purged, most
+// recently deleted first
+func (s *SQLiteStorage) ListDeletedProjects() ([]*supabase.DeletedProject, error) {
+	return s.queryDeletedProjects("SELECT " + deletedProjectColumns + " FROM deleted_projects ORDER BY deleted_at DESC")
+}
+
+// GetDeletedProject returns a deleted project not yet purged
+func (s *SQLiteStorage) GetDeletedProject(id string) (*supabase.DeletedProject, error) {
+	project, err := scanDeletedProject(s.db.QueryRow("SELECT "+deletedProjectColumns+" FROM deleted_projects WHERE id = ?", id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("deleted project not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get deleted project: %w", err)
+	}
+	return project, nil
+}
+
+// PurgeDeletedProjects permanently removes projects deleted before the
+// given time, along with their migration history, migration role, tenants,
+// share links and former names. It returns the purged projects.
+func (s *SQLiteStorage) PurgeDeletedProjects(before time.Time) ([]*supabase.DeletedProject, error) {
+	expired, err := s.queryDeletedProjects(
+		"SELECT "+deletedProjectColumns+" FROM deleted_projects WHERE deleted_at < ? ORDER BY deleted_at", before)
+	if err != nil {
+		return nil, err
+	}
+
+	var purged []*supabase.DeletedProject
+	for _, project := range expired {
+		if err := s.purgeDeletedProject(project.ID); err != nil {
+			return purged, err
+		}
+		purged = append(purged, project)
+	}
+	return purged, nil
+}
+
+// purgeDeletedProject removes one deleted project and its artifacts
+func (s *SQLiteStorage) purgeDeletedProject(id string) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, artifact := range projectArtifacts {
+		query := fmt.Sprintf("DELETE FROM %s WHERE %s = ?", artifact.table, artifact.column)
+		if _, err := tx.Exec(query, id); err != nil {
+			return fmt.Errorf("failed to purge %s of project %s: %w", artifact.table, id, err)
+		}
+	}
+
+	if _, err := tx.Exec("DELETE FROM deleted_projects WHERE id = ?", id); err != nil {
+		return fmt.Errorf("failed to purge project %s: %w", id, err)
+	}
+
+	return tx.Commit()
+}
+
+// queryDeletedProjects runs a query selecting deletedProjectColumns
+func (s *SQLiteStorage) queryDeletedProjects(query string, args ...interface{}) ([]*supabase.DeletedProject, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list deleted projects: %w", err)
+	}
+	defer rows.Close()
+
+	projects := []*supabase.DeletedProject{}
+	for rows.Next() {
+		project, err := scanDeletedProject(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan deleted project: %w", err)
+		}
+		projects = append(projects, project)
+	}
+
+	return projects, rows.Err()
+}
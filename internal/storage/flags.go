@@ -0,0 +1,108 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"supabase-manager/internal/supabase"
+)
+
+// featureFlagColumns is the column list shared by feature flag queries
+const featureFlagColumns = `project_id, key, type, value, description, updated_by, updated_at`
+
+// scanFeatureFlag reads a flag selected with featureFlagColumns
+func scanFeatureFlag(row rowScanner) (*supabase.FeatureFlag, error) {
+	var flag supabase.FeatureFlag
+	var value string
+	err := row.Scan(
+		&flag.ProjectID,
+		&flag.Key,
+		&flag.Type,
+		&value,
+		&flag.Description,
+		&flag.UpdatedBy,
+		&flag.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	flag.Value = []byte(value)
+	return &flag, nil
+}
+
+// SaveFeatureFlag creates or replaces a project's flag
+func (s *SQLiteStorage) SaveFeatureFlag(flag *supabase.FeatureFlag) error {
+	query := `
+		INSERT INTO feature_flags (project_id, key, type, value, description, updated_by, updated_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT (project_id, key) DO UPDATE SET
+			type = excluded.type,
+			value = excluded.value,
+			description = excluded.description,
+			updated_by = excluded.updated_by,
+			updated_at = excluded.updated_at
+	`
+
+	_, err := s.db.Exec(query,
+		flag.ProjectID,
+		flag.Key,
+		flag.Type,
+		string(flag.Value),
+		flag.Description,
+		flag.UpdatedBy,
+		flag.UpdatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save feature flag: %w", err)
+	}
+	return nil
+}
+
+// GetFeatureFlag returns a project's flag by key
+func (s *SQLiteStorage) GetFeatureFlag(projectID, key string) (*supabase.FeatureFlag, error) {
+	row := s.db.QueryRow(`SELECT `+featureFlagColumns+` FROM feature_flags WHERE project_id = ? AND key = ?`, projectID, key)
+	flag, err := scanFeatureFlag(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("feature flag not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get feature flag: %w", err)
+	}
+	return flag, nil
+}
+
+// ListFeatureFlags returns a project's flags ordered by key
+func (s *SQLiteStorage) ListFeatureFlags(projectID string) ([]*supabase.FeatureFlag, error) {
+	rows, err := s.db.Query(`SELECT `+featureFlagColumns+` FROM feature_flags WHERE project_id = ? ORDER BY key`, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list feature flags: %w", err)
+	}
+	defer rows.Close()
+
+	flags := []*supabase.FeatureFlag{}
+	for rows.Next() {
+		flag, err := scanFeatureFlag(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan feature flag: %w", err)
+		}
+		flags = append(flags, flag)
+	}
+	return flags, rows.Err()
+}
+
+// DeleteFeatureFlag removes a project's flag
+func (s *SQLiteStorage) DeleteFeatureFlag(projectID, key string) error {
+	result, err := s.db.Exec(`DELETE FROM feature_flags WHERE project_id = ? AND key = ?`, projectID, key)
+	if err != nil {
+		return fmt.Errorf("failed to delete feature flag: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("feature flag not found")
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"supabase-manager/internal/supabase"
+)
+
+// freezeWindowColumns is the column list shared by freeze window queries
+const freezeWindowColumns = `id, project_id, label_selector, starts_at, ends_at, schedule, duration,
+	time_zone, operations, reason, created_by, created_at`
+
+// scanFreezeWindow reads a freeze window selected with freezeWindowColumns
+func scanFreezeWindow(row rowScanner) (*supabase.FreezeWindow, error) {
+	var window supabase.FreezeWindow
+	var startsAt, endsAt sql.NullTime
+	var operations string
+	err := row.Scan(
+		&window.ID,
+		&window.ProjectID,
+		&window.LabelSelector,
+		&startsAt,
+		&endsAt,
+		&window.Schedule,
+		&window.Duration,
+		&window.TimeZone,
+		&operations,
+		&window.Reason,
+		&window.CreatedBy,
+		&window.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if startsAt.Valid {
+		window.StartsAt = &startsAt.Time
+	}
+	if endsAt.Valid {
+		window.EndsAt = &endsAt.Time
+	}
+	window.Operations = []string{}
+	if operations != "" {
+		window.Operations = strings.Split(operations, ",")
+	}
+	return &window, nil
+}
+
+// SaveFreezeWindow stores a new freeze window
+func (s *SQLiteStorage) SaveFreezeWindow(window *supabase.FreezeWindow) error {
+	query := `
+		INSERT INTO freeze_windows (id, project_id, label_selector, starts_at, ends_at, schedule, duration,
+			time_zone, operations, reason, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		window.ID,
+		window.ProjectID,
+		window.LabelSelector,
+		window.StartsAt,
+		window.EndsAt,
+		window.Schedule,
+		window.Duration,
+		window.TimeZone,
+		strings.Join(window.Operations, ","),
+		window.Reason,
+		window.CreatedBy,
+		window.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save freeze window: %w", err)
+	}
+	return nil
+}
+
+// GetFreezeWindow returns a freeze window by ID
+func (s *SQLiteStorage) GetFreezeWindow(id string) (*supabase.FreezeWindow, error) {
+	row := s.db.QueryRow(`SELECT `+freezeWindowColumns+` FROM freeze_windows WHERE id = ?`, id)
+	window, err := scanFreezeWindow(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("freeze window not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get freeze window: %w", err)
+	}
+	return window, nil
+}
+
+// ListFreezeWindows returns all freeze windows, oldest first
+func (s *SQLiteStorage) ListFreezeWindows() ([]*supabase.FreezeWindow, error) {
+	rows, err := s.db.Query(`SELECT ` + freezeWindowColumns + ` FROM freeze_windows ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list freeze windows: %w", err)
+	}
+	defer rows.Close()
+
+	var windows []*supabase.FreezeWindow
+	for rows.Next() {
+		window, err := scanFreezeWindow(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan freeze window: %w", err)
+		}
+		windows = append(windows, window)
+	}
+	return windows, rows.Err()
+}
+
+// DeleteFreezeWindow removes a freeze window
+func (s *SQLiteStorage) DeleteFreezeWindow(id string) error {
+	result, err := s.db.Exec(`DELETE FROM freeze_windows WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete freeze window: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("freeze window not found")
+	}
+	return nil
+}
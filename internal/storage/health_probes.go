@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"supabase-manager/internal/supabase"
+)
+
+// projectHealthColumns is the column list shared by project health queries
+const projectHealthColumns = `project_id, reachable, checks, failures, last_checked_at, last_failure_at, last_failure, unreachable_since`
+
+// scanProjectHealth reads a row selected with projectHealthColumns
+func scanProjectHealth(row rowScanner) (*supabase.ProjectHealth, error) {
+	var health supabase.ProjectHealth
+	var lastFailureAt, unreachableSince sql.NullTime
+	err := row.Scan(
+		&health.ProjectID,
+		&health.Reachable,
+		&health.Checks,
+		&health.Failures,
+		&health.LastCheckedAt,
+		&lastFailureAt,
+		&health.LastFailure,
+		&unreachableSince,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if lastFailureAt.Valid {
+		health.LastFailureAt = &lastFailureAt.Time
+	}
+	if unreachableSince.Valid {
+		health.UnreachableSince = &unreachableSince.Time
+	}
+	health.SetUptime()
+	return &health, nil
+}
+
+// SaveProjectHealth stores the probe outcome of a project, replacing the
+// earlier one
+func (s *SQLiteStorage) SaveProjectHealth(health *supabase.ProjectHealth) error {
+	query := `
+		INSERT INTO project_health (` + projectHealthColumns + `)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(project_id) DO UPDATE SET
+			reachable = excluded.reachable,
+			checks = excluded.checks,
+			failures = excluded.failures,
+			last_checked_at = excluded.last_checked_at,
+			last_failure_at = excluded.last_failure_at,
+			last_failure = excluded.last_failure,
+			unreachable_since = excluded.unreachable_since
+	`
+
+	_, err := s.db.Exec(query,
+		health.ProjectID,
+		health.Reachable,
+		health.Checks,
+		health.Failures,
+		health.LastCheckedAt,
+		health.LastFailureAt,
+		health.LastFailure,
+		health.UnreachableSince,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save project health: %w", err)
+	}
+	return nil
+}
+
+// GetProjectHealth returns the probe outcome of a project
+func (s *SQLiteStorage) GetProjectHealth(projectID string) (*supabase.ProjectHealth, error) {
+	query := `SELECT ` + projectHealthColumns + ` FROM project_health WHERE project_id = ?`
+
+	health, err := scanProjectHealth(s.db.QueryRow(query, projectID))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("project health not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get project health: %w", err)
+	}
+	return health, nil
+}
+
+// ListProjectHealth returns the probe outcomes of all probed projects keyed
+// by project ID
+func (s *SQLiteStorage) ListProjectHealth() (map[string]*supabase.ProjectHealth, error) {
+	rows, err := s.db.Query(`SELECT ` + projectHealthColumns + ` FROM project_health`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list project health: %w", err)
+	}
+	defer rows.Close()
+
+	healths := make(map[string]*supabase.ProjectHealth)
+	for rows.Next() {
+		health, err := scanProjectHealth(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan project health: %w", err)
+		}
+		healths[health.ProjectID] = health
+	}
+
+	return healths, rows.Err()
+}
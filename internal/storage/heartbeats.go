@@ -0,0 +1,22 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// RecordHeartbeat stores the latest heartbeat of a manager instance
+func (s *SQLiteStorage) RecordHeartbeat(instanceID, hostname string, startedAt, beatAt time.Time) error {
+	query := `
+	INSERT INTO heartbeats (instance_id, hostname, started_at, last_beat_at)
+	VALUES (?, ?, ?, ?)
+	ON CONFLICT(instance_id) DO UPDATE SET
+		last_beat_at = excluded.last_beat_at
+	`
+
+	if _, err := s.db.Exec(query, instanceID, hostname, startedAt, beatAt); err != nil {
+		return fmt.Errorf("failed to record heartbeat: %w", err)
+	}
+
+	return nil
+}
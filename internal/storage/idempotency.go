@@ -0,0 +1,77 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// IdempotencyRecord is a request made with an Idempotency-Key. StatusCode is
+// 0 while the first request with the key is still running.
+type IdempotencyRecord struct {
+	Fingerprint string
+	StatusCode  int
+	ContentType string
+	Body        []byte
+}
+
+// ClaimIdempotencyKey records a request's idempotency key in its scope
+// until expiresAt, the lease of the running request. It returns nil if the
+// key was new, so the request should run, or the record of the earlier
+// request with the key. Expired keys are purged first.
+func (s *SQLiteStorage) ClaimIdempotencyKey(scope, key, fingerprint string, expiresAt, now time.Time) (*IdempotencyRecord, error) {
+	if _, err := s.db.Exec("DELETE FROM idempotency_keys WHERE expires_at <= ?", now); err != nil {
+		return nil, fmt.Errorf("failed to purge idempotency keys: %w", err)
+	}
+
+	result, err := s.db.Exec(
+		"INSERT INTO idempotency_keys (scope, key, fingerprint, expires_at) VALUES (?, ?, ?, ?) ON CONFLICT(scope, key) DO NOTHING",
+		scope, key, fingerprint, expiresAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record idempotency key: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 1 {
+		return nil, nil
+	}
+
+	record := &IdempotencyRecord{}
+	err = s.db.QueryRow(
+		"SELECT fingerprint, status_code, content_type, body FROM idempotency_keys WHERE scope = ? AND key = ?",
+		scope, key,
+	).Scan(&record.Fingerprint, &record.StatusCode, &record.ContentType, &record.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		// Purged between the insert and the read; claim it again
+		return s.ClaimIdempotencyKey(scope, key, fingerprint, expiresAt, now)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get idempotency key: %w", err)
+	}
+	return record, nil
+}
+
+// CompleteIdempotencyKey stores the response of the request that claimed a
+// key, so retries with the key get it back until expiresAt
+func (s *SQLiteStorage) CompleteIdempotencyKey(scope, key string, statusCode int, contentType string, body []byte, expiresAt time.Time) error {
+	_, err := s.db.Exec(
+		"UPDATE idempotency_keys SET status_code = ?, content_type = ?, body = ?, expires_at = ? WHERE scope = ? AND key = ?",
+		statusCode, contentType, body, expiresAt, scope, key)
+	if err != nil {
+		return fmt.Errorf("failed to store idempotent response: %w", err)
+	}
+	return nil
+}
+
+// ReleaseIdempotencyKey forgets a key whose request failed in a way worth
+// retrying, so the next request with it runs again
+func (s *SQLiteStorage) ReleaseIdempotencyKey(scope, key string) error {
+	if _, err := s.db.Exec("DELETE FROM idempotency_keys WHERE scope = ? AND key = ?", scope, key); err != nil {
+		return fmt.Errorf("failed to release idempotency key: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,178 @@
+package storage
+
+import (
+	"database/sql"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// QueryObserver receives the outcome of every storage query, for metrics
+type QueryObserver interface {
+	// ObserveQuery is called once per query with the operation name (such
+	// as "select_projects"), the time the query took, the rows it returned
+	// or affected and its error, if any
+	ObserveQuery(operation string, duration time.Duration, rows int64, err error)
+}
+
+// SetQueryObserver registers the observer for all subsequent queries. It
+// must be called before the storage is used concurrently.
+func (s *SQLiteStorage) SetQueryObserver(observer QueryObserver) {
+	s.db.observer = observer
+}
+
+// instrumentedDB wraps the SQLite handle and reports every query to the
+// observer. Times passed as arguments are stored in timestampFormat.
+type instrumentedDB struct {
+	*sql.DB
+	observer QueryObserver
+}
+
+// observe reports a finished query
+func (db *instrumentedDB) observe(query string, duration time.Duration, rows int64, err error) {
+	if db.observer != nil {
+		db.observer.ObserveQuery(operationName(query), duration, rows, err)
+	}
+}
+
+// Exec runs a statement and reports its affected rows
+func (db *instrumentedDB) Exec(query string, args ...interface{}) (sql.Result, error) {
+	start := time.Now()
+	result, err := db.DB.Exec(query, normalizeArgs(args)...)
+
+	var rows int64
+	if err == nil {
+		rows, _ = result.RowsAffected()
+	}
+	db.observe(query, time.Since(start), rows, err)
+
+	return result, err
+}
+
+// Query runs a query. The rows read are reported when the result is closed.
+func (db *instrumentedDB) Query(query string, args ...interface{}) (*instrumentedRows, error) {
+	start := time.Now()
+	rows, err := db.DB.Query(query, normalizeArgs(args)...)
+	duration := time.Since(start)
+
+	if err != nil {
+		db.observe(query, duration, 0, err)
+		return nil, err
+	}
+	return &instrumentedRows{Rows: rows, db: db, query: query, duration: duration}, nil
+}
+
+// QueryRow runs a single-row query. It is reported when the row is scanned.
+func (db *instrumentedDB) QueryRow(query string, args ...interface{}) *instrumentedRow {
+	start := time.Now()
+	row := db.DB.QueryRow(query, normalizeArgs(args)...)
+	return &instrumentedRow{Row: row, db: db, query: query, duration: time.Since(start)}
+}
+
+// Begin starts a transaction
+func (db *instrumentedDB) Begin() (*normalizedTx, error) {
+	tx, err := db.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	return &normalizedTx{Tx: tx}, nil
+}
+
+// normalizedTx is a transaction that stores the times passed as arguments
+// in timestampFormat, like instrumentedDB
+type normalizedTx struct {
+	*sql.Tx
+}
+
+// Exec runs a statement in the transaction
+func (tx *normalizedTx) Exec(query string, args ...interface{}) (sql.Result, error) {
+	return tx.Tx.Exec(query, normalizeArgs(args)...)
+}
+
+// Query runs a query in the transaction
+func (tx *normalizedTx) Query(query string, args ...interface{}) (*sql.Rows, error) {
+	return tx.Tx.Query(query, normalizeArgs(args)...)
+}
+
+// QueryRow runs a single-row query in the transaction
+func (tx *normalizedTx) QueryRow(query string, args ...interface{}) *sql.Row {
+	return tx.Tx.QueryRow(query, normalizeArgs(args)...)
+}
+
+// instrumentedRows counts the rows read from a query
+type instrumentedRows struct {
+	*sql.Rows
+	db       *instrumentedDB
+	query    string
+	duration time.Duration
+	count    int64
+	closed   bool
+}
+
+// Next advances to the next row, counting it
+func (r *instrumentedRows) Next() bool {
+	if r.Rows.Next() {
+		r.count++
+		return true
+	}
+	return false
+}
+
+// Close closes the rows and reports the query
+func (r *instrumentedRows) Close() error {
+	err := r.Rows.Close()
+	if !r.closed {
+		r.closed = true
+		queryErr := r.Rows.Err()
+		if queryErr == nil {
+			queryErr = err
+		}
+		r.db.observe(r.query, r.duration, r.count, queryErr)
+	}
+	return err
+}
+
+// instrumentedRow reports a single-row query when it is scanned
+type instrumentedRow struct {
+	*sql.Row
+	db       *instrumentedDB
+	query    string
+	duration time.Duration
+}
+
+// Scan copies the row into dest and reports the query. A missing row is not
+// counted as an error.
+func (r *instrumentedRow) Scan(dest ...interface{}) error {
+	err := r.Row.Scan(dest...)
+
+	switch err {
+	case nil:
+		r.db.observe(r.query, r.duration, 1, nil)
+	case sql.ErrNoRows:
+		r.db.observe(r.query, r.duration, 0, nil)
+	default:
+		r.db.observe(r.query, r.duration, 0, err)
+	}
+	return err
+}
+
+// operationTablePattern finds the table a statement works on
+var operationTablePattern = regexp.MustCompile(`(?i)\b(?:FROM|INTO|UPDATE|TABLE|ON)\s+"?([a-zA-Z_][a-zA-Z0-9_]*)"?`)
+
+// operationName names a query by its verb and table, such as
+// "select_projects" or "insert_migrations"
+func operationName(query string) string {
+	fields := strings.Fields(query)
+	if len(fields) == 0 {
+		return "unknown"
+	}
+
+	verb := strings.ToLower(fields[0])
+	if verb == "with" {
+		verb = "select"
+	}
+	if match := operationTablePattern.FindStringSubmatch(query); match != nil {
+		return verb + "_" + strings.ToLower(match[1])
+	}
+	return verb
+}
@@ -0,0 +1,208 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/pagination"
+	"supabase-manager/internal/supabase"
+)
+
+// SaveJob stores a job, updating its status, result and timestamps if it
+// already exists
+func (s *SQLiteStorage) SaveJob(job *supabase.Job) error {
+	query := `
+		INSERT INTO jobs (id, type, status, created_by, result, error, created_at, started_at, completed_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			status = excluded.status,
+			result = excluded.result,
+			error = excluded.error,
+			started_at = excluded.started_at,
+			completed_at = excluded.completed_at
+	`
+
+	_, err := s.db.Exec(query,
+		job.ID,
+		job.Type,
+		job.Status,
+		job.CreatedBy,
+		string(job.Result),
+		job.Error,
+		job.CreatedAt,
+		job.StartedAt,
+		job.CompletedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save job: %w", err)
+	}
+
+	return nil
+}
+
+// jobColumns is the column list shared by job queries
+const jobColumns = "id, type, status, created_by, result, error, created_at, started_at, completed_at"
+
+// scanJob scans a row selected with jobColumns, after any leading
+// destinations
+func scanJob(row rowScanner, leading ...interface{}) (*supabase.Job, error) {
+	var job supabase.Job
+	var result string
+	var startedAt, completedAt sql.NullTime
+	dest := append(leading,
+		&job.ID,
+		&job.Type,
+		&job.Status,
+		&job.CreatedBy,
+		&result,
+		&job.Error,
+		&job.CreatedAt,
+		&startedAt,
+		&completedAt,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+
+	if result != "" {
+		job.Result = []byte(result)
+	}
+	if startedAt.Valid {
+		job.StartedAt = &startedAt.Time
+	}
+	if completedAt.Valid {
+		job.CompletedAt = &completedAt.Time
+	}
+
+	return &job, nil
+}
+
+// GetJob retrieves a job by ID
+func (s *SQLiteStorage) GetJob(id string) (*supabase.Job, error) {
+	query := "SELECT " + jobColumns + " FROM jobs WHERE id = ?"
+
+	job, err := scanJob(s.db.QueryRow(query, id))
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("job not found")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get job: %w", err)
+	}
+
+	return job, nil
+}
+
+// JobFilter narrows a job listing; empty fields match every job
+type JobFilter struct {
+	Type      string
+	Status    string
+	CreatedBy string
+	// Since and Until bound the creation time of the jobs
+	Since *time.Time
+	Until *time.Time
+}
+
+// ListJobs returns a page of the jobs matching filter, newest first
+func (s *SQLiteStorage) ListJobs(filter JobFilter, req pagination.Request) ([]*supabase.Job, pagination.Page, error) {
+	where := "1 = 1"
+	var args []interface{}
+	if filter.Type != "" {
+		where += " AND type = ?"
+		args = append(args, filter.Type)
+	}
+	if filter.Status != "" {
+		where += " AND status = ?"
+		args = append(args, filter.Status)
+	}
+	if filter.CreatedBy != "" {
+		where += " AND created_by = ?"
+		args = append(args, filter.CreatedBy)
+	}
+	if filter.Since != nil {
+		where += " AND created_at >= ?"
+		args = append(args, *filter.Since)
+	}
+	if filter.Until != nil {
+		where += " AND created_at < ?"
+		args = append(args, *filter.Until)
+	}
+
+	return listPage(s, "jobs", jobColumns, where, args, req,
+		func(row rowScanner, position *int64) (*supabase.Job, error) {
+			return scanJob(row, position)
+		})
+}
+
+// FailInterruptedJobs marks jobs left queued or running by a previous run as
+// failed, returning how many there were
+func (s *SQLiteStorage) FailInterruptedJobs() (int64, error) {
+	result, err := s.db.Exec(
+		"UPDATE jobs SET status = ?, error = ?, completed_at = ? WHERE status IN (?, ?)",
+		supabase.JobFailed, "interrupted by a restart of the manager", time.Now(),
+		supabase.JobQueued, supabase.JobRunning,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to fail interrupted jobs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// PurgeJobs deletes the jobs that finished before the given time, with
+// their results and logs, returning how many there were. Queued and
+// running jobs are kept.
+func (s *SQLiteStorage) PurgeJobs(before time.Time) (int64, error) {
+	result, err := s.db.Exec(
+		"DELETE FROM jobs WHERE status IN (?, ?) AND completed_at < ?",
+		supabase.JobSucceeded, supabase.JobFailed, before,
+	)
+	if err != nil {
+		return 0, fmt.Errorf("failed to purge jobs: %w", err)
+	}
+
+	if _, err := s.db.Exec("DELETE FROM job_logs WHERE job_id NOT IN (SELECT id FROM jobs)"); err != nil {
+		return 0, fmt.Errorf("failed to purge job logs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
+
+// AppendJobLog adds a line to a job's log
+func (s *SQLiteStorage) AppendJobLog(line *supabase.JobLogLine) error {
+	result, err := s.db.Exec(
+		"INSERT INTO job_logs (job_id, message, created_at) VALUES (?, ?, ?)",
+		line.JobID, line.Message, line.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to append job log: %w", err)
+	}
+
+	line.ID, err = result.LastInsertId()
+	return err
+}
+
+// ListJobLogs returns up to limit lines of a job's log after the line with
+// the given ID, oldest first
+func (s *SQLiteStorage) ListJobLogs(jobID string, after int64, limit int) ([]supabase.JobLogLine, error) {
+	rows, err := s.db.Query(
+		"SELECT id, job_id, message, created_at FROM job_logs WHERE job_id = ? AND id > ? ORDER BY id LIMIT ?",
+		jobID, after, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job logs: %w", err)
+	}
+	defer rows.Close()
+
+	lines := []supabase.JobLogLine{}
+	for rows.Next() {
+		var line supabase.JobLogLine
+		if err := rows.Scan(&line.ID, &line.JobID, &line.Message, &line.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan job log: %w", err)
+		}
+		lines = append(lines, line)
+	}
+
+	return lines, rows.Err()
+}
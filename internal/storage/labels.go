@@ -0,0 +1,53 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// encodeLabels renders project labels for the labels column
+func encodeLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "{}"
+	}
+	data, err := json.Marshal(labels)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// decodeLabels parses the labels column
+func decodeLabels(data string) (map[string]string, error) {
+	if data == "" || data == "{}" {
+		return nil, nil
+	}
+	var labels map[string]string
+	if err := json.Unmarshal([]byte(data), &labels); err != nil {
+		return nil, fmt.Errorf("failed to decode labels: %w", err)
+	}
+	return labels, nil
+}
+
+// SetProjectLabels replaces a project's labels
+func (s *SQLiteStorage) SetProjectLabels(id string, labels map[string]string) error {
+	result, err := s.db.Exec(
+		"UPDATE projects SET labels = ?, updated_at = ? WHERE id = ?",
+		encodeLabels(labels), time.Now(), id,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to update labels: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("project not found")
+	}
+
+	return nil
+}
@@ -0,0 +1,264 @@
+package storage
+
+import (
+	"bytes"
+	"compress/gzip"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"supabase-manager/internal/pagination"
+	"supabase-manager/internal/supabase"
+)
+
+// SaveMigration records an applied (or failed) migration in the history
+func (s *SQLiteStorage) SaveMigration(record *supabase.MigrationRecord) error {
+	sqlGz, err := compressSQL(record.SQL)
+	if err != nil {
+		return fmt.Errorf("failed to compress migration SQL: %w", err)
+	}
+
+	query := `
+		INSERT INTO migrations (
+			id, target_id, version, name, description, author, ticket, role,
+			success, statements_run, execution_time_ms, error, applied_at, sql_gz,
+			source, source_sha256, imported
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err = s.db.Exec(
+		query,
+		record.ID,
+		record.TargetID,
+		record.Version,
+		record.Name,
+		record.Description,
+		record.Author,
+		record.Ticket,
+		record.Role,
+		record.Success,
+		record.StatementsRun,
+		record.ExecutionTimeMs,
+		record.Error,
+		record.AppliedAt,
+		sqlGz,
+		record.Source,
+		record.SourceSHA256,
+		record.Imported,
+	)
+
+	if err != nil {
+		return fmt.Errorf("failed to save migration: %w", err)
+	}
+
+	return nil
+}
+
+// migrationColumns is the column list of migration history queries, which
+// leave out the SQL
+const migrationColumns = `id, target_id, version, name, description, author, ticket, role,
+		       success, statements_run, execution_time_ms, error, applied_at,
+		       source, source_sha256, imported`
+
+// scanMigration scans a row selected with migrationColumns, after any
+// leading destinations
+func scanMigration(row rowScanner, leading ...interface{}) (*supabase.MigrationRecord, error) {
+	var record supabase.MigrationRecord
+	dest := append(leading,
+		&record.ID,
+		&record.TargetID,
+		&record.Version,
+		&record.Name,
+		&record.Description,
+		&record.Author,
+		&record.Ticket,
+		&record.Role,
+		&record.Success,
+		&record.StatementsRun,
+		&record.ExecutionTimeMs,
+		&record.Error,
+		&record.AppliedAt,
+		&record.Source,
+		&record.SourceSHA256,
+		&record.Imported,
+	)
+	if err := row.Scan(dest...); err != nil {
+		return nil, err
+	}
+	return &record, nil
+}
+
+// ListMigrations returns the migration history of a project or database,
+// newest first
+func (s *SQLiteStorage) ListMigrations(targetID string) ([]*supabase.MigrationRecord, error) {
+	query := `
+		SELECT ` + migrationColumns + `
+		FROM migrations
+		WHERE target_id = ?
+		ORDER BY applied_at DESC
+	`
+
+	rows, err := s.db.Query(query, targetID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list migrations: %w", err)
+	}
+	defer rows.Close()
+
+	var records []*supabase.MigrationRecord
+	for rows.Next() {
+		record, err := scanMigration(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan migration: %w", err)
+		}
+		records = append(records, record)
+	}
+
+	return records, nil
+}
+
+// ListMigrationsPage returns a page of the migration history of a project
+// or database, most recently recorded first
+func (s *SQLiteStorage) ListMigrationsPage(targetID string, req pagination.Request) ([]*supabase.MigrationRecord, pagination.Page, error) {
+	return listPage(s, "migrations", migrationColumns, "target_id = ?", []interface{}{targetID}, req,
+		func(row rowScanner, position *int64) (*supabase.MigrationRecord, error) {
+			return scanMigration(row, position)
+		})
+}
+
+// GetMigration returns the latest migration of a target with the given
+// version, including its SQL. SQL is empty for migrations recorded before
+// scripts were stored.
+func (s *SQLiteStorage) GetMigration(targetID, version string) (*supabase.MigrationRecord, error) {
+	query := `
+		SELECT id, target_id, version, name, description, author, ticket, role,
+		       success, statements_run, execution_time_ms, error, applied_at, sql_gz,
+		       source, source_sha256, imported
+		FROM migrations
+		WHERE target_id = ? AND version = ?
+		ORDER BY applied_at DESC
+		LIMIT 1
+	`
+
+	var record supabase.MigrationRecord
+	var sqlGz []byte
+	err := s.db.QueryRow(query, targetID, version).Scan(
+		&record.ID,
+		&record.TargetID,
+		&record.Version,
+		&record.Name,
+		&record.Description,
+		&record.Author,
+		&record.Ticket,
+		&record.Role,
+		&record.Success,
+		&record.StatementsRun,
+		&record.ExecutionTimeMs,
+		&record.Error,
+		&record.AppliedAt,
+		&sqlGz,
+		&record.Source,
+		&record.SourceSHA256,
+		&record.Imported,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("migration not found")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration: %w", err)
+	}
+
+	record.SQL, err = decompressSQL(sqlGz)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decompress migration SQL: %w", err)
+	}
+
+	return &record, nil
+}
+
+// compressSQL gzips a migration script. An empty script is stored as NULL.
+func compressSQL(script string) ([]byte, error) {
+	if script == "" {
+		return nil, nil
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	if _, err := zw.Write([]byte(script)); err != nil {
+		return nil, err
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// decompressSQL reverses compressSQL
+func decompressSQL(data []byte) (string, error) {
+	if len(data) == 0 {
+		return "", nil
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return "", err
+	}
+	defer zr.Close()
+
+	script, err := io.ReadAll(zr)
+	if err != nil {
+		return "", err
+	}
+	return string(script), nil
+}
+
+// GetMigrationRoles returns the roles migrations against a target may run
+// as. A target without a mapping gets an empty set.
+func (s *SQLiteStorage) GetMigrationRoles(targetID string) (*supabase.MigrationRoles, error) {
+	query := `
+		SELECT roles, default_role, updated_at
+		FROM migration_roles
+		WHERE target_id = ?
+	`
+
+	roles := &supabase.MigrationRoles{TargetID: targetID, Roles: []string{}}
+	var rolesJSON string
+	err := s.db.QueryRow(query, targetID).Scan(&rolesJSON, &roles.DefaultRole, &roles.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return roles, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get migration roles: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(rolesJSON), &roles.Roles); err != nil {
+		return nil, fmt.Errorf("failed to decode migration roles: %w", err)
+	}
+
+	return roles, nil
+}
+
+// SaveMigrationRoles replaces the migration roles of a target
+func (s *SQLiteStorage) SaveMigrationRoles(roles *supabase.MigrationRoles) error {
+	rolesJSON, err := json.Marshal(roles.Roles)
+	if err != nil {
+		return fmt.Errorf("failed to encode migration roles: %w", err)
+	}
+
+	query := `
+		INSERT INTO migration_roles (target_id, roles, default_role, updated_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(target_id) DO UPDATE SET
+			roles = excluded.roles,
+			default_role = excluded.default_role,
+			updated_at = excluded.updated_at
+	`
+
+	if _, err := s.db.Exec(query, roles.TargetID, string(rolesJSON), roles.DefaultRole, roles.UpdatedAt); err != nil {
+		return fmt.Errorf("failed to save migration roles: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,86 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// RenameProject changes a project's name, keeping the old name and its slug
+// in the project's name history. It returns the old name.
+func (s *SQLiteStorage) RenameProject(id, name string) (string, error) {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return "", fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	var oldName string
+	err = tx.QueryRow("SELECT name FROM projects WHERE id = ?", id).Scan(&oldName)
+	if err == sql.ErrNoRows {
+		return "", fmt.Errorf("project not found")
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project: %w", err)
+	}
+
+	now := time.Now()
+	if _, err := tx.Exec("UPDATE projects SET name = ?, updated_at = ? WHERE id = ?", name, now, id); err != nil {
+		return "", fmt.Errorf("failed to rename project: %w", err)
+	}
+
+	if oldName != "" {
+		_, err = tx.Exec(
+			"INSERT INTO project_names (project_id, name, slug, replaced_at) VALUES (?, ?, ?, ?)",
+			id, oldName, supabase.Slugify(oldName), now,
+		)
+		if err != nil {
+			return "", fmt.Errorf("failed to record former name: %w", err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("failed to rename project: %w", err)
+	}
+	return oldName, nil
+}
+
+// ListProjectNames returns the names a project went by, most recent first
+func (s *SQLiteStorage) ListProjectNames(id string) ([]supabase.ProjectName, error) {
+	rows, err := s.db.Query(
+		"SELECT name, slug, replaced_at FROM project_names WHERE project_id = ? ORDER BY replaced_at DESC", id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list former names: %w", err)
+	}
+	defer rows.Close()
+
+	names := []supabase.ProjectName{}
+	for rows.Next() {
+		var name supabase.ProjectName
+		if err := rows.Scan(&name.Name, &name.Slug, &name.ReplacedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan former name: %w", err)
+		}
+		names = append(names, name)
+	}
+
+	return names, rows.Err()
+}
+
+// FindProjectIDByFormerName returns the project that most recently went by
+// a name or slug, or "" if none did
+func (s *SQLiteStorage) FindProjectIDByFormerName(nameOrSlug string) (string, error) {
+	var id string
+	err := s.db.QueryRow(
+		"SELECT project_id FROM project_names WHERE name = ? OR slug = ? ORDER BY replaced_at DESC LIMIT 1",
+		nameOrSlug, nameOrSlug,
+	).Scan(&id)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to look up former name: %w", err)
+	}
+	return id, nil
+}
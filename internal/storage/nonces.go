@@ -0,0 +1,29 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+)
+
+// ClaimNonce records the nonce of a signed inbound request until expiresAt
+// and reports whether it was new in its scope. A nonce seen before is a
+// replay. Expired nonces are purged first, so the table only holds those
+// still inside their request's timestamp tolerance.
+func (s *SQLiteStorage) ClaimNonce(scope, nonce string, expiresAt, now time.Time) (bool, error) {
+	if _, err := s.db.Exec("DELETE FROM request_nonces WHERE expires_at <= ?", now); err != nil {
+		return false, fmt.Errorf("failed to purge nonces: %w", err)
+	}
+
+	result, err := s.db.Exec(
+		"INSERT INTO request_nonces (scope, nonce, expires_at) VALUES (?, ?, ?) ON CONFLICT(scope, nonce) DO NOTHING",
+		scope, nonce, expiresAt)
+	if err != nil {
+		return false, fmt.Errorf("failed to record nonce: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return false, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	return rows == 1, nil
+}
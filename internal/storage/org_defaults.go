@@ -0,0 +1,59 @@
+package storage
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// GetOrgDefaults returns the stored organization defaults, empty if none
+// were ever saved
+func (s *SQLiteStorage) GetOrgDefaults() (*supabase.OrgDefaults, error) {
+	var settings string
+	var updatedAt time.Time
+	defaults := &supabase.OrgDefaults{}
+	err := s.db.QueryRow(`SELECT settings, updated_at, updated_by FROM org_defaults WHERE id = 1`).
+		Scan(&settings, &updatedAt, &defaults.UpdatedBy)
+	if err == sql.ErrNoRows {
+		return defaults, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get organization defaults: %w", err)
+	}
+
+	if err := json.Unmarshal([]byte(settings), defaults); err != nil {
+		return nil, fmt.Errorf("failed to decode organization defaults: %w", err)
+	}
+	defaults.UpdatedAt = &updatedAt
+	return defaults, nil
+}
+
+// SaveOrgDefaults replaces the stored organization defaults
+func (s *SQLiteStorage) SaveOrgDefaults(defaults *supabase.OrgDefaults) error {
+	settings, err := json.Marshal(supabase.OrgDefaults{
+		Region:           defaults.Region,
+		Plan:             defaults.Plan,
+		NamingPolicy:     defaults.NamingPolicy,
+		SecurityBaseline: defaults.SecurityBaseline,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to encode organization defaults: %w", err)
+	}
+
+	query := `
+		INSERT INTO org_defaults (id, settings, updated_at, updated_by)
+		VALUES (1, ?, ?, ?)
+		ON CONFLICT(id) DO UPDATE SET
+			settings = excluded.settings,
+			updated_at = excluded.updated_at,
+			updated_by = excluded.updated_by
+	`
+
+	if _, err := s.db.Exec(query, string(settings), defaults.UpdatedAt, defaults.UpdatedBy); err != nil {
+		return fmt.Errorf("failed to save organization defaults: %w", err)
+	}
+	return nil
+}
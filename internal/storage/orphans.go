@@ -0,0 +1,70 @@
+package storage
+
+import (
+	"fmt"
+
+	"supabase-manager/internal/supabase"
+)
+
+// IgnoreRemoteOrphan puts a remote project on the orphan ignore list,
+// replacing any earlier entry for it
+func (s *SQLiteStorage) IgnoreRemoteOrphan(ignore *supabase.OrphanIgnore) error {
+	query := `
+		INSERT INTO remote_orphan_ignores (project_ref, reason, ignored_by, created_at)
+		VALUES (?, ?, ?, ?)
+		ON CONFLICT(project_ref) DO UPDATE SET
+			reason = excluded.reason,
+			ignored_by = excluded.ignored_by,
+			created_at = excluded.created_at
+	`
+
+	_, err := s.db.Exec(query, ignore.ProjectRef, ignore.Reason, ignore.IgnoredBy, ignore.CreatedAt)
+	if err != nil {
+		return fmt.Errorf("failed to ignore remote project: %w", err)
+	}
+	return nil
+}
+
+// UnignoreRemoteOrphan takes a remote project off the orphan ignore list
+func (s *SQLiteStorage) UnignoreRemoteOrphan(projectRef string) error {
+	result, err := s.db.Exec(`DELETE FROM remote_orphan_ignores WHERE project_ref = ?`, projectRef)
+	if err != nil {
+		return fmt.Errorf("failed to unignore remote project: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("remote project is not ignored")
+	}
+
+	return nil
+}
+
+// ListIgnoredOrphans returns the orphan ignore list keyed by project ref
+func (s *SQLiteStorage) ListIgnoredOrphans() (map[string]*supabase.OrphanIgnore, error) {
+	query := `
+		SELECT project_ref, reason, ignored_by, created_at
+		FROM remote_orphan_ignores
+	`
+
+	rows, err := s.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list ignored remote projects: %w", err)
+	}
+	defer rows.Close()
+
+	ignored := make(map[string]*supabase.OrphanIgnore)
+	for rows.Next() {
+		var ignore supabase.OrphanIgnore
+		if err := rows.Scan(&ignore.ProjectRef, &ignore.Reason, &ignore.IgnoredBy, &ignore.CreatedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan ignored remote project: %w", err)
+		}
+		ignored[ignore.ProjectRef] = &ignore
+	}
+
+	return ignored, rows.Err()
+}
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"fmt"
+
+	"supabase-manager/internal/pagination"
+)
+
+// listPage reads one page of a list, newest first. Rows are positioned by
+// their rowid, which grows with every insert and survives updates, so a
+// cursor keeps its place while rows are added. scan reads a row selected as
+// rowid followed by columns.
+func listPage[T any](s *SQLiteStorage, table, columns, where string, args []interface{}, req pagination.Request, scan func(row rowScanner, position *int64) (T, error)) ([]T, pagination.Page, error) {
+	query := fmt.Sprintf("SELECT rowid, %s FROM %s WHERE %s", columns, table, where)
+	pageArgs := append([]interface{}{}, args...)
+	if req.After > 0 {
+		query += " AND rowid < ?"
+		pageArgs = append(pageArgs, req.After)
+	}
+	query += " ORDER BY rowid DESC LIMIT ?"
+	pageArgs = append(pageArgs, req.Limit+1)
+
+	rows, err := s.db.Query(query, pageArgs...)
+	if err != nil {
+		return nil, pagination.Page{}, fmt.Errorf("failed to list %s: %w", table, err)
+	}
+	defer rows.Close()
+
+	items := []T{}
+	var positions []int64
+	for rows.Next() {
+		var position int64
+		item, err := scan(rows, &position)
+		if err != nil {
+			return nil, pagination.Page{}, fmt.Errorf("failed to scan %s: %w", table, err)
+		}
+		items = append(items, item)
+		positions = append(positions, position)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, pagination.Page{}, fmt.Errorf("failed to list %s: %w", table, err)
+	}
+
+	items, page := pagination.Slice(req, items, positions)
+
+	if req.IncludeTotal {
+		var total int
+		countQuery := fmt.Sprintf("SELECT COUNT(*) FROM %s WHERE %s", table, where)
+		if err := s.db.QueryRow(countQuery, args...).Scan(&total); err != nil {
+			return nil, pagination.Page{}, fmt.Errorf("failed to count %s: %w", table, err)
+		}
+		page = page.WithTotal(req, total)
+	}
+
+	return items, page, nil
+}
@@ -0,0 +1,37 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// GetProjectPlan returns the billing plan a project is on, or "" if it is
+// not known, as for projects created before plans were recorded
+func (s *SQLiteStorage) GetProjectPlan(projectID string) (string, error) {
+	var plan string
+	err := s.db.QueryRow(`SELECT plan FROM project_plans WHERE project_id = ?`, projectID).Scan(&plan)
+	if err == sql.ErrNoRows {
+		return "", nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to get project plan: %w", err)
+	}
+	return plan, nil
+}
+
+// SetProjectPlan records the billing plan a project is on
+func (s *SQLiteStorage) SetProjectPlan(projectID, plan string) error {
+	query := `
+		INSERT INTO project_plans (project_id, plan, updated_at)
+		VALUES (?, ?, ?)
+		ON CONFLICT(project_id) DO UPDATE SET
+			plan = excluded.plan,
+			updated_at = excluded.updated_at
+	`
+
+	if _, err := s.db.Exec(query, projectID, plan, time.Now()); err != nil {
+		return fmt.Errorf("failed to set project plan: %w", err)
+	}
+	return nil
+}
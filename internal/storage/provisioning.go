@@ -0,0 +1,104 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// RecordProvisionDuration stores how long a project took to become ready
+func (s *SQLiteStorage) RecordProvisionDuration(projectID, region string, startedAt time.Time, duration time.Duration) error {
+	_, err := s.db.Exec(
+		"INSERT INTO provision_durations (project_id, region, started_at, duration_ms) VALUES (?, ?, ?, ?)",
+		projectID, region, startedAt, duration.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record provision duration: %w", err)
+	}
+	return nil
+}
+
+// RecordProvisionFailure stores a provision that never became ready, and
+// how long it ran before failing. Failures count towards the provisioning
+// SLO but not the duration baselines.
+func (s *SQLiteStorage) RecordProvisionFailure(projectID, region string, startedAt time.Time, duration time.Duration) error {
+	_, err := s.db.Exec(
+		"INSERT INTO provision_durations (project_id, region, started_at, duration_ms, succeeded) VALUES (?, ?, ?, ?, 0)",
+		projectID, region, startedAt, duration.Milliseconds(),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record provision failure: %w", err)
+	}
+	return nil
+}
+
+// ProvisionOutcomes returns the provisions started since the given time,
+// oldest first
+func (s *SQLiteStorage) ProvisionOutcomes(since time.Time) ([]supabase.ProvisionOutcome, error) {
+	rows, err := s.db.Query(
+		"SELECT region, started_at, duration_ms, succeeded FROM provision_durations WHERE started_at >= ? ORDER BY started_at",
+		since,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provision outcomes: %w", err)
+	}
+	defer rows.Close()
+
+	var outcomes []supabase.ProvisionOutcome
+	for rows.Next() {
+		var outcome supabase.ProvisionOutcome
+		var ms int64
+		if err := rows.Scan(&outcome.Region, &outcome.StartedAt, &ms, &outcome.Succeeded); err != nil {
+			return nil, fmt.Errorf("failed to scan provision outcome: %w", err)
+		}
+		outcome.Duration = time.Duration(ms) * time.Millisecond
+		outcomes = append(outcomes, outcome)
+	}
+
+	return outcomes, rows.Err()
+}
+
+// RecentProvisionDurations returns the durations of the latest provisions in
+// a region, newest first
+func (s *SQLiteStorage) RecentProvisionDurations(region string, limit int) ([]time.Duration, error) {
+	rows, err := s.db.Query(
+		"SELECT duration_ms FROM provision_durations WHERE region = ? AND succeeded = 1 ORDER BY started_at DESC LIMIT ?",
+		region, limit,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provision durations: %w", err)
+	}
+	defer rows.Close()
+
+	var durations []time.Duration
+	for rows.Next() {
+		var ms int64
+		if err := rows.Scan(&ms); err != nil {
+			return nil, fmt.Errorf("failed to scan provision duration: %w", err)
+		}
+		durations = append(durations, time.Duration(ms)*time.Millisecond)
+	}
+
+	return durations, rows.Err()
+}
+
+// ProvisionRegions returns the regions with recorded provision durations
+func (s *SQLiteStorage) ProvisionRegions() ([]string, error) {
+	rows, err := s.db.Query("SELECT DISTINCT region FROM provision_durations WHERE succeeded = 1 ORDER BY region")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list provision regions: %w", err)
+	}
+	defer rows.Close()
+
+	var regions []string
+	for rows.Next() {
+		var region string
+		if err := rows.Scan(&region); err != nil {
+			return nil, fmt.Errorf("failed to scan provision region: %w", err)
+		}
+		regions = append(regions, region)
+	}
+
+	return regions, rows.Err()
+}
@@ -0,0 +1,25 @@
+package storage
+
+import "fmt"
+
+// CountProjectsCreatedBy counts the projects an API key created, leaving out
+// those being deleted
+func (s *SQLiteStorage) CountProjectsCreatedBy(keyID string) (int, error) {
+	return s.countProjects("created_by = ?", keyID)
+}
+
+// CountOrganizationProjects counts the projects of a Supabase organization,
+// leaving out those being deleted
+func (s *SQLiteStorage) CountOrganizationProjects(organizationID string) (int, error) {
+	return s.countProjects("organization_id = ?", organizationID)
+}
+
+// countProjects counts the projects matching a condition on one column
+func (s *SQLiteStorage) countProjects(condition string, arg interface{}) (int, error) {
+	var count int
+	query := "SELECT COUNT(*) FROM projects WHERE status != 'PENDING_DELETION' AND " + condition
+	if err := s.db.QueryRow(query, arg).Scan(&count); err != nil {
+		return 0, fmt.Errorf("failed to count projects: %w", err)
+	}
+	return count, nil
+}
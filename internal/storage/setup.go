@@ -0,0 +1,101 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// SaveSetupSteps stores the planned setup steps of a project, replacing any
+// earlier plan
+func (s *SQLiteStorage) SaveSetupSteps(projectID string, steps []supabase.SetupStep) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM project_setup WHERE project_id = ?`, projectID); err != nil {
+		return fmt.Errorf("failed to clear setup steps: %w", err)
+	}
+
+	now := time.Now()
+	for _, step := range steps {
+		_, err := tx.Exec(`
+			INSERT INTO project_setup (project_id, position, component, name, status, error, updated_at)
+			VALUES (?, ?, ?, ?, ?, ?, ?)
+		`, projectID, step.Position, step.Component, step.Name, step.Status, step.Error, now)
+		if err != nil {
+			return fmt.Errorf("failed to save setup step: %w", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// UpdateSetupStep records the outcome of one setup step
+func (s *SQLiteStorage) UpdateSetupStep(projectID string, position int, status, errMsg string) error {
+	query := `
+		UPDATE project_setup
+		SET status = ?, error = ?, updated_at = ?
+		WHERE project_id = ? AND position = ?
+	`
+
+	if _, err := s.db.Exec(query, status, errMsg, time.Now(), projectID, position); err != nil {
+		return fmt.Errorf("failed to update setup step: %w", err)
+	}
+	return nil
+}
+
+// SkipPendingSetupSteps marks the steps of a project that have not run as
+// skipped, with the reason
+func (s *SQLiteStorage) SkipPendingSetupSteps(projectID, reason string) error {
+	query := `
+		UPDATE project_setup
+		SET status = ?, error = ?, updated_at = ?
+		WHERE project_id = ? AND status = ?
+	`
+
+	_, err := s.db.Exec(query, supabase.SetupSkipped, reason, time.Now(), projectID, supabase.SetupPending)
+	if err != nil {
+		return fmt.Errorf("failed to skip setup steps: %w", err)
+	}
+	return nil
+}
+
+// ListSetupSteps returns the setup steps of a project in the order they
+// are applied
+func (s *SQLiteStorage) ListSetupSteps(projectID string) ([]supabase.SetupStep, error) {
+	query := `
+		SELECT position, component, name, status, error, updated_at
+		FROM project_setup
+		WHERE project_id = ?
+		ORDER BY position ASC
+	`
+
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list setup steps: %w", err)
+	}
+	defer rows.Close()
+
+	steps := []supabase.SetupStep{}
+	for rows.Next() {
+		var step supabase.SetupStep
+		err := rows.Scan(
+			&step.Position,
+			&step.Component,
+			&step.Name,
+			&step.Status,
+			&step.Error,
+			&step.UpdatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan setup step: %w", err)
+		}
+		steps = append(steps, step)
+	}
+
+	return steps, rows.Err()
+}
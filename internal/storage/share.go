@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// Share link redemption errors
+var (
+	ErrShareLinkNotFound = errors.New("share link not found")
+	ErrShareLinkExpired  = errors.New("share link has expired")
+	ErrShareLinkUsed     = errors.New("share link has already been used")
+)
+
+// shareLinkColumns is the column list shared by share link queries
+const shareLinkColumns = `id, project_id, token_hash, created_by, note, expires_at, used_at, created_at`
+
+// scanShareLink reads a share link selected with shareLinkColumns
+func scanShareLink(row rowScanner) (*supabase.ShareLink, error) {
+	var link supabase.ShareLink
+	var usedAt sql.NullTime
+	err := row.Scan(
+		&link.ID,
+		&link.ProjectID,
+		&link.TokenHash,
+		&link.CreatedBy,
+		&link.Note,
+		&link.ExpiresAt,
+		&usedAt,
+		&link.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if usedAt.Valid {
+		link.UsedAt = &usedAt.Time
+	}
+	return &link, nil
+}
+
+// SaveShareLink stores a newly minted share link
+func (s *SQLiteStorage) SaveShareLink(link *supabase.ShareLink) error {
+	query := `
+		INSERT INTO share_links (id, project_id, token_hash, created_by, note, expires_at, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		link.ID,
+		link.ProjectID,
+		link.TokenHash,
+		link.CreatedBy,
+		link.Note,
+		link.ExpiresAt,
+		link.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save share link: %w", err)
+	}
+
+	return nil
+}
+
+// RedeemShareLink marks the link with the given token hash as used and
+// returns it. A link can only be redeemed once: of two concurrent
+// redemptions, one gets ErrShareLinkUsed. The link is returned alongside
+// ErrShareLinkExpired and ErrShareLinkUsed so the attempt can be audited.
+func (s *SQLiteStorage) RedeemShareLink(tokenHash string, now time.Time) (*supabase.ShareLink, error) {
+	link, err := scanShareLink(s.db.QueryRow(
+		"SELECT "+shareLinkColumns+" FROM share_links WHERE token_hash = ?", tokenHash))
+	if err == sql.ErrNoRows {
+		return nil, ErrShareLinkNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get share link: %w", err)
+	}
+
+	if link.UsedAt != nil {
+		return link, ErrShareLinkUsed
+	}
+	if !now.Before(link.ExpiresAt) {
+		return link, ErrShareLinkExpired
+	}
+
+	result, err := s.db.Exec(
+		"UPDATE share_links SET used_at = ? WHERE id = ? AND used_at IS NULL", now, link.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to redeem share link: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return link, ErrShareLinkUsed
+	}
+
+	link.UsedAt = &now
+	return link, nil
+}
+
+// ListShareLinks returns a project's share links, newest first
+func (s *SQLiteStorage) ListShareLinks(projectID string) ([]*supabase.ShareLink, error) {
+	rows, err := s.db.Query(
+		"SELECT "+shareLinkColumns+" FROM share_links WHERE project_id = ? ORDER BY created_at DESC", projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list share links: %w", err)
+	}
+	defer rows.Close()
+
+	links := []*supabase.ShareLink{}
+	for rows.Next() {
+		link, err := scanShareLink(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan share link: %w", err)
+		}
+		links = append(links, link)
+	}
+
+	return links, nil
+}
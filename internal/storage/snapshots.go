@@ -0,0 +1,126 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+
+	"supabase-manager/internal/supabase"
+)
+
+// snapshotColumns is the column list shared by snapshot queries
+const snapshotColumns = `id, project_id, project_ref, project_name, region, postgres_version,
+	schemas, reason, path, size_bytes, sha256, created_by, created_at`
+
+// scanSnapshot reads a snapshot selected with snapshotColumns
+func scanSnapshot(row rowScanner) (*supabase.Snapshot, error) {
+	var snapshot supabase.Snapshot
+	var schemas string
+	err := row.Scan(
+		&snapshot.ID,
+		&snapshot.ProjectID,
+		&snapshot.ProjectRef,
+		&snapshot.ProjectName,
+		&snapshot.Region,
+		&snapshot.PostgresVersion,
+		&schemas,
+		&snapshot.Reason,
+		&snapshot.Path,
+		&snapshot.SizeBytes,
+		&snapshot.SHA256,
+		&snapshot.CreatedBy,
+		&snapshot.CreatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Schemas = strings.Split(schemas, ",")
+	return &snapshot, nil
+}
+
+// SaveSnapshot stores a snapshot that was taken
+func (s *SQLiteStorage) SaveSnapshot(snapshot *supabase.Snapshot) error {
+	query := `
+		INSERT INTO snapshots (id, project_id, project_ref, project_name, region, postgres_version,
+			schemas, reason, path, size_bytes, sha256, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		snapshot.ID,
+		snapshot.ProjectID,
+		snapshot.ProjectRef,
+		snapshot.ProjectName,
+		snapshot.Region,
+		snapshot.PostgresVersion,
+		strings.Join(snapshot.Schemas, ","),
+		snapshot.Reason,
+		snapshot.Path,
+		snapshot.SizeBytes,
+		snapshot.SHA256,
+		snapshot.CreatedBy,
+		snapshot.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save snapshot: %w", err)
+	}
+	return nil
+}
+
+// GetSnapshot returns a snapshot by ID
+func (s *SQLiteStorage) GetSnapshot(id string) (*supabase.Snapshot, error) {
+	row := s.db.QueryRow(`SELECT `+snapshotColumns+` FROM snapshots WHERE id = ?`, id)
+	snapshot, err := scanSnapshot(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("snapshot not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get snapshot: %w", err)
+	}
+	return snapshot, nil
+}
+
+// ListSnapshots returns the snapshots of a project, or of all projects when
+// projectID is empty, newest first
+func (s *SQLiteStorage) ListSnapshots(projectID string) ([]*supabase.Snapshot, error) {
+	query := `SELECT ` + snapshotColumns + ` FROM snapshots`
+	var args []interface{}
+	if projectID != "" {
+		query += ` WHERE project_id = ?`
+		args = append(args, projectID)
+	}
+	query += ` ORDER BY created_at DESC`
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []*supabase.Snapshot{}
+	for rows.Next() {
+		snapshot, err := scanSnapshot(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan snapshot: %w", err)
+		}
+		snapshots = append(snapshots, snapshot)
+	}
+	return snapshots, rows.Err()
+}
+
+// DeleteSnapshot removes a snapshot's record
+func (s *SQLiteStorage) DeleteSnapshot(id string) error {
+	result, err := s.db.Exec(`DELETE FROM snapshots WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete snapshot: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("snapshot not found")
+	}
+	return nil
+}
@@ -3,6 +3,7 @@ package storage
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 	"time"
 
 	_"modernc.org/sqlite" // Pure Go SQLite - works without CGO
@@ -11,12 +12,22 @@ import (
 
 // SQLiteStorage implements credential storage using SQLite
 type SQLiteStorage struct {
-	db *sql.DB
+	db *instrumentedDB
 }
 
 // NewSQLiteStorage creates a new SQLite storage instance
 func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
-	db, err := sql.Open("sqlite", dbPath)
+	// Background tasks write concurrently from pooled connections; a busy
+	// timeout on each makes them wait for the lock instead of failing
+	dsn := dbPath
+	if strings.Contains(dsn, "?") {
+		dsn += "&"
+	} else {
+		dsn += "?"
+	}
+	dsn += "_pragma=busy_timeout(5000)"
+
+	db, err := sql.Open("sqlite", dsn)
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database: %w", err)
 	}
@@ -26,7 +37,7 @@ func NewSQLiteStorage(dbPath string) (*SQLiteStorage, error) {
 		return nil, fmt.Errorf("failed to ping database: %w", err)
 	}
 
-	storage := &SQLiteStorage{db: db}
+	storage := &SQLiteStorage{db: &instrumentedDB{DB: db}}
 
 	// Initialize schema
 	if err := storage.initSchema(); err != nil {
@@ -55,32 +66,409 @@ func (s *SQLiteStorage) initSchema() error {
 	CREATE INDEX IF NOT EXISTS idx_projects_ref ON projects(project_ref);
 	CREATE INDEX IF NOT EXISTS idx_projects_status ON projects(status);
 	CREATE INDEX IF NOT EXISTS idx_projects_created_at ON projects(created_at);
+
+	CREATE TABLE IF NOT EXISTS deleted_projects (
+		id TEXT PRIMARY KEY,
+		project_ref TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		region TEXT NOT NULL DEFAULT '',
+		last_status TEXT NOT NULL,
+		labels TEXT NOT NULL DEFAULT '{}',
+		created_at DATETIME NOT NULL,
+		deleted_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_deleted_projects_deleted_at ON deleted_projects(deleted_at);
+
+	CREATE TABLE IF NOT EXISTS project_names (
+		project_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		slug TEXT NOT NULL,
+		replaced_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_project_names_project ON project_names(project_id, replaced_at);
+	CREATE INDEX IF NOT EXISTS idx_project_names_name ON project_names(name);
+	CREATE INDEX IF NOT EXISTS idx_project_names_slug ON project_names(slug);
+
+	CREATE TABLE IF NOT EXISTS provision_durations (
+		project_id TEXT NOT NULL,
+		region TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		duration_ms INTEGER NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_provision_durations_region ON provision_durations(region, started_at);
+	CREATE INDEX IF NOT EXISTS idx_provision_durations_started ON provision_durations(started_at);
+
+	CREATE TABLE IF NOT EXISTS databases (
+		id TEXT PRIMARY KEY,
+		name TEXT UNIQUE NOT NULL,
+		host TEXT NOT NULL,
+		port INTEGER NOT NULL,
+		database_name TEXT NOT NULL,
+		username TEXT NOT NULL,
+		password TEXT NOT NULL,
+		sslmode TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS migrations (
+		id TEXT PRIMARY KEY,
+		target_id TEXT NOT NULL,
+		version TEXT NOT NULL,
+		name TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		author TEXT NOT NULL DEFAULT '',
+		ticket TEXT NOT NULL DEFAULT '',
+		success INTEGER NOT NULL,
+		statements_run INTEGER NOT NULL,
+		execution_time_ms INTEGER NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		applied_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_migrations_target ON migrations(target_id, applied_at);
+
+	CREATE TABLE IF NOT EXISTS migration_roles (
+		target_id TEXT PRIMARY KEY,
+		roles TEXT NOT NULL,
+		default_role TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS stats_history (
+		recorded_at DATETIME PRIMARY KEY,
+		total_projects INTEGER NOT NULL,
+		active_projects INTEGER NOT NULL,
+		failed_projects INTEGER NOT NULL,
+		migrations_applied INTEGER NOT NULL,
+		background_tasks_completed INTEGER NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS heartbeats (
+		instance_id TEXT PRIMARY KEY,
+		hostname TEXT NOT NULL,
+		started_at DATETIME NOT NULL,
+		last_beat_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS tenants (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		name TEXT NOT NULL,
+		schema_name TEXT NOT NULL,
+		role_name TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		UNIQUE(project_id, name)
+	);
+
+	CREATE TABLE IF NOT EXISTS share_links (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		token_hash TEXT UNIQUE NOT NULL,
+		created_by TEXT NOT NULL,
+		note TEXT NOT NULL DEFAULT '',
+		expires_at DATETIME NOT NULL,
+		used_at DATETIME,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS audit_events (
+		id TEXT PRIMARY KEY,
+		action TEXT NOT NULL,
+		project_id TEXT NOT NULL DEFAULT '',
+		actor TEXT NOT NULL,
+		request_id TEXT NOT NULL DEFAULT '',
+		client_ip TEXT NOT NULL DEFAULT '',
+		details TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_audit_events_project ON audit_events(project_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS jobs (
+		id TEXT PRIMARY KEY,
+		type TEXT NOT NULL,
+		status TEXT NOT NULL,
+		created_by TEXT NOT NULL DEFAULT '',
+		result TEXT NOT NULL DEFAULT '',
+		error TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL,
+		started_at DATETIME,
+		completed_at DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS job_logs (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		job_id TEXT NOT NULL,
+		message TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS project_setup (
+		project_id TEXT NOT NULL,
+		position INTEGER NOT NULL,
+		component TEXT NOT NULL,
+		name TEXT NOT NULL,
+		status TEXT NOT NULL,
+		error TEXT NOT NULL DEFAULT '',
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (project_id, position)
+	);
+
+	CREATE TABLE IF NOT EXISTS remote_orphan_ignores (
+		project_ref TEXT PRIMARY KEY,
+		reason TEXT NOT NULL DEFAULT '',
+		ignored_by TEXT NOT NULL DEFAULT '',
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS project_health (
+		project_id TEXT PRIMARY KEY,
+		reachable INTEGER NOT NULL DEFAULT 0,
+		checks INTEGER NOT NULL DEFAULT 0,
+		failures INTEGER NOT NULL DEFAULT 0,
+		last_checked_at DATETIME NOT NULL,
+		last_failure_at DATETIME,
+		last_failure TEXT NOT NULL DEFAULT '',
+		unreachable_since DATETIME
+	);
+
+	CREATE TABLE IF NOT EXISTS webhooks (
+		id TEXT PRIMARY KEY,
+		url TEXT NOT NULL,
+		events TEXT NOT NULL DEFAULT '',
+		description TEXT NOT NULL DEFAULT '',
+		key_id TEXT NOT NULL DEFAULT '',
+		secret TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL,
+		last_delivery_at DATETIME,
+		last_delivery_status INTEGER NOT NULL DEFAULT 0,
+		last_delivery_error TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS snapshots (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL,
+		project_ref TEXT NOT NULL,
+		project_name TEXT NOT NULL,
+		region TEXT NOT NULL,
+		postgres_version TEXT NOT NULL DEFAULT '',
+		schemas TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		path TEXT NOT NULL,
+		size_bytes INTEGER NOT NULL,
+		sha256 TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS idx_snapshots_project ON snapshots(project_id, created_at);
+
+	CREATE TABLE IF NOT EXISTS feature_flags (
+		project_id TEXT NOT NULL,
+		key TEXT NOT NULL,
+		type TEXT NOT NULL,
+		value TEXT NOT NULL,
+		description TEXT NOT NULL DEFAULT '',
+		updated_by TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		PRIMARY KEY (project_id, key)
+	);
+
+	CREATE TABLE IF NOT EXISTS org_defaults (
+		id INTEGER PRIMARY KEY CHECK (id = 1),
+		settings TEXT NOT NULL,
+		updated_at DATETIME NOT NULL,
+		updated_by TEXT NOT NULL DEFAULT ''
+	);
+
+	CREATE TABLE IF NOT EXISTS key_usage (
+		project_id TEXT NOT NULL,
+		key_type TEXT NOT NULL,
+		reports INTEGER NOT NULL DEFAULT 0,
+		first_used_at DATETIME NOT NULL,
+		last_used_at DATETIME NOT NULL,
+		PRIMARY KEY (project_id, key_type)
+	);
+
+	CREATE TABLE IF NOT EXISTS project_plans (
+		project_id TEXT PRIMARY KEY,
+		plan TEXT NOT NULL,
+		updated_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS freeze_windows (
+		id TEXT PRIMARY KEY,
+		project_id TEXT NOT NULL DEFAULT '',
+		label_selector TEXT NOT NULL DEFAULT '',
+		starts_at DATETIME,
+		ends_at DATETIME,
+		schedule TEXT NOT NULL DEFAULT '',
+		duration TEXT NOT NULL DEFAULT '',
+		time_zone TEXT NOT NULL DEFAULT '',
+		operations TEXT NOT NULL,
+		reason TEXT NOT NULL,
+		created_by TEXT NOT NULL,
+		created_at DATETIME NOT NULL
+	);
+
+	CREATE TABLE IF NOT EXISTS request_nonces (
+		scope TEXT NOT NULL,
+		nonce TEXT NOT NULL,
+		expires_at DATETIME NOT NULL,
+		PRIMARY KEY (scope, nonce)
+	);
+
+	CREATE TABLE IF NOT EXISTS idempotency_keys (
+		scope TEXT NOT NULL,
+		key TEXT NOT NULL,
+		fingerprint TEXT NOT NULL,
+		status_code INTEGER NOT NULL DEFAULT 0,
+		content_type TEXT NOT NULL DEFAULT '',
+		body BLOB,
+		expires_at DATETIME NOT NULL,
+		PRIMARY KEY (scope, key)
+	);
+
+	CREATE INDEX IF NOT EXISTS idx_jobs_status ON jobs(status);
+	CREATE INDEX IF NOT EXISTS idx_request_nonces_expires ON request_nonces(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_job_logs_job ON job_logs(job_id, id);
+	CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires ON idempotency_keys(expires_at);
+	CREATE INDEX IF NOT EXISTS idx_jobs_completed ON jobs(completed_at);
 	`
 
-	_, err := s.db.Exec(schema)
-	return err
+	if _, err := s.db.Exec(schema); err != nil {
+		return err
+	}
+
+	if err := s.migrateColumns(); err != nil {
+		return err
+	}
+	if err := s.normalizeTimestamps(); err != nil {
+		return err
+	}
+	return s.createUpdatedAtTriggers()
+}
+
+// addedColumns lists columns introduced after a table was first created,
+// applied to existing databases by migrateColumns
+var addedColumns = []struct {
+	table      string
+	column     string
+	definition string
+}{
+	{"projects", "postgres_version", "TEXT NOT NULL DEFAULT ''"},
+	{"migrations", "role", "TEXT NOT NULL DEFAULT ''"},
+	{"projects", "deletion_attempts", "INTEGER NOT NULL DEFAULT 0"},
+	{"projects", "deletion_error", "TEXT NOT NULL DEFAULT ''"},
+	{"projects", "byo_credentials", "INTEGER NOT NULL DEFAULT 0"},
+	{"migrations", "sql_gz", "BLOB"},
+	{"projects", "name", "TEXT NOT NULL DEFAULT ''"},
+	{"projects", "labels", "TEXT NOT NULL DEFAULT '{}'"},
+	{"migrations", "source", "TEXT NOT NULL DEFAULT ''"},
+	{"migrations", "source_sha256", "TEXT NOT NULL DEFAULT ''"},
+	{"projects", "organization_id", "TEXT NOT NULL DEFAULT ''"},
+	{"deleted_projects", "organization_id", "TEXT NOT NULL DEFAULT ''"},
+	{"provision_durations", "succeeded", "INTEGER NOT NULL DEFAULT 1"},
+	{"migrations", "imported", "INTEGER NOT NULL DEFAULT 0"},
+	{"projects", "created_by", "TEXT NOT NULL DEFAULT ''"},
+	{"projects", "requested_region", "TEXT NOT NULL DEFAULT ''"},
+}
+
+// migrateColumns adds missing columns to tables created by older versions
+func (s *SQLiteStorage) migrateColumns() error {
+	for _, added := range addedColumns {
+		var count int
+		err := s.db.QueryRow(
+			"SELECT COUNT(*) FROM pragma_table_info(?) WHERE name = ?",
+			added.table, added.column,
+		).Scan(&count)
+		if err != nil {
+			return fmt.Errorf("failed to inspect %s: %w", added.table, err)
+		}
+		if count > 0 {
+			continue
+		}
+
+		alter := fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", added.table, added.column, added.definition)
+		if _, err := s.db.Exec(alter); err != nil {
+			return fmt.Errorf("failed to add column %s.%s: %w", added.table, added.column, err)
+		}
+	}
+
+	return nil
+}
+
+// projectColumns is the column list shared by all project queries
+const projectColumns = `id, name, project_ref, project_url, region, anon_key, service_key,
+		       db_password, status, postgres_version, deletion_attempts, deletion_error,
+		       byo_credentials, organization_id, created_by, requested_region, labels, created_at, updated_at`
+
+// rowScanner is satisfied by *sql.Row and *sql.Rows
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// scanProject scans a row selected with projectColumns
+func scanProject(row rowScanner) (*supabase.StoredProject, error) {
+	var project supabase.StoredProject
+	var labels string
+	err := row.Scan(
+		&project.ID,
+		&project.Name,
+		&project.ProjectRef,
+		&project.ProjectURL,
+		&project.Region,
+		&project.AnonKey,
+		&project.ServiceKey,
+		&project.DBPassword,
+		&project.Status,
+		&project.PostgresVersion,
+		&project.DeletionAttempts,
+		&project.DeletionError,
+		&project.BYOCredentials,
+		&project.OrganizationID,
+		&project.CreatedBy,
+		&project.RequestedRegion,
+		&labels,
+		&project.CreatedAt,
+		&project.UpdatedAt,
+	)
+	if err != nil {
+		return nil, err
+	}
+	if project.Labels, err = decodeLabels(labels); err != nil {
+		return nil, err
+	}
+	return &project, nil
 }
 
 // SaveProject stores a project in the database
 func (s *SQLiteStorage) SaveProject(project *supabase.StoredProject) error {
 	query := `
 		INSERT INTO projects (
-			id, project_ref, project_url, region, anon_key, service_key, 
-			db_password, status, created_at, updated_at
-		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+			id, name, project_ref, project_url, region, anon_key, service_key, 
+			db_password, status, postgres_version, deletion_attempts, deletion_error,
+			byo_credentials, organization_id, created_by, requested_region, labels, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
 		ON CONFLICT(id) DO UPDATE SET
 			project_url = excluded.project_url,
 			region = excluded.region,
 			anon_key = excluded.anon_key,
 			service_key = excluded.service_key,
 			db_password = excluded.db_password,
-			status = excluded.status,
+			status = CASE WHEN projects.status IN ('PENDING_DELETION', 'DELETION_FAILED') THEN projects.status ELSE excluded.status END,
+			postgres_version = excluded.postgres_version,
 			updated_at = excluded.updated_at
 	`
 
 	_, err := s.db.Exec(
 		query,
 		project.ID,
+		project.Name,
 		project.ProjectRef,
 		project.ProjectURL,
 		project.Region,
@@ -88,6 +476,14 @@ func (s *SQLiteStorage) SaveProject(project *supabase.StoredProject) error {
 		project.ServiceKey,
 		project.DBPassword,
 		project.Status,
+		project.PostgresVersion,
+		project.DeletionAttempts,
+		project.DeletionError,
+		project.BYOCredentials,
+		project.OrganizationID,
+		project.CreatedBy,
+		project.RequestedRegion,
+		encodeLabels(project.Labels),
 		project.CreatedAt,
 		project.UpdatedAt,
 	)
@@ -102,25 +498,12 @@ func (s *SQLiteStorage) SaveProject(project *supabase.StoredProject) error {
 // GetProject retrieves a project by ID
 func (s *SQLiteStorage) GetProject(id string) (*supabase.StoredProject, error) {
 	query := `
-		SELECT id, project_ref, project_url, region, anon_key, service_key,
-		       db_password, status, created_at, updated_at
+		SELECT ` + projectColumns + `
 		FROM projects
 		WHERE id = ?
 	`
 
-	var project supabase.StoredProject
-	err := s.db.QueryRow(query, id).Scan(
-		&project.ID,
-		&project.ProjectRef,
-		&project.ProjectURL,
-		&project.Region,
-		&project.AnonKey,
-		&project.ServiceKey,
-		&project.DBPassword,
-		&project.Status,
-		&project.CreatedAt,
-		&project.UpdatedAt,
-	)
+	project, err := scanProject(s.db.QueryRow(query, id))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("project not found")
@@ -130,31 +513,18 @@ func (s *SQLiteStorage) GetProject(id string) (*supabase.StoredProject, error) {
 		return nil, fmt.Errorf("failed to get project: %w", err)
 	}
 
-	return &project, nil
+	return project, nil
 }
 
 // GetProjectByRef retrieves a project by project reference
 func (s *SQLiteStorage) GetProjectByRef(projectRef string) (*supabase.StoredProject, error) {
 	query := `
-		SELECT id, project_ref, project_url, region, anon_key, service_key,
-		       db_password, status, created_at, updated_at
+		SELECT ` + projectColumns + `
 		FROM projects
 		WHERE project_ref = ?
 	`
 
-	var project supabase.StoredProject
-	err := s.db.QueryRow(query, projectRef).Scan(
-		&project.ID,
-		&project.ProjectRef,
-		&project.ProjectURL,
-		&project.Region,
-		&project.AnonKey,
-		&project.ServiceKey,
-		&project.DBPassword,
-		&project.Status,
-		&project.CreatedAt,
-		&project.UpdatedAt,
-	)
+	project, err := scanProject(s.db.QueryRow(query, projectRef))
 
 	if err == sql.ErrNoRows {
 		return nil, fmt.Errorf("project not found")
@@ -164,14 +534,13 @@ func (s *SQLiteStorage) GetProjectByRef(projectRef string) (*supabase.StoredProj
 		return nil, fmt.Errorf("failed to get project: %w", err)
 	}
 
-	return &project, nil
+	return project, nil
 }
 
 // ListProjects returns all projects
 func (s *SQLiteStorage) ListProjects() ([]*supabase.StoredProject, error) {
 	query := `
-		SELECT id, project_ref, project_url, region, anon_key, service_key,
-		       db_password, status, created_at, updated_at
+		SELECT ` + projectColumns + `
 		FROM projects
 		ORDER BY created_at DESC
 	`
@@ -184,33 +553,45 @@ func (s *SQLiteStorage) ListProjects() ([]*supabase.StoredProject, error) {
 
 	var projects []*supabase.StoredProject
 	for rows.Next() {
-		var project supabase.StoredProject
-		err := rows.Scan(
-			&project.ID,
-			&project.ProjectRef,
-			&project.ProjectURL,
-			&project.Region,
-			&project.AnonKey,
-			&project.ServiceKey,
-			&project.DBPassword,
-			&project.Status,
-			&project.CreatedAt,
-			&project.UpdatedAt,
-		)
+		project, err := scanProject(rows)
 		if err != nil {
 			return nil, fmt.Errorf("failed to scan project: %w", err)
 		}
-		projects = append(projects, &project)
+		projects = append(projects, project)
 	}
 
 	return projects, nil
 }
 
-// DeleteProject removes a project from the database
+// ProjectNameTaken reports whether a local project already uses name
+func (s *SQLiteStorage) ProjectNameTaken(name string) (bool, error) {
+	var count int
+	if err := s.db.QueryRow(`SELECT COUNT(*) FROM projects WHERE name = ?`, name).Scan(&count); err != nil {
+		return false, fmt.Errorf("failed to check project name: %w", err)
+	}
+	return count > 0, nil
+}
+
+// DeleteProject removes a project from the database. A record of it is
+// kept in deleted_projects until PurgeDeletedProjects removes it.
 func (s *SQLiteStorage) DeleteProject(id string) error {
-	query := `DELETE FROM projects WHERE id = ?`
+	tx, err := s.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	// Keep a record of the project until it is purged
+	_, err = tx.Exec(`
+		INSERT OR REPLACE INTO deleted_projects (id, project_ref, name, region, last_status, organization_id, labels, created_at, deleted_at)
+		SELECT id, project_ref, name, region, status, organization_id, labels, created_at, ?
+		FROM projects WHERE id = ?
+	`, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to record deleted project: %w", err)
+	}
 
-	result, err := s.db.Exec(query, id)
+	result, err := tx.Exec(`DELETE FROM projects WHERE id = ?`, id)
 	if err != nil {
 		return fmt.Errorf("failed to delete project: %w", err)
 	}
@@ -224,6 +605,17 @@ func (s *SQLiteStorage) DeleteProject(id string) error {
 		return fmt.Errorf("project not found")
 	}
 
+	return tx.Commit()
+}
+
+// BackfillOrganization records organizationID as the organization of the
+// projects stored before organizations were recorded, other than those
+// created with caller-supplied credentials, whose organization is unknown
+func (s *SQLiteStorage) BackfillOrganization(organizationID string) error {
+	query := `UPDATE projects SET organization_id = ? WHERE organization_id = '' AND byo_credentials = 0`
+	if _, err := s.db.Exec(query, organizationID); err != nil {
+		return fmt.Errorf("failed to backfill project organizations: %w", err)
+	}
 	return nil
 }
 
@@ -252,6 +644,70 @@ func (s *SQLiteStorage) UpdateProjectStatus(id, status string) error {
 	return nil
 }
 
+// RecordDeletionAttempt marks a project as waiting for remote deletion and
+// stores the number of failed attempts and the last error
+func (s *SQLiteStorage) RecordDeletionAttempt(id string, attempts int, lastError string) error {
+	return s.recordDeletion(id, "PENDING_DELETION", attempts, lastError)
+}
+
+// RecordDeletionFailure marks a project whose remote deletion failed for
+// good and stores the number of failed attempts and the last error
+func (s *SQLiteStorage) RecordDeletionFailure(id string, attempts int, lastError string) error {
+	return s.recordDeletion(id, "DELETION_FAILED", attempts, lastError)
+}
+
+// recordDeletion stores a failed remote deletion with the project's new
+// status
+func (s *SQLiteStorage) recordDeletion(id, status string, attempts int, lastError string) error {
+	query := `
+		UPDATE projects
+		SET status = ?, deletion_attempts = ?, deletion_error = ?, updated_at = ?
+		WHERE id = ?
+	`
+
+	result, err := s.db.Exec(query, status, attempts, lastError, time.Now(), id)
+	if err != nil {
+		return fmt.Errorf("failed to record deletion attempt: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("project not found")
+	}
+
+	return nil
+}
+
+// UpdateProjectKeys stores the API keys of a project and updates its status
+func (s *SQLiteStorage) UpdateProjectKeys(id, anonKey, serviceKey, status string) error {
+	query := `
+		UPDATE projects
+		SET anon_key = ?, service_key = ?, updated_at = ?,
+		    status = CASE WHEN status IN ('PENDING_DELETION', 'DELETION_FAILED') THEN status ELSE ? END
+		WHERE id = ?
+	`
+
+	result, err := s.db.Exec(query, anonKey, serviceKey, time.Now(), status, id)
+	if err != nil {
+		return fmt.Errorf("failed to update keys: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("project not found")
+	}
+
+	return nil
+}
+
 // Close closes the database connection
 func (s *SQLiteStorage) Close() error {
 	return s.db.Close()
@@ -0,0 +1,105 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// TakeStatsSnapshot counts projects by status and the migrations applied
+// since the given time
+func (s *SQLiteStorage) TakeStatsSnapshot(since time.Time) (*supabase.StatsSnapshot, error) {
+	snapshot := &supabase.StatsSnapshot{RecordedAt: time.Now()}
+
+	query := `
+		SELECT COUNT(*),
+		       COALESCE(SUM(CASE WHEN status = 'ACTIVE_HEALTHY' THEN 1 ELSE 0 END), 0),
+		       COALESCE(SUM(CASE WHEN status = 'FAILED' THEN 1 ELSE 0 END), 0)
+		FROM projects
+	`
+	err := s.db.QueryRow(query).Scan(&snapshot.TotalProjects, &snapshot.ActiveProjects, &snapshot.FailedProjects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count projects: %w", err)
+	}
+
+	err = s.db.QueryRow(
+		"SELECT COUNT(*) FROM migrations WHERE applied_at > ? AND imported = 0",
+		since,
+	).Scan(&snapshot.MigrationsApplied)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count migrations: %w", err)
+	}
+
+	return snapshot, nil
+}
+
+// SaveStatsSnapshot appends a snapshot to the stats history
+func (s *SQLiteStorage) SaveStatsSnapshot(snapshot *supabase.StatsSnapshot) error {
+	query := `
+		INSERT INTO stats_history (
+			recorded_at, total_projects, active_projects, failed_projects,
+			migrations_applied, background_tasks_completed
+		) VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(
+		query,
+		snapshot.RecordedAt,
+		snapshot.TotalProjects,
+		snapshot.ActiveProjects,
+		snapshot.FailedProjects,
+		snapshot.MigrationsApplied,
+		snapshot.BackgroundTasksCompleted,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save stats snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// ListStatsHistory returns the snapshots recorded since the given time,
+// oldest first
+func (s *SQLiteStorage) ListStatsHistory(since time.Time) ([]*supabase.StatsSnapshot, error) {
+	query := `
+		SELECT recorded_at, total_projects, active_projects, failed_projects,
+		       migrations_applied, background_tasks_completed
+		FROM stats_history
+		WHERE recorded_at >= ?
+		ORDER BY recorded_at ASC
+	`
+
+	rows, err := s.db.Query(query, since)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list stats history: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := []*supabase.StatsSnapshot{}
+	for rows.Next() {
+		var snapshot supabase.StatsSnapshot
+		err := rows.Scan(
+			&snapshot.RecordedAt,
+			&snapshot.TotalProjects,
+			&snapshot.ActiveProjects,
+			&snapshot.FailedProjects,
+			&snapshot.MigrationsApplied,
+			&snapshot.BackgroundTasksCompleted,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan stats snapshot: %w", err)
+		}
+		snapshots = append(snapshots, &snapshot)
+	}
+
+	return snapshots, rows.Err()
+}
+
+// PruneStatsHistory deletes snapshots recorded before the given time
+func (s *SQLiteStorage) PruneStatsHistory(before time.Time) error {
+	if _, err := s.db.Exec("DELETE FROM stats_history WHERE recorded_at < ?", before); err != nil {
+		return fmt.Errorf("failed to prune stats history: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package storage
+
+import (
+	"fmt"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// RecordKeyUsage counts a report of a project's key being used at usedAt,
+// keeping the earliest and latest use of each key type
+func (s *SQLiteStorage) RecordKeyUsage(projectID, keyType string, usedAt time.Time) error {
+	query := `
+		INSERT INTO key_usage (project_id, key_type, reports, first_used_at, last_used_at)
+		VALUES (?, ?, 1, ?, ?)
+		ON CONFLICT(project_id, key_type) DO UPDATE SET
+			reports = reports + 1,
+			first_used_at = MIN(first_used_at, excluded.first_used_at),
+			last_used_at = MAX(last_used_at, excluded.last_used_at)
+	`
+
+	if _, err := s.db.Exec(query, projectID, keyType, usedAt.UTC(), usedAt.UTC()); err != nil {
+		return fmt.Errorf("failed to record key usage: %w", err)
+	}
+	return nil
+}
+
+// GetKeyUsage returns the key usage of a project by key type, empty if no
+// use was ever reported
+func (s *SQLiteStorage) GetKeyUsage(projectID string) ([]supabase.KeyUsage, error) {
+	rows, err := s.db.Query(
+		`SELECT key_type, reports, first_used_at, last_used_at FROM key_usage WHERE project_id = ? ORDER BY key_type`,
+		projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get key usage: %w", err)
+	}
+	defer rows.Close()
+
+	usages := []supabase.KeyUsage{}
+	for rows.Next() {
+		var usage supabase.KeyUsage
+		if err := rows.Scan(&usage.KeyType, &usage.Reports, &usage.FirstUsedAt, &usage.LastUsedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan key usage: %w", err)
+		}
+		usages = append(usages, usage)
+	}
+	return usages, rows.Err()
+}
@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+
+	"supabase-manager/internal/supabase"
+)
+
+// SaveTenant stores a provisioned tenant
+func (s *SQLiteStorage) SaveTenant(tenant *supabase.Tenant) error {
+	query := `
+		INSERT INTO tenants (id, project_id, name, schema_name, role_name, created_at)
+		VALUES (?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		tenant.ID,
+		tenant.ProjectID,
+		tenant.Name,
+		tenant.Schema,
+		tenant.Role,
+		tenant.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save tenant: %w", err)
+	}
+
+	return nil
+}
+
+// GetTenant retrieves a project's tenant by name
+func (s *SQLiteStorage) GetTenant(projectID, name string) (*supabase.Tenant, error) {
+	query := `
+		SELECT id, project_id, name, schema_name, role_name, created_at
+		FROM tenants
+		WHERE project_id = ? AND name = ?
+	`
+
+	var tenant supabase.Tenant
+	err := s.db.QueryRow(query, projectID, name).Scan(
+		&tenant.ID,
+		&tenant.ProjectID,
+		&tenant.Name,
+		&tenant.Schema,
+		&tenant.Role,
+		&tenant.CreatedAt,
+	)
+
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("tenant not found")
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("failed to get tenant: %w", err)
+	}
+
+	return &tenant, nil
+}
+
+// ListTenants returns a project's tenants in creation order
+func (s *SQLiteStorage) ListTenants(projectID string) ([]*supabase.Tenant, error) {
+	query := `
+		SELECT id, project_id, name, schema_name, role_name, created_at
+		FROM tenants
+		WHERE project_id = ?
+		ORDER BY created_at ASC
+	`
+
+	rows, err := s.db.Query(query, projectID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tenants: %w", err)
+	}
+	defer rows.Close()
+
+	tenants := []*supabase.Tenant{}
+	for rows.Next() {
+		var tenant supabase.Tenant
+		err := rows.Scan(
+			&tenant.ID,
+			&tenant.ProjectID,
+			&tenant.Name,
+			&tenant.Schema,
+			&tenant.Role,
+			&tenant.CreatedAt,
+		)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan tenant: %w", err)
+		}
+		tenants = append(tenants, &tenant)
+	}
+
+	return tenants, nil
+}
+
+// DeleteTenant removes a tenant record
+func (s *SQLiteStorage) DeleteTenant(id string) error {
+	result, err := s.db.Exec(`DELETE FROM tenants WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete tenant: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+
+	if rows == 0 {
+		return fmt.Errorf("tenant not found")
+	}
+
+	return nil
+}
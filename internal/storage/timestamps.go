@@ -0,0 +1,183 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// timestampFormat is how timestamps are stored: RFC 3339 in UTC with a
+// fixed nine-digit fraction, so stored values sort and compare as text in
+// the order of the times they represent
+const timestampFormat = "2006-01-02T15:04:05.000000000Z07:00"
+
+// timestampGlob matches a value stored in timestampFormat
+const timestampGlob = "[0-9][0-9][0-9][0-9]-[0-9][0-9]-[0-9][0-9]T[0-9][0-9]:[0-9][0-9]:[0-9][0-9].[0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9][0-9]Z"
+
+// sqliteNow is the SQL expression for the current time in timestampFormat.
+// SQLite only has millisecond precision, the rest of the fraction is padded.
+const sqliteNow = `strftime('%Y-%m-%dT%H:%M:%f', 'now') || '000000Z'`
+
+// formatTimestamp formats t for storage
+func formatTimestamp(t time.Time) string {
+	return t.UTC().Format(timestampFormat)
+}
+
+// normalizeArgs replaces the times among a query's arguments with their
+// stored form. The driver would otherwise write them in their own zone and
+// in Go's default format, which doesn't sort as text.
+func normalizeArgs(args []interface{}) []interface{} {
+	normalized := args
+	copied := false
+	for i, arg := range args {
+		var value interface{}
+		switch t := arg.(type) {
+		case time.Time:
+			value = formatTimestamp(t)
+		case *time.Time:
+			if t != nil {
+				value = formatTimestamp(*t)
+			}
+		case sql.NullTime:
+			if t.Valid {
+				value = formatTimestamp(t.Time)
+			}
+		default:
+			continue
+		}
+		// The caller's slice is left as is
+		if !copied {
+			normalized = append([]interface{}(nil), args...)
+			copied = true
+		}
+		normalized[i] = value
+	}
+	return normalized
+}
+
+// updatedAtTables are the tables whose updated_at column is kept current
+// by a trigger, so an update that doesn't set it still moves it
+var updatedAtTables = []string{
+	"projects",
+	"databases",
+	"migration_roles",
+	"project_setup",
+	"feature_flags",
+	"org_defaults",
+	"project_plans",
+}
+
+// createUpdatedAtTriggers sets updated_at on every update of
+// updatedAtTables that leaves it unchanged
+func (s *SQLiteStorage) createUpdatedAtTriggers() error {
+	for _, table := range updatedAtTables {
+		trigger := fmt.Sprintf(`
+			CREATE TRIGGER IF NOT EXISTS %[1]s_updated_at AFTER UPDATE ON %[1]s
+			FOR EACH ROW WHEN NEW.updated_at IS OLD.updated_at
+			BEGIN
+				UPDATE %[1]s SET updated_at = %[2]s WHERE rowid = NEW.rowid;
+			END`, table, sqliteNow)
+		if _, err := s.db.Exec(trigger); err != nil {
+			return fmt.Errorf("failed to create updated_at trigger on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// dropUpdatedAtTriggers removes the triggers createUpdatedAtTriggers adds
+func (s *SQLiteStorage) dropUpdatedAtTriggers() error {
+	for _, table := range updatedAtTables {
+		if _, err := s.db.Exec(fmt.Sprintf(`DROP TRIGGER IF EXISTS %s_updated_at`, table)); err != nil {
+			return fmt.Errorf("failed to drop updated_at trigger on %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// normalizeTimestamps rewrites timestamps stored by older versions, in Go's
+// default format and often in local time, to timestampFormat. Values
+// already in it are skipped, so this is cheap once a database is converted.
+// The updated_at triggers are dropped first so rewriting a row doesn't
+// count as updating it; createUpdatedAtTriggers restores them.
+func (s *SQLiteStorage) normalizeTimestamps() error {
+	if err := s.dropUpdatedAtTriggers(); err != nil {
+		return err
+	}
+
+	columns, err := s.timestampColumns()
+	if err != nil {
+		return err
+	}
+
+	for _, column := range columns {
+		if err := s.normalizeColumn(column[0], column[1]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// timestampColumns lists the DATETIME columns of every table as table and
+// column pairs
+func (s *SQLiteStorage) timestampColumns() ([][2]string, error) {
+	rows, err := s.db.Query(`
+		SELECT m.name, c.name
+		FROM sqlite_master m, pragma_table_info(m.name) c
+		WHERE m.type = 'table' AND m.name NOT LIKE 'sqlite_%' AND c.type = 'DATETIME'
+		ORDER BY m.name, c.cid
+	`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list timestamp columns: %w", err)
+	}
+	defer rows.Close()
+
+	var columns [][2]string
+	for rows.Next() {
+		var table, column string
+		if err := rows.Scan(&table, &column); err != nil {
+			return nil, fmt.Errorf("failed to scan timestamp column: %w", err)
+		}
+		columns = append(columns, [2]string{table, column})
+	}
+	return columns, rows.Err()
+}
+
+// normalizeColumn rewrites the values of one timestamp column that aren't
+// in timestampFormat yet. Values the driver can't read as times are left
+// alone.
+func (s *SQLiteStorage) normalizeColumn(table, column string) error {
+	rows, err := s.db.Query(fmt.Sprintf(
+		`SELECT rowid, %[2]q FROM %[1]q WHERE typeof(%[2]q) = 'text' AND %[2]q NOT GLOB ?`, table, column),
+		timestampGlob)
+	if err != nil {
+		return fmt.Errorf("failed to read %s.%s: %w", table, column, err)
+	}
+
+	updates := map[int64]time.Time{}
+	for rows.Next() {
+		var rowID int64
+		var value interface{}
+		if err := rows.Scan(&rowID, &value); err != nil {
+			rows.Close()
+			return fmt.Errorf("failed to scan %s.%s: %w", table, column, err)
+		}
+		if t, ok := value.(time.Time); ok {
+			updates[rowID] = t
+		} else {
+			fmt.Printf("Warning: Leaving unreadable timestamp %v in %s.%s\n", value, table, column)
+		}
+	}
+	err = rows.Err()
+	rows.Close()
+	if err != nil {
+		return fmt.Errorf("failed to read %s.%s: %w", table, column, err)
+	}
+
+	for rowID, t := range updates {
+		update := fmt.Sprintf(`UPDATE %q SET %q = ? WHERE rowid = ?`, table, column)
+		if _, err := s.db.Exec(update, t, rowID); err != nil {
+			return fmt.Errorf("failed to normalize %s.%s: %w", table, column, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,131 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"supabase-manager/internal/supabase"
+)
+
+// RefConflict selects how UpsertProjectByRef treats an existing project with
+// the same project_ref
+type RefConflict string
+
+const (
+	// RefConflictMerge keeps the existing ID, creation time and deletion
+	// state, and takes every non-empty incoming field
+	RefConflictMerge RefConflict = "merge"
+	// RefConflictReplace keeps the existing ID and creation time and
+	// overwrites everything else
+	RefConflictReplace RefConflict = "replace"
+	// RefConflictKeep leaves the existing project untouched
+	RefConflictKeep RefConflict = "keep"
+	// RefConflictError fails with ErrProjectRefExists
+	RefConflictError RefConflict = "error"
+)
+
+// ErrProjectRefExists is returned by UpsertProjectByRef with
+// RefConflictError when a project with the same ref is already stored
+var ErrProjectRefExists = errors.New("a project with this project_ref already exists")
+
+// mergeValue takes the incoming value of a text column unless it is empty
+func mergeValue(column string) string {
+	return fmt.Sprintf("%s = CASE WHEN excluded.%s != '' THEN excluded.%s ELSE projects.%s END", column, column, column, column)
+}
+
+// refConflictClauses are the ON CONFLICT(project_ref) actions per strategy
+var refConflictClauses = map[RefConflict]string{
+	RefConflictMerge: "DO UPDATE SET " + strings.Join([]string{
+		mergeValue("name"),
+		mergeValue("project_url"),
+		mergeValue("region"),
+		mergeValue("anon_key"),
+		mergeValue("service_key"),
+		mergeValue("db_password"),
+		mergeValue("postgres_version"),
+		mergeValue("organization_id"),
+		mergeValue("created_by"),
+		mergeValue("requested_region"),
+		"status = CASE WHEN projects.status IN ('PENDING_DELETION', 'DELETION_FAILED') OR excluded.status = '' THEN projects.status ELSE excluded.status END",
+		"labels = CASE WHEN excluded.labels != '{}' THEN excluded.labels ELSE projects.labels END",
+		"updated_at = excluded.updated_at",
+	}, ",\n\t\t\t"),
+	RefConflictReplace: `DO UPDATE SET
+			name = excluded.name,
+			project_url = excluded.project_url,
+			region = excluded.region,
+			anon_key = excluded.anon_key,
+			service_key = excluded.service_key,
+			db_password = excluded.db_password,
+			status = excluded.status,
+			postgres_version = excluded.postgres_version,
+			deletion_attempts = excluded.deletion_attempts,
+			deletion_error = excluded.deletion_error,
+			byo_credentials = excluded.byo_credentials,
+			organization_id = excluded.organization_id,
+			created_by = excluded.created_by,
+			requested_region = excluded.requested_region,
+			labels = excluded.labels,
+			updated_at = excluded.updated_at`,
+	RefConflictKeep: "DO NOTHING",
+}
+
+// UpsertProjectByRef stores a project keyed on its project_ref rather than
+// its ID, so a project discovered remotely and one created locally end up as
+// a single record. The upsert is a single statement, so concurrent saves of
+// the same ref cannot violate the UNIQUE constraint. It returns the stored
+// project, whose ID is the existing one when the ref was already known.
+func (s *SQLiteStorage) UpsertProjectByRef(project *supabase.StoredProject, onConflict RefConflict) (*supabase.StoredProject, error) {
+	if project.ProjectRef == "" {
+		return nil, fmt.Errorf("failed to save project: project_ref is required")
+	}
+
+	conflictClause := ""
+	if onConflict != RefConflictError {
+		clause, ok := refConflictClauses[onConflict]
+		if !ok {
+			return nil, fmt.Errorf("unknown project_ref conflict strategy: %q", onConflict)
+		}
+		conflictClause = "ON CONFLICT(project_ref) " + clause
+	}
+
+	query := `
+		INSERT INTO projects (
+			id, name, project_ref, project_url, region, anon_key, service_key,
+			db_password, status, postgres_version, deletion_attempts, deletion_error,
+			byo_credentials, organization_id, created_by, requested_region, labels, created_at, updated_at
+		) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		` + conflictClause
+
+	_, err := s.db.Exec(
+		query,
+		project.ID,
+		project.Name,
+		project.ProjectRef,
+		project.ProjectURL,
+		project.Region,
+		project.AnonKey,
+		project.ServiceKey,
+		project.DBPassword,
+		project.Status,
+		project.PostgresVersion,
+		project.DeletionAttempts,
+		project.DeletionError,
+		project.BYOCredentials,
+		project.OrganizationID,
+		project.CreatedBy,
+		project.RequestedRegion,
+		encodeLabels(project.Labels),
+		project.CreatedAt,
+		project.UpdatedAt,
+	)
+	if err != nil {
+		if onConflict == RefConflictError && strings.Contains(err.Error(), "UNIQUE constraint failed: projects.project_ref") {
+			return nil, ErrProjectRefExists
+		}
+		return nil, fmt.Errorf("failed to save project: %w", err)
+	}
+
+	return s.GetProjectByRef(project.ProjectRef)
+}
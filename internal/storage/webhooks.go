@@ -0,0 +1,130 @@
+package storage
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+// webhookColumns is the column list shared by webhook queries
+const webhookColumns = `id, url, events, description, key_id, secret, created_by, created_at,
+	last_delivery_at, last_delivery_status, last_delivery_error`
+
+// scanWebhook reads a webhook selected with webhookColumns
+func scanWebhook(row rowScanner) (*supabase.Webhook, error) {
+	var webhook supabase.Webhook
+	var events string
+	var lastDeliveryAt sql.NullTime
+	err := row.Scan(
+		&webhook.ID,
+		&webhook.URL,
+		&events,
+		&webhook.Description,
+		&webhook.KeyID,
+		&webhook.Secret,
+		&webhook.CreatedBy,
+		&webhook.CreatedAt,
+		&lastDeliveryAt,
+		&webhook.LastDeliveryStatus,
+		&webhook.LastDeliveryError,
+	)
+	if err != nil {
+		return nil, err
+	}
+	webhook.Events = []string{}
+	if events != "" {
+		webhook.Events = strings.Split(events, ",")
+	}
+	if lastDeliveryAt.Valid {
+		webhook.LastDeliveryAt = &lastDeliveryAt.Time
+	}
+	return &webhook, nil
+}
+
+// SaveWebhook stores a newly registered webhook
+func (s *SQLiteStorage) SaveWebhook(webhook *supabase.Webhook) error {
+	query := `
+		INSERT INTO webhooks (id, url, events, description, key_id, secret, created_by, created_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, ?)
+	`
+
+	_, err := s.db.Exec(query,
+		webhook.ID,
+		webhook.URL,
+		strings.Join(webhook.Events, ","),
+		webhook.Description,
+		webhook.KeyID,
+		webhook.Secret,
+		webhook.CreatedBy,
+		webhook.CreatedAt,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to save webhook: %w", err)
+	}
+	return nil
+}
+
+// GetWebhook returns a webhook by ID
+func (s *SQLiteStorage) GetWebhook(id string) (*supabase.Webhook, error) {
+	row := s.db.QueryRow(`SELECT `+webhookColumns+` FROM webhooks WHERE id = ?`, id)
+	webhook, err := scanWebhook(row)
+	if err == sql.ErrNoRows {
+		return nil, fmt.Errorf("webhook not found")
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get webhook: %w", err)
+	}
+	return webhook, nil
+}
+
+// ListWebhooks returns all webhooks, oldest first
+func (s *SQLiteStorage) ListWebhooks() ([]*supabase.Webhook, error) {
+	rows, err := s.db.Query(`SELECT ` + webhookColumns + ` FROM webhooks ORDER BY created_at`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhooks: %w", err)
+	}
+	defer rows.Close()
+
+	var webhooks []*supabase.Webhook
+	for rows.Next() {
+		webhook, err := scanWebhook(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan webhook: %w", err)
+		}
+		webhooks = append(webhooks, webhook)
+	}
+	return webhooks, rows.Err()
+}
+
+// DeleteWebhook removes a webhook
+func (s *SQLiteStorage) DeleteWebhook(id string) error {
+	result, err := s.db.Exec(`DELETE FROM webhooks WHERE id = ?`, id)
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook: %w", err)
+	}
+
+	rows, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to get rows affected: %w", err)
+	}
+	if rows == 0 {
+		return fmt.Errorf("webhook not found")
+	}
+	return nil
+}
+
+// RecordWebhookDelivery stores the outcome of the last delivery to a
+// webhook; status is 0 when no response was received
+func (s *SQLiteStorage) RecordWebhookDelivery(id string, at time.Time, status int, deliveryErr string) error {
+	query := `
+		UPDATE webhooks SET last_delivery_at = ?, last_delivery_status = ?, last_delivery_error = ?
+		WHERE id = ?
+	`
+	if _, err := s.db.Exec(query, at, status, deliveryErr, id); err != nil {
+		return fmt.Errorf("failed to record webhook delivery: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,399 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// apiVersionPattern matches Management API versions such as v1 or v2beta
+var apiVersionPattern = regexp.MustCompile(`^v[0-9]+((alpha|beta)[0-9]*)?$`)
+
+// apiEndpointPattern matches endpoint names as returned by ManagementEndpoint
+var apiEndpointPattern = regexp.MustCompile(`^[a-z][a-z0-9-]*$`)
+
+// APIVersions pins Management API endpoints, named as by
+// ManagementEndpoint, to API versions. Endpoints not pinned use the version
+// of the base URL.
+type APIVersions map[string]string
+
+// ParseAPIVersions reads a comma-separated list of endpoint=version pins,
+// such as "api-keys=v1,projects=v1"
+func ParseAPIVersions(spec string) (APIVersions, error) {
+	versions := APIVersions{}
+	for _, entry := range strings.Split(spec, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+		endpoint, version, ok := strings.Cut(entry, "=")
+		endpoint, version = strings.TrimSpace(endpoint), strings.TrimSpace(version)
+		if !ok || !apiEndpointPattern.MatchString(endpoint) {
+			return nil, fmt.Errorf("invalid entry %q, expected endpoint=version", entry)
+		}
+		if !apiVersionPattern.MatchString(version) {
+			return nil, fmt.Errorf("invalid version %q of %s, expected a version such as v1", version, endpoint)
+		}
+		if _, dup := versions[endpoint]; dup {
+			return nil, fmt.Errorf("%s is pinned twice", endpoint)
+		}
+		versions[endpoint] = version
+	}
+	return versions, nil
+}
+
+// ManagementEndpoint names the endpoint a Management API path belongs to:
+// the resource under a project, such as api-keys for
+// /projects/{ref}/api-keys, or the first path segment otherwise, such as
+// projects for /projects and /projects/{ref}
+func ManagementEndpoint(path string) string {
+	path, _, _ = strings.Cut(path, "?")
+	segments := strings.Split(strings.Trim(path, "/"), "/")
+	if segments[0] == "projects" && len(segments) > 2 {
+		return segments[2]
+	}
+	return segments[0]
+}
+
+// splitAPIVersion splits a base URL ending in a version, such as
+// https://api.supabase.com/v1, into its root and version
+func splitAPIVersion(baseURL string) (root, version string, ok bool) {
+	i := strings.LastIndex(baseURL, "/")
+	if i < 0 || !apiVersionPattern.MatchString(baseURL[i+1:]) {
+		return baseURL, "", false
+	}
+	return baseURL[:i], baseURL[i+1:], true
+}
+
+// fieldRename is an upstream field rename absorbed in responses: when a
+// response has From but not To, To is filled in from From
+type fieldRename struct {
+	From string
+	To   string
+}
+
+// responseShims are the renames absorbed in the responses of each endpoint
+var responseShims = map[string][]fieldRename{
+	"projects": {
+		{From: "id", To: "ref"},
+		{From: "organization_slug", To: "organization_id"},
+	},
+	"api-keys": {
+		{From: "api_key_value", To: "api_key"},
+	},
+}
+
+// Sources of an APIProbe
+const (
+	APIProbeOpenAPI  = "openapi"
+	APIProbeDefaults = "defaults"
+)
+
+// createProjectOptional are the create project parameters the client can
+// leave out when the API no longer accepts them
+var createProjectOptional = []string{"plan", "postgres_engine", "desired_instance_size"}
+
+// createProjectSent are the create project parameters the client knows how
+// to send
+var createProjectSent = []string{"organization_id", "organization_slug", "name", "region", "region_selection", "db_pass", "plan", "postgres_engine", "desired_instance_size"}
+
+// APIProbe is what the startup capability probe learned about the
+// Management API
+type APIProbe struct {
+	ProbedAt time.Time `json:"probed_at"`
+	// Source is openapi when the API's OpenAPI document was read, or
+	// defaults when it could not be and the request shapes this version was
+	// built against are used
+	Source string `json:"source"`
+	Error  string `json:"error,omitempty"`
+	// CreateProjectParams are the parameters the create project endpoint
+	// accepts, and CreateProjectRequired those it requires
+	CreateProjectParams   []string `json:"create_project_params,omitempty"`
+	CreateProjectRequired []string `json:"create_project_required,omitempty"`
+	// RegionSelection sends the region as region_selection, and
+	// OrganizationSlug the organization as organization_slug
+	RegionSelection  bool     `json:"region_selection"`
+	OrganizationSlug bool     `json:"organization_slug"`
+	Warnings         []string `json:"warnings,omitempty"`
+}
+
+// accepts reports whether create project takes a parameter
+func (p *APIProbe) accepts(param string) bool {
+	return slices.Contains(p.CreateProjectParams, param)
+}
+
+// apiCompat holds the version pins, the probe result and shim counters of
+// a client. It is shared by clients derived with WithCredentials.
+type apiCompat struct {
+	mu       sync.RWMutex
+	versions APIVersions
+	probe    *APIProbe
+	applied  map[string]int64
+}
+
+// newAPICompat creates compatibility state with no pins and no probe
+func newAPICompat() *apiCompat {
+	return &apiCompat{versions: APIVersions{}, applied: make(map[string]int64)}
+}
+
+// version returns the version an endpoint is pinned to, if any
+func (a *apiCompat) version(endpoint string) string {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.versions[endpoint]
+}
+
+// currentProbe returns the last probe result, nil before the first probe
+func (a *apiCompat) currentProbe() *APIProbe {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return a.probe
+}
+
+// count records that a shim was applied
+func (a *apiCompat) count(name string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.applied[name]++
+}
+
+// SetAPIVersions pins endpoints to Management API versions. It must be
+// called before the client is used.
+func (c *Client) SetAPIVersions(versions APIVersions) {
+	c.compat.mu.Lock()
+	defer c.compat.mu.Unlock()
+	c.compat.versions = versions
+}
+
+// url returns the URL of a Management API path, at the version its
+// endpoint is pinned to. Pins only apply to base URLs ending in a version.
+func (c *Client) url(path string) string {
+	version := c.compat.version(ManagementEndpoint(path))
+	root, current, ok := splitAPIVersion(c.baseURL)
+	if !ok || version == "" || version == current {
+		return c.baseURL + path
+	}
+	return root + "/" + version + path
+}
+
+// decode decodes a response of path into out, first absorbing the renames
+// known for its endpoint
+func (c *Client) decode(path string, body io.Reader, out interface{}) error {
+	endpoint := ManagementEndpoint(path)
+	renames := responseShims[endpoint]
+	if len(renames) == 0 {
+		if err := json.NewDecoder(body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+		return nil
+	}
+
+	var raw interface{}
+	if err := json.NewDecoder(body).Decode(&raw); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	objects := []interface{}{raw}
+	if list, ok := raw.([]interface{}); ok {
+		objects = list
+	}
+	for _, object := range objects {
+		fields, ok := object.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for _, rename := range renames {
+			value, has := fields[rename.From]
+			if _, present := fields[rename.To]; has && !present {
+				fields[rename.To] = value
+				c.compat.count(endpoint + "." + rename.To + " from " + rename.From)
+			}
+		}
+	}
+
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	if err := json.Unmarshal(data, out); err != nil {
+		return fmt.Errorf("failed to decode response: %w", err)
+	}
+	return nil
+}
+
+// shapeCreateProject adapts a create project payload to the parameters
+// the probe found: the region and organization are sent under their new
+// names, and optional parameters the API no longer accepts are left out.
+// Without an OpenAPI probe the payload is sent as built.
+func (c *Client) shapeCreateProject(payload map[string]interface{}) {
+	probe := c.compat.currentProbe()
+	if probe == nil || probe.Source != APIProbeOpenAPI {
+		return
+	}
+
+	if probe.RegionSelection {
+		payload["region_selection"] = map[string]interface{}{"type": "specific", "code": payload["region"]}
+		delete(payload, "region")
+		c.compat.count("create_project.region as region_selection")
+	}
+	if probe.OrganizationSlug {
+		payload["organization_slug"] = payload["organization_id"]
+		delete(payload, "organization_id")
+		c.compat.count("create_project.organization_id as organization_slug")
+	}
+	for _, param := range createProjectOptional {
+		if _, set := payload[param]; set && !probe.accepts(param) {
+			delete(payload, param)
+			c.compat.count("create_project." + param + " dropped")
+		}
+	}
+}
+
+// openAPISchema is the part of an OpenAPI schema the probe reads
+type openAPISchema struct {
+	Ref        string                     `json:"$ref"`
+	Properties map[string]json.RawMessage `json:"properties"`
+	Required   []string                   `json:"required"`
+}
+
+// openAPIDocument is the part of the Management API's OpenAPI document the
+// probe reads
+type openAPIDocument struct {
+	Paths map[string]map[string]struct {
+		RequestBody struct {
+			Content map[string]struct {
+				Schema openAPISchema `json:"schema"`
+			} `json:"content"`
+		} `json:"requestBody"`
+	} `json:"paths"`
+	Components struct {
+		Schemas map[string]openAPISchema `json:"schemas"`
+	} `json:"components"`
+}
+
+// ProbeCapabilities reads the Management API's OpenAPI document to learn
+// which parameters creating a project takes, so renamed or newly required
+// parameters are sent the way the API expects. When the document cannot
+// be read, the request shapes this version was built against are kept. The
+// result is kept for later requests and returned.
+func (c *Client) ProbeCapabilities() *APIProbe {
+	probe, err := c.probeOpenAPI()
+	if err != nil {
+		probe = &APIProbe{Source: APIProbeDefaults, Error: err.Error()}
+	}
+	probe.ProbedAt = time.Now().UTC()
+
+	c.compat.mu.Lock()
+	c.compat.probe = probe
+	c.compat.mu.Unlock()
+	return probe
+}
+
+// probeOpenAPI fetches and reads the OpenAPI document of the projects
+// endpoint's version
+func (c *Client) probeOpenAPI() (*APIProbe, error) {
+	root, version, ok := splitAPIVersion(c.baseURL)
+	if !ok {
+		return nil, fmt.Errorf("base URL %s does not end in an API version", c.baseURL)
+	}
+	if pinned := c.compat.version("projects"); pinned != "" {
+		version = pinned
+	}
+
+	req, err := http.NewRequest("GET", root+"/api/"+version+"-json", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch the OpenAPI document: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch the OpenAPI document: status %d", resp.StatusCode)
+	}
+
+	var doc openAPIDocument
+	if err := json.NewDecoder(io.LimitReader(resp.Body, 16<<20)).Decode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to read the OpenAPI document: %w", err)
+	}
+
+	schema, ok := doc.Paths["/"+version+"/projects"]["post"].RequestBody.Content["application/json"]
+	if !ok {
+		return nil, fmt.Errorf("the OpenAPI document does not describe creating projects")
+	}
+	body := schema.Schema
+	if name, isRef := strings.CutPrefix(body.Ref, "#/components/schemas/"); isRef {
+		if body, ok = doc.Components.Schemas[name]; !ok {
+			return nil, fmt.Errorf("the OpenAPI document lacks schema %s", name)
+		}
+	}
+
+	probe := &APIProbe{Source: APIProbeOpenAPI, CreateProjectRequired: body.Required}
+	for param := range body.Properties {
+		probe.CreateProjectParams = append(probe.CreateProjectParams, param)
+	}
+	sort.Strings(probe.CreateProjectParams)
+	sort.Strings(probe.CreateProjectRequired)
+
+	probe.RegionSelection = !probe.accepts("region") && probe.accepts("region_selection")
+	probe.OrganizationSlug = !probe.accepts("organization_id") && probe.accepts("organization_slug")
+	if !probe.accepts("region") && !probe.accepts("region_selection") {
+		probe.Warnings = append(probe.Warnings, "create project takes neither region nor region_selection")
+	}
+	for _, param := range createProjectOptional {
+		if !probe.accepts(param) {
+			probe.Warnings = append(probe.Warnings, fmt.Sprintf("create project no longer takes %s; it is left out", param))
+		}
+	}
+	for _, param := range probe.CreateProjectRequired {
+		if !slices.Contains(createProjectSent, param) {
+			probe.Warnings = append(probe.Warnings, fmt.Sprintf("create project requires %s, which this version does not send", param))
+		}
+	}
+	return probe, nil
+}
+
+// ShimCount is how often a compatibility shim was applied
+type ShimCount struct {
+	Shim    string `json:"shim"`
+	Applied int64  `json:"applied"`
+}
+
+// APICompatStatus describes how the client talks to the Management API
+type APICompatStatus struct {
+	BaseURL  string      `json:"base_url"`
+	Versions APIVersions `json:"versions"`
+	Probe    *APIProbe   `json:"probe,omitempty"`
+	Shims    []ShimCount `json:"shims_applied"`
+}
+
+// APICompatStatus returns the version pins, the probe result and how often
+// each compatibility shim was applied
+func (c *Client) APICompatStatus() APICompatStatus {
+	c.compat.mu.RLock()
+	defer c.compat.mu.RUnlock()
+
+	status := APICompatStatus{
+		BaseURL:  c.baseURL,
+		Versions: APIVersions{},
+		Probe:    c.compat.probe,
+		Shims:    []ShimCount{},
+	}
+	for endpoint, version := range c.compat.versions {
+		status.Versions[endpoint] = version
+	}
+	for shim, applied := range c.compat.applied {
+		status.Shims = append(status.Shims, ShimCount{Shim: shim, Applied: applied})
+	}
+	sort.Slice(status.Shims, func(i, j int) bool { return status.Shims[i].Shim < status.Shims[j].Shim })
+	return status
+}
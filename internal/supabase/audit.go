@@ -0,0 +1,17 @@
+package supabase
+
+import "time"
+
+// AuditEvent records a security-relevant action
+type AuditEvent struct {
+	ID        string `json:"id"`
+	Action    string `json:"action"`
+	ProjectID string `json:"project_id,omitempty"`
+	// Actor is the API key ID, or "share_link:<id>" for share link
+	// recipients
+	Actor     string    `json:"actor"`
+	RequestID string    `json:"request_id,omitempty"`
+	ClientIP  string    `json:"client_ip,omitempty"`
+	Details   string    `json:"details,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
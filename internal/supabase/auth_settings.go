@@ -0,0 +1,142 @@
+package supabase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// Bounds Supabase enforces on the access token lifetime, in seconds
+const (
+	minJWTExpiry = 300
+	maxJWTExpiry = 604800
+)
+
+// AuthSettings holds the security related part of a project's auth config.
+// Nil fields are left unchanged on update.
+type AuthSettings struct {
+	JWTExpiry                   *int  `json:"jwt_exp,omitempty"`
+	RefreshTokenRotationEnabled *bool `json:"refresh_token_rotation_enabled,omitempty"`
+	RefreshTokenReuseInterval   *int  `json:"security_refresh_token_reuse_interval,omitempty"`
+	RateLimitEmailSent          *int  `json:"rate_limit_email_sent,omitempty"`
+	RateLimitSMSSent            *int  `json:"rate_limit_sms_sent,omitempty"`
+	RateLimitVerify             *int  `json:"rate_limit_verify,omitempty"`
+	RateLimitTokenRefresh       *int  `json:"rate_limit_token_refresh,omitempty"`
+	RateLimitOTP                *int  `json:"rate_limit_otp,omitempty"`
+	RateLimitAnonymousUsers     *int  `json:"rate_limit_anonymous_users,omitempty"`
+}
+
+// IsEmpty reports whether no setting is present
+func (s AuthSettings) IsEmpty() bool {
+	return s == AuthSettings{}
+}
+
+// Merge returns the settings with the non-nil settings of over applied on
+// top
+func (s AuthSettings) Merge(over AuthSettings) AuthSettings {
+	fields := []struct{ dst, src **int }{
+		{&s.JWTExpiry, &over.JWTExpiry},
+		{&s.RefreshTokenReuseInterval, &over.RefreshTokenReuseInterval},
+		{&s.RateLimitEmailSent, &over.RateLimitEmailSent},
+		{&s.RateLimitSMSSent, &over.RateLimitSMSSent},
+		{&s.RateLimitVerify, &over.RateLimitVerify},
+		{&s.RateLimitTokenRefresh, &over.RateLimitTokenRefresh},
+		{&s.RateLimitOTP, &over.RateLimitOTP},
+		{&s.RateLimitAnonymousUsers, &over.RateLimitAnonymousUsers},
+	}
+	for _, field := range fields {
+		if *field.src != nil {
+			*field.dst = *field.src
+		}
+	}
+	if over.RefreshTokenRotationEnabled != nil {
+		s.RefreshTokenRotationEnabled = over.RefreshTokenRotationEnabled
+	}
+	return s
+}
+
+// Validate checks the settings against the limits Supabase accepts
+func (s AuthSettings) Validate() error {
+	if s.JWTExpiry != nil && (*s.JWTExpiry < minJWTExpiry || *s.JWTExpiry > maxJWTExpiry) {
+		return fmt.Errorf("jwt_exp must be between %d and %d seconds", minJWTExpiry, maxJWTExpiry)
+	}
+	if s.RefreshTokenReuseInterval != nil && *s.RefreshTokenReuseInterval < 0 {
+		return fmt.Errorf("security_refresh_token_reuse_interval must not be negative")
+	}
+
+	rateLimits := map[string]*int{
+		"rate_limit_email_sent":      s.RateLimitEmailSent,
+		"rate_limit_sms_sent":        s.RateLimitSMSSent,
+		"rate_limit_verify":          s.RateLimitVerify,
+		"rate_limit_token_refresh":   s.RateLimitTokenRefresh,
+		"rate_limit_otp":             s.RateLimitOTP,
+		"rate_limit_anonymous_users": s.RateLimitAnonymousUsers,
+	}
+	for name, value := range rateLimits {
+		if value != nil && *value < 1 {
+			return fmt.Errorf("%s must be at least 1", name)
+		}
+	}
+
+	return nil
+}
+
+// GetAuthSettings returns the security related auth settings of a project
+func (c *Client) GetAuthSettings(projectRef string) (*AuthSettings, error) {
+	var settings AuthSettings
+	if err := c.managementRequest("GET", "/projects/"+projectRef+"/config/auth", nil, &settings); err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// UpdateAuthSettings applies the non-nil settings to a project and returns
+// the resulting settings
+func (c *Client) UpdateAuthSettings(projectRef string, settings AuthSettings) (*AuthSettings, error) {
+	var updated AuthSettings
+	if err := c.managementRequest("PATCH", "/projects/"+projectRef+"/config/auth", settings, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// managementRequest calls the Management API with the account access token
+func (c *Client) managementRequest(method, path string, payload interface{}, out interface{}) error {
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequest(method, c.url(path), body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+c.accessToken)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusNoContent {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return newAPIError(resp.StatusCode, bodyBytes)
+	}
+
+	if out != nil && resp.StatusCode != http.StatusNoContent {
+		if err := c.decode(path, resp.Body, out); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+package supabase
+
+import "time"
+
+// Outcomes of deleting one project of a batch
+const (
+	BatchDeleteDeleted         = "deleted"
+	BatchDeletePendingDeletion = "pending_deletion"
+	BatchDeleteFailed          = "failed"
+)
+
+// BatchDeleteRequest deletes every project matching a label selector. Sent
+// without a confirmation token it only returns a plan; sent again with the
+// plan's token it deletes the planned projects.
+type BatchDeleteRequest struct {
+	LabelSelector     string `json:"label_selector" binding:"required"`
+	DeleteRemote      bool   `json:"delete_remote,omitempty"`
+	ConfirmationToken string `json:"confirmation_token,omitempty"`
+}
+
+// BatchDeletePlan lists the projects a batch delete would remove
+type BatchDeletePlan struct {
+	LabelSelector     string              `json:"label_selector"`
+	DeleteRemote      bool                `json:"delete_remote"`
+	Projects          []BatchDeleteTarget `json:"projects"`
+	ConfirmationToken string              `json:"confirmation_token"`
+	ExpiresAt         time.Time           `json:"expires_at"`
+}
+
+// BatchDeleteTarget is a project in a batch delete plan
+type BatchDeleteTarget struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	ProjectRef string            `json:"project_ref"`
+	Status     string            `json:"status"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	// LastKeyUse is when an app last reported using one of the project's
+	// keys, so projects still in use stand out before they are deleted
+	LastKeyUse *time.Time `json:"last_key_use,omitempty"`
+}
+
+// BatchDeleteResult is the outcome for one project of a batch delete
+type BatchDeleteResult struct {
+	ID     string `json:"id"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+	// SnapshotID is the snapshot taken before the project was deleted in
+	// Supabase
+	SnapshotID string `json:"snapshot_id,omitempty"`
+}
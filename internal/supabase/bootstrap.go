@@ -0,0 +1,220 @@
+package supabase
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+)
+
+// extensionPattern matches Postgres extension names such as uuid-ossp
+var extensionPattern = regexp.MustCompile(`^[a-z0-9_][a-z0-9_-]{0,62}$`)
+
+// ProjectBootstrap is setup applied to a new project once it is ready
+type ProjectBootstrap struct {
+	Buckets    []BucketRequest `json:"buckets,omitempty"`
+	Extensions []string        `json:"extensions,omitempty"`
+	// Profile names the provisioning profile whose SQL runs after the
+	// extensions are enabled
+	Profile    string `json:"profile,omitempty"`
+	ProfileSQL string `json:"profile_sql,omitempty"`
+	// LogDrains are created before the extensions and profile SQL
+	LogDrains []LogDrain `json:"log_drains,omitempty"`
+	// Secrets and AuthProviders come from a template and are set before
+	// the extensions; TemplateSQL runs after the profile SQL
+	Secrets       []ProjectSecret `json:"secrets,omitempty"`
+	AuthProviders []AuthProvider  `json:"auth_providers,omitempty"`
+	TemplateSQL   string          `json:"template_sql,omitempty"`
+	// Migrations run last, in order, stopping at the first that fails
+	Migrations []BootstrapMigration `json:"migrations,omitempty"`
+	// Comment, if set, receives the outcome of a preview
+	Comment *PreviewComment `json:"comment,omitempty"`
+	// AuthSettings and MigrationRoles come from a configured provisioning
+	// profile. The auth settings are applied over the auth baseline once
+	// the project is ready; the migration roles are set when it is stored.
+	AuthSettings   *AuthSettings          `json:"-"`
+	MigrationRoles *MigrationRolesRequest `json:"migration_roles,omitempty"`
+}
+
+// IsEmpty reports whether there is nothing to set up
+func (b ProjectBootstrap) IsEmpty() bool {
+	return len(b.Buckets) == 0 && len(b.Extensions) == 0 && b.ProfileSQL == "" && len(b.LogDrains) == 0 &&
+		len(b.Secrets) == 0 && len(b.AuthProviders) == 0 && b.TemplateSQL == "" &&
+		len(b.Migrations) == 0 && b.Comment == nil
+}
+
+// AddTemplate adds the components of a template. Extensions and buckets
+// already present are not repeated.
+func (b *ProjectBootstrap) AddTemplate(t *ProjectTemplate) {
+	if t == nil {
+		return
+	}
+	for _, extension := range t.Extensions {
+		if !slices.Contains(b.Extensions, extension) {
+			b.Extensions = append(b.Extensions, extension)
+		}
+	}
+	buckets := slices.Clone(b.Buckets)
+	for _, bucket := range t.Buckets {
+		if !slices.ContainsFunc(buckets, func(existing BucketRequest) bool { return existing.Name == bucket.Name }) {
+			buckets = append(buckets, bucket)
+		}
+	}
+	b.Buckets = buckets
+	b.Secrets = t.Secrets
+	b.AuthProviders = t.AuthProviders
+	b.TemplateSQL = strings.TrimSpace(t.SQL)
+
+	// The template's migrations and seed run before any other migrations,
+	// since those may build on the template's schema
+	var migrations []BootstrapMigration
+	batch := ApplySchemaRequest{Migrations: t.Migrations}
+	for _, step := range batch.BatchSteps() {
+		name := step.Name
+		if name == "" {
+			name = "template " + step.Version
+		}
+		migrations = append(migrations, BootstrapMigration{Version: step.Version, Name: name, Source: "template", SQL: step.SQL})
+	}
+	if seed := strings.TrimSpace(t.Seed); seed != "" {
+		migrations = append(migrations, BootstrapMigration{Name: "template seed", Source: "template", SQL: seed})
+	}
+	b.Migrations = append(migrations, b.Migrations...)
+}
+
+// Setup components, in the order they are applied
+const (
+	SetupBucket       = "bucket"
+	SetupLogDrain     = "log_drain"
+	SetupSecrets      = "secrets"
+	SetupAuthProvider = "auth_provider"
+	SetupExtensions   = "extensions"
+	SetupProfile      = "profile"
+	SetupSQL          = "sql"
+	SetupMigration    = "migration"
+)
+
+// Setup step statuses
+const (
+	SetupPending = "pending"
+	SetupApplied = "applied"
+	SetupFailed  = "failed"
+	SetupSkipped = "skipped"
+)
+
+// SetupStep is one component of a project's bootstrap and its outcome
+type SetupStep struct {
+	Position  int       `json:"position"`
+	Component string    `json:"component"`
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Error     string    `json:"error,omitempty"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// Steps lists the steps of the bootstrap in the order they are applied,
+// all pending
+func (b ProjectBootstrap) Steps() []SetupStep {
+	var steps []SetupStep
+	add := func(component, name string) {
+		steps = append(steps, SetupStep{Position: len(steps) + 1, Component: component, Name: name, Status: SetupPending})
+	}
+	for _, bucket := range b.Buckets {
+		add(SetupBucket, bucket.Name)
+	}
+	for _, drain := range b.LogDrains {
+		add(SetupLogDrain, drain.Name)
+	}
+	if len(b.Secrets) > 0 {
+		add(SetupSecrets, strings.Join(SecretNames(b.Secrets), ", "))
+	}
+	for _, provider := range b.AuthProviders {
+		add(SetupAuthProvider, provider.Provider)
+	}
+	if len(b.Extensions) > 0 {
+		add(SetupExtensions, strings.Join(b.Extensions, ", "))
+	}
+	if b.ProfileSQL != "" {
+		add(SetupProfile, b.Profile)
+	}
+	if b.TemplateSQL != "" {
+		add(SetupSQL, "template")
+	}
+	for _, migration := range b.Migrations {
+		add(SetupMigration, migration.Name)
+	}
+	return steps
+}
+
+// SetupStatus summarizes a project's setup: none when there was nothing to
+// set up, pending until every step has finished, then applied, partial or
+// failed
+func SetupStatus(steps []SetupStep) string {
+	if len(steps) == 0 {
+		return "none"
+	}
+	applied := 0
+	for _, step := range steps {
+		switch step.Status {
+		case SetupPending:
+			return SetupPending
+		case SetupApplied:
+			applied++
+		}
+	}
+	switch applied {
+	case len(steps):
+		return SetupApplied
+	case 0:
+		return SetupFailed
+	}
+	return "partial"
+}
+
+// ParseBucketList parses a comma-separated list of bucket names, each
+// optionally followed by ":public", such as "avatars:public,uploads"
+func ParseBucketList(list string) ([]BucketRequest, error) {
+	var buckets []BucketRequest
+	for _, entry := range strings.Split(list, ",") {
+		entry = strings.TrimSpace(entry)
+		if entry == "" {
+			continue
+		}
+
+		name, visibility, _ := strings.Cut(entry, ":")
+		bucket := BucketRequest{Name: strings.TrimSpace(name)}
+		switch strings.TrimSpace(visibility) {
+		case "", "private":
+		case "public":
+			bucket.Public = true
+		default:
+			return nil, fmt.Errorf("invalid visibility for bucket %q: %q (public or private)", bucket.Name, visibility)
+		}
+		if !identifierPattern.MatchString(bucket.Name) {
+			return nil, fmt.Errorf("invalid bucket name: %q", bucket.Name)
+		}
+		buckets = append(buckets, bucket)
+	}
+	return buckets, nil
+}
+
+// ValidateExtensions checks extension names
+func ValidateExtensions(names []string) error {
+	for _, name := range names {
+		if !extensionPattern.MatchString(name) {
+			return fmt.Errorf("invalid extension name: %q", name)
+		}
+	}
+	return nil
+}
+
+// EnableExtensionsSQL returns statements creating the extensions in the
+// extensions schema, where Supabase keeps them
+func EnableExtensionsSQL(names []string) string {
+	var b strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&b, "CREATE EXTENSION IF NOT EXISTS %s WITH SCHEMA extensions;\n", quoteIdentifier(name))
+	}
+	return b.String()
+}
@@ -0,0 +1,168 @@
+package supabase
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"fmt"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// bareKeyPattern matches TOML keys that need no quoting
+var bareKeyPattern = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// CLIBundle is a project laid out as the supabase directory of the Supabase
+// CLI, so it can be run locally with supabase start
+type CLIBundle struct {
+	// ProjectID is the project_id written to config.toml
+	ProjectID string
+	// PostgresVersion is the project's Postgres version, such as 17.4.1.054;
+	// the local database runs the same major version
+	PostgresVersion string
+	Auth            *AuthSettings
+	Buckets         []Bucket
+	// Migrations are applied migrations, oldest first, with their SQL
+	Migrations []*MigrationRecord
+	// Seed is written to seed.sql
+	Seed string
+}
+
+// ConfigTOML renders config.toml. Settings not known to the manager are
+// left out so the CLI applies its defaults.
+func (b *CLIBundle) ConfigTOML() string {
+	var s strings.Builder
+	fmt.Fprintf(&s, "project_id = %s\n", strconv.Quote(b.ProjectID))
+
+	s.WriteString("\n[db]\n")
+	if major, _, _ := strings.Cut(b.PostgresVersion, "."); major != "" {
+		fmt.Fprintf(&s, "major_version = %s\n", major)
+	}
+	s.WriteString("\n[db.seed]\nenabled = true\nsql_paths = [\"./seed.sql\"]\n")
+
+	s.WriteString("\n[storage]\nenabled = true\n")
+	for _, bucket := range b.Buckets {
+		fmt.Fprintf(&s, "\n[storage.buckets.%s]\n", tomlKey(bucket.Name))
+		fmt.Fprintf(&s, "public = %t\n", bucket.Public)
+		if bucket.FileSizeLimit != nil {
+			fmt.Fprintf(&s, "file_size_limit = %s\n", strconv.Quote(formatSize(*bucket.FileSizeLimit)))
+		}
+		if len(bucket.AllowedMimeTypes) > 0 {
+			fmt.Fprintf(&s, "allowed_mime_types = %s\n", tomlStrings(bucket.AllowedMimeTypes))
+		}
+	}
+
+	s.WriteString("\n[auth]\nenabled = true\n")
+	if auth := b.Auth; auth != nil {
+		writeTOMLInt(&s, "jwt_expiry", auth.JWTExpiry)
+		if auth.RefreshTokenRotationEnabled != nil {
+			fmt.Fprintf(&s, "enable_refresh_token_rotation = %t\n", *auth.RefreshTokenRotationEnabled)
+		}
+		writeTOMLInt(&s, "refresh_token_reuse_interval", auth.RefreshTokenReuseInterval)
+
+		s.WriteString("\n[auth.rate_limit]\n")
+		writeTOMLInt(&s, "email_sent", auth.RateLimitEmailSent)
+		writeTOMLInt(&s, "sms_sent", auth.RateLimitSMSSent)
+		writeTOMLInt(&s, "anonymous_users", auth.RateLimitAnonymousUsers)
+		writeTOMLInt(&s, "token_refresh", auth.RateLimitTokenRefresh)
+		writeTOMLInt(&s, "sign_in_sign_ups", auth.RateLimitOTP)
+		writeTOMLInt(&s, "token_verifications", auth.RateLimitVerify)
+	}
+
+	return s.String()
+}
+
+// MigrationFileName returns the CLI file name of a migration, such as
+// 20240101120000_create_todos.sql. Imported migrations keep the file name
+// they were imported with.
+func MigrationFileName(record *MigrationRecord) string {
+	if match := cliMigrationFilePattern.FindStringSubmatch(record.Name); match != nil && match[1] == record.Version {
+		return record.Name
+	}
+	name := strings.ReplaceAll(Slugify(record.Name), "-", "_")
+	if name == "" {
+		name = "migration"
+	}
+	return record.Version + "_" + name + ".sql"
+}
+
+// bundleFile is a file of a CLI bundle
+type bundleFile struct {
+	name    string
+	content string
+}
+
+// WriteTarGz writes the bundle as a gzipped tarball with a top-level
+// supabase directory
+func (b *CLIBundle) WriteTarGz(w io.Writer) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	modTime := time.Now()
+
+	files := []bundleFile{
+		{"supabase/config.toml", b.ConfigTOML()},
+		{"supabase/seed.sql", b.Seed},
+	}
+	for _, record := range b.Migrations {
+		script := record.SQL
+		if script == "" {
+			script = fmt.Sprintf("-- The SQL of migration %s was not recorded by the manager\n", record.Version)
+		}
+		files = append(files, bundleFile{"supabase/migrations/" + MigrationFileName(record), script})
+	}
+
+	for _, file := range files {
+		header := &tar.Header{
+			Name:    file.name,
+			Mode:    0644,
+			Size:    int64(len(file.content)),
+			ModTime: modTime,
+		}
+		if err := tw.WriteHeader(header); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+		if _, err := io.WriteString(tw, file.content); err != nil {
+			return fmt.Errorf("failed to write %s: %w", file.name, err)
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// writeTOMLInt writes an integer setting when it is set
+func writeTOMLInt(s *strings.Builder, key string, value *int) {
+	if value != nil {
+		fmt.Fprintf(s, "%s = %d\n", key, *value)
+	}
+}
+
+// tomlKey quotes a key when it is not a valid bare key
+func tomlKey(key string) string {
+	if bareKeyPattern.MatchString(key) {
+		return key
+	}
+	return strconv.Quote(key)
+}
+
+// tomlStrings renders a TOML array of strings
+func tomlStrings(values []string) string {
+	quoted := make([]string, len(values))
+	for i, value := range values {
+		quoted[i] = strconv.Quote(value)
+	}
+	return "[" + strings.Join(quoted, ", ") + "]"
+}
+
+// formatSize renders a byte count the way config.toml writes sizes
+func formatSize(bytes int64) string {
+	const mib = 1 << 20
+	if bytes > 0 && bytes%mib == 0 {
+		return fmt.Sprintf("%dMiB", bytes/mib)
+	}
+	return fmt.Sprintf("%dB", bytes)
+}
@@ -6,6 +6,7 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"strings"
 	"time"
 )
 
@@ -20,21 +21,62 @@ type Client struct {
 	accessToken    string
 	organizationID string
 	httpClient     *http.Client
+	transport      *instrumentedTransport
+	baseURL        string
+	statusPageURL  string
+	compat         *apiCompat
 }
 
 // NewClient creates a new Supabase client
 func NewClient(accessToken, organizationID string) *Client {
+	return NewClientWithTransport(accessToken, organizationID, DefaultTransportConfig())
+}
+
+// NewClientWithTransport creates a new Supabase client with custom transport settings
+func NewClientWithTransport(accessToken, organizationID string, config TransportConfig) *Client {
+	transport := &instrumentedTransport{base: newTransport(config)}
+
 	return &Client{
 		accessToken:    accessToken,
 		organizationID: organizationID,
 		httpClient: &http.Client{
-			Timeout: 60 * time.Second,
+			Transport: transport,
+			Timeout:   config.RequestTimeout,
 		},
+		transport:     transport,
+		baseURL:       managementAPIURL,
+		statusPageURL: statusPageURL,
+		compat:        newAPICompat(),
 	}
 }
 
+// SetBaseURL points the client at a different Management API, such as a
+// sandbox or test server
+func (c *Client) SetBaseURL(baseURL string) {
+	c.baseURL = strings.TrimSuffix(baseURL, "/")
+}
+
+// WithCredentials returns a client that shares this client's transport and
+// base URL but authenticates with another access token and organization
+func (c *Client) WithCredentials(accessToken, organizationID string) *Client {
+	clone := *c
+	clone.accessToken = accessToken
+	clone.organizationID = organizationID
+	return &clone
+}
+
+// OrganizationID returns the organization the client works in
+func (c *Client) OrganizationID() string {
+	return c.organizationID
+}
+
+// TransportStats returns connection and request metrics for the Management API transport
+func (c *Client) TransportStats() TransportStats {
+	return c.transport.stats()
+}
+
 // CreateProject creates a new Supabase project
-func (c *Client) CreateProject(name, region string) (*Project, error) {
+func (c *Client) CreateProject(name, region string, opts ProjectOptions) (*Project, error) {
 	// Generate database password
 	dbPassword := generateSecurePassword()
 
@@ -45,13 +87,23 @@ func (c *Client) CreateProject(name, region string) (*Project, error) {
 		"plan":            "free", // Use free tier for POC
 		"db_pass":         dbPassword,
 	}
+	if opts.PostgresVersion != "" {
+		payload["postgres_engine"] = opts.PostgresVersion
+	}
+	if opts.Plan != "" {
+		payload["plan"] = opts.Plan
+	}
+	if opts.ComputeSize != "" {
+		payload["desired_instance_size"] = opts.ComputeSize
+	}
+	c.shapeCreateProject(payload)
 
 	body, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to marshal request: %w", err)
 	}
 
-	req, err := http.NewRequest("POST", managementAPIURL+"/projects", bytes.NewBuffer(body))
+	req, err := http.NewRequest("POST", c.url("/projects"), bytes.NewBuffer(body))
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -67,23 +119,40 @@ func (c *Client) CreateProject(name, region string) (*Project, error) {
 
 	if resp.StatusCode != http.StatusCreated && resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, newAPIError(resp.StatusCode, bodyBytes)
 	}
 
 	var result Project
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.decode("/projects", resp.Body, &result); err != nil {
+		return nil, err
 	}
 
 	// Store the database password (not returned by API)
 	result.DBPassword = dbPassword
+	result.PostgresVersion = opts.PostgresVersion
 
 	return &result, nil
 }
 
+// ListProjects returns the projects of the client's organization
+func (c *Client) ListProjects() ([]Project, error) {
+	var projects []Project
+	if err := c.managementRequest("GET", "/projects", nil, &projects); err != nil {
+		return nil, err
+	}
+
+	orgProjects := make([]Project, 0, len(projects))
+	for _, project := range projects {
+		if project.OrganizationID == c.organizationID {
+			orgProjects = append(orgProjects, project)
+		}
+	}
+	return orgProjects, nil
+}
+
 // GetProject retrieves project details
 func (c *Client) GetProject(projectRef string) (*Project, error) {
-	req, err := http.NewRequest("GET", managementAPIURL+"/projects/"+projectRef, nil)
+	req, err := http.NewRequest("GET", c.url("/projects/"+projectRef), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -102,17 +171,21 @@ func (c *Client) GetProject(projectRef string) (*Project, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, newAPIError(resp.StatusCode, bodyBytes)
 	}
 
 	var project Project
-	if err := json.NewDecoder(resp.Body).Decode(&project); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.decode("/projects/"+projectRef, resp.Body, &project); err != nil {
+		return nil, err
 	}
 
 	// Also fetch database connection details from a separate endpoint
 	// Supabase provides database host info in the project response
 	// Let's extract it properly
+	if project.Database != nil {
+		project.DatabaseHost = project.Database.Host
+		project.PostgresVersion = project.Database.Version
+	}
 	if project.ProjectRef != "" && project.DatabaseHost == "" {
 		// Use IPv6 format which is more reliable
 		project.DatabaseHost = fmt.Sprintf("db.%s.supabase.co", project.ProjectRef)
@@ -158,7 +231,7 @@ func (c *Client) WaitForProject(projectRef string, timeout time.Duration) (*Proj
 
 // DeleteProject deletes a Supabase project
 func (c *Client) DeleteProject(projectRef string) error {
-	req, err := http.NewRequest("DELETE", managementAPIURL+"/projects/"+projectRef, nil)
+	req, err := http.NewRequest("DELETE", c.url("/projects/"+projectRef), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -171,14 +244,37 @@ func (c *Client) DeleteProject(projectRef string) error {
 	}
 	defer resp.Body.Close()
 
+	// A project that no longer exists is already deleted
+	if resp.StatusCode == http.StatusNotFound {
+		return nil
+	}
+
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return newAPIError(resp.StatusCode, bodyBytes)
 	}
 
 	return nil
 }
 
+// RenameProject changes the name of a Supabase project
+func (c *Client) RenameProject(projectRef, name string) error {
+	return c.managementRequest("PATCH", "/projects/"+projectRef, map[string]string{"name": name}, nil)
+}
+
+// RestartProject restarts a Supabase project. The project leaves
+// ACTIVE_HEALTHY while it restarts; use WaitForProject to wait for it.
+func (c *Client) RestartProject(projectRef string) error {
+	return c.managementRequest("POST", "/projects/"+projectRef+"/restart", nil, nil)
+}
+
+// UpdateComputeSize moves a Supabase project to another instance size by
+// applying the matching compute add-on, such as ci_small for "small"
+func (c *Client) UpdateComputeSize(projectRef, size string) error {
+	addon := map[string]string{"addon_type": "compute_instance", "addon_variant": "ci_" + size}
+	return c.managementRequest("PATCH", "/projects/"+projectRef+"/billing/addons", addon, nil)
+}
+
 // ProjectAPIKeys holds API keys for a project
 type ProjectAPIKeys struct {
 	AnonKey    string `json:"anon_key"`
@@ -188,7 +284,7 @@ type ProjectAPIKeys struct {
 // GetProjectAPIKeys retrieves the API keys for a project
 func (c *Client) GetProjectAPIKeys(projectRef string) (*ProjectAPIKeys, error) {
 	// Get project config which includes API keys
-	req, err := http.NewRequest("GET", managementAPIURL+"/projects/"+projectRef+"/api-keys", nil)
+	req, err := http.NewRequest("GET", c.url("/projects/"+projectRef+"/api-keys"), nil)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create request: %w", err)
 	}
@@ -203,7 +299,7 @@ func (c *Client) GetProjectAPIKeys(projectRef string) (*ProjectAPIKeys, error) {
 
 	if resp.StatusCode != http.StatusOK {
 		bodyBytes, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+		return nil, newAPIError(resp.StatusCode, bodyBytes)
 	}
 
 	var keys []struct {
@@ -211,8 +307,8 @@ func (c *Client) GetProjectAPIKeys(projectRef string) (*ProjectAPIKeys, error) {
 		APIKey string `json:"api_key"`
 	}
 	
-	if err := json.NewDecoder(resp.Body).Decode(&keys); err != nil {
-		return nil, fmt.Errorf("failed to decode response: %w", err)
+	if err := c.decode("/projects/"+projectRef+"/api-keys", resp.Body, &keys); err != nil {
+		return nil, err
 	}
 
 	result := &ProjectAPIKeys{}
@@ -229,7 +325,7 @@ func (c *Client) GetProjectAPIKeys(projectRef string) (*ProjectAPIKeys, error) {
 
 // TestConnection verifies that the Supabase API is reachable
 func (c *Client) TestConnection() error {
-	req, err := http.NewRequest("GET", managementAPIURL+"/projects", nil)
+	req, err := http.NewRequest("GET", c.url("/projects"), nil)
 	if err != nil {
 		return fmt.Errorf("failed to create request: %w", err)
 	}
@@ -253,6 +349,40 @@ func (c *Client) TestConnection() error {
 	return nil
 }
 
+// Organization is a Supabase organization visible to an access token
+type Organization struct {
+	ID   string `json:"id"`
+	Name string `json:"name"`
+	// Plan is the billing plan, which Supabase sets per organization. It is
+	// only returned when a single organization is fetched.
+	Plan string `json:"plan,omitempty"`
+}
+
+// HasOrganization reports whether the client's access token can see the
+// client's organization. An invalid token is returned as an error.
+func (c *Client) HasOrganization() (bool, error) {
+	var organizations []Organization
+	if err := c.managementRequest("GET", "/organizations", nil, &organizations); err != nil {
+		return false, err
+	}
+
+	for _, org := range organizations {
+		if org.ID == c.organizationID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// GetOrganization returns the client's organization, including its plan
+func (c *Client) GetOrganization() (*Organization, error) {
+	var org Organization
+	if err := c.managementRequest("GET", "/organizations/"+c.organizationID, nil, &org); err != nil {
+		return nil, err
+	}
+	return &org, nil
+}
+
 // generateSecurePassword generates a secure random password
 func generateSecurePassword() string {
 	// For production, use crypto/rand
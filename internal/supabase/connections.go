@@ -0,0 +1,84 @@
+package supabase
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// PoolerConfig is the configuration of a project's connection pooler
+// (Supavisor)
+type PoolerConfig struct {
+	DatabaseType    string `json:"database_type,omitempty"`
+	PoolMode        string `json:"pool_mode"`
+	DefaultPoolSize *int   `json:"default_pool_size,omitempty"`
+	MaxClientConn   *int   `json:"max_client_conn,omitempty"`
+}
+
+// ConnectionStats describes the client connections a database has open,
+// as seen from pg_stat_activity
+type ConnectionStats struct {
+	MaxConnections int `json:"max_connections"`
+	// ReservedConnections are kept for superusers and not available to
+	// clients
+	ReservedConnections int               `json:"reserved_connections"`
+	Open                int               `json:"open"`
+	ByClient            []ConnectionGroup `json:"by_client"`
+}
+
+// ConnectionGroup counts the connections of one user, application and state
+type ConnectionGroup struct {
+	User        string `json:"user"`
+	Application string `json:"application"`
+	State       string `json:"state"`
+	Count       int    `json:"count"`
+}
+
+// GetPoolerConfig returns the configuration of a project's connection
+// poolers
+func (c *Client) GetPoolerConfig(projectRef string) ([]PoolerConfig, error) {
+	var configs []PoolerConfig
+	if err := c.managementRequest("GET", "/projects/"+projectRef+"/config/database/pooler", nil, &configs); err != nil {
+		return nil, err
+	}
+	return configs, nil
+}
+
+// PoolStats returns the statistics of the runner's own connection pool
+func (mr *MigrationRunner) PoolStats() sql.DBStats {
+	return mr.db.Stats()
+}
+
+// ConnectionStats counts the client connections open in the database,
+// including the runner's own
+func (mr *MigrationRunner) ConnectionStats() (*ConnectionStats, error) {
+	stats := &ConnectionStats{ByClient: []ConnectionGroup{}}
+
+	err := mr.db.QueryRow(
+		"SELECT current_setting('max_connections')::int, current_setting('superuser_reserved_connections')::int",
+	).Scan(&stats.MaxConnections, &stats.ReservedConnections)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read connection limits: %w", err)
+	}
+
+	rows, err := mr.db.Query(`
+		SELECT coalesce(usename, ''), coalesce(application_name, ''), coalesce(state, ''), count(*)
+		FROM pg_stat_activity
+		WHERE backend_type = 'client backend'
+		GROUP BY 1, 2, 3
+		ORDER BY 4 DESC, 1, 2, 3`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list connections: %w", err)
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var group ConnectionGroup
+		if err := rows.Scan(&group.User, &group.Application, &group.State, &group.Count); err != nil {
+			return nil, fmt.Errorf("failed to scan connections: %w", err)
+		}
+		stats.Open += group.Count
+		stats.ByClient = append(stats.ByClient, group)
+	}
+
+	return stats, rows.Err()
+}
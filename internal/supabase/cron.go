@@ -0,0 +1,135 @@
+package supabase
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// cronField is the range of one field of a cron expression
+type cronField struct {
+	name     string
+	min, max int
+}
+
+// cronFields are the five fields of a cron expression, in order
+var cronFields = []cronField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// CronSchedule is a parsed five-field cron expression: minute, hour, day of
+// month, month and day of week (0 or 7 is Sunday). Fields take "*", values,
+// ranges such as "1-5", steps such as "*/15" and comma-separated lists of
+// these. As in cron, when both day fields are restricted a time matches if
+// either does.
+type CronSchedule struct {
+	fields [5]uint64
+	// anyDayOfMonth and anyDayOfWeek record which day fields start with
+	// "*", as cron does
+	anyDayOfMonth bool
+	anyDayOfWeek  bool
+}
+
+// ParseCronSchedule parses an expression such as "0 18 * * 5"
+func ParseCronSchedule(spec string) (*CronSchedule, error) {
+	parts := strings.Fields(spec)
+	if len(parts) != len(cronFields) {
+		return nil, fmt.Errorf("cron schedule %q must have 5 fields (minute hour day-of-month month day-of-week)", spec)
+	}
+
+	schedule := &CronSchedule{
+		anyDayOfMonth: strings.HasPrefix(parts[2], "*"),
+		anyDayOfWeek:  strings.HasPrefix(parts[4], "*"),
+	}
+	for i, part := range parts {
+		bits, err := parseCronField(part, cronFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("cron schedule %q: %w", spec, err)
+		}
+		schedule.fields[i] = bits
+	}
+
+	// Sunday may be written as 7
+	if schedule.fields[4]&(1<<7) != 0 {
+		schedule.fields[4] |= 1
+	}
+	return schedule, nil
+}
+
+// parseCronField parses one field into a bit set of the values it allows
+func parseCronField(spec string, field cronField) (uint64, error) {
+	var bits uint64
+	for _, term := range strings.Split(spec, ",") {
+		rangeSpec, stepSpec, hasStep := strings.Cut(term, "/")
+
+		low, high := field.min, field.max
+		if rangeSpec != "*" {
+			lowSpec, highSpec, isRange := strings.Cut(rangeSpec, "-")
+			var err error
+			if low, err = parseCronValue(lowSpec, field); err != nil {
+				return 0, err
+			}
+			high = low
+			if isRange {
+				if high, err = parseCronValue(highSpec, field); err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				// "5/15" runs from 5 to the end of the range
+				high = field.max
+			}
+			if high < low {
+				return 0, fmt.Errorf("invalid %s range %q", field.name, rangeSpec)
+			}
+		}
+
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepSpec)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid %s step %q", field.name, stepSpec)
+			}
+		}
+
+		for value := low; value <= high; value += step {
+			bits |= 1 << value
+		}
+	}
+	return bits, nil
+}
+
+// parseCronValue parses a single value of a field
+func parseCronValue(spec string, field cronField) (int, error) {
+	value, err := strconv.Atoi(spec)
+	if err != nil || value < field.min || value > field.max {
+		return 0, fmt.Errorf("invalid %s %q (expected %d-%d)", field.name, spec, field.min, field.max)
+	}
+	return value, nil
+}
+
+// Matches reports whether the schedule fires at the minute of t, in t's
+// location
+func (s *CronSchedule) Matches(t time.Time) bool {
+	if s.fields[0]&(1<<t.Minute()) == 0 || s.fields[1]&(1<<t.Hour()) == 0 || s.fields[3]&(1<<int(t.Month())) == 0 {
+		return false
+	}
+
+	dayOfMonth := s.fields[2]&(1<<t.Day()) != 0
+	dayOfWeek := s.fields[4]&(1<<int(t.Weekday())) != 0
+	switch {
+	case s.anyDayOfMonth && s.anyDayOfWeek:
+		return true
+	case s.anyDayOfMonth:
+		return dayOfWeek
+	case s.anyDayOfWeek:
+		return dayOfMonth
+	default:
+		return dayOfMonth || dayOfWeek
+	}
+}
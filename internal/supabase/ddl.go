@@ -0,0 +1,565 @@
+package supabase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+var (
+	createExtensionPattern = regexp.MustCompile(`(?is)^CREATE\s+EXTENSION\s+(?:IF\s+NOT\s+EXISTS\s+)?"?([\w-]+)"?`)
+	createIndexNamePattern = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?"?(\w+)"?\s+ON\s+(?:ONLY\s+)?([\w."]+)`)
+	addColumnPattern       = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+ADD\s+(?:COLUMN\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(.+)$`)
+	dropColumnPattern      = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+DROP\s+(?:COLUMN\s+)?(?:IF\s+EXISTS\s+)?"?(\w+)"?`)
+	dropConstraintPattern  = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+DROP\s+CONSTRAINT\s+(?:IF\s+EXISTS\s+)?"?(\w+)"?`)
+	dropTablePattern       = regexp.MustCompile(`(?is)^DROP\s+TABLE\s+(?:IF\s+EXISTS\s+)?([\w."]+)`)
+	tableConstraintPattern = regexp.MustCompile(`(?is)^(?:CONSTRAINT\s+"?(\w+)"?\s+)?(PRIMARY\s+KEY|UNIQUE|FOREIGN\s+KEY|CHECK|EXCLUDE)\b`)
+	expressionWordPattern  = regexp.MustCompile(`[A-Za-z_]\w*`)
+	stringLiteralPattern   = regexp.MustCompile(`'(?:[^']|'')*'`)
+	typeModifierPattern    = regexp.MustCompile(`\s*\([^)]*\)`)
+)
+
+// columnConstraintWords end the type in a column definition
+var columnConstraintWords = map[string]bool{
+	"not": true, "null": true, "default": true, "primary": true, "unique": true,
+	"references": true, "check": true, "constraint": true, "generated": true,
+	"collate": true,
+}
+
+// informationSchemaTypes maps type names as written in DDL to the data_type
+// reported by information_schema.columns
+var informationSchemaTypes = map[string]string{
+	"int":                         "integer",
+	"int4":                        "integer",
+	"integer":                     "integer",
+	"serial":                      "integer",
+	"serial4":                     "integer",
+	"bigint":                      "bigint",
+	"int8":                        "bigint",
+	"bigserial":                   "bigint",
+	"serial8":                     "bigint",
+	"smallint":                    "smallint",
+	"int2":                        "smallint",
+	"smallserial":                 "smallint",
+	"serial2":                     "smallint",
+	"text":                        "text",
+	"varchar":                     "character varying",
+	"character varying":           "character varying",
+	"char":                        "character",
+	"character":                   "character",
+	"bool":                        "boolean",
+	"boolean":                     "boolean",
+	"uuid":                        "uuid",
+	"json":                        "json",
+	"jsonb":                       "jsonb",
+	"date":                        "date",
+	"timestamp":                   "timestamp without time zone",
+	"timestamp without time zone": "timestamp without time zone",
+	"timestamptz":                 "timestamp with time zone",
+	"timestamp with time zone":    "timestamp with time zone",
+	"time":                        "time without time zone",
+	"time without time zone":      "time without time zone",
+	"timetz":                      "time with time zone",
+	"time with time zone":         "time with time zone",
+	"interval":                    "interval",
+	"numeric":                     "numeric",
+	"decimal":                     "numeric",
+	"real":                        "real",
+	"float4":                      "real",
+	"double precision":            "double precision",
+	"float8":                      "double precision",
+	"float":                       "double precision",
+	"bytea":                       "bytea",
+	"inet":                        "inet",
+	"cidr":                        "cidr",
+	"citext":                      "USER-DEFINED",
+}
+
+// serialTypes are implicitly NOT NULL
+var serialTypes = map[string]bool{
+	"serial": true, "serial4": true, "bigserial": true, "serial8": true,
+	"smallserial": true, "serial2": true,
+}
+
+// ParseSchemaSQL builds the schema snapshot a DDL script describes, for
+// comparison with a live database. It understands CREATE TABLE, CREATE
+// INDEX, CREATE EXTENSION, DROP TABLE and ALTER TABLE ADD/DROP COLUMN and
+// ADD/DROP CONSTRAINT; other statements are counted as ignored. Unnamed
+// constraints get the names Postgres would give them. Column types,
+// defaults, index and constraint definitions are kept as written rather
+// than in the form Postgres reports them, so the snapshot should be
+// compared with StructuralDrift.
+func ParseSchemaSQL(sql string) (*SchemaSnapshot, int, error) {
+	tables := make(map[string]*TableInfo)
+	var tableOrder []string
+	indexes := make(map[string]IndexInfo)
+	constraints := make(map[string]ConstraintInfo)
+	extensions := make(map[string]bool)
+	ignored := 0
+
+	// Primary key, unique and exclusion constraints are backed by an index
+	// of the same name
+	addConstraint := func(constraint ConstraintInfo) {
+		if constraint.Type != ConstraintCheck && constraint.Type != ConstraintExclude {
+			constraint.Columns = constraintColumns(constraint.Definition)
+		}
+		constraints[constraint.QualifiedName()] = constraint
+		if constraint.Type != ConstraintForeignKey && constraint.Type != ConstraintCheck {
+			indexes[constraint.Schema+"."+constraint.Name] = IndexInfo{Schema: constraint.Schema, Table: constraint.Table, Name: constraint.Name}
+		}
+	}
+
+	for _, stmt := range splitSQLStatements(sql) {
+		stmt = stripLeadingComments(strings.TrimSpace(stmt))
+		if stmt == "" {
+			continue
+		}
+
+		if m := createTablePattern.FindStringSubmatch(stmt); m != nil {
+			schema, name := splitQualifiedName(m[1])
+			table := &TableInfo{Schema: schema, Name: name}
+			var columnConstraints []ConstraintInfo
+			var tableConstraints []string
+
+			for _, def := range splitTopLevel(m[2]) {
+				def = strings.TrimSpace(def)
+				if def == "" {
+					continue
+				}
+				if tableConstraintPattern.MatchString(def) {
+					tableConstraints = append(tableConstraints, def)
+					continue
+				}
+
+				column, found, err := parseColumnDefinition(table, def)
+				if err != nil {
+					return nil, 0, fmt.Errorf("table %s.%s: %w", schema, name, err)
+				}
+				table.Columns = append(table.Columns, column)
+				columnConstraints = append(columnConstraints, found...)
+			}
+
+			key := table.QualifiedName()
+			if _, exists := tables[key]; !exists {
+				tableOrder = append(tableOrder, key)
+			}
+			tables[key] = table
+
+			for _, constraint := range columnConstraints {
+				addConstraint(constraint)
+			}
+			for _, def := range tableConstraints {
+				addConstraint(parseTableConstraint(table, def))
+			}
+			continue
+		}
+
+		if m := createIndexNamePattern.FindStringSubmatch(stmt); m != nil {
+			schema, table := splitQualifiedName(m[2])
+			name := strings.ToLower(m[1])
+			indexes[schema+"."+name] = IndexInfo{Schema: schema, Table: table, Name: name, Definition: stmt}
+			continue
+		}
+
+		if m := createExtensionPattern.FindStringSubmatch(stmt); m != nil {
+			extensions[strings.ToLower(m[1])] = true
+			continue
+		}
+
+		if m := dropConstraintPattern.FindStringSubmatch(stmt); m != nil {
+			schema, table := splitQualifiedName(m[1])
+			name := strings.ToLower(m[2])
+			delete(constraints, schema+"."+table+"."+name)
+			delete(indexes, schema+"."+name)
+			continue
+		}
+
+		if m := dropColumnPattern.FindStringSubmatch(stmt); m != nil {
+			if table, ok := tables[normalizeTableName(m[1])]; ok {
+				dropped := strings.ToLower(m[2])
+				kept := table.Columns[:0]
+				for _, col := range table.Columns {
+					if col.Name != dropped {
+						kept = append(kept, col)
+					}
+				}
+				table.Columns = kept
+			}
+			continue
+		}
+
+		if m := addColumnPattern.FindStringSubmatch(stmt); m != nil {
+			table, ok := tables[normalizeTableName(m[1])]
+			if !ok {
+				ignored++
+				continue
+			}
+			if tableConstraintPattern.MatchString(m[2]) {
+				addConstraint(parseTableConstraint(table, m[2]))
+				continue
+			}
+			column, found, err := parseColumnDefinition(table, m[2])
+			if err != nil {
+				return nil, 0, fmt.Errorf("table %s: %w", table.QualifiedName(), err)
+			}
+			table.Columns = append(table.Columns, column)
+			for _, constraint := range found {
+				addConstraint(constraint)
+			}
+			continue
+		}
+
+		if m := dropTablePattern.FindStringSubmatch(stmt); m != nil {
+			delete(tables, normalizeTableName(m[1]))
+			continue
+		}
+
+		ignored++
+	}
+
+	if len(tables) == 0 && len(extensions) == 0 {
+		return nil, 0, fmt.Errorf("no CREATE TABLE or CREATE EXTENSION statements found")
+	}
+
+	// Primary key columns are NOT NULL
+	for _, constraint := range constraints {
+		table, ok := tables[constraint.Schema+"."+constraint.Table]
+		if !ok || constraint.Type != ConstraintPrimaryKey {
+			continue
+		}
+		keyColumns := make(map[string]bool)
+		for _, col := range constraintColumns(constraint.Definition) {
+			keyColumns[col] = true
+		}
+		for i := range table.Columns {
+			if keyColumns[table.Columns[i].Name] {
+				table.Columns[i].Nullable = false
+			}
+		}
+	}
+
+	snapshot := &SchemaSnapshot{
+		Tables:            []TableInfo{},
+		Indexes:           []IndexInfo{},
+		Constraints:       []ConstraintInfo{},
+		Extensions:        []ExtensionInfo{},
+		MigrationVersions: []string{},
+	}
+	for _, key := range tableOrder {
+		if table, ok := tables[key]; ok {
+			snapshot.Tables = append(snapshot.Tables, *table)
+		}
+	}
+	for _, key := range sortedKeys(indexes) {
+		index := indexes[key]
+		if _, ok := tables[index.Schema+"."+index.Table]; ok {
+			snapshot.Indexes = append(snapshot.Indexes, index)
+		}
+	}
+	for _, key := range sortedKeys(constraints) {
+		constraint := constraints[key]
+		if _, ok := tables[constraint.Schema+"."+constraint.Table]; ok {
+			snapshot.Constraints = append(snapshot.Constraints, constraint)
+		}
+	}
+	for _, name := range sortedKeys(extensions) {
+		snapshot.Extensions = append(snapshot.Extensions, ExtensionInfo{Name: name})
+	}
+
+	return snapshot, ignored, nil
+}
+
+// parseColumnDefinition parses "name type [constraints]" of a column of
+// table, returning the column and its primary key, unique, foreign key and
+// check constraints
+func parseColumnDefinition(table *TableInfo, def string) (ColumnInfo, []ConstraintInfo, error) {
+	words := sqlWords(def)
+	if len(words) == 0 {
+		return ColumnInfo{}, nil, fmt.Errorf("cannot parse column definition %q", def)
+	}
+
+	column := ColumnInfo{Name: strings.ToLower(strings.Trim(words[0], `"`)), Nullable: true}
+
+	i := 1
+	for i < len(words) && !columnConstraintWords[strings.ToLower(words[i])] {
+		i++
+	}
+	if i == 1 {
+		return ColumnInfo{}, nil, fmt.Errorf("column %s has no type", column.Name)
+	}
+	column.Type = strings.Join(words[1:i], " ")
+	typeName := strings.ToLower(typeModifierPattern.ReplaceAllString(column.Type, ""))
+	column.DataType = normalizeDataType(typeName)
+	if serialTypes[typeName] {
+		column.Nullable = false
+	}
+
+	var constraints []ConstraintInfo
+	constraintName := ""
+	add := func(constraintType, definition, defaultName string) {
+		name := constraintName
+		if name == "" {
+			name = defaultName
+		}
+		constraints = append(constraints, ConstraintInfo{
+			Schema:     table.Schema,
+			Table:      table.Name,
+			Name:       name,
+			Type:       constraintType,
+			Definition: definition,
+		})
+		constraintName = ""
+	}
+
+	for i < len(words) {
+		word := strings.ToLower(words[i])
+		switch {
+		case word == "constraint" && i+1 < len(words):
+			constraintName = strings.ToLower(strings.Trim(words[i+1], `"`))
+			i += 2
+		case word == "not" && i+1 < len(words) && strings.EqualFold(words[i+1], "null"):
+			column.Nullable = false
+			i += 2
+		case word == "primary" && i+1 < len(words) && strings.EqualFold(words[i+1], "key"):
+			column.Nullable = false
+			add(ConstraintPrimaryKey, "PRIMARY KEY ("+column.Name+")", table.Name+"_pkey")
+			i += 2
+		case word == "unique":
+			add(ConstraintUnique, "UNIQUE ("+column.Name+")", table.Name+"_"+column.Name+"_key")
+			i++
+		case word == "check" && i+1 < len(words):
+			expr := words[i+1]
+			add(ConstraintCheck, "CHECK "+expr, checkConstraintName(table, expr, column.Name))
+			i += 2
+		case word == "references" && i+1 < len(words):
+			end := i + 2
+			if end < len(words) && strings.HasPrefix(words[end], "(") {
+				end++
+			}
+			end = skipReferentialActions(words, end)
+			add(ConstraintForeignKey, "FOREIGN KEY ("+column.Name+") REFERENCES "+strings.Join(words[i+1:end], " "), table.Name+"_"+column.Name+"_fkey")
+			i = end
+		case word == "default":
+			end := i + 1
+			for end < len(words) && !columnConstraintWords[strings.ToLower(words[end])] {
+				end++
+			}
+			column.Default = strings.Join(words[i+1:end], " ")
+			i = end
+		default:
+			i++
+		}
+	}
+
+	return column, constraints, nil
+}
+
+// parseTableConstraint parses a table constraint of table, such as
+// "CONSTRAINT name FOREIGN KEY (a) REFERENCES b(id)"
+func parseTableConstraint(table *TableInfo, def string) ConstraintInfo {
+	def = strings.TrimSpace(def)
+	m := tableConstraintPattern.FindStringSubmatchIndex(def)
+	constraint := ConstraintInfo{
+		Schema:     table.Schema,
+		Table:      table.Name,
+		Type:       strings.ToUpper(strings.Join(strings.Fields(def[m[4]:m[5]]), " ")),
+		Definition: def[m[4]:],
+	}
+	if m[2] >= 0 {
+		constraint.Name = strings.ToLower(def[m[2]:m[3]])
+	}
+	if constraint.Name != "" {
+		return constraint
+	}
+
+	columns := constraintColumns(constraint.Definition)
+	switch constraint.Type {
+	case ConstraintPrimaryKey:
+		constraint.Name = table.Name + "_pkey"
+	case ConstraintUnique:
+		constraint.Name = table.Name + "_" + strings.Join(columns, "_") + "_key"
+	case ConstraintForeignKey:
+		constraint.Name = table.Name + "_" + strings.Join(columns, "_") + "_fkey"
+	case ConstraintCheck:
+		constraint.Name = checkConstraintName(table, def[m[5]:], "")
+	default:
+		constraint.Name = table.Name + "_excl"
+	}
+	return constraint
+}
+
+// checkConstraintName returns the name Postgres gives an unnamed check
+// constraint: table_column_check when the expression refers to one column
+// only, table_check otherwise
+func checkConstraintName(table *TableInfo, expr, column string) string {
+	known := make(map[string]bool)
+	for _, col := range table.Columns {
+		known[col.Name] = true
+	}
+	if column != "" {
+		known[column] = true
+	}
+
+	referenced := make(map[string]bool)
+	for _, word := range expressionWordPattern.FindAllString(stringLiteralPattern.ReplaceAllString(expr, ""), -1) {
+		if word = strings.ToLower(word); known[word] {
+			referenced[word] = true
+		}
+	}
+	if len(referenced) == 1 {
+		for col := range referenced {
+			return table.Name + "_" + col + "_check"
+		}
+	}
+	return table.Name + "_check"
+}
+
+// constraintColumns returns the columns in the first parenthesized list of
+// a constraint definition, such as a and b of "UNIQUE (a, b)"
+func constraintColumns(definition string) []string {
+	open := strings.Index(definition, "(")
+	closing := strings.Index(definition, ")")
+	if open < 0 || closing < open {
+		return nil
+	}
+	return splitIdentifiers(definition[open+1 : closing])
+}
+
+// skipReferentialActions returns the index of the first word at or after
+// start that is not part of the ON DELETE, ON UPDATE, MATCH and
+// DEFERRABLE clauses of a foreign key
+func skipReferentialActions(words []string, start int) int {
+	i := start
+	for i < len(words) {
+		word := strings.ToLower(words[i])
+		next := ""
+		if i+1 < len(words) {
+			next = strings.ToLower(words[i+1])
+		}
+		switch {
+		case word == "on" && (next == "delete" || next == "update"):
+			i += 2
+			if i < len(words) {
+				action := strings.ToLower(words[i])
+				i++
+				if (action == "set" || action == "no") && i < len(words) {
+					i++
+				}
+			}
+		case word == "match" || word == "initially":
+			i += 2
+		case word == "deferrable":
+			i++
+		case word == "not" && next == "deferrable":
+			i += 2
+		default:
+			return i
+		}
+	}
+	return i
+}
+
+// sqlWords splits SQL on whitespace outside parentheses and quotes, so
+// "numeric(10, 2) DEFAULT 'a b'" is three words
+func sqlWords(s string) []string {
+	var words []string
+	var quote rune
+	depth, start := 0, -1
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == '(':
+			depth++
+		case r == ')':
+			depth--
+		case depth == 0 && (r == ' ' || r == '\t' || r == '\n' || r == '\r'):
+			if start >= 0 {
+				words = append(words, s[start:i])
+				start = -1
+			}
+			continue
+		}
+		if start < 0 {
+			start = i
+		}
+	}
+	if start >= 0 {
+		words = append(words, s[start:])
+	}
+	return words
+}
+
+// normalizeDataType maps a DDL type name to its information_schema data_type
+func normalizeDataType(typeName string) string {
+	if strings.HasSuffix(typeName, "[]") || strings.HasPrefix(typeName, "_") {
+		return "ARRAY"
+	}
+	typeName = strings.TrimPrefix(typeName, "pg_catalog.")
+	if mapped, ok := informationSchemaTypes[typeName]; ok {
+		return mapped
+	}
+	// Enums, domains and extension types such as vector
+	return "USER-DEFINED"
+}
+
+// splitIdentifiers splits a comma-separated column list
+func splitIdentifiers(list string) []string {
+	var names []string
+	for _, name := range strings.Split(list, ",") {
+		name = strings.ToLower(strings.Trim(strings.TrimSpace(name), `"`))
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// StructuralDrift compares a live snapshot against one parsed from DDL. Only
+// what ParseSchemaSQL derives is compared: tables, columns with their types
+// and nullability, index and constraint names and the presence of the
+// expected extensions.
+// Extensions Supabase installs on its own are not reported as drift.
+func StructuralDrift(expected, actual *SchemaSnapshot) *SchemaComparison {
+	return CompareSnapshots(structural(expected, nil), structural(actual, expected))
+}
+
+// structural strips what DDL parsing cannot derive from a snapshot. When
+// expected is set, extensions it does not mention are dropped.
+func structural(snapshot, expected *SchemaSnapshot) *SchemaSnapshot {
+	result := &SchemaSnapshot{}
+
+	for _, table := range snapshot.Tables {
+		columns := make([]ColumnInfo, len(table.Columns))
+		for i, col := range table.Columns {
+			columns[i] = ColumnInfo{Name: col.Name, DataType: col.DataType, Nullable: col.Nullable}
+		}
+		result.Tables = append(result.Tables, TableInfo{Schema: table.Schema, Name: table.Name, Columns: columns})
+	}
+
+	for _, index := range snapshot.Indexes {
+		result.Indexes = append(result.Indexes, IndexInfo{Schema: index.Schema, Table: index.Table, Name: index.Name})
+	}
+
+	for _, constraint := range snapshot.Constraints {
+		result.Constraints = append(result.Constraints, ConstraintInfo{Schema: constraint.Schema, Table: constraint.Table, Name: constraint.Name, Type: constraint.Type})
+	}
+
+	wanted := make(map[string]bool)
+	if expected != nil {
+		for _, ext := range expected.Extensions {
+			wanted[ext.Name] = true
+		}
+	}
+	for _, ext := range snapshot.Extensions {
+		if expected == nil || wanted[ext.Name] {
+			result.Extensions = append(result.Extensions, ExtensionInfo{Name: ext.Name})
+		}
+	}
+
+	return result
+}
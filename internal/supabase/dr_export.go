@@ -0,0 +1,170 @@
+package supabase
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// DRExportFormat identifies the disaster recovery export format
+const DRExportFormat = "supabase-manager-dr/v1"
+
+// DR export fields, also bound into the ciphertext of each value so a value
+// cannot be moved to another field or project
+const (
+	DRFieldDBPassword = "db_password"
+	DRFieldServiceKey = "service_role_key"
+)
+
+// DRKey is the wrapping key of disaster recovery exports, kept apart from
+// the manager's state so the export is useless without it
+type DRKey []byte
+
+// ParseDRKey decodes a base64 encoded 32 byte key, as generated by
+// openssl rand -base64 32
+func ParseDRKey(encoded string) (DRKey, error) {
+	key, err := base64.StdEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, fmt.Errorf("key must be base64 encoded: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("key must be 32 bytes, got %d", len(key))
+	}
+	return DRKey(key), nil
+}
+
+// ID fingerprints the key, so an export can be matched to the key that
+// wrapped it without revealing the key
+func (k DRKey) ID() string {
+	sum := sha256.Sum256(k)
+	return hex.EncodeToString(sum[:8])
+}
+
+// DRExport is the minimal credential set needed to reach each project in a
+// break-glass procedure. Secrets are sealed with AES-256-GCM under the DR
+// key; everything else is in the clear.
+type DRExport struct {
+	Format    string          `json:"format"`
+	KeyID     string          `json:"key_id"`
+	CreatedAt time.Time       `json:"created_at"`
+	Projects  []DRCredentials `json:"projects"`
+}
+
+// DRCredentials are the credentials of one project. Sealed values are
+// base64 encoded nonces followed by ciphertext; a value the manager does not
+// hold, as for projects created with caller-supplied credentials, is left
+// out.
+type DRCredentials struct {
+	ProjectID  string `json:"project_id"`
+	Name       string `json:"name"`
+	ProjectRef string `json:"project_ref"`
+	Region     string `json:"region"`
+	DBPassword string `json:"db_password,omitempty"`
+	ServiceKey string `json:"service_role_key,omitempty"`
+}
+
+// NewDRExport seals the credentials of the projects under the key
+func NewDRExport(key DRKey, projects []*StoredProject, now time.Time) (*DRExport, error) {
+	export := &DRExport{
+		Format:    DRExportFormat,
+		KeyID:     key.ID(),
+		CreatedAt: now,
+		Projects:  make([]DRCredentials, 0, len(projects)),
+	}
+
+	for _, project := range projects {
+		credentials := DRCredentials{
+			ProjectID:  project.ID,
+			Name:       project.Name,
+			ProjectRef: project.ProjectRef,
+			Region:     project.Region,
+		}
+		var err error
+		if credentials.DBPassword, err = key.seal(project.ID, DRFieldDBPassword, project.DBPassword); err != nil {
+			return nil, err
+		}
+		if credentials.ServiceKey, err = key.seal(project.ID, DRFieldServiceKey, project.ServiceKey); err != nil {
+			return nil, err
+		}
+		export.Projects = append(export.Projects, credentials)
+	}
+	return export, nil
+}
+
+// Open decrypts the sealed values of the export in place, failing if the
+// export was not wrapped with the key or was tampered with
+func (e *DRExport) Open(key DRKey) error {
+	if e.Format != DRExportFormat {
+		return fmt.Errorf("unsupported export format %q", e.Format)
+	}
+	if e.KeyID != key.ID() {
+		return fmt.Errorf("export was wrapped with key %s, not %s", e.KeyID, key.ID())
+	}
+
+	for i := range e.Projects {
+		credentials := &e.Projects[i]
+		var err error
+		if credentials.DBPassword, err = key.open(credentials.ProjectID, DRFieldDBPassword, credentials.DBPassword); err != nil {
+			return err
+		}
+		if credentials.ServiceKey, err = key.open(credentials.ProjectID, DRFieldServiceKey, credentials.ServiceKey); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// aead returns the AES-256-GCM cipher of the key
+func (k DRKey) aead() (cipher.AEAD, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DR key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
+
+// seal encrypts a value of a project, binding the project and field as
+// additional data; empty values stay empty
+func (k DRKey) seal(projectID, field, value string) (string, error) {
+	if value == "" {
+		return "", nil
+	}
+	aead, err := k.aead()
+	if err != nil {
+		return "", err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	sealed := aead.Seal(nonce, nonce, []byte(value), []byte(projectID+"/"+field))
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// open decrypts a value sealed by seal
+func (k DRKey) open(projectID, field, sealed string) (string, error) {
+	if sealed == "" {
+		return "", nil
+	}
+	aead, err := k.aead()
+	if err != nil {
+		return "", err
+	}
+
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < aead.NonceSize() {
+		return "", fmt.Errorf("%s of project %s is not a sealed value", field, projectID)
+	}
+	nonce, ciphertext := data[:aead.NonceSize()], data[aead.NonceSize():]
+	value, err := aead.Open(nil, nonce, ciphertext, []byte(projectID+"/"+field))
+	if err != nil {
+		return "", fmt.Errorf("%s of project %s could not be decrypted: %w", field, projectID, err)
+	}
+	return string(value), nil
+}
@@ -0,0 +1,90 @@
+package supabase
+
+import (
+	"bytes"
+	"encoding/base64"
+	"strings"
+	"testing"
+	"time"
+)
+
+func testDRKey(t *testing.T, fill byte) DRKey {
+	t.Helper()
+
+	key, err := ParseDRKey(base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{fill}, 32)))
+	if err != nil {
+		t.Fatalf("parse key: %v", err)
+	}
+	return key
+}
+
+func testDRProjects() []*StoredProject {
+	return []*StoredProject{
+		{ID: "p1", Name: "one", ProjectRef: "refone", Region: "us-east-1", DBPassword: "password-one", ServiceKey: "service-one"},
+		{ID: "p2", Name: "two", ProjectRef: "reftwo", Region: "eu-west-1", DBPassword: "password-two", ServiceKey: "service-two"},
+	}
+}
+
+func TestDRExportRoundTrip(t *testing.T) {
+	key := testDRKey(t, 1)
+	export, err := NewDRExport(key, testDRProjects(), time.Now())
+	if err != nil {
+		t.Fatalf("new export: %v", err)
+	}
+
+	for _, credentials := range export.Projects {
+		if strings.Contains(credentials.DBPassword, "password") || strings.Contains(credentials.ServiceKey, "service") {
+			t.Fatalf("export holds a secret in the clear: %+v", credentials)
+		}
+	}
+
+	if err := export.Open(key); err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	if got := export.Projects[1]; got.DBPassword != "password-two" || got.ServiceKey != "service-two" {
+		t.Errorf("opened credentials %+v", got)
+	}
+}
+
+func TestDRExportOpenRejects(t *testing.T) {
+	key := testDRKey(t, 1)
+	seal := func() *DRExport {
+		export, err := NewDRExport(key, testDRProjects(), time.Now())
+		if err != nil {
+			t.Fatalf("new export: %v", err)
+		}
+		return export
+	}
+
+	if err := seal().Open(testDRKey(t, 2)); err == nil {
+		t.Error("opened with another key")
+	}
+
+	// A sealed value is bound to its project and field
+	swapped := seal()
+	swapped.Projects[0].DBPassword, swapped.Projects[1].DBPassword = swapped.Projects[1].DBPassword, swapped.Projects[0].DBPassword
+	if err := swapped.Open(key); err == nil {
+		t.Error("opened values moved between projects")
+	}
+	moved := seal()
+	moved.Projects[0].ServiceKey = moved.Projects[0].DBPassword
+	if err := moved.Open(key); err == nil {
+		t.Error("opened a value moved between fields")
+	}
+
+	tampered := seal()
+	data, _ := base64.StdEncoding.DecodeString(tampered.Projects[0].ServiceKey)
+	data[len(data)-1] ^= 1
+	tampered.Projects[0].ServiceKey = base64.StdEncoding.EncodeToString(data)
+	if err := tampered.Open(key); err == nil {
+		t.Error("opened a tampered value")
+	}
+}
+
+func TestParseDRKey(t *testing.T) {
+	for _, encoded := range []string{"not base64!", base64.StdEncoding.EncodeToString(make([]byte, 16))} {
+		if _, err := ParseDRKey(encoded); err == nil {
+			t.Errorf("ParseDRKey(%q) accepted an invalid key", encoded)
+		}
+	}
+}
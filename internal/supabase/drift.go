@@ -0,0 +1,62 @@
+package supabase
+
+// DriftReportRequest asks for a fleet drift report. Exactly one of
+// CanonicalSQL and ReferenceProjectID gives the expected schema.
+type DriftReportRequest struct {
+	CanonicalSQL       string `json:"canonical_sql,omitempty"`
+	ReferenceProjectID string `json:"reference_project_id,omitempty"`
+	// LabelSelector picks the projects to check; empty checks all
+	LabelSelector string `json:"label_selector,omitempty"`
+}
+
+// Drift statuses of a single project
+const (
+	DriftInSync  = "in_sync"
+	DriftDrifted = "drifted"
+	DriftError   = "error"
+	DriftSkipped = "skipped"
+)
+
+// ProjectDrift is the drift summary of one project
+type ProjectDrift struct {
+	ProjectID   string            `json:"project_id"`
+	Name        string            `json:"name"`
+	Status      string            `json:"status"`
+	Differences *SchemaComparison `json:"differences,omitempty"`
+	Error       string            `json:"error,omitempty"`
+}
+
+// DriftSummary counts projects by drift status
+type DriftSummary struct {
+	Total   int `json:"total"`
+	InSync  int `json:"in_sync"`
+	Drifted int `json:"drifted"`
+	Errors  int `json:"errors"`
+	Skipped int `json:"skipped"`
+}
+
+// DriftReport is the result of a drift report job
+type DriftReport struct {
+	// Canonical is "sql" or "project:<id>"
+	Canonical         string         `json:"canonical"`
+	LabelSelector     string         `json:"label_selector"`
+	IgnoredStatements int            `json:"ignored_statements,omitempty"`
+	Summary           DriftSummary   `json:"summary"`
+	Projects          []ProjectDrift `json:"projects"`
+}
+
+// Add records a project's drift and counts it in the summary
+func (r *DriftReport) Add(drift ProjectDrift) {
+	r.Projects = append(r.Projects, drift)
+	r.Summary.Total++
+	switch drift.Status {
+	case DriftInSync:
+		r.Summary.InSync++
+	case DriftDrifted:
+		r.Summary.Drifted++
+	case DriftError:
+		r.Summary.Errors++
+	case DriftSkipped:
+		r.Summary.Skipped++
+	}
+}
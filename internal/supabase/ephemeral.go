@@ -0,0 +1,167 @@
+package supabase
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// EphemeralRoleTTL bounds how long a per-run role can log in, should the
+// manager fail to drop it
+const EphemeralRoleTTL = 15 * time.Minute
+
+// ephemeralRolePrefix names the roles created for single migration runs
+const ephemeralRolePrefix = "mgr_run_"
+
+// connectionString is a DatabaseTarget for a ready-made connection string
+type connectionString string
+
+func (c connectionString) GetDatabaseConnectionString() string {
+	return string(c)
+}
+
+// ephemeralRole is a short-lived login role owned by a migration runner
+type ephemeralRole struct {
+	admin  *MigrationRunner
+	name   string
+	parent string
+}
+
+// NewEphemeralMigrationRunner connects to the target with a login role
+// created for this runner alone. The target's own credentials are only used
+// to create the role, which inherits the connecting user's privileges,
+// expires after EphemeralRoleTTL and is dropped when the runner is closed.
+// Migrations run as the connecting user unless a role is given, so objects
+// they create are not owned by the temporary role.
+func NewEphemeralMigrationRunner(target DatabaseTarget) (*MigrationRunner, error) {
+	admin, err := NewMigrationRunner(target)
+	if err != nil {
+		return nil, err
+	}
+
+	var parent string
+	if err := admin.db.QueryRow("SELECT current_user").Scan(&parent); err != nil {
+		admin.Close()
+		return nil, fmt.Errorf("failed to get current user: %w", err)
+	}
+
+	// Roles left behind by a crash have expired by now
+	admin.dropExpiredRoles(parent)
+
+	suffix, err := randomHex(6)
+	if err != nil {
+		admin.Close()
+		return nil, fmt.Errorf("failed to generate role name: %w", err)
+	}
+	password, err := randomHex(24)
+	if err != nil {
+		admin.Close()
+		return nil, fmt.Errorf("failed to generate password: %w", err)
+	}
+	name := ephemeralRolePrefix + suffix
+
+	connStr, err := withCredentials(target.GetDatabaseConnectionString(), name, password)
+	if err != nil {
+		admin.Close()
+		return nil, err
+	}
+
+	validUntil := time.Now().Add(EphemeralRoleTTL).UTC().Format(time.RFC3339)
+	_, err = admin.db.Exec(fmt.Sprintf("CREATE ROLE %s LOGIN PASSWORD %s VALID UNTIL %s IN ROLE %s",
+		quoteIdentifier(name), quoteLiteral(password), quoteLiteral(validUntil), quoteIdentifier(parent)))
+	if err != nil {
+		admin.Close()
+		return nil, fmt.Errorf("failed to create temporary role: %w", err)
+	}
+
+	runner, err := NewMigrationRunner(connectionString(connStr))
+	if err != nil {
+		admin.dropRole(name, parent)
+		admin.Close()
+		return nil, fmt.Errorf("failed to connect as temporary role: %w", err)
+	}
+
+	runner.target = target
+	runner.defaultRole = parent
+	runner.ephemeral = &ephemeralRole{admin: admin, name: name, parent: parent}
+	return runner, nil
+}
+
+// close drops the role and closes the admin connection
+func (r *ephemeralRole) close() error {
+	err := r.admin.dropRole(r.name, r.parent)
+	if closeErr := r.admin.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+// dropRole hands anything the role owns to parent and drops it
+func (mr *MigrationRunner) dropRole(name, parent string) error {
+	statements := []string{
+		fmt.Sprintf("REASSIGN OWNED BY %s TO %s", quoteIdentifier(name), quoteIdentifier(parent)),
+		fmt.Sprintf("DROP OWNED BY %s", quoteIdentifier(name)),
+		fmt.Sprintf("DROP ROLE IF EXISTS %s", quoteIdentifier(name)),
+	}
+	for _, stmt := range statements {
+		if _, err := mr.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to drop temporary role %s: %w", name, err)
+		}
+	}
+	return nil
+}
+
+// dropExpiredRoles drops per-run roles whose validity has passed, which a
+// previous runner could not clean up. Failures are ignored; the roles can no
+// longer log in.
+func (mr *MigrationRunner) dropExpiredRoles(parent string) {
+	rows, err := mr.db.Query(
+		`SELECT rolname FROM pg_roles WHERE rolname LIKE $1 AND rolvaliduntil < now()`,
+		strings.ReplaceAll(ephemeralRolePrefix, "_", `\_`)+"%",
+	)
+	if err != nil {
+		return
+	}
+
+	var expired []string
+	for rows.Next() {
+		var name string
+		if rows.Scan(&name) == nil {
+			expired = append(expired, name)
+		}
+	}
+	rows.Close()
+
+	for _, name := range expired {
+		mr.dropRole(name, parent)
+	}
+}
+
+// withCredentials returns the connection string with another user and
+// password. Supabase pooler users carry the project ref as a suffix
+// (postgres.<ref>), which is kept.
+func withCredentials(connStr, user, password string) (string, error) {
+	u, err := url.Parse(connStr)
+	if err != nil || u.User == nil {
+		return "", fmt.Errorf("cannot derive a connection string for a temporary role")
+	}
+
+	if current := u.User.Username(); strings.Contains(u.Host, "pooler.supabase.com") && strings.Contains(current, ".") {
+		user += current[strings.Index(current, "."):]
+	}
+
+	u.User = url.UserPassword(user, password)
+	return u.String(), nil
+}
+
+// randomHex returns n random bytes, hex encoded
+func randomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
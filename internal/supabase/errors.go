@@ -0,0 +1,137 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// APIErrorKind classifies a Management API error
+type APIErrorKind string
+
+// Management API error kinds
+const (
+	ErrKindQuotaExceeded   APIErrorKind = "quota_exceeded"
+	ErrKindInvalidRegion   APIErrorKind = "invalid_region"
+	ErrKindNameTaken       APIErrorKind = "name_taken"
+	ErrKindPlanRestriction APIErrorKind = "plan_restriction"
+	ErrKindUnauthorized    APIErrorKind = "unauthorized"
+	ErrKindForbidden       APIErrorKind = "forbidden"
+	ErrKindNotFound        APIErrorKind = "not_found"
+	ErrKindRateLimited     APIErrorKind = "rate_limited"
+	ErrKindInvalidRequest  APIErrorKind = "invalid_request"
+	ErrKindUpstream        APIErrorKind = "upstream"
+	ErrKindRegionCapacity  APIErrorKind = "region_capacity"
+)
+
+// APIError is an error response from the Management API
+type APIError struct {
+	StatusCode int
+	Kind       APIErrorKind
+	// Message is the message from the response body, or the raw body when
+	// it is not JSON
+	Message string
+	Body    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("API error (status %d): %s", e.StatusCode, e.Body)
+}
+
+// HTTPStatus returns the status the manager should answer with for this
+// error
+func (e *APIError) HTTPStatus() int {
+	switch e.Kind {
+	case ErrKindQuotaExceeded, ErrKindPlanRestriction:
+		return http.StatusPaymentRequired
+	case ErrKindInvalidRegion, ErrKindInvalidRequest:
+		return http.StatusBadRequest
+	case ErrKindNameTaken:
+		return http.StatusConflict
+	case ErrKindNotFound:
+		return http.StatusNotFound
+	case ErrKindRateLimited:
+		return http.StatusTooManyRequests
+	case ErrKindRegionCapacity:
+		return http.StatusServiceUnavailable
+	default:
+		// The manager's own credentials failing is not the caller's fault
+		return http.StatusBadGateway
+	}
+}
+
+// Code returns the error code used in the manager's error responses
+func (e *APIError) Code() string {
+	return "SUPABASE_" + strings.ToUpper(string(e.Kind))
+}
+
+// newAPIError parses a Management API error response. The API returns
+// {"message": "..."}; some endpoints use "error" instead.
+func newAPIError(statusCode int, body []byte) *APIError {
+	apiErr := &APIError{
+		StatusCode: statusCode,
+		Message:    strings.TrimSpace(string(body)),
+		Body:       string(body),
+	}
+
+	var parsed struct {
+		Message string `json:"message"`
+		Error   string `json:"error"`
+	}
+	if err := json.Unmarshal(body, &parsed); err == nil {
+		if parsed.Message != "" {
+			apiErr.Message = parsed.Message
+		} else if parsed.Error != "" {
+			apiErr.Message = parsed.Error
+		}
+	}
+
+	apiErr.Kind = classifyAPIError(statusCode, strings.ToLower(apiErr.Message))
+	return apiErr
+}
+
+// classifyAPIError picks an error kind from the status code and message
+func classifyAPIError(statusCode int, message string) APIErrorKind {
+	switch {
+	case statusCode == http.StatusUnauthorized:
+		return ErrKindUnauthorized
+	case statusCode == http.StatusTooManyRequests:
+		return ErrKindRateLimited
+	case statusCode == http.StatusNotFound:
+		return ErrKindNotFound
+	case containsAny(message, "capacity", "no available instances", "resources exhausted"):
+		return ErrKindRegionCapacity
+	case statusCode == http.StatusPaymentRequired:
+		if strings.Contains(message, "plan") {
+			return ErrKindPlanRestriction
+		}
+		return ErrKindQuotaExceeded
+	case containsAny(message, "quota", "limit reached", "maximum number", "exceeded"):
+		return ErrKindQuotaExceeded
+	case strings.Contains(message, "region") && containsAny(message, "invalid", "not supported", "unsupported", "must be one of"):
+		return ErrKindInvalidRegion
+	case containsAny(message, "already exists", "already taken", "name is taken", "duplicate"):
+		return ErrKindNameTaken
+	case containsAny(message, "plan", "upgrade"):
+		return ErrKindPlanRestriction
+	case statusCode == http.StatusForbidden:
+		return ErrKindForbidden
+	case statusCode == http.StatusConflict:
+		return ErrKindNameTaken
+	case statusCode >= 400 && statusCode < 500:
+		return ErrKindInvalidRequest
+	default:
+		return ErrKindUpstream
+	}
+}
+
+// containsAny reports whether s contains any of the substrings
+func containsAny(s string, substrings ...string) bool {
+	for _, sub := range substrings {
+		if strings.Contains(s, sub) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,242 @@
+package supabase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Feature flag types
+const (
+	FlagBoolean = "boolean"
+	FlagString  = "string"
+	FlagNumber  = "number"
+	FlagJSON    = "json"
+)
+
+// Places feature flags are synced to
+const (
+	// FlagSyncTable replaces the rows of public.feature_flags in the
+	// project's database
+	FlagSyncTable = "table"
+	// FlagSyncSecrets sets an Edge Function secret per flag
+	FlagSyncSecrets = "secrets"
+)
+
+// FlagSecretPrefix starts the names of the secrets flags are synced to, so
+// FLAG_NEW_CHECKOUT holds the flag new_checkout
+const FlagSecretPrefix = "FLAG_"
+
+// maxFlagValueBytes caps the encoded value of a flag
+const maxFlagValueBytes = 16 << 10
+
+// flagKeyPattern matches flag keys. Keys become secret names, so they are
+// limited to what maps onto one unambiguously.
+var flagKeyPattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// FeatureFlag is a typed value the manager holds for a project. The
+// manager's copy is authoritative; syncs overwrite the project's copies.
+type FeatureFlag struct {
+	ProjectID   string          `json:"-"`
+	Key         string          `json:"key"`
+	Type        string          `json:"type"`
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description,omitempty"`
+	UpdatedBy   string          `json:"updated_by"`
+	UpdatedAt   time.Time       `json:"updated_at"`
+}
+
+// SetFeatureFlagRequest creates or replaces a flag
+type SetFeatureFlagRequest struct {
+	Type        string          `json:"type" binding:"required"`
+	Value       json.RawMessage `json:"value"`
+	Description string          `json:"description,omitempty"`
+}
+
+// SyncFeatureFlagsRequest copies a project's flags to the given targets
+type SyncFeatureFlagsRequest struct {
+	Targets []string `json:"targets" binding:"required"`
+}
+
+// FlagSyncResult is the outcome of syncing flags to one target
+type FlagSyncResult struct {
+	Target string `json:"target"`
+	Synced int    `json:"synced"`
+	// Removed counts the stale copies deleted, of flags no longer held
+	Removed int `json:"removed"`
+}
+
+// NewFeatureFlag validates a flag and compacts its value
+func NewFeatureFlag(projectID, key string, req SetFeatureFlagRequest) (*FeatureFlag, error) {
+	if !flagKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("invalid flag key: %q (lowercase letters, digits and underscores, starting with a letter)", key)
+	}
+
+	value := bytes.TrimSpace(req.Value)
+	if len(value) == 0 || bytes.Equal(value, []byte("null")) {
+		return nil, fmt.Errorf("value is required")
+	}
+	if len(value) > maxFlagValueBytes {
+		return nil, fmt.Errorf("value is larger than %d bytes", maxFlagValueBytes)
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(value, &decoded); err != nil {
+		return nil, fmt.Errorf("value is not valid JSON: %w", err)
+	}
+	var ok bool
+	switch req.Type {
+	case FlagBoolean:
+		_, ok = decoded.(bool)
+	case FlagString:
+		_, ok = decoded.(string)
+	case FlagNumber:
+		_, ok = decoded.(float64)
+	case FlagJSON:
+		ok = true
+	default:
+		return nil, fmt.Errorf("unknown flag type %q (expected %s, %s, %s or %s)", req.Type, FlagBoolean, FlagString, FlagNumber, FlagJSON)
+	}
+	if !ok {
+		return nil, fmt.Errorf("value is not a %s", req.Type)
+	}
+
+	var compact bytes.Buffer
+	if err := json.Compact(&compact, value); err != nil {
+		return nil, fmt.Errorf("value is not valid JSON: %w", err)
+	}
+
+	return &FeatureFlag{
+		ProjectID:   projectID,
+		Key:         key,
+		Type:        req.Type,
+		Value:       compact.Bytes(),
+		Description: strings.TrimSpace(req.Description),
+	}, nil
+}
+
+// Validate checks the sync targets
+func (r SyncFeatureFlagsRequest) Validate() error {
+	if len(r.Targets) == 0 {
+		return fmt.Errorf("targets must name at least one target")
+	}
+	seen := map[string]bool{}
+	for _, target := range r.Targets {
+		if target != FlagSyncTable && target != FlagSyncSecrets {
+			return fmt.Errorf("unknown sync target %q (expected %s or %s)", target, FlagSyncTable, FlagSyncSecrets)
+		}
+		if seen[target] {
+			return fmt.Errorf("duplicate sync target %q", target)
+		}
+		seen[target] = true
+	}
+	return nil
+}
+
+// SecretName is the name of the secret the flag is synced to
+func (f *FeatureFlag) SecretName() string {
+	return FlagSecretPrefix + strings.ToUpper(f.Key)
+}
+
+// SecretValue is the flag's value as a secret: strings as they are, other
+// types as JSON
+func (f *FeatureFlag) SecretValue() string {
+	if f.Type == FlagString {
+		var s string
+		if err := json.Unmarshal(f.Value, &s); err == nil {
+			return s
+		}
+	}
+	return string(f.Value)
+}
+
+// FlagValues maps each flag's key to its value
+func FlagValues(flags []*FeatureFlag) map[string]json.RawMessage {
+	values := make(map[string]json.RawMessage, len(flags))
+	for _, flag := range flags {
+		values[flag.Key] = flag.Value
+	}
+	return values
+}
+
+// flagTableSQL creates the table flags are synced to. Row level security
+// is enabled without policies, so only the service role reads flags until
+// the project adds a policy of its own.
+const flagTableSQL = `
+CREATE TABLE IF NOT EXISTS public.feature_flags (
+	key text NOT NULL PRIMARY KEY,
+	type text NOT NULL,
+	value jsonb NOT NULL,
+	description text NOT NULL DEFAULT '',
+	updated_at timestamptz NOT NULL DEFAULT now()
+);
+ALTER TABLE public.feature_flags ENABLE ROW LEVEL SECURITY`
+
+// SyncFeatureFlags makes public.feature_flags hold exactly the given flags,
+// in one transaction, and returns how many stale rows were deleted
+func (mr *MigrationRunner) SyncFeatureFlags(flags []*FeatureFlag) (int, error) {
+	tx, err := mr.db.Begin()
+	if err != nil {
+		return 0, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	for _, stmt := range splitSQLStatements(flagTableSQL) {
+		if _, err := tx.Exec(stmt); err != nil {
+			return 0, fmt.Errorf("failed to create feature_flags table: %w", err)
+		}
+	}
+
+	keys := make([]string, len(flags))
+	for i, flag := range flags {
+		keys[i] = flag.Key
+	}
+	result, err := tx.Exec(`DELETE FROM public.feature_flags WHERE NOT (key = ANY($1))`, pq.Array(keys))
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete stale flags: %w", err)
+	}
+	removed, _ := result.RowsAffected()
+
+	for _, flag := range flags {
+		_, err := tx.Exec(`
+			INSERT INTO public.feature_flags (key, type, value, description, updated_at)
+			VALUES ($1, $2, $3, $4, $5)
+			ON CONFLICT (key) DO UPDATE
+			SET type = EXCLUDED.type, value = EXCLUDED.value,
+			    description = EXCLUDED.description, updated_at = EXCLUDED.updated_at`,
+			flag.Key, flag.Type, string(flag.Value), flag.Description, flag.UpdatedAt,
+		)
+		if err != nil {
+			return 0, fmt.Errorf("failed to write flag %s: %w", flag.Key, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return 0, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+	return int(removed), nil
+}
+
+// ListProjectSecretNames returns the names of a project's Edge Function
+// secrets
+func (c *Client) ListProjectSecretNames(projectRef string) ([]string, error) {
+	var secrets []projectSecretBody
+	if err := c.managementRequest("GET", "/projects/"+projectRef+"/secrets", nil, &secrets); err != nil {
+		return nil, err
+	}
+	names := make([]string, len(secrets))
+	for i, secret := range secrets {
+		names[i] = secret.Name
+	}
+	return names, nil
+}
+
+// DeleteProjectSecrets deletes Edge Function secrets by name
+func (c *Client) DeleteProjectSecrets(projectRef string, names []string) error {
+	return c.managementRequest("DELETE", "/projects/"+projectRef+"/secrets", names, nil)
+}
@@ -0,0 +1,175 @@
+package supabase
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Operations a freeze window can block
+const (
+	FreezeMigrations = "migrations"
+	FreezeDeletes    = "deletes"
+)
+
+// FreezeOperations are the operations a freeze window can block
+var FreezeOperations = []string{FreezeMigrations, FreezeDeletes}
+
+// maxFreezeDuration caps how long each occurrence of a scheduled freeze
+// window lasts
+const maxFreezeDuration = 7 * 24 * time.Hour
+
+// FreezeWindow is a period during which migrations and deletes of some
+// projects are refused, for change-management calendars. It is either an
+// explicit range from StartsAt to EndsAt, or a cron Schedule that opens it
+// for Duration each time it fires. It covers the project ProjectID, the
+// projects matching LabelSelector, such as "env=prod", or every project
+// when neither is set.
+type FreezeWindow struct {
+	ID            string     `json:"id"`
+	ProjectID     string     `json:"project_id,omitempty"`
+	LabelSelector string     `json:"label_selector,omitempty"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	Schedule      string     `json:"schedule,omitempty"`
+	Duration      string     `json:"duration,omitempty"`
+	// TimeZone is the IANA time zone Schedule is read in, UTC when empty
+	TimeZone   string    `json:"time_zone,omitempty"`
+	Operations []string  `json:"operations"`
+	Reason     string    `json:"reason"`
+	CreatedBy  string    `json:"created_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Blocks reports whether the window refuses an operation
+func (w *FreezeWindow) Blocks(operation string) bool {
+	return slices.Contains(w.Operations, operation)
+}
+
+// Covers reports whether the window applies to a project
+func (w *FreezeWindow) Covers(project *StoredProject) bool {
+	if w.ProjectID != "" && w.ProjectID != project.ID {
+		return false
+	}
+	if w.LabelSelector != "" {
+		selector, err := ParseLabelSelector(w.LabelSelector)
+		if err != nil || !selector.Matches(project.Labels) {
+			return false
+		}
+	}
+	return true
+}
+
+// ActiveAt reports whether the window is in force at t and when the
+// occurrence covering t ends
+func (w *FreezeWindow) ActiveAt(t time.Time) (time.Time, bool) {
+	if w.Schedule == "" {
+		if w.EndsAt == nil || !t.Before(*w.EndsAt) || (w.StartsAt != nil && t.Before(*w.StartsAt)) {
+			return time.Time{}, false
+		}
+		return *w.EndsAt, true
+	}
+
+	schedule, err := ParseCronSchedule(w.Schedule)
+	if err != nil {
+		return time.Time{}, false
+	}
+	duration, err := time.ParseDuration(w.Duration)
+	if err != nil {
+		return time.Time{}, false
+	}
+	location, err := loadFreezeLocation(w.TimeZone)
+	if err != nil {
+		return time.Time{}, false
+	}
+
+	// Look back for the latest time the schedule fired within one
+	// duration of t
+	for start := t.Truncate(time.Minute); t.Sub(start) < duration; start = start.Add(-time.Minute) {
+		if schedule.Matches(start.In(location)) {
+			return start.Add(duration), true
+		}
+	}
+	return time.Time{}, false
+}
+
+// loadFreezeLocation returns the location of an IANA time zone, UTC when
+// it is empty
+func loadFreezeLocation(name string) (*time.Location, error) {
+	if name == "" {
+		return time.UTC, nil
+	}
+	return time.LoadLocation(name)
+}
+
+// CreateFreezeWindowRequest defines a freeze window. It takes either
+// ends_at, with an optional starts_at, or a schedule with a duration.
+type CreateFreezeWindowRequest struct {
+	ProjectID     string     `json:"project_id,omitempty"`
+	LabelSelector string     `json:"label_selector,omitempty"`
+	StartsAt      *time.Time `json:"starts_at,omitempty"`
+	EndsAt        *time.Time `json:"ends_at,omitempty"`
+	Schedule      string     `json:"schedule,omitempty"`
+	Duration      string     `json:"duration,omitempty"`
+	TimeZone      string     `json:"time_zone,omitempty"`
+	// Operations are the operations blocked, all of them when empty
+	Operations []string `json:"operations,omitempty"`
+	Reason     string   `json:"reason" binding:"required"`
+}
+
+// Validate checks the request, fills in the default operations and
+// rewrites the label selector in its canonical form
+func (r *CreateFreezeWindowRequest) Validate() error {
+	if strings.TrimSpace(r.Reason) == "" {
+		return fmt.Errorf("reason must not be empty")
+	}
+
+	if r.LabelSelector != "" {
+		selector, err := ParseLabelSelector(r.LabelSelector)
+		if err != nil {
+			return err
+		}
+		r.LabelSelector = selector.String()
+	}
+
+	if len(r.Operations) == 0 {
+		r.Operations = slices.Clone(FreezeOperations)
+	}
+	for _, operation := range r.Operations {
+		if !slices.Contains(FreezeOperations, operation) {
+			return fmt.Errorf("unknown operation %q (expected %s)", operation, strings.Join(FreezeOperations, ", "))
+		}
+	}
+
+	if r.Schedule == "" {
+		if r.Duration != "" || r.TimeZone != "" {
+			return fmt.Errorf("duration and time_zone only apply to a schedule")
+		}
+		if r.EndsAt == nil {
+			return fmt.Errorf("set ends_at, or a schedule with a duration")
+		}
+		if r.StartsAt != nil && !r.EndsAt.After(*r.StartsAt) {
+			return fmt.Errorf("ends_at must be after starts_at")
+		}
+		if !r.EndsAt.After(time.Now()) {
+			return fmt.Errorf("ends_at must be in the future")
+		}
+		return nil
+	}
+
+	if r.StartsAt != nil || r.EndsAt != nil {
+		return fmt.Errorf("set either starts_at and ends_at or a schedule, not both")
+	}
+	if _, err := ParseCronSchedule(r.Schedule); err != nil {
+		return err
+	}
+	duration, err := time.ParseDuration(r.Duration)
+	if err != nil || duration < time.Minute || duration > maxFreezeDuration {
+		return fmt.Errorf("duration must be between 1m and 168h (7 days), such as \"2h\"")
+	}
+	if _, err := loadFreezeLocation(r.TimeZone); err != nil {
+		return fmt.Errorf("unknown time_zone %q", r.TimeZone)
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package supabase
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// ApplyGitMigrationsRequest applies the .sql files of a directory of a git
+// repository, named like Supabase CLI migrations, as a batch of versioned
+// migrations
+type ApplyGitMigrationsRequest struct {
+	// Repo is the https URL of the repository
+	Repo string `json:"repo" binding:"required"`
+	// Ref is the branch, tag or commit to read
+	Ref string `json:"ref" binding:"required"`
+	// Path is the directory of the migrations, supabase/migrations by
+	// default
+	Path string `json:"path,omitempty"`
+	// SourceToken authenticates the fetch and is never stored
+	SourceToken string        `json:"source_token,omitempty"`
+	Description string        `json:"description,omitempty"`
+	Author      string        `json:"author,omitempty"`
+	Ticket      string        `json:"ticket,omitempty"`
+	Role        string        `json:"role,omitempty"`
+	Strict      *StrictLimits `json:"strict,omitempty"`
+}
+
+// Validate checks a git migrations request and fills in the default path
+func (r *ApplyGitMigrationsRequest) Validate() error {
+	if err := validatePreviewRepo(r.Repo); err != nil {
+		return err
+	}
+	if !gitRefPattern.MatchString(r.Ref) {
+		return fmt.Errorf("invalid ref %q", r.Ref)
+	}
+	if r.Path == "" {
+		r.Path = DefaultPreviewMigrationsDir
+	}
+	r.Path = strings.Trim(r.Path, "/")
+	if !gitRefPattern.MatchString(r.Path) || strings.Contains(r.Path, "..") {
+		return fmt.Errorf("invalid path %q", r.Path)
+	}
+	return nil
+}
+
+// SourceURL returns the git source of the migrations directory
+func (r *ApplyGitMigrationsRequest) SourceURL() string {
+	return "git+" + r.Repo + "#" + r.Ref + ":" + r.Path
+}
+
+// Batch turns the fetched files of the directory into a batch request.
+// Each file's version is the numeric prefix of its name, as in
+// 20240101120000_create_todos.sql; files without one are refused rather
+// than applied out of order.
+func (r *ApplyGitMigrationsRequest) Batch(files []FetchedSchema) (*ApplySchemaRequest, error) {
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no .sql files in %s at %s", r.Path, r.Ref)
+	}
+
+	batch := &ApplySchemaRequest{
+		Description: r.Description,
+		Author:      r.Author,
+		Ticket:      r.Ticket,
+		Role:        r.Role,
+		Strict:      r.Strict,
+		Migrations:  make([]VersionedMigration, len(files)),
+	}
+	for i, file := range files {
+		base := path.Base(file.Source)
+		match := cliMigrationFilePattern.FindStringSubmatch(base)
+		if match == nil {
+			return nil, fmt.Errorf("%q is not a migration file name like 20240101120000_create_todos.sql", base)
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(base, match[1]+"_"), ".sql")
+
+		batch.Migrations[i] = VersionedMigration{Version: match[1], Name: name, SQL: file.SQL}
+	}
+	if batch.Description == "" {
+		batch.Description = "Applied from " + r.SourceURL()
+	}
+	return batch, nil
+}
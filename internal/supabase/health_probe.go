@@ -0,0 +1,78 @@
+package supabase
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// ProjectHealth is the running outcome of the background health probes of
+// an active project: whether its REST endpoint and database answered the
+// last probe, its uptime across all probes and its last failure
+type ProjectHealth struct {
+	ProjectID string `json:"-"`
+	Reachable bool   `json:"reachable"`
+	Checks    int    `json:"checks"`
+	Failures  int    `json:"failures"`
+	// UptimePercent is the share of probes that succeeded
+	UptimePercent    float64    `json:"uptime_percent"`
+	LastCheckedAt    time.Time  `json:"last_checked_at"`
+	LastFailureAt    *time.Time `json:"last_failure_at,omitempty"`
+	LastFailure      string     `json:"last_failure,omitempty"`
+	UnreachableSince *time.Time `json:"unreachable_since,omitempty"`
+}
+
+// Record adds the outcome of a probe at the given time; failure is empty
+// when the project answered
+func (h *ProjectHealth) Record(failure string, at time.Time) {
+	h.Checks++
+	h.LastCheckedAt = at
+	if failure == "" {
+		h.Reachable = true
+		h.UnreachableSince = nil
+	} else {
+		h.Failures++
+		h.LastFailure = failure
+		h.LastFailureAt = &at
+		if h.Reachable || h.UnreachableSince == nil {
+			h.UnreachableSince = &at
+		}
+		h.Reachable = false
+	}
+	h.SetUptime()
+}
+
+// SetUptime computes UptimePercent from the probe counts
+func (h *ProjectHealth) SetUptime() {
+	if h.Checks == 0 {
+		h.UptimePercent = 0
+		return
+	}
+	h.UptimePercent = float64(h.Checks-h.Failures) * 100 / float64(h.Checks)
+}
+
+// ProbeREST checks that the project's REST endpoint answers. Any response
+// below 500 counts, since a missing or restricted schema still shows the
+// API is up.
+func (c *Client) ProbeREST(project *Project) error {
+	req, err := http.NewRequest("GET", project.GetProjectURL()+"/rest/v1/", nil)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	if project.AnonKey != "" {
+		req.Header.Set("apikey", project.AnonKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode >= http.StatusInternalServerError {
+		return fmt.Errorf("answered with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,186 @@
+package supabase
+
+import (
+	"sort"
+	"strings"
+	"time"
+)
+
+// Audit actions that record a project's state, replayed by ReplayProject
+const (
+	AuditProjectStatusChanged = "project.status_changed"
+	AuditProjectKeysStored    = "project.keys_stored"
+	AuditProjectRenamed       = "project.renamed"
+	AuditJWTSecretRotated     = "jwt_secret.rotated"
+	AuditProjectPurged        = "project.purged"
+)
+
+// snapshotRecentEvents is how many audit events before the requested time
+// a snapshot includes
+const snapshotRecentEvents = 20
+
+// ProjectSnapshot is the known state of a project at a point in time,
+// rebuilt from its audit log and migration history
+type ProjectSnapshot struct {
+	ProjectID string    `json:"project_id"`
+	At        time.Time `json:"at"`
+	// Existed reports whether the project existed at the time; Deleted
+	// whether it had already been deleted
+	Existed bool   `json:"existed"`
+	Deleted bool   `json:"deleted,omitempty"`
+	Name    string `json:"name,omitempty"`
+	// Status is empty when no status was recorded before the time
+	Status      string     `json:"status,omitempty"`
+	StatusSince *time.Time `json:"status_since,omitempty"`
+	Keys        KeysState  `json:"keys"`
+	// AppliedMigrations are the migrations that had succeeded by the time,
+	// oldest first
+	AppliedMigrations []AppliedMigration `json:"applied_migrations"`
+	// RecentEvents are the last audit events up to the time, newest first
+	RecentEvents []*AuditEvent `json:"recent_events"`
+	// HistoryStartsAt is the first recorded event. State before it, such as
+	// for projects created before state changes were recorded, is unknown.
+	HistoryStartsAt *time.Time `json:"history_starts_at,omitempty"`
+	Complete        bool       `json:"complete"`
+}
+
+// KeysState describes which API keys a project had at a point in time
+type KeysState struct {
+	Anon        bool       `json:"anon"`
+	ServiceRole bool       `json:"service_role"`
+	Since       *time.Time `json:"since,omitempty"`
+	// Revoked is set when the JWT secret was rotated after the keys were
+	// stored, invalidating them until new ones arrived
+	Revoked bool `json:"revoked,omitempty"`
+}
+
+// AppliedMigration is a migration in a project snapshot
+type AppliedMigration struct {
+	Version   string    `json:"version"`
+	Name      string    `json:"name,omitempty"`
+	AppliedAt time.Time `json:"applied_at"`
+}
+
+// ProjectLifetime is what is still known of a project's creation and
+// deletion; zero times are unknown
+type ProjectLifetime struct {
+	Name      string
+	CreatedAt time.Time
+	DeletedAt time.Time
+}
+
+// KeysStoredDetails describes which keys were stored, for a
+// project.keys_stored audit event
+func KeysStoredDetails(anonKey, serviceKey string) string {
+	var keys []string
+	if anonKey != "" {
+		keys = append(keys, "anon")
+	}
+	if serviceKey != "" {
+		keys = append(keys, "service_role")
+	}
+	return strings.Join(keys, ",")
+}
+
+// ReplayProject rebuilds a project's state at a point in time from its
+// lifetime, its audit events and its migration history
+func ReplayProject(projectID string, at time.Time, lifetime ProjectLifetime, events []*AuditEvent, migrations []*MigrationRecord) *ProjectSnapshot {
+	snapshot := &ProjectSnapshot{
+		ProjectID:         projectID,
+		At:                at,
+		Name:              lifetime.Name,
+		AppliedMigrations: []AppliedMigration{},
+		RecentEvents:      []*AuditEvent{},
+	}
+
+	sorted := make([]*AuditEvent, len(events))
+	copy(sorted, events)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].CreatedAt.Before(sorted[j].CreatedAt) })
+
+	if len(sorted) > 0 {
+		first := sorted[0].CreatedAt
+		snapshot.HistoryStartsAt = &first
+	}
+
+	// Purged projects are known only from their events
+	createdAt, deletedAt := lifetime.CreatedAt, lifetime.DeletedAt
+	for _, event := range sorted {
+		if createdAt.IsZero() && event.Action == AuditProjectStatusChanged {
+			createdAt = event.CreatedAt
+		}
+		if deletedAt.IsZero() && event.Action == AuditProjectPurged {
+			deletedAt = event.CreatedAt
+		}
+	}
+
+	snapshot.Existed = !createdAt.IsZero() && !createdAt.After(at)
+	if !deletedAt.IsZero() && !deletedAt.After(at) {
+		snapshot.Existed = false
+		snapshot.Deleted = true
+	}
+
+	// Names are replayed backwards: each rename after the time undoes to
+	// the name it replaced
+	for i := len(sorted) - 1; i >= 0; i-- {
+		event := sorted[i]
+		if event.Action != AuditProjectRenamed || !event.CreatedAt.After(at) {
+			continue
+		}
+		if previous, _, ok := strings.Cut(event.Details, " -> "); ok {
+			snapshot.Name = previous
+		}
+	}
+
+	for _, event := range sorted {
+		if event.CreatedAt.After(at) {
+			break
+		}
+		since := event.CreatedAt
+		switch event.Action {
+		case AuditProjectStatusChanged:
+			snapshot.Status = event.Details
+			snapshot.StatusSince = &since
+		case AuditProjectKeysStored:
+			snapshot.Keys = KeysState{
+				Anon:        strings.Contains(event.Details, "anon"),
+				ServiceRole: strings.Contains(event.Details, "service_role"),
+				Since:       &since,
+			}
+		case AuditJWTSecretRotated:
+			if snapshot.Keys.Anon || snapshot.Keys.ServiceRole {
+				snapshot.Keys.Revoked = true
+			}
+		}
+	}
+
+	for i := len(sorted) - 1; i >= 0 && len(snapshot.RecentEvents) < snapshotRecentEvents; i-- {
+		if !sorted[i].CreatedAt.After(at) {
+			snapshot.RecentEvents = append(snapshot.RecentEvents, sorted[i])
+		}
+	}
+
+	applied := map[string]bool{}
+	for _, record := range migrations {
+		if !record.Success || record.AppliedAt.After(at) || applied[record.Version] {
+			continue
+		}
+		applied[record.Version] = true
+		snapshot.AppliedMigrations = append(snapshot.AppliedMigrations, AppliedMigration{
+			Version:   record.Version,
+			Name:      record.Name,
+			AppliedAt: record.AppliedAt,
+		})
+	}
+	sort.Slice(snapshot.AppliedMigrations, func(i, j int) bool {
+		return snapshot.AppliedMigrations[i].AppliedAt.Before(snapshot.AppliedMigrations[j].AppliedAt)
+	})
+
+	snapshot.Complete = snapshot.HistoryStartsAt != nil && !createdAt.IsZero() && !snapshot.HistoryStartsAt.After(createdAt.Add(time.Minute))
+	if !snapshot.Existed {
+		snapshot.Status = ""
+		snapshot.StatusSince = nil
+		snapshot.Keys = KeysState{}
+	}
+
+	return snapshot
+}
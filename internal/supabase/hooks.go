@@ -0,0 +1,138 @@
+package supabase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Sources of the events a project sends to the hook receiver
+const (
+	HookSourceDatabase = "database"
+	HookSourceAuth     = "auth"
+)
+
+// HookTimestampTolerance bounds how far the timestamp of a signed hook may
+// be from now, so captured requests can't be replayed later
+const HookTimestampTolerance = 5 * time.Minute
+
+// hookNamePattern matches auth hook names such as before_user_created
+var hookNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,62}$`)
+
+// ErrHookUnauthorized is returned for hook requests that carry neither a
+// valid signature nor the shared secret
+var ErrHookUnauthorized = errors.New("hook request is not signed with the hook secret")
+
+// HookEvent is an event a Supabase project sent to the hook receiver: a
+// database webhook fired by a table change, or an auth hook
+type HookEvent struct {
+	Source string `json:"source"`
+	// Type is INSERT, UPDATE or DELETE for database webhooks, and the hook
+	// name for auth hooks
+	Type   string `json:"type"`
+	Schema string `json:"schema,omitempty"`
+	Table  string `json:"table,omitempty"`
+	// WebhookID is the ID of a signed delivery, the same across retries
+	WebhookID string `json:"webhook_id,omitempty"`
+}
+
+// String describes the event, such as "database INSERT public.orders"
+func (e *HookEvent) String() string {
+	if e.Source == HookSourceDatabase {
+		return fmt.Sprintf("%s %s %s.%s", e.Source, e.Type, e.Schema, e.Table)
+	}
+	return e.Source + " " + e.Type
+}
+
+// VerifyHook checks that a hook request comes from a project configured
+// with secret. Auth hooks are signed following Standard Webhooks, with the
+// webhook-id, webhook-timestamp and webhook-signature headers and a
+// "v1,whsec_..." secret. Database webhooks can't sign, so they send the
+// secret itself in an Authorization bearer token or X-Hook-Secret header.
+func VerifyHook(header http.Header, body []byte, secret string, now time.Time) error {
+	if signatures := header.Get("webhook-signature"); signatures != "" {
+		return verifyHookSignature(header.Get("webhook-id"), header.Get("webhook-timestamp"), signatures, body, secret, now)
+	}
+
+	token := header.Get("X-Hook-Secret")
+	if bearer, ok := strings.CutPrefix(header.Get("Authorization"), "Bearer "); ok {
+		token = bearer
+	}
+	if token == "" || subtle.ConstantTimeCompare([]byte(token), []byte(secret)) != 1 {
+		return ErrHookUnauthorized
+	}
+	return nil
+}
+
+// verifyHookSignature checks a Standard Webhooks signature: an HMAC-SHA256
+// of "id.timestamp.body" keyed with the base64 part of the secret
+func verifyHookSignature(id, timestamp, signatures string, body []byte, secret string, now time.Time) error {
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if id == "" || err != nil {
+		return ErrHookUnauthorized
+	}
+	if sent := time.Unix(seconds, 0); sent.Before(now.Add(-HookTimestampTolerance)) || sent.After(now.Add(HookTimestampTolerance)) {
+		return fmt.Errorf("%w: timestamp is more than %s from now", ErrHookUnauthorized, HookTimestampTolerance)
+	}
+
+	key := []byte(secret)
+	if _, encoded, ok := strings.Cut(secret, "whsec_"); ok {
+		if key, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return fmt.Errorf("invalid hook secret: %w", err)
+		}
+	}
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%s.", id, timestamp)
+	mac.Write(body)
+	expected := mac.Sum(nil)
+
+	for _, signature := range strings.Fields(signatures) {
+		version, encoded, _ := strings.Cut(signature, ",")
+		if version != "v1" {
+			continue
+		}
+		if sum, err := base64.StdEncoding.DecodeString(encoded); err == nil && hmac.Equal(sum, expected) {
+			return nil
+		}
+	}
+	return ErrHookUnauthorized
+}
+
+// ParseHookEvent reads the event in a hook body. Database webhook payloads
+// carry their type, schema and table; anything else is an auth hook, named
+// by hookName, which defaults to "hook".
+func ParseHookEvent(body []byte, hookName string) (*HookEvent, error) {
+	var payload struct {
+		Type   string `json:"type"`
+		Schema string `json:"schema"`
+		Table  string `json:"table"`
+	}
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("hook body is not a JSON object: %w", err)
+	}
+
+	switch payload.Type {
+	case "INSERT", "UPDATE", "DELETE":
+		if payload.Schema == "" || payload.Table == "" {
+			return nil, fmt.Errorf("database webhook without schema and table")
+		}
+		return &HookEvent{Source: HookSourceDatabase, Type: payload.Type, Schema: payload.Schema, Table: payload.Table}, nil
+	}
+
+	if hookName == "" {
+		hookName = "hook"
+	}
+	if !hookNamePattern.MatchString(hookName) {
+		return nil, fmt.Errorf("invalid hook name %q", hookName)
+	}
+	return &HookEvent{Source: HookSourceAuth, Type: hookName}, nil
+}
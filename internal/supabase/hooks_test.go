@@ -0,0 +1,80 @@
+package supabase
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// signHook returns the Standard Webhooks headers of a delivery signed with key
+func signHook(id string, sent time.Time, body []byte, key []byte) http.Header {
+	timestamp := strconv.FormatInt(sent.Unix(), 10)
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%s.", id, timestamp)
+	mac.Write(body)
+
+	header := http.Header{}
+	header.Set("webhook-id", id)
+	header.Set("webhook-timestamp", timestamp)
+	header.Set("webhook-signature", "v1,"+base64.StdEncoding.EncodeToString(mac.Sum(nil)))
+	return header
+}
+
+func TestVerifyHookSignature(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	body := []byte(`{"type":"INSERT","schema":"public","table":"todos"}`)
+	key := []byte("signing-key")
+	whsec := "whsec_" + base64.StdEncoding.EncodeToString(key)
+
+	tests := []struct {
+		name   string
+		header http.Header
+		body   []byte
+		secret string
+		ok     bool
+	}{
+		{"signed", signHook("msg_1", now, body, key), body, string(key), true},
+		{"whsec secret", signHook("msg_1", now, body, key), body, whsec, true},
+		{"other secret", signHook("msg_1", now, body, []byte("other")), body, string(key), false},
+		{"tampered body", signHook("msg_1", now, body, key), []byte(`{"type":"DELETE"}`), string(key), false},
+		{"stale", signHook("msg_1", now.Add(-2*HookTimestampTolerance), body, key), body, string(key), false},
+		{"future", signHook("msg_1", now.Add(2*HookTimestampTolerance), body, key), body, string(key), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyHook(tt.header, tt.body, tt.secret, now)
+			if tt.ok && err != nil {
+				t.Errorf("got %v, want the hook accepted", err)
+			}
+			if !tt.ok && !errors.Is(err, ErrHookUnauthorized) {
+				t.Errorf("got %v, want ErrHookUnauthorized", err)
+			}
+		})
+	}
+}
+
+func TestVerifyHookSharedSecret(t *testing.T) {
+	now := time.Now()
+	for _, tt := range []struct {
+		name, header, value string
+		ok                  bool
+	}{
+		{"secret header", "X-Hook-Secret", "shared", true},
+		{"bearer", "Authorization", "Bearer shared", true},
+		{"wrong secret", "X-Hook-Secret", "guess", false},
+		{"missing", "X-Other", "shared", false},
+	} {
+		header := http.Header{}
+		header.Set(tt.header, tt.value)
+		err := VerifyHook(header, []byte(`{}`), "shared", now)
+		if (err == nil) != tt.ok {
+			t.Errorf("%s: got %v, want accepted %v", tt.name, err, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// statusPageURL is the Supabase status page (a Statuspage.io site)
+const statusPageURL = "https://status.supabase.com"
+
+// Incident is an unresolved incident reported on the Supabase status page
+type Incident struct {
+	Name      string    `json:"name"`
+	Status    string    `json:"status"`
+	Impact    string    `json:"impact"`
+	Shortlink string    `json:"shortlink,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// SetStatusPageURL points the client at a different status page, such as a
+// sandbox or test server
+func (c *Client) SetStatusPageURL(url string) {
+	c.statusPageURL = url
+}
+
+// UnresolvedIncidents returns the incidents currently open on the Supabase
+// status page
+func (c *Client) UnresolvedIncidents() ([]Incident, error) {
+	resp, err := c.httpClient.Get(c.statusPageURL + "/api/v2/incidents/unresolved.json")
+	if err != nil {
+		return nil, fmt.Errorf("failed to reach status page: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("status page error (status %d)", resp.StatusCode)
+	}
+
+	var result struct {
+		Incidents []Incident `json:"incidents"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return nil, fmt.Errorf("failed to decode status page response: %w", err)
+	}
+
+	return result.Incidents, nil
+}
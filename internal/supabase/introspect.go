@@ -0,0 +1,620 @@
+package supabase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/lib/pq"
+)
+
+// systemSchemas lists schemas managed by Postgres or Supabase itself, which are
+// excluded when introspecting user-defined objects
+var systemSchemas = []string{
+	"pg_catalog",
+	"information_schema",
+	"pg_toast",
+	"auth",
+	"storage",
+	"realtime",
+	"_realtime",
+	"_analytics",
+	"extensions",
+	"graphql",
+	"graphql_public",
+	"net",
+	"pgbouncer",
+	"pgsodium",
+	"pgsodium_masks",
+	"supabase_functions",
+	"supabase_migrations",
+	"vault",
+	"cron",
+}
+
+// SchemaSnapshot describes the user-visible structure of a database
+type SchemaSnapshot struct {
+	Tables            []TableInfo      `json:"tables"`
+	Indexes           []IndexInfo      `json:"indexes"`
+	Constraints       []ConstraintInfo `json:"constraints"`
+	Extensions        []ExtensionInfo  `json:"extensions"`
+	MigrationVersions []string         `json:"migration_versions"`
+}
+
+// TableInfo describes a table and its columns
+type TableInfo struct {
+	Schema  string       `json:"schema"`
+	Name    string       `json:"name"`
+	Columns []ColumnInfo `json:"columns"`
+	// RLSEnabled and RLSForced report row level security, forced when it
+	// also applies to the table's owner; Policies counts its policies
+	RLSEnabled bool `json:"rls_enabled"`
+	RLSForced  bool `json:"rls_forced,omitempty"`
+	Policies   int  `json:"policies"`
+}
+
+// ColumnInfo describes a single table column
+type ColumnInfo struct {
+	Name     string `json:"name"`
+	DataType string `json:"data_type"`
+	// Type is the full type with its modifiers, such as
+	// character varying(50), as it is written in DDL
+	Type     string `json:"type,omitempty"`
+	Nullable bool   `json:"nullable"`
+	Default  string `json:"default,omitempty"`
+}
+
+// IndexInfo describes an index
+type IndexInfo struct {
+	Schema     string `json:"schema"`
+	Table      string `json:"table"`
+	Name       string `json:"name"`
+	Definition string `json:"definition"`
+}
+
+// Constraint types of ConstraintInfo.Type
+const (
+	ConstraintPrimaryKey = "PRIMARY KEY"
+	ConstraintUnique     = "UNIQUE"
+	ConstraintForeignKey = "FOREIGN KEY"
+	ConstraintCheck      = "CHECK"
+	ConstraintExclude    = "EXCLUDE"
+)
+
+// ConstraintInfo describes a table constraint other than NOT NULL
+type ConstraintInfo struct {
+	Schema string `json:"schema"`
+	Table  string `json:"table"`
+	Name   string `json:"name"`
+	Type   string `json:"type"`
+	// Definition is the constraint as written after ADD CONSTRAINT name,
+	// such as FOREIGN KEY (user_id) REFERENCES users(id)
+	Definition string `json:"definition"`
+	// Columns are the constrained columns, in key order
+	Columns []string `json:"columns,omitempty"`
+	// References is what a foreign key refers to
+	References *ForeignKeyReference `json:"references,omitempty"`
+}
+
+// ForeignKeyReference is the referenced side of a foreign key
+type ForeignKeyReference struct {
+	Schema  string   `json:"schema"`
+	Table   string   `json:"table"`
+	Columns []string `json:"columns"`
+	// OnDelete and OnUpdate are the referential actions, such as CASCADE
+	OnDelete string `json:"on_delete"`
+	OnUpdate string `json:"on_update"`
+}
+
+// ExtensionInfo describes an installed extension
+type ExtensionInfo struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
+
+// QualifiedName returns the schema-qualified table name
+func (t TableInfo) QualifiedName() string {
+	return t.Schema + "." + t.Name
+}
+
+// QualifiedName returns the constraint name qualified with its table, as
+// constraint names are only unique per table
+func (c ConstraintInfo) QualifiedName() string {
+	return c.Schema + "." + c.Table + "." + c.Name
+}
+
+// describe renders a constraint for comparison
+func (c ConstraintInfo) describe() string {
+	if c.Definition == "" {
+		return c.Type
+	}
+	return c.Definition
+}
+
+// systemSchemaList renders systemSchemas as a quoted SQL list
+func systemSchemaList() string {
+	quoted := make([]string, len(systemSchemas))
+	for i, schema := range systemSchemas {
+		quoted[i] = "'" + schema + "'"
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// Snapshot introspects tables, columns, indexes, constraints, extensions and
+// applied migration versions of the connected database
+func (mr *MigrationRunner) Snapshot() (*SchemaSnapshot, error) {
+	snapshot := &SchemaSnapshot{}
+
+	tables, err := mr.snapshotTables()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Tables = tables
+
+	indexes, err := mr.snapshotIndexes()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Indexes = indexes
+
+	constraints, err := mr.snapshotConstraints()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Constraints = constraints
+
+	extensions, err := mr.snapshotExtensions()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.Extensions = extensions
+
+	versions, err := mr.snapshotMigrationVersions()
+	if err != nil {
+		return nil, err
+	}
+	snapshot.MigrationVersions = versions
+
+	return snapshot, nil
+}
+
+// SchemaVersion returns a hash of what Snapshot would return, computed from
+// pg_catalog in one query plus the migration versions, so a cached snapshot
+// can be checked without introspecting the database again
+func (mr *MigrationRunner) SchemaVersion() (string, error) {
+	query := fmt.Sprintf(`
+		SELECT COALESCE(md5(string_agg(item, E'\n' ORDER BY item)), '')
+		FROM (
+			SELECT 'column ' || n.nspname || '.' || c.relname || '.' || a.attname || ' ' ||
+			       format_type(a.atttypid, a.atttypmod) || ' ' || a.attnotnull || ' ' ||
+			       COALESCE(pg_get_expr(d.adbin, d.adrelid), '') AS item
+			FROM pg_attribute a
+			JOIN pg_class c ON c.oid = a.attrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			LEFT JOIN pg_attrdef d ON d.adrelid = a.attrelid AND d.adnum = a.attnum
+			WHERE c.relkind IN ('r', 'p') AND a.attnum > 0 AND NOT a.attisdropped
+			AND n.nspname NOT IN (%[1]s)
+			UNION ALL
+			SELECT 'index ' || schemaname || '.' || indexname || ' ' || indexdef
+			FROM pg_indexes
+			WHERE schemaname NOT IN (%[1]s)
+			UNION ALL
+			SELECT 'constraint ' || n.nspname || '.' || c.relname || '.' || con.conname || ' ' ||
+			       pg_get_constraintdef(con.oid)
+			FROM pg_constraint con
+			JOIN pg_class c ON c.oid = con.conrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE con.contype IN ('p', 'u', 'f', 'c', 'x')
+			AND n.nspname NOT IN (%[1]s)
+			UNION ALL
+			SELECT 'rls ' || n.nspname || '.' || c.relname || ' ' || c.relrowsecurity || ' ' || c.relforcerowsecurity
+			FROM pg_class c
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE c.relkind IN ('r', 'p') AND n.nspname NOT IN (%[1]s)
+			UNION ALL
+			SELECT 'policy ' || n.nspname || '.' || c.relname || '.' || p.polname
+			FROM pg_policy p
+			JOIN pg_class c ON c.oid = p.polrelid
+			JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname NOT IN (%[1]s)
+			UNION ALL
+			SELECT 'extension ' || extname || ' ' || extversion
+			FROM pg_extension
+		) items
+	`, systemSchemaList())
+
+	var catalogHash string
+	if err := mr.db.QueryRow(query).Scan(&catalogHash); err != nil {
+		return "", fmt.Errorf("failed to hash the schema: %w", err)
+	}
+
+	versions, err := mr.snapshotMigrationVersions()
+	if err != nil {
+		return "", err
+	}
+
+	sum := sha256.Sum256([]byte(catalogHash + "\n" + strings.Join(versions, "\n")))
+	return hex.EncodeToString(sum[:16]), nil
+}
+
+// snapshotTables returns user tables with their columns and row level
+// security
+func (mr *MigrationRunner) snapshotTables() ([]TableInfo, error) {
+	query := fmt.Sprintf(`
+		SELECT c.table_schema, c.table_name, c.column_name, c.data_type,
+		       format_type(a.atttypid, a.atttypmod),
+		       c.is_nullable = 'YES', COALESCE(c.column_default, ''),
+		       cl.relrowsecurity, cl.relforcerowsecurity,
+		       (SELECT count(*) FROM pg_policy p WHERE p.polrelid = cl.oid)
+		FROM information_schema.columns c
+		JOIN information_schema.tables t
+		  ON t.table_schema = c.table_schema AND t.table_name = c.table_name
+		JOIN pg_class cl
+		  ON cl.oid = format('%%I.%%I', c.table_schema, c.table_name)::regclass
+		JOIN pg_attribute a
+		  ON a.attrelid = cl.oid AND a.attname = c.column_name
+		WHERE t.table_type = 'BASE TABLE'
+		AND c.table_schema NOT IN (%s)
+		ORDER BY c.table_schema, c.table_name, c.ordinal_position
+	`, systemSchemaList())
+
+	rows, err := mr.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query columns: %w", err)
+	}
+	defer rows.Close()
+
+	var tables []TableInfo
+	for rows.Next() {
+		var schema, table string
+		var column ColumnInfo
+		var rlsEnabled, rlsForced bool
+		var policies int
+		if err := rows.Scan(&schema, &table, &column.Name, &column.DataType, &column.Type, &column.Nullable, &column.Default,
+			&rlsEnabled, &rlsForced, &policies); err != nil {
+			return nil, fmt.Errorf("failed to scan column: %w", err)
+		}
+
+		if len(tables) == 0 || tables[len(tables)-1].Schema != schema || tables[len(tables)-1].Name != table {
+			tables = append(tables, TableInfo{Schema: schema, Name: table, RLSEnabled: rlsEnabled, RLSForced: rlsForced, Policies: policies})
+		}
+		last := &tables[len(tables)-1]
+		last.Columns = append(last.Columns, column)
+	}
+
+	return tables, rows.Err()
+}
+
+// snapshotIndexes returns indexes on user tables
+func (mr *MigrationRunner) snapshotIndexes() ([]IndexInfo, error) {
+	query := fmt.Sprintf(`
+		SELECT schemaname, tablename, indexname, indexdef
+		FROM pg_indexes
+		WHERE schemaname NOT IN (%s)
+		ORDER BY schemaname, tablename, indexname
+	`, systemSchemaList())
+
+	rows, err := mr.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query indexes: %w", err)
+	}
+	defer rows.Close()
+
+	var indexes []IndexInfo
+	for rows.Next() {
+		var index IndexInfo
+		if err := rows.Scan(&index.Schema, &index.Table, &index.Name, &index.Definition); err != nil {
+			return nil, fmt.Errorf("failed to scan index: %w", err)
+		}
+		indexes = append(indexes, index)
+	}
+
+	return indexes, rows.Err()
+}
+
+// referentialActions names the confdeltype and confupdtype codes of
+// pg_constraint
+var referentialActions = map[string]string{
+	"a": "NO ACTION",
+	"r": "RESTRICT",
+	"c": "CASCADE",
+	"n": "SET NULL",
+	"d": "SET DEFAULT",
+}
+
+// snapshotConstraints returns primary key, unique, foreign key, check and
+// exclusion constraints on user tables
+func (mr *MigrationRunner) snapshotConstraints() ([]ConstraintInfo, error) {
+	query := fmt.Sprintf(`
+		SELECT n.nspname, c.relname, con.conname,
+		       CASE con.contype
+		           WHEN 'p' THEN 'PRIMARY KEY'
+		           WHEN 'u' THEN 'UNIQUE'
+		           WHEN 'f' THEN 'FOREIGN KEY'
+		           WHEN 'c' THEN 'CHECK'
+		           ELSE 'EXCLUDE'
+		       END,
+		       pg_get_constraintdef(con.oid),
+		       ARRAY(SELECT a.attname FROM unnest(con.conkey) WITH ORDINALITY k(attnum, ord)
+		             JOIN pg_attribute a ON a.attrelid = con.conrelid AND a.attnum = k.attnum
+		             ORDER BY k.ord)::text[],
+		       COALESCE(fn.nspname, ''), COALESCE(fc.relname, ''),
+		       ARRAY(SELECT a.attname FROM unnest(con.confkey) WITH ORDINALITY k(attnum, ord)
+		             JOIN pg_attribute a ON a.attrelid = con.confrelid AND a.attnum = k.attnum
+		             ORDER BY k.ord)::text[],
+		       con.confdeltype, con.confupdtype
+		FROM pg_constraint con
+		JOIN pg_class c ON c.oid = con.conrelid
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		LEFT JOIN pg_class fc ON fc.oid = con.confrelid
+		LEFT JOIN pg_namespace fn ON fn.oid = fc.relnamespace
+		WHERE con.contype IN ('p', 'u', 'f', 'c', 'x')
+		AND n.nspname NOT IN (%s)
+		ORDER BY n.nspname, c.relname, con.conname
+	`, systemSchemaList())
+
+	rows, err := mr.db.Query(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query constraints: %w", err)
+	}
+	defer rows.Close()
+
+	constraints := []ConstraintInfo{}
+	for rows.Next() {
+		var constraint ConstraintInfo
+		var columns, refColumns pq.StringArray
+		var ref ForeignKeyReference
+		var onDelete, onUpdate string
+		if err := rows.Scan(&constraint.Schema, &constraint.Table, &constraint.Name, &constraint.Type, &constraint.Definition,
+			&columns, &ref.Schema, &ref.Table, &refColumns, &onDelete, &onUpdate); err != nil {
+			return nil, fmt.Errorf("failed to scan constraint: %w", err)
+		}
+		if len(columns) > 0 {
+			constraint.Columns = columns
+		}
+		if constraint.Type == ConstraintForeignKey {
+			ref.Columns = refColumns
+			ref.OnDelete = referentialActions[onDelete]
+			ref.OnUpdate = referentialActions[onUpdate]
+			constraint.References = &ref
+		}
+		constraints = append(constraints, constraint)
+	}
+
+	return constraints, rows.Err()
+}
+
+// snapshotExtensions returns installed extensions
+func (mr *MigrationRunner) snapshotExtensions() ([]ExtensionInfo, error) {
+	rows, err := mr.db.Query(`SELECT extname, extversion FROM pg_extension ORDER BY extname`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query extensions: %w", err)
+	}
+	defer rows.Close()
+
+	var extensions []ExtensionInfo
+	for rows.Next() {
+		var extension ExtensionInfo
+		if err := rows.Scan(&extension.Name, &extension.Version); err != nil {
+			return nil, fmt.Errorf("failed to scan extension: %w", err)
+		}
+		extensions = append(extensions, extension)
+	}
+
+	return extensions, rows.Err()
+}
+
+// snapshotMigrationVersions returns versions recorded by the Supabase CLI
+// migration table, if the project has one
+func (mr *MigrationRunner) snapshotMigrationVersions() ([]string, error) {
+	var exists bool
+	err := mr.db.QueryRow(`SELECT to_regclass('supabase_migrations.schema_migrations') IS NOT NULL`).Scan(&exists)
+	if err != nil {
+		return nil, fmt.Errorf("failed to check migration table: %w", err)
+	}
+	if !exists {
+		return []string{}, nil
+	}
+
+	rows, err := mr.db.Query(`SELECT version FROM supabase_migrations.schema_migrations ORDER BY version`)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query migration versions: %w", err)
+	}
+	defer rows.Close()
+
+	versions := []string{}
+	for rows.Next() {
+		var version string
+		if err := rows.Scan(&version); err != nil {
+			return nil, fmt.Errorf("failed to scan migration version: %w", err)
+		}
+		versions = append(versions, version)
+	}
+
+	return versions, rows.Err()
+}
+
+// SetDiff lists names present on only one side of a comparison
+type SetDiff struct {
+	OnlyInA []string `json:"only_in_a"`
+	OnlyInB []string `json:"only_in_b"`
+}
+
+// Empty reports whether both sides matched
+func (d SetDiff) Empty() bool {
+	return len(d.OnlyInA) == 0 && len(d.OnlyInB) == 0
+}
+
+// ValueChange describes a named item whose value differs between A and B
+type ValueChange struct {
+	Name string `json:"name"`
+	A    string `json:"a"`
+	B    string `json:"b"`
+}
+
+// TableChange describes column differences in a table present on both sides
+type TableChange struct {
+	Table          string        `json:"table"`
+	Columns        SetDiff       `json:"columns"`
+	ColumnsChanged []ValueChange `json:"columns_changed,omitempty"`
+}
+
+// SchemaComparison is the result of comparing two schema snapshots
+type SchemaComparison struct {
+	Identical          bool          `json:"identical"`
+	Tables             SetDiff       `json:"tables"`
+	TablesChanged      []TableChange `json:"tables_changed,omitempty"`
+	Indexes            SetDiff       `json:"indexes"`
+	IndexesChanged     []ValueChange `json:"indexes_changed,omitempty"`
+	Constraints        SetDiff       `json:"constraints"`
+	ConstraintsChanged []ValueChange `json:"constraints_changed,omitempty"`
+	Extensions         SetDiff       `json:"extensions"`
+	ExtensionsChanged  []ValueChange `json:"extensions_changed,omitempty"`
+	MigrationVersions  SetDiff       `json:"migration_versions"`
+}
+
+// CompareSnapshots reports the differences between two schema snapshots
+func CompareSnapshots(a, b *SchemaSnapshot) *SchemaComparison {
+	result := &SchemaComparison{}
+
+	tablesA := make(map[string]TableInfo)
+	for _, t := range a.Tables {
+		tablesA[t.QualifiedName()] = t
+	}
+	tablesB := make(map[string]TableInfo)
+	for _, t := range b.Tables {
+		tablesB[t.QualifiedName()] = t
+	}
+	result.Tables = diffKeys(tablesA, tablesB)
+	for _, name := range sortedKeys(tablesA) {
+		tableB, ok := tablesB[name]
+		if !ok {
+			continue
+		}
+		if change, changed := compareTables(name, tablesA[name], tableB); changed {
+			result.TablesChanged = append(result.TablesChanged, change)
+		}
+	}
+
+	indexesA := make(map[string]string)
+	for _, idx := range a.Indexes {
+		indexesA[idx.Schema+"."+idx.Name] = idx.Definition
+	}
+	indexesB := make(map[string]string)
+	for _, idx := range b.Indexes {
+		indexesB[idx.Schema+"."+idx.Name] = idx.Definition
+	}
+	result.Indexes = diffKeys(indexesA, indexesB)
+	result.IndexesChanged = diffValues(indexesA, indexesB)
+
+	constraintsA := make(map[string]string)
+	for _, con := range a.Constraints {
+		constraintsA[con.QualifiedName()] = con.describe()
+	}
+	constraintsB := make(map[string]string)
+	for _, con := range b.Constraints {
+		constraintsB[con.QualifiedName()] = con.describe()
+	}
+	result.Constraints = diffKeys(constraintsA, constraintsB)
+	result.ConstraintsChanged = diffValues(constraintsA, constraintsB)
+
+	extensionsA := make(map[string]string)
+	for _, ext := range a.Extensions {
+		extensionsA[ext.Name] = ext.Version
+	}
+	extensionsB := make(map[string]string)
+	for _, ext := range b.Extensions {
+		extensionsB[ext.Name] = ext.Version
+	}
+	result.Extensions = diffKeys(extensionsA, extensionsB)
+	result.ExtensionsChanged = diffValues(extensionsA, extensionsB)
+
+	versionsA := make(map[string]bool)
+	for _, v := range a.MigrationVersions {
+		versionsA[v] = true
+	}
+	versionsB := make(map[string]bool)
+	for _, v := range b.MigrationVersions {
+		versionsB[v] = true
+	}
+	result.MigrationVersions = diffKeys(versionsA, versionsB)
+
+	result.Identical = result.Tables.Empty() && len(result.TablesChanged) == 0 &&
+		result.Indexes.Empty() && len(result.IndexesChanged) == 0 &&
+		result.Constraints.Empty() && len(result.ConstraintsChanged) == 0 &&
+		result.Extensions.Empty() && len(result.ExtensionsChanged) == 0 &&
+		result.MigrationVersions.Empty()
+
+	return result
+}
+
+// compareTables compares the columns of a table present in both snapshots
+func compareTables(name string, a, b TableInfo) (TableChange, bool) {
+	columnsA := make(map[string]string)
+	for _, col := range a.Columns {
+		columnsA[col.Name] = describeColumn(col)
+	}
+	columnsB := make(map[string]string)
+	for _, col := range b.Columns {
+		columnsB[col.Name] = describeColumn(col)
+	}
+
+	change := TableChange{
+		Table:          name,
+		Columns:        diffKeys(columnsA, columnsB),
+		ColumnsChanged: diffValues(columnsA, columnsB),
+	}
+
+	return change, !change.Columns.Empty() || len(change.ColumnsChanged) > 0
+}
+
+// describeColumn renders a column definition for comparison
+func describeColumn(col ColumnInfo) string {
+	desc := col.DataType
+	if !col.Nullable {
+		desc += " NOT NULL"
+	}
+	if col.Default != "" {
+		desc += " DEFAULT " + col.Default
+	}
+	return desc
+}
+
+// diffKeys returns the keys present in only one of the two maps
+func diffKeys[V any](a, b map[string]V) SetDiff {
+	diff := SetDiff{OnlyInA: []string{}, OnlyInB: []string{}}
+	for _, key := range sortedKeys(a) {
+		if _, ok := b[key]; !ok {
+			diff.OnlyInA = append(diff.OnlyInA, key)
+		}
+	}
+	for _, key := range sortedKeys(b) {
+		if _, ok := a[key]; !ok {
+			diff.OnlyInB = append(diff.OnlyInB, key)
+		}
+	}
+	return diff
+}
+
+// diffValues returns keys present in both maps whose values differ
+func diffValues(a, b map[string]string) []ValueChange {
+	var changes []ValueChange
+	for _, key := range sortedKeys(a) {
+		if valueB, ok := b[key]; ok && valueB != a[key] {
+			changes = append(changes, ValueChange{Name: key, A: a[key], B: valueB})
+		}
+	}
+	return changes
+}
+
+// sortedKeys returns the keys of a map in sorted order
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
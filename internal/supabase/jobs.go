@@ -0,0 +1,43 @@
+package supabase
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Job statuses
+const (
+	JobQueued    = "queued"
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+)
+
+// Job is a long-running operation run in the background. Its result is
+// stored as JSON once it finishes.
+type Job struct {
+	ID          string          `json:"id"`
+	Type        string          `json:"type"`
+	Status      string          `json:"status"`
+	CreatedBy   string          `json:"created_by,omitempty"`
+	Result      json.RawMessage `json:"result,omitempty"`
+	Error       string          `json:"error,omitempty"`
+	CreatedAt   time.Time       `json:"created_at"`
+	StartedAt   *time.Time      `json:"started_at,omitempty"`
+	CompletedAt *time.Time      `json:"completed_at,omitempty"`
+}
+
+// Done reports whether the job has finished
+func (j *Job) Done() bool {
+	return j.Status == JobSucceeded || j.Status == JobFailed
+}
+
+// JobLogLine is a line of a job's log, such as a provisioning step or the
+// progress of a migration
+type JobLogLine struct {
+	// ID orders the lines and resumes a tail after the last one read
+	ID        int64     `json:"id"`
+	JobID     string    `json:"job_id"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,68 @@
+package supabase
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+)
+
+// Bounds on the length of a project's JWT secret
+const (
+	minJWTSecretLength       = 32
+	generatedJWTSecretLength = 64
+)
+
+// jwtSecretAlphabet is the alphabet of generated JWT secrets
+const jwtSecretAlphabet = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+
+// RotateJWTSecretRequest replaces a project's JWT secret. Every key and
+// session signed with the old secret stops working, which the caller has to
+// acknowledge with Confirm.
+type RotateJWTSecretRequest struct {
+	// Secret is the new secret; one is generated when empty
+	Secret  string `json:"jwt_secret,omitempty"`
+	Confirm bool   `json:"confirm"`
+}
+
+// postgrestConfig is the part of a project's PostgREST config holding the
+// JWT secret
+type postgrestConfig struct {
+	JWTSecret string `json:"jwt_secret,omitempty"`
+}
+
+// ValidateJWTSecret checks a caller-supplied JWT secret
+func ValidateJWTSecret(secret string) error {
+	if len(secret) < minJWTSecretLength {
+		return fmt.Errorf("jwt_secret must be at least %d characters", minJWTSecretLength)
+	}
+	return nil
+}
+
+// GenerateJWTSecret returns a random secret suitable for signing project JWTs
+func GenerateJWTSecret() (string, error) {
+	secret := make([]byte, generatedJWTSecretLength)
+	max := big.NewInt(int64(len(jwtSecretAlphabet)))
+	for i := range secret {
+		n, err := rand.Int(rand.Reader, max)
+		if err != nil {
+			return "", fmt.Errorf("failed to generate JWT secret: %w", err)
+		}
+		secret[i] = jwtSecretAlphabet[n.Int64()]
+	}
+	return string(secret), nil
+}
+
+// GetJWTSecret returns the secret a project signs its JWTs with
+func (c *Client) GetJWTSecret(projectRef string) (string, error) {
+	var config postgrestConfig
+	if err := c.managementRequest("GET", "/projects/"+projectRef+"/postgrest", nil, &config); err != nil {
+		return "", err
+	}
+	return config.JWTSecret, nil
+}
+
+// UpdateJWTSecret replaces a project's JWT secret. Supabase then reissues
+// the anon and service role keys, signed with the new secret.
+func (c *Client) UpdateJWTSecret(projectRef, secret string) error {
+	return c.managementRequest("PATCH", "/projects/"+projectRef+"/postgrest", postgrestConfig{JWTSecret: secret}, nil)
+}
@@ -0,0 +1,116 @@
+package supabase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// maxLabels is the most labels a project may carry
+const maxLabels = 32
+
+var (
+	labelKeyPattern   = regexp.MustCompile(`^[a-z0-9]([a-z0-9._/-]{0,62})$`)
+	labelValuePattern = regexp.MustCompile(`^[A-Za-z0-9._-]{0,63}$`)
+)
+
+// ValidateLabels checks label keys and values
+func ValidateLabels(labels map[string]string) error {
+	if len(labels) > maxLabels {
+		return fmt.Errorf("too many labels: %d (at most %d)", len(labels), maxLabels)
+	}
+	for key, value := range labels {
+		if !labelKeyPattern.MatchString(key) {
+			return fmt.Errorf("invalid label key %q (lowercase letters, digits, '.', '_', '-' and '/', at most 63 characters)", key)
+		}
+		if !labelValuePattern.MatchString(value) {
+			return fmt.Errorf("invalid value for label %q: %q (letters, digits, '.', '_' and '-', at most 63 characters)", key, value)
+		}
+	}
+	return nil
+}
+
+// labelRequirement is one comma-separated term of a label selector
+type labelRequirement struct {
+	key    string
+	value  string
+	negate bool
+	// exists is set for bare "key" and "!key" terms
+	exists bool
+}
+
+// LabelSelector selects projects by label, as comma-separated terms that
+// must all hold: "key=value", "key!=value", "key" (present) and "!key"
+// (absent). The empty selector matches everything.
+type LabelSelector struct {
+	requirements []labelRequirement
+}
+
+// ParseLabelSelector parses a selector such as "env=prod,tier!=free"
+func ParseLabelSelector(selector string) (*LabelSelector, error) {
+	s := &LabelSelector{}
+	for _, term := range strings.Split(selector, ",") {
+		term = strings.TrimSpace(term)
+		if term == "" {
+			continue
+		}
+
+		var req labelRequirement
+		switch {
+		case strings.Contains(term, "!="):
+			parts := strings.SplitN(term, "!=", 2)
+			req = labelRequirement{key: strings.TrimSpace(parts[0]), value: strings.TrimSpace(parts[1]), negate: true}
+		case strings.Contains(term, "="):
+			parts := strings.SplitN(strings.Replace(term, "==", "=", 1), "=", 2)
+			req = labelRequirement{key: strings.TrimSpace(parts[0]), value: strings.TrimSpace(parts[1])}
+		case strings.HasPrefix(term, "!"):
+			req = labelRequirement{key: strings.TrimSpace(term[1:]), negate: true, exists: true}
+		default:
+			req = labelRequirement{key: term, exists: true}
+		}
+
+		if !labelKeyPattern.MatchString(req.key) {
+			return nil, fmt.Errorf("invalid label selector term %q", term)
+		}
+		if !req.exists && !labelValuePattern.MatchString(req.value) {
+			return nil, fmt.Errorf("invalid label selector term %q", term)
+		}
+		s.requirements = append(s.requirements, req)
+	}
+	return s, nil
+}
+
+// Matches reports whether labels satisfy every term of the selector
+func (s *LabelSelector) Matches(labels map[string]string) bool {
+	for _, req := range s.requirements {
+		value, ok := labels[req.key]
+		var holds bool
+		if req.exists {
+			holds = ok
+		} else {
+			holds = ok && value == req.value
+		}
+		if holds == req.negate {
+			return false
+		}
+	}
+	return true
+}
+
+// String returns the selector in its canonical form
+func (s *LabelSelector) String() string {
+	terms := make([]string, len(s.requirements))
+	for i, req := range s.requirements {
+		switch {
+		case req.exists && req.negate:
+			terms[i] = "!" + req.key
+		case req.exists:
+			terms[i] = req.key
+		case req.negate:
+			terms[i] = req.key + "!=" + req.value
+		default:
+			terms[i] = req.key + "=" + req.value
+		}
+	}
+	return strings.Join(terms, ",")
+}
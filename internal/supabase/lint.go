@@ -0,0 +1,254 @@
+package supabase
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Lint rule identifiers
+const (
+	LintMissingRLS          = "missing_rls"
+	LintGrantToClientRole   = "grant_to_client_role"
+	LintReservedSchema      = "reserved_schema"
+	LintUnindexedForeignKey = "unindexed_foreign_key"
+)
+
+// largeTableRows is the row count above which adding an unindexed foreign
+// key to an existing table is reported
+const largeTableRows = 10000
+
+// clientRoles are the roles Supabase exposes to browser clients
+var clientRoles = map[string]bool{
+	"anon":          true,
+	"authenticated": true,
+	"public":        true,
+}
+
+// reservedSchemas are managed by Supabase and should not be changed by
+// migrations
+var reservedSchemas = map[string]bool{
+	"auth":    true,
+	"storage": true,
+}
+
+var (
+	createTablePattern   = regexp.MustCompile(`(?is)^CREATE\s+(?:UNLOGGED\s+)?TABLE\s+(?:IF\s+NOT\s+EXISTS\s+)?([\w."]+)\s*\((.*)\)`)
+	enableRLSPattern     = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+ENABLE\s+ROW\s+LEVEL\s+SECURITY`)
+	grantPattern         = regexp.MustCompile(`(?is)^GRANT\s+.+?\s+TO\s+(.+?)(?:\s+WITH\s+GRANT\s+OPTION)?$`)
+	reservedPattern      = regexp.MustCompile(`(?is)^(?:CREATE(?:\s+OR\s+REPLACE)?|ALTER|DROP)\s+(?:TABLE|VIEW|MATERIALIZED\s+VIEW|FUNCTION|PROCEDURE|TYPE|SEQUENCE)\s+(?:IF\s+(?:NOT\s+)?EXISTS\s+)?(?:ONLY\s+)?([\w."]+)`)
+	createIndexPattern   = regexp.MustCompile(`(?is)^CREATE\s+(?:UNIQUE\s+)?INDEX\s+(?:CONCURRENTLY\s+)?(?:IF\s+NOT\s+EXISTS\s+)?(?:[\w"]+\s+)?ON\s+(?:ONLY\s+)?([\w."]+)\s*(?:USING\s+\w+\s*)?\(\s*"?(\w+)"?`)
+	addForeignKeyPattern = regexp.MustCompile(`(?is)^ALTER\s+TABLE\s+(?:IF\s+EXISTS\s+)?(?:ONLY\s+)?([\w."]+)\s+ADD\s+(?:CONSTRAINT\s+[\w"]+\s+)?FOREIGN\s+KEY\s*\(\s*"?(\w+)"?`)
+	tableForeignKey      = regexp.MustCompile(`(?is)^(?:CONSTRAINT\s+[\w"]+\s+)?FOREIGN\s+KEY\s*\(\s*"?(\w+)"?`)
+	tableKeyPattern      = regexp.MustCompile(`(?is)^(?:CONSTRAINT\s+[\w"]+\s+)?(?:PRIMARY\s+KEY|UNIQUE)\s*\(\s*"?(\w+)"?`)
+	columnPattern        = regexp.MustCompile(`(?is)^"?(\w+)"?\s+(.*)$`)
+	referencesPattern    = regexp.MustCompile(`(?i)\bREFERENCES\b`)
+	columnKeyPattern     = regexp.MustCompile(`(?i)\b(?:PRIMARY\s+KEY|UNIQUE)\b`)
+)
+
+// LintWarning is a Supabase-specific problem found in a migration
+type LintWarning struct {
+	Rule      string `json:"rule"`
+	Message   string `json:"message"`
+	Statement int    `json:"statement"`
+}
+
+// LintRequest represents the request to lint a SQL script
+type LintRequest struct {
+	SQL string `json:"sql" binding:"required"`
+}
+
+// foreignKeyRef is a foreign key column found while linting
+type foreignKeyRef struct {
+	table     string
+	column    string
+	statement int
+	existing  bool // added to a table that already exists
+}
+
+// LintMigration checks a SQL script for Supabase-specific pitfalls: tables
+// without row level security, grants to client roles, changes to reserved
+// schemas and foreign keys without an index. rowCount, if given, is used to
+// skip foreign keys added to existing tables that are small.
+func LintMigration(sqlScript string, rowCount func(table string) (int, error)) []LintWarning {
+	var warnings []LintWarning
+
+	statements := splitSQLStatements(sqlScript)
+	createdTables := make(map[string]int)
+	rlsEnabled := make(map[string]bool)
+	indexed := make(map[string]bool)
+	var foreignKeys []foreignKeyRef
+
+	for i, stmt := range statements {
+		stmt = stripLeadingComments(stmt)
+		n := i + 1
+
+		if m := reservedPattern.FindStringSubmatch(stmt); m != nil {
+			if schema, _ := splitQualifiedName(m[1]); reservedSchemas[schema] {
+				warnings = append(warnings, LintWarning{
+					Rule:      LintReservedSchema,
+					Message:   fmt.Sprintf("%s is in the %s schema, which is managed by Supabase", m[1], schema),
+					Statement: n,
+				})
+			}
+		}
+
+		if m := createTablePattern.FindStringSubmatch(stmt); m != nil {
+			table := normalizeTableName(m[1])
+			createdTables[table] = n
+			fks, keyColumns := tableKeys(table, m[2], n)
+			foreignKeys = append(foreignKeys, fks...)
+			for _, column := range keyColumns {
+				indexed[table+"."+column] = true
+			}
+		}
+
+		if m := enableRLSPattern.FindStringSubmatch(stmt); m != nil {
+			rlsEnabled[normalizeTableName(m[1])] = true
+		}
+
+		if m := createIndexPattern.FindStringSubmatch(stmt); m != nil {
+			indexed[normalizeTableName(m[1])+"."+strings.ToLower(m[2])] = true
+		}
+
+		if m := addForeignKeyPattern.FindStringSubmatch(stmt); m != nil {
+			foreignKeys = append(foreignKeys, foreignKeyRef{
+				table:     normalizeTableName(m[1]),
+				column:    strings.ToLower(m[2]),
+				statement: n,
+				existing:  true,
+			})
+		}
+
+		if m := grantPattern.FindStringSubmatch(stmt); m != nil {
+			for _, role := range strings.Split(m[1], ",") {
+				role = strings.ToLower(strings.Trim(strings.TrimSpace(role), `"`))
+				if clientRoles[role] {
+					warnings = append(warnings, LintWarning{
+						Rule:      LintGrantToClientRole,
+						Message:   fmt.Sprintf("GRANT to %s exposes objects to every API client; prefer RLS policies", role),
+						Statement: n,
+					})
+				}
+			}
+		}
+	}
+
+	for table, n := range createdTables {
+		schema, _ := splitQualifiedName(table)
+		if schema == "public" && !rlsEnabled[table] {
+			warnings = append(warnings, LintWarning{
+				Rule:      LintMissingRLS,
+				Message:   fmt.Sprintf("table %s is created without ENABLE ROW LEVEL SECURITY and is readable through the API", table),
+				Statement: n,
+			})
+		}
+	}
+
+	for _, fk := range foreignKeys {
+		if indexed[fk.table+"."+fk.column] {
+			continue
+		}
+		if _, created := createdTables[fk.table]; fk.existing && !created && rowCount != nil {
+			if count, err := rowCount(fk.table); err == nil && count < largeTableRows {
+				continue
+			}
+		}
+		warnings = append(warnings, LintWarning{
+			Rule:      LintUnindexedForeignKey,
+			Message:   fmt.Sprintf("foreign key %s.%s has no index; joins and cascading deletes will scan the table", fk.table, fk.column),
+			Statement: fk.statement,
+		})
+	}
+
+	sort.SliceStable(warnings, func(i, j int) bool {
+		if warnings[i].Statement != warnings[j].Statement {
+			return warnings[i].Statement < warnings[j].Statement
+		}
+		return warnings[i].Rule < warnings[j].Rule
+	})
+	return warnings
+}
+
+// tableKeys returns the foreign key columns of a CREATE TABLE body and the
+// primary key and unique columns, which are indexed implicitly
+func tableKeys(table, body string, statement int) ([]foreignKeyRef, []string) {
+	var foreignKeys []foreignKeyRef
+	var keyColumns []string
+
+	for _, def := range splitTopLevel(body) {
+		def = strings.TrimSpace(def)
+
+		if m := tableForeignKey.FindStringSubmatch(def); m != nil {
+			foreignKeys = append(foreignKeys, foreignKeyRef{table: table, column: strings.ToLower(m[1]), statement: statement})
+			continue
+		}
+		if m := tableKeyPattern.FindStringSubmatch(def); m != nil {
+			keyColumns = append(keyColumns, strings.ToLower(m[1]))
+			continue
+		}
+
+		m := columnPattern.FindStringSubmatch(def)
+		if m == nil {
+			continue
+		}
+		column := strings.ToLower(m[1])
+		switch {
+		case columnKeyPattern.MatchString(m[2]):
+			keyColumns = append(keyColumns, column)
+		case referencesPattern.MatchString(m[2]):
+			foreignKeys = append(foreignKeys, foreignKeyRef{table: table, column: column, statement: statement})
+		}
+	}
+
+	return foreignKeys, keyColumns
+}
+
+// splitTopLevel splits s on commas that are not inside parentheses
+func splitTopLevel(s string) []string {
+	var parts []string
+	depth, start := 0, 0
+	for i, r := range s {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// stripLeadingComments removes -- comment lines before a statement
+func stripLeadingComments(stmt string) string {
+	for strings.HasPrefix(stmt, "--") {
+		idx := strings.Index(stmt, "\n")
+		if idx < 0 {
+			return ""
+		}
+		stmt = strings.TrimSpace(stmt[idx+1:])
+	}
+	return stmt
+}
+
+// splitQualifiedName splits an optionally schema-qualified name, defaulting
+// the schema to public
+func splitQualifiedName(name string) (string, string) {
+	name = strings.ToLower(strings.ReplaceAll(name, `"`, ""))
+	if idx := strings.Index(name, "."); idx >= 0 {
+		return name[:idx], name[idx+1:]
+	}
+	return "public", name
+}
+
+// normalizeTableName returns a table name as schema.table
+func normalizeTableName(name string) string {
+	schema, table := splitQualifiedName(name)
+	return schema + "." + table
+}
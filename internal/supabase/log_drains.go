@@ -0,0 +1,153 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Log drain destinations
+const (
+	LogDrainDatadog  = "datadog"
+	LogDrainBigQuery = "bigquery"
+	LogDrainWebhook  = "webhook"
+)
+
+// logDrainConfigKeys are the required and optional config keys of each
+// destination
+var logDrainConfigKeys = map[string]struct {
+	required []string
+	optional []string
+}{
+	LogDrainDatadog:  {required: []string{"api_key", "region"}},
+	LogDrainBigQuery: {required: []string{"project_id", "dataset_id", "service_account_key"}},
+	LogDrainWebhook:  {required: []string{"url"}, optional: []string{"authorization"}},
+}
+
+// secretLogDrainKeys are config keys redacted whenever a drain is returned
+var secretLogDrainKeys = map[string]bool{
+	"api_key":             true,
+	"service_account_key": true,
+	"authorization":       true,
+}
+
+// datadogRegions are the Datadog sites logs can be shipped to
+var datadogRegions = []string{"US1", "US3", "US5", "EU", "AP1", "US1-FED"}
+
+// LogDrain ships a project's Postgres and API logs to an external
+// destination. Secret config values are redacted when it is encoded as
+// JSON, so a drain can be returned or logged safely.
+type LogDrain struct {
+	ID     string            `json:"id,omitempty"`
+	Name   string            `json:"name" binding:"required"`
+	Type   string            `json:"type" binding:"required"`
+	Config map[string]string `json:"config"`
+}
+
+// logDrainBody is a log drain as sent to and returned by the Management
+// API, with its secrets
+type logDrainBody struct {
+	ID     string            `json:"id,omitempty"`
+	Name   string            `json:"name"`
+	Type   string            `json:"type"`
+	Config map[string]string `json:"config"`
+}
+
+// MarshalJSON encodes the drain with its secret config values redacted
+func (d LogDrain) MarshalJSON() ([]byte, error) {
+	redacted := logDrainBody{ID: d.ID, Name: d.Name, Type: d.Type, Config: map[string]string{}}
+	for key, value := range d.Config {
+		if secretLogDrainKeys[key] && value != "" {
+			value = "[redacted]"
+		}
+		redacted.Config[key] = value
+	}
+	return json.Marshal(redacted)
+}
+
+// Validate checks a log drain's name, type and config
+func (d *LogDrain) Validate() error {
+	if !identifierPattern.MatchString(d.Name) {
+		return fmt.Errorf("invalid log drain name: %q", d.Name)
+	}
+
+	keys, ok := logDrainConfigKeys[d.Type]
+	if !ok {
+		return fmt.Errorf("unknown log drain type %q (expected %s, %s or %s)", d.Type, LogDrainDatadog, LogDrainBigQuery, LogDrainWebhook)
+	}
+	for _, key := range keys.required {
+		if d.Config[key] == "" {
+			return fmt.Errorf("%s log drain %q needs config.%s", d.Type, d.Name, key)
+		}
+	}
+	for key := range d.Config {
+		if !slices.Contains(keys.required, key) && !slices.Contains(keys.optional, key) {
+			return fmt.Errorf("%s log drain %q does not take config.%s", d.Type, d.Name, key)
+		}
+	}
+
+	switch d.Type {
+	case LogDrainDatadog:
+		if !slices.Contains(datadogRegions, strings.ToUpper(d.Config["region"])) {
+			return fmt.Errorf("unknown Datadog region %q (expected one of %s)", d.Config["region"], strings.Join(datadogRegions, ", "))
+		}
+	case LogDrainBigQuery:
+		if !json.Valid([]byte(d.Config["service_account_key"])) {
+			return fmt.Errorf("config.service_account_key must be the service account's JSON key")
+		}
+	case LogDrainWebhook:
+		u, err := url.Parse(d.Config["url"])
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("config.url must be an https URL")
+		}
+	}
+	return nil
+}
+
+// ValidateLogDrains checks a set of log drains, which must have unique names
+func ValidateLogDrains(drains []LogDrain) error {
+	names := map[string]bool{}
+	for i := range drains {
+		if err := drains[i].Validate(); err != nil {
+			return err
+		}
+		if names[drains[i].Name] {
+			return fmt.Errorf("duplicate log drain name %q", drains[i].Name)
+		}
+		names[drains[i].Name] = true
+	}
+	return nil
+}
+
+// ListLogDrains returns a project's log drains, ordered by name
+func (c *Client) ListLogDrains(projectRef string) ([]LogDrain, error) {
+	var bodies []logDrainBody
+	if err := c.managementRequest("GET", "/projects/"+projectRef+"/analytics/log-drains", nil, &bodies); err != nil {
+		return nil, err
+	}
+
+	drains := make([]LogDrain, len(bodies))
+	for i, body := range bodies {
+		drains[i] = LogDrain(body)
+	}
+	sort.Slice(drains, func(i, j int) bool { return drains[i].Name < drains[j].Name })
+	return drains, nil
+}
+
+// CreateLogDrain adds a log drain to a project, returning it with its ID
+func (c *Client) CreateLogDrain(projectRef string, drain LogDrain) (*LogDrain, error) {
+	var created logDrainBody
+	if err := c.managementRequest("POST", "/projects/"+projectRef+"/analytics/log-drains", logDrainBody(drain), &created); err != nil {
+		return nil, err
+	}
+	result := LogDrain(created)
+	return &result, nil
+}
+
+// DeleteLogDrain removes a log drain from a project
+func (c *Client) DeleteLogDrain(projectRef, drainID string) error {
+	return c.managementRequest("DELETE", "/projects/"+projectRef+"/analytics/log-drains/"+url.PathEscape(drainID), nil, nil)
+}
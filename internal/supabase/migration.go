@@ -4,21 +4,41 @@ import (
 	"bytes"
 	"database/sql"
 	"fmt"
+	"regexp"
 	"strings"
 	"time"
 
 	_ "github.com/lib/pq" // PostgreSQL driver
 )
 
-// MigrationRunner handles SQL migrations on Supabase databases
+// DatabaseTarget is anything the migration runner can connect to
+type DatabaseTarget interface {
+	GetDatabaseConnectionString() string
+}
+
+// MigrationRunner handles SQL migrations on Supabase and external Postgres databases
 type MigrationRunner struct {
-	project *Project
-	db      *sql.DB
+	target DatabaseTarget
+	db     *sql.DB
+	// defaultRole is assumed by migrations that do not name a role
+	defaultRole string
+	// ephemeral is the temporary login role this runner connects as
+	ephemeral *ephemeralRole
+	// onClose runs after the connection is closed
+	onClose []func()
+	// strict turns on strict mode for the migrations of this runner
+	strict *StrictLimits
+	// version and versionName are recorded in the migration table by the
+	// next migration
+	version     string
+	versionName string
+	// onStatement runs before each statement of a migration
+	onStatement func(n, total int, statement string)
 }
 
 // NewMigrationRunner creates a new migration runner
-func NewMigrationRunner(project *Project) (*MigrationRunner, error) {
-	connStr := project.GetDatabaseConnectionString()
+func NewMigrationRunner(target DatabaseTarget) (*MigrationRunner, error) {
+	connStr := target.GetDatabaseConnectionString()
 	if connStr == "" {
 		return nil, fmt.Errorf("no database connection string available")
 	}
@@ -49,21 +69,66 @@ func NewMigrationRunner(project *Project) (*MigrationRunner, error) {
 	}
 
 	return &MigrationRunner{
-		project: project,
-		db:      db,
+		target: target,
+		db:     db,
 	}, nil
 }
 
 // Close closes the database connection
 func (mr *MigrationRunner) Close() error {
+	var err error
 	if mr.db != nil {
-		return mr.db.Close()
+		err = mr.db.Close()
 	}
-	return nil
+	if mr.ephemeral != nil {
+		if dropErr := mr.ephemeral.close(); err == nil {
+			err = dropErr
+		}
+		mr.ephemeral = nil
+	}
+	for _, fn := range mr.onClose {
+		fn()
+	}
+	mr.onClose = nil
+	return err
+}
+
+// OnClose registers fn to run once the runner is closed
+func (mr *MigrationRunner) OnClose(fn func()) {
+	mr.onClose = append(mr.onClose, fn)
+}
+
+// OnStatement registers fn to run before each statement of later
+// migrations, with its position and the number of statements, so their
+// progress can be followed
+func (mr *MigrationRunner) OnStatement(fn func(n, total int, statement string)) {
+	mr.onStatement = fn
+}
+
+// SetStrictLimits runs later migrations in strict mode with the given
+// limits; nil turns strict mode off
+func (mr *MigrationRunner) SetStrictLimits(limits *StrictLimits) {
+	mr.strict = limits
 }
 
 // ApplyMigration executes SQL migration on the database
 func (mr *MigrationRunner) ApplyMigration(sqlScript string) (*MigrationResult, error) {
+	return mr.ApplyMigrationAs(sqlScript, "")
+}
+
+// ApplyMigrationAs executes a SQL migration under the given role (SET LOCAL
+// ROLE) so it is limited to that role's privileges. An empty role runs the
+// migration as the connecting user. Statements that could switch back to a
+// more privileged role are rejected.
+func (mr *MigrationRunner) ApplyMigrationAs(sqlScript, role string) (*MigrationResult, error) {
+	return mr.ApplyVerifiedMigration(sqlScript, role, nil, VerifyRollback)
+}
+
+// ApplyVerifiedMigration executes a SQL migration like ApplyMigrationAs,
+// then runs the verification queries in the same transaction. When one
+// fails, the migration is rolled back with ErrVerificationFailed, or with
+// onFailure VerifyFlag, committed and flagged in its result.
+func (mr *MigrationRunner) ApplyVerifiedMigration(sqlScript, role string, checks []Verification, onFailure string) (*MigrationResult, error) {
 	startTime := time.Now()
 	result := &MigrationResult{
 		Success: false,
@@ -74,6 +139,12 @@ func (mr *MigrationRunner) ApplyMigration(sqlScript string) (*MigrationResult, e
 		result.Error = fmt.Sprintf("SQL validation failed: %v", err)
 		return result, err
 	}
+	if role != "" {
+		if err := validateRoleSQL(sqlScript, role); err != nil {
+			result.Error = fmt.Sprintf("SQL validation failed: %v", err)
+			return result, err
+		}
+	}
 
 	// Split SQL into individual statements
 	statements := splitSQLStatements(sqlScript)
@@ -93,6 +164,18 @@ func (mr *MigrationRunner) ApplyMigration(sqlScript string) (*MigrationResult, e
 		}
 	}()
 
+	// Drop privileges for the rest of the transaction
+	setRole := role
+	if setRole == "" {
+		setRole = mr.defaultRole
+	}
+	if setRole != "" {
+		if _, err := tx.Exec("SET LOCAL ROLE " + quoteIdentifier(setRole)); err != nil {
+			result.Error = fmt.Sprintf("failed to set role %s: %v", setRole, err)
+			return result, fmt.Errorf("failed to set role %s: %w", setRole, err)
+		}
+	}
+
 	// Execute each statement
 	var tablesCreated []string
 	var totalRowsInserted int
@@ -103,7 +186,27 @@ func (mr *MigrationRunner) ApplyMigration(sqlScript string) (*MigrationResult, e
 			continue
 		}
 
+		// In strict mode, reject the statement if the planner expects it
+		// to touch too much before it runs
+		if mr.strict != nil && isStrictStatement(stmt) {
+			estimate, err := estimateStatement(tx, i+1, stmt)
+			if err != nil {
+				result.Error = err.Error()
+				return result, err
+			}
+			exceeded := mr.strict.check(estimate)
+			estimate.Exceeded = exceeded != ""
+			result.Estimates = append(result.Estimates, *estimate)
+			if exceeded != "" {
+				result.Error = fmt.Sprintf("statement %d rejected by strict mode, rolled back: %s\nStatement: %s", i+1, exceeded, stmt[:min(len(stmt), 100)])
+				return result, fmt.Errorf("%w: statement %d %s", ErrStrictLimitExceeded, i+1, exceeded)
+			}
+		}
+
 		// Execute statement
+		if mr.onStatement != nil {
+			mr.onStatement(i+1, len(statements), stmt)
+		}
 		execResult, err := tx.Exec(stmt)
 		if err != nil {
 			result.Error = fmt.Sprintf("statement %d failed: %v\nStatement: %s", i+1, err, stmt[:min(len(stmt), 100)])
@@ -125,6 +228,24 @@ func (mr *MigrationRunner) ApplyMigration(sqlScript string) (*MigrationResult, e
 		}
 	}
 
+	// Verify the migration before committing it
+	for i, check := range checks {
+		result.Verifications = append(result.Verifications, runVerification(tx, i, check))
+	}
+	if failed := FailedVerifications(result.Verifications); failed != "" {
+		result.VerificationFailed = true
+		if onFailure != VerifyFlag {
+			result.Error = fmt.Sprintf("verification failed, rolled back: %s", failed)
+			return result, fmt.Errorf("%w: %s", ErrVerificationFailed, failed)
+		}
+	}
+
+	// Record the version with the migration, so it is applied exactly once
+	if err := mr.recordVersion(tx, statements, setRole); err != nil {
+		result.Error = err.Error()
+		return result, err
+	}
+
 	// Commit transaction
 	if err := tx.Commit(); err != nil {
 		result.Error = fmt.Sprintf("failed to commit transaction: %v", err)
@@ -198,6 +319,41 @@ func validateSQL(sql string) error {
 	return nil
 }
 
+// roleChangePattern matches statements that change or reset the current role
+var roleChangePattern = regexp.MustCompile(`(?i)\b(RESET\s+(ROLE|ALL|SESSION\s+AUTHORIZATION)|SET\s+(SESSION\s+|LOCAL\s+)?(ROLE|SESSION\s+AUTHORIZATION)\b|SET_CONFIG\s*\(\s*'role')`)
+
+// privilegedRoles can never be used as a migration role
+var privilegedRoles = map[string]bool{
+	"postgres":               true,
+	"supabase_admin":         true,
+	"supabase_auth_admin":    true,
+	"supabase_storage_admin": true,
+	"service_role":           true,
+	"authenticator":          true,
+}
+
+// ValidateMigrationRole checks that a role may be used to run migrations
+func ValidateMigrationRole(role string) error {
+	if !identifierPattern.MatchString(role) {
+		return fmt.Errorf("invalid role name: %q", role)
+	}
+	if privilegedRoles[strings.ToLower(role)] {
+		return fmt.Errorf("role %s is privileged and cannot run migrations", role)
+	}
+	return nil
+}
+
+// validateRoleSQL rejects SQL that would escape the migration role
+func validateRoleSQL(sql, role string) error {
+	if err := ValidateMigrationRole(role); err != nil {
+		return err
+	}
+	if match := roleChangePattern.FindString(sql); match != "" {
+		return fmt.Errorf("changing the role is not allowed when running as %s: %s", role, match)
+	}
+	return nil
+}
+
 // splitSQLStatements splits SQL script into individual statements
 func splitSQLStatements(sql string) []string {
 	var statements []string
@@ -339,4 +495,28 @@ func min(a, b int) int {
 		return a
 	}
 	return b
-}
\ No newline at end of file
+}
+// MigrationPlan describes what a migration would execute without running it
+type MigrationPlan struct {
+	Statements    []string      `json:"statements"`
+	TablesCreated []string      `json:"tables_created,omitempty"`
+	Warnings      []LintWarning `json:"warnings,omitempty"`
+}
+
+// PlanMigration validates a SQL script and splits it into the statements
+// ApplyMigration would execute
+func PlanMigration(sqlScript string) (*MigrationPlan, error) {
+	if err := validateSQL(sqlScript); err != nil {
+		return nil, fmt.Errorf("SQL validation failed: %w", err)
+	}
+
+	plan := &MigrationPlan{Statements: splitSQLStatements(sqlScript)}
+	for _, stmt := range plan.Statements {
+		if tableName := extractTableName(stmt); tableName != "" {
+			plan.TablesCreated = append(plan.TablesCreated, tableName)
+		}
+	}
+	plan.Warnings = LintMigration(sqlScript, nil)
+
+	return plan, nil
+}
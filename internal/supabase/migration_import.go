@@ -0,0 +1,112 @@
+package supabase
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// maxImportedMigrations bounds the migrations of one import request
+const maxImportedMigrations = 1000
+
+// cliMigrationFilePattern matches Supabase CLI migration file names such as
+// 20240101120000_create_todos.sql, capturing the version
+var cliMigrationFilePattern = regexp.MustCompile(`^([0-9]+)_[^/\\]*\.sql$`)
+
+// sha256Pattern matches a hex encoded SHA-256 checksum
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// ImportMigrationsRequest registers migrations that were applied outside
+// the manager, such as with the Supabase CLI, as history without running
+// them
+type ImportMigrationsRequest struct {
+	Migrations []ImportedMigration `json:"migrations" binding:"required"`
+}
+
+// ImportedMigration is a migration file of a supabase/migrations folder
+type ImportedMigration struct {
+	// File is the file name, such as 20240101120000_create_todos.sql; its
+	// numeric prefix becomes the version
+	File string `json:"file"`
+	// SHA256 is the checksum of the file. It can be left out when SQL is
+	// sent; when both are sent they must match.
+	SHA256 string `json:"sha256,omitempty"`
+	// SQL is the content of the file, stored for export but never run
+	SQL string `json:"sql,omitempty"`
+	// AppliedAt defaults to the time of the import
+	AppliedAt *time.Time `json:"applied_at,omitempty"`
+}
+
+// Import outcomes of ImportedMigrationResult.Status
+const (
+	ImportStatusImported = "imported"
+	ImportStatusSkipped  = "skipped"
+	ImportStatusConflict = "conflict"
+)
+
+// ImportedMigrationResult is the outcome of importing one file
+type ImportedMigrationResult struct {
+	File    string `json:"file"`
+	Version string `json:"version"`
+	Status  string `json:"status"`
+	Details string `json:"details,omitempty"`
+}
+
+// Validate checks the file names and checksums, and that no version is
+// listed twice
+func (r *ImportMigrationsRequest) Validate() error {
+	if len(r.Migrations) == 0 {
+		return fmt.Errorf("at least one migration is required")
+	}
+	if len(r.Migrations) > maxImportedMigrations {
+		return fmt.Errorf("at most %d migrations can be imported at once, got %d", maxImportedMigrations, len(r.Migrations))
+	}
+
+	versions := make(map[string]string, len(r.Migrations))
+	for i := range r.Migrations {
+		migration := &r.Migrations[i]
+		version, err := migration.Version()
+		if err != nil {
+			return err
+		}
+		if other, ok := versions[version]; ok {
+			return fmt.Errorf("%s and %s have the same version %s", other, migration.File, version)
+		}
+		versions[version] = migration.File
+		if _, err := migration.Checksum(); err != nil {
+			return fmt.Errorf("%s: %w", migration.File, err)
+		}
+	}
+	return nil
+}
+
+// Version returns the version of the migration from its file name
+func (m *ImportedMigration) Version() (string, error) {
+	match := cliMigrationFilePattern.FindStringSubmatch(m.File)
+	if match == nil {
+		return "", fmt.Errorf("%q is not a migration file name like 20240101120000_create_todos.sql", m.File)
+	}
+	return match[1], nil
+}
+
+// Checksum returns the SHA-256 of the file, from SQL when it was sent
+func (m *ImportedMigration) Checksum() (string, error) {
+	if m.SHA256 != "" && !sha256Pattern.MatchString(m.SHA256) {
+		return "", fmt.Errorf("sha256 must be 64 lowercase hex characters")
+	}
+	if m.SQL == "" {
+		if m.SHA256 == "" {
+			return "", fmt.Errorf("sha256 or sql is required")
+		}
+		return m.SHA256, nil
+	}
+
+	sum := sha256.Sum256([]byte(m.SQL))
+	checksum := hex.EncodeToString(sum[:])
+	if m.SHA256 != "" && m.SHA256 != checksum {
+		return "", fmt.Errorf("sha256 does not match the sql, which hashes to %s", checksum)
+	}
+	return checksum, nil
+}
@@ -0,0 +1,202 @@
+package supabase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// NamingTeamToken is replaced in a naming policy's prefix and suffix by the
+// project's team
+const NamingTeamToken = "{team}"
+
+// NamingPolicy constrains the names of projects created by the manager, so
+// projects in a shared organization can be told apart by owner. Names given
+// by callers are checked against it; names the manager generates are made
+// to follow it. The zero policy allows any name.
+type NamingPolicy struct {
+	// Prefix and Suffix are required at the start and end of every name.
+	// Either may contain {team}, replaced by the project's team.
+	Prefix string `json:"prefix,omitempty"`
+	Suffix string `json:"suffix,omitempty"`
+	// AllowedChars is the body of a regular expression character class,
+	// such as "a-z0-9-", that every character of a name must match
+	AllowedChars string `json:"allowed_chars,omitempty"`
+	// MaxLength limits the length of names in bytes; 0 means no limit
+	MaxLength int `json:"max_length,omitempty"`
+	// TeamLabel is the label holding a project's team
+	TeamLabel string `json:"team_label,omitempty"`
+}
+
+// Validate checks that the policy can be applied: the character class
+// compiles, the fixed parts of the prefix and suffix use allowed
+// characters, and they leave room for a name
+func (p *NamingPolicy) Validate() error {
+	if p.MaxLength < 0 {
+		return fmt.Errorf("max length must not be negative")
+	}
+	allowed, err := p.allowedPattern()
+	if err != nil {
+		return err
+	}
+	for _, affix := range []string{p.Prefix, p.Suffix} {
+		fixed := strings.ReplaceAll(affix, NamingTeamToken, "")
+		if allowed != nil && !allowed.MatchString(fixed) {
+			return fmt.Errorf("prefix and suffix must only use the allowed characters [%s], got %q", p.AllowedChars, affix)
+		}
+	}
+	if p.usesTeam() && p.TeamLabel == "" {
+		return fmt.Errorf("a team label is required to use %s", NamingTeamToken)
+	}
+	if p.MaxLength > 0 && len(p.Prefix)+len(p.Suffix) >= p.MaxLength {
+		return fmt.Errorf("prefix and suffix leave no room for a name within %d characters", p.MaxLength)
+	}
+	return nil
+}
+
+// usesTeam reports whether the prefix or suffix contains the team token
+func (p *NamingPolicy) usesTeam() bool {
+	return strings.Contains(p.Prefix, NamingTeamToken) || strings.Contains(p.Suffix, NamingTeamToken)
+}
+
+// allowedPattern compiles AllowedChars into a pattern matching whole
+// strings, nil when any character is allowed
+func (p *NamingPolicy) allowedPattern() (*regexp.Regexp, error) {
+	if p.AllowedChars == "" {
+		return nil, nil
+	}
+	pattern, err := regexp.Compile("^[" + p.AllowedChars + "]*$")
+	if err != nil {
+		return nil, fmt.Errorf("invalid allowed characters %q: %w", p.AllowedChars, err)
+	}
+	return pattern, nil
+}
+
+// affixes returns the prefix and suffix with the team of a project with
+// the given labels filled in
+func (p *NamingPolicy) affixes(labels map[string]string) (string, string, error) {
+	if !p.usesTeam() {
+		return p.Prefix, p.Suffix, nil
+	}
+	team := labels[p.TeamLabel]
+	if team == "" {
+		return "", "", fmt.Errorf("the naming policy needs the project's team in the %q label", p.TeamLabel)
+	}
+	return strings.ReplaceAll(p.Prefix, NamingTeamToken, team), strings.ReplaceAll(p.Suffix, NamingTeamToken, team), nil
+}
+
+// Check reports how a name breaks the policy for a project with the given
+// labels, or nil if it follows it
+func (p *NamingPolicy) Check(name string, labels map[string]string) error {
+	prefix, suffix, err := p.affixes(labels)
+	if err != nil {
+		return err
+	}
+	if !strings.HasPrefix(name, prefix) {
+		return fmt.Errorf("name must start with %q", prefix)
+	}
+	if !strings.HasSuffix(name, suffix) || len(name) < len(prefix)+len(suffix) {
+		return fmt.Errorf("name must end with %q", suffix)
+	}
+	if p.MaxLength > 0 && len(name) > p.MaxLength {
+		return fmt.Errorf("name is %d characters long, the limit is %d", len(name), p.MaxLength)
+	}
+	allowed, err := p.allowedPattern()
+	if err != nil {
+		return err
+	}
+	if allowed != nil {
+		for _, r := range name {
+			if !allowed.MatchString(string(r)) {
+				return fmt.Errorf("name contains %q, but only [%s] is allowed", r, p.AllowedChars)
+			}
+		}
+	}
+	return nil
+}
+
+// Apply turns a generated name into one that follows the policy: the
+// prefix and suffix are added unless already present, letters are
+// lowercased if only their lowercase is allowed, other characters that are
+// not allowed become '-' (or are dropped if '-' is not allowed either), and
+// the middle is shortened to fit the length limit
+func (p *NamingPolicy) Apply(name string, labels map[string]string) (string, error) {
+	prefix, suffix, err := p.affixes(labels)
+	if err != nil {
+		return "", err
+	}
+	allowed, err := p.allowedPattern()
+	if err != nil {
+		return "", err
+	}
+
+	body := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+	if allowed != nil {
+		replacement := ""
+		if allowed.MatchString("-") {
+			replacement = "-"
+		}
+		var b strings.Builder
+		for _, r := range body {
+			if lower := unicode.ToLower(r); !allowed.MatchString(string(r)) && allowed.MatchString(string(lower)) {
+				r = lower
+			}
+			if allowed.MatchString(string(r)) {
+				b.WriteRune(r)
+			} else if replacement != "" && !strings.HasSuffix(b.String(), replacement) {
+				b.WriteString(replacement)
+			}
+		}
+		body = strings.Trim(b.String(), "-")
+	}
+
+	applied := p.fit(prefix, body, suffix)
+	if err := p.Check(applied, labels); err != nil {
+		return "", err
+	}
+	return applied, nil
+}
+
+// Disambiguate adds a tag such as "2" to a name that is taken, before the
+// policy's suffix and within its length limit: "team-app-prod" becomes
+// "team-app-2-prod" with suffix "-prod". The result can still be too long
+// when the tag itself does not fit.
+func (p *NamingPolicy) Disambiguate(name, tag string, labels map[string]string) string {
+	prefix, suffix, err := p.affixes(labels)
+	if err != nil || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+		prefix, suffix = "", ""
+	}
+	body := strings.TrimSuffix(strings.TrimPrefix(name, prefix), suffix)
+	if p.MaxLength > 0 {
+		body = shorten(body, p.MaxLength-len(prefix)-len(suffix)-len(tag)-1)
+	}
+	if body == "" {
+		return prefix + tag + suffix
+	}
+	return prefix + body + "-" + tag + suffix
+}
+
+// fit joins a name's parts, shortening the body to the length limit
+func (p *NamingPolicy) fit(prefix, body, suffix string) string {
+	if p.MaxLength > 0 {
+		body = shorten(body, p.MaxLength-len(prefix)-len(suffix))
+	}
+	return prefix + body + suffix
+}
+
+// shorten cuts s to at most n bytes on a character boundary, without a
+// trailing '-'
+func shorten(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	if n < 0 {
+		n = 0
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return strings.TrimRight(s[:n], "-")
+}
@@ -0,0 +1,42 @@
+package supabase
+
+import (
+	"fmt"
+	"slices"
+	"time"
+)
+
+// OrgDefaults are the organization-wide defaults of new projects, managed
+// at /api/org/defaults. Each default that is set replaces the one the
+// deployment configures; a create request that sets its own value keeps
+// it.
+type OrgDefaults struct {
+	Region string `json:"region,omitempty"`
+	Plan   string `json:"plan,omitempty"`
+	// NamingPolicy replaces the configured naming policy as a whole,
+	// except for the team label when it leaves that unset
+	NamingPolicy *NamingPolicy `json:"naming_policy,omitempty"`
+	// SecurityBaseline is applied over the configured auth baseline
+	SecurityBaseline *AuthSettings `json:"security_baseline,omitempty"`
+	UpdatedAt        *time.Time    `json:"updated_at,omitempty"`
+	UpdatedBy        string        `json:"updated_by,omitempty"`
+}
+
+// Validate checks that every default that is set can be applied. The
+// region is left to the caller, which knows the current region list.
+func (d *OrgDefaults) Validate() error {
+	if d.Plan != "" && !slices.Contains(Plans, d.Plan) {
+		return fmt.Errorf("plan must be one of %v, got %q", Plans, d.Plan)
+	}
+	if d.NamingPolicy != nil {
+		if err := d.NamingPolicy.Validate(); err != nil {
+			return fmt.Errorf("naming_policy: %w", err)
+		}
+	}
+	if d.SecurityBaseline != nil {
+		if err := d.SecurityBaseline.Validate(); err != nil {
+			return fmt.Errorf("security_baseline: %w", err)
+		}
+	}
+	return nil
+}
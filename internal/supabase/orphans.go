@@ -0,0 +1,41 @@
+package supabase
+
+import "time"
+
+// AuditProjectAdopted records that a remote project was imported into the
+// manager
+const AuditProjectAdopted = "project.adopted"
+
+// RemoteOrphan is a project of the organization that exists in Supabase
+// but is not tracked by the manager, such as one created in the dashboard
+// or one whose local record was lost
+type RemoteOrphan struct {
+	ProjectRef string    `json:"project_ref"`
+	Name       string    `json:"name"`
+	Region     string    `json:"region"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+	// Ignored is set for orphans on the ignore list, which are only listed
+	// when asked for
+	Ignored bool          `json:"ignored,omitempty"`
+	Ignore  *OrphanIgnore `json:"ignore,omitempty"`
+}
+
+// OrphanIgnore puts a remote project on the ignore list, so it is no longer
+// reported as drift
+type OrphanIgnore struct {
+	ProjectRef string    `json:"project_ref"`
+	Reason     string    `json:"reason,omitempty"`
+	IgnoredBy  string    `json:"ignored_by"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// AdoptProjectRequest imports a remote orphan. The body is optional.
+type AdoptProjectRequest struct {
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// IgnoreOrphanRequest is the optional body of an ignore request
+type IgnoreOrphanRequest struct {
+	Reason string `json:"reason,omitempty"`
+}
@@ -0,0 +1,279 @@
+package supabase
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Labels marking preview projects. PreviewLabelID identifies the repository
+// and branch; the others are readable, possibly shortened, forms of them.
+const (
+	PreviewLabelID     = "preview/id"
+	PreviewLabelRepo   = "preview/repo"
+	PreviewLabelBranch = "preview/branch"
+)
+
+// DefaultPreviewMigrationsDir is where the Supabase CLI keeps migrations
+const DefaultPreviewMigrationsDir = "supabase/migrations"
+
+// Reasons a preview is closed
+const (
+	PreviewMerged = "merged"
+	PreviewClosed = "closed"
+)
+
+// previewCommentTimeout bounds the post of a preview comment
+const previewCommentTimeout = 10 * time.Second
+
+// labelUnsafePattern matches the runs of characters not allowed in label
+// values
+var labelUnsafePattern = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// CreatePreviewRequest creates a preview project for a branch of a git
+// repository and applies the branch's migrations to it
+type CreatePreviewRequest struct {
+	// Repo is the https URL of the repository
+	Repo   string `json:"repo" binding:"required"`
+	Branch string `json:"branch" binding:"required"`
+	// MigrationsDir holds the branch's migrations, applied in file name
+	// order; defaults to supabase/migrations
+	MigrationsDir string `json:"migrations_dir,omitempty"`
+	// SourceToken authenticates the fetch of the migrations
+	SourceToken     string            `json:"source_token,omitempty"`
+	Region          string            `json:"region,omitempty"`
+	PostgresVersion string            `json:"postgres_version,omitempty"`
+	Labels          map[string]string `json:"labels,omitempty"`
+	// CommentURL receives the preview's URL and keys once it is ready,
+	// such as the GitHub comments URL of the pull request
+	CommentURL string `json:"comment_url,omitempty"`
+	// CommentToken is sent as a bearer token to CommentURL
+	CommentToken string `json:"comment_token,omitempty"`
+	// IncludeServiceKey adds the service role key to the comment; only the
+	// anon key is posted otherwise
+	IncludeServiceKey bool `json:"include_service_key,omitempty"`
+}
+
+// ClosePreviewRequest reports that a branch was merged or closed, so its
+// preview is deleted
+type ClosePreviewRequest struct {
+	Repo   string `json:"repo" binding:"required"`
+	Branch string `json:"branch" binding:"required"`
+	// Reason is merged or closed
+	Reason string `json:"reason,omitempty"`
+}
+
+// BootstrapMigration is a migration file applied to a new project
+type BootstrapMigration struct {
+	// Version, if set, is recorded in the project's migration table
+	Version string `json:"version,omitempty"`
+	Name    string `json:"name"`
+	Source  string `json:"source,omitempty"`
+	SHA256  string `json:"sha256,omitempty"`
+	SQL     string `json:"-"`
+}
+
+// PreviewComment is where the outcome of a preview is posted
+type PreviewComment struct {
+	URL               string `json:"url"`
+	Token             string `json:"-"`
+	Repo              string `json:"repo"`
+	Branch            string `json:"branch"`
+	IncludeServiceKey bool   `json:"include_service_key,omitempty"`
+}
+
+// PreviewReport is posted to the comment URL of a preview. Body is a
+// Markdown summary, so the payload can be sent straight to the GitHub and
+// Gitea comment APIs.
+type PreviewReport struct {
+	Body       string                   `json:"body"`
+	Event      string                   `json:"event"`
+	Repo       string                   `json:"repo"`
+	Branch     string                   `json:"branch"`
+	ProjectID  string                   `json:"project_id,omitempty"`
+	ProjectURL string                   `json:"project_url,omitempty"`
+	AnonKey    string                   `json:"anon_key,omitempty"`
+	ServiceKey string                   `json:"service_key,omitempty"`
+	Migrations []PreviewMigrationResult `json:"migrations,omitempty"`
+	Error      string                   `json:"error,omitempty"`
+}
+
+// PreviewMigrationResult is the outcome of one migration file of a preview
+type PreviewMigrationResult struct {
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Skipped bool   `json:"skipped,omitempty"`
+	Error   string `json:"error,omitempty"`
+}
+
+// Preview report events
+const (
+	PreviewEventReady  = "preview.ready"
+	PreviewEventFailed = "preview.failed"
+)
+
+// Validate checks a preview request and fills in the default migrations
+// directory
+func (r *CreatePreviewRequest) Validate() error {
+	if err := validatePreviewRepo(r.Repo); err != nil {
+		return err
+	}
+	if !gitRefPattern.MatchString(r.Branch) {
+		return fmt.Errorf("invalid branch %q", r.Branch)
+	}
+	if r.MigrationsDir == "" {
+		r.MigrationsDir = DefaultPreviewMigrationsDir
+	}
+	r.MigrationsDir = strings.Trim(r.MigrationsDir, "/")
+	if !gitRefPattern.MatchString(r.MigrationsDir) || strings.Contains(r.MigrationsDir, "..") {
+		return fmt.Errorf("invalid migrations_dir %q", r.MigrationsDir)
+	}
+	if r.CommentURL != "" {
+		u, err := url.Parse(r.CommentURL)
+		if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil {
+			return fmt.Errorf("comment_url must be an https URL without credentials")
+		}
+	}
+	return nil
+}
+
+// validatePreviewRepo checks that a repository is an https URL without
+// credentials, query or fragment
+func validatePreviewRepo(repo string) error {
+	u, err := url.Parse(repo)
+	if err != nil || u.Scheme != "https" || u.Host == "" || u.User != nil || u.RawQuery != "" || u.Fragment != "" {
+		return fmt.Errorf("repo must be the https URL of a git repository, got %q", repo)
+	}
+	return nil
+}
+
+// MigrationsURL returns the git source of the branch's migrations directory
+func (r *CreatePreviewRequest) MigrationsURL() string {
+	return "git+" + r.Repo + "#" + r.Branch + ":" + r.MigrationsDir
+}
+
+// PreviewID identifies the preview of a branch of a repository. Trailing
+// slashes and ".git" are ignored, and the host is case-insensitive.
+func PreviewID(repo, branch string) string {
+	sum := sha256.Sum256([]byte(normalizeRepo(repo) + "\x00" + branch))
+	return hex.EncodeToString(sum[:8])
+}
+
+// normalizeRepo returns the canonical form of a repository URL
+func normalizeRepo(repo string) string {
+	u, err := url.Parse(repo)
+	if err != nil {
+		return repo
+	}
+	return strings.ToLower(u.Host) + strings.TrimSuffix(strings.TrimRight(u.Path, "/"), ".git")
+}
+
+// PreviewLabels returns the labels marking the preview of a branch
+func PreviewLabels(repo, branch string) map[string]string {
+	name := normalizeRepo(repo)
+	if _, path, ok := strings.Cut(name, "/"); ok {
+		name = path
+	}
+	return map[string]string{
+		PreviewLabelID:     PreviewID(repo, branch),
+		PreviewLabelRepo:   labelValue(name),
+		PreviewLabelBranch: labelValue(branch),
+	}
+}
+
+// PreviewProjectName returns the name of a branch's preview project, such
+// as "preview-app-feature-login" for branch feature/login of owner/app
+func PreviewProjectName(repo, branch string) string {
+	name := normalizeRepo(repo)
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+	slug := Slugify("preview " + name + " " + branch)
+	if len(slug) > 60 {
+		slug = strings.TrimRight(slug[:60], "-")
+	}
+	return slug
+}
+
+// labelValue turns s into a valid label value, replacing other characters
+// with '.' and keeping the end, which usually tells branches apart
+func labelValue(s string) string {
+	s = strings.Trim(labelUnsafePattern.ReplaceAllString(s, "."), ".")
+	if len(s) > 63 {
+		s = strings.TrimLeft(s[len(s)-63:], "._-")
+	}
+	return s
+}
+
+// PreviewCommentBody renders the Markdown of a preview report
+func PreviewCommentBody(r *PreviewReport) string {
+	var b strings.Builder
+	if r.Event == PreviewEventFailed {
+		fmt.Fprintf(&b, "### Supabase preview failed\n\n`%s` could not be set up: %s\n", r.Branch, r.Error)
+	} else {
+		fmt.Fprintf(&b, "### Supabase preview ready\n\n| | |\n|---|---|\n| Branch | `%s` |\n| URL | %s |\n", r.Branch, r.ProjectURL)
+		if r.AnonKey != "" {
+			fmt.Fprintf(&b, "| Anon key | `%s` |\n", r.AnonKey)
+		}
+		if r.ServiceKey != "" {
+			fmt.Fprintf(&b, "| Service role key | `%s` |\n", r.ServiceKey)
+		}
+	}
+
+	if len(r.Migrations) > 0 {
+		b.WriteString("\n**Migrations**\n\n")
+		for _, m := range r.Migrations {
+			switch {
+			case m.Skipped:
+				fmt.Fprintf(&b, "- `%s` skipped\n", m.Name)
+			case m.Success:
+				fmt.Fprintf(&b, "- `%s` applied\n", m.Name)
+			default:
+				fmt.Fprintf(&b, "- `%s` failed: %s\n", m.Name, m.Error)
+			}
+		}
+	}
+	return b.String()
+}
+
+// PostPreviewComment posts a preview report as JSON to the comment URL
+func PostPreviewComment(comment *PreviewComment, report *PreviewReport) error {
+	report.Repo = comment.Repo
+	report.Branch = comment.Branch
+	report.Body = PreviewCommentBody(report)
+	body, err := json.Marshal(report)
+	if err != nil {
+		return fmt.Errorf("failed to marshal preview comment: %w", err)
+	}
+
+	req, err := http.NewRequest("POST", comment.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json")
+	if comment.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+comment.Token)
+	}
+
+	client := &http.Client{Timeout: previewCommentTimeout}
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to post preview comment: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		bodyBytes, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("preview comment error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+	return nil
+}
@@ -0,0 +1,152 @@
+package supabase
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Embedding index types of the ai profile
+const (
+	IndexHNSW    = "hnsw"
+	IndexIVFFlat = "ivfflat"
+)
+
+// Bounds on embedding dimensions; pgvector indexes support up to 2000
+const (
+	defaultEmbeddingDimensions = 1536
+	maxEmbeddingDimensions     = 2000
+)
+
+// ProfileOptions tune a provisioning profile
+type ProfileOptions struct {
+	// Dimensions is the size of the embedding vectors (default 1536)
+	Dimensions int `json:"dimensions,omitempty"`
+	// Index is the vector index type: hnsw (default) or ivfflat
+	Index string `json:"index,omitempty"`
+	// LogDrains are created by profiles that ship logs
+	LogDrains []LogDrain `json:"log_drains,omitempty"`
+}
+
+// ProjectProfile is a named set of setup steps applied to a new project
+// once it is ready, on top of any requested buckets and extensions
+type ProjectProfile struct {
+	Name        string   `json:"name"`
+	Description string   `json:"description"`
+	Extensions  []string `json:"extensions"`
+	// LogDrains reports whether the profile needs log drains in its
+	// options
+	LogDrains bool `json:"log_drains"`
+	// setupSQL returns the SQL run after the extensions are enabled, if
+	// the profile has any
+	setupSQL func(opts ProfileOptions) (string, error)
+}
+
+// profiles are the provisioning profiles that can be requested
+var profiles = map[string]ProjectProfile{
+	"ai": {
+		Name:        "ai",
+		Description: "pgvector with an embeddings table, a vector index and a match_embeddings search function",
+		Extensions:  []string{"vector"},
+		setupSQL:    aiProfileSQL,
+	},
+	"production": {
+		Name:        "production",
+		Description: "log drains shipping Postgres and API logs to Datadog, BigQuery or a webhook, and pg_stat_statements for query insights",
+		Extensions:  []string{"pg_stat_statements"},
+		LogDrains:   true,
+	},
+}
+
+// Profiles returns the available provisioning profiles by name
+func Profiles() []ProjectProfile {
+	list := make([]ProjectProfile, 0, len(profiles))
+	for _, profile := range profiles {
+		list = append(list, profile)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list
+}
+
+// LookupProfile returns a provisioning profile by name
+func LookupProfile(name string) (*ProjectProfile, error) {
+	profile, ok := profiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown profile %q", name)
+	}
+	return &profile, nil
+}
+
+// SetupSQL validates the options and returns the profile's SQL for them,
+// which is empty for profiles without SQL
+func (p *ProjectProfile) SetupSQL(opts ProfileOptions) (string, error) {
+	if p.LogDrains {
+		if len(opts.LogDrains) == 0 {
+			return "", fmt.Errorf("profile %s needs at least one entry in log_drains", p.Name)
+		}
+		if err := ValidateLogDrains(opts.LogDrains); err != nil {
+			return "", err
+		}
+	} else if len(opts.LogDrains) > 0 {
+		return "", fmt.Errorf("profile %s does not take log_drains", p.Name)
+	}
+
+	if p.setupSQL == nil {
+		return "", nil
+	}
+	return p.setupSQL(opts)
+}
+
+// aiProfileSQL creates the embeddings table, its cosine distance index and a
+// similarity search function, applying defaults for unset options. The
+// index build gets more memory than the default, and ivfflat starts with
+// the 100 lists pgvector recommends for up to a million rows.
+func aiProfileSQL(opts ProfileOptions) (string, error) {
+	if opts.Dimensions == 0 {
+		opts.Dimensions = defaultEmbeddingDimensions
+	}
+	if opts.Index == "" {
+		opts.Index = IndexHNSW
+	}
+	if opts.Dimensions < 1 || opts.Dimensions > maxEmbeddingDimensions {
+		return "", fmt.Errorf("dimensions must be between 1 and %d", maxEmbeddingDimensions)
+	}
+	if opts.Index != IndexHNSW && opts.Index != IndexIVFFlat {
+		return "", fmt.Errorf("index must be %s or %s", IndexHNSW, IndexIVFFlat)
+	}
+
+	index := "USING hnsw (embedding extensions.vector_cosine_ops)"
+	if opts.Index == IndexIVFFlat {
+		index = "USING ivfflat (embedding extensions.vector_cosine_ops) WITH (lists = 100)"
+	}
+
+	return fmt.Sprintf(`SET LOCAL maintenance_work_mem = '256MB';
+
+CREATE TABLE IF NOT EXISTS public.embeddings (
+    id bigint GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    content text NOT NULL,
+    metadata jsonb NOT NULL DEFAULT '{}'::jsonb,
+    embedding extensions.vector(%[1]d),
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+ALTER TABLE public.embeddings ENABLE ROW LEVEL SECURITY;
+
+CREATE INDEX IF NOT EXISTS embeddings_embedding_idx ON public.embeddings %[2]s;
+
+CREATE OR REPLACE FUNCTION public.match_embeddings(
+    query_embedding extensions.vector(%[1]d),
+    match_threshold float,
+    match_count int
+)
+RETURNS TABLE (id bigint, content text, metadata jsonb, similarity float)
+LANGUAGE sql STABLE
+SET search_path = public, extensions
+AS $$
+    SELECT e.id, e.content, e.metadata, 1 - (e.embedding <=> query_embedding) AS similarity
+    FROM public.embeddings e
+    WHERE 1 - (e.embedding <=> query_embedding) > match_threshold
+    ORDER BY e.embedding <=> query_embedding
+    LIMIT match_count;
+$$;
+`, opts.Dimensions, index), nil
+}
@@ -0,0 +1,150 @@
+package supabase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+)
+
+// provisioningProfileNamePattern matches configured profile names such as
+// eu-prod-large
+var provisioningProfileNamePattern = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+// ProvisioningProfile is a named set of project settings configured
+// centrally by the operator, such as "eu-prod-large" or "us-dev-free". A
+// create request naming it in "profile" gets the profile's plan, region,
+// compute size, Postgres version, labels and template unless the request
+// sets its own, and the profile's auth settings and migration roles.
+type ProvisioningProfile struct {
+	Name            string `json:"name"`
+	Description     string `json:"description,omitempty"`
+	Plan            string `json:"plan,omitempty"`
+	Region          string `json:"region,omitempty"`
+	ComputeSize     string `json:"compute_size,omitempty"`
+	PostgresVersion string `json:"postgres_version,omitempty"`
+	// Labels are added to the request's labels, which win on conflict
+	Labels   map[string]string `json:"labels,omitempty"`
+	Template *ProjectTemplate  `json:"template,omitempty"`
+	// Setup names a built-in profile, such as "ai", applied once the
+	// project is ready, with SetupOptions unless the request has options
+	Setup        string          `json:"setup,omitempty"`
+	SetupOptions *ProfileOptions `json:"setup_options,omitempty"`
+	// AuthSettings are applied over the manager's auth baseline
+	AuthSettings *AuthSettings `json:"auth_settings,omitempty"`
+	// MigrationRoles restrict the roles migrations against the project
+	// may run as
+	MigrationRoles *MigrationRolesRequest `json:"migration_roles,omitempty"`
+}
+
+// ParseProvisioningProfiles reads a JSON array of provisioning profiles and
+// validates each. Names must be unique and must not shadow a built-in
+// profile.
+func ParseProvisioningProfiles(data []byte) ([]ProvisioningProfile, error) {
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var list []ProvisioningProfile
+	if err := decoder.Decode(&list); err != nil {
+		return nil, fmt.Errorf("failed to parse profiles: %w", err)
+	}
+
+	seen := make(map[string]bool, len(list))
+	for i := range list {
+		profile := &list[i]
+		if err := profile.Validate(); err != nil {
+			return nil, fmt.Errorf("profile %q: %w", profile.Name, err)
+		}
+		if seen[profile.Name] {
+			return nil, fmt.Errorf("profile %q is defined twice", profile.Name)
+		}
+		seen[profile.Name] = true
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Name < list[j].Name })
+	return list, nil
+}
+
+// Validate checks that a create request using the profile can succeed.
+// The region is checked at startup against the current region list.
+func (p *ProvisioningProfile) Validate() error {
+	if !provisioningProfileNamePattern.MatchString(p.Name) {
+		return fmt.Errorf("name must be lowercase letters, digits and dashes, at most 63 characters")
+	}
+	if _, ok := profiles[p.Name]; ok {
+		return fmt.Errorf("name is taken by a built-in profile")
+	}
+	if p.Plan != "" && !slices.Contains(Plans, p.Plan) {
+		return fmt.Errorf("plan must be one of %v, got %q", Plans, p.Plan)
+	}
+	if p.ComputeSize != "" && !slices.Contains(ComputeSizes, p.ComputeSize) {
+		return fmt.Errorf("compute_size must be one of %v, got %q", ComputeSizes, p.ComputeSize)
+	}
+	if p.PostgresVersion != "" && !IsSupportedPostgresVersion(p.PostgresVersion) {
+		return fmt.Errorf("unsupported Postgres version %q", p.PostgresVersion)
+	}
+	if err := ValidateLabels(p.Labels); err != nil {
+		return err
+	}
+	if p.Template != nil {
+		if err := p.Template.Validate(); err != nil {
+			return fmt.Errorf("invalid template: %w", err)
+		}
+	}
+	if p.Setup != "" {
+		setup, err := LookupProfile(p.Setup)
+		if err != nil {
+			return err
+		}
+		var opts ProfileOptions
+		if p.SetupOptions != nil {
+			opts = *p.SetupOptions
+		}
+		if _, err := setup.SetupSQL(opts); err != nil {
+			return fmt.Errorf("invalid setup options: %w", err)
+		}
+	} else if p.SetupOptions != nil {
+		return fmt.Errorf("setup_options needs a setup profile")
+	}
+	if p.AuthSettings != nil {
+		if err := p.AuthSettings.Validate(); err != nil {
+			return fmt.Errorf("invalid auth settings: %w", err)
+		}
+	}
+	if p.MigrationRoles != nil {
+		if err := p.MigrationRoles.Validate(); err != nil {
+			return fmt.Errorf("invalid migration roles: %w", err)
+		}
+	}
+	return nil
+}
+
+// Apply fills the settings a create request leaves unset from the profile
+func (p *ProvisioningProfile) Apply(req *CreateProjectRequest) {
+	if req.Plan == "" {
+		req.Plan = p.Plan
+	}
+	if req.Region == "" {
+		req.Region = p.Region
+	}
+	if req.ComputeSize == "" {
+		req.ComputeSize = p.ComputeSize
+	}
+	if req.PostgresVersion == "" {
+		req.PostgresVersion = p.PostgresVersion
+	}
+	if req.Template == nil {
+		req.Template = p.Template
+	}
+	if len(p.Labels) > 0 {
+		labels := make(map[string]string, len(p.Labels)+len(req.Labels))
+		for key, value := range p.Labels {
+			labels[key] = value
+		}
+		for key, value := range req.Labels {
+			labels[key] = value
+		}
+		req.Labels = labels
+	}
+}
@@ -0,0 +1,95 @@
+package supabase
+
+import (
+	"net/url"
+)
+
+// Region is a region projects can be created in
+type Region struct {
+	Code     string `json:"code"`
+	Name     string `json:"name"`
+	Provider string `json:"provider,omitempty"`
+	// Available is false while Supabase reports the region as unable to
+	// take new projects, with the reason in Status
+	Available bool   `json:"available"`
+	Status    string `json:"status,omitempty"`
+}
+
+// regionNames are the friendly names of SupportedRegions
+var regionNames = map[string]string{
+	"us-east-1":      "East US (North Virginia)",
+	"us-east-2":      "East US (Ohio)",
+	"us-west-1":      "West US (North California)",
+	"us-west-2":      "West US (Oregon)",
+	"ca-central-1":   "Canada (Central)",
+	"eu-west-1":      "West EU (Ireland)",
+	"eu-west-2":      "West Europe (London)",
+	"eu-west-3":      "West EU (Paris)",
+	"eu-central-1":   "Central EU (Frankfurt)",
+	"eu-central-2":   "Central Europe (Zurich)",
+	"eu-north-1":     "North EU (Stockholm)",
+	"ap-south-1":     "South Asia (Mumbai)",
+	"ap-southeast-1": "Southeast Asia (Singapore)",
+	"ap-southeast-2": "Oceania (Sydney)",
+	"ap-northeast-1": "Northeast Asia (Tokyo)",
+	"ap-northeast-2": "Northeast Asia (Seoul)",
+	"sa-east-1":      "South America (São Paulo)",
+}
+
+// BuiltinRegions returns SupportedRegions as regions, all available. They
+// are used when the Management API's list can't be fetched.
+func BuiltinRegions() []Region {
+	regions := make([]Region, 0, len(SupportedRegions))
+	for _, code := range SupportedRegions {
+		name := regionNames[code]
+		if name == "" {
+			name = code
+		}
+		regions = append(regions, Region{Code: code, Name: name, Provider: "AWS", Available: true})
+	}
+	return regions
+}
+
+// availableRegions is the part of the available regions response the
+// client reads
+type availableRegions struct {
+	All struct {
+		Specific []struct {
+			Code     string `json:"code"`
+			Name     string `json:"name"`
+			Provider string `json:"provider"`
+			Status   string `json:"status"`
+		} `json:"specific"`
+	} `json:"all"`
+}
+
+// ListRegions retrieves the regions the organization can create projects
+// in. A region Supabase reports a status for, such as one at capacity, is
+// not available.
+func (c *Client) ListRegions() ([]Region, error) {
+	path := "/projects/available-regions?organization_slug=" + url.QueryEscape(c.organizationID)
+
+	var response availableRegions
+	if err := c.managementRequest("GET", path, nil, &response); err != nil {
+		return nil, err
+	}
+
+	regions := make([]Region, 0, len(response.All.Specific))
+	for _, region := range response.All.Specific {
+		if region.Code == "" {
+			continue
+		}
+		name := region.Name
+		if name == "" {
+			name = region.Code
+		}
+		regions = append(regions, Region{
+			Code:      region.Code,
+			Name:      name,
+			Provider:  region.Provider,
+			Available: region.Status == "",
+			Status:    region.Status,
+		})
+	}
+	return regions, nil
+}
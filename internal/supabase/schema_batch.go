@@ -0,0 +1,107 @@
+package supabase
+
+import "fmt"
+
+const (
+	// maxSchemaBatchProjects bounds the projects of one batch schema apply
+	maxSchemaBatchProjects = 200
+	// DefaultSchemaBatchConcurrency and MaxSchemaBatchConcurrency bound
+	// how many projects a batch schema apply migrates at once
+	DefaultSchemaBatchConcurrency = 4
+	MaxSchemaBatchConcurrency     = 16
+)
+
+// Outcomes of applying a batch migration to one project
+const (
+	SchemaBatchApplied   = "applied"
+	SchemaBatchSkipped   = "skipped"
+	SchemaBatchFailed    = "failed"
+	SchemaBatchCancelled = "cancelled"
+)
+
+// BatchSchemaRequest applies one migration to several projects, picked by
+// ID or by label selector. The migration takes the fields of a schema
+// request.
+type BatchSchemaRequest struct {
+	ApplySchemaRequest
+	ProjectIDs    []string `json:"project_ids,omitempty"`
+	LabelSelector string   `json:"label_selector,omitempty"`
+	// Concurrency is how many projects are migrated at once
+	Concurrency int `json:"concurrency,omitempty"`
+	// FailFast cancels the projects not started yet once one fails
+	FailFast bool `json:"fail_fast,omitempty"`
+}
+
+// Validate checks a batch schema request and fills in the default
+// concurrency
+func (r *BatchSchemaRequest) Validate() error {
+	if (len(r.ProjectIDs) == 0) == (r.LabelSelector == "") {
+		return fmt.Errorf("exactly one of project_ids and label_selector is required")
+	}
+	if len(r.ProjectIDs) > maxSchemaBatchProjects {
+		return fmt.Errorf("at most %d projects can be migrated at once, got %d", maxSchemaBatchProjects, len(r.ProjectIDs))
+	}
+	seen := make(map[string]bool, len(r.ProjectIDs))
+	for _, id := range r.ProjectIDs {
+		if seen[id] {
+			return fmt.Errorf("project %s is listed twice", id)
+		}
+		seen[id] = true
+	}
+
+	if r.SQL == "" {
+		return fmt.Errorf("sql is required")
+	}
+	if r.SourceURL != "" || len(r.Migrations) > 0 {
+		return fmt.Errorf("source_url and migrations are not supported across projects; send the migration as sql")
+	}
+
+	if r.Concurrency == 0 {
+		r.Concurrency = DefaultSchemaBatchConcurrency
+	}
+	if r.Concurrency < 1 || r.Concurrency > MaxSchemaBatchConcurrency {
+		return fmt.Errorf("concurrency must be between 1 and %d", MaxSchemaBatchConcurrency)
+	}
+	return nil
+}
+
+// ProjectMigration is the outcome of a batch migration for one project
+type ProjectMigration struct {
+	ProjectID string           `json:"project_id"`
+	Name      string           `json:"name"`
+	Status    string           `json:"status"`
+	Result    *MigrationResult `json:"result,omitempty"`
+	Error     string           `json:"error,omitempty"`
+}
+
+// SchemaBatchSummary counts the projects of a batch by outcome
+type SchemaBatchSummary struct {
+	Total     int `json:"total"`
+	Applied   int `json:"applied"`
+	Skipped   int `json:"skipped"`
+	Failed    int `json:"failed"`
+	Cancelled int `json:"cancelled"`
+}
+
+// SchemaBatchResult is the result of a batch schema apply job
+type SchemaBatchResult struct {
+	Version  string             `json:"version"`
+	Summary  SchemaBatchSummary `json:"summary"`
+	Projects []ProjectMigration `json:"projects"`
+}
+
+// Add records a project's outcome and counts it in the summary
+func (r *SchemaBatchResult) Add(migration ProjectMigration) {
+	r.Projects = append(r.Projects, migration)
+	r.Summary.Total++
+	switch migration.Status {
+	case SchemaBatchApplied:
+		r.Summary.Applied++
+	case SchemaBatchSkipped:
+		r.Summary.Skipped++
+	case SchemaBatchFailed:
+		r.Summary.Failed++
+	case SchemaBatchCancelled:
+		r.Summary.Cancelled++
+	}
+}
@@ -0,0 +1,420 @@
+package supabase
+
+import (
+	"fmt"
+	"strings"
+)
+
+// SchemaDiffRequest asks for the DDL converging a project's database on a
+// desired schema. Exactly one of SQL and Schema gives the desired state;
+// Schema has the form GET /api/projects/:id/schema returns.
+type SchemaDiffRequest struct {
+	SQL    string          `json:"sql,omitempty"`
+	Schema *SchemaSnapshot `json:"schema,omitempty"`
+	// Schemas limits the diff to these database schemas; by default it
+	// covers the schemas the desired state has tables in
+	Schemas []string `json:"schemas,omitempty"`
+}
+
+// Validate checks the request, filling in what a desired schema given as
+// JSON leaves out: the public schema and data types derived from types
+func (r *SchemaDiffRequest) Validate() error {
+	if (r.SQL == "") == (r.Schema == nil) {
+		return fmt.Errorf("exactly one of sql and schema is required")
+	}
+	if r.Schema == nil {
+		return nil
+	}
+
+	for i := range r.Schema.Tables {
+		table := &r.Schema.Tables[i]
+		if table.Name == "" {
+			return fmt.Errorf("table %d has no name", i)
+		}
+		if table.Schema == "" {
+			table.Schema = "public"
+		}
+		for j := range table.Columns {
+			column := &table.Columns[j]
+			if column.Name == "" {
+				return fmt.Errorf("table %s: column %d has no name", table.QualifiedName(), j)
+			}
+			if column.DataType == "" && column.Type == "" {
+				return fmt.Errorf("table %s: column %s has no type", table.QualifiedName(), column.Name)
+			}
+			if column.DataType == "" {
+				column.DataType = normalizeDataType(strings.ToLower(typeModifierPattern.ReplaceAllString(column.Type, "")))
+			}
+		}
+	}
+	for i := range r.Schema.Indexes {
+		if r.Schema.Indexes[i].Schema == "" {
+			r.Schema.Indexes[i].Schema = "public"
+		}
+	}
+	for i := range r.Schema.Constraints {
+		constraint := &r.Schema.Constraints[i]
+		if constraint.Name == "" || constraint.Table == "" {
+			return fmt.Errorf("constraint %d needs a name and a table", i)
+		}
+		if constraint.Schema == "" {
+			constraint.Schema = "public"
+		}
+	}
+	return nil
+}
+
+// Actions of DiffStatement.Action
+const (
+	DiffCreateExtension = "create_extension"
+	DiffCreateTable     = "create_table"
+	DiffDropTable       = "drop_table"
+	DiffAddColumn       = "add_column"
+	DiffDropColumn      = "drop_column"
+	DiffAlterColumn     = "alter_column"
+	DiffAddConstraint   = "add_constraint"
+	DiffDropConstraint  = "drop_constraint"
+	DiffCreateIndex     = "create_index"
+	DiffDropIndex       = "drop_index"
+)
+
+// DiffStatement is one DDL statement of a schema diff
+type DiffStatement struct {
+	SQL    string `json:"sql"`
+	Action string `json:"action"`
+	// Object is the table, column, index or constraint acted on
+	Object string `json:"object"`
+	// Destructive is set on statements that drop tables or columns, or
+	// rewrite a column to another type
+	Destructive bool `json:"destructive,omitempty"`
+}
+
+// SchemaDiff is the DDL converging a database on a desired schema, in the
+// order it must run
+type SchemaDiff struct {
+	InSync     bool            `json:"in_sync"`
+	Statements []DiffStatement `json:"statements"`
+	// DDL is the statements as one script
+	DDL         string `json:"ddl"`
+	Destructive int    `json:"destructive"`
+	// Warnings name differences no statement could be generated for
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// add appends a statement to the diff
+func (d *SchemaDiff) add(action, object, sql string, destructive bool) {
+	d.Statements = append(d.Statements, DiffStatement{SQL: sql, Action: action, Object: object, Destructive: destructive})
+	if destructive {
+		d.Destructive++
+	}
+}
+
+// warn records a difference the diff cannot converge
+func (d *SchemaDiff) warn(format string, args ...interface{}) {
+	d.Warnings = append(d.Warnings, fmt.Sprintf(format, args...))
+}
+
+// DiffSchemas returns the DDL turning the live schema into the desired one,
+// within the given database schemas (those of the desired tables when
+// empty). Extensions are created but never dropped, as Supabase installs
+// its own. Indexes backing a primary key, unique or exclusion constraint
+// follow their constraint.
+//
+// With structural set, as for a desired schema parsed from SQL, only what
+// ParseSchemaSQL derives reliably is compared: columns by data type and
+// nullability, defaults only where the live column has none, and indexes
+// and constraints by name and type. Otherwise types, defaults and
+// definitions are compared as Postgres reports them.
+func DiffSchemas(desired, live *SchemaSnapshot, schemas []string, structural bool) *SchemaDiff {
+	scope := make(map[string]bool)
+	for _, schema := range schemas {
+		scope[schema] = true
+	}
+	if len(scope) == 0 {
+		for _, table := range desired.Tables {
+			scope[table.Schema] = true
+		}
+	}
+	if len(scope) == 0 {
+		scope["public"] = true
+	}
+
+	diff := &SchemaDiff{Statements: []DiffStatement{}}
+
+	desiredTables, desiredOrder := tablesInScope(desired, scope)
+	liveTables, liveOrder := tablesInScope(live, scope)
+	desiredConstraints, desiredBacked := constraintsInScope(desired, scope)
+	liveConstraints, liveBacked := constraintsInScope(live, scope)
+	desiredIndexes := indexesInScope(desired, scope, desiredBacked)
+	liveIndexes := indexesInScope(live, scope, liveBacked)
+
+	installed := make(map[string]bool)
+	for _, ext := range live.Extensions {
+		installed[ext.Name] = true
+	}
+	for _, ext := range desired.Extensions {
+		if !installed[ext.Name] {
+			diff.add(DiffCreateExtension, ext.Name, "CREATE EXTENSION IF NOT EXISTS "+quoteIdentifier(ext.Name), false)
+		}
+	}
+
+	constraintChanged := func(want, have ConstraintInfo) bool {
+		if structural {
+			return want.Type != have.Type
+		}
+		return want.describe() != have.describe()
+	}
+
+	// Foreign keys go first, so the tables and keys they refer to can be
+	// dropped. Constraints of dropped tables go with them.
+	for _, foreignKeys := range []bool{true, false} {
+		for _, key := range sortedKeys(liveConstraints) {
+			have := liveConstraints[key]
+			if (have.Type == ConstraintForeignKey) != foreignKeys {
+				continue
+			}
+			if _, kept := desiredTables[have.Schema+"."+have.Table]; !kept && !foreignKeys {
+				continue
+			}
+			if want, ok := desiredConstraints[key]; ok && !constraintChanged(want, have) {
+				continue
+			}
+			diff.add(DiffDropConstraint, key, fmt.Sprintf("ALTER TABLE %s DROP CONSTRAINT %s",
+				qualifiedIdentifier(have.Schema, have.Table), quoteIdentifier(have.Name)), false)
+		}
+	}
+
+	for _, key := range sortedKeys(liveIndexes) {
+		have := liveIndexes[key]
+		if _, kept := desiredTables[have.Schema+"."+have.Table]; !kept {
+			continue
+		}
+		if want, ok := desiredIndexes[key]; ok && (structural || want.Definition == have.Definition) {
+			continue
+		}
+		diff.add(DiffDropIndex, key, "DROP INDEX "+qualifiedIdentifier(have.Schema, have.Name), false)
+	}
+
+	for _, key := range desiredOrder {
+		if _, exists := liveTables[key]; exists {
+			continue
+		}
+		table := desiredTables[key]
+		var columns []string
+		for _, column := range table.Columns {
+			definition, ok := columnDefinition(column)
+			if !ok {
+				diff.warn("column %s.%s has no type to create it with", key, column.Name)
+				continue
+			}
+			columns = append(columns, "  "+definition)
+		}
+		diff.add(DiffCreateTable, key, fmt.Sprintf("CREATE TABLE %s (\n%s\n)",
+			qualifiedIdentifier(table.Schema, table.Name), strings.Join(columns, ",\n")), false)
+	}
+
+	for _, key := range desiredOrder {
+		have, exists := liveTables[key]
+		if !exists {
+			continue
+		}
+		diffColumns(diff, desiredTables[key], have, structural)
+	}
+
+	for _, foreignKeys := range []bool{false, true} {
+		for _, key := range sortedKeys(desiredConstraints) {
+			want := desiredConstraints[key]
+			if (want.Type == ConstraintForeignKey) != foreignKeys {
+				continue
+			}
+			if have, ok := liveConstraints[key]; ok && !constraintChanged(want, have) {
+				continue
+			}
+			if want.Definition == "" {
+				diff.warn("constraint %s has no definition to add it with", key)
+				continue
+			}
+			diff.add(DiffAddConstraint, key, fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s",
+				qualifiedIdentifier(want.Schema, want.Table), quoteIdentifier(want.Name), want.Definition), false)
+		}
+	}
+
+	for _, key := range sortedKeys(desiredIndexes) {
+		want := desiredIndexes[key]
+		if have, ok := liveIndexes[key]; ok && (structural || want.Definition == have.Definition) {
+			continue
+		}
+		if want.Definition == "" {
+			diff.warn("index %s has no definition to create it with", key)
+			continue
+		}
+		diff.add(DiffCreateIndex, key, want.Definition, false)
+	}
+
+	for _, key := range desiredOrder {
+		have, exists := liveTables[key]
+		if !exists {
+			continue
+		}
+		wanted := make(map[string]bool)
+		for _, column := range desiredTables[key].Columns {
+			wanted[column.Name] = true
+		}
+		for _, column := range have.Columns {
+			if !wanted[column.Name] {
+				diff.add(DiffDropColumn, key+"."+column.Name, fmt.Sprintf("ALTER TABLE %s DROP COLUMN %s",
+					qualifiedIdentifier(have.Schema, have.Name), quoteIdentifier(column.Name)), true)
+			}
+		}
+	}
+
+	for _, key := range liveOrder {
+		if _, kept := desiredTables[key]; kept {
+			continue
+		}
+		table := liveTables[key]
+		diff.add(DiffDropTable, key, "DROP TABLE "+qualifiedIdentifier(table.Schema, table.Name), true)
+	}
+
+	sql := make([]string, len(diff.Statements))
+	for i, statement := range diff.Statements {
+		sql[i] = statement.SQL + ";\n"
+	}
+	diff.DDL = strings.Join(sql, "")
+	diff.InSync = len(diff.Statements) == 0
+	return diff
+}
+
+// diffColumns adds the statements converging the columns of a table present
+// on both sides, except drops, which run after new constraints and indexes
+func diffColumns(diff *SchemaDiff, want, have TableInfo, structural bool) {
+	table := qualifiedIdentifier(want.Schema, want.Name)
+	existing := make(map[string]ColumnInfo)
+	for _, column := range have.Columns {
+		existing[column.Name] = column
+	}
+
+	for _, column := range want.Columns {
+		object := want.QualifiedName() + "." + column.Name
+		current, ok := existing[column.Name]
+		if !ok {
+			definition, ok := columnDefinition(column)
+			if !ok {
+				diff.warn("column %s has no type to add it with", object)
+				continue
+			}
+			diff.add(DiffAddColumn, object, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s", table, definition), false)
+			continue
+		}
+
+		alter := fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s ", table, quoteIdentifier(column.Name))
+		typeChanged := column.DataType != current.DataType ||
+			(!structural && column.Type != "" && current.Type != "" && column.Type != current.Type)
+		if typeChanged {
+			columnType := alterableType(column)
+			if columnType == "" {
+				diff.warn("column %s changes type but has none to alter it to", object)
+			} else {
+				diff.add(DiffAlterColumn, object, fmt.Sprintf("%sTYPE %s USING %s::%s", alter, columnType, quoteIdentifier(column.Name), columnType), true)
+			}
+		}
+
+		switch {
+		case !column.Nullable && current.Nullable:
+			diff.add(DiffAlterColumn, object, alter+"SET NOT NULL", false)
+		case column.Nullable && !current.Nullable:
+			diff.add(DiffAlterColumn, object, alter+"DROP NOT NULL", false)
+		}
+
+		switch {
+		case column.Default == current.Default:
+		case column.Default != "" && (!structural || current.Default == ""):
+			diff.add(DiffAlterColumn, object, alter+"SET DEFAULT "+column.Default, false)
+		case column.Default == "" && !structural:
+			diff.add(DiffAlterColumn, object, alter+"DROP DEFAULT", false)
+		}
+	}
+}
+
+// columnDefinition renders a column for CREATE TABLE or ADD COLUMN,
+// reporting false if its type is not known well enough to write it
+func columnDefinition(column ColumnInfo) (string, bool) {
+	columnType := column.Type
+	if columnType == "" {
+		if column.DataType == "USER-DEFINED" || column.DataType == "ARRAY" {
+			return "", false
+		}
+		columnType = column.DataType
+	}
+
+	definition := quoteIdentifier(column.Name) + " " + columnType
+	if column.Default != "" {
+		definition += " DEFAULT " + column.Default
+	}
+	if !column.Nullable {
+		definition += " NOT NULL"
+	}
+	return definition, true
+}
+
+// alterableType returns the type to ALTER COLUMN TYPE a column to: serial
+// types become their integer type, as they are only valid when creating
+func alterableType(column ColumnInfo) string {
+	if column.Type != "" && !serialTypes[strings.ToLower(column.Type)] {
+		return column.Type
+	}
+	if column.DataType == "USER-DEFINED" || column.DataType == "ARRAY" {
+		return ""
+	}
+	return column.DataType
+}
+
+// tablesInScope returns the tables of a snapshot in the given schemas, by
+// qualified name and in snapshot order
+func tablesInScope(snapshot *SchemaSnapshot, scope map[string]bool) (map[string]TableInfo, []string) {
+	tables := make(map[string]TableInfo)
+	var order []string
+	for _, table := range snapshot.Tables {
+		if scope[table.Schema] {
+			tables[table.QualifiedName()] = table
+			order = append(order, table.QualifiedName())
+		}
+	}
+	return tables, order
+}
+
+// constraintsInScope returns the constraints of a snapshot in the given
+// schemas by qualified name, and the schema-qualified names of the indexes
+// backing them
+func constraintsInScope(snapshot *SchemaSnapshot, scope map[string]bool) (map[string]ConstraintInfo, map[string]bool) {
+	constraints := make(map[string]ConstraintInfo)
+	backed := make(map[string]bool)
+	for _, constraint := range snapshot.Constraints {
+		if !scope[constraint.Schema] {
+			continue
+		}
+		constraints[constraint.QualifiedName()] = constraint
+		if constraint.Type != ConstraintForeignKey && constraint.Type != ConstraintCheck {
+			backed[constraint.Schema+"."+constraint.Name] = true
+		}
+	}
+	return constraints, backed
+}
+
+// indexesInScope returns the indexes of a snapshot in the given schemas that
+// do not back a constraint, by schema-qualified name
+func indexesInScope(snapshot *SchemaSnapshot, scope map[string]bool, backed map[string]bool) map[string]IndexInfo {
+	indexes := make(map[string]IndexInfo)
+	for _, index := range snapshot.Indexes {
+		key := index.Schema + "." + index.Name
+		if scope[index.Schema] && !backed[key] {
+			indexes[key] = index
+		}
+	}
+	return indexes
+}
+
+// qualifiedIdentifier quotes a schema-qualified name
+func qualifiedIdentifier(schema, name string) string {
+	return quoteIdentifier(schema) + "." + quoteIdentifier(name)
+}
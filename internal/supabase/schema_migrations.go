@@ -0,0 +1,175 @@
+package supabase
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"slices"
+
+	"github.com/lib/pq"
+)
+
+// maxVersionedMigrations bounds the migrations of one apply request
+const maxVersionedMigrations = 100
+
+// migrationVersionPattern matches migration versions: digits, as in the
+// Supabase CLI's timestamp versions such as 20240101120000
+var migrationVersionPattern = regexp.MustCompile(`^[0-9]{1,32}$`)
+
+// ValidateMigrationVersion checks a caller-supplied migration version
+func ValidateMigrationVersion(version string) error {
+	if !migrationVersionPattern.MatchString(version) {
+		return fmt.Errorf("invalid migration version %q: must be 1 to 32 digits, such as 20240101120000", version)
+	}
+	return nil
+}
+
+// VersionedMigration is one migration of an ApplySchemaRequest batch
+type VersionedMigration struct {
+	Version string `json:"version"`
+	Name    string `json:"name,omitempty"`
+	SQL     string `json:"sql"`
+}
+
+// MigrationBatchResult is the outcome of applying a batch of versioned
+// migrations. Versions already recorded in the database are skipped; the
+// batch stops at the first migration that fails.
+type MigrationBatchResult struct {
+	Applied []string           `json:"applied"`
+	Skipped []string           `json:"skipped"`
+	Results []*MigrationResult `json:"results"`
+}
+
+// ValidateBatch checks the migrations of a batch request: each needs a
+// valid, unique version and SQL, and the batch cannot be combined with a
+// single migration's sql, source_url, version or verifications
+func (r *ApplySchemaRequest) ValidateBatch() error {
+	if r.SQL != "" || r.SourceURL != "" || r.Version != "" {
+		return fmt.Errorf("migrations cannot be combined with sql, source_url or version")
+	}
+	if len(r.Verify) > 0 {
+		return fmt.Errorf("verify is not supported with migrations")
+	}
+	if len(r.Migrations) > maxVersionedMigrations {
+		return fmt.Errorf("at most %d migrations can be applied at once, got %d", maxVersionedMigrations, len(r.Migrations))
+	}
+
+	seen := make(map[string]bool, len(r.Migrations))
+	for _, migration := range r.Migrations {
+		if err := ValidateMigrationVersion(migration.Version); err != nil {
+			return err
+		}
+		if seen[migration.Version] {
+			return fmt.Errorf("version %s is listed twice", migration.Version)
+		}
+		seen[migration.Version] = true
+		if migration.SQL == "" {
+			return fmt.Errorf("migration %s has no sql", migration.Version)
+		}
+	}
+	return nil
+}
+
+// BatchSteps splits a batch request into one request per migration, in
+// version order. Each inherits the batch's role, author, ticket,
+// description and strict mode limits.
+func (r *ApplySchemaRequest) BatchSteps() []*ApplySchemaRequest {
+	migrations := slices.Clone(r.Migrations)
+	slices.SortFunc(migrations, func(a, b VersionedMigration) int {
+		return compareMigrationVersions(a.Version, b.Version)
+	})
+
+	steps := make([]*ApplySchemaRequest, 0, len(migrations))
+	for _, migration := range migrations {
+		steps = append(steps, &ApplySchemaRequest{
+			SQL:         migration.SQL,
+			Name:        migration.Name,
+			Version:     migration.Version,
+			Description: r.Description,
+			Author:      r.Author,
+			Ticket:      r.Ticket,
+			Role:        r.Role,
+			Strict:      r.Strict,
+		})
+	}
+	return steps
+}
+
+// compareMigrationVersions orders numeric versions of any length
+func compareMigrationVersions(a, b string) int {
+	if len(a) != len(b) {
+		return len(a) - len(b)
+	}
+	if a < b {
+		return -1
+	}
+	if a > b {
+		return 1
+	}
+	return 0
+}
+
+// migrationTableSQL creates the migration table of the Supabase CLI, so
+// versions applied by the manager and by supabase db push are tracked
+// together
+const migrationTableSQL = `
+CREATE SCHEMA IF NOT EXISTS supabase_migrations;
+CREATE TABLE IF NOT EXISTS supabase_migrations.schema_migrations (
+	version text NOT NULL PRIMARY KEY,
+	statements text[],
+	name text
+)`
+
+// EnsureMigrationTable creates the database's migration table if it does
+// not exist yet
+func (mr *MigrationRunner) EnsureMigrationTable() error {
+	for _, stmt := range splitSQLStatements(migrationTableSQL) {
+		if _, err := mr.db.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to create migration table: %w", err)
+		}
+	}
+	return nil
+}
+
+// AppliedVersions returns the versions recorded in the database's
+// migration table
+func (mr *MigrationRunner) AppliedVersions() (map[string]bool, error) {
+	versions, err := mr.snapshotMigrationVersions()
+	if err != nil {
+		return nil, err
+	}
+	applied := make(map[string]bool, len(versions))
+	for _, version := range versions {
+		applied[version] = true
+	}
+	return applied, nil
+}
+
+// SetVersion records the next migration of this runner in the migration
+// table under version and name, in the migration's transaction; an empty
+// version records nothing
+func (mr *MigrationRunner) SetVersion(version, name string) {
+	mr.version = version
+	mr.versionName = name
+}
+
+// recordVersion inserts the runner's version into the migration table as
+// the connecting user, since the migration role may not write to it
+func (mr *MigrationRunner) recordVersion(tx *sql.Tx, statements []string, role string) error {
+	if mr.version == "" {
+		return nil
+	}
+	if role != "" {
+		if _, err := tx.Exec("RESET ROLE"); err != nil {
+			return fmt.Errorf("failed to reset role: %w", err)
+		}
+	}
+	_, err := tx.Exec(
+		`INSERT INTO supabase_migrations.schema_migrations (version, statements, name) VALUES ($1, $2, $3)`,
+		mr.version, pq.Array(statements), mr.versionName,
+	)
+	if err != nil {
+		return fmt.Errorf("failed to record migration version %s: %w", mr.version, err)
+	}
+	return nil
+}
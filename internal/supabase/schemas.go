@@ -0,0 +1,289 @@
+package supabase
+
+import (
+	"sort"
+
+	"supabase-manager/internal/jsonschema"
+)
+
+// SchemaVersion is the version of the published request schemas. It is
+// bumped when a change would reject documents that used to be valid.
+const SchemaVersion = 1
+
+// Names of the published schemas, served at /api/schemas/<name>.json
+const (
+	SchemaCreateProject   = "create-project"
+	SchemaSupabaseProject = "supabase-project"
+)
+
+// regionPattern matches region names such as eu-central-1
+const regionPattern = `^[a-z]{2}-[a-z]+-[0-9]+$`
+
+// kubernetesNamePattern matches Kubernetes resource names (DNS subdomains)
+const kubernetesNamePattern = `^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+
+// schemas are the published schemas by name, compiled for validation
+var schemas = map[string]jsonschema.Schema{
+	SchemaCreateProject:   jsonschema.MustCompile(createProjectSchema()),
+	SchemaSupabaseProject: jsonschema.MustCompile(supabaseProjectSchema()),
+}
+
+// LookupSchema returns a published schema by name
+func LookupSchema(name string) (jsonschema.Schema, bool) {
+	schema, ok := schemas[name]
+	return schema, ok
+}
+
+// SchemaNames returns the names of the published schemas
+func SchemaNames() []string {
+	names := make([]string, 0, len(schemas))
+	for name := range schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// schemaHeader returns the keywords shared by the published schemas
+func schemaHeader(name, title, description string) jsonschema.Schema {
+	return jsonschema.Schema{
+		"$schema":     jsonschema.Draft,
+		"$id":         "/api/schemas/" + name + ".json",
+		"title":       title,
+		"description": description,
+		"version":     SchemaVersion,
+	}
+}
+
+// createProjectSchema describes CreateProjectRequest
+func createProjectSchema() jsonschema.Schema {
+	versions := make([]string, len(SupportedPostgresVersions))
+	for i, v := range SupportedPostgresVersions {
+		versions[i] = v.Version
+	}
+	profileNames := make([]string, 0, len(profiles))
+	for _, profile := range Profiles() {
+		profileNames = append(profileNames, profile.Name)
+	}
+
+	schema := schemaHeader(SchemaCreateProject, "Create project request",
+		"Body of POST /api/projects")
+	schema["type"] = "object"
+	schema["required"] = []string{"name"}
+	schema["additionalProperties"] = false
+	schema["properties"] = jsonschema.Schema{
+		"name": jsonschema.Schema{
+			"type":      "string",
+			"minLength": 1,
+			"maxLength": 256,
+		},
+		"region": jsonschema.Schema{
+			"type":        "string",
+			"pattern":     regionPattern,
+			"description": "Defaults to the manager's DEFAULT_REGION",
+			"examples":    SupportedRegions,
+		},
+		"postgres_version": jsonschema.Schema{
+			"type": "string",
+			"enum": versions,
+		},
+		"name_conflict": jsonschema.Schema{
+			"type": "string",
+			"enum": []string{NameConflictError, NameConflictSuffix, NameConflictTimestamp},
+		},
+		"check_remote": jsonschema.Schema{"type": "boolean"},
+		"labels": jsonschema.Schema{
+			"type":          "object",
+			"maxProperties": maxLabels,
+			"propertyNames": jsonschema.Schema{"pattern": labelKeyPattern.String()},
+			"additionalProperties": jsonschema.Schema{
+				"type":    "string",
+				"pattern": labelValuePattern.String(),
+			},
+		},
+		"with_default_buckets": jsonschema.Schema{"type": "boolean"},
+		"enable_extensions": jsonschema.Schema{
+			"type":  "array",
+			"items": jsonschema.Schema{"type": "string", "pattern": extensionPattern.String()},
+		},
+		"profile": jsonschema.Schema{
+			"type":        "string",
+			"pattern":     provisioningProfileNamePattern.String(),
+			"description": "A built-in profile or one configured on the manager; see GET /api/capabilities",
+			"examples":    profileNames,
+		},
+		"profile_options": jsonschema.Schema{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties": jsonschema.Schema{
+				"dimensions": jsonschema.Schema{
+					"type":    "integer",
+					"minimum": 1,
+					"maximum": maxEmbeddingDimensions,
+				},
+				"index": jsonschema.Schema{
+					"type": "string",
+					"enum": []string{IndexHNSW, IndexIVFFlat},
+				},
+				"log_drains": jsonschema.Schema{
+					"type":  "array",
+					"items": logDrainSchema(),
+				},
+			},
+		},
+		"template": templateSchema(),
+		"plan": jsonschema.Schema{
+			"type": "string",
+			"enum": Plans,
+		},
+		"compute_size": jsonschema.Schema{
+			"type": "string",
+			"enum": ComputeSizes,
+		},
+		"strict_region": jsonschema.Schema{
+			"type":        "boolean",
+			"description": "Fail when the region has no capacity instead of trying the manager's REGION_FALLBACKS",
+		},
+	}
+	return schema
+}
+
+// templateSchema describes a ProjectTemplate
+func templateSchema() jsonschema.Schema {
+	return jsonschema.Schema{
+		"type":                 "object",
+		"additionalProperties": false,
+		"description":          "Setup applied once the project is ready, reported at /api/projects/:id/setup",
+		"properties": jsonschema.Schema{
+			"extensions": jsonschema.Schema{
+				"type":  "array",
+				"items": jsonschema.Schema{"type": "string", "pattern": extensionPattern.String()},
+			},
+			"buckets": jsonschema.Schema{
+				"type": "array",
+				"items": jsonschema.Schema{
+					"type":                 "object",
+					"required":             []string{"name"},
+					"additionalProperties": false,
+					"properties": jsonschema.Schema{
+						"name":            jsonschema.Schema{"type": "string", "pattern": identifierPattern.String()},
+						"public":          jsonschema.Schema{"type": "boolean"},
+						"file_size_limit": jsonschema.Schema{"type": "integer", "minimum": 0},
+						"allowed_mime_types": jsonschema.Schema{
+							"type":  "array",
+							"items": jsonschema.Schema{"type": "string"},
+						},
+					},
+				},
+			},
+			"secrets": jsonschema.Schema{
+				"type": "array",
+				"items": jsonschema.Schema{
+					"type":                 "object",
+					"required":             []string{"name", "value"},
+					"additionalProperties": false,
+					"properties": jsonschema.Schema{
+						"name":  jsonschema.Schema{"type": "string", "pattern": secretNamePattern.String()},
+						"value": jsonschema.Schema{"type": "string", "minLength": 1},
+					},
+				},
+			},
+			"auth_providers": jsonschema.Schema{
+				"type": "array",
+				"items": jsonschema.Schema{
+					"type":                 "object",
+					"required":             []string{"provider", "client_id", "secret"},
+					"additionalProperties": false,
+					"properties": jsonschema.Schema{
+						"provider":  jsonschema.Schema{"type": "string", "enum": AuthProviderNames()},
+						"client_id": jsonschema.Schema{"type": "string", "minLength": 1},
+						"secret":    jsonschema.Schema{"type": "string", "minLength": 1},
+						"url":       jsonschema.Schema{"type": "string", "description": "https URL of a self-hosted azure, gitlab, keycloak or workos instance"},
+					},
+				},
+			},
+			"sql": jsonschema.Schema{"type": "string"},
+			"migrations": jsonschema.Schema{
+				"type":        "array",
+				"description": "Versioned migrations applied after sql, in version order",
+				"items": jsonschema.Schema{
+					"type":                 "object",
+					"required":             []string{"version", "sql"},
+					"additionalProperties": false,
+					"properties": jsonschema.Schema{
+						"version": jsonschema.Schema{"type": "string"},
+						"name":    jsonschema.Schema{"type": "string"},
+						"sql":     jsonschema.Schema{"type": "string", "minLength": 1},
+					},
+				},
+			},
+			"seed": jsonschema.Schema{"type": "string", "description": "Seed data applied once every migration has applied"},
+		},
+	}
+}
+
+// logDrainSchema describes a LogDrain in a request
+func logDrainSchema() jsonschema.Schema {
+	return jsonschema.Schema{
+		"type":                 "object",
+		"required":             []string{"name", "type"},
+		"additionalProperties": false,
+		"properties": jsonschema.Schema{
+			"name": jsonschema.Schema{"type": "string", "pattern": identifierPattern.String()},
+			"type": jsonschema.Schema{
+				"type": "string",
+				"enum": []string{LogDrainDatadog, LogDrainBigQuery, LogDrainWebhook},
+			},
+			"config": jsonschema.Schema{
+				"type":                 "object",
+				"additionalProperties": jsonschema.Schema{"type": "string"},
+			},
+		},
+	}
+}
+
+// supabaseProjectSchema describes a SupabaseProject resource, the
+// declarative manifest reconciled by the Kubernetes controller
+func supabaseProjectSchema() jsonschema.Schema {
+	schema := schemaHeader(SchemaSupabaseProject, "SupabaseProject resource",
+		"Manifest reconciled by the Kubernetes controller (deploy/supabaseproject-crd.yaml)")
+	schema["type"] = "object"
+	schema["required"] = []string{"apiVersion", "kind", "metadata"}
+	schema["properties"] = jsonschema.Schema{
+		"apiVersion": jsonschema.Schema{"type": "string", "enum": []string{"supabase-manager.io/v1alpha1"}},
+		"kind":       jsonschema.Schema{"type": "string", "enum": []string{"SupabaseProject"}},
+		"metadata": jsonschema.Schema{
+			"type":     "object",
+			"required": []string{"name"},
+			"properties": jsonschema.Schema{
+				"name":      jsonschema.Schema{"type": "string", "maxLength": 253, "pattern": kubernetesNamePattern},
+				"namespace": jsonschema.Schema{"type": "string", "maxLength": 63, "pattern": kubernetesNamePattern},
+			},
+		},
+		"spec": jsonschema.Schema{
+			"type":                 "object",
+			"additionalProperties": false,
+			"properties": jsonschema.Schema{
+				"name": jsonschema.Schema{
+					"type":        "string",
+					"minLength":   1,
+					"maxLength":   256,
+					"description": "Supabase project name, defaults to the resource name",
+				},
+				"region": jsonschema.Schema{
+					"type":        "string",
+					"pattern":     regionPattern,
+					"description": "Supabase region, defaults to the manager's DEFAULT_REGION",
+					"examples":    SupportedRegions,
+				},
+				"secretName": jsonschema.Schema{
+					"type":        "string",
+					"maxLength":   253,
+					"pattern":     kubernetesNamePattern,
+					"description": "Secret receiving URL and keys, defaults to <name>-supabase",
+				},
+			},
+		},
+	}
+	return schema
+}
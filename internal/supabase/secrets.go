@@ -0,0 +1,194 @@
+package supabase
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// How submitted SQL with embedded secrets is handled
+const (
+	SecretScanReject = "reject"
+	SecretScanWarn   = "warn"
+	SecretScanOff    = "off"
+)
+
+// LintEmbeddedSecret is the lint rule of secrets found in a migration
+const LintEmbeddedSecret = "embedded_secret"
+
+// minSecretEntropy is the Shannon entropy, in bits per character, above
+// which a value assigned to a secret-like name is taken for a real secret
+// rather than a placeholder
+const minSecretEntropy = 3.5
+
+// secretPattern is a kind of credential recognized by its format
+type secretPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+	// check, if set, must also accept the match
+	check func(match string) bool
+}
+
+// secretPatterns are the credentials scanned for, most specific first
+var secretPatterns = []secretPattern{
+	{kind: "private_key", pattern: regexp.MustCompile(`-----BEGIN (?:RSA |EC |DSA |OPENSSH |PGP |ENCRYPTED )?PRIVATE KEY(?: BLOCK)?-----`)},
+	{kind: "supabase_service_key", pattern: regexp.MustCompile(`eyJ[A-Za-z0-9_-]{10,}\.eyJ[A-Za-z0-9_-]{10,}\.[A-Za-z0-9_-]{10,}`), check: isServiceRoleJWT},
+	{kind: "supabase_secret_key", pattern: regexp.MustCompile(`\bsb_secret_[A-Za-z0-9_-]{20,}`)},
+	{kind: "supabase_access_token", pattern: regexp.MustCompile(`\bsbp_[a-f0-9]{40}\b`)},
+	{kind: "aws_access_key", pattern: regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`)},
+	{kind: "github_token", pattern: regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{60,})\b`)},
+	{kind: "stripe_secret_key", pattern: regexp.MustCompile(`\b[rs]k_live_[A-Za-z0-9]{24,}\b`)},
+	{kind: "slack_token", pattern: regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`)},
+	{kind: "google_api_key", pattern: regexp.MustCompile(`\bAIza[A-Za-z0-9_-]{35}\b`)},
+	{kind: "database_url_password", pattern: regexp.MustCompile(`\b(?:postgres(?:ql)?|mysql|mongodb(?:\+srv)?|redis)://[^:\s/'"@]+:([^@\s'"]+)@`), check: isRealPassword},
+}
+
+// secretAssignmentPattern matches a value assigned to a secret-like name,
+// as in 'api_key' => '...' or password = '...'
+var secretAssignmentPattern = regexp.MustCompile(`(?i)\b[\w-]*(?:password|passwd|secret|api[_-]?key|access[_-]?key|private[_-]?key|token)[\w-]*["']?\s*(?:=>|:=|=|:|,)\s*'([^'\s]{16,})'`)
+
+// SecretFinding is a credential found in submitted SQL. The credential
+// itself is never included.
+type SecretFinding struct {
+	Kind      string `json:"kind"`
+	Statement int    `json:"statement"`
+	Line      int    `json:"line"`
+	// Preview shows the first characters of the match and its length
+	Preview string `json:"preview"`
+}
+
+// ScanSecrets looks for credentials embedded in a SQL script: private keys,
+// service role JWTs, provider API keys and tokens, passwords in connection
+// URLs, and high-entropy values assigned to secret-like names. Findings are
+// ordered by position.
+func ScanSecrets(sqlScript string) []SecretFinding {
+	var findings []SecretFinding
+
+	offset := 0
+	for i, stmt := range splitSQLStatements(sqlScript) {
+		start := strings.Index(sqlScript[offset:], stmt)
+		if start >= 0 {
+			start += offset
+			offset = start + len(stmt)
+		} else {
+			start = offset
+		}
+		line := 1 + strings.Count(sqlScript[:start], "\n")
+
+		found := map[[2]int]bool{}
+		add := func(kind string, loc []int) {
+			for span := range found {
+				if loc[0] < span[1] && span[0] < loc[1] {
+					return
+				}
+			}
+			found[[2]int{loc[0], loc[1]}] = true
+			findings = append(findings, SecretFinding{
+				Kind:      kind,
+				Statement: i + 1,
+				Line:      line + strings.Count(stmt[:loc[0]], "\n"),
+				Preview:   previewSecret(stmt[loc[0]:loc[1]]),
+			})
+		}
+
+		for _, p := range secretPatterns {
+			for _, loc := range p.pattern.FindAllStringSubmatchIndex(stmt, -1) {
+				if len(loc) >= 4 && loc[2] >= 0 {
+					loc = loc[2:4]
+				}
+				if p.check == nil || p.check(stmt[loc[0]:loc[1]]) {
+					add(p.kind, loc[:2])
+				}
+			}
+		}
+
+		for _, loc := range secretAssignmentPattern.FindAllStringSubmatchIndex(stmt, -1) {
+			if value := stmt[loc[2]:loc[3]]; isRealPassword(value) && entropy(value) >= minSecretEntropy {
+				add("high_entropy_secret", loc[2:4])
+			}
+		}
+	}
+
+	sort.SliceStable(findings, func(i, j int) bool { return findings[i].Line < findings[j].Line })
+	return findings
+}
+
+// SecretWarnings reports secret findings as lint warnings
+func SecretWarnings(findings []SecretFinding) []LintWarning {
+	var warnings []LintWarning
+	for _, f := range findings {
+		warnings = append(warnings, LintWarning{
+			Rule:      LintEmbeddedSecret,
+			Message:   fmt.Sprintf("Possible %s on line %d (%s); secrets end up in the migration history, load them at runtime instead", strings.ReplaceAll(f.Kind, "_", " "), f.Line, f.Preview),
+			Statement: f.Statement,
+		})
+	}
+	return warnings
+}
+
+// ValidSecretScanMode reports whether mode is a secret scan mode
+func ValidSecretScanMode(mode string) bool {
+	return mode == SecretScanReject || mode == SecretScanWarn || mode == SecretScanOff
+}
+
+// isServiceRoleJWT reports whether a JWT carries the service_role role.
+// Anon keys are public by design and are not reported.
+func isServiceRoleJWT(token string) bool {
+	parts := strings.Split(token, ".")
+	payload, err := base64.RawURLEncoding.DecodeString(strings.TrimRight(parts[1], "="))
+	if err != nil {
+		return false
+	}
+	var claims struct {
+		Role string `json:"role"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return false
+	}
+	return claims.Role == "service_role" || claims.Role == "supabase_admin"
+}
+
+// placeholderPattern matches values that stand in for a secret
+var placeholderPattern = regexp.MustCompile(`(?i)^(?:\$\{.*\}|\$\w+|<.*>|\[.*\]|\{\{.*\}\}|:\w+|x+|\*+|\.+|(?:your|my|example|changeme|change-me|placeholder|password|secret|redacted)[\w-]*)$`)
+
+// isRealPassword reports whether a value is more than a placeholder or
+// password hash
+func isRealPassword(value string) bool {
+	if placeholderPattern.MatchString(value) {
+		return false
+	}
+	// bcrypt and crypt(3) hashes are expected in seeded auth users
+	if strings.HasPrefix(value, "$2") || strings.HasPrefix(value, "$argon2") {
+		return false
+	}
+	return true
+}
+
+// entropy returns the Shannon entropy of s in bits per character
+func entropy(s string) float64 {
+	counts := map[rune]int{}
+	for _, r := range s {
+		counts[r]++
+	}
+	total := float64(len([]rune(s)))
+	var bits float64
+	for _, n := range counts {
+		p := float64(n) / total
+		bits -= p * math.Log2(p)
+	}
+	return bits
+}
+
+// previewSecret shows the first characters of a secret and its length
+func previewSecret(secret string) string {
+	runes := []rune(secret)
+	shown := 4
+	if len(runes) <= 8 {
+		shown = 1
+	}
+	return fmt.Sprintf("%s… (%d characters)", string(runes[:shown]), len(runes))
+}
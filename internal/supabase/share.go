@@ -0,0 +1,70 @@
+package supabase
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"time"
+)
+
+// Share link lifetimes
+const (
+	DefaultShareLinkTTL = 24 * time.Hour
+	MaxShareLinkTTL     = 7 * 24 * time.Hour
+)
+
+// ShareLink is a one-time, expiring token that lets someone without an API
+// key fetch a project's URL and anon key. Only the token's hash is stored.
+type ShareLink struct {
+	ID        string     `json:"id"`
+	ProjectID string     `json:"project_id"`
+	TokenHash string     `json:"-"`
+	CreatedBy string     `json:"created_by"`
+	Note      string     `json:"note,omitempty"`
+	ExpiresAt time.Time  `json:"expires_at"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `json:"created_at"`
+}
+
+// CreateShareLinkRequest represents the request to mint a share link
+type CreateShareLinkRequest struct {
+	// ExpiresIn is a Go duration such as "2h"; it defaults to 24h and may
+	// not exceed 7 days
+	ExpiresIn string `json:"expires_in,omitempty"`
+	// Note records who the link is for, for the audit log
+	Note string `json:"note,omitempty"`
+}
+
+// TTL parses and checks the requested lifetime
+func (r *CreateShareLinkRequest) TTL() (time.Duration, error) {
+	if r.ExpiresIn == "" {
+		return DefaultShareLinkTTL, nil
+	}
+
+	ttl, err := time.ParseDuration(r.ExpiresIn)
+	if err != nil {
+		return 0, fmt.Errorf("invalid expires_in: %w", err)
+	}
+	if ttl <= 0 || ttl > MaxShareLinkTTL {
+		return 0, fmt.Errorf("expires_in must be between 0 and %s", MaxShareLinkTTL)
+	}
+	return ttl, nil
+}
+
+// NewShareToken returns a random token and the hash to store for it
+func NewShareToken() (token, hash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", fmt.Errorf("failed to generate token: %w", err)
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, HashShareToken(token), nil
+}
+
+// HashShareToken returns the stored form of a share token
+func HashShareToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
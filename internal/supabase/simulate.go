@@ -0,0 +1,218 @@
+package supabase
+
+import (
+	"database/sql"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// simulationLockTimeout bounds how long a simulated statement waits for a
+// lock, so a simulation never queues behind the project's own traffic
+const simulationLockTimeout = "5s"
+
+// publicQualifiedPattern matches an explicit reference to the public schema,
+// which the rewritten search_path does not redirect
+var publicQualifiedPattern = regexp.MustCompile(`(?i)(\bpublic|"public")\s*\.`)
+
+// SimulatedStatement is the outcome of one statement of a simulation
+type SimulatedStatement struct {
+	Position  int    `json:"position"`
+	Statement string `json:"statement"`
+	Duration  string `json:"duration"`
+	Error     string `json:"error,omitempty"`
+}
+
+// SimulationResult reports a migration run against a scratch schema
+type SimulationResult struct {
+	Success bool `json:"success"`
+	// Schema is the scratch schema the migration ran in
+	Schema string `json:"schema"`
+	// ClonedTables is how many public tables were copied, without their
+	// rows, into the scratch schema before the migration ran
+	ClonedTables  int                  `json:"cloned_tables"`
+	StatementsRun int                  `json:"statements_run"`
+	Statements    []SimulatedStatement `json:"statements"`
+	// Created lists the objects the migration left in the scratch schema
+	Created       []string `json:"created,omitempty"`
+	Warnings      []string `json:"warnings,omitempty"`
+	ExecutionTime string   `json:"execution_time"`
+	Error         string   `json:"error,omitempty"`
+}
+
+// SimulateMigration runs a migration against a scratch schema holding empty
+// copies of the public tables, with search_path rewritten to resolve to the
+// copies first, and reports how each statement fared. Everything runs in
+// one transaction that is always rolled back, which drops the scratch
+// schema with whatever the migration created in it; nothing is recorded in
+// the migration table.
+func (mr *MigrationRunner) SimulateMigration(sqlScript, role string) (*SimulationResult, error) {
+	startTime := time.Now()
+	result := &SimulationResult{Statements: []SimulatedStatement{}}
+
+	if err := validateSQL(sqlScript); err != nil {
+		result.Error = fmt.Sprintf("SQL validation failed: %v", err)
+		return result, err
+	}
+	if role != "" {
+		if err := validateRoleSQL(sqlScript, role); err != nil {
+			result.Error = fmt.Sprintf("SQL validation failed: %v", err)
+			return result, err
+		}
+	}
+
+	suffix, err := randomHex(6)
+	if err != nil {
+		return nil, fmt.Errorf("failed to name the scratch schema: %w", err)
+	}
+	result.Schema = "sim_" + suffix
+	scratch := quoteIdentifier(result.Schema)
+
+	tx, err := mr.db.Begin()
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	setRole := role
+	if setRole == "" {
+		setRole = mr.defaultRole
+	}
+
+	if _, err := tx.Exec("SET LOCAL lock_timeout = '" + simulationLockTimeout + "'"); err != nil {
+		return nil, fmt.Errorf("failed to set lock timeout: %w", err)
+	}
+	if _, err := tx.Exec("CREATE SCHEMA " + scratch); err != nil {
+		return nil, fmt.Errorf("failed to create scratch schema: %w", err)
+	}
+	cloned, err := cloneTables(tx, scratch, setRole)
+	if err != nil {
+		return nil, err
+	}
+	result.ClonedTables = cloned
+
+	if setRole != "" {
+		if _, err := tx.Exec("ALTER SCHEMA " + scratch + " OWNER TO " + quoteIdentifier(setRole)); err != nil {
+			return nil, fmt.Errorf("failed to hand the scratch schema to %s: %w", setRole, err)
+		}
+	}
+	if _, err := tx.Exec("SET LOCAL search_path = " + scratch + ", public"); err != nil {
+		return nil, fmt.Errorf("failed to set search_path: %w", err)
+	}
+	if setRole != "" {
+		if _, err := tx.Exec("SET LOCAL ROLE " + quoteIdentifier(setRole)); err != nil {
+			return nil, fmt.Errorf("failed to set role %s: %w", setRole, err)
+		}
+	}
+
+	statements := splitSQLStatements(sqlScript)
+	result.Success = true
+	for i, stmt := range statements {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+		if publicQualifiedPattern.MatchString(stmt) {
+			result.Warnings = append(result.Warnings, fmt.Sprintf(
+				"statement %d names the public schema, so it ran against public itself before being rolled back", i+1))
+		}
+
+		statementStart := time.Now()
+		_, err := tx.Exec(stmt)
+		outcome := SimulatedStatement{
+			Position:  i + 1,
+			Statement: stmt[:min(len(stmt), 100)],
+			Duration:  time.Since(statementStart).String(),
+		}
+		result.StatementsRun++
+		if err != nil {
+			// The transaction is aborted, so the statements after this
+			// one cannot be simulated
+			outcome.Error = err.Error()
+			result.Statements = append(result.Statements, outcome)
+			result.Success = false
+			result.Error = fmt.Sprintf("statement %d failed: %v", i+1, err)
+			break
+		}
+		result.Statements = append(result.Statements, outcome)
+	}
+
+	if result.Success {
+		if result.Created, err = scratchObjects(tx, result.Schema); err != nil {
+			result.Warnings = append(result.Warnings, fmt.Sprintf("could not list the objects created: %v", err))
+		}
+	}
+
+	result.ExecutionTime = time.Since(startTime).String()
+	return result, nil
+}
+
+// cloneTables copies the definition of every public table into the scratch
+// schema, owned by role when it is set so the migration may alter them
+func cloneTables(tx *sql.Tx, scratch, role string) (int, error) {
+	rows, err := tx.Query(`SELECT c.relname FROM pg_class c
+		JOIN pg_namespace n ON n.oid = c.relnamespace
+		WHERE n.nspname = 'public' AND c.relkind IN ('r', 'p')
+		ORDER BY c.relname`)
+	if err != nil {
+		return 0, fmt.Errorf("failed to list public tables: %w", err)
+	}
+	var tables []string
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			rows.Close()
+			return 0, fmt.Errorf("failed to list public tables: %w", err)
+		}
+		tables = append(tables, name)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("failed to list public tables: %w", err)
+	}
+
+	for _, name := range tables {
+		table := scratch + "." + quoteIdentifier(name)
+		if _, err := tx.Exec("CREATE TABLE " + table + " (LIKE public." + quoteIdentifier(name) + " INCLUDING ALL)"); err != nil {
+			return 0, fmt.Errorf("failed to copy table %s: %w", name, err)
+		}
+		if role != "" {
+			if _, err := tx.Exec("ALTER TABLE " + table + " OWNER TO " + quoteIdentifier(role)); err != nil {
+				return 0, fmt.Errorf("failed to hand table %s to %s: %w", name, role, err)
+			}
+		}
+	}
+	return len(tables), nil
+}
+
+// scratchObjects lists the relations and functions in the scratch schema
+// other than the copies of public tables, as "kind name"
+func scratchObjects(tx *sql.Tx, schema string) ([]string, error) {
+	rows, err := tx.Query(`SELECT kind, name FROM (
+			SELECT CASE c.relkind WHEN 'r' THEN 'table' WHEN 'p' THEN 'table' WHEN 'v' THEN 'view'
+				WHEN 'm' THEN 'materialized view' WHEN 'i' THEN 'index' WHEN 'S' THEN 'sequence'
+				ELSE 'relation' END AS kind, c.relname AS name
+			FROM pg_class c JOIN pg_namespace n ON n.oid = c.relnamespace
+			WHERE n.nspname = $1
+				AND NOT EXISTS (SELECT 1 FROM pg_class p JOIN pg_namespace pn ON pn.oid = p.relnamespace
+					WHERE pn.nspname = 'public' AND p.relname = c.relname AND p.relkind = c.relkind)
+			UNION ALL
+			SELECT 'function', p.proname FROM pg_proc p JOIN pg_namespace n ON n.oid = p.pronamespace
+			WHERE n.nspname = $1
+		) objects ORDER BY kind, name`, schema)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var created []string
+	for rows.Next() {
+		var kind, name string
+		if err := rows.Scan(&kind, &name); err != nil {
+			return nil, err
+		}
+		created = append(created, kind+" "+name)
+	}
+	return created, rows.Err()
+}
@@ -0,0 +1,160 @@
+package supabase
+
+import (
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ProvisionOutcome is a finished provision: how long it ran and whether the
+// project became healthy
+type ProvisionOutcome struct {
+	Region    string
+	StartedAt time.Time
+	Duration  time.Duration
+	Succeeded bool
+}
+
+// ProvisioningSLO holds the targets provisioning is measured against
+type ProvisioningSLO struct {
+	// SuccessTarget is the percentage of provisions that must succeed,
+	// such as 99
+	SuccessTarget float64
+	// TimeToHealthyTarget bounds the p95 time successful provisions take
+	// to become healthy
+	TimeToHealthyTarget time.Duration
+	// Window is the period compliance is measured over
+	Window time.Duration
+	// BurnWindow is the recent period the error budget burn rate is
+	// measured over, and BurnRateAlert the rate that raises an alert; a
+	// rate of 1 spends the budget exactly over the window
+	BurnWindow    time.Duration
+	BurnRateAlert float64
+}
+
+// sloBurnMinProvisions is how many provisions the burn window needs before
+// its burn rate can raise an alert, so a single failure does not
+const sloBurnMinProvisions = 5
+
+// Validate checks that the targets can be measured
+func (s *ProvisioningSLO) Validate() error {
+	if s.SuccessTarget <= 0 || s.SuccessTarget >= 100 {
+		return fmt.Errorf("success target must be between 0 and 100 percent, exclusive")
+	}
+	if s.TimeToHealthyTarget <= 0 {
+		return fmt.Errorf("time to healthy target must be positive")
+	}
+	if s.Window <= 0 || s.BurnWindow <= 0 || s.BurnWindow > s.Window {
+		return fmt.Errorf("the window must be positive and the burn window within it")
+	}
+	if s.BurnRateAlert < 0 {
+		return fmt.Errorf("burn rate alert must not be negative")
+	}
+	return nil
+}
+
+// SuccessSLOReport is the compliance of the provisioning success rate
+type SuccessSLOReport struct {
+	TargetPercent float64 `json:"target_percent"`
+	ActualPercent float64 `json:"actual_percent"`
+	Compliant     bool    `json:"compliant"`
+	// AllowedFailures is the error budget of the window in provisions
+	AllowedFailures float64 `json:"allowed_failures"`
+	// BudgetRemainingPercent is the share of the error budget not yet
+	// spent; it goes negative once the budget is exhausted
+	BudgetRemainingPercent float64  `json:"budget_remaining_percent"`
+	Burn                   BurnRate `json:"burn"`
+}
+
+// BurnRate is how fast the error budget was spent over the burn window
+type BurnRate struct {
+	WindowSeconds  float64 `json:"window_seconds"`
+	Provisions     int     `json:"provisions"`
+	Failed         int     `json:"failed"`
+	Rate           float64 `json:"rate"`
+	AlertThreshold float64 `json:"alert_threshold"`
+	Burning        bool    `json:"burning"`
+}
+
+// LatencySLOReport is the compliance of the p95 time to healthy
+type LatencySLOReport struct {
+	TargetSeconds float64 `json:"target_seconds"`
+	P95Seconds    float64 `json:"p95_seconds"`
+	Compliant     bool    `json:"compliant"`
+	// Slow counts successful provisions slower than the target
+	Slow int `json:"slow"`
+}
+
+// ProvisioningSLOReport is the compliance of provisioning with its SLO over
+// the window
+type ProvisioningSLOReport struct {
+	WindowSeconds float64          `json:"window_seconds"`
+	Provisions    int              `json:"provisions"`
+	Succeeded     int              `json:"succeeded"`
+	Failed        int              `json:"failed"`
+	SuccessRate   SuccessSLOReport `json:"success_rate"`
+	TimeToHealthy LatencySLOReport `json:"time_to_healthy"`
+}
+
+// Report measures the outcomes of the window ending at now against the
+// targets. With no provisions, both objectives are compliant and the budget
+// is untouched.
+func (s *ProvisioningSLO) Report(outcomes []ProvisionOutcome, now time.Time) *ProvisioningSLOReport {
+	report := &ProvisioningSLOReport{WindowSeconds: s.Window.Seconds()}
+	burnFrom := now.Add(-s.BurnWindow)
+	burn := BurnRate{WindowSeconds: s.BurnWindow.Seconds(), AlertThreshold: s.BurnRateAlert}
+
+	var durations []time.Duration
+	for _, outcome := range outcomes {
+		if outcome.StartedAt.Before(now.Add(-s.Window)) {
+			continue
+		}
+		report.Provisions++
+		if outcome.Succeeded {
+			report.Succeeded++
+			durations = append(durations, outcome.Duration)
+			if outcome.Duration > s.TimeToHealthyTarget {
+				report.TimeToHealthy.Slow++
+			}
+		} else {
+			report.Failed++
+		}
+		if !outcome.StartedAt.Before(burnFrom) {
+			burn.Provisions++
+			if !outcome.Succeeded {
+				burn.Failed++
+			}
+		}
+	}
+
+	allowedRate := 1 - s.SuccessTarget/100
+	success := &report.SuccessRate
+	success.TargetPercent = s.SuccessTarget
+	success.ActualPercent = 100
+	success.BudgetRemainingPercent = 100
+	success.AllowedFailures = allowedRate * float64(report.Provisions)
+	if report.Provisions > 0 {
+		success.ActualPercent = float64(report.Succeeded) * 100 / float64(report.Provisions)
+		success.BudgetRemainingPercent = (1 - float64(report.Failed)/success.AllowedFailures) * 100
+	}
+	success.Compliant = success.ActualPercent >= s.SuccessTarget
+
+	if burn.Provisions > 0 {
+		burn.Rate = float64(burn.Failed) / float64(burn.Provisions) / allowedRate
+	}
+	burn.Burning = s.BurnRateAlert > 0 && burn.Provisions >= sloBurnMinProvisions && burn.Rate >= s.BurnRateAlert
+	success.Burn = burn
+
+	latency := &report.TimeToHealthy
+	latency.TargetSeconds = s.TimeToHealthyTarget.Seconds()
+	latency.Compliant = true
+	if len(durations) > 0 {
+		sort.Slice(durations, func(i, j int) bool { return durations[i] < durations[j] })
+		rank := (95*len(durations) + 99) / 100
+		p95 := durations[rank-1]
+		latency.P95Seconds = p95.Seconds()
+		latency.Compliant = p95 <= s.TimeToHealthyTarget
+	}
+
+	return report
+}
@@ -0,0 +1,38 @@
+package supabase
+
+import (
+	"strings"
+	"time"
+)
+
+// RenameProjectRequest renames a project
+type RenameProjectRequest struct {
+	Name string `json:"name" binding:"required"`
+}
+
+// ProjectName is a name a project went by before it was renamed
+type ProjectName struct {
+	Name       string    `json:"name"`
+	Slug       string    `json:"slug"`
+	ReplacedAt time.Time `json:"replaced_at"`
+}
+
+// Slugify returns the URL-friendly form of a project name: lowercase
+// letters and digits, with every other run of characters turned into a
+// single '-'. "My App (Staging)" becomes "my-app-staging".
+func Slugify(name string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+		} else {
+			dash = true
+		}
+	}
+	return b.String()
+}
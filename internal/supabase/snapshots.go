@@ -0,0 +1,232 @@
+package supabase
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SnapshotReasonPreDelete marks snapshots taken before a project was
+// deleted in Supabase
+const SnapshotReasonPreDelete = "pre_delete"
+
+// Snapshot is a pg_dump of a project's schemas and data, kept after the
+// project is gone so it can be restored to a new project
+type Snapshot struct {
+	ID              string   `json:"id"`
+	ProjectID       string   `json:"project_id"`
+	ProjectRef      string   `json:"project_ref"`
+	ProjectName     string   `json:"project_name"`
+	Region          string   `json:"region"`
+	PostgresVersion string   `json:"postgres_version,omitempty"`
+	Schemas         []string `json:"schemas"`
+	Reason          string   `json:"reason"`
+	// Path is the gzipped dump on the manager's disk
+	Path      string    `json:"-"`
+	SizeBytes int64     `json:"size_bytes"`
+	SHA256    string    `json:"sha256"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// RestoreSnapshotRequest restores a snapshot into a new project
+type RestoreSnapshotRequest struct {
+	Name string `json:"name" binding:"required"`
+	// Region defaults to the region of the snapshotted project
+	Region string            `json:"region,omitempty"`
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// SnapshotPolicy selects the projects snapshotted before they are deleted
+// in Supabase: none, all, or those whose labels match a selector such as
+// env=production
+type SnapshotPolicy struct {
+	Always   bool
+	Selector *LabelSelector
+}
+
+// ParseSnapshotPolicy parses "off" (or empty), "always" or a label
+// selector
+func ParseSnapshotPolicy(policy string) (SnapshotPolicy, error) {
+	switch strings.TrimSpace(policy) {
+	case "", "off":
+		return SnapshotPolicy{}, nil
+	case "always":
+		return SnapshotPolicy{Always: true}, nil
+	}
+	selector, err := ParseLabelSelector(policy)
+	if err != nil {
+		return SnapshotPolicy{}, err
+	}
+	return SnapshotPolicy{Selector: selector}, nil
+}
+
+// Requires reports whether a project with the given labels is snapshotted
+// before it is deleted
+func (p SnapshotPolicy) Requires(labels map[string]string) bool {
+	return p.Always || (p.Selector != nil && p.Selector.Matches(labels))
+}
+
+// String describes the policy as it is configured
+func (p SnapshotPolicy) String() string {
+	switch {
+	case p.Always:
+		return "always"
+	case p.Selector != nil:
+		return p.Selector.String()
+	}
+	return "off"
+}
+
+// SnapshotTool takes snapshots with pg_dump and restores them with psql.
+// Both must be at least as new as the projects' Postgres.
+type SnapshotTool struct {
+	// Dir holds the gzipped dumps
+	Dir string
+	// PgDump and Psql are the paths of the binaries
+	PgDump string
+	Psql   string
+	// Schemas are the schemas dumped; Supabase-managed schemas such as
+	// auth and storage are recreated by every new project
+	Schemas []string
+}
+
+// Dump writes a gzipped plain SQL dump of the target's schemas to the
+// snapshot's path, filling in its size and checksum
+func (t *SnapshotTool) Dump(ctx context.Context, target DatabaseTarget, snapshot *Snapshot) error {
+	env, err := pgEnv(target.GetDatabaseConnectionString())
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(t.Dir, 0o700); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	snapshot.Path = filepath.Join(t.Dir, snapshot.ID+".sql.gz")
+	snapshot.Schemas = t.Schemas
+	file, err := os.OpenFile(snapshot.Path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create snapshot file: %w", err)
+	}
+
+	hash := sha256.New()
+	gz := gzip.NewWriter(io.MultiWriter(file, hash))
+	args := []string{"--format=plain", "--no-owner", "--no-privileges"}
+	for _, schema := range t.Schemas {
+		args = append(args, "--schema="+schema)
+	}
+	dumpErr := runPgTool(ctx, t.PgDump, args, env, nil, gz)
+	if err := gz.Close(); dumpErr == nil {
+		dumpErr = err
+	}
+	if err := file.Close(); dumpErr == nil {
+		dumpErr = err
+	}
+	if dumpErr != nil {
+		os.Remove(snapshot.Path)
+		return fmt.Errorf("pg_dump failed: %w", dumpErr)
+	}
+
+	info, err := os.Stat(snapshot.Path)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	snapshot.SizeBytes = info.Size()
+	snapshot.SHA256 = hex.EncodeToString(hash.Sum(nil))
+	return nil
+}
+
+// Restore loads a snapshot into the target in a single transaction, after
+// checking the dump against the snapshot's checksum
+func (t *SnapshotTool) Restore(ctx context.Context, target DatabaseTarget, snapshot *Snapshot) error {
+	env, err := pgEnv(target.GetDatabaseConnectionString())
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Open(snapshot.Path)
+	if err != nil {
+		return fmt.Errorf("failed to open snapshot file: %w", err)
+	}
+	defer file.Close()
+
+	hash := sha256.New()
+	if _, err := io.Copy(hash, file); err != nil {
+		return fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	if hex.EncodeToString(hash.Sum(nil)) != snapshot.SHA256 {
+		return fmt.Errorf("snapshot file does not match its checksum")
+	}
+	if _, err := file.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+	gz, err := gzip.NewReader(file)
+	if err != nil {
+		return fmt.Errorf("failed to read snapshot file: %w", err)
+	}
+
+	args := []string{"--no-psqlrc", "--quiet", "--single-transaction", "--set=ON_ERROR_STOP=1"}
+	if err := runPgTool(ctx, t.Psql, args, env, gz, io.Discard); err != nil {
+		return fmt.Errorf("psql failed: %w", err)
+	}
+	return nil
+}
+
+// runPgTool runs a Postgres client binary, reporting the end of its stderr
+// on failure
+func runPgTool(ctx context.Context, path string, args, env []string, stdin io.Reader, stdout io.Writer) error {
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Env = env
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		message := strings.TrimSpace(stderr.String())
+		if message == "" {
+			return err
+		}
+		if len(message) > 2000 {
+			message = "..." + message[len(message)-2000:]
+		}
+		return fmt.Errorf("%v: %s", err, message)
+	}
+	return nil
+}
+
+// pgEnv turns a postgresql:// connection string into libpq environment
+// variables, so the password never shows up in the process list
+func pgEnv(connStr string) ([]string, error) {
+	if connStr == "" {
+		return nil, fmt.Errorf("no database connection string available")
+	}
+	u, err := url.Parse(connStr)
+	if err != nil || (u.Scheme != "postgres" && u.Scheme != "postgresql") {
+		return nil, fmt.Errorf("unsupported database connection string")
+	}
+
+	env := append(os.Environ(), "PGHOST="+u.Hostname(), "PGDATABASE="+strings.TrimPrefix(u.Path, "/"), "PGCONNECT_TIMEOUT=10")
+	if port := u.Port(); port != "" {
+		env = append(env, "PGPORT="+port)
+	}
+	if u.User != nil {
+		env = append(env, "PGUSER="+u.User.Username())
+		if password, ok := u.User.Password(); ok {
+			env = append(env, "PGPASSWORD="+password)
+		}
+	}
+	if sslmode := u.Query().Get("sslmode"); sslmode != "" {
+		env = append(env, "PGSSLMODE="+sslmode)
+	}
+	return env, nil
+}
@@ -0,0 +1,84 @@
+package supabase
+
+import "fmt"
+
+// Soft quotas of QuotaWarning.Quota
+const (
+	QuotaProjectsPerKey       = "projects_per_key"
+	QuotaOrganizationCapacity = "organization_capacity"
+)
+
+// SoftQuotas are usage thresholds that warn, rather than reject, when a
+// create reaches them, as a nudge before the organization's hard limits
+// start rejecting creates
+type SoftQuotas struct {
+	// ProjectsPerKey is the number of projects an API key may create
+	// before it is warned; 0 disables the quota
+	ProjectsPerKey int
+	// OrganizationCapacity is the number of projects an organization
+	// holds; 0 disables the quota
+	OrganizationCapacity int
+	// OrganizationPercent is the share of the capacity, in percent, at
+	// which creates are warned
+	OrganizationPercent int
+}
+
+// Validate checks the quotas
+func (q *SoftQuotas) Validate() error {
+	if q.ProjectsPerKey < 0 || q.OrganizationCapacity < 0 {
+		return fmt.Errorf("quotas must not be negative")
+	}
+	if q.OrganizationPercent <= 0 || q.OrganizationPercent > 100 {
+		return fmt.Errorf("organization percent must be between 1 and 100")
+	}
+	return nil
+}
+
+// Enabled reports whether any quota is set
+func (q *SoftQuotas) Enabled() bool {
+	return q.ProjectsPerKey > 0 || q.OrganizationCapacity > 0
+}
+
+// organizationThreshold is the number of projects at which the
+// organization quota warns
+func (q *SoftQuotas) organizationThreshold() int {
+	return (q.OrganizationCapacity*q.OrganizationPercent + 99) / 100
+}
+
+// QuotaWarning reports a soft quota a create reached or went past
+type QuotaWarning struct {
+	Quota   string `json:"quota"`
+	Message string `json:"message"`
+	// Usage is the number of projects counted after the create, and
+	// Threshold the number at which the quota warns
+	Usage     int `json:"usage"`
+	Threshold int `json:"threshold"`
+	// Crossed is set on the create that first reached the threshold
+	Crossed bool `json:"crossed"`
+}
+
+// Check returns the warnings for usage after a create: the projects of the
+// calling key and of its organization, either -1 when not counted
+func (q *SoftQuotas) Check(keyProjects, orgProjects int) []QuotaWarning {
+	var warnings []QuotaWarning
+	if q.ProjectsPerKey > 0 && keyProjects >= q.ProjectsPerKey {
+		warnings = append(warnings, QuotaWarning{
+			Quota:     QuotaProjectsPerKey,
+			Message:   fmt.Sprintf("this API key has created %d projects; the soft quota is %d", keyProjects, q.ProjectsPerKey),
+			Usage:     keyProjects,
+			Threshold: q.ProjectsPerKey,
+			Crossed:   keyProjects == q.ProjectsPerKey,
+		})
+	}
+	if threshold := q.organizationThreshold(); q.OrganizationCapacity > 0 && orgProjects >= threshold {
+		warnings = append(warnings, QuotaWarning{
+			Quota: QuotaOrganizationCapacity,
+			Message: fmt.Sprintf("the organization holds %d of %d projects (%d%%); warnings start at %d%%",
+				orgProjects, q.OrganizationCapacity, orgProjects*100/q.OrganizationCapacity, q.OrganizationPercent),
+			Usage:     orgProjects,
+			Threshold: threshold,
+			Crossed:   orgProjects == threshold,
+		})
+	}
+	return warnings
+}
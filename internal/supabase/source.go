@@ -0,0 +1,336 @@
+package supabase
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schema source errors
+var (
+	ErrSourceNotAllowed     = errors.New("source URL is not allowed")
+	ErrSourceTooLarge       = errors.New("source is too large")
+	ErrSourceChecksumFailed = errors.New("source checksum does not match")
+)
+
+const (
+	sourceFetchTimeout = 30 * time.Second
+	sourceGitTimeout   = 2 * time.Minute
+	sourceMaxRedirects = 5
+)
+
+// gitRefPattern matches the ref and path of a git source. A leading '-'
+// is rejected so neither can be read as an option.
+var gitRefPattern = regexp.MustCompile(`^[A-Za-z0-9._][A-Za-z0-9._/-]*$`)
+
+// SchemaFetcher fetches migration SQL from allowed hosts, over https or
+// from a git repository
+type SchemaFetcher struct {
+	allowedHosts map[string]bool
+	maxBytes     int64
+	httpClient   *http.Client
+}
+
+// FetchedSchema is SQL fetched from a source
+type FetchedSchema struct {
+	SQL string
+	// Source is the URL the SQL came from
+	Source string
+	// SHA256 is the hex checksum of the SQL
+	SHA256 string
+}
+
+// NewSchemaFetcher creates a fetcher for the given hosts, refusing sources
+// larger than maxBytes
+func NewSchemaFetcher(allowedHosts []string, maxBytes int64) *SchemaFetcher {
+	f := &SchemaFetcher{
+		allowedHosts: make(map[string]bool),
+		maxBytes:     maxBytes,
+	}
+	for _, host := range allowedHosts {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			f.allowedHosts[host] = true
+		}
+	}
+	f.httpClient = &http.Client{
+		Timeout: sourceFetchTimeout,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= sourceMaxRedirects {
+				return fmt.Errorf("too many redirects")
+			}
+			return f.checkURL(req.URL)
+		},
+	}
+	return f
+}
+
+// AllowedHosts returns the hosts SQL may be fetched from, sorted
+func (f *SchemaFetcher) AllowedHosts() []string {
+	hosts := make([]string, 0, len(f.allowedHosts))
+	for host := range f.allowedHosts {
+		hosts = append(hosts, host)
+	}
+	sort.Strings(hosts)
+	return hosts
+}
+
+// MaxBytes returns the size limit of fetched SQL
+func (f *SchemaFetcher) MaxBytes() int64 {
+	return f.maxBytes
+}
+
+// Fetch retrieves the SQL at rawURL, either an https URL or
+// git+https://host/repo.git#<ref>:<path>. The token, if any, authenticates
+// the fetch. A non-empty checksum must match the SHA-256 of the content.
+func (f *SchemaFetcher) Fetch(ctx context.Context, rawURL, token, checksum string) (*FetchedSchema, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSourceNotAllowed, err)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: credentials belong in source_token, not the URL", ErrSourceNotAllowed)
+	}
+
+	var content []byte
+	switch u.Scheme {
+	case "https":
+		if err := f.checkURL(u); err != nil {
+			return nil, err
+		}
+		content, err = f.fetchHTTPS(ctx, u, token)
+	case "git+https":
+		content, err = f.fetchGit(ctx, u, token)
+	default:
+		return nil, fmt.Errorf("%w: scheme must be https or git+https", ErrSourceNotAllowed)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha256.Sum256(content)
+	digest := hex.EncodeToString(sum[:])
+	if checksum != "" && !strings.EqualFold(checksum, digest) {
+		return nil, fmt.Errorf("%w: expected %s, got %s", ErrSourceChecksumFailed, strings.ToLower(checksum), digest)
+	}
+
+	return &FetchedSchema{SQL: string(content), Source: u.String(), SHA256: digest}, nil
+}
+
+// checkURL checks that an https URL points at an allowed host
+func (f *SchemaFetcher) checkURL(u *url.URL) error {
+	if u.Scheme != "https" {
+		return fmt.Errorf("%w: %s is not https", ErrSourceNotAllowed, u.Redacted())
+	}
+	if !f.allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("%w: host %s is not in SCHEMA_SOURCE_ALLOWED_HOSTS", ErrSourceNotAllowed, u.Hostname())
+	}
+	return nil
+}
+
+// fetchHTTPS downloads a file
+func (f *SchemaFetcher) fetchHTTPS(ctx context.Context, u *url.URL, token string) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", u.String(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create request: %w", err)
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := f.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch %s: %w", u.Redacted(), err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to fetch %s: status %d", u.Redacted(), resp.StatusCode)
+	}
+	if resp.ContentLength > f.maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (at most %d)", ErrSourceTooLarge, resp.ContentLength, f.maxBytes)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, f.maxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", u.Redacted(), err)
+	}
+	if int64(len(content)) > f.maxBytes {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrSourceTooLarge, f.maxBytes)
+	}
+	return content, nil
+}
+
+// fetchGit reads one file at a ref of a git repository, fetching only that
+// commit and, lazily, the blobs it needs
+func (f *SchemaFetcher) fetchGit(ctx context.Context, u *url.URL, token string) ([]byte, error) {
+	ref, path, ok := strings.Cut(u.Fragment, ":")
+	if !ok || !gitRefPattern.MatchString(ref) || !gitRefPattern.MatchString(path) {
+		return nil, fmt.Errorf("%w: git sources look like git+https://host/repo.git#<ref>:<path>", ErrSourceNotAllowed)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, sourceGitTimeout)
+	defer cancel()
+
+	git, cleanup, err := f.fetchCommit(ctx, u, ref, token)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	return f.readGitBlob(git, path, ref)
+}
+
+// FetchDirectory reads the .sql files of a directory at a ref of a git
+// repository, git+https://host/repo.git#<ref>:<dir>, ordered by file name.
+// Their combined size is limited like a single source.
+func (f *SchemaFetcher) FetchDirectory(ctx context.Context, rawURL, token string) ([]FetchedSchema, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrSourceNotAllowed, err)
+	}
+	if u.User != nil {
+		return nil, fmt.Errorf("%w: credentials belong in the token, not the URL", ErrSourceNotAllowed)
+	}
+	ref, dir, ok := strings.Cut(u.Fragment, ":")
+	if u.Scheme != "git+https" || !ok || !gitRefPattern.MatchString(ref) || !gitRefPattern.MatchString(dir) {
+		return nil, fmt.Errorf("%w: directories look like git+https://host/repo.git#<ref>:<dir>", ErrSourceNotAllowed)
+	}
+	dir = strings.TrimSuffix(dir, "/")
+
+	ctx, cancel := context.WithTimeout(ctx, sourceGitTimeout)
+	defer cancel()
+
+	git, cleanup, err := f.fetchCommit(ctx, u, ref, token)
+	if err != nil {
+		return nil, err
+	}
+	defer cleanup()
+
+	listing, err := git("ls-tree", "-z", "FETCH_HEAD:"+dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s at %s: %w", dir, ref, err)
+	}
+	var names []string
+	for _, entry := range strings.Split(string(listing), "\x00") {
+		// Entries are "<mode> <type> <object>\t<name>"
+		info, name, ok := strings.Cut(entry, "\t")
+		if ok && strings.Contains(info, " blob ") && strings.HasSuffix(name, ".sql") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var files []FetchedSchema
+	var total int64
+	for _, name := range names {
+		content, err := f.readGitBlob(git, dir+"/"+name, ref)
+		if err != nil {
+			return nil, err
+		}
+		if total += int64(len(content)); total > f.maxBytes {
+			return nil, fmt.Errorf("%w: more than %d bytes in %s", ErrSourceTooLarge, f.maxBytes, dir)
+		}
+
+		sum := sha256.Sum256(content)
+		file := *u
+		file.Fragment = ref + ":" + dir + "/" + name
+		files = append(files, FetchedSchema{SQL: string(content), Source: file.String(), SHA256: hex.EncodeToString(sum[:])})
+	}
+	return files, nil
+}
+
+// gitRunner runs a git command in a work directory
+type gitRunner func(args ...string) ([]byte, error)
+
+// fetchCommit fetches only the commit at ref of an allowed repository,
+// with its blobs loaded lazily, into a temporary work directory. The
+// cleanup function removes the directory.
+func (f *SchemaFetcher) fetchCommit(ctx context.Context, u *url.URL, ref, token string) (gitRunner, func(), error) {
+	repo := *u
+	repo.Scheme = "https"
+	repo.Fragment = ""
+	if err := f.checkURL(&repo); err != nil {
+		return nil, nil, err
+	}
+
+	dir, err := os.MkdirTemp("", "schema-source-")
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create work directory: %w", err)
+	}
+	cleanup := func() { os.RemoveAll(dir) }
+
+	// Configuration is passed through the environment so the token never
+	// shows up in the process list
+	config := [][2]string{
+		{"protocol.allow", "never"},
+		{"protocol.https.allow", "always"},
+		{"http.followRedirects", "false"},
+	}
+	if token != "" {
+		credentials := base64.StdEncoding.EncodeToString([]byte("x-access-token:" + token))
+		config = append(config, [2]string{"http.extraHeader", "Authorization: Basic " + credentials})
+	}
+	env := append(os.Environ(), "GIT_TERMINAL_PROMPT=0", "GIT_CONFIG_NOSYSTEM=1", "GIT_CONFIG_COUNT="+strconv.Itoa(len(config)))
+	for i, kv := range config {
+		env = append(env, fmt.Sprintf("GIT_CONFIG_KEY_%d=%s", i, kv[0]), fmt.Sprintf("GIT_CONFIG_VALUE_%d=%s", i, kv[1]))
+	}
+
+	git := func(args ...string) ([]byte, error) {
+		cmd := exec.CommandContext(ctx, "git", append([]string{"-C", dir}, args...)...)
+		cmd.Env = env
+		var stderr bytes.Buffer
+		cmd.Stderr = &stderr
+		out, err := cmd.Output()
+		if err != nil {
+			return nil, fmt.Errorf("git %s: %v: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+		return out, nil
+	}
+
+	if _, err := git("init", "-q"); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to fetch %s: %w", repo.String(), err)
+	}
+	if _, err := git("fetch", "-q", "--depth=1", "--filter=blob:none", "--no-tags", repo.String(), ref); err != nil {
+		cleanup()
+		return nil, nil, fmt.Errorf("failed to fetch %s at %s: %w", repo.String(), ref, err)
+	}
+	return git, cleanup, nil
+}
+
+// readGitBlob reads a file of the fetched commit, refusing files larger
+// than the size limit
+func (f *SchemaFetcher) readGitBlob(git gitRunner, path, ref string) ([]byte, error) {
+	object := "FETCH_HEAD:" + path
+	sizeOut, err := git("cat-file", "-s", object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find %s at %s: %w", path, ref, err)
+	}
+	size, err := strconv.ParseInt(strings.TrimSpace(string(sizeOut)), 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read size of %s: %w", path, err)
+	}
+	if size > f.maxBytes {
+		return nil, fmt.Errorf("%w: %d bytes (at most %d)", ErrSourceTooLarge, size, f.maxBytes)
+	}
+
+	content, err := git("cat-file", "blob", object)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s at %s: %w", path, ref, err)
+	}
+	return content, nil
+}
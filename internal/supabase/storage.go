@@ -0,0 +1,279 @@
+package supabase
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// identifierPattern matches identifiers that are safe to use unquoted in
+// generated SQL (policy names, role names, bucket IDs)
+var identifierPattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_\-]{0,62}$`)
+
+// policyOperations lists the commands a storage policy can apply to
+var policyOperations = map[string]bool{
+	"select": true,
+	"insert": true,
+	"update": true,
+	"delete": true,
+	"all":    true,
+}
+
+// Bucket represents a Supabase Storage bucket
+type Bucket struct {
+	ID               string   `json:"id"`
+	Name             string   `json:"name"`
+	Public           bool     `json:"public"`
+	FileSizeLimit    *int64   `json:"file_size_limit,omitempty"`
+	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"`
+	CreatedAt        string   `json:"created_at,omitempty"`
+	UpdatedAt        string   `json:"updated_at,omitempty"`
+}
+
+// BucketRequest represents the request to create or update a bucket
+type BucketRequest struct {
+	Name             string   `json:"name"`
+	Public           bool     `json:"public"`
+	FileSizeLimit    *int64   `json:"file_size_limit,omitempty"`
+	AllowedMimeTypes []string `json:"allowed_mime_types,omitempty"`
+}
+
+// BucketPolicyRequest represents a storage RLS policy scoped to one bucket
+type BucketPolicyRequest struct {
+	Name       string   `json:"name" binding:"required"`
+	Operations []string `json:"operations" binding:"required"`
+	Roles      []string `json:"roles,omitempty"`
+	Using      string   `json:"using,omitempty"`
+	WithCheck  string   `json:"with_check,omitempty"`
+}
+
+// StoragePolicy describes an existing policy on storage.objects
+type StoragePolicy struct {
+	Name      string   `json:"name"`
+	Command   string   `json:"command"`
+	Roles     []string `json:"roles"`
+	Using     string   `json:"using,omitempty"`
+	WithCheck string   `json:"with_check,omitempty"`
+}
+
+// storageRequest calls the project's Storage API with its service key
+func (c *Client) storageRequest(project *Project, method, path string, payload interface{}, out interface{}) error {
+	if project.ServiceKey == "" {
+		return fmt.Errorf("project has no service key")
+	}
+
+	var body io.Reader
+	if payload != nil {
+		data, err := json.Marshal(payload)
+		if err != nil {
+			return fmt.Errorf("failed to marshal request: %w", err)
+		}
+		body = bytes.NewBuffer(data)
+	}
+
+	req, err := http.NewRequest(method, project.GetProjectURL()+"/storage/v1"+path, body)
+	if err != nil {
+		return fmt.Errorf("failed to create request: %w", err)
+	}
+
+	req.Header.Set("Authorization", "Bearer "+project.ServiceKey)
+	req.Header.Set("apikey", project.ServiceKey)
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to make request: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("bucket not found")
+	}
+
+	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusCreated {
+		bodyBytes, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("storage API error (status %d): %s", resp.StatusCode, string(bodyBytes))
+	}
+
+	if out != nil {
+		if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+			return fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// ListBuckets returns the storage buckets of a project
+func (c *Client) ListBuckets(project *Project) ([]Bucket, error) {
+	var buckets []Bucket
+	if err := c.storageRequest(project, "GET", "/bucket", nil, &buckets); err != nil {
+		return nil, err
+	}
+	return buckets, nil
+}
+
+// CreateBucket creates a storage bucket in a project
+func (c *Client) CreateBucket(project *Project, bucket BucketRequest) error {
+	if !identifierPattern.MatchString(bucket.Name) {
+		return fmt.Errorf("invalid bucket name: %q", bucket.Name)
+	}
+
+	payload := map[string]interface{}{
+		"id":     bucket.Name,
+		"name":   bucket.Name,
+		"public": bucket.Public,
+	}
+	if bucket.FileSizeLimit != nil {
+		payload["file_size_limit"] = *bucket.FileSizeLimit
+	}
+	if len(bucket.AllowedMimeTypes) > 0 {
+		payload["allowed_mime_types"] = bucket.AllowedMimeTypes
+	}
+
+	return c.storageRequest(project, "POST", "/bucket", payload, nil)
+}
+
+// UpdateBucket updates visibility and upload restrictions of a bucket
+func (c *Client) UpdateBucket(project *Project, bucketID string, bucket BucketRequest) error {
+	payload := map[string]interface{}{
+		"id":                 bucketID,
+		"public":             bucket.Public,
+		"file_size_limit":    bucket.FileSizeLimit,
+		"allowed_mime_types": bucket.AllowedMimeTypes,
+	}
+
+	return c.storageRequest(project, "PUT", "/bucket/"+bucketID, payload, nil)
+}
+
+// DeleteBucket deletes an empty storage bucket
+func (c *Client) DeleteBucket(project *Project, bucketID string) error {
+	return c.storageRequest(project, "DELETE", "/bucket/"+bucketID, nil, nil)
+}
+
+// BuildBucketPolicySQL generates CREATE POLICY statements on storage.objects
+// restricted to a single bucket, one per requested operation
+func BuildBucketPolicySQL(bucketID string, req BucketPolicyRequest) (string, error) {
+	if !identifierPattern.MatchString(bucketID) {
+		return "", fmt.Errorf("invalid bucket id: %q", bucketID)
+	}
+	if !identifierPattern.MatchString(req.Name) {
+		return "", fmt.Errorf("invalid policy name: %q", req.Name)
+	}
+
+	roles := req.Roles
+	if len(roles) == 0 {
+		roles = []string{"authenticated"}
+	}
+	for _, role := range roles {
+		if !identifierPattern.MatchString(role) {
+			return "", fmt.Errorf("invalid role: %q", role)
+		}
+	}
+
+	bucketCondition := fmt.Sprintf("bucket_id = %s", quoteLiteral(bucketID))
+
+	var statements []string
+	for _, op := range req.Operations {
+		op = strings.ToLower(op)
+		if !policyOperations[op] {
+			return "", fmt.Errorf("invalid operation: %q", op)
+		}
+
+		stmt := fmt.Sprintf("CREATE POLICY %s ON storage.objects FOR %s TO %s",
+			quoteIdentifier(policyName(bucketID, req.Name, op)),
+			strings.ToUpper(op),
+			strings.Join(roles, ", "),
+		)
+
+		// INSERT policies only accept WITH CHECK
+		if op != "insert" {
+			stmt += fmt.Sprintf(" USING (%s)", combineCondition(bucketCondition, req.Using))
+		}
+		if op == "insert" || op == "update" || op == "all" {
+			check := req.WithCheck
+			if check == "" {
+				check = req.Using
+			}
+			stmt += fmt.Sprintf(" WITH CHECK (%s)", combineCondition(bucketCondition, check))
+		}
+
+		statements = append(statements, stmt+";")
+	}
+
+	return strings.Join(statements, "\n"), nil
+}
+
+// BuildDropBucketPolicySQL generates DROP POLICY statements for every
+// operation of a named bucket policy
+func BuildDropBucketPolicySQL(bucketID, name string) (string, error) {
+	if !identifierPattern.MatchString(bucketID) || !identifierPattern.MatchString(name) {
+		return "", fmt.Errorf("invalid bucket or policy name")
+	}
+
+	var statements []string
+	for op := range policyOperations {
+		statements = append(statements, fmt.Sprintf("DROP POLICY IF EXISTS %s ON storage.objects;",
+			quoteIdentifier(policyName(bucketID, name, op))))
+	}
+
+	return strings.Join(statements, "\n"), nil
+}
+
+// ListStoragePolicies returns the policies on storage.objects that mention a bucket
+func (mr *MigrationRunner) ListStoragePolicies(bucketID string) ([]StoragePolicy, error) {
+	query := `
+		SELECT policyname, cmd, array_to_string(roles, ','),
+		       COALESCE(qual, ''), COALESCE(with_check, '')
+		FROM pg_policies
+		WHERE schemaname = 'storage' AND tablename = 'objects'
+		AND (qual LIKE '%' || $1 || '%' OR with_check LIKE '%' || $1 || '%')
+		ORDER BY policyname
+	`
+
+	rows, err := mr.db.Query(query, quoteLiteral(bucketID))
+	if err != nil {
+		return nil, fmt.Errorf("failed to query policies: %w", err)
+	}
+	defer rows.Close()
+
+	policies := []StoragePolicy{}
+	for rows.Next() {
+		var policy StoragePolicy
+		var roles string
+		if err := rows.Scan(&policy.Name, &policy.Command, &roles, &policy.Using, &policy.WithCheck); err != nil {
+			return nil, fmt.Errorf("failed to scan policy: %w", err)
+		}
+		policy.Roles = strings.Split(roles, ",")
+		policies = append(policies, policy)
+	}
+
+	return policies, rows.Err()
+}
+
+// policyName derives the Postgres policy name for a bucket policy operation
+func policyName(bucketID, name, op string) string {
+	return fmt.Sprintf("%s_%s_%s", bucketID, name, op)
+}
+
+// combineCondition ANDs an optional user condition onto the bucket condition
+func combineCondition(bucketCondition, condition string) string {
+	if strings.TrimSpace(condition) == "" {
+		return bucketCondition
+	}
+	return fmt.Sprintf("%s AND (%s)", bucketCondition, condition)
+}
+
+// quoteIdentifier quotes a SQL identifier
+func quoteIdentifier(name string) string {
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// quoteLiteral quotes a SQL string literal
+func quoteLiteral(value string) string {
+	return "'" + strings.ReplaceAll(value, "'", "''") + "'"
+}
@@ -0,0 +1,123 @@
+package supabase
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrStrictLimitExceeded is returned when a statement of a migration run
+// in strict mode is estimated to exceed its limits, and the migration is
+// rolled back before the statement runs
+var ErrStrictLimitExceeded = errors.New("strict mode limit exceeded")
+
+// StrictLimits turn on strict mode for a migration: each data-modifying
+// statement is EXPLAINed before it runs, and the migration is rolled back
+// if the planner's estimate exceeds a limit. A zero limit is not checked.
+type StrictLimits struct {
+	// MaxRows bounds the rows any node of the statement's plan is
+	// estimated to produce
+	MaxRows int64 `json:"max_rows,omitempty"`
+	// MaxCost bounds the plan's estimated total cost
+	MaxCost float64 `json:"max_cost,omitempty"`
+}
+
+// Validate checks that the limits are not negative
+func (l *StrictLimits) Validate() error {
+	if l.MaxRows < 0 || l.MaxCost < 0 {
+		return fmt.Errorf("strict limits must not be negative")
+	}
+	return nil
+}
+
+// WithDefaults returns the limits with unset ones taken from defaults
+func (l StrictLimits) WithDefaults(defaults StrictLimits) StrictLimits {
+	if l.MaxRows == 0 {
+		l.MaxRows = defaults.MaxRows
+	}
+	if l.MaxCost == 0 {
+		l.MaxCost = defaults.MaxCost
+	}
+	return l
+}
+
+// StatementEstimate is the planner's estimate for a statement of a
+// migration run in strict mode
+type StatementEstimate struct {
+	// Statement is the 1-based position of the statement in the migration
+	Statement int     `json:"statement"`
+	Rows      int64   `json:"rows"`
+	Cost      float64 `json:"cost"`
+	Exceeded  bool    `json:"exceeded,omitempty"`
+}
+
+// strictCommands are the leading keywords of the statements checked in
+// strict mode. WITH is included since its queries can modify data.
+var strictCommands = map[string]bool{
+	"INSERT": true,
+	"UPDATE": true,
+	"DELETE": true,
+	"MERGE":  true,
+	"WITH":   true,
+}
+
+// isStrictStatement reports whether a statement is checked in strict mode
+func isStrictStatement(stmt string) bool {
+	words := strings.Fields(strings.ToUpper(stripLeadingComments(strings.TrimSpace(stmt))))
+	return len(words) > 0 && strictCommands[words[0]]
+}
+
+// explainPlan is the part of a plan node read from EXPLAIN (FORMAT JSON)
+type explainPlan struct {
+	TotalCost float64       `json:"Total Cost"`
+	PlanRows  float64       `json:"Plan Rows"`
+	Plans     []explainPlan `json:"Plans"`
+}
+
+// maxRows returns the largest row estimate in the plan tree. The top node
+// of an UPDATE or DELETE reports no rows, the scan below it does.
+func (p *explainPlan) maxRows() float64 {
+	rows := p.PlanRows
+	for i := range p.Plans {
+		if child := p.Plans[i].maxRows(); child > rows {
+			rows = child
+		}
+	}
+	return rows
+}
+
+// estimateStatement EXPLAINs a statement in the migration's transaction,
+// so it sees the effect of the statements before it
+func estimateStatement(tx *sql.Tx, position int, stmt string) (*StatementEstimate, error) {
+	var output string
+	if err := tx.QueryRow("EXPLAIN (FORMAT JSON) " + stmt).Scan(&output); err != nil {
+		return nil, fmt.Errorf("failed to explain statement %d: %w", position, err)
+	}
+
+	var plans []struct {
+		Plan explainPlan `json:"Plan"`
+	}
+	if err := json.Unmarshal([]byte(output), &plans); err != nil || len(plans) == 0 {
+		return nil, fmt.Errorf("failed to read the plan of statement %d: %v", position, err)
+	}
+
+	return &StatementEstimate{
+		Statement: position,
+		Rows:      int64(plans[0].Plan.maxRows()),
+		Cost:      plans[0].Plan.TotalCost,
+	}, nil
+}
+
+// check reports how an estimate exceeds the limits, or "" if it does not
+func (l *StrictLimits) check(estimate *StatementEstimate) string {
+	var exceeded []string
+	if l.MaxRows > 0 && estimate.Rows > l.MaxRows {
+		exceeded = append(exceeded, fmt.Sprintf("estimated %d rows, the limit is %d", estimate.Rows, l.MaxRows))
+	}
+	if l.MaxCost > 0 && estimate.Cost > l.MaxCost {
+		exceeded = append(exceeded, fmt.Sprintf("estimated cost %.0f, the limit is %.0f", estimate.Cost, l.MaxCost))
+	}
+	return strings.Join(exceeded, ", ")
+}
@@ -0,0 +1,81 @@
+package supabase
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"time"
+)
+
+// Key types a provisioned app reports using
+const (
+	KeyTypeAnon        = "anon"
+	KeyTypeServiceRole = "service_role"
+)
+
+const (
+	// maxTelemetryClockSkew is how far in the future a usage report may be
+	// timestamped
+	maxTelemetryClockSkew = 5 * time.Minute
+	// MaxTelemetryAge is how old a usage report may be, so a replayed or
+	// buffered report can't make a long idle project look active
+	MaxTelemetryAge = 24 * time.Hour
+)
+
+// KeyUsageReport is a provisioned app reporting that it used one of its
+// project's API keys
+type KeyUsageReport struct {
+	ProjectRef string `json:"project_ref" binding:"required"`
+	KeyType    string `json:"key_type" binding:"required"`
+	// Timestamp is when the key was used, now when not set
+	Timestamp *time.Time `json:"timestamp,omitempty"`
+}
+
+// Validate checks a usage report and fills in its timestamp
+func (r *KeyUsageReport) Validate(now time.Time) error {
+	if r.KeyType != KeyTypeAnon && r.KeyType != KeyTypeServiceRole {
+		return fmt.Errorf("key_type must be %s or %s", KeyTypeAnon, KeyTypeServiceRole)
+	}
+	if r.Timestamp == nil {
+		r.Timestamp = &now
+	}
+	if r.Timestamp.After(now.Add(maxTelemetryClockSkew)) {
+		return fmt.Errorf("timestamp is in the future")
+	}
+	if r.Timestamp.Before(now.Add(-MaxTelemetryAge)) {
+		return fmt.Errorf("timestamp is more than %s old", MaxTelemetryAge)
+	}
+	return nil
+}
+
+// KeyUsage aggregates the usage reports of one key type of a project
+type KeyUsage struct {
+	KeyType     string    `json:"key_type"`
+	Reports     int64     `json:"reports"`
+	FirstUsedAt time.Time `json:"first_used_at"`
+	LastUsedAt  time.Time `json:"last_used_at"`
+}
+
+// LastKeyUse returns when any key of a set of usages was last used, or nil
+// if none was
+func LastKeyUse(usages []KeyUsage) *time.Time {
+	var last *time.Time
+	for i := range usages {
+		if last == nil || usages[i].LastUsedAt.After(*last) {
+			last = &usages[i].LastUsedAt
+		}
+	}
+	return last
+}
+
+// MatchesKey reports whether key is the project's key of keyType, in
+// constant time
+func (p *StoredProject) MatchesKey(keyType, key string) bool {
+	var expected string
+	switch keyType {
+	case KeyTypeAnon:
+		expected = p.AnonKey
+	case KeyTypeServiceRole:
+		expected = p.ServiceKey
+	}
+	return expected != "" && subtle.ConstantTimeCompare([]byte(expected), []byte(key)) == 1
+}
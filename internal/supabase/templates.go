@@ -0,0 +1,232 @@
+package supabase
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// secretNamePattern matches Edge Function secret names
+var secretNamePattern = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]{0,255}$`)
+
+// reservedSecretPrefix starts the names of the secrets Supabase sets itself
+const reservedSecretPrefix = "SUPABASE_"
+
+// authProviders are the external OAuth providers a template can enable,
+// and whether each takes the URL of a self-hosted instance
+var authProviders = map[string]bool{
+	"apple":         false,
+	"azure":         true,
+	"bitbucket":     false,
+	"discord":       false,
+	"facebook":      false,
+	"figma":         false,
+	"github":        false,
+	"gitlab":        true,
+	"google":        false,
+	"kakao":         false,
+	"keycloak":      true,
+	"linkedin_oidc": false,
+	"notion":        false,
+	"slack_oidc":    false,
+	"spotify":       false,
+	"twitch":        false,
+	"twitter":       false,
+	"workos":        true,
+	"zoom":          false,
+}
+
+// ProjectTemplate declares the setup of a new project in one document:
+// Postgres extensions, storage buckets, Edge Function secrets, OAuth
+// providers, SQL, versioned migrations and seed data. The provisioning
+// pipeline applies them once the project is ready and reports the outcome
+// of each component.
+type ProjectTemplate struct {
+	Extensions    []string        `json:"extensions,omitempty"`
+	Buckets       []BucketRequest `json:"buckets,omitempty"`
+	Secrets       []ProjectSecret `json:"secrets,omitempty"`
+	AuthProviders []AuthProvider  `json:"auth_providers,omitempty"`
+	// SQL runs after the extensions and any profile SQL
+	SQL string `json:"sql,omitempty"`
+	// Migrations run after the SQL in version order, each recorded under
+	// its version, stopping at the first that fails
+	Migrations []VersionedMigration `json:"migrations,omitempty"`
+	// Seed runs last, once every migration has applied
+	Seed string `json:"seed,omitempty"`
+}
+
+// ProjectSecret is an Edge Function secret. Its value is redacted when it
+// is encoded as JSON, so a template can be returned or logged safely.
+type ProjectSecret struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// projectSecretBody is a secret as sent to the Management API
+type projectSecretBody struct {
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+// MarshalJSON encodes the secret with its value redacted
+func (s ProjectSecret) MarshalJSON() ([]byte, error) {
+	return json.Marshal(projectSecretBody{Name: s.Name, Value: "[redacted]"})
+}
+
+// AuthProvider enables sign-in with an external OAuth provider. The client
+// secret is redacted when it is encoded as JSON.
+type AuthProvider struct {
+	Provider string `json:"provider"`
+	ClientID string `json:"client_id"`
+	Secret   string `json:"secret"`
+	// URL is the address of a self-hosted instance, for azure, gitlab,
+	// keycloak and workos
+	URL string `json:"url,omitempty"`
+}
+
+// authProviderBody is an AuthProvider as encoded with its secret
+type authProviderBody struct {
+	Provider string `json:"provider"`
+	ClientID string `json:"client_id"`
+	Secret   string `json:"secret"`
+	URL      string `json:"url,omitempty"`
+}
+
+// MarshalJSON encodes the provider with its client secret redacted
+func (p AuthProvider) MarshalJSON() ([]byte, error) {
+	body := authProviderBody(p)
+	body.Secret = "[redacted]"
+	return json.Marshal(body)
+}
+
+// AuthProviderNames returns the providers a template can enable, sorted
+func AuthProviderNames() []string {
+	names := make([]string, 0, len(authProviders))
+	for name := range authProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// IsEmpty reports whether the template declares nothing
+func (t *ProjectTemplate) IsEmpty() bool {
+	return t == nil || (len(t.Extensions) == 0 && len(t.Buckets) == 0 && len(t.Secrets) == 0 &&
+		len(t.AuthProviders) == 0 && strings.TrimSpace(t.SQL) == "" && len(t.Migrations) == 0 &&
+		strings.TrimSpace(t.Seed) == "")
+}
+
+// Validate checks every component of a template, so a template that can't
+// be applied is rejected before the project is created
+func (t *ProjectTemplate) Validate() error {
+	if err := ValidateExtensions(t.Extensions); err != nil {
+		return fmt.Errorf("extensions: %w", err)
+	}
+
+	buckets := map[string]bool{}
+	for _, bucket := range t.Buckets {
+		if !identifierPattern.MatchString(bucket.Name) {
+			return fmt.Errorf("buckets: invalid bucket name: %q", bucket.Name)
+		}
+		if buckets[bucket.Name] {
+			return fmt.Errorf("buckets: duplicate bucket %q", bucket.Name)
+		}
+		buckets[bucket.Name] = true
+	}
+
+	secrets := map[string]bool{}
+	for _, secret := range t.Secrets {
+		if !secretNamePattern.MatchString(secret.Name) {
+			return fmt.Errorf("secrets: invalid secret name: %q", secret.Name)
+		}
+		if strings.HasPrefix(strings.ToUpper(secret.Name), reservedSecretPrefix) {
+			return fmt.Errorf("secrets: names starting with %s are reserved: %q", reservedSecretPrefix, secret.Name)
+		}
+		if secret.Value == "" {
+			return fmt.Errorf("secrets: %s has no value", secret.Name)
+		}
+		if secrets[secret.Name] {
+			return fmt.Errorf("secrets: duplicate secret %q", secret.Name)
+		}
+		secrets[secret.Name] = true
+	}
+
+	providers := map[string]bool{}
+	for _, provider := range t.AuthProviders {
+		takesURL, ok := authProviders[provider.Provider]
+		if !ok {
+			return fmt.Errorf("auth_providers: unknown provider %q (expected one of %s)", provider.Provider, strings.Join(AuthProviderNames(), ", "))
+		}
+		if provider.ClientID == "" || provider.Secret == "" {
+			return fmt.Errorf("auth_providers: %s needs client_id and secret", provider.Provider)
+		}
+		if provider.URL != "" {
+			if !takesURL {
+				return fmt.Errorf("auth_providers: %s does not take a url", provider.Provider)
+			}
+			u, err := url.Parse(provider.URL)
+			if err != nil || u.Scheme != "https" || u.Host == "" {
+				return fmt.Errorf("auth_providers: url of %s must be an https URL", provider.Provider)
+			}
+		}
+		if providers[provider.Provider] {
+			return fmt.Errorf("auth_providers: duplicate provider %q", provider.Provider)
+		}
+		providers[provider.Provider] = true
+	}
+
+	if len(t.Migrations) > 0 {
+		batch := ApplySchemaRequest{Migrations: t.Migrations}
+		if err := batch.ValidateBatch(); err != nil {
+			return fmt.Errorf("migrations: %w", err)
+		}
+	}
+	return nil
+}
+
+// SQLScripts returns every SQL script of the template: its SQL, its
+// migrations and its seed
+func (t *ProjectTemplate) SQLScripts() []string {
+	scripts := []string{t.SQL}
+	for _, migration := range t.Migrations {
+		scripts = append(scripts, migration.SQL)
+	}
+	return append(scripts, t.Seed)
+}
+
+// SecretNames returns the names of a set of secrets
+func SecretNames(secrets []ProjectSecret) []string {
+	names := make([]string, len(secrets))
+	for i, secret := range secrets {
+		names[i] = secret.Name
+	}
+	return names
+}
+
+// CreateProjectSecrets creates or replaces a project's Edge Function
+// secrets
+func (c *Client) CreateProjectSecrets(projectRef string, secrets []ProjectSecret) error {
+	bodies := make([]projectSecretBody, len(secrets))
+	for i, secret := range secrets {
+		bodies[i] = projectSecretBody(secret)
+	}
+	return c.managementRequest("POST", "/projects/"+projectRef+"/secrets", bodies, nil)
+}
+
+// EnableAuthProvider turns on sign-in with an external OAuth provider in a
+// project's auth config
+func (c *Client) EnableAuthProvider(projectRef string, provider AuthProvider) error {
+	prefix := "external_" + provider.Provider + "_"
+	config := map[string]interface{}{
+		prefix + "enabled":   true,
+		prefix + "client_id": provider.ClientID,
+		prefix + "secret":    provider.Secret,
+	}
+	if provider.URL != "" {
+		config[prefix+"url"] = provider.URL
+	}
+	return c.managementRequest("PATCH", "/projects/"+projectRef+"/config/auth", config, nil)
+}
@@ -0,0 +1,112 @@
+package supabase
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// tenantNamePattern matches tenant names. Names become part of the schema
+// and role identifiers, so they are kept lowercase and short.
+var tenantNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{0,39}$`)
+
+// searchPathPattern matches statements that change the search_path
+var searchPathPattern = regexp.MustCompile(`(?i)\b(SET\s+(SESSION\s+|LOCAL\s+)?SEARCH_PATH|SET_CONFIG\s*\(\s*'search_path')`)
+
+// Tenant is an isolated schema inside a project, owned by a dedicated role
+type Tenant struct {
+	ID        string    `json:"id"`
+	ProjectID string    `json:"project_id"`
+	Name      string    `json:"name"`
+	Schema    string    `json:"schema"`
+	Role      string    `json:"role"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateTenantRequest represents the request to provision a tenant schema
+type CreateTenantRequest struct {
+	Name string `json:"name" binding:"required"`
+	// TemplateSQL creates the tenant's tables. It runs with the search_path
+	// set to the tenant schema, so unqualified names land there.
+	TemplateSQL string `json:"template_sql,omitempty"`
+}
+
+// NewTenant validates a tenant name and derives its schema and role
+func NewTenant(projectID, name string) (*Tenant, error) {
+	if !tenantNamePattern.MatchString(name) {
+		return nil, fmt.Errorf("invalid tenant name: %q (lowercase letters, digits and underscores, starting with a letter)", name)
+	}
+
+	return &Tenant{
+		ProjectID: projectID,
+		Name:      name,
+		Schema:    "tenant_" + name,
+		Role:      "tenant_" + name,
+	}, nil
+}
+
+// BuildTenantSQL generates the SQL that creates the tenant schema and role,
+// grants the role access to the schema, points the role's search_path at it
+// and creates the template tables inside it
+func BuildTenantSQL(tenant *Tenant, templateSQL string) (string, error) {
+	if match := roleChangePattern.FindString(templateSQL); match != "" {
+		return "", fmt.Errorf("changing the role is not allowed in tenant templates: %s", match)
+	}
+	if match := searchPathPattern.FindString(templateSQL); match != "" {
+		return "", fmt.Errorf("changing the search_path is not allowed in tenant templates: %s", match)
+	}
+
+	schema := quoteIdentifier(tenant.Schema)
+	role := quoteIdentifier(tenant.Role)
+
+	statements := []string{
+		fmt.Sprintf("CREATE SCHEMA %s;", schema),
+		fmt.Sprintf("CREATE ROLE %s NOLOGIN;", role),
+		// Lets the connecting user run migrations as the tenant role
+		fmt.Sprintf("GRANT %s TO CURRENT_USER;", role),
+		fmt.Sprintf("GRANT USAGE, CREATE ON SCHEMA %s TO %s;", schema, role),
+		fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT ALL ON TABLES TO %s;", schema, role),
+		fmt.Sprintf("ALTER DEFAULT PRIVILEGES IN SCHEMA %s GRANT ALL ON SEQUENCES TO %s;", schema, role),
+		fmt.Sprintf("ALTER ROLE %s SET search_path = %s;", role, schema),
+	}
+
+	if strings.TrimSpace(templateSQL) != "" {
+		statements = append(statements,
+			fmt.Sprintf("SET LOCAL search_path = %s;", schema),
+			strings.TrimSpace(templateSQL),
+		)
+	}
+
+	return strings.Join(statements, "\n"), nil
+}
+
+// DropTenant removes a tenant's schema, everything in it and its role
+func (mr *MigrationRunner) DropTenant(tenant *Tenant) error {
+	if !tenantNamePattern.MatchString(tenant.Name) {
+		return fmt.Errorf("invalid tenant name: %q", tenant.Name)
+	}
+
+	tx, err := mr.db.Begin()
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	statements := []string{
+		fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE", quoteIdentifier(tenant.Schema)),
+		fmt.Sprintf("DROP OWNED BY %s", quoteIdentifier(tenant.Role)),
+		fmt.Sprintf("DROP ROLE IF EXISTS %s", quoteIdentifier(tenant.Role)),
+	}
+	for _, stmt := range statements {
+		if _, err := tx.Exec(stmt); err != nil {
+			return fmt.Errorf("failed to drop tenant %s: %w", tenant.Name, err)
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,144 @@
+package supabase
+
+import (
+	"crypto/tls"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"sync/atomic"
+	"time"
+)
+
+// TransportConfig controls connection reuse and timeouts for Management API calls
+type TransportConfig struct {
+	MaxIdleConns          int
+	MaxIdleConnsPerHost   int
+	MaxConnsPerHost       int
+	IdleConnTimeout       time.Duration
+	DialTimeout           time.Duration
+	KeepAlive             time.Duration
+	TLSHandshakeTimeout   time.Duration
+	ResponseHeaderTimeout time.Duration
+	RequestTimeout        time.Duration
+}
+
+// DefaultTransportConfig returns transport settings tuned for a single upstream host
+func DefaultTransportConfig() TransportConfig {
+	return TransportConfig{
+		MaxIdleConns:          32,
+		MaxIdleConnsPerHost:   16,
+		MaxConnsPerHost:       32,
+		IdleConnTimeout:       90 * time.Second,
+		DialTimeout:           10 * time.Second,
+		KeepAlive:             30 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: 30 * time.Second,
+		RequestTimeout:        60 * time.Second,
+	}
+}
+
+// TransportStats is a snapshot of Management API transport counters
+type TransportStats struct {
+	Requests          int64   `json:"requests"`
+	Errors            int64   `json:"errors"`
+	HTTP2Responses    int64   `json:"http2_responses"`
+	ConnectionsOpened int64   `json:"connections_opened"`
+	ConnectionsReused int64   `json:"connections_reused"`
+	TLSHandshakes     int64   `json:"tls_handshakes"`
+	AvgLatencyMs      float64 `json:"avg_latency_ms"`
+}
+
+// instrumentedTransport wraps a RoundTripper and records connection metrics
+type instrumentedTransport struct {
+	base http.RoundTripper
+
+	requests          atomic.Int64
+	errors            atomic.Int64
+	http2Responses    atomic.Int64
+	connectionsOpened atomic.Int64
+	connectionsReused atomic.Int64
+	tlsHandshakes     atomic.Int64
+	totalLatencyNanos atomic.Int64
+}
+
+// newTransport builds a keep-alive, HTTP/2 capable transport from config
+func newTransport(config TransportConfig) *http.Transport {
+	dialer := &net.Dialer{
+		Timeout:   config.DialTimeout,
+		KeepAlive: config.KeepAlive,
+	}
+
+	return &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		DialContext:           dialer.DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          config.MaxIdleConns,
+		MaxIdleConnsPerHost:   config.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       config.MaxConnsPerHost,
+		IdleConnTimeout:       config.IdleConnTimeout,
+		TLSHandshakeTimeout:   config.TLSHandshakeTimeout,
+		ResponseHeaderTimeout: config.ResponseHeaderTimeout,
+		ExpectContinueTimeout: 1 * time.Second,
+		TLSClientConfig: &tls.Config{
+			MinVersion: tls.VersionTLS12,
+		},
+	}
+}
+
+// RoundTrip executes a request while tracing connection reuse
+func (t *instrumentedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	trace := &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			if info.Reused {
+				t.connectionsReused.Add(1)
+			} else {
+				t.connectionsOpened.Add(1)
+			}
+		},
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				t.tlsHandshakes.Add(1)
+			}
+		},
+	}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
+	resp, err := t.base.RoundTrip(req)
+	t.totalLatencyNanos.Add(int64(time.Since(start)))
+	t.requests.Add(1)
+
+	if err != nil {
+		t.errors.Add(1)
+		return nil, err
+	}
+	if resp.ProtoMajor == 2 {
+		t.http2Responses.Add(1)
+	}
+
+	return resp, nil
+}
+
+// WrapTransport replaces the Management API transport with wrap(current),
+// for example to record or replay traffic. Transport stats keep counting.
+// It must be called before the client is used.
+func (c *Client) WrapTransport(wrap func(http.RoundTripper) http.RoundTripper) {
+	c.transport.base = wrap(c.transport.base)
+}
+
+// stats returns a snapshot of the collected counters
+func (t *instrumentedTransport) stats() TransportStats {
+	requests := t.requests.Load()
+	stats := TransportStats{
+		Requests:          requests,
+		Errors:            t.errors.Load(),
+		HTTP2Responses:    t.http2Responses.Load(),
+		ConnectionsOpened: t.connectionsOpened.Load(),
+		ConnectionsReused: t.connectionsReused.Load(),
+		TLSHandshakes:     t.tlsHandshakes.Load(),
+	}
+	if requests > 0 {
+		stats.AvgLatencyMs = float64(t.totalLatencyNanos.Load()) / float64(requests) / float64(time.Millisecond)
+	}
+	return stats
+}
@@ -2,6 +2,12 @@ package supabase
 
 import ("time"
 	"fmt"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+
+	"supabase-manager/internal/jsonschema"
 )
 
 // Project represents a Supabase project
@@ -18,6 +24,10 @@ type Project struct {
 	DatabaseURL  string `json:"database_url,omitempty"`
 	DBPassword   string `json:"-"` // Never serialize password
 	
+	// Database details reported by the Management API
+	Database        *ProjectDatabase `json:"database,omitempty"`
+	PostgresVersion string           `json:"-"`
+	
 	// API keys and URLs - these come from different API responses
 	ProjectRef string `json:"ref,omitempty"`
 	Endpoint   string `json:"endpoint,omitempty"`
@@ -25,6 +35,66 @@ type Project struct {
 	ServiceKey string `json:"service_role_key,omitempty"`
 }
 
+// ProjectDatabase holds the database section of a Management API project
+type ProjectDatabase struct {
+	Host           string `json:"host,omitempty"`
+	Version        string `json:"version,omitempty"`
+	PostgresEngine string `json:"postgres_engine,omitempty"`
+	ReleaseChannel string `json:"release_channel,omitempty"`
+}
+
+// ProjectOptions holds optional settings for project creation
+type ProjectOptions struct {
+	PostgresVersion string
+	// Plan is the billing plan, free when unset
+	Plan string
+	// ComputeSize is the instance size, the plan's default when unset
+	ComputeSize string
+}
+
+// PostgresVersion describes a Postgres major version that can be requested
+type PostgresVersion struct {
+	Version        string `json:"version"`
+	ReleaseChannel string `json:"release_channel"`
+	Default        bool   `json:"default"`
+}
+
+// SupportedPostgresVersions lists the postgres_engine values accepted by the
+// Management API at project creation. The API offers no endpoint to list
+// them, so they are maintained here.
+var SupportedPostgresVersions = []PostgresVersion{
+	{Version: "15", ReleaseChannel: "ga"},
+	{Version: "17", ReleaseChannel: "ga", Default: true},
+}
+
+// IsSupportedPostgresVersion reports whether a version can be requested
+func IsSupportedPostgresVersion(version string) bool {
+	for _, v := range SupportedPostgresVersions {
+		if v.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+// SupportedRegions lists the regions projects can be created in. Like the
+// Postgres versions, they are maintained here.
+var SupportedRegions = []string{
+	"us-east-1", "us-east-2", "us-west-1", "us-west-2", "ca-central-1",
+	"eu-west-1", "eu-west-2", "eu-west-3", "eu-central-1", "eu-central-2", "eu-north-1",
+	"ap-south-1", "ap-southeast-1", "ap-southeast-2", "ap-northeast-1", "ap-northeast-2",
+	"sa-east-1",
+}
+
+// Plans lists the billing plans projects can be created on
+var Plans = []string{"free", "pro"}
+
+// ComputeSizes lists the instance sizes projects can be created with
+var ComputeSizes = []string{
+	"nano", "micro", "small", "medium", "large", "xlarge",
+	"2xlarge", "4xlarge", "8xlarge", "12xlarge", "16xlarge",
+}
+
 // GetProjectURL returns the full project URL
 func (p *Project) GetProjectURL() string {
 	if p.Endpoint != "" {
@@ -79,17 +149,189 @@ type MigrationResult struct {
 	ExecutionTime  time.Duration `json:"execution_time"`
 	Error          string        `json:"error,omitempty"`
 	StatementsRun  int           `json:"statements_run"`
+	MigrationID    string        `json:"migration_id,omitempty"`
+	Version        string        `json:"version,omitempty"`
+	Warnings       []LintWarning `json:"warnings,omitempty"`
+	// Verifications are the results of the migration's verification
+	// queries; VerificationFailed is set when any failed
+	Verifications      []VerificationResult `json:"verifications,omitempty"`
+	VerificationFailed bool                 `json:"verification_failed,omitempty"`
+	// Estimates are the planner's estimates for the statements checked in
+	// strict mode
+	Estimates []StatementEstimate `json:"estimates,omitempty"`
+	// Skipped is set when the migration's version was already applied
+	Skipped bool `json:"skipped,omitempty"`
 }
 
 // CreateProjectRequest represents the request to create a project
 type CreateProjectRequest struct {
-	Name   string `json:"name" binding:"required"`
-	Region string `json:"region,omitempty"`
+	Name            string `json:"name" binding:"required"`
+	Region          string `json:"region,omitempty"`
+	PostgresVersion string `json:"postgres_version,omitempty"`
+	// NameConflict selects what happens when the name is already taken:
+	// "error" (default), "suffix" (name-2, name-3, ...) or "timestamp"
+	NameConflict string `json:"name_conflict,omitempty"`
+	// CheckRemote also checks the organization's projects in Supabase
+	CheckRemote bool `json:"check_remote,omitempty"`
+	// Labels are key/value pairs used to select projects in bulk
+	Labels map[string]string `json:"labels,omitempty"`
+	// WithDefaultBuckets creates the configured default storage buckets
+	// once the project is ready
+	WithDefaultBuckets bool `json:"with_default_buckets,omitempty"`
+	// EnableExtensions lists Postgres extensions to create once the
+	// project is ready
+	EnableExtensions []string `json:"enable_extensions,omitempty"`
+	// Profile applies a provisioning profile, such as "ai", once the
+	// project is ready
+	Profile        string          `json:"profile,omitempty"`
+	ProfileOptions *ProfileOptions `json:"profile_options,omitempty"`
+	// Template declares extensions, buckets, secrets, auth providers and
+	// SQL applied once the project is ready
+	Template *ProjectTemplate `json:"template,omitempty"`
+	// Plan is the billing plan, "free" (default) or "pro"
+	Plan string `json:"plan,omitempty"`
+	// ComputeSize is the instance size, such as "small"; see ComputeSizes
+	ComputeSize string `json:"compute_size,omitempty"`
+	// StrictRegion fails the create when the region has no capacity
+	// instead of trying the configured fallback regions
+	StrictRegion bool `json:"strict_region,omitempty"`
+}
+
+// ProjectLabelsRequest replaces a project's labels
+type ProjectLabelsRequest struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// ChangePlanRequest moves a project to another billing plan, compute size
+// or both
+type ChangePlanRequest struct {
+	// Plan is recorded for the project once its organization is on it;
+	// Supabase bills plans per organization
+	Plan string `json:"plan,omitempty"`
+	// ComputeSize is applied as a compute add-on; see ComputeSizes
+	ComputeSize string `json:"compute_size,omitempty"`
 }
 
+// UpdateProjectRequest changes the settings of a project. Fields left out
+// are kept; labels, when given, replace the project's labels.
+type UpdateProjectRequest struct {
+	Name   *string            `json:"name,omitempty"`
+	Labels *map[string]string `json:"labels,omitempty"`
+}
+
+// Name conflict strategies for CreateProjectRequest.NameConflict
+const (
+	NameConflictError     = "error"
+	NameConflictSuffix    = "suffix"
+	NameConflictTimestamp = "timestamp"
+)
+
 // ApplySchemaRequest represents the request to apply a schema
 type ApplySchemaRequest struct {
-	SQL string `json:"sql" binding:"required"`
+	SQL         string `json:"sql,omitempty"`
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+	Author      string `json:"author,omitempty"`
+	Ticket      string `json:"ticket,omitempty"`
+	Role        string `json:"role,omitempty"`
+	// SourceURL is fetched for the SQL instead of sending it inline: an
+	// https URL or git+https://host/repo.git#<ref>:<path>
+	SourceURL string `json:"source_url,omitempty"`
+	// SourceToken authenticates the fetch and is never stored
+	SourceToken string `json:"source_token,omitempty"`
+	// SHA256 pins the fetched SQL to a hex checksum
+	SHA256 string `json:"sha256,omitempty"`
+	// Verify are queries run after the migration, in its transaction,
+	// with the results they must produce
+	Verify []Verification `json:"verify,omitempty"`
+	// OnVerifyFailure is "rollback" (default) or "flag", which commits the
+	// migration and flags it
+	OnVerifyFailure string `json:"on_verify_failure,omitempty"`
+	// Strict runs the migration in strict mode, rejecting data-modifying
+	// statements the planner expects to be too large. Limits left unset
+	// use the manager's defaults.
+	Strict *StrictLimits `json:"strict,omitempty"`
+	// Version records the migration in the database's migration table;
+	// a version already recorded there is skipped
+	Version string `json:"version,omitempty"`
+	// Migrations applies a batch of versioned migrations in version
+	// order instead of a single one
+	Migrations []VersionedMigration `json:"migrations,omitempty"`
+}
+
+// MigrationRecord is an entry in the local migration history of a project
+// or external database
+type MigrationRecord struct {
+	ID              string    `json:"id"`
+	TargetID        string    `json:"target_id"`
+	Version         string    `json:"version"`
+	Name            string    `json:"name,omitempty"`
+	Description     string    `json:"description,omitempty"`
+	Author          string    `json:"author,omitempty"`
+	Ticket          string    `json:"ticket,omitempty"`
+	Role            string    `json:"role,omitempty"`
+	Success         bool      `json:"success"`
+	StatementsRun   int       `json:"statements_run"`
+	ExecutionTimeMs int64     `json:"execution_time_ms"`
+	Error           string    `json:"error,omitempty"`
+	AppliedAt       time.Time `json:"applied_at"`
+	// Source is the URL the SQL was fetched from, with its checksum
+	Source          string    `json:"source,omitempty"`
+	SourceSHA256    string    `json:"source_sha256,omitempty"`
+	// Imported marks history registered from a migrations folder without
+	// the manager running it; SourceSHA256 holds the file's checksum
+	Imported        bool      `json:"imported,omitempty"`
+	// SQL is the submitted script, stored compressed and served separately
+	SQL             string    `json:"-"`
+}
+
+// MigrationRoles is the set of roles migrations against a project or
+// database may run as
+type MigrationRoles struct {
+	TargetID    string    `json:"target_id"`
+	Roles       []string  `json:"roles"`
+	DefaultRole string    `json:"default_role,omitempty"`
+	UpdatedAt   time.Time `json:"updated_at,omitempty"`
+}
+
+// Allows reports whether migrations may run as role
+func (mr *MigrationRoles) Allows(role string) bool {
+	for _, r := range mr.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+// MigrationRolesRequest represents the request to set a target's migration roles
+type MigrationRolesRequest struct {
+	Roles       []string `json:"roles"`
+	DefaultRole string   `json:"default_role,omitempty"`
+}
+
+// Validate checks that the roles may run migrations and that the default
+// role is one of them
+func (r *MigrationRolesRequest) Validate() error {
+	for _, role := range r.Roles {
+		if err := ValidateMigrationRole(role); err != nil {
+			return err
+		}
+	}
+	if r.DefaultRole != "" && !slices.Contains(r.Roles, r.DefaultRole) {
+		return fmt.Errorf("default_role must be one of roles")
+	}
+	return nil
+}
+
+// StatsSnapshot is a point in the stats history
+type StatsSnapshot struct {
+	RecordedAt               time.Time `json:"recorded_at"`
+	TotalProjects            int       `json:"total_projects"`
+	ActiveProjects           int       `json:"active_projects"`
+	FailedProjects           int       `json:"failed_projects"`
+	MigrationsApplied        int       `json:"migrations_applied"`
+	BackgroundTasksCompleted int64     `json:"background_tasks_completed"`
 }
 
 // ErrorResponse represents an API error response
@@ -102,11 +344,14 @@ type ErrorDetail struct {
 	Code    string `json:"code"`
 	Message string `json:"message"`
 	Details string `json:"details,omitempty"`
+	// Violations lists where a request body does not match its schema
+	Violations []jsonschema.Violation `json:"violations,omitempty"`
 }
 
 // StoredProject represents a project stored in local database
 type StoredProject struct {
 	ID             string    `json:"id"`
+	Name           string    `json:"name"`
 	ProjectRef     string    `json:"project_ref"`
 	ProjectURL     string    `json:"project_url"`
 	Region         string    `json:"region"`
@@ -114,14 +359,46 @@ type StoredProject struct {
 	ServiceKey     string    `json:"-"` // Sensitive, don't expose in JSON by default
 	DBPassword     string    `json:"-"` // Sensitive
 	Status         string    `json:"status"`
+	PostgresVersion string    `json:"postgres_version,omitempty"`
+	DeletionAttempts int      `json:"deletion_attempts,omitempty"`
+	DeletionError  string    `json:"deletion_error,omitempty"`
+	// BYOCredentials is set for projects created with caller-supplied
+	// credentials. The credentials themselves are never stored.
+	BYOCredentials bool      `json:"byo_credentials,omitempty"`
+	// OrganizationID is the Supabase organization the project belongs to;
+	// empty for projects stored before it was recorded
+	OrganizationID string    `json:"organization_id,omitempty"`
+	// CreatedBy is the API key that created the project; empty for
+	// projects discovered in Supabase or stored before it was recorded
+	CreatedBy      string    `json:"created_by,omitempty"`
+	// RequestedRegion is the region asked for when the project was created
+	// in a fallback region instead; empty when it got the one asked for
+	RequestedRegion string   `json:"requested_region,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
 }
 
+// DeletedProject is what is kept of a deleted project until it is purged.
+// Keys and the database password are not kept.
+type DeletedProject struct {
+	ID         string            `json:"id"`
+	Name       string            `json:"name"`
+	ProjectRef string            `json:"project_ref"`
+	Region     string            `json:"region"`
+	// LastStatus is the project's status when it was deleted
+	LastStatus string            `json:"last_status"`
+	OrganizationID string        `json:"organization_id,omitempty"`
+	Labels     map[string]string `json:"labels,omitempty"`
+	CreatedAt  time.Time         `json:"created_at"`
+	DeletedAt  time.Time         `json:"deleted_at"`
+}
+
 // ToStoredProject converts Project to StoredProject
 func (p *Project) ToStoredProject() *StoredProject {
 	return &StoredProject{
 		ID:          p.ID,
+		Name:        p.Name,
 		ProjectRef:  p.ProjectRef,
 		ProjectURL:  p.GetProjectURL(),
 		Region:      p.Region,
@@ -129,7 +406,65 @@ func (p *Project) ToStoredProject() *StoredProject {
 		ServiceKey:  p.ServiceKey,
 		DBPassword:  p.DBPassword,
 		Status:      p.Status,
+		PostgresVersion: p.PostgresVersion,
+		OrganizationID: p.OrganizationID,
 		CreatedAt:   p.CreatedAt,
-		UpdatedAt:   time.Now(),
+		UpdatedAt:   time.Now().UTC(),
+	}
+}
+// ToProject converts StoredProject back to a Project for database access
+func (sp *StoredProject) ToProject() *Project {
+	return &Project{
+		ID:         sp.ID,
+		Name:       sp.Name,
+		ProjectRef: sp.ProjectRef,
+		Region:     sp.Region,
+		Status:     sp.Status,
+		DBPassword: sp.DBPassword,
+		AnonKey:    sp.AnonKey,
+		ServiceKey: sp.ServiceKey,
+		PostgresVersion: sp.PostgresVersion,
+		CreatedAt:  sp.CreatedAt,
+	}
+}
+
+// ExternalDatabase represents a plain Postgres database registered as a migration target
+type ExternalDatabase struct {
+	ID        string    `json:"id"`
+	Name      string    `json:"name"`
+	Host      string    `json:"host"`
+	Port      int       `json:"port"`
+	Database  string    `json:"database"`
+	User      string    `json:"user"`
+	Password  string    `json:"-"` // Sensitive
+	SSLMode   string    `json:"sslmode"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// GetDatabaseConnectionString returns PostgreSQL connection string
+func (d *ExternalDatabase) GetDatabaseConnectionString() string {
+	if d.Host == "" || d.Database == "" || d.User == "" {
+		return ""
+	}
+
+	connURL := url.URL{
+		Scheme:   "postgresql",
+		User:     url.UserPassword(d.User, d.Password),
+		Host:     net.JoinHostPort(d.Host, strconv.Itoa(d.Port)),
+		Path:     "/" + d.Database,
+		RawQuery: url.Values{"sslmode": []string{d.SSLMode}}.Encode(),
 	}
-}
\ No newline at end of file
+	return connURL.String()
+}
+
+// RegisterDatabaseRequest represents the request to register an external database
+type RegisterDatabaseRequest struct {
+	Name     string `json:"name" binding:"required"`
+	Host     string `json:"host" binding:"required"`
+	Port     int    `json:"port,omitempty"`
+	Database string `json:"database" binding:"required"`
+	User     string `json:"user" binding:"required"`
+	Password string `json:"password"`
+	SSLMode  string `json:"sslmode,omitempty"`
+}
@@ -0,0 +1,218 @@
+package supabase
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// What happens when a verification query of a migration fails
+const (
+	// VerifyRollback rolls the migration back (the default)
+	VerifyRollback = "rollback"
+	// VerifyFlag commits the migration and flags it in its result and history
+	VerifyFlag = "flag"
+)
+
+// ErrVerificationFailed is returned when a verification query of a
+// migration fails and the migration is rolled back
+var ErrVerificationFailed = errors.New("verification failed")
+
+// MaxVerifications is the most verification queries a migration may carry
+const MaxVerifications = 20
+
+// Verification is a read-only query run after a migration's statements, in
+// the same transaction, with the result it must produce. Exactly one
+// expectation is set.
+type Verification struct {
+	Name  string `json:"name,omitempty"`
+	Query string `json:"query"`
+	// ExpectRows is the number of rows the query returns
+	ExpectRows *int `json:"expect_rows,omitempty"`
+	// ExpectExists is whether the query returns any row
+	ExpectExists *bool `json:"expect_exists,omitempty"`
+	// ExpectValue is the first column of the first row, as text; NULL is
+	// "null"
+	ExpectValue *string `json:"expect_value,omitempty"`
+}
+
+// VerificationResult is the outcome of a verification query
+type VerificationResult struct {
+	Name     string `json:"name,omitempty"`
+	Query    string `json:"query"`
+	Passed   bool   `json:"passed"`
+	Expected string `json:"expected"`
+	Actual   string `json:"actual,omitempty"`
+	Error    string `json:"error,omitempty"`
+}
+
+// ValidateVerifications checks a migration's verification queries and what
+// to do when one fails
+func ValidateVerifications(checks []Verification, onFailure string) error {
+	if onFailure != "" && onFailure != VerifyRollback && onFailure != VerifyFlag {
+		return fmt.Errorf("on_verify_failure must be %s or %s", VerifyRollback, VerifyFlag)
+	}
+	if len(checks) > MaxVerifications {
+		return fmt.Errorf("at most %d verification queries are allowed", MaxVerifications)
+	}
+
+	for i, check := range checks {
+		label := check.label(i)
+
+		statements := splitSQLStatements(check.Query)
+		if len(statements) != 1 {
+			return fmt.Errorf("%s must be a single statement", label)
+		}
+		words := strings.Fields(strings.ToUpper(statements[0]))
+		if len(words) == 0 || (words[0] != "SELECT" && words[0] != "WITH") {
+			return fmt.Errorf("%s must be a SELECT query", label)
+		}
+
+		expectations := 0
+		if check.ExpectRows != nil {
+			expectations++
+			if *check.ExpectRows < 0 {
+				return fmt.Errorf("%s expects a negative row count", label)
+			}
+		}
+		if check.ExpectExists != nil {
+			expectations++
+		}
+		if check.ExpectValue != nil {
+			expectations++
+		}
+		if expectations != 1 {
+			return fmt.Errorf("%s needs exactly one of expect_rows, expect_exists or expect_value", label)
+		}
+	}
+	return nil
+}
+
+// label names a verification in messages
+func (v Verification) label(index int) string {
+	if v.Name != "" {
+		return fmt.Sprintf("verification %q", v.Name)
+	}
+	return fmt.Sprintf("verification %d", index+1)
+}
+
+// expected describes the verification's expectation
+func (v Verification) expected() string {
+	switch {
+	case v.ExpectRows != nil:
+		return fmt.Sprintf("%d rows", *v.ExpectRows)
+	case v.ExpectExists != nil && *v.ExpectExists:
+		return "at least one row"
+	case v.ExpectExists != nil:
+		return "no rows"
+	case v.ExpectValue != nil:
+		return strconv.Quote(*v.ExpectValue)
+	}
+	return ""
+}
+
+// runVerification runs a verification query in a migration's transaction.
+// The query runs under a savepoint so a failing query does not abort the
+// transaction.
+func runVerification(tx *sql.Tx, index int, check Verification) VerificationResult {
+	result := VerificationResult{
+		Name:     check.Name,
+		Query:    check.Query,
+		Expected: check.expected(),
+	}
+
+	savepoint := fmt.Sprintf("verify_%d", index+1)
+	if _, err := tx.Exec("SAVEPOINT " + savepoint); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	count, first, err := queryVerification(tx, check.Query)
+	if err != nil {
+		result.Error = err.Error()
+		tx.Exec("ROLLBACK TO SAVEPOINT " + savepoint)
+		return result
+	}
+	tx.Exec("RELEASE SAVEPOINT " + savepoint)
+
+	switch {
+	case check.ExpectRows != nil:
+		result.Actual = fmt.Sprintf("%d rows", count)
+		result.Passed = count == *check.ExpectRows
+	case check.ExpectExists != nil:
+		result.Actual = fmt.Sprintf("%d rows", count)
+		result.Passed = (count > 0) == *check.ExpectExists
+	case check.ExpectValue != nil:
+		if count == 0 {
+			result.Actual = "no rows"
+			break
+		}
+		result.Actual = strconv.Quote(first)
+		result.Passed = first == *check.ExpectValue
+	}
+	return result
+}
+
+// queryVerification returns the number of rows a query returns and the
+// first column of its first row as text
+func queryVerification(tx *sql.Tx, query string) (int, string, error) {
+	rows, err := tx.Query(query)
+	if err != nil {
+		return 0, "", err
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		return 0, "", err
+	}
+
+	count := 0
+	first := ""
+	for rows.Next() {
+		if count == 0 && len(columns) > 0 {
+			values := make([]interface{}, len(columns))
+			for i := range values {
+				values[i] = new(interface{})
+			}
+			if err := rows.Scan(values...); err != nil {
+				return 0, "", err
+			}
+			first = formatVerificationValue(*values[0].(*interface{}))
+		}
+		count++
+	}
+	return count, first, rows.Err()
+}
+
+// formatVerificationValue renders a column value for comparison
+func formatVerificationValue(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case []byte:
+		return string(v)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// FailedVerifications summarizes the failed verifications of a migration,
+// or returns "" if all passed
+func FailedVerifications(results []VerificationResult) string {
+	var failed []string
+	for i, result := range results {
+		if result.Passed {
+			continue
+		}
+		label := Verification{Name: result.Name}.label(i)
+		if result.Error != "" {
+			failed = append(failed, fmt.Sprintf("%s failed: %s", label, result.Error))
+		} else {
+			failed = append(failed, fmt.Sprintf("%s expected %s, got %s", label, result.Expected, result.Actual))
+		}
+	}
+	return strings.Join(failed, "; ")
+}
@@ -0,0 +1,149 @@
+package supabase
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Project lifecycle events delivered to webhooks
+const (
+	WebhookEventProjectReady   = "project.ready"
+	WebhookEventProjectFailed  = "project.failed"
+	WebhookEventProjectDeleted = "project.deleted"
+)
+
+// WebhookEvents are the events a webhook can subscribe to
+var WebhookEvents = []string{WebhookEventProjectReady, WebhookEventProjectFailed, WebhookEventProjectDeleted}
+
+// Webhook is a callback URL notified of project lifecycle events. A webhook
+// registered for an API key only hears about projects in the organizations
+// that key may work in; a global one hears about every project.
+type Webhook struct {
+	ID          string   `json:"id"`
+	URL         string   `json:"url"`
+	Events      []string `json:"events"`
+	Description string   `json:"description,omitempty"`
+	// KeyID is the API key the webhook belongs to, empty for global webhooks
+	KeyID string `json:"key_id,omitempty"`
+	// Secret signs deliveries; it is only returned when the webhook is
+	// created
+	Secret    string    `json:"-"`
+	CreatedBy string    `json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	// The outcome of the last delivery, once there was one
+	LastDeliveryAt     *time.Time `json:"last_delivery_at,omitempty"`
+	LastDeliveryStatus int        `json:"last_delivery_status,omitempty"`
+	LastDeliveryError  string     `json:"last_delivery_error,omitempty"`
+}
+
+// Subscribes reports whether the webhook wants an event; no events means
+// all of them
+func (w *Webhook) Subscribes(event string) bool {
+	return len(w.Events) == 0 || slices.Contains(w.Events, event)
+}
+
+// CreateWebhookRequest registers a webhook
+type CreateWebhookRequest struct {
+	URL         string   `json:"url" binding:"required"`
+	Events      []string `json:"events,omitempty"`
+	Description string   `json:"description,omitempty"`
+	// Global delivers events of every project rather than only those of
+	// the calling key's organizations; it takes the admin scope
+	Global bool `json:"global,omitempty"`
+}
+
+// Validate checks the URL and events. Deliveries carry project details, so
+// they only go over HTTPS, and only to allowedHosts unless it is empty.
+func (r *CreateWebhookRequest) Validate(allowedHosts map[string]bool) error {
+	u, err := url.Parse(r.URL)
+	if err != nil || u.Scheme != "https" || u.Host == "" {
+		return fmt.Errorf("url must be an https URL")
+	}
+	if u.User != nil {
+		return fmt.Errorf("url must not carry credentials")
+	}
+	if len(allowedHosts) > 0 && !allowedHosts[strings.ToLower(u.Hostname())] {
+		return fmt.Errorf("url host %s is not in WEBHOOK_ALLOWED_HOSTS", u.Hostname())
+	}
+
+	for _, event := range r.Events {
+		if !slices.Contains(WebhookEvents, event) {
+			return fmt.Errorf("unknown event %q (expected %s)", event, strings.Join(WebhookEvents, ", "))
+		}
+	}
+	if len(r.Description) > 200 {
+		return fmt.Errorf("description must be at most 200 characters")
+	}
+	return nil
+}
+
+// NewWebhookSecret returns a random Standard Webhooks signing secret
+func NewWebhookSecret() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate secret: %w", err)
+	}
+	return "whsec_" + base64.StdEncoding.EncodeToString(buf), nil
+}
+
+// WebhookProject describes the project of a lifecycle event. It carries no
+// keys or passwords.
+type WebhookProject struct {
+	ID             string            `json:"id"`
+	Name           string            `json:"name"`
+	ProjectRef     string            `json:"project_ref"`
+	ProjectURL     string            `json:"project_url,omitempty"`
+	Region         string            `json:"region"`
+	Status         string            `json:"status"`
+	OrganizationID string            `json:"organization_id,omitempty"`
+	Labels         map[string]string `json:"labels,omitempty"`
+}
+
+// WebhookPayload is the JSON body posted to webhooks
+type WebhookPayload struct {
+	ID        string         `json:"id"`
+	Type      string         `json:"type"`
+	Timestamp time.Time      `json:"timestamp"`
+	Project   WebhookProject `json:"project"`
+	// SetupStatus is the outcome of the project's bootstrap, on ready
+	SetupStatus string `json:"setup_status,omitempty"`
+	// Error is why provisioning failed, on failed
+	Error string `json:"error,omitempty"`
+}
+
+// NewWebhookProject describes a stored project for a webhook payload
+func NewWebhookProject(project *StoredProject) WebhookProject {
+	return WebhookProject{
+		ID:             project.ID,
+		Name:           project.Name,
+		ProjectRef:     project.ProjectRef,
+		ProjectURL:     project.ProjectURL,
+		Region:         project.Region,
+		Status:         project.Status,
+		OrganizationID: project.OrganizationID,
+		Labels:         project.Labels,
+	}
+}
+
+// SignWebhook returns the Standard Webhooks signature header of a delivery,
+// the same scheme VerifyHook checks on incoming hooks
+func SignWebhook(id string, timestamp time.Time, body []byte, secret string) (string, error) {
+	key := []byte(secret)
+	if _, encoded, ok := strings.Cut(secret, "whsec_"); ok {
+		var err error
+		if key, err = base64.StdEncoding.DecodeString(encoded); err != nil {
+			return "", fmt.Errorf("invalid webhook secret: %w", err)
+		}
+	}
+	mac := hmac.New(sha256.New, key)
+	fmt.Fprintf(mac, "%s.%d.", id, timestamp.Unix())
+	mac.Write(body)
+	return "v1," + base64.StdEncoding.EncodeToString(mac.Sum(nil)), nil
+}
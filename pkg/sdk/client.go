@@ -0,0 +1,361 @@
+// Package sdk is a Go client for the supabase-manager API. Requests are
+// retried with exponential backoff on network errors, 429s and 5xx
+// responses, and a circuit breaker stops calling a manager that keeps
+// failing. POSTs carry an Idempotency-Key that stays the same across
+// retries, so a create that timed out is never run twice. WaitUntilActive
+// replaces the polling loop callers used to write around project creation.
+package sdk
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetryPolicy controls how failed requests are retried
+type RetryPolicy struct {
+	// MaxAttempts is the number of tries, including the first; 1 disables
+	// retries
+	MaxAttempts int
+	// InitialDelay is the wait before the first retry, doubled after each
+	// one up to MaxDelay. A Retry-After header sent by the server is used
+	// instead when it is longer.
+	InitialDelay time.Duration
+	MaxDelay     time.Duration
+}
+
+// DefaultRetryPolicy returns the retry policy used when none is configured
+func DefaultRetryPolicy() RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts:  5,
+		InitialDelay: 500 * time.Millisecond,
+		MaxDelay:     30 * time.Second,
+	}
+}
+
+// BreakerPolicy controls the circuit breaker shared by a client's requests
+type BreakerPolicy struct {
+	// FailureThreshold is the number of consecutive failed attempts, network
+	// errors or 5xx responses, that opens the breaker; 0 disables it
+	FailureThreshold int
+	// OpenDuration is how long an open breaker fails requests with
+	// ErrCircuitOpen before letting one through to test the manager
+	OpenDuration time.Duration
+}
+
+// DefaultBreakerPolicy returns the breaker policy used when none is
+// configured
+func DefaultBreakerPolicy() BreakerPolicy {
+	return BreakerPolicy{
+		FailureThreshold: 10,
+		OpenDuration:     30 * time.Second,
+	}
+}
+
+// ErrCircuitOpen is returned without calling the manager while the circuit
+// breaker is open
+var ErrCircuitOpen = errors.New("circuit breaker is open: the manager keeps failing")
+
+// Config configures a Client
+type Config struct {
+	// BaseURL is the manager's address, such as "http://localhost:8080"
+	BaseURL string
+	// APIKey is sent as X-API-Key
+	APIKey string
+	// HTTPClient defaults to a client with a 60s timeout
+	HTTPClient *http.Client
+	// Retry defaults to DefaultRetryPolicy
+	Retry *RetryPolicy
+	// Breaker defaults to DefaultBreakerPolicy
+	Breaker *BreakerPolicy
+	// PollInterval is how often WaitUntilActive checks a project, 5s when
+	// zero
+	PollInterval time.Duration
+}
+
+// Client calls the supabase-manager API
+type Client struct {
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	retry        RetryPolicy
+	breaker      *breaker
+	pollInterval time.Duration
+}
+
+// NewClient creates a client from config
+func NewClient(config Config) *Client {
+	client := &Client{
+		baseURL:      strings.TrimRight(config.BaseURL, "/"),
+		apiKey:       config.APIKey,
+		httpClient:   config.HTTPClient,
+		retry:        DefaultRetryPolicy(),
+		pollInterval: config.PollInterval,
+	}
+	if client.httpClient == nil {
+		client.httpClient = &http.Client{Timeout: 60 * time.Second}
+	}
+	if config.Retry != nil {
+		client.retry = *config.Retry
+	}
+	if client.retry.MaxAttempts < 1 {
+		client.retry.MaxAttempts = 1
+	}
+	client.breaker = &breaker{policy: DefaultBreakerPolicy()}
+	if config.Breaker != nil {
+		client.breaker.policy = *config.Breaker
+	}
+	if client.pollInterval <= 0 {
+		client.pollInterval = 5 * time.Second
+	}
+	return client
+}
+
+// Error is an error response from the manager
+type Error struct {
+	StatusCode int
+	Code       string `json:"code"`
+	Message    string `json:"message"`
+	Details    string `json:"details,omitempty"`
+}
+
+func (e *Error) Error() string {
+	if e.Details != "" {
+		return fmt.Sprintf("%s (status %d): %s: %s", e.Code, e.StatusCode, e.Message, e.Details)
+	}
+	return fmt.Sprintf("%s (status %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+// IsNotFound reports whether err is a 404 from the manager
+func IsNotFound(err error) bool {
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound
+}
+
+type idempotencyKeyContextKey struct{}
+
+// WithIdempotencyKey sets the Idempotency-Key of the POSTs made with ctx.
+// Without it each call generates its own key, which only covers that
+// call's retries; a caller that persists its key can also retry safely
+// after restarting.
+func WithIdempotencyKey(ctx context.Context, key string) context.Context {
+	return context.WithValue(ctx, idempotencyKeyContextKey{}, key)
+}
+
+// do sends a request, retrying it per the retry policy, and decodes a
+// successful JSON response into out
+func (c *Client) do(ctx context.Context, method, path string, body, out interface{}) error {
+	var payload []byte
+	if body != nil {
+		var err error
+		if payload, err = json.Marshal(body); err != nil {
+			return fmt.Errorf("failed to encode request: %w", err)
+		}
+	}
+
+	idempotencyKey := ""
+	if method == http.MethodPost {
+		idempotencyKey, _ = ctx.Value(idempotencyKeyContextKey{}).(string)
+		if idempotencyKey == "" {
+			idempotencyKey = uuid.New().String()
+		}
+	}
+
+	delay := c.retry.InitialDelay
+	for attempt := 1; ; attempt++ {
+		if !c.breaker.allow(time.Now()) {
+			return ErrCircuitOpen
+		}
+		retryAfter, err := c.attempt(ctx, method, path, payload, idempotencyKey, out)
+		c.breaker.record(err, time.Now())
+		if err == nil || attempt >= c.retry.MaxAttempts || !retryable(err) {
+			return err
+		}
+
+		wait := min(delay, c.retry.MaxDelay)
+		// Jitter keeps clients that failed together from retrying together
+		wait = wait/2 + rand.N(wait/2+1)
+		if retryAfter > wait {
+			wait = retryAfter
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(wait):
+		}
+		delay *= 2
+	}
+}
+
+// attempt sends a request once. It returns the server's Retry-After, if any,
+// with the error.
+func (c *Client) attempt(ctx context.Context, method, path string, payload []byte, idempotencyKey string, out interface{}) (time.Duration, error) {
+	var body io.Reader
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.baseURL+path, body)
+	if err != nil {
+		return 0, fmt.Errorf("failed to create request: %w", err)
+	}
+	req.Header.Set("X-API-Key", c.apiKey)
+	req.Header.Set("Accept", "application/json")
+	if payload != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if idempotencyKey != "" {
+		req.Header.Set("Idempotency-Key", idempotencyKey)
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return 0, &transportError{err: err}
+	}
+	defer resp.Body.Close()
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return 0, &transportError{err: err}
+	}
+
+	if resp.StatusCode >= 400 {
+		apiErr := &Error{StatusCode: resp.StatusCode}
+		var parsed struct {
+			Error *Error `json:"error"`
+		}
+		if json.Unmarshal(data, &parsed) == nil && parsed.Error != nil {
+			apiErr.Code = parsed.Error.Code
+			apiErr.Message = parsed.Error.Message
+			apiErr.Details = parsed.Error.Details
+		} else {
+			apiErr.Message = strings.TrimSpace(string(data))
+		}
+		return parseRetryAfter(resp.Header.Get("Retry-After")), apiErr
+	}
+
+	if out != nil && len(data) > 0 {
+		if err := json.Unmarshal(data, out); err != nil {
+			return 0, fmt.Errorf("failed to decode response: %w", err)
+		}
+	}
+	return 0, nil
+}
+
+// transportError is a request that got no response
+type transportError struct {
+	err error
+}
+
+func (e *transportError) Error() string {
+	return "request failed: " + e.err.Error()
+}
+
+func (e *transportError) Unwrap() error {
+	return e.err
+}
+
+// retryable reports whether a failed request may succeed if sent again.
+// POSTs are safe to resend since they carry an Idempotency-Key.
+func retryable(err error) bool {
+	var transportErr *transportError
+	if errors.As(err, &transportErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	var apiErr *Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+	switch apiErr.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	case http.StatusConflict:
+		// The first request with the same Idempotency-Key is still running
+		return apiErr.Code == "IDEMPOTENCY_KEY_IN_USE"
+	}
+	return false
+}
+
+// breakerFailure reports whether a failed attempt counts against the
+// circuit breaker. Only failures of the manager itself do; errors caused by
+// the request and rate limits do not.
+func breakerFailure(err error) bool {
+	var transportErr *transportError
+	if errors.As(err, &transportErr) {
+		return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+	}
+	var apiErr *Error
+	return errors.As(err, &apiErr) && apiErr.StatusCode >= http.StatusInternalServerError
+}
+
+// breaker opens after a run of failed attempts and fails requests fast
+// while open. Once OpenDuration has passed it lets a single attempt
+// through: a success closes it again and a failure keeps it open for
+// another OpenDuration.
+type breaker struct {
+	policy BreakerPolicy
+
+	mu        sync.Mutex
+	failures  int
+	openUntil time.Time
+	probing   bool
+}
+
+// allow reports whether an attempt may be sent now
+func (b *breaker) allow(now time.Time) bool {
+	if b.policy.FailureThreshold <= 0 {
+		return true
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.failures < b.policy.FailureThreshold {
+		return true
+	}
+	if b.probing || now.Before(b.openUntil) {
+		return false
+	}
+	b.probing = true
+	return true
+}
+
+// record counts the outcome of an attempt let through by allow. Attempts
+// the caller cancelled say nothing about the manager and are not counted.
+func (b *breaker) record(err error, now time.Time) {
+	if b.policy.FailureThreshold <= 0 {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+	switch {
+	case breakerFailure(err):
+		b.failures++
+		if b.failures >= b.policy.FailureThreshold {
+			b.openUntil = now.Add(b.policy.OpenDuration)
+		}
+	case errors.Is(err, context.Canceled), errors.Is(err, context.DeadlineExceeded):
+	default:
+		b.failures = 0
+	}
+}
+
+// parseRetryAfter reads a Retry-After header given in seconds
+func parseRetryAfter(value string) time.Duration {
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		return 0
+	}
+	return time.Duration(seconds) * time.Second
+}
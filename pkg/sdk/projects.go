@@ -0,0 +1,117 @@
+package sdk
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// Project statuses reported by the manager
+const (
+	StatusActiveHealthy   = "ACTIVE_HEALTHY"
+	StatusFailed          = "FAILED"
+	StatusDeletionFailed  = "DELETION_FAILED"
+	StatusPendingDeletion = "PENDING_DELETION"
+)
+
+// CreateProjectRequest creates a project. See POST /api/projects for the
+// fields.
+type CreateProjectRequest struct {
+	Name               string            `json:"name"`
+	Region             string            `json:"region,omitempty"`
+	PostgresVersion    string            `json:"postgres_version,omitempty"`
+	NameConflict       string            `json:"name_conflict,omitempty"`
+	Labels             map[string]string `json:"labels,omitempty"`
+	WithDefaultBuckets bool              `json:"with_default_buckets,omitempty"`
+	EnableExtensions   []string          `json:"enable_extensions,omitempty"`
+	Profile            string            `json:"profile,omitempty"`
+	Plan               string            `json:"plan,omitempty"`
+	ComputeSize        string            `json:"compute_size,omitempty"`
+	StrictRegion       bool              `json:"strict_region,omitempty"`
+}
+
+// Project is a project as returned by the manager
+type Project struct {
+	ID              string            `json:"id"`
+	Name            string            `json:"name"`
+	ProjectRef      string            `json:"project_ref"`
+	ProjectURL      string            `json:"project_url"`
+	AnonKey         string            `json:"anon_key"`
+	Status          string            `json:"status"`
+	Region          string            `json:"region"`
+	RequestedRegion string            `json:"requested_region,omitempty"`
+	OrganizationID  string            `json:"organization_id,omitempty"`
+	PostgresVersion string            `json:"postgres_version"`
+	Labels          map[string]string `json:"labels"`
+	JobID           string            `json:"job_id,omitempty"`
+	CreatedAt       time.Time         `json:"created_at"`
+	UpdatedAt       time.Time         `json:"updated_at"`
+}
+
+// CreateProject starts creating a project. The project is returned while
+// it is still coming up; use WaitUntilActive to wait for it.
+func (c *Client) CreateProject(ctx context.Context, req *CreateProjectRequest) (*Project, error) {
+	var project Project
+	if err := c.do(ctx, http.MethodPost, "/api/projects", req, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// GetProject returns a project by ID
+func (c *Client) GetProject(ctx context.Context, id string) (*Project, error) {
+	var project Project
+	if err := c.do(ctx, http.MethodGet, "/api/projects/"+url.PathEscape(id), nil, &project); err != nil {
+		return nil, err
+	}
+	return &project, nil
+}
+
+// DeleteProject deletes a project, and in Supabase too when deleteRemote is
+// set. Deletes are idempotent, so they are retried like reads.
+func (c *Client) DeleteProject(ctx context.Context, id string, deleteRemote bool) error {
+	path := "/api/projects/" + url.PathEscape(id)
+	if deleteRemote {
+		path += "?delete_remote=true"
+	}
+	return c.do(ctx, http.MethodDelete, path, nil, nil)
+}
+
+// WaitUntilActive polls a project until it is ACTIVE_HEALTHY and returns
+// it. It fails when the project fails to provision or is being deleted,
+// and when ctx is done; set a deadline on ctx to bound the wait.
+func (c *Client) WaitUntilActive(ctx context.Context, id string) (*Project, error) {
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+
+	for {
+		project, err := c.GetProject(ctx, id)
+		if err != nil {
+			return nil, err
+		}
+
+		switch project.Status {
+		case StatusActiveHealthy:
+			return project, nil
+		case StatusFailed, StatusPendingDeletion, StatusDeletionFailed:
+			return project, fmt.Errorf("project %s is %s", id, project.Status)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("project %s is still %s: %w", id, project.Status, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// CreateProjectAndWait creates a project and waits until it is active
+func (c *Client) CreateProjectAndWait(ctx context.Context, req *CreateProjectRequest) (*Project, error) {
+	project, err := c.CreateProject(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	return c.WaitUntilActive(ctx, project.ID)
+}
@@ -0,0 +1,208 @@
+package supabasetest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Interaction is one recorded Management API exchange. Request headers and
+// bodies are not recorded, so access tokens and generated database
+// passwords stay out of the cassette.
+type Interaction struct {
+	Method      string    `json:"method"`
+	Host        string    `json:"host"`
+	Path        string    `json:"path"`
+	Status      int       `json:"status"`
+	ContentType string    `json:"content_type,omitempty"`
+	Body        string    `json:"body"`
+	RecordedAt  time.Time `json:"recorded_at"`
+}
+
+// key identifies the requests an interaction answers
+func (i *Interaction) key() string {
+	return i.Method + " " + i.Host + i.Path
+}
+
+// Cassette is a file of recorded interactions
+type Cassette struct {
+	Interactions []Interaction `json:"interactions"`
+}
+
+// requestKey identifies a request the same way as Interaction.key
+func requestKey(req *http.Request) string {
+	return req.Method + " " + req.URL.Host + req.URL.RequestURI()
+}
+
+// Recorder is an http.RoundTripper that passes requests to the real API and
+// appends every exchange to a cassette file
+type Recorder struct {
+	base http.RoundTripper
+	path string
+
+	mu       sync.Mutex
+	cassette Cassette
+}
+
+// NewRecorder records to the cassette at path, adding to the interactions
+// it already holds. Install it with Wrap.
+func NewRecorder(path string) (*Recorder, error) {
+	r := &Recorder{path: path}
+
+	data, err := os.ReadFile(path)
+	switch {
+	case err == nil:
+		if err := json.Unmarshal(data, &r.cassette); err != nil {
+			return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+		}
+	case !os.IsNotExist(err):
+		return nil, fmt.Errorf("failed to read cassette %s: %w", path, err)
+	}
+
+	return r, nil
+}
+
+// Wrap makes the recorder pass requests to base and returns it, for use
+// with Client.WrapTransport
+func (r *Recorder) Wrap(base http.RoundTripper) http.RoundTripper {
+	r.base = base
+	return r
+}
+
+// RoundTrip forwards the request and records the response
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := r.base.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	interaction := Interaction{
+		Method:      req.Method,
+		Host:        req.URL.Host,
+		Path:        req.URL.RequestURI(),
+		Status:      resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        string(body),
+		RecordedAt:  time.Now().UTC(),
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cassette.Interactions = append(r.cassette.Interactions, interaction)
+	if err := r.save(); err != nil {
+		fmt.Printf("Warning: Failed to save cassette %s: %v\n", r.path, err)
+	}
+
+	return resp, nil
+}
+
+// save writes the cassette atomically
+func (r *Recorder) save() error {
+	data, err := json.MarshalIndent(r.cassette, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp, err := os.CreateTemp(filepath.Dir(r.path), filepath.Base(r.path)+".*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), r.path)
+}
+
+// Replayer is an http.RoundTripper that answers from a cassette without
+// touching the network. Requests with several recordings, such as status
+// polls, get them in recorded order and then the last one again.
+type Replayer struct {
+	mu           sync.Mutex
+	interactions map[string][]Interaction
+	served       map[string]int
+}
+
+// LoadReplayer reads the cassette at path
+func LoadReplayer(path string) (*Replayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read cassette %s: %w", path, err)
+	}
+
+	var cassette Cassette
+	if err := json.Unmarshal(data, &cassette); err != nil {
+		return nil, fmt.Errorf("failed to parse cassette %s: %w", path, err)
+	}
+
+	r := &Replayer{
+		interactions: make(map[string][]Interaction),
+		served:       make(map[string]int),
+	}
+	for _, interaction := range cassette.Interactions {
+		key := interaction.key()
+		r.interactions[key] = append(r.interactions[key], interaction)
+	}
+	return r, nil
+}
+
+// RoundTrip answers the request with its next recorded response
+func (r *Replayer) RoundTrip(req *http.Request) (*http.Response, error) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+
+	key := requestKey(req)
+
+	r.mu.Lock()
+	recorded := r.interactions[key]
+	index := r.served[key]
+	if index < len(recorded) {
+		r.served[key] = index + 1
+	}
+	r.mu.Unlock()
+
+	if len(recorded) == 0 {
+		return nil, fmt.Errorf("offline mode: no recorded response for %s", strings.TrimSpace(key))
+	}
+	if index >= len(recorded) {
+		index = len(recorded) - 1
+	}
+	interaction := recorded[index]
+
+	header := make(http.Header)
+	if interaction.ContentType != "" {
+		header.Set("Content-Type", interaction.ContentType)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", interaction.Status, http.StatusText(interaction.Status)),
+		StatusCode:    interaction.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(strings.NewReader(interaction.Body)),
+		ContentLength: int64(len(interaction.Body)),
+		Request:       req,
+	}, nil
+}
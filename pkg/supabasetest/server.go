@@ -0,0 +1,636 @@
+// Package supabasetest provides a fake Supabase Management API for tests and
+// sandbox runs. It serves recorded responses for the create, get, API key,
+// auth config, organization, region list, status page, OpenAPI document,
+// restart, plan change and delete flows, so the manager can be exercised
+// end to end without real credentials. Recorder and Replayer capture real
+// Management API traffic and serve it back offline.
+package supabasetest
+
+import (
+	"crypto/rand"
+	"embed"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"supabase-manager/internal/supabase"
+)
+
+//go:embed fixtures/*.json
+var fixtures embed.FS
+
+// refAlphabet is the alphabet of Supabase project refs
+const refAlphabet = "abcdefghijklmnopqrstuvwxyz"
+
+// Server is a fake Management API backed by an httptest.Server
+type Server struct {
+	*httptest.Server
+
+	// ReadyAfter is the number of GET requests a new project answers with
+	// COMING_UP before it reports ACTIVE_HEALTHY
+	ReadyAfter int
+
+	// FullRegions turn down new projects as out of capacity, as Supabase
+	// does for popular regions under load
+	FullRegions []string
+
+	// OrganizationPlan is the plan the organization reports; empty means
+	// free
+	OrganizationPlan string
+
+	mu       sync.Mutex
+	projects map[string]*fakeProject
+	requests []string
+}
+
+// fakeProject is a project created on the fake server
+type fakeProject struct {
+	body       map[string]interface{}
+	polls      int
+	authConfig map[string]interface{}
+	// jwtSecret signs the project's keys; rotations counts its changes
+	jwtSecret string
+	rotations int
+	// logDrains are keyed by ID; drainSeq numbers them
+	logDrains map[string]map[string]interface{}
+	drainSeq  int
+	// secrets are Edge Function secrets by name
+	secrets map[string]string
+}
+
+// NewServer starts a fake Management API. Point a client at it with
+// ManagementURL or use Client.
+func NewServer() *Server {
+	s := &Server{projects: make(map[string]*fakeProject)}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /status/api/v2/incidents/unresolved.json", s.listIncidents)
+	mux.HandleFunc("GET /api/v1-json", s.getOpenAPI)
+	mux.HandleFunc("GET /v1/organizations", s.listOrganizations)
+	mux.HandleFunc("GET /v1/organizations/{slug}", s.getOrganization)
+	mux.HandleFunc("GET /v1/projects", s.listProjects)
+	mux.HandleFunc("GET /v1/projects/available-regions", s.listRegions)
+	mux.HandleFunc("POST /v1/projects", s.createProject)
+	mux.HandleFunc("GET /v1/projects/{ref}", s.getProject)
+	mux.HandleFunc("PATCH /v1/projects/{ref}", s.updateProject)
+	mux.HandleFunc("DELETE /v1/projects/{ref}", s.deleteProject)
+	mux.HandleFunc("POST /v1/projects/{ref}/restart", s.restartProject)
+	mux.HandleFunc("PATCH /v1/projects/{ref}/billing/addons", s.updateAddons)
+	mux.HandleFunc("GET /v1/projects/{ref}/api-keys", s.getAPIKeys)
+	mux.HandleFunc("GET /v1/projects/{ref}/config/auth", s.getAuthConfig)
+	mux.HandleFunc("PATCH /v1/projects/{ref}/config/auth", s.updateAuthConfig)
+	mux.HandleFunc("GET /v1/projects/{ref}/postgrest", s.getPostgrestConfig)
+	mux.HandleFunc("PATCH /v1/projects/{ref}/postgrest", s.updatePostgrestConfig)
+	mux.HandleFunc("GET /v1/projects/{ref}/config/database/pooler", s.getPoolerConfig)
+	mux.HandleFunc("GET /v1/projects/{ref}/analytics/log-drains", s.listLogDrains)
+	mux.HandleFunc("POST /v1/projects/{ref}/analytics/log-drains", s.createLogDrain)
+	mux.HandleFunc("DELETE /v1/projects/{ref}/analytics/log-drains/{id}", s.deleteLogDrain)
+	mux.HandleFunc("GET /v1/projects/{ref}/secrets", s.listSecrets)
+	mux.HandleFunc("POST /v1/projects/{ref}/secrets", s.createSecrets)
+	mux.HandleFunc("DELETE /v1/projects/{ref}/secrets", s.deleteSecrets)
+
+	s.Server = httptest.NewServer(s.authorize(mux))
+	return s
+}
+
+// ManagementURL returns the base URL to use in place of the Management API
+func (s *Server) ManagementURL() string {
+	return s.URL + "/v1"
+}
+
+// StatusPageURL returns the base URL to use in place of the status page
+func (s *Server) StatusPageURL() string {
+	return s.URL + "/status"
+}
+
+// Client returns a Supabase client talking to the fake server
+func (s *Server) Client() *supabase.Client {
+	client := supabase.NewClient("sandbox-token", "sandbox-org")
+	client.SetBaseURL(s.ManagementURL())
+	client.SetStatusPageURL(s.StatusPageURL())
+	return client
+}
+
+// Requests returns the requests served so far as "METHOD /path"
+func (s *Server) Requests() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.requests...)
+}
+
+// ProjectRefs returns the refs of the projects that currently exist
+func (s *Server) ProjectRefs() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	refs := make([]string, 0, len(s.projects))
+	for ref := range s.projects {
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// authorize records every request and rejects those without a bearer token
+func (s *Server) authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.requests = append(s.requests, r.Method+" "+r.URL.Path)
+		s.mu.Unlock()
+
+		// The status page is public
+		if strings.HasPrefix(r.URL.Path, "/status/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if token == "" || token == r.Header.Get("Authorization") {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"message": "Unauthorized"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (s *Server) listIncidents(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, loadFixture("incidents.json"))
+}
+
+func (s *Server) getOpenAPI(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, loadFixture("openapi.json"))
+}
+
+func (s *Server) listOrganizations(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, loadFixture("organizations.json"))
+}
+
+// getOrganization returns the sandbox organization with OrganizationPlan
+func (s *Server) getOrganization(w http.ResponseWriter, r *http.Request) {
+	orgs, _ := loadFixture("organizations.json").([]interface{})
+	for _, entry := range orgs {
+		org, ok := entry.(map[string]interface{})
+		if !ok || org["id"] != r.PathValue("slug") {
+			continue
+		}
+		org["plan"] = "free"
+		if s.OrganizationPlan != "" {
+			org["plan"] = s.OrganizationPlan
+		}
+		writeJSON(w, http.StatusOK, org)
+		return
+	}
+	writeJSON(w, http.StatusNotFound, map[string]string{"message": "Organization not found"})
+}
+
+// listRegions lists the built-in regions, with FullRegions reported as at
+// capacity
+func (s *Server) listRegions(w http.ResponseWriter, r *http.Request) {
+	specific := make([]map[string]string, 0, len(supabase.SupportedRegions))
+	for _, region := range supabase.BuiltinRegions() {
+		entry := map[string]string{"code": region.Code, "name": region.Name, "provider": region.Provider}
+		if slices.Contains(s.FullRegions, region.Code) {
+			entry["status"] = "capacity"
+		}
+		specific = append(specific, entry)
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"all": map[string]interface{}{"specific": specific},
+	})
+}
+
+func (s *Server) listProjects(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	projects := make([]map[string]interface{}, 0, len(s.projects))
+	for _, project := range s.projects {
+		projects = append(projects, project.body)
+	}
+	writeJSON(w, http.StatusOK, projects)
+}
+
+func (s *Server) createProject(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name           string `json:"name"`
+		Region         string `json:"region"`
+		OrganizationID string `json:"organization_id"`
+		PostgresEngine string `json:"postgres_engine"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "name is required"})
+		return
+	}
+
+	if slices.Contains(s.FullRegions, req.Region) {
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"message": fmt.Sprintf("Region %s is at capacity, please try another region", req.Region),
+		})
+		return
+	}
+
+	ref := newRef()
+	body := loadFixture("create_project.json").(map[string]interface{})
+	body["id"] = ref
+	body["ref"] = ref
+	body["name"] = req.Name
+	body["region"] = req.Region
+	body["organization_id"] = req.OrganizationID
+	body["created_at"] = time.Now().UTC().Format(time.RFC3339Nano)
+	body["endpoint"] = s.URL
+
+	database := loadFixture("get_project.json").(map[string]interface{})["database"].(map[string]interface{})
+	database["host"] = "db." + ref + ".supabase.co"
+	if req.PostgresEngine != "" {
+		database["postgres_engine"] = req.PostgresEngine
+	}
+	body["database"] = database
+
+	s.mu.Lock()
+	s.projects[ref] = &fakeProject{
+		body:       body,
+		authConfig: loadFixture("auth_config.json").(map[string]interface{}),
+		jwtSecret:  "super-secret-jwt-token-with-at-least-32-characters-long",
+	}
+	s.mu.Unlock()
+
+	writeJSON(w, http.StatusCreated, body)
+}
+
+func (s *Server) getProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+
+	project.polls++
+	if project.polls > s.ReadyAfter {
+		project.body["status"] = "ACTIVE_HEALTHY"
+	}
+	writeJSON(w, http.StatusOK, project.body)
+}
+
+func (s *Server) updateProject(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Name == "" {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "name is required"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ref := r.PathValue("ref")
+	project, ok := s.projects[ref]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	project.body["name"] = req.Name
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":   ref,
+		"ref":  ref,
+		"name": req.Name,
+	})
+}
+
+func (s *Server) deleteProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ref := r.PathValue("ref")
+	project, ok := s.projects[ref]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	delete(s.projects, ref)
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"id":   ref,
+		"ref":  ref,
+		"name": project.body["name"],
+	})
+}
+
+// restartProject takes a project down until it has been polled ReadyAfter
+// more times, like a new one
+func (s *Server) restartProject(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	project.body["status"] = "RESTARTING"
+	project.polls = 0
+
+	w.WriteHeader(http.StatusOK)
+}
+
+// updateAddons applies a compute add-on, moving the project to its instance
+// size
+func (s *Server) updateAddons(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		AddonType    string `json:"addon_type"`
+		AddonVariant string `json:"addon_variant"`
+	}
+	size, found := "", false
+	if err := json.NewDecoder(r.Body).Decode(&req); err == nil && req.AddonType == "compute_instance" {
+		size, found = strings.CutPrefix(req.AddonVariant, "ci_")
+	}
+	if !found || !slices.Contains(supabase.ComputeSizes, size) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "addon_variant must be ci_ followed by one of " + strings.Join(supabase.ComputeSizes, ", ")})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	project.body["desired_instance_size"] = size
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (s *Server) getAPIKeys(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+
+	// Keys are reissued when the JWT secret is rotated
+	keys := loadFixture("api_keys.json").([]interface{})
+	if project.rotations > 0 {
+		for _, key := range keys {
+			key := key.(map[string]interface{})
+			key["api_key"] = fmt.Sprintf("%s-%d", key["api_key"], project.rotations)
+		}
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (s *Server) getPostgrestConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jwt_secret": project.jwtSecret})
+}
+
+func (s *Server) updatePostgrestConfig(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		JWTSecret string `json:"jwt_secret"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid body"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	if req.JWTSecret != "" && req.JWTSecret != project.jwtSecret {
+		project.jwtSecret = req.JWTSecret
+		project.rotations++
+	}
+	writeJSON(w, http.StatusOK, map[string]interface{}{"jwt_secret": project.jwtSecret})
+}
+
+func (s *Server) getAuthConfig(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, project.authConfig)
+}
+
+func (s *Server) updateAuthConfig(w http.ResponseWriter, r *http.Request) {
+	var changes map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&changes); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid body"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	for key, value := range changes {
+		project.authConfig[key] = value
+	}
+	writeJSON(w, http.StatusOK, project.authConfig)
+}
+
+func (s *Server) getPoolerConfig(w http.ResponseWriter, r *http.Request) {
+	if !s.exists(r.PathValue("ref")) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	writeJSON(w, http.StatusOK, []map[string]interface{}{{
+		"database_type":     "PRIMARY",
+		"pool_mode":         "transaction",
+		"default_pool_size": 15,
+		"max_client_conn":   200,
+	}})
+}
+
+func (s *Server) listLogDrains(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	drains := []map[string]interface{}{}
+	for _, drain := range project.logDrains {
+		drains = append(drains, drain)
+	}
+	writeJSON(w, http.StatusOK, drains)
+}
+
+func (s *Server) createLogDrain(w http.ResponseWriter, r *http.Request) {
+	var drain map[string]interface{}
+	if err := json.NewDecoder(r.Body).Decode(&drain); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid body"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	if project.logDrains == nil {
+		project.logDrains = make(map[string]map[string]interface{})
+	}
+	project.drainSeq++
+	drain["id"] = fmt.Sprintf("drain-%d", project.drainSeq)
+	project.logDrains[drain["id"].(string)] = drain
+	writeJSON(w, http.StatusCreated, drain)
+}
+
+func (s *Server) deleteLogDrain(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	if _, ok := project.logDrains[r.PathValue("id")]; !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Log drain not found"})
+		return
+	}
+	delete(project.logDrains, r.PathValue("id"))
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *Server) listSecrets(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	secrets := []map[string]string{}
+	for name, value := range project.secrets {
+		secrets = append(secrets, map[string]string{"name": name, "value": value})
+	}
+	writeJSON(w, http.StatusOK, secrets)
+}
+
+func (s *Server) createSecrets(w http.ResponseWriter, r *http.Request) {
+	var secrets []struct {
+		Name  string `json:"name"`
+		Value string `json:"value"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&secrets); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid body"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	if project.secrets == nil {
+		project.secrets = make(map[string]string)
+	}
+	for _, secret := range secrets {
+		project.secrets[secret.Name] = secret.Value
+	}
+	w.WriteHeader(http.StatusCreated)
+}
+
+func (s *Server) deleteSecrets(w http.ResponseWriter, r *http.Request) {
+	var names []string
+	if err := json.NewDecoder(r.Body).Decode(&names); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"message": "invalid body"})
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	project, ok := s.projects[r.PathValue("ref")]
+	if !ok {
+		writeJSON(w, http.StatusNotFound, map[string]string{"message": "Project not found"})
+		return
+	}
+	for _, name := range names {
+		delete(project.secrets, name)
+	}
+	writeJSON(w, http.StatusOK, map[string]string{"message": "Secrets deleted"})
+}
+
+// exists reports whether a project exists
+func (s *Server) exists(ref string) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, ok := s.projects[ref]
+	return ok
+}
+
+// loadFixture decodes an embedded fixture into a fresh value
+func loadFixture(name string) interface{} {
+	data, err := fixtures.ReadFile("fixtures/" + name)
+	if err != nil {
+		panic(fmt.Sprintf("supabasetest: missing fixture %s", name))
+	}
+
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		panic(fmt.Sprintf("supabasetest: invalid fixture %s: %v", name, err))
+	}
+	return value
+}
+
+// newRef generates a random 20 character project ref
+func newRef() string {
+	buf := make([]byte, 20)
+	rand.Read(buf)
+	for i := range buf {
+		buf[i] = refAlphabet[int(buf[i])%len(refAlphabet)]
+	}
+	return string(buf)
+}
+
+// writeJSON writes a JSON response
+func writeJSON(w http.ResponseWriter, status int, value interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(value)
+}